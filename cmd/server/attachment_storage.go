@@ -0,0 +1,14 @@
+package main
+
+import (
+	"gorm.io/gorm"
+
+	"github.com/MarkoPoloResearchLab/loopaware/internal/blobstore"
+)
+
+func newAttachmentStore(serverConfig ServerConfig, database *gorm.DB) (blobstore.Store, error) {
+	if serverConfig.AttachmentStorage == blobstore.KindFilesystem {
+		return blobstore.NewFilesystemStore(serverConfig.AttachmentDirectory)
+	}
+	return blobstore.NewDatabaseStore(database), nil
+}
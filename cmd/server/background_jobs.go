@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/MarkoPoloResearchLab/loopaware/internal/api"
+	"github.com/MarkoPoloResearchLab/loopaware/internal/task"
+)
+
+const (
+	jobNameFaviconRefresh        = "favicon_refresh"
+	jobNameVisitRollup           = "visit_rollup"
+	jobNameCampaignDispatch      = "campaign_dispatch"
+	jobNameSubscriberImports     = "subscriber_imports"
+	jobNamePendingSubscriberScan = "pending_subscriber_sweep"
+	jobNameDigestEmails          = "digest_emails"
+	jobNameTrafficAlerts         = "traffic_alerts"
+)
+
+// backgroundJobs holds the components whose periodic work runs through the job registry.
+type backgroundJobs struct {
+	faviconManager           *api.SiteFaviconManager
+	visitRollup              *task.VisitRollupJob
+	campaignDispatcher       *api.CampaignDispatcher
+	subscriberImportRunner   *api.SubscriberImportRunner
+	pendingSubscriberSweeper *api.PendingSubscriberSweeper
+	digestReporter           *api.DigestReporter
+	trafficAlertMonitor      *api.TrafficAlertMonitor
+}
+
+type backgroundJobDefinition struct {
+	name        string
+	description string
+	schedule    string
+	jitter      time.Duration
+	timeout     time.Duration
+	run         task.JobFunc
+}
+
+// registerBackgroundJobs registers every periodic job. Cron expressions are evaluated in UTC.
+func registerBackgroundJobs(registry *task.Registry, jobs backgroundJobs) error {
+	definitions := []backgroundJobDefinition{
+		{
+			name:        jobNameFaviconRefresh,
+			description: "Queue favicon and page metadata refreshes for sites whose favicon is missing or stale",
+			schedule:    "@every 1h",
+			jitter:      5 * time.Minute,
+			timeout:     10 * time.Minute,
+			run:         jobs.faviconManager.RefreshAll,
+		},
+		{
+			name:        jobNameVisitRollup,
+			description: "Aggregate yesterday's visits into daily rollups",
+			schedule:    "15 0 * * *",
+			jitter:      5 * time.Minute,
+			timeout:     30 * time.Minute,
+			run:         jobs.visitRollup.Run,
+		},
+		{
+			name:        jobNameCampaignDispatch,
+			description: "Send scheduled newsletter campaigns and resume interrupted sends",
+			schedule:    "@every 1m",
+			timeout:     2 * time.Hour,
+			run:         jobs.campaignDispatcher.DispatchDue,
+		},
+		{
+			name:        jobNameSubscriberImports,
+			description: "Run queued subscriber CSV imports and resume interrupted ones",
+			schedule:    "@every 1m",
+			timeout:     2 * time.Hour,
+			run:         jobs.subscriberImportRunner.RunPending,
+		},
+		{
+			name:        jobNamePendingSubscriberScan,
+			description: "Remind pending subscribers and expire stale ones",
+			schedule:    "@every 1h",
+			jitter:      5 * time.Minute,
+			timeout:     30 * time.Minute,
+			run: func(ctx context.Context) error {
+				_, sweepErr := jobs.pendingSubscriberSweeper.Sweep(ctx)
+				return sweepErr
+			},
+		},
+		{
+			name:        jobNameDigestEmails,
+			description: "Email daily and weekly digests to opted-in site owners",
+			schedule:    "5 * * * *",
+			timeout:     30 * time.Minute,
+			run: func(ctx context.Context) error {
+				_, digestErr := jobs.digestReporter.SendDueDigests(ctx)
+				return digestErr
+			},
+		},
+		{
+			name:        jobNameTrafficAlerts,
+			description: "Evaluate traffic alert rules",
+			schedule:    "*/15 * * * *",
+			timeout:     10 * time.Minute,
+			run: func(ctx context.Context) error {
+				_, evaluateErr := jobs.trafficAlertMonitor.EvaluateRules(ctx)
+				return evaluateErr
+			},
+		},
+	}
+
+	for _, definition := range definitions {
+		schedule, scheduleErr := task.ParseSchedule(definition.schedule)
+		if scheduleErr != nil {
+			return fmt.Errorf("job %s: %w", definition.name, scheduleErr)
+		}
+		registerErr := registry.Register(task.Job{
+			Name:        definition.name,
+			Description: definition.description,
+			Schedule:    schedule,
+			Jitter:      definition.jitter,
+			Timeout:     definition.timeout,
+			Run:         definition.run,
+		})
+		if registerErr != nil {
+			return registerErr
+		}
+	}
+	return nil
+}
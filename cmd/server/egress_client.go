@@ -0,0 +1,46 @@
+package main
+
+import (
+	"net/http"
+
+	"go.uber.org/zap"
+
+	"github.com/MarkoPoloResearchLab/loopaware/pkg/egress"
+)
+
+// newEgressHTTPClient builds the client used for every fetch of a user-supplied URL: favicons, avatars and
+// traffic alert webhooks. Hosts without an EGRESS_HOST_TIMEOUTS entry use the egress package's default timeout.
+func newEgressHTTPClient(serverConfig ServerConfig, logger *zap.Logger) (*http.Client, error) {
+	allowedNetworks, allowedErr := egress.ParseNetworks(serverConfig.EgressAllowedNetworks)
+	if allowedErr != nil {
+		return nil, allowedErr
+	}
+	deniedNetworks, deniedErr := egress.ParseNetworks(serverConfig.EgressDeniedNetworks)
+	if deniedErr != nil {
+		return nil, deniedErr
+	}
+	hostTimeouts, hostTimeoutsErr := egress.ParseHostTimeouts(serverConfig.EgressHostTimeouts)
+	if hostTimeoutsErr != nil {
+		return nil, hostTimeoutsErr
+	}
+	return egress.NewClient(
+		egress.WithLogger(logger),
+		egress.WithAllowedNetworks(allowedNetworks...),
+		egress.WithDeniedNetworks(deniedNetworks...),
+		egress.WithHostTimeouts(hostTimeouts),
+	), nil
+}
+
+func invalidEgressParameters(configuration ServerConfig) []string {
+	var invalidParameters []string
+	if _, parseErr := egress.ParseNetworks(configuration.EgressAllowedNetworks); parseErr != nil {
+		invalidParameters = append(invalidParameters, flagNameEgressAllowedNetworks)
+	}
+	if _, parseErr := egress.ParseNetworks(configuration.EgressDeniedNetworks); parseErr != nil {
+		invalidParameters = append(invalidParameters, flagNameEgressDeniedNetworks)
+	}
+	if _, parseErr := egress.ParseHostTimeouts(configuration.EgressHostTimeouts); parseErr != nil {
+		invalidParameters = append(invalidParameters, flagNameEgressHostTimeouts)
+	}
+	return invalidParameters
+}
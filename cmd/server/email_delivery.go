@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/MarkoPoloResearchLab/loopaware/internal/api"
+	"github.com/MarkoPoloResearchLab/loopaware/internal/emailtemplate"
+	"github.com/MarkoPoloResearchLab/loopaware/internal/mailer"
+	"github.com/MarkoPoloResearchLab/loopaware/internal/notifications"
+)
+
+type emailDelivery struct {
+	feedbackNotifier     api.FeedbackNotifier
+	subscriptionNotifier api.SubscriptionNotifier
+	trafficAlertNotifier api.TrafficAlertNotifier
+	emailSender          api.EmailSender
+	// connectionCheck reports whether the notification backend is reachable; nil when there is nothing to probe.
+	connectionCheck func(ctx context.Context) error
+	closer          func() error
+}
+
+// Close releases resources held by the configured email backend.
+func (delivery emailDelivery) Close() error {
+	if delivery.closer == nil {
+		return nil
+	}
+	return delivery.closer()
+}
+
+func (application *ServerApplication) newEmailDelivery(logger *zap.Logger, serverConfig ServerConfig, emailTemplates *emailtemplate.Renderer) (emailDelivery, error) {
+	if serverConfig.EmailBackend == emailBackendSMTP {
+		smtpSender, smtpErr := mailer.NewSMTPSender(logger, mailer.SMTPConfig{
+			Host:     serverConfig.SMTPHost,
+			Port:     serverConfig.SMTPPort,
+			Username: serverConfig.SMTPUsername,
+			Password: serverConfig.SMTPPassword,
+			From:     serverConfig.SMTPFrom,
+			Security: serverConfig.SMTPSecurity,
+		})
+		if smtpErr != nil {
+			return emailDelivery{}, smtpErr
+		}
+		emailNotifier := notifications.NewEmailNotifier(logger, smtpSender, emailTemplates)
+		return emailDelivery{
+			feedbackNotifier:     emailNotifier,
+			subscriptionNotifier: emailNotifier,
+			trafficAlertNotifier: emailNotifier,
+			emailSender:          smtpSender,
+		}, nil
+	}
+
+	pinguinNotifier, notifierErr := notifications.NewPinguinNotifier(logger, notifications.PinguinConfig{
+		Address:           serverConfig.PinguinAddress,
+		AuthToken:         serverConfig.PinguinAuthToken,
+		TenantID:          serverConfig.PinguinTenantID,
+		ConnectionTimeout: time.Duration(serverConfig.PinguinConnTimeoutSec) * time.Second,
+		OperationTimeout:  time.Duration(serverConfig.PinguinOpTimeoutSec) * time.Second,
+		Dialer:            application.pinguinDialer,
+		Templates:         emailTemplates,
+	})
+	if notifierErr != nil {
+		return emailDelivery{}, notifierErr
+	}
+	return emailDelivery{
+		feedbackNotifier:     pinguinNotifier,
+		subscriptionNotifier: pinguinNotifier,
+		trafficAlertNotifier: pinguinNotifier,
+		emailSender:          pinguinNotifier,
+		connectionCheck: func(context.Context) error {
+			return pinguinNotifier.CheckConnection()
+		},
+		closer: pinguinNotifier.Close,
+	}, nil
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/MarkoPoloResearchLab/loopaware/internal/tlscert"
+)
+
+// runHTTPServer serves plain HTTP, or HTTPS with HTTP/2 when the server carries a TLS configuration.
+func runHTTPServer(server *http.Server) error {
+	if server.TLSConfig != nil {
+		return server.ListenAndServeTLS("", "")
+	}
+	return server.ListenAndServe()
+}
+
+// newHTTPServer applies the configured timeouts to one listener. Event stream routes clear their own deadlines.
+func newHTTPServer(address string, handler http.Handler, serverConfig ServerConfig, tlsConfig *tls.Config) *http.Server {
+	server := &http.Server{
+		Addr:              address,
+		Handler:           handler,
+		ReadHeaderTimeout: readHeaderTimeoutSeconds * time.Second,
+		ReadTimeout:       time.Duration(serverConfig.HTTPReadTimeoutSeconds) * time.Second,
+		WriteTimeout:      time.Duration(serverConfig.HTTPWriteTimeoutSeconds) * time.Second,
+		IdleTimeout:       time.Duration(serverConfig.HTTPIdleTimeoutSeconds) * time.Second,
+		TLSConfig:         tlsConfig,
+	}
+	if tlsConfig != nil {
+		server.Protocols = new(http.Protocols)
+		server.Protocols.SetHTTP1(true)
+		server.Protocols.SetHTTP2(true)
+	}
+	return server
+}
+
+// newServerTLSConfig loads TLS_CERT_FILE and TLS_KEY_FILE and keeps them fresh until ctx is done. It returns a
+// nil configuration when TLS is not configured.
+func newServerTLSConfig(ctx context.Context, serverConfig ServerConfig, logger *zap.Logger) (*tls.Config, error) {
+	if serverConfig.TLSCertFile == "" && serverConfig.TLSKeyFile == "" {
+		return nil, nil
+	}
+	reloader, reloaderErr := tlscert.NewReloader(serverConfig.TLSCertFile, serverConfig.TLSKeyFile, logger)
+	if reloaderErr != nil {
+		return nil, reloaderErr
+	}
+	go reloader.Run(ctx, tlscert.DefaultCheckInterval)
+	return reloader.TLSConfig(), nil
+}
+
+// shutdownHTTPServers drains every listener concurrently within ctx and closes the ones that do not finish.
+func shutdownHTTPServers(ctx context.Context, servers []*http.Server, logger *zap.Logger) {
+	var waitGroup sync.WaitGroup
+	for _, server := range servers {
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+			if shutdownErr := server.Shutdown(ctx); shutdownErr != nil {
+				logger.Warn("shutdown_http_incomplete", zap.String(logFieldAddress, server.Addr), zap.Error(shutdownErr))
+				_ = server.Close()
+			}
+		}()
+	}
+	waitGroup.Wait()
+}
+
+func invalidListenerParameters(configuration ServerConfig) []string {
+	var invalidParameters []string
+	if (configuration.TLSCertFile == "") != (configuration.TLSKeyFile == "") {
+		invalidParameters = append(invalidParameters, flagNameTLSCertFile, flagNameTLSKeyFile)
+	}
+	if configuration.PublicAddress != "" && configuration.PublicAddress == configuration.ApplicationAddress {
+		invalidParameters = append(invalidParameters, flagNamePublicAddress)
+	}
+	if configuration.HTTPReadTimeoutSeconds < 0 {
+		invalidParameters = append(invalidParameters, flagNameHTTPReadTimeout)
+	}
+	if configuration.HTTPWriteTimeoutSeconds < 0 {
+		invalidParameters = append(invalidParameters, flagNameHTTPWriteTimeout)
+	}
+	if configuration.HTTPIdleTimeoutSeconds < 0 {
+		invalidParameters = append(invalidParameters, flagNameHTTPIdleTimeout)
+	}
+	return invalidParameters
+}
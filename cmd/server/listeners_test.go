@@ -0,0 +1,258 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+	"github.com/tyemirov/tauth/pkg/sessionvalidator"
+	"gorm.io/gorm"
+
+	"github.com/MarkoPoloResearchLab/loopaware/internal/model"
+	"github.com/MarkoPoloResearchLab/loopaware/internal/storage"
+)
+
+const (
+	testApplicationAddress = "127.0.0.1:18080"
+	testPublicAddress      = "127.0.0.1:18081"
+	testExportOwnerEmail   = "owner@example.com"
+	testExportSiteID       = "export-deadline-site"
+)
+
+func writeTestCertificate(testingT *testing.T) (string, string) {
+	testingT.Helper()
+	privateKey, keyErr := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(testingT, keyErr)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	certificateDER, certificateErr := x509.CreateCertificate(rand.Reader, template, template, &privateKey.PublicKey, privateKey)
+	require.NoError(testingT, certificateErr)
+	keyDER, marshalErr := x509.MarshalECPrivateKey(privateKey)
+	require.NoError(testingT, marshalErr)
+
+	directory := testingT.TempDir()
+	certificateFile := filepath.Join(directory, "tls.crt")
+	keyFile := filepath.Join(directory, "tls.key")
+	require.NoError(testingT, os.WriteFile(certificateFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificateDER}), 0o600))
+	require.NoError(testingT, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	return certificateFile, keyFile
+}
+
+func TestEnsureRequiredConfigurationValidatesListeners(testingT *testing.T) {
+	application := NewServerApplication()
+	config := ServerConfig{
+		ApplicationAddress:      testApplicationAddress,
+		DatabaseDriverName:      storage.DriverNameSQLite,
+		DatabaseDataSourceName:  testDatabaseDSNValue,
+		SessionSecret:           testSessionSecretValue,
+		TauthBaseURL:            testTauthBaseURLValue,
+		TauthTenantID:           testTauthTenantIDValue,
+		TauthSigningKey:         testTauthSigningKeyValue,
+		PublicBaseURL:           testPublicBaseURLValue,
+		EmailBackend:            emailBackendSMTP,
+		SMTPHost:                "smtp.example.com",
+		SMTPPort:                defaultSMTPPort,
+		SMTPFrom:                "noreply@example.com",
+		PublicAddress:           testPublicAddress,
+		TLSCertFile:             "tls.crt",
+		TLSKeyFile:              "tls.key",
+		HTTPReadTimeoutSeconds:  defaultHTTPReadTimeoutSeconds,
+		HTTPWriteTimeoutSeconds: 0,
+		HTTPIdleTimeoutSeconds:  defaultHTTPIdleTimeoutSeconds,
+	}
+	require.NoError(testingT, application.ensureRequiredConfiguration(config))
+
+	config.TLSKeyFile = ""
+	config.PublicAddress = testApplicationAddress
+	config.HTTPWriteTimeoutSeconds = -1
+	validationErr := application.ensureRequiredConfiguration(config)
+	require.ErrorContains(testingT, validationErr, flagNameTLSKeyFile)
+	require.ErrorContains(testingT, validationErr, flagNamePublicAddress)
+	require.ErrorContains(testingT, validationErr, flagNameHTTPWriteTimeout)
+}
+
+func TestRunCommandSplitsPublicAndAPIListenersOverTLS(testingT *testing.T) {
+	listener := startPinguinServer(testingT)
+	setRequiredEnvironment(testingT, testPinguinAddress)
+	certificateFile, keyFile := writeTestCertificate(testingT)
+	testingT.Setenv(environmentKeyApplicationAddress, testApplicationAddress)
+	testingT.Setenv(environmentKeyPublicAddress, testPublicAddress)
+	testingT.Setenv(environmentKeyTLSCertFile, certificateFile)
+	testingT.Setenv(environmentKeyTLSKeyFile, keyFile)
+	testingT.Setenv(environmentKeyHTTPWriteTimeout, "45")
+
+	application := NewServerApplication()
+	application.WithPinguinDialer(createPinguinDialer(listener))
+	command, commandErr := application.Command()
+	require.NoError(testingT, commandErr)
+
+	var mutex sync.Mutex
+	servers := make(map[string]*http.Server)
+	var started sync.WaitGroup
+	started.Add(2)
+	application.WithServerRunner(func(server *http.Server) error {
+		mutex.Lock()
+		servers[server.Addr] = server
+		mutex.Unlock()
+		started.Done()
+		started.Wait()
+		return http.ErrServerClosed
+	})
+
+	require.NoError(testingT, application.runCommand(command, nil))
+	require.Len(testingT, servers, 2)
+	apiServer := servers[testApplicationAddress]
+	publicServer := servers[testPublicAddress]
+	require.NotNil(testingT, apiServer)
+	require.NotNil(testingT, publicServer)
+
+	for _, server := range []*http.Server{apiServer, publicServer} {
+		require.NotNil(testingT, server.TLSConfig)
+		require.Contains(testingT, server.TLSConfig.NextProtos, "h2")
+		require.True(testingT, server.Protocols.HTTP2())
+		require.Equal(testingT, 45*time.Second, server.WriteTimeout)
+		require.Equal(testingT, defaultHTTPReadTimeoutSeconds*time.Second, server.ReadTimeout)
+		require.Equal(testingT, defaultHTTPIdleTimeoutSeconds*time.Second, server.IdleTimeout)
+	}
+
+	serve := func(server *http.Server, method string, path string) int {
+		recorder := httptest.NewRecorder()
+		server.Handler.ServeHTTP(recorder, httptest.NewRequest(method, path, nil))
+		return recorder.Code
+	}
+	require.Equal(testingT, http.StatusOK, serve(apiServer, http.MethodGet, operationalRouteHealthz))
+	require.Equal(testingT, http.StatusNotFound, serve(publicServer, http.MethodGet, operationalRouteHealthz))
+	require.Equal(testingT, http.StatusNotFound, serve(apiServer, http.MethodPost, publicRouteFeedback))
+	require.NotEqual(testingT, http.StatusNotFound, serve(publicServer, http.MethodPost, publicRouteFeedback))
+	require.Equal(testingT, http.StatusNotFound, serve(publicServer, http.MethodGet, apiRoutePrefix+apiRouteMe))
+	require.Equal(testingT, http.StatusUnauthorized, serve(apiServer, http.MethodGet, apiRoutePrefix+apiRouteMe))
+}
+
+func TestNewHTTPServerServesPlainHTTPWithoutTLS(testingT *testing.T) {
+	server := newHTTPServer(testApplicationAddress, http.NotFoundHandler(), ServerConfig{HTTPReadTimeoutSeconds: 10}, nil)
+	require.Nil(testingT, server.TLSConfig)
+	require.Nil(testingT, server.Protocols)
+	require.Equal(testingT, 10*time.Second, server.ReadTimeout)
+	require.Zero(testingT, server.WriteTimeout)
+	require.Equal(testingT, readHeaderTimeoutSeconds*time.Second, server.ReadHeaderTimeout)
+}
+
+func signTestSessionCookie(testingT *testing.T, email string) *http.Cookie {
+	testingT.Helper()
+	now := time.Now().UTC()
+	claims := &sessionvalidator.Claims{
+		TenantID:  testTauthTenantIDValue,
+		UserID:    "export-owner",
+		UserEmail: email,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    "tauth",
+			Subject:   "export-owner",
+			IssuedAt:  jwt.NewNumericDate(now.Add(-time.Minute)),
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+		},
+	}
+	signedToken, signErr := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(testTauthSigningKeyValue))
+	require.NoError(testingT, signErr)
+	return &http.Cookie{Name: testTauthCookieNameValue, Value: signedToken}
+}
+
+func TestRunCommandStreamsExportsPastWriteTimeout(testingT *testing.T) {
+	listener := startPinguinServer(testingT)
+	setRequiredEnvironment(testingT, testPinguinAddress)
+	testingT.Setenv(environmentKeyHTTPWriteTimeout, "1")
+
+	httpListener, listenErr := net.Listen("tcp", "127.0.0.1:0")
+	if listenErr != nil {
+		testingT.Skip("server listen not permitted in sandbox")
+	}
+
+	shutdownContext, triggerShutdown := context.WithCancel(context.Background())
+	defer triggerShutdown()
+	application := NewServerApplication()
+	application.WithPinguinDialer(createPinguinDialer(listener))
+	application.WithShutdownSignal(func(parent context.Context) (context.Context, context.CancelFunc) {
+		return shutdownContext, func() {}
+	})
+	application.WithDatabaseOpener(func(config storage.Config) (*gorm.DB, error) {
+		database, openErr := storage.OpenDatabase(config)
+		if openErr != nil {
+			return nil, openErr
+		}
+		slowExport := func(statement *gorm.DB) {
+			if statement.Statement.Table == "feedbacks" {
+				time.Sleep(1500 * time.Millisecond)
+			}
+		}
+		if registerErr := database.Callback().Row().Before("gorm:row").Register("test:slow_export", slowExport); registerErr != nil {
+			return nil, registerErr
+		}
+		return database, nil
+	})
+	command, commandErr := application.Command()
+	require.NoError(testingT, commandErr)
+
+	type exportResult struct {
+		status int
+		body   string
+		err    error
+	}
+	results := make(chan exportResult, 1)
+	application.WithServerRunner(func(server *http.Server) error {
+		go func() {
+			defer triggerShutdown()
+			database, openErr := storage.OpenDatabase(storage.Config{DriverName: testDatabaseDriverValue, DataSourceName: testDatabaseDSNValue})
+			if openErr != nil {
+				results <- exportResult{err: openErr}
+				return
+			}
+			site := model.Site{ID: testExportSiteID, Name: "Export", AllowedOrigin: "https://export.example", OwnerEmail: testExportOwnerEmail}
+			feedback := model.Feedback{ID: "export-deadline-feedback", SiteID: site.ID, Contact: "reader@example.com", Message: "still streaming"}
+			if createErr := database.Create(&site).Error; createErr != nil {
+				results <- exportResult{err: createErr}
+				return
+			}
+			if createErr := database.Create(&feedback).Error; createErr != nil {
+				results <- exportResult{err: createErr}
+				return
+			}
+			request, _ := http.NewRequest(http.MethodGet, "http://"+httpListener.Addr().String()+apiRoutePrefix+"/sites/"+site.ID+"/messages/export", nil)
+			request.AddCookie(signTestSessionCookie(testingT, testExportOwnerEmail))
+			response, requestErr := http.DefaultClient.Do(request)
+			if requestErr != nil {
+				results <- exportResult{err: requestErr}
+				return
+			}
+			defer response.Body.Close()
+			body, readErr := io.ReadAll(response.Body)
+			results <- exportResult{status: response.StatusCode, body: string(body), err: readErr}
+		}()
+		return server.Serve(httpListener)
+	})
+
+	require.NoError(testingT, application.runCommand(command, nil))
+	result := <-results
+	require.NoError(testingT, result.err)
+	require.Equal(testingT, http.StatusOK, result.status, result.body)
+	require.Contains(testingT, result.body, "still streaming")
+}
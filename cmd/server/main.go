@@ -15,12 +15,19 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 
 	"github.com/MarkoPoloResearchLab/loopaware/internal/api"
-	"github.com/MarkoPoloResearchLab/loopaware/internal/notifications"
+	"github.com/MarkoPoloResearchLab/loopaware/internal/blobstore"
+	"github.com/MarkoPoloResearchLab/loopaware/internal/emailtemplate"
+	"github.com/MarkoPoloResearchLab/loopaware/internal/logging"
+	"github.com/MarkoPoloResearchLab/loopaware/internal/mailer"
+	"github.com/MarkoPoloResearchLab/loopaware/internal/metrics"
 	"github.com/MarkoPoloResearchLab/loopaware/internal/storage"
+	"github.com/MarkoPoloResearchLab/loopaware/internal/task"
+	"github.com/MarkoPoloResearchLab/loopaware/internal/tracing"
 	"github.com/MarkoPoloResearchLab/loopaware/pkg/favicon"
 )
 
@@ -31,6 +38,8 @@ const (
 	missingConfigurationMessage       = "missing required configuration"
 	loggerCreationErrorMessage        = "logger"
 	logEventListening                 = "listening"
+	logEventShutdownStarted           = "shutdown_started"
+	logEventShutdownComplete          = "shutdown_complete"
 	logFieldAddress                   = "addr"
 	flagNameConfigFile                = "config"
 	flagNameApplicationAddress        = "app-addr"
@@ -48,6 +57,38 @@ const (
 	flagNamePinguinTenantID           = "pinguin-tenant-id"
 	flagNamePinguinConnectionTimeout  = "pinguin-conn-timeout"
 	flagNamePinguinOperationTimeout   = "pinguin-op-timeout"
+	flagNameCampaignSendInterval      = "campaign-send-interval-ms"
+	flagNameEmailBackend              = "email-backend"
+	flagNameSMTPHost                  = "smtp-host"
+	flagNameSMTPPort                  = "smtp-port"
+	flagNameSMTPUsername              = "smtp-username"
+	flagNameSMTPPassword              = "smtp-password"
+	flagNameSMTPFrom                  = "smtp-from"
+	flagNameSMTPSecurity              = "smtp-security"
+	flagNamePendingReminderHours      = "pending-reminder-hours"
+	flagNamePendingExpiryDays         = "pending-expiry-days"
+	flagNamePendingExpiryAction       = "pending-expiry-action"
+	flagNameAttachmentStorage         = "attachment-storage"
+	flagNameAttachmentDirectory       = "attachment-dir"
+	flagNameAttachmentMaxBytes        = "attachment-max-bytes"
+	flagNameLiveVisitorWindow         = "live-visitor-window-minutes"
+	flagNameShutdownTimeout           = "shutdown-timeout-seconds"
+	flagNameTracingExporter           = "tracing-exporter"
+	flagNameTracingEndpoint           = "tracing-otlp-endpoint"
+	flagNameTracingInsecure           = "tracing-otlp-insecure"
+	flagNameTracingServiceName        = "tracing-service-name"
+	flagNameLogLevel                  = "log-level"
+	flagNameLogFormat                 = "log-format"
+	flagNameLogRedaction              = "log-redaction"
+	flagNamePublicAddress             = "public-addr"
+	flagNameTLSCertFile               = "tls-cert-file"
+	flagNameTLSKeyFile                = "tls-key-file"
+	flagNameHTTPReadTimeout           = "http-read-timeout-seconds"
+	flagNameHTTPWriteTimeout          = "http-write-timeout-seconds"
+	flagNameHTTPIdleTimeout           = "http-idle-timeout-seconds"
+	flagNameEgressAllowedNetworks     = "egress-allowed-networks"
+	flagNameEgressDeniedNetworks      = "egress-denied-networks"
+	flagNameEgressHostTimeouts        = "egress-host-timeouts"
 	flagUsageConfigFile               = "path to configuration file"
 	flagUsageApplicationAddress       = "address for the HTTP server to listen on"
 	flagUsageDatabaseDriver           = "database driver (e.g. sqlite)"
@@ -64,6 +105,38 @@ const (
 	flagUsagePinguinConnTimeout       = "Pinguin connection timeout in seconds"
 	flagUsagePinguinOpTimeout         = "Pinguin operation timeout in seconds"
 	flagUsageSubscriptionNotify       = "enable notifications for new subscriptions"
+	flagUsageCampaignSendInterval     = "pause between campaign emails in milliseconds"
+	flagUsageEmailBackend             = "email delivery backend (pinguin or smtp)"
+	flagUsageSMTPHost                 = "SMTP relay host"
+	flagUsageSMTPPort                 = "SMTP relay port"
+	flagUsageSMTPUsername             = "SMTP username (enables AUTH PLAIN)"
+	flagUsageSMTPPassword             = "SMTP password"
+	flagUsageSMTPFrom                 = "sender address for outgoing email"
+	flagUsageSMTPSecurity             = "SMTP transport security (starttls, tls, or none)"
+	flagUsagePendingReminderHours     = "hours after the confirmation email to send one reminder (0 disables)"
+	flagUsagePendingExpiryDays        = "days a subscriber may stay pending before expiring (0 disables)"
+	flagUsagePendingExpiryAction      = "what to do with expired pending subscribers (archive or delete)"
+	flagUsageAttachmentStorage        = "feedback attachment storage (database or filesystem)"
+	flagUsageAttachmentDirectory      = "directory for feedback attachments when storage is filesystem"
+	flagUsageAttachmentMaxBytes       = "maximum size of one feedback attachment in bytes"
+	flagUsageLiveVisitorWindow        = "minutes a visitor stays in the live visitor count after their last page view"
+	flagUsageShutdownTimeout          = "seconds to drain requests and notification work after SIGTERM or SIGINT"
+	flagUsageTracingExporter          = "OpenTelemetry span exporter (none, stdout, otlp-grpc, or otlp-http)"
+	flagUsageTracingEndpoint          = "OTLP collector host:port; the OTEL_EXPORTER_OTLP_* variables apply when empty"
+	flagUsageTracingInsecure          = "send OTLP spans without TLS"
+	flagUsageTracingServiceName       = "service.name reported on every span"
+	flagUsageLogLevel                 = "minimum log level (debug, info, warn, or error)"
+	flagUsageLogFormat                = "log encoding (json or console)"
+	flagUsageLogRedaction             = "log redaction policy (hash replaces emails, contact details, and IPs with keyed hashes; none)"
+	flagUsagePublicAddress            = "separate address for the public collection routes; empty serves them on app-addr"
+	flagUsageTLSCertFile              = "PEM certificate file; with tls-key-file, serves HTTPS and HTTP/2 and reloads the pair when it changes"
+	flagUsageTLSKeyFile               = "PEM private key file for tls-cert-file"
+	flagUsageHTTPReadTimeout          = "seconds to read a whole request, body included (0 disables)"
+	flagUsageHTTPWriteTimeout         = "seconds to write a response; exports and attachment downloads get this long per chunk, event streams are exempt (0 disables)"
+	flagUsageHTTPIdleTimeout          = "seconds to keep an idle keep-alive connection open (0 disables)"
+	flagUsageEgressAllowedNetworks    = "CIDR ranges outbound fetches may reach even though they are private or reserved"
+	flagUsageEgressDeniedNetworks     = "extra CIDR ranges outbound fetches must never reach"
+	flagUsageEgressHostTimeouts       = "per-host outbound fetch timeouts as host=duration pairs"
 	environmentKeyApplicationAddress  = "APP_ADDR"
 	environmentKeyDatabaseDriverName  = "DB_DRIVER"
 	environmentKeyDatabaseDataSource  = "DB_DSN"
@@ -81,6 +154,38 @@ const (
 	environmentKeyPinguinConnTimeout  = "PINGUIN_CONNECTION_TIMEOUT_SEC"
 	environmentKeyPinguinOpTimeout    = "PINGUIN_OPERATION_TIMEOUT_SEC"
 	environmentKeySubscriptionNotify  = "SUBSCRIPTION_NOTIFICATIONS"
+	environmentKeyCampaignInterval    = "CAMPAIGN_SEND_INTERVAL_MS"
+	environmentKeyEmailBackend        = "EMAIL_BACKEND"
+	environmentKeySMTPHost            = "SMTP_HOST"
+	environmentKeySMTPPort            = "SMTP_PORT"
+	environmentKeySMTPUsername        = "SMTP_USERNAME"
+	environmentKeySMTPPassword        = "SMTP_PASSWORD"
+	environmentKeySMTPFrom            = "SMTP_FROM"
+	environmentKeySMTPSecurity        = "SMTP_SECURITY"
+	environmentKeyPendingReminder     = "PENDING_SUBSCRIBER_REMINDER_HOURS"
+	environmentKeyPendingExpiryDays   = "PENDING_SUBSCRIBER_EXPIRY_DAYS"
+	environmentKeyPendingExpiryAction = "PENDING_SUBSCRIBER_EXPIRY_ACTION"
+	environmentKeyAttachmentStorage   = "ATTACHMENT_STORAGE"
+	environmentKeyAttachmentDirectory = "ATTACHMENT_DIR"
+	environmentKeyAttachmentMaxBytes  = "ATTACHMENT_MAX_BYTES"
+	environmentKeyLiveVisitorWindow   = "LIVE_VISITOR_WINDOW_MINUTES"
+	environmentKeyShutdownTimeout     = "SHUTDOWN_TIMEOUT_SECONDS"
+	environmentKeyTracingExporter     = "TRACING_EXPORTER"
+	environmentKeyTracingEndpoint     = "TRACING_OTLP_ENDPOINT"
+	environmentKeyTracingInsecure     = "TRACING_OTLP_INSECURE"
+	environmentKeyTracingServiceName  = "TRACING_SERVICE_NAME"
+	environmentKeyLogLevel            = "LOG_LEVEL"
+	environmentKeyLogFormat           = "LOG_FORMAT"
+	environmentKeyLogRedaction        = "LOG_REDACTION"
+	environmentKeyPublicAddress       = "PUBLIC_ADDR"
+	environmentKeyTLSCertFile         = "TLS_CERT_FILE"
+	environmentKeyTLSKeyFile          = "TLS_KEY_FILE"
+	environmentKeyHTTPReadTimeout     = "HTTP_READ_TIMEOUT_SECONDS"
+	environmentKeyHTTPWriteTimeout    = "HTTP_WRITE_TIMEOUT_SECONDS"
+	environmentKeyHTTPIdleTimeout     = "HTTP_IDLE_TIMEOUT_SECONDS"
+	environmentKeyEgressAllowed       = "EGRESS_ALLOWED_NETWORKS"
+	environmentKeyEgressDenied        = "EGRESS_DENIED_NETWORKS"
+	environmentKeyEgressHostTimeouts  = "EGRESS_HOST_TIMEOUTS"
 	configurationKeyAdmins            = "admins"
 	defaultApplicationAddress         = ":8080"
 	sqliteFileDataSourceNamePattern   = "file:%s?_foreign_keys=on"
@@ -92,32 +197,79 @@ const (
 	defaultPinguinConnTimeoutSeconds  = 5
 	defaultPinguinOpTimeoutSeconds    = 30
 	defaultSubscriptionNotify         = true
+	defaultCampaignSendIntervalMs     = 200
+	emailBackendPinguin               = "pinguin"
+	emailBackendSMTP                  = "smtp"
+	defaultSMTPPort                   = 587
+	defaultPendingReminderHours       = 24
+	defaultPendingExpiryDays          = 7
+	defaultAttachmentMaxBytes         = int(api.DefaultFeedbackAttachmentMaxBytes)
+	defaultLiveVisitorWindowMinutes   = int(api.DefaultVisitorPresenceWindow / time.Minute)
+	defaultShutdownTimeoutSeconds     = 30
+	defaultHTTPReadTimeoutSeconds     = 30
+	defaultHTTPWriteTimeoutSeconds    = 60
+	defaultHTTPIdleTimeoutSeconds     = 120
 	publicRoutePrefix                 = "/public"
 	publicRouteFeedback               = "/public/feedback"
 	publicRouteSubscription           = "/public/subscriptions"
 	publicRouteSubscriptionConfirm    = "/public/subscriptions/confirm"
 	publicRouteSubscriptionOptOut     = "/public/subscriptions/unsubscribe"
 	publicRouteVisitPixel             = "/public/visits"
+	publicRouteDigestUnsubscribe      = "/public/digests/unsubscribe"
+	publicRouteFeedbackAttachments    = "/public/feedback/attachments"
 	apiRoutePrefix                    = "/api"
+	operationalRouteHealthz           = "/healthz"
+	operationalRouteReadyz            = "/readyz"
+	operationalRouteMetrics           = "/metrics"
 	apiRouteMe                        = "/me"
 	apiRouteMeAvatar                  = "/me/avatar"
+	apiRouteMeDigest                  = "/me/digest"
 	apiRouteSites                     = "/sites"
 	apiRouteSiteUpdate                = "/sites/:id"
 	apiRouteSiteMessages              = "/sites/:id/messages"
+	apiRouteSiteMessagesExport        = "/sites/:id/messages/export"
+	apiRouteSiteFeedbackRatings       = "/sites/:id/feedback/ratings"
+	apiRouteSiteFeedbackNPS           = "/sites/:id/feedback/nps"
+	apiRouteSiteFeedbackCategories    = "/sites/:id/feedback/categories"
+	apiRouteFeedbackSearch            = "/feedback/search"
 	apiRouteSiteVisitStats            = "/sites/:id/visits/stats"
 	apiRouteSiteVisitTrend            = "/sites/:id/visits/trend"
 	apiRouteSiteVisitAttribution      = "/sites/:id/visits/attribution"
 	apiRouteSiteVisitEngagement       = "/sites/:id/visits/engagement"
+	apiRouteSiteVisitsExport          = "/sites/:id/visits/export"
+	apiRouteSiteVisitRollupsExport    = "/sites/:id/visits/rollups/export"
+	apiRouteSiteVisitsLive            = "/sites/:id/visits/live"
+	apiRouteSiteTrafficAlerts         = "/sites/:id/traffic-alerts"
+	apiRouteSiteTrafficAlertRules     = "/sites/:id/traffic-alerts/rules"
+	apiRouteSiteTrafficAlertRule      = "/sites/:id/traffic-alerts/rules/:rule_id"
+	apiRouteSiteTrafficAlertSnooze    = "/sites/:id/traffic-alerts/rules/:rule_id/snooze"
 	apiRouteSiteSubscribers           = "/sites/:id/subscribers"
 	apiRouteSiteSubscriberUpdate      = "/sites/:id/subscribers/:subscriber_id"
 	apiRouteSiteSubscribersExport     = "/sites/:id/subscribers/export"
+	apiRouteSiteSubscribersImport     = "/sites/:id/subscribers/import"
+	apiRouteSiteSubscribersImportJob  = "/sites/:id/subscribers/import/:job_id"
+	apiRouteSiteSubscriberResend      = "/sites/:id/subscribers/:subscriber_id/resend-confirmation"
+	apiRouteSiteCampaigns             = "/sites/:id/campaigns"
+	apiRouteSiteCampaign              = "/sites/:id/campaigns/:campaign_id"
+	apiRouteSiteCampaignSend          = "/sites/:id/campaigns/:campaign_id/send"
+	apiRouteSiteCampaignCancel        = "/sites/:id/campaigns/:campaign_id/cancel"
+	apiRouteSiteCampaignDeliveries    = "/sites/:id/campaigns/:campaign_id/deliveries"
+	apiRouteSiteEmailTemplates        = "/sites/:id/email-templates"
+	apiRouteSiteEmailTemplate         = "/sites/:id/email-templates/:kind"
+	apiRouteSiteEmailTemplatePreview  = "/sites/:id/email-templates/:kind/preview"
 	apiRouteSiteFavicon               = "/sites/:id/favicon"
+	apiRouteSiteAttachment            = "/sites/:id/attachments/:attachment_id"
 	apiRouteSiteFaviconEvents         = "/sites/favicons/events"
 	apiRouteSiteFeedbackEvents        = "/sites/feedback/events"
+	apiRouteAdminJobs                 = "/admin/jobs"
+	apiRouteAdminJobPause             = "/admin/jobs/:name/pause"
+	apiRouteAdminJobResume            = "/admin/jobs/:name/resume"
+	apiRouteAdminJobTrigger           = "/admin/jobs/:name/trigger"
 	corsOriginWildcard                = "*"
 	corsHeaderAuthorization           = "Authorization"
 	corsHeaderContentType             = "Content-Type"
 	corsHeaderXTAuthTenant            = "X-TAuth-Tenant"
+	corsHeaderRequestID               = logging.HeaderRequestID
 	httpMethodGet                     = "GET"
 	httpMethodOptions                 = "OPTIONS"
 	httpMethodPost                    = "POST"
@@ -128,6 +280,7 @@ const (
 	loggerContextServer               = "server"
 	loggerContextAuthService          = "auth_service"
 	readHeaderTimeoutSeconds          = 5
+	tracingFlushTimeout               = 5 * time.Second
 	unexpectedArgumentsMessage        = "unexpected command arguments"
 	commandInitializationFailure      = "failed to configure command"
 	flagNotDefinedMessage             = "flag %s not defined"
@@ -139,8 +292,8 @@ const (
 
 var (
 	corsAllowedMethods          = []string{httpMethodPost, httpMethodGet, httpMethodOptions, httpMethodPatch, httpMethodDelete}
-	corsAllowedHeaders          = []string{corsHeaderAuthorization, corsHeaderContentType, corsHeaderXTAuthTenant}
-	corsExposedHeaders          = []string{corsHeaderContentType}
+	corsAllowedHeaders          = []string{corsHeaderAuthorization, corsHeaderContentType, corsHeaderXTAuthTenant, corsHeaderRequestID}
+	corsExposedHeaders          = []string{corsHeaderContentType, corsHeaderRequestID}
 	defaultDatabaseDriverName   = storage.DriverNameSQLite
 	defaultSQLiteDataSourceName = fmt.Sprintf(sqliteFileDataSourceNamePattern, defaultSQLiteDatabaseFileName)
 )
@@ -164,6 +317,38 @@ type ServerConfig struct {
 	PinguinConnTimeoutSec     int
 	PinguinOpTimeoutSec       int
 	SubscriptionNotifications bool
+	CampaignSendIntervalMs    int
+	EmailBackend              string
+	SMTPHost                  string
+	SMTPPort                  int
+	SMTPUsername              string
+	SMTPPassword              string
+	SMTPFrom                  string
+	SMTPSecurity              string
+	PendingReminderHours      int
+	PendingExpiryDays         int
+	PendingExpiryAction       string
+	AttachmentStorage         string
+	AttachmentDirectory       string
+	AttachmentMaxBytes        int
+	LiveVisitorWindowMinutes  int
+	ShutdownTimeoutSeconds    int
+	TracingExporter           string
+	TracingEndpoint           string
+	TracingInsecure           bool
+	TracingServiceName        string
+	LogLevel                  string
+	LogFormat                 string
+	LogRedaction              string
+	PublicAddress             string
+	TLSCertFile               string
+	TLSKeyFile                string
+	HTTPReadTimeoutSeconds    int
+	HTTPWriteTimeoutSeconds   int
+	HTTPIdleTimeoutSeconds    int
+	EgressAllowedNetworks     string
+	EgressDeniedNetworks      string
+	EgressHostTimeouts        string
 }
 
 // DatabaseOpener opens a database connection using the provided configuration.
@@ -177,6 +362,7 @@ type ServerApplication struct {
 	configurationLoader *viper.Viper
 	databaseOpener      DatabaseOpener
 	serverRunner        ServerRunner
+	shutdownSignal      ShutdownSignal
 	pinguinDialer       func(context.Context, string) (net.Conn, error)
 }
 
@@ -185,9 +371,8 @@ func NewServerApplication() *ServerApplication {
 	return &ServerApplication{
 		configurationLoader: viper.New(),
 		databaseOpener:      storage.OpenDatabase,
-		serverRunner: func(server *http.Server) error {
-			return server.ListenAndServe()
-		},
+		serverRunner:        runHTTPServer,
+		shutdownSignal:      notifyShutdownSignals,
 	}
 }
 
@@ -203,6 +388,12 @@ func (application *ServerApplication) WithServerRunner(serverRunner ServerRunner
 	return application
 }
 
+// WithShutdownSignal overrides how the server learns that it should shut down; SIGTERM and SIGINT by default.
+func (application *ServerApplication) WithShutdownSignal(shutdownSignal ShutdownSignal) *ServerApplication {
+	application.shutdownSignal = shutdownSignal
+	return application
+}
+
 // WithPinguinDialer overrides the Pinguin gRPC dialer dependency.
 func (application *ServerApplication) WithPinguinDialer(dialer func(context.Context, string) (net.Conn, error)) *ServerApplication {
 	application.pinguinDialer = dialer
@@ -246,6 +437,38 @@ func (application *ServerApplication) configureCommand(command *cobra.Command) e
 		{environmentKeyPinguinOpTimeout, defaultPinguinOpTimeoutSeconds},
 		{environmentKeyPinguinSharedAuth, ""},
 		{environmentKeySubscriptionNotify, defaultSubscriptionNotify},
+		{environmentKeyCampaignInterval, defaultCampaignSendIntervalMs},
+		{environmentKeyEmailBackend, emailBackendPinguin},
+		{environmentKeySMTPHost, ""},
+		{environmentKeySMTPPort, defaultSMTPPort},
+		{environmentKeySMTPUsername, ""},
+		{environmentKeySMTPPassword, ""},
+		{environmentKeySMTPFrom, ""},
+		{environmentKeySMTPSecurity, mailer.SecurityStartTLS},
+		{environmentKeyPendingReminder, defaultPendingReminderHours},
+		{environmentKeyPendingExpiryDays, defaultPendingExpiryDays},
+		{environmentKeyPendingExpiryAction, api.PendingSubscriberExpiryArchive},
+		{environmentKeyAttachmentStorage, blobstore.KindDatabase},
+		{environmentKeyAttachmentDirectory, ""},
+		{environmentKeyAttachmentMaxBytes, defaultAttachmentMaxBytes},
+		{environmentKeyLiveVisitorWindow, defaultLiveVisitorWindowMinutes},
+		{environmentKeyShutdownTimeout, defaultShutdownTimeoutSeconds},
+		{environmentKeyTracingExporter, tracing.ExporterNone},
+		{environmentKeyTracingEndpoint, ""},
+		{environmentKeyTracingInsecure, false},
+		{environmentKeyTracingServiceName, tracing.DefaultServiceName},
+		{environmentKeyLogLevel, logging.DefaultLevel},
+		{environmentKeyLogFormat, logging.FormatJSON},
+		{environmentKeyLogRedaction, logging.RedactionHash},
+		{environmentKeyPublicAddress, ""},
+		{environmentKeyTLSCertFile, ""},
+		{environmentKeyTLSKeyFile, ""},
+		{environmentKeyHTTPReadTimeout, defaultHTTPReadTimeoutSeconds},
+		{environmentKeyHTTPWriteTimeout, defaultHTTPWriteTimeoutSeconds},
+		{environmentKeyHTTPIdleTimeout, defaultHTTPIdleTimeoutSeconds},
+		{environmentKeyEgressAllowed, ""},
+		{environmentKeyEgressDenied, ""},
+		{environmentKeyEgressHostTimeouts, ""},
 	}
 	for _, entry := range defaults {
 		application.configurationLoader.SetDefault(entry.environmentKey, entry.value)
@@ -271,6 +494,27 @@ func (application *ServerApplication) configureCommand(command *cobra.Command) e
 		{flagNamePinguinAddress, defaultPinguinAddress, flagUsagePinguinAddress},
 		{flagNamePinguinAuthToken, "", flagUsagePinguinAuthToken},
 		{flagNamePinguinTenantID, "", flagUsagePinguinTenantID},
+		{flagNameEmailBackend, emailBackendPinguin, flagUsageEmailBackend},
+		{flagNameSMTPHost, "", flagUsageSMTPHost},
+		{flagNameSMTPUsername, "", flagUsageSMTPUsername},
+		{flagNameSMTPPassword, "", flagUsageSMTPPassword},
+		{flagNameSMTPFrom, "", flagUsageSMTPFrom},
+		{flagNameSMTPSecurity, mailer.SecurityStartTLS, flagUsageSMTPSecurity},
+		{flagNamePendingExpiryAction, api.PendingSubscriberExpiryArchive, flagUsagePendingExpiryAction},
+		{flagNameAttachmentStorage, blobstore.KindDatabase, flagUsageAttachmentStorage},
+		{flagNameAttachmentDirectory, "", flagUsageAttachmentDirectory},
+		{flagNameEgressAllowedNetworks, "", flagUsageEgressAllowedNetworks},
+		{flagNameEgressDeniedNetworks, "", flagUsageEgressDeniedNetworks},
+		{flagNameEgressHostTimeouts, "", flagUsageEgressHostTimeouts},
+		{flagNameTracingExporter, tracing.ExporterNone, flagUsageTracingExporter},
+		{flagNameTracingEndpoint, "", flagUsageTracingEndpoint},
+		{flagNameTracingServiceName, tracing.DefaultServiceName, flagUsageTracingServiceName},
+		{flagNameLogLevel, logging.DefaultLevel, flagUsageLogLevel},
+		{flagNameLogFormat, logging.FormatJSON, flagUsageLogFormat},
+		{flagNameLogRedaction, logging.RedactionHash, flagUsageLogRedaction},
+		{flagNamePublicAddress, "", flagUsagePublicAddress},
+		{flagNameTLSCertFile, "", flagUsageTLSCertFile},
+		{flagNameTLSKeyFile, "", flagUsageTLSKeyFile},
 	}
 	for _, flagEntry := range stringFlags {
 		commandFlags.String(flagEntry.flagName, flagEntry.defaultValue, flagEntry.usage)
@@ -283,6 +527,16 @@ func (application *ServerApplication) configureCommand(command *cobra.Command) e
 	}{
 		{flagNamePinguinConnectionTimeout, defaultPinguinConnTimeoutSeconds, flagUsagePinguinConnTimeout},
 		{flagNamePinguinOperationTimeout, defaultPinguinOpTimeoutSeconds, flagUsagePinguinOpTimeout},
+		{flagNameCampaignSendInterval, defaultCampaignSendIntervalMs, flagUsageCampaignSendInterval},
+		{flagNameSMTPPort, defaultSMTPPort, flagUsageSMTPPort},
+		{flagNamePendingReminderHours, defaultPendingReminderHours, flagUsagePendingReminderHours},
+		{flagNamePendingExpiryDays, defaultPendingExpiryDays, flagUsagePendingExpiryDays},
+		{flagNameAttachmentMaxBytes, defaultAttachmentMaxBytes, flagUsageAttachmentMaxBytes},
+		{flagNameLiveVisitorWindow, defaultLiveVisitorWindowMinutes, flagUsageLiveVisitorWindow},
+		{flagNameShutdownTimeout, defaultShutdownTimeoutSeconds, flagUsageShutdownTimeout},
+		{flagNameHTTPReadTimeout, defaultHTTPReadTimeoutSeconds, flagUsageHTTPReadTimeout},
+		{flagNameHTTPWriteTimeout, defaultHTTPWriteTimeoutSeconds, flagUsageHTTPWriteTimeout},
+		{flagNameHTTPIdleTimeout, defaultHTTPIdleTimeoutSeconds, flagUsageHTTPIdleTimeout},
 	}
 	for _, flagEntry := range intFlags {
 		commandFlags.Int(flagEntry.flagName, flagEntry.defaultValue, flagEntry.usage)
@@ -294,6 +548,7 @@ func (application *ServerApplication) configureCommand(command *cobra.Command) e
 		usage        string
 	}{
 		{flagNameSubscriptionNotifications, defaultSubscriptionNotify, flagUsageSubscriptionNotify},
+		{flagNameTracingInsecure, false, flagUsageTracingInsecure},
 	}
 	for _, flagEntry := range boolFlags {
 		commandFlags.Bool(flagEntry.flagName, flagEntry.defaultValue, flagEntry.usage)
@@ -318,6 +573,38 @@ func (application *ServerApplication) configureCommand(command *cobra.Command) e
 		{environmentKeyPinguinConnTimeout, flagNamePinguinConnectionTimeout},
 		{environmentKeyPinguinOpTimeout, flagNamePinguinOperationTimeout},
 		{environmentKeySubscriptionNotify, flagNameSubscriptionNotifications},
+		{environmentKeyCampaignInterval, flagNameCampaignSendInterval},
+		{environmentKeyEmailBackend, flagNameEmailBackend},
+		{environmentKeySMTPHost, flagNameSMTPHost},
+		{environmentKeySMTPPort, flagNameSMTPPort},
+		{environmentKeySMTPUsername, flagNameSMTPUsername},
+		{environmentKeySMTPPassword, flagNameSMTPPassword},
+		{environmentKeySMTPFrom, flagNameSMTPFrom},
+		{environmentKeySMTPSecurity, flagNameSMTPSecurity},
+		{environmentKeyPendingReminder, flagNamePendingReminderHours},
+		{environmentKeyPendingExpiryDays, flagNamePendingExpiryDays},
+		{environmentKeyPendingExpiryAction, flagNamePendingExpiryAction},
+		{environmentKeyAttachmentStorage, flagNameAttachmentStorage},
+		{environmentKeyAttachmentDirectory, flagNameAttachmentDirectory},
+		{environmentKeyAttachmentMaxBytes, flagNameAttachmentMaxBytes},
+		{environmentKeyLiveVisitorWindow, flagNameLiveVisitorWindow},
+		{environmentKeyShutdownTimeout, flagNameShutdownTimeout},
+		{environmentKeyTracingExporter, flagNameTracingExporter},
+		{environmentKeyTracingEndpoint, flagNameTracingEndpoint},
+		{environmentKeyTracingInsecure, flagNameTracingInsecure},
+		{environmentKeyTracingServiceName, flagNameTracingServiceName},
+		{environmentKeyLogLevel, flagNameLogLevel},
+		{environmentKeyLogFormat, flagNameLogFormat},
+		{environmentKeyLogRedaction, flagNameLogRedaction},
+		{environmentKeyPublicAddress, flagNamePublicAddress},
+		{environmentKeyTLSCertFile, flagNameTLSCertFile},
+		{environmentKeyTLSKeyFile, flagNameTLSKeyFile},
+		{environmentKeyHTTPReadTimeout, flagNameHTTPReadTimeout},
+		{environmentKeyHTTPWriteTimeout, flagNameHTTPWriteTimeout},
+		{environmentKeyHTTPIdleTimeout, flagNameHTTPIdleTimeout},
+		{environmentKeyEgressAllowed, flagNameEgressAllowedNetworks},
+		{environmentKeyEgressDenied, flagNameEgressDeniedNetworks},
+		{environmentKeyEgressHostTimeouts, flagNameEgressHostTimeouts},
 	}
 	for _, binding := range flagBindings {
 		if bindErr := application.bindFlag(commandFlags, binding.environmentKey, binding.flagName); bindErr != nil {
@@ -374,7 +661,12 @@ func (application *ServerApplication) runCommand(command *cobra.Command, argumen
 		return validationErr
 	}
 
-	logger, loggerErr := zap.NewProduction()
+	logger, loggerErr := logging.New(logging.Config{
+		Level:        serverConfig.LogLevel,
+		Format:       serverConfig.LogFormat,
+		Redaction:    serverConfig.LogRedaction,
+		RedactionKey: logging.DeriveRedactionKey(serverConfig.SessionSecret),
+	})
 	if loggerErr != nil {
 		return fmt.Errorf("%s: %w", loggerCreationErrorMessage, loggerErr)
 	}
@@ -384,11 +676,45 @@ func (application *ServerApplication) runCommand(command *cobra.Command, argumen
 
 	application.logAdministratorWarning(logger, serverConfig)
 
-	router := gin.New()
-	router.Use(gin.Recovery())
-	router.Use(api.RequestLogger(logger))
+	shutdownTracing, tracingErr := tracing.Setup(context.Background(), tracing.Config{
+		Exporter:    serverConfig.TracingExporter,
+		Endpoint:    serverConfig.TracingEndpoint,
+		Insecure:    serverConfig.TracingInsecure,
+		ServiceName: serverConfig.TracingServiceName,
+	})
+	if tracingErr != nil {
+		logger.Fatal("tracing", zap.Error(tracingErr))
+	}
+	defer func() {
+		flushContext, flushCancel := context.WithTimeout(context.Background(), tracingFlushTimeout)
+		defer flushCancel()
+		if flushErr := shutdownTracing(flushContext); flushErr != nil {
+			logger.Warn("tracing_flush", zap.Error(flushErr))
+		}
+	}()
+	tracingEnabled := serverConfig.TracingExporter != "" && serverConfig.TracingExporter != tracing.ExporterNone
+
+	serverMetrics := metrics.New()
+	newRouter := func() *gin.Engine {
+		router := gin.New()
+		router.Use(gin.Recovery())
+		router.Use(api.RequestID())
+		if tracingEnabled {
+			router.Use(otelgin.Middleware(serverConfig.TracingServiceName, otelgin.WithFilter(tracedRequest)))
+		}
+		router.Use(api.RequestLogger(logger, serverMetrics))
+		return router
+	}
+	router := newRouter()
+	publicRouter := router
+	if serverConfig.PublicAddress != "" {
+		publicRouter = newRouter()
+	}
 
-	sharedHTTPClient := &http.Client{Timeout: 5 * time.Second}
+	sharedHTTPClient, egressErr := newEgressHTTPClient(serverConfig, logger)
+	if egressErr != nil {
+		logger.Fatal("egress_client", zap.Error(egressErr))
+	}
 	database, databaseErr := application.databaseOpener(storage.Config{
 		DriverName:     serverConfig.DatabaseDriverName,
 		DataSourceName: serverConfig.DatabaseDataSourceName,
@@ -396,6 +722,11 @@ func (application *ServerApplication) runCommand(command *cobra.Command, argumen
 	if databaseErr != nil {
 		logger.Fatal(loggerContextOpenDatabase, zap.Error(databaseErr))
 	}
+	if tracingEnabled {
+		if pluginErr := database.Use(tracing.GormPlugin{}); pluginErr != nil {
+			logger.Fatal("tracing_gorm", zap.Error(pluginErr))
+		}
+	}
 
 	if migrateErr := storage.AutoMigrate(database); migrateErr != nil {
 		logger.Fatal(loggerContextAutoMigrate, zap.Error(migrateErr))
@@ -414,55 +745,191 @@ func (application *ServerApplication) runCommand(command *cobra.Command, argumen
 	defer feedbackBroadcaster.Close()
 	subscriptionEvents := api.NewSubscriptionTestEventBroadcaster()
 	defer subscriptionEvents.Close()
-	pinguinNotifier, notifierErr := notifications.NewPinguinNotifier(logger, notifications.PinguinConfig{
-		Address:           serverConfig.PinguinAddress,
-		AuthToken:         serverConfig.PinguinAuthToken,
-		TenantID:          serverConfig.PinguinTenantID,
-		ConnectionTimeout: time.Duration(serverConfig.PinguinConnTimeoutSec) * time.Second,
-		OperationTimeout:  time.Duration(serverConfig.PinguinOpTimeoutSec) * time.Second,
-		Dialer:            application.pinguinDialer,
-	})
-	if notifierErr != nil {
-		logger.Fatal("pinguin_notifier", zap.Error(notifierErr))
+	serverMetrics.RegisterSubscriberGauge("feedback", feedbackBroadcaster.SubscriberCount)
+	serverMetrics.RegisterSubscriberGauge("subscription_test", subscriptionEvents.SubscriberCount)
+	emailTemplates := emailtemplate.NewRenderer(api.NewDatabaseEmailTemplateSource(database), logger)
+	delivery, deliveryErr := application.newEmailDelivery(logger, serverConfig, emailTemplates)
+	if deliveryErr != nil {
+		logger.Fatal("email_delivery", zap.Error(deliveryErr))
 	}
-	defer pinguinNotifier.Close()
+	defer delivery.Close()
+	notificationWork := &inFlightWork{}
+	delivery = instrumentEmailDelivery(delivery, serverMetrics, notificationWork)
 	var subscriptionNotifier api.SubscriptionNotifier
 	if serverConfig.SubscriptionNotifications {
-		subscriptionNotifier = pinguinNotifier
+		subscriptionNotifier = delivery.subscriptionNotifier
+	}
+	attachmentStore, attachmentStoreErr := newAttachmentStore(serverConfig, database)
+	if attachmentStoreErr != nil {
+		logger.Fatal("attachment_store", zap.Error(attachmentStoreErr))
 	}
-	publicHandlers := api.NewPublicHandlers(database, logger, feedbackBroadcaster, subscriptionEvents, pinguinNotifier, subscriptionNotifier, serverConfig.SubscriptionNotifications, serverConfig.PublicBaseURL, serverConfig.SessionSecret, pinguinNotifier)
+	visitorPresence := api.NewVisitorPresenceTracker(time.Duration(serverConfig.LiveVisitorWindowMinutes) * time.Minute)
+	visitorPresenceContext, visitorPresenceCancel := context.WithCancel(context.Background())
+	defer visitorPresence.Stop()
+	defer visitorPresenceCancel()
+	visitorPresence.Start(visitorPresenceContext)
+	serverMetrics.RegisterSubscriberGauge("visitors_live", visitorPresence.SubscriberCount)
+	publicHandlers := api.NewPublicHandlers(database, logger, feedbackBroadcaster, subscriptionEvents, delivery.feedbackNotifier, subscriptionNotifier, serverConfig.SubscriptionNotifications, serverConfig.PublicBaseURL, serverConfig.SessionSecret, delivery.emailSender).
+		WithEmailTemplates(emailTemplates).
+		WithAttachments(attachmentStore, int64(serverConfig.AttachmentMaxBytes)).
+		WithVisitorPresence(visitorPresence).
+		WithMetrics(serverMetrics)
 	faviconResolver := favicon.NewHTTPResolver(sharedHTTPClient, logger)
 	faviconService := favicon.NewService(faviconResolver)
-	faviconManager := api.NewSiteFaviconManager(database, faviconService, logger)
+	jobRegistry := task.NewRegistry(database, logger, task.WithRegistryRunObserver(serverMetrics.ObserveJob))
+	jobRegistryContext, jobRegistryCancel := context.WithCancel(context.Background())
+	defer jobRegistryCancel()
+	faviconManager := api.NewSiteFaviconManager(database, faviconService, logger, api.WithExternalFaviconScans())
 	faviconManagerContext, faviconManagerCancel := context.WithCancel(context.Background())
 	defer faviconManager.Stop()
 	defer faviconManagerCancel()
 	faviconManager.Start(faviconManagerContext)
-	faviconManager.TriggerScheduledRefresh()
+	serverMetrics.RegisterSubscriberGauge("favicons", faviconManager.SubscriberCount)
 	statsProvider := api.NewDatabaseSiteStatisticsProvider(database)
-	siteHandlers := api.NewSiteHandlers(database, logger, serverConfig.PublicBaseURL, faviconManager, statsProvider, feedbackBroadcaster)
-	widgetTestHandlers := api.NewSiteWidgetTestHandlers(database, logger, feedbackBroadcaster, pinguinNotifier)
-	subscribeTestHandlers := api.NewSiteSubscribeTestHandlers(database, logger, subscriptionEvents, subscriptionNotifier, serverConfig.SubscriptionNotifications, serverConfig.PublicBaseURL, serverConfig.SessionSecret, pinguinNotifier)
+	siteHandlers := api.NewSiteHandlers(database, logger, serverConfig.PublicBaseURL, faviconManager, statsProvider, feedbackBroadcaster).
+		WithAttachmentStore(attachmentStore).
+		WithVisitorPresence(visitorPresence)
+	widgetTestHandlers := api.NewSiteWidgetTestHandlers(database, logger, feedbackBroadcaster, delivery.feedbackNotifier)
+	subscribeTestHandlers := api.NewSiteSubscribeTestHandlers(database, logger, subscriptionEvents, subscriptionNotifier, serverConfig.SubscriptionNotifications, serverConfig.PublicBaseURL, serverConfig.SessionSecret, delivery.emailSender).WithEmailTemplates(emailTemplates)
+	triggerSubscriberImports := func() {
+		if _, triggerErr := jobRegistry.Trigger(jobRegistryContext, jobNameSubscriberImports); triggerErr != nil {
+			logger.Warn("trigger_subscriber_imports", zap.Error(triggerErr))
+		}
+	}
+	subscriberImportRunner := api.NewSubscriberImportRunner(database, logger, delivery.emailSender, serverConfig.PublicBaseURL, serverConfig.SessionSecret, triggerSubscriberImports,
+		api.WithSubscriberImportTemplates(emailTemplates),
+	)
+	subscriberImportHandlers := api.NewSubscriberImportHandlers(database, logger, subscriberImportRunner)
+	triggerCampaignDispatch := func() {
+		if _, triggerErr := jobRegistry.Trigger(jobRegistryContext, jobNameCampaignDispatch); triggerErr != nil {
+			logger.Warn("trigger_campaign_dispatch", zap.Error(triggerErr))
+		}
+	}
+	campaignDispatcher := api.NewCampaignDispatcher(database, logger, delivery.emailSender, serverConfig.PublicBaseURL, serverConfig.SessionSecret, triggerCampaignDispatch,
+		api.WithCampaignSendInterval(time.Duration(serverConfig.CampaignSendIntervalMs)*time.Millisecond),
+	)
+	campaignHandlers := api.NewCampaignHandlers(database, logger, campaignDispatcher)
+	pendingSubscriberSweeper := api.NewPendingSubscriberSweeper(database, logger, delivery.emailSender, serverConfig.PublicBaseURL, serverConfig.SessionSecret,
+		api.WithPendingSubscriberTemplates(emailTemplates),
+		api.WithPendingSubscriberReminderAfter(time.Duration(serverConfig.PendingReminderHours)*time.Hour),
+		api.WithPendingSubscriberExpiryAfter(time.Duration(serverConfig.PendingExpiryDays)*24*time.Hour),
+		api.WithPendingSubscriberExpiryAction(serverConfig.PendingExpiryAction),
+	)
+	pendingSubscriberHandlers := api.NewPendingSubscriberHandlers(database, logger, pendingSubscriberSweeper)
+	digestReporter := api.NewDigestReporter(database, logger, delivery.emailSender, statsProvider, serverConfig.PublicBaseURL, serverConfig.SessionSecret)
+	digestHandlers := api.NewDigestHandlers(database, logger, serverConfig.SessionSecret)
+	trafficAlertMonitor := api.NewTrafficAlertMonitor(database, logger, delivery.trafficAlertNotifier, api.WithTrafficAlertHTTPClient(sharedHTTPClient))
+	trafficAlertHandlers := api.NewTrafficAlertHandlers(database, logger)
+	registerJobsErr := registerBackgroundJobs(jobRegistry, backgroundJobs{
+		faviconManager:           faviconManager,
+		visitRollup:              task.NewVisitRollupJob(database, logger, task.VisitRollupConfig{}),
+		campaignDispatcher:       campaignDispatcher,
+		subscriberImportRunner:   subscriberImportRunner,
+		pendingSubscriberSweeper: pendingSubscriberSweeper,
+		digestReporter:           digestReporter,
+		trafficAlertMonitor:      trafficAlertMonitor,
+	})
+	if registerJobsErr != nil {
+		logger.Fatal("register_jobs", zap.Error(registerJobsErr))
+	}
+	defer jobRegistry.Stop()
+	jobRegistry.Start(jobRegistryContext)
+	if _, triggerErr := jobRegistry.Trigger(jobRegistryContext, jobNameFaviconRefresh); triggerErr != nil {
+		logger.Warn("trigger_favicon_refresh", zap.Error(triggerErr))
+	}
+	jobHandlers := api.NewJobHandlers(jobRegistry, logger)
+	emailTemplateHandlers := api.NewEmailTemplateHandlers(database, logger, emailTemplates)
+	feedbackInsightsHandlers := api.NewFeedbackInsightsHandlers(database, logger)
+	feedbackSearchHandlers := api.NewFeedbackSearchHandlers(database, logger)
 	authenticatedOrigin, originErr := resolveOrigin(serverConfig.PublicBaseURL)
 	if originErr != nil {
 		logger.Fatal("cors_origin", zap.Error(originErr))
 	}
-	registerBackendRoutes(router, authManager, publicHandlers, siteHandlers, widgetTestHandlers, subscribeTestHandlers, authenticatedOrigin)
+	readinessChecks := []api.ReadinessCheck{
+		api.DatabaseReadinessCheck(database),
+		api.FaviconWorkerReadinessCheck(faviconManager),
+	}
+	if delivery.connectionCheck != nil {
+		readinessChecks = append(readinessChecks, api.ReadinessCheck{Name: "pinguin", Check: delivery.connectionCheck})
+	}
+	registerOperationalRoutes(router, api.NewHealthHandlers(logger, readinessChecks...), serverMetrics.Handler())
+	registerBackendRoutes(publicRouter, router, authManager, publicHandlers, siteHandlers, widgetTestHandlers, subscribeTestHandlers, subscriberImportHandlers, pendingSubscriberHandlers, campaignHandlers, emailTemplateHandlers, feedbackInsightsHandlers, feedbackSearchHandlers, digestHandlers, trafficAlertHandlers, jobHandlers, authenticatedOrigin, time.Duration(serverConfig.HTTPWriteTimeoutSeconds)*time.Second)
+
+	certificateContext, certificateCancel := context.WithCancel(context.Background())
+	defer certificateCancel()
+	tlsConfig, tlsErr := newServerTLSConfig(certificateContext, serverConfig, logger)
+	if tlsErr != nil {
+		logger.Fatal("tls_certificate", zap.Error(tlsErr))
+	}
+	httpServer := newHTTPServer(serverConfig.ApplicationAddress, router, serverConfig, tlsConfig)
+	httpServers := []*http.Server{httpServer}
+	if publicRouter != router {
+		httpServers = append(httpServers, newHTTPServer(serverConfig.PublicAddress, publicRouter, serverConfig, tlsConfig))
+	}
 
-	httpServer := &http.Server{
-		Addr:              serverConfig.ApplicationAddress,
-		Handler:           router,
-		ReadHeaderTimeout: readHeaderTimeoutSeconds * time.Second,
+	// Shutdown waits for active connections to go idle, so the event streams are ended first; each stream sends
+	// a final server_shutdown event when its subscription closes.
+	httpServer.RegisterOnShutdown(func() {
+		feedbackBroadcaster.Close()
+		subscriptionEvents.Close()
+		visitorPresence.Stop()
+		faviconManager.CloseSubscribers()
+	})
+
+	shutdownContext, stopShutdownSignal := application.shutdownSignal(context.Background())
+	defer stopShutdownSignal()
+	serveResult := make(chan error, len(httpServers))
+	for _, server := range httpServers {
+		logger.Info(logEventListening, zap.String(logFieldAddress, server.Addr), zap.Bool("tls", server.TLSConfig != nil))
+		go func() {
+			serveResult <- application.serverRunner(server)
+		}()
+	}
+	select {
+	case serveErr := <-serveResult:
+		if serveErr != nil && !errors.Is(serveErr, http.ErrServerClosed) {
+			logger.Fatal(loggerContextServer, zap.Error(serveErr))
+		}
+		for _, server := range httpServers {
+			_ = server.Close()
+		}
+		return nil
+	case <-shutdownContext.Done():
 	}
 
-	logger.Info(logEventListening, zap.String(logFieldAddress, serverConfig.ApplicationAddress))
-	if serveErr := application.serverRunner(httpServer); serveErr != nil && !errors.Is(serveErr, http.ErrServerClosed) {
-		logger.Fatal(loggerContextServer, zap.Error(serveErr))
+	shutdownTimeout := time.Duration(serverConfig.ShutdownTimeoutSeconds) * time.Second
+	if shutdownTimeout <= 0 {
+		shutdownTimeout = defaultShutdownTimeoutSeconds * time.Second
 	}
+	logger.Info(logEventShutdownStarted, zap.Duration("timeout", shutdownTimeout))
+	drainContext, drainCancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer drainCancel()
+	shutdownHTTPServers(drainContext, httpServers, logger)
+	for range httpServers {
+		if serveErr := <-serveResult; serveErr != nil && !errors.Is(serveErr, http.ErrServerClosed) {
+			logger.Warn(loggerContextServer, zap.Error(serveErr))
+		}
+	}
+	if drainErr := notificationWork.wait(drainContext); drainErr != nil {
+		logger.Warn("shutdown_notifications_incomplete", zap.Int("in_flight", notificationWork.pending()), zap.Error(drainErr))
+	}
+	jobRegistry.Stop()
+	faviconManager.Stop()
+	logger.Info(logEventShutdownComplete)
 
 	return nil
 }
 
+// tracedRequest keeps probe and scrape requests out of traces.
+func tracedRequest(request *http.Request) bool {
+	switch request.URL.Path {
+	case operationalRouteHealthz, operationalRouteReadyz, operationalRouteMetrics:
+		return false
+	default:
+		return true
+	}
+}
+
 func resolveOrigin(rawURL string) (string, error) {
 	trimmed := strings.TrimSpace(rawURL)
 	if trimmed == "" {
@@ -519,6 +986,38 @@ func (application *ServerApplication) loadServerConfig(configFilePath string) (S
 		PinguinConnTimeoutSec:     application.configurationLoader.GetInt(environmentKeyPinguinConnTimeout),
 		PinguinOpTimeoutSec:       application.configurationLoader.GetInt(environmentKeyPinguinOpTimeout),
 		SubscriptionNotifications: application.configurationLoader.GetBool(environmentKeySubscriptionNotify),
+		CampaignSendIntervalMs:    application.configurationLoader.GetInt(environmentKeyCampaignInterval),
+		EmailBackend:              strings.ToLower(strings.TrimSpace(application.configurationLoader.GetString(environmentKeyEmailBackend))),
+		SMTPHost:                  strings.TrimSpace(application.configurationLoader.GetString(environmentKeySMTPHost)),
+		SMTPPort:                  application.configurationLoader.GetInt(environmentKeySMTPPort),
+		SMTPUsername:              strings.TrimSpace(application.configurationLoader.GetString(environmentKeySMTPUsername)),
+		SMTPPassword:              application.configurationLoader.GetString(environmentKeySMTPPassword),
+		SMTPFrom:                  strings.TrimSpace(application.configurationLoader.GetString(environmentKeySMTPFrom)),
+		SMTPSecurity:              strings.ToLower(strings.TrimSpace(application.configurationLoader.GetString(environmentKeySMTPSecurity))),
+		PendingReminderHours:      application.configurationLoader.GetInt(environmentKeyPendingReminder),
+		PendingExpiryDays:         application.configurationLoader.GetInt(environmentKeyPendingExpiryDays),
+		PendingExpiryAction:       strings.ToLower(strings.TrimSpace(application.configurationLoader.GetString(environmentKeyPendingExpiryAction))),
+		AttachmentStorage:         strings.ToLower(strings.TrimSpace(application.configurationLoader.GetString(environmentKeyAttachmentStorage))),
+		AttachmentDirectory:       strings.TrimSpace(application.configurationLoader.GetString(environmentKeyAttachmentDirectory)),
+		AttachmentMaxBytes:        application.configurationLoader.GetInt(environmentKeyAttachmentMaxBytes),
+		LiveVisitorWindowMinutes:  application.configurationLoader.GetInt(environmentKeyLiveVisitorWindow),
+		ShutdownTimeoutSeconds:    application.configurationLoader.GetInt(environmentKeyShutdownTimeout),
+		TracingExporter:           strings.ToLower(strings.TrimSpace(application.configurationLoader.GetString(environmentKeyTracingExporter))),
+		TracingEndpoint:           strings.TrimSpace(application.configurationLoader.GetString(environmentKeyTracingEndpoint)),
+		TracingInsecure:           application.configurationLoader.GetBool(environmentKeyTracingInsecure),
+		TracingServiceName:        strings.TrimSpace(application.configurationLoader.GetString(environmentKeyTracingServiceName)),
+		LogLevel:                  strings.ToLower(strings.TrimSpace(application.configurationLoader.GetString(environmentKeyLogLevel))),
+		LogFormat:                 strings.ToLower(strings.TrimSpace(application.configurationLoader.GetString(environmentKeyLogFormat))),
+		LogRedaction:              strings.ToLower(strings.TrimSpace(application.configurationLoader.GetString(environmentKeyLogRedaction))),
+		PublicAddress:             strings.TrimSpace(application.configurationLoader.GetString(environmentKeyPublicAddress)),
+		TLSCertFile:               strings.TrimSpace(application.configurationLoader.GetString(environmentKeyTLSCertFile)),
+		TLSKeyFile:                strings.TrimSpace(application.configurationLoader.GetString(environmentKeyTLSKeyFile)),
+		HTTPReadTimeoutSeconds:    application.configurationLoader.GetInt(environmentKeyHTTPReadTimeout),
+		HTTPWriteTimeoutSeconds:   application.configurationLoader.GetInt(environmentKeyHTTPWriteTimeout),
+		HTTPIdleTimeoutSeconds:    application.configurationLoader.GetInt(environmentKeyHTTPIdleTimeout),
+		EgressAllowedNetworks:     strings.TrimSpace(application.configurationLoader.GetString(environmentKeyEgressAllowed)),
+		EgressDeniedNetworks:      strings.TrimSpace(application.configurationLoader.GetString(environmentKeyEgressDenied)),
+		EgressHostTimeouts:        strings.TrimSpace(application.configurationLoader.GetString(environmentKeyEgressHostTimeouts)),
 	}
 
 	if serverConfig.PinguinAuthToken == "" {
@@ -528,6 +1027,10 @@ func (application *ServerApplication) loadServerConfig(configFilePath string) (S
 		}
 	}
 
+	if serverConfig.TracingServiceName == "" {
+		serverConfig.TracingServiceName = tracing.DefaultServiceName
+	}
+
 	if serverConfig.DatabaseDriverName == storage.DriverNameSQLite && serverConfig.DatabaseDataSourceName == "" {
 		serverConfig.DatabaseDataSourceName = defaultSQLiteDataSourceName
 	}
@@ -588,6 +1091,60 @@ func (application *ServerApplication) ensureRequiredConfiguration(configuration
 		missingParameters = append(missingParameters, flagNameSessionSecret)
 	}
 
+	switch configuration.EmailBackend {
+	case emailBackendSMTP:
+		missingParameters = append(missingParameters, missingSMTPParameters(configuration)...)
+	case emailBackendPinguin, "":
+		missingParameters = append(missingParameters, missingPinguinParameters(configuration)...)
+	default:
+		missingParameters = append(missingParameters, flagNameEmailBackend)
+	}
+
+	switch configuration.PendingExpiryAction {
+	case api.PendingSubscriberExpiryArchive, api.PendingSubscriberExpiryDelete, "":
+	default:
+		missingParameters = append(missingParameters, flagNamePendingExpiryAction)
+	}
+
+	switch configuration.AttachmentStorage {
+	case blobstore.KindDatabase, "":
+	case blobstore.KindFilesystem:
+		if configuration.AttachmentDirectory == "" {
+			missingParameters = append(missingParameters, flagNameAttachmentDirectory)
+		}
+	default:
+		missingParameters = append(missingParameters, flagNameAttachmentStorage)
+	}
+
+	if configuration.AttachmentMaxBytes < 0 {
+		missingParameters = append(missingParameters, flagNameAttachmentMaxBytes)
+	}
+	if !tracing.ValidExporter(configuration.TracingExporter) {
+		missingParameters = append(missingParameters, flagNameTracingExporter)
+	}
+	if !logging.ValidLevel(configuration.LogLevel) {
+		missingParameters = append(missingParameters, flagNameLogLevel)
+	}
+	if !logging.ValidFormat(configuration.LogFormat) {
+		missingParameters = append(missingParameters, flagNameLogFormat)
+	}
+	if !logging.ValidRedaction(configuration.LogRedaction) {
+		missingParameters = append(missingParameters, flagNameLogRedaction)
+	}
+	missingParameters = append(missingParameters, invalidListenerParameters(configuration)...)
+
+	missingParameters = append(missingParameters, invalidEgressParameters(configuration)...)
+
+	if len(missingParameters) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("%s: %s", missingConfigurationMessage, strings.Join(missingParameters, ", "))
+}
+
+func missingPinguinParameters(configuration ServerConfig) []string {
+	var missingParameters []string
+
 	if configuration.PinguinAddress == "" {
 		missingParameters = append(missingParameters, flagNamePinguinAddress)
 	}
@@ -608,11 +1165,25 @@ func (application *ServerApplication) ensureRequiredConfiguration(configuration
 		missingParameters = append(missingParameters, flagNamePinguinOperationTimeout)
 	}
 
-	if len(missingParameters) == 0 {
-		return nil
+	return missingParameters
+}
+
+func missingSMTPParameters(configuration ServerConfig) []string {
+	var missingParameters []string
+
+	if configuration.SMTPHost == "" {
+		missingParameters = append(missingParameters, flagNameSMTPHost)
 	}
 
-	return fmt.Errorf("%s: %s", missingConfigurationMessage, strings.Join(missingParameters, ", "))
+	if configuration.SMTPPort <= 0 {
+		missingParameters = append(missingParameters, flagNameSMTPPort)
+	}
+
+	if configuration.SMTPFrom == "" {
+		missingParameters = append(missingParameters, flagNameSMTPFrom)
+	}
+
+	return missingParameters
 }
 
 func main() {
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"net"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"syscall"
@@ -19,8 +20,15 @@ import (
 	"google.golang.org/grpc/test/bufconn"
 	"gorm.io/gorm"
 
+	"github.com/MarkoPoloResearchLab/loopaware/internal/api"
+	"github.com/MarkoPoloResearchLab/loopaware/internal/blobstore"
+	"github.com/MarkoPoloResearchLab/loopaware/internal/logging"
+	"github.com/MarkoPoloResearchLab/loopaware/internal/mailer"
+	"github.com/MarkoPoloResearchLab/loopaware/internal/metrics"
 	"github.com/MarkoPoloResearchLab/loopaware/internal/notifications/pinguinpb"
 	"github.com/MarkoPoloResearchLab/loopaware/internal/storage"
+	"github.com/MarkoPoloResearchLab/loopaware/internal/task"
+	"github.com/MarkoPoloResearchLab/loopaware/internal/tracing"
 )
 
 const (
@@ -338,6 +346,114 @@ func TestEnsureRequiredConfigurationReportsMissing(testingT *testing.T) {
 	require.NoError(testingT, application.ensureRequiredConfiguration(config))
 }
 
+func TestEnsureRequiredConfigurationSelectsEmailBackend(testingT *testing.T) {
+	application := NewServerApplication()
+	config := ServerConfig{
+		DatabaseDriverName:     storage.DriverNameSQLite,
+		DatabaseDataSourceName: testDatabaseDSNValue,
+		SessionSecret:          testSessionSecretValue,
+		TauthBaseURL:           testTauthBaseURLValue,
+		TauthTenantID:          testTauthTenantIDValue,
+		TauthSigningKey:        testTauthSigningKeyValue,
+		PublicBaseURL:          testPublicBaseURLValue,
+		EmailBackend:           emailBackendSMTP,
+	}
+
+	missingErr := application.ensureRequiredConfiguration(config)
+	require.ErrorContains(testingT, missingErr, flagNameSMTPHost)
+	require.ErrorContains(testingT, missingErr, flagNameSMTPFrom)
+	require.NotContains(testingT, missingErr.Error(), flagNamePinguinAuthToken)
+
+	config.SMTPHost = "smtp.example.com"
+	config.SMTPPort = defaultSMTPPort
+	config.SMTPFrom = "noreply@example.com"
+	require.NoError(testingT, application.ensureRequiredConfiguration(config))
+
+	config.EmailBackend = "carrier-pigeon"
+	require.ErrorContains(testingT, application.ensureRequiredConfiguration(config), flagNameEmailBackend)
+}
+
+func TestEnsureRequiredConfigurationValidatesAttachmentStorage(testingT *testing.T) {
+	application := NewServerApplication()
+	config := ServerConfig{
+		DatabaseDriverName:     storage.DriverNameSQLite,
+		DatabaseDataSourceName: testDatabaseDSNValue,
+		SessionSecret:          testSessionSecretValue,
+		TauthBaseURL:           testTauthBaseURLValue,
+		TauthTenantID:          testTauthTenantIDValue,
+		TauthSigningKey:        testTauthSigningKeyValue,
+		PublicBaseURL:          testPublicBaseURLValue,
+		EmailBackend:           emailBackendSMTP,
+		SMTPHost:               "smtp.example.com",
+		SMTPPort:               defaultSMTPPort,
+		SMTPFrom:               "noreply@example.com",
+		AttachmentStorage:      blobstore.KindFilesystem,
+	}
+	require.ErrorContains(testingT, application.ensureRequiredConfiguration(config), flagNameAttachmentDirectory)
+
+	config.AttachmentDirectory = testingT.TempDir()
+	require.NoError(testingT, application.ensureRequiredConfiguration(config))
+	store, storeErr := newAttachmentStore(config, nil)
+	require.NoError(testingT, storeErr)
+	require.IsType(testingT, &blobstore.FilesystemStore{}, store)
+
+	config.AttachmentMaxBytes = -1
+	require.ErrorContains(testingT, application.ensureRequiredConfiguration(config), flagNameAttachmentMaxBytes)
+
+	config.AttachmentMaxBytes = 0
+	config.AttachmentStorage = "s3"
+	require.ErrorContains(testingT, application.ensureRequiredConfiguration(config), flagNameAttachmentStorage)
+}
+
+func TestEnsureRequiredConfigurationValidatesEgressPolicy(testingT *testing.T) {
+	application := NewServerApplication()
+	config := ServerConfig{
+		DatabaseDriverName:     storage.DriverNameSQLite,
+		DatabaseDataSourceName: testDatabaseDSNValue,
+		SessionSecret:          testSessionSecretValue,
+		TauthBaseURL:           testTauthBaseURLValue,
+		TauthTenantID:          testTauthTenantIDValue,
+		TauthSigningKey:        testTauthSigningKeyValue,
+		PublicBaseURL:          testPublicBaseURLValue,
+		EmailBackend:           emailBackendSMTP,
+		SMTPHost:               "smtp.example.com",
+		SMTPPort:               defaultSMTPPort,
+		SMTPFrom:               "noreply@example.com",
+		EgressAllowedNetworks:  "10.20.0.0/16",
+		EgressDeniedNetworks:   "203.0.113.7",
+		EgressHostTimeouts:     "cdn.example.com=2s",
+	}
+	require.NoError(testingT, application.ensureRequiredConfiguration(config))
+	client, clientErr := newEgressHTTPClient(config, zap.NewNop())
+	require.NoError(testingT, clientErr)
+	require.NotNil(testingT, client)
+
+	config.EgressAllowedNetworks = "10.20.0.0/99"
+	config.EgressHostTimeouts = "cdn.example.com"
+	configurationErr := application.ensureRequiredConfiguration(config)
+	require.ErrorContains(testingT, configurationErr, flagNameEgressAllowedNetworks)
+	require.ErrorContains(testingT, configurationErr, flagNameEgressHostTimeouts)
+	_, clientErr = newEgressHTTPClient(config, zap.NewNop())
+	require.Error(testingT, clientErr)
+}
+
+func TestNewEmailDeliveryUsesSMTPBackend(testingT *testing.T) {
+	application := NewServerApplication()
+	delivery, deliveryErr := application.newEmailDelivery(zap.NewNop(), ServerConfig{
+		EmailBackend: emailBackendSMTP,
+		SMTPHost:     "smtp.example.com",
+		SMTPPort:     defaultSMTPPort,
+		SMTPFrom:     "noreply@example.com",
+		SMTPSecurity: mailer.SecurityStartTLS,
+	}, nil)
+	require.NoError(testingT, deliveryErr)
+	require.IsType(testingT, &mailer.SMTPSender{}, delivery.emailSender)
+	require.NoError(testingT, delivery.Close())
+
+	_, invalidErr := application.newEmailDelivery(zap.NewNop(), ServerConfig{EmailBackend: emailBackendSMTP, SMTPHost: "smtp.example.com", SMTPPort: defaultSMTPPort, SMTPFrom: "invalid"}, nil)
+	require.Error(testingT, invalidErr)
+}
+
 func TestLogAdministratorWarningEmitsWhenMissing(testingT *testing.T) {
 	observedCore, observedLogs := observer.New(zap.WarnLevel)
 	logger := zap.New(observedCore)
@@ -451,3 +567,211 @@ func TestDefaultServerRunnerHandlesServerClose(testingT *testing.T) {
 	require.ErrorIs(testingT, runErr, http.ErrServerClosed)
 	<-closeDone
 }
+
+func TestRegisterBackgroundJobsParsesEverySchedule(testingT *testing.T) {
+	registry := task.NewRegistry(nil, zap.NewNop())
+	require.NoError(testingT, registerBackgroundJobs(registry, backgroundJobs{}))
+	for _, jobName := range []string{jobNameFaviconRefresh, jobNameVisitRollup, jobNameCampaignDispatch, jobNameSubscriberImports, jobNamePendingSubscriberScan, jobNameDigestEmails, jobNameTrafficAlerts} {
+		registerErr := registry.Register(task.Job{Name: jobName, Schedule: task.Every(time.Minute), Run: func(context.Context) error { return nil }})
+		require.ErrorIs(testingT, registerErr, task.ErrDuplicateJob, jobName)
+	}
+}
+
+func TestRunCommandServesProbesAndMetrics(testingT *testing.T) {
+	listener := startPinguinServer(testingT)
+	setRequiredEnvironment(testingT, testPinguinAddress)
+
+	application := NewServerApplication()
+	application.WithPinguinDialer(createPinguinDialer(listener))
+	command, commandErr := application.Command()
+	require.NoError(testingT, commandErr)
+
+	responses := make(map[string]*httptest.ResponseRecorder)
+	application.WithServerRunner(func(server *http.Server) error {
+		for _, path := range []string{operationalRouteHealthz, operationalRouteReadyz, operationalRouteMetrics} {
+			recorder := httptest.NewRecorder()
+			server.Handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+			responses[path] = recorder
+		}
+		return http.ErrServerClosed
+	})
+
+	require.NoError(testingT, application.runCommand(command, nil))
+	require.Equal(testingT, http.StatusOK, responses[operationalRouteHealthz].Code)
+	require.Equal(testingT, http.StatusOK, responses[operationalRouteReadyz].Code, responses[operationalRouteReadyz].Body.String())
+	require.JSONEq(testingT, `{"status":"ready","checks":{"database":"ok","favicon_worker":"ok","pinguin":"ok"}}`, responses[operationalRouteReadyz].Body.String())
+	require.Equal(testingT, http.StatusOK, responses[operationalRouteMetrics].Code)
+	require.Contains(testingT, responses[operationalRouteMetrics].Body.String(), `loopaware_http_request_duration_seconds_count{method="GET",route="/healthz",status="200"} 1`)
+	require.Contains(testingT, responses[operationalRouteMetrics].Body.String(), `loopaware_sse_subscribers{stream="feedback"} 0`)
+}
+
+type recordingMessageSender struct {
+	messages []mailer.Message
+}
+
+func (sender *recordingMessageSender) SendEmail(context.Context, string, string, string) error {
+	return errors.New("plain text path used")
+}
+
+func (sender *recordingMessageSender) SendEmailMessage(_ context.Context, message mailer.Message) error {
+	sender.messages = append(sender.messages, message)
+	return nil
+}
+
+func TestInstrumentEmailDeliveryKeepsMultipartSender(testingT *testing.T) {
+	sender := &recordingMessageSender{}
+	serverMetrics := metrics.New()
+	delivery := instrumentEmailDelivery(emailDelivery{emailSender: sender}, serverMetrics, nil)
+
+	messageSender, ok := delivery.emailSender.(api.EmailMessageSender)
+	require.True(testingT, ok)
+	require.NoError(testingT, messageSender.SendEmailMessage(context.Background(), mailer.Message{Recipient: "owner@example.com", Subject: "Digest"}))
+	require.Error(testingT, delivery.emailSender.SendEmail(context.Background(), "owner@example.com", "Digest", "body"))
+	require.Len(testingT, sender.messages, 1)
+	require.Nil(testingT, delivery.feedbackNotifier)
+
+	recorder := httptest.NewRecorder()
+	serverMetrics.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, operationalRouteMetrics, nil))
+	require.Contains(testingT, recorder.Body.String(), `loopaware_notifications_total{kind="email",outcome="sent"} 1`)
+	require.Contains(testingT, recorder.Body.String(), `loopaware_notifications_total{kind="email",outcome="failed"} 1`)
+}
+
+func TestRunCommandShutsDownGracefullyOnSignal(testingT *testing.T) {
+	listener := startPinguinServer(testingT)
+	setRequiredEnvironment(testingT, testPinguinAddress)
+	testingT.Setenv(environmentKeyShutdownTimeout, "5")
+
+	httpListener, listenErr := net.Listen("tcp", "127.0.0.1:0")
+	if listenErr != nil {
+		testingT.Skip("server listen not permitted in sandbox")
+	}
+
+	shutdownContext, triggerShutdown := context.WithCancel(context.Background())
+	defer triggerShutdown()
+	application := NewServerApplication()
+	application.WithPinguinDialer(createPinguinDialer(listener))
+	application.WithShutdownSignal(func(parent context.Context) (context.Context, context.CancelFunc) {
+		return shutdownContext, func() {}
+	})
+	command, commandErr := application.Command()
+	require.NoError(testingT, commandErr)
+
+	healthStatus := make(chan int, 1)
+	var runnerErr error
+	application.WithServerRunner(func(server *http.Server) error {
+		go func() {
+			defer triggerShutdown()
+			response, requestErr := http.Get("http://" + httpListener.Addr().String() + operationalRouteHealthz)
+			if requestErr != nil {
+				healthStatus <- 0
+				return
+			}
+			_ = response.Body.Close()
+			healthStatus <- response.StatusCode
+		}()
+		runnerErr = server.Serve(httpListener)
+		return runnerErr
+	})
+
+	require.NoError(testingT, application.runCommand(command, nil))
+	require.Equal(testingT, http.StatusOK, <-healthStatus)
+	require.ErrorIs(testingT, runnerErr, http.ErrServerClosed)
+	_, dialErr := net.DialTimeout("tcp", httpListener.Addr().String(), time.Second)
+	require.Error(testingT, dialErr)
+}
+
+type blockingEmailSender struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (sender blockingEmailSender) SendEmail(context.Context, string, string, string) error {
+	close(sender.started)
+	<-sender.release
+	return nil
+}
+
+func TestInFlightWorkWaitsForActiveDeliveries(testingT *testing.T) {
+	work := &inFlightWork{}
+	require.NoError(testingT, work.wait(context.Background()))
+
+	sender := blockingEmailSender{started: make(chan struct{}), release: make(chan struct{})}
+	delivery := instrumentEmailDelivery(emailDelivery{emailSender: sender}, nil, work)
+	sendDone := make(chan error, 1)
+	go func() {
+		sendDone <- delivery.emailSender.SendEmail(context.Background(), "owner@example.com", "Digest", "body")
+	}()
+	<-sender.started
+	require.Equal(testingT, 1, work.pending())
+
+	expiredContext, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(testingT, work.wait(expiredContext), context.DeadlineExceeded)
+
+	waitDone := make(chan error, 1)
+	go func() {
+		waitDone <- work.wait(context.Background())
+	}()
+	close(sender.release)
+	require.NoError(testingT, <-sendDone)
+	require.NoError(testingT, <-waitDone)
+	require.Zero(testingT, work.pending())
+}
+
+func TestEnsureRequiredConfigurationValidatesTracingExporter(testingT *testing.T) {
+	application := NewServerApplication()
+	config := ServerConfig{
+		DatabaseDriverName:     storage.DriverNameSQLite,
+		DatabaseDataSourceName: testDatabaseDSNValue,
+		SessionSecret:          testSessionSecretValue,
+		TauthBaseURL:           testTauthBaseURLValue,
+		TauthTenantID:          testTauthTenantIDValue,
+		TauthSigningKey:        testTauthSigningKeyValue,
+		PublicBaseURL:          testPublicBaseURLValue,
+		EmailBackend:           emailBackendSMTP,
+		SMTPHost:               "smtp.example.com",
+		SMTPPort:               defaultSMTPPort,
+		SMTPFrom:               "noreply@example.com",
+		TracingExporter:        tracing.ExporterOTLPGRPC,
+	}
+	require.NoError(testingT, application.ensureRequiredConfiguration(config))
+
+	config.TracingExporter = "zipkin"
+	require.ErrorContains(testingT, application.ensureRequiredConfiguration(config), flagNameTracingExporter)
+}
+
+func TestEnsureRequiredConfigurationValidatesLogging(testingT *testing.T) {
+	application := NewServerApplication()
+	config := ServerConfig{
+		DatabaseDriverName:     storage.DriverNameSQLite,
+		DatabaseDataSourceName: testDatabaseDSNValue,
+		SessionSecret:          testSessionSecretValue,
+		TauthBaseURL:           testTauthBaseURLValue,
+		TauthTenantID:          testTauthTenantIDValue,
+		TauthSigningKey:        testTauthSigningKeyValue,
+		PublicBaseURL:          testPublicBaseURLValue,
+		EmailBackend:           emailBackendSMTP,
+		SMTPHost:               "smtp.example.com",
+		SMTPPort:               defaultSMTPPort,
+		SMTPFrom:               "noreply@example.com",
+		LogLevel:               "debug",
+		LogFormat:              logging.FormatConsole,
+		LogRedaction:           logging.RedactionNone,
+	}
+	require.NoError(testingT, application.ensureRequiredConfiguration(config))
+
+	config.LogLevel = "verbose"
+	config.LogFormat = "logfmt"
+	config.LogRedaction = "mask"
+	validationErr := application.ensureRequiredConfiguration(config)
+	require.ErrorContains(testingT, validationErr, flagNameLogLevel)
+	require.ErrorContains(testingT, validationErr, flagNameLogFormat)
+	require.ErrorContains(testingT, validationErr, flagNameLogRedaction)
+}
+
+func TestTracedRequestSkipsOperationalRoutes(testingT *testing.T) {
+	for _, path := range []string{operationalRouteHealthz, operationalRouteReadyz, operationalRouteMetrics} {
+		require.False(testingT, tracedRequest(httptest.NewRequest(http.MethodGet, path, nil)), path)
+	}
+	require.True(testingT, tracedRequest(httptest.NewRequest(http.MethodPost, publicRouteFeedback, nil)))
+}
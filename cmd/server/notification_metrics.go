@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+
+	"github.com/MarkoPoloResearchLab/loopaware/internal/api"
+	"github.com/MarkoPoloResearchLab/loopaware/internal/mailer"
+	"github.com/MarkoPoloResearchLab/loopaware/internal/metrics"
+	"github.com/MarkoPoloResearchLab/loopaware/internal/model"
+)
+
+// instrumentEmailDelivery wraps every notifier and the email sender so that each delivery attempt is counted by
+// kind and outcome and tracked in work while it runs, whichever component sends it.
+func instrumentEmailDelivery(delivery emailDelivery, instance *metrics.Metrics, work *inFlightWork) emailDelivery {
+	if instance == nil && work == nil {
+		return delivery
+	}
+	instrumented := delivery
+	if delivery.feedbackNotifier != nil {
+		instrumented.feedbackNotifier = instrumentedFeedbackNotifier{next: delivery.feedbackNotifier, metrics: instance, work: work}
+	}
+	if delivery.subscriptionNotifier != nil {
+		instrumented.subscriptionNotifier = instrumentedSubscriptionNotifier{next: delivery.subscriptionNotifier, metrics: instance, work: work}
+	}
+	if delivery.trafficAlertNotifier != nil {
+		instrumented.trafficAlertNotifier = instrumentedTrafficAlertNotifier{next: delivery.trafficAlertNotifier, metrics: instance, work: work}
+	}
+	if delivery.emailSender != nil {
+		instrumented.emailSender = instrumentedEmailSender{next: delivery.emailSender, metrics: instance, work: work}
+	}
+	return instrumented
+}
+
+func notificationOutcome(err error) string {
+	if err != nil {
+		return metrics.OutcomeFailed
+	}
+	return metrics.OutcomeSent
+}
+
+type instrumentedFeedbackNotifier struct {
+	next    api.FeedbackNotifier
+	metrics *metrics.Metrics
+	work    *inFlightWork
+}
+
+func (notifier instrumentedFeedbackNotifier) NotifyFeedback(ctx context.Context, site model.Site, feedback model.Feedback) (string, error) {
+	notifier.work.begin()
+	defer notifier.work.end()
+	delivery, notifyErr := notifier.next.NotifyFeedback(ctx, site, feedback)
+	outcome := notificationOutcome(notifyErr)
+	if notifyErr == nil && delivery == model.FeedbackDeliveryNone {
+		outcome = metrics.OutcomeSkipped
+	}
+	notifier.metrics.ObserveNotification(metrics.NotificationFeedback, outcome)
+	return delivery, notifyErr
+}
+
+type instrumentedSubscriptionNotifier struct {
+	next    api.SubscriptionNotifier
+	metrics *metrics.Metrics
+	work    *inFlightWork
+}
+
+func (notifier instrumentedSubscriptionNotifier) NotifySubscription(ctx context.Context, site model.Site, subscriber model.Subscriber) error {
+	notifier.work.begin()
+	defer notifier.work.end()
+	notifyErr := notifier.next.NotifySubscription(ctx, site, subscriber)
+	notifier.metrics.ObserveNotification(metrics.NotificationSubscription, notificationOutcome(notifyErr))
+	return notifyErr
+}
+
+type instrumentedTrafficAlertNotifier struct {
+	next    api.TrafficAlertNotifier
+	metrics *metrics.Metrics
+	work    *inFlightWork
+}
+
+func (notifier instrumentedTrafficAlertNotifier) NotifyTrafficAlert(ctx context.Context, site model.Site, alert model.TrafficAlert) error {
+	notifier.work.begin()
+	defer notifier.work.end()
+	notifyErr := notifier.next.NotifyTrafficAlert(ctx, site, alert)
+	notifier.metrics.ObserveNotification(metrics.NotificationTrafficAlert, notificationOutcome(notifyErr))
+	return notifyErr
+}
+
+// instrumentedEmailSender keeps the multipart path of senders that implement api.EmailMessageSender.
+type instrumentedEmailSender struct {
+	next    api.EmailSender
+	metrics *metrics.Metrics
+	work    *inFlightWork
+}
+
+func (sender instrumentedEmailSender) SendEmail(ctx context.Context, recipient string, subject string, message string) error {
+	sender.work.begin()
+	defer sender.work.end()
+	sendErr := sender.next.SendEmail(ctx, recipient, subject, message)
+	sender.metrics.ObserveNotification(metrics.NotificationEmail, notificationOutcome(sendErr))
+	return sendErr
+}
+
+func (sender instrumentedEmailSender) SendEmailMessage(ctx context.Context, message mailer.Message) error {
+	sender.work.begin()
+	defer sender.work.end()
+	var sendErr error
+	if messageSender, ok := sender.next.(api.EmailMessageSender); ok {
+		sendErr = messageSender.SendEmailMessage(ctx, message)
+	} else {
+		sendErr = sender.next.SendEmail(ctx, message.Recipient, message.Subject, message.TextBody)
+	}
+	sender.metrics.ObserveNotification(metrics.NotificationEmail, notificationOutcome(sendErr))
+	return sendErr
+}
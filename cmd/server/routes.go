@@ -15,12 +15,20 @@ func isPublicAPIPath(path string) bool {
 	if path == "" {
 		return false
 	}
-	if path == publicRouteFeedback || path == "/public/widget-config" || path == publicRouteVisitPixel {
+	if path == publicRouteFeedback || path == publicRouteFeedbackAttachments || path == "/public/widget-config" || path == publicRouteVisitPixel {
 		return true
 	}
 	return strings.HasPrefix(path, publicRouteSubscription)
 }
 
+// registerOperationalRoutes exposes the orchestrator probes and the Prometheus scrape endpoint outside the
+// authenticated API.
+func registerOperationalRoutes(router *gin.Engine, healthHandlers *api.HealthHandlers, metricsHandler http.Handler) {
+	router.GET(operationalRouteHealthz, healthHandlers.Healthz)
+	router.GET(operationalRouteReadyz, healthHandlers.Readyz)
+	router.GET(operationalRouteMetrics, gin.WrapH(metricsHandler))
+}
+
 func registerAPIPreflightRoutes(router *gin.Engine, publicCORS gin.HandlerFunc, authenticatedCORS gin.HandlerFunc) {
 	preflightHandler := func(context *gin.Context) {
 		requestPath := context.Request.URL.Path
@@ -39,14 +47,27 @@ func registerAPIPreflightRoutes(router *gin.Engine, publicCORS gin.HandlerFunc,
 	router.OPTIONS(publicRoutePrefix+"/*path", preflightHandler)
 }
 
+// registerBackendRoutes mounts the public collection routes on publicRouter and the authenticated API on apiRouter;
+// both are the same engine unless PUBLIC_ADDR gives the public routes their own listener.
 func registerBackendRoutes(
-	router *gin.Engine,
+	publicRouter *gin.Engine,
+	apiRouter *gin.Engine,
 	authManager *api.AuthManager,
 	publicHandlers *api.PublicHandlers,
 	siteHandlers *api.SiteHandlers,
 	widgetTestHandlers *api.SiteWidgetTestHandlers,
 	subscribeTestHandlers *api.SiteSubscribeTestHandlers,
+	subscriberImportHandlers *api.SubscriberImportHandlers,
+	pendingSubscriberHandlers *api.PendingSubscriberHandlers,
+	campaignHandlers *api.CampaignHandlers,
+	emailTemplateHandlers *api.EmailTemplateHandlers,
+	feedbackInsightsHandlers *api.FeedbackInsightsHandlers,
+	feedbackSearchHandlers *api.FeedbackSearchHandlers,
+	digestHandlers *api.DigestHandlers,
+	trafficAlertHandlers *api.TrafficAlertHandlers,
+	jobHandlers *api.JobHandlers,
 	authenticatedOrigin string,
+	downloadWriteTimeout time.Duration,
 ) {
 	publicCORS := cors.New(cors.Config{
 		AllowOrigins:     []string{corsOriginWildcard},
@@ -65,43 +86,90 @@ func registerBackendRoutes(
 		MaxAge:           12 * time.Hour,
 	})
 
-	registerAPIPreflightRoutes(router, publicCORS, authenticatedCORS)
+	registerAPIPreflightRoutes(apiRouter, publicCORS, authenticatedCORS)
+	if publicRouter != apiRouter {
+		registerAPIPreflightRoutes(publicRouter, publicCORS, authenticatedCORS)
+	}
 
-	publicGroup := router.Group("/")
+	publicGroup := publicRouter.Group("/")
 	publicGroup.Use(publicCORS)
 	publicGroup.POST(publicRouteFeedback, publicHandlers.CreateFeedback)
+	publicGroup.POST(publicRouteFeedbackAttachments, publicHandlers.CreateFeedbackAttachment)
 	publicGroup.POST(publicRouteSubscription, publicHandlers.CreateSubscription)
 	publicGroup.POST(publicRouteSubscriptionConfirm, publicHandlers.ConfirmSubscription)
 	publicGroup.POST(publicRouteSubscriptionOptOut, publicHandlers.Unsubscribe)
 	publicGroup.GET("/public/widget-config", publicHandlers.WidgetConfig)
 	publicGroup.GET("/public/subscriptions/confirm-link", publicHandlers.ConfirmSubscriptionLinkJSON)
 	publicGroup.GET("/public/subscriptions/unsubscribe-link", publicHandlers.UnsubscribeSubscriptionLinkJSON)
+	publicGroup.POST("/public/subscriptions/unsubscribe-link", publicHandlers.UnsubscribeSubscriptionLinkJSON)
 	publicGroup.GET(publicRouteVisitPixel, publicHandlers.CollectVisit)
 	publicGroup.POST(publicRouteVisitPixel, publicHandlers.CollectVisit)
+	publicGroup.GET(publicRouteDigestUnsubscribe, digestHandlers.Unsubscribe)
+	publicGroup.POST(publicRouteDigestUnsubscribe, digestHandlers.Unsubscribe)
 
-	apiGroup := router.Group(apiRoutePrefix)
+	// Event streams stay open indefinitely; exports and attachment downloads may outlive HTTP_WRITE_TIMEOUT_SECONDS
+	// but must keep making progress, so each chunk gets that long to be written.
+	streamDeadlines := api.WithoutConnectionDeadlines()
+	downloadDeadlines := api.WithRollingWriteDeadline(downloadWriteTimeout)
+	apiGroup := apiRouter.Group(apiRoutePrefix)
 	apiGroup.Use(authenticatedCORS)
 	apiGroup.Use(authManager.RequireAuthenticatedJSON())
 	apiGroup.GET(apiRouteMe, siteHandlers.CurrentUser)
 	apiGroup.GET(apiRouteMeAvatar, siteHandlers.UserAvatar)
+	apiGroup.GET(apiRouteMeDigest, digestHandlers.Preference)
+	apiGroup.PATCH(apiRouteMeDigest, digestHandlers.UpdatePreference)
 	apiGroup.GET(apiRouteSites, siteHandlers.ListSites)
 	apiGroup.POST(apiRouteSites, siteHandlers.CreateSite)
 	apiGroup.PATCH(apiRouteSiteUpdate, siteHandlers.UpdateSite)
 	apiGroup.DELETE(apiRouteSiteUpdate, siteHandlers.DeleteSite)
 	apiGroup.GET(apiRouteSiteMessages, siteHandlers.ListMessagesBySite)
+	apiGroup.GET(apiRouteSiteMessagesExport, downloadDeadlines, siteHandlers.ExportMessages)
+	apiGroup.GET(apiRouteSiteAttachment, downloadDeadlines, siteHandlers.FeedbackAttachment)
+	apiGroup.GET(apiRouteSiteFeedbackRatings, feedbackInsightsHandlers.RatingSummary)
+	apiGroup.GET(apiRouteSiteFeedbackNPS, feedbackInsightsHandlers.NPSSummary)
+	apiGroup.GET(apiRouteSiteFeedbackCategories, feedbackInsightsHandlers.CategorySummary)
+	apiGroup.GET(apiRouteFeedbackSearch, feedbackSearchHandlers.Search)
 	apiGroup.GET(apiRouteSiteSubscribers, siteHandlers.ListSubscribers)
-	apiGroup.GET(apiRouteSiteSubscribersExport, siteHandlers.ExportSubscribers)
+	apiGroup.GET(apiRouteSiteSubscribersExport, downloadDeadlines, siteHandlers.ExportSubscribers)
+	apiGroup.POST(apiRouteSiteSubscribersImport, subscriberImportHandlers.ImportSubscribers)
+	apiGroup.GET(apiRouteSiteSubscribersImportJob, subscriberImportHandlers.SubscriberImportStatus)
 	apiGroup.PATCH(apiRouteSiteSubscriberUpdate, siteHandlers.UpdateSubscriberStatus)
 	apiGroup.DELETE(apiRouteSiteSubscriberUpdate, siteHandlers.DeleteSubscriber)
+	apiGroup.POST(apiRouteSiteSubscriberResend, pendingSubscriberHandlers.ResendSubscriberConfirmation)
+	apiGroup.GET(apiRouteSiteCampaigns, campaignHandlers.ListCampaigns)
+	apiGroup.POST(apiRouteSiteCampaigns, campaignHandlers.CreateCampaign)
+	apiGroup.GET(apiRouteSiteCampaign, campaignHandlers.GetCampaign)
+	apiGroup.PATCH(apiRouteSiteCampaign, campaignHandlers.UpdateCampaign)
+	apiGroup.DELETE(apiRouteSiteCampaign, campaignHandlers.DeleteCampaign)
+	apiGroup.POST(apiRouteSiteCampaignSend, campaignHandlers.SendCampaign)
+	apiGroup.POST(apiRouteSiteCampaignCancel, campaignHandlers.CancelCampaign)
+	apiGroup.GET(apiRouteSiteCampaignDeliveries, campaignHandlers.ListCampaignDeliveries)
+	apiGroup.GET(apiRouteSiteEmailTemplates, emailTemplateHandlers.ListEmailTemplates)
+	apiGroup.PUT(apiRouteSiteEmailTemplate, emailTemplateHandlers.SaveEmailTemplate)
+	apiGroup.DELETE(apiRouteSiteEmailTemplate, emailTemplateHandlers.DeleteEmailTemplate)
+	apiGroup.POST(apiRouteSiteEmailTemplatePreview, emailTemplateHandlers.PreviewEmailTemplate)
 	apiGroup.GET(apiRouteSiteFavicon, siteHandlers.SiteFavicon)
-	apiGroup.GET(apiRouteSiteFaviconEvents, siteHandlers.StreamFaviconUpdates)
-	apiGroup.GET(apiRouteSiteFeedbackEvents, siteHandlers.StreamFeedbackUpdates)
+	apiGroup.GET(apiRouteSiteFaviconEvents, streamDeadlines, siteHandlers.StreamFaviconUpdates)
+	apiGroup.GET(apiRouteSiteFeedbackEvents, streamDeadlines, siteHandlers.StreamFeedbackUpdates)
 	apiGroup.GET(apiRouteSiteVisitStats, siteHandlers.VisitStats)
 	apiGroup.GET(apiRouteSiteVisitTrend, siteHandlers.VisitTrend)
 	apiGroup.GET(apiRouteSiteVisitAttribution, siteHandlers.VisitAttribution)
 	apiGroup.GET(apiRouteSiteVisitEngagement, siteHandlers.VisitEngagement)
+	apiGroup.GET(apiRouteSiteVisitsExport, downloadDeadlines, siteHandlers.ExportVisits)
+	apiGroup.GET(apiRouteSiteVisitRollupsExport, downloadDeadlines, siteHandlers.ExportVisitRollups)
+	apiGroup.GET(apiRouteSiteVisitsLive, streamDeadlines, siteHandlers.StreamLiveVisitors)
+	apiGroup.GET(apiRouteSiteTrafficAlerts, trafficAlertHandlers.ListAlerts)
+	apiGroup.GET(apiRouteSiteTrafficAlertRules, trafficAlertHandlers.ListRules)
+	apiGroup.POST(apiRouteSiteTrafficAlertRules, trafficAlertHandlers.CreateRule)
+	apiGroup.PATCH(apiRouteSiteTrafficAlertRule, trafficAlertHandlers.UpdateRule)
+	apiGroup.DELETE(apiRouteSiteTrafficAlertRule, trafficAlertHandlers.DeleteRule)
+	apiGroup.POST(apiRouteSiteTrafficAlertSnooze, trafficAlertHandlers.SnoozeRule)
+	apiGroup.GET(apiRouteAdminJobs, jobHandlers.ListJobs)
+	apiGroup.POST(apiRouteAdminJobPause, jobHandlers.PauseJob)
+	apiGroup.POST(apiRouteAdminJobResume, jobHandlers.ResumeJob)
+	apiGroup.POST(apiRouteAdminJobTrigger, jobHandlers.TriggerJob)
 
 	apiGroup.POST("/sites/:id/widget-test/feedback", widgetTestHandlers.SubmitWidgetTestFeedback)
-	apiGroup.GET("/sites/:id/subscribe-test/events", subscribeTestHandlers.StreamSubscriptionTestEvents)
+	apiGroup.GET("/sites/:id/subscribe-test/events", streamDeadlines, subscribeTestHandlers.StreamSubscriptionTestEvents)
 	apiGroup.POST("/sites/:id/subscribe-test/subscriptions", subscribeTestHandlers.CreateSubscription)
 }
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+// ShutdownSignal derives a context that is canceled when the server should begin a graceful shutdown.
+type ShutdownSignal func(context.Context) (context.Context, context.CancelFunc)
+
+func notifyShutdownSignals(parent context.Context) (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(parent, os.Interrupt, syscall.SIGTERM)
+}
+
+// inFlightWork counts notification deliveries that are still running so shutdown can wait for them before the
+// backend connection is closed. Unlike sync.WaitGroup it allows new work to begin while a waiter is blocked.
+type inFlightWork struct {
+	mutex  sync.Mutex
+	active int
+	idle   chan struct{}
+}
+
+func (work *inFlightWork) begin() {
+	if work == nil {
+		return
+	}
+	work.mutex.Lock()
+	defer work.mutex.Unlock()
+	work.active++
+	if work.idle == nil {
+		work.idle = make(chan struct{})
+	}
+}
+
+func (work *inFlightWork) end() {
+	if work == nil {
+		return
+	}
+	work.mutex.Lock()
+	defer work.mutex.Unlock()
+	work.active--
+	if work.active == 0 && work.idle != nil {
+		close(work.idle)
+		work.idle = nil
+	}
+}
+
+// pending reports how many deliveries are running.
+func (work *inFlightWork) pending() int {
+	if work == nil {
+		return 0
+	}
+	work.mutex.Lock()
+	defer work.mutex.Unlock()
+	return work.active
+}
+
+// wait blocks until no delivery is running or ctx is done.
+func (work *inFlightWork) wait(ctx context.Context) error {
+	if work == nil {
+		return nil
+	}
+	work.mutex.Lock()
+	idle := work.idle
+	work.mutex.Unlock()
+	if idle == nil {
+		return nil
+	}
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -18,6 +18,7 @@ import (
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 
+	"github.com/MarkoPoloResearchLab/loopaware/internal/blobstore"
 	"github.com/MarkoPoloResearchLab/loopaware/internal/model"
 	"github.com/MarkoPoloResearchLab/loopaware/internal/storage"
 )
@@ -42,6 +43,7 @@ const (
 	errorValueInvalidOwner            = "invalid_owner"
 	errorValueInvalidWidgetSide       = "invalid_widget_side"
 	errorValueInvalidWidgetOffset     = "invalid_widget_offset"
+	errorValueInvalidWidgetConfig     = "invalid_widget_config"
 	errorValueInvalidSubscriberStatus = "invalid_subscriber_status"
 	errorValueNothingToUpdate         = "nothing_to_update"
 	errorValueDeleteFailed            = "delete_failed"
@@ -49,6 +51,7 @@ const (
 	errorValueStreamUnavailable       = "stream_unavailable"
 	errorValueInvalidDays             = "invalid_days"
 	errorValueInvalidLimit            = "invalid_limit"
+	errorValueInvalidFaviconSize      = "invalid_favicon_size"
 
 	widgetScriptTemplate            = "<script defer src=\"%s\"></script>"
 	widgetScriptPath                = "/widget.js"
@@ -82,6 +85,10 @@ type SiteHandlers struct {
 	faviconManager      *SiteFaviconManager
 	statsProvider       SiteStatisticsProvider
 	feedbackBroadcaster *FeedbackEventBroadcaster
+	attachmentStore     blobstore.Store
+	visitorPresence     *VisitorPresenceTracker
+
+	streamHeartbeatInterval time.Duration
 }
 
 func NewSiteHandlers(database *gorm.DB, logger *zap.Logger, widgetBaseURL string, faviconManager *SiteFaviconManager, statsProvider SiteStatisticsProvider, feedbackBroadcaster *FeedbackEventBroadcaster) *SiteHandlers {
@@ -95,48 +102,59 @@ func NewSiteHandlers(database *gorm.DB, logger *zap.Logger, widgetBaseURL string
 		faviconManager:      faviconManager,
 		statsProvider:       statsProvider,
 		feedbackBroadcaster: feedbackBroadcaster,
+
+		streamHeartbeatInterval: defaultEventStreamHeartbeatInterval,
 	}
 }
 
 type createSiteRequest struct {
-	Name                     string `json:"name"`
-	AllowedOrigin            string `json:"allowed_origin"`
-	SubscribeAllowedOrigins  string `json:"subscribe_allowed_origins"`
-	WidgetAllowedOrigins     string `json:"widget_allowed_origins"`
-	TrafficAllowedOrigins    string `json:"traffic_allowed_origins"`
-	OwnerEmail               string `json:"owner_email"`
-	WidgetBubbleSide         string `json:"widget_bubble_side"`
-	WidgetBubbleBottomOffset *int   `json:"widget_bubble_bottom_offset"`
+	Name                     string              `json:"name"`
+	AllowedOrigin            string              `json:"allowed_origin"`
+	SubscribeAllowedOrigins  string              `json:"subscribe_allowed_origins"`
+	WidgetAllowedOrigins     string              `json:"widget_allowed_origins"`
+	TrafficAllowedOrigins    string              `json:"traffic_allowed_origins"`
+	OwnerEmail               string              `json:"owner_email"`
+	WidgetBubbleSide         string              `json:"widget_bubble_side"`
+	WidgetBubbleBottomOffset *int                `json:"widget_bubble_bottom_offset"`
+	WidgetConfig             *model.WidgetConfig `json:"widget_config"`
 }
 
 type updateSiteRequest struct {
-	Name                     *string `json:"name"`
-	AllowedOrigin            *string `json:"allowed_origin"`
-	SubscribeAllowedOrigins  *string `json:"subscribe_allowed_origins"`
-	WidgetAllowedOrigins     *string `json:"widget_allowed_origins"`
-	TrafficAllowedOrigins    *string `json:"traffic_allowed_origins"`
-	OwnerEmail               *string `json:"owner_email"`
-	WidgetBubbleSide         *string `json:"widget_bubble_side"`
-	WidgetBubbleBottomOffset *int    `json:"widget_bubble_bottom_offset"`
+	Name                     *string             `json:"name"`
+	AllowedOrigin            *string             `json:"allowed_origin"`
+	SubscribeAllowedOrigins  *string             `json:"subscribe_allowed_origins"`
+	WidgetAllowedOrigins     *string             `json:"widget_allowed_origins"`
+	TrafficAllowedOrigins    *string             `json:"traffic_allowed_origins"`
+	OwnerEmail               *string             `json:"owner_email"`
+	WidgetBubbleSide         *string             `json:"widget_bubble_side"`
+	WidgetBubbleBottomOffset *int                `json:"widget_bubble_bottom_offset"`
+	WidgetConfig             *model.WidgetConfig `json:"widget_config"`
 }
 
 type siteResponse struct {
-	ID                       string `json:"id"`
-	Name                     string `json:"name"`
-	AllowedOrigin            string `json:"allowed_origin"`
-	SubscribeAllowedOrigins  string `json:"subscribe_allowed_origins"`
-	WidgetAllowedOrigins     string `json:"widget_allowed_origins"`
-	TrafficAllowedOrigins    string `json:"traffic_allowed_origins"`
-	OwnerEmail               string `json:"owner_email"`
-	FaviconURL               string `json:"favicon_url"`
-	Widget                   string `json:"widget"`
-	CreatedAt                int64  `json:"created_at"`
-	FeedbackCount            int64  `json:"feedback_count"`
-	SubscriberCount          int64  `json:"subscriber_count"`
-	VisitCount               int64  `json:"visit_count"`
-	UniqueVisitorCount       int64  `json:"unique_visitor_count"`
-	WidgetBubbleSide         string `json:"widget_bubble_side"`
-	WidgetBubbleBottomOffset int    `json:"widget_bubble_bottom_offset"`
+	ID                       string             `json:"id"`
+	Name                     string             `json:"name"`
+	AllowedOrigin            string             `json:"allowed_origin"`
+	SubscribeAllowedOrigins  string             `json:"subscribe_allowed_origins"`
+	WidgetAllowedOrigins     string             `json:"widget_allowed_origins"`
+	TrafficAllowedOrigins    string             `json:"traffic_allowed_origins"`
+	OwnerEmail               string             `json:"owner_email"`
+	FaviconURL               string             `json:"favicon_url"`
+	FaviconSource            string             `json:"favicon_source,omitempty"`
+	FaviconSourceReason      string             `json:"favicon_source_reason,omitempty"`
+	PageTitle                string             `json:"page_title,omitempty"`
+	PageDescription          string             `json:"page_description,omitempty"`
+	PageImageURL             string             `json:"page_image_url,omitempty"`
+	PageCanonicalURL         string             `json:"page_canonical_url,omitempty"`
+	Widget                   string             `json:"widget"`
+	CreatedAt                int64              `json:"created_at"`
+	FeedbackCount            int64              `json:"feedback_count"`
+	SubscriberCount          int64              `json:"subscriber_count"`
+	VisitCount               int64              `json:"visit_count"`
+	UniqueVisitorCount       int64              `json:"unique_visitor_count"`
+	WidgetBubbleSide         string             `json:"widget_bubble_side"`
+	WidgetBubbleBottomOffset int                `json:"widget_bubble_bottom_offset"`
+	WidgetConfig             model.WidgetConfig `json:"widget_config"`
 }
 
 type listSitesResponse struct {
@@ -164,13 +182,19 @@ type SubscriberRecord struct {
 }
 
 type feedbackMessageResponse struct {
-	ID        string `json:"id"`
-	Contact   string `json:"contact"`
-	Message   string `json:"message"`
-	IP        string `json:"ip"`
-	UserAgent string `json:"user_agent"`
-	CreatedAt int64  `json:"created_at"`
-	Delivery  string `json:"delivery"`
+	ID          string                       `json:"id"`
+	Contact     string                       `json:"contact"`
+	Message     string                       `json:"message"`
+	ExtraField  string                       `json:"extra_field,omitempty"`
+	Rating      *int                         `json:"rating,omitempty"`
+	NPSScore    *int                         `json:"nps_score,omitempty"`
+	Category    string                       `json:"category,omitempty"`
+	PageURL     string                       `json:"page_url,omitempty"`
+	Attachments []feedbackAttachmentResponse `json:"attachments,omitempty"`
+	IP          string                       `json:"ip"`
+	UserAgent   string                       `json:"user_agent"`
+	CreatedAt   int64                        `json:"created_at"`
+	Delivery    string                       `json:"delivery"`
 }
 
 type VisitStatsResponse struct {
@@ -310,10 +334,19 @@ func (handlers *SiteHandlers) CreateSite(context *gin.Context) {
 		context.JSON(http.StatusBadRequest, gin.H{jsonKeyError: errorValueInvalidWidgetOffset})
 		return
 	}
+	var widgetConfig model.WidgetConfig
+	if payload.WidgetConfig != nil {
+		normalizedConfig, configErr := model.NewWidgetConfig(*payload.WidgetConfig)
+		if configErr != nil {
+			respondInvalidWidgetConfig(context, configErr)
+			return
+		}
+		widgetConfig = normalizedConfig
+	}
 
 	conflictExists, conflictCheckErr := handlers.allowedOriginConflictExists(payload.AllowedOrigin, "")
 	if conflictCheckErr != nil {
-		handlers.logger.Warn("check_allowed_origin_conflict", zap.Error(conflictCheckErr))
+		requestLogger(context, handlers.logger).Warn("check_allowed_origin_conflict", zap.Error(conflictCheckErr))
 		context.JSON(http.StatusInternalServerError, gin.H{jsonKeyError: errorValueQueryFailed})
 		return
 	}
@@ -334,10 +367,11 @@ func (handlers *SiteHandlers) CreateSite(context *gin.Context) {
 		FaviconOrigin:              primaryAllowedOrigin(payload.AllowedOrigin),
 		WidgetBubbleSide:           widgetBubbleSide,
 		WidgetBubbleBottomOffsetPx: widgetBubbleBottomOffset,
+		WidgetConfig:               widgetConfig,
 	}
 
 	if err := handlers.database.Create(&site).Error; err != nil {
-		handlers.logger.Warn("create_site", zap.Error(err))
+		requestLogger(context, handlers.logger).Warn("create_site", zap.Error(err))
 		context.JSON(http.StatusInternalServerError, gin.H{jsonKeyError: errorValueSaveFailed})
 		return
 	}
@@ -399,7 +433,7 @@ func (handlers *SiteHandlers) UserAvatar(context *gin.Context) {
 			context.AbortWithStatus(http.StatusNotFound)
 			return
 		}
-		handlers.logger.Warn("load_user_avatar", zap.Error(err))
+		requestLogger(context, handlers.logger).Warn("load_user_avatar", zap.Error(err))
 		context.JSON(http.StatusInternalServerError, gin.H{jsonKeyError: errorValueQueryFailed})
 		return
 	}
@@ -441,7 +475,14 @@ func (handlers *SiteHandlers) SiteFavicon(context *gin.Context) {
 		return
 	}
 
-	if len(site.FaviconData) == 0 {
+	requestedSize, sizeOK := parseFaviconSize(context.Query(siteFaviconQuerySize))
+	if !sizeOK {
+		context.JSON(http.StatusBadRequest, gin.H{jsonKeyError: errorValueInvalidFaviconSize})
+		return
+	}
+
+	faviconData := selectFaviconVariant(site, requestedSize)
+	if len(faviconData) == 0 {
 		context.AbortWithStatus(http.StatusNotFound)
 		return
 	}
@@ -450,8 +491,18 @@ func (handlers *SiteHandlers) SiteFavicon(context *gin.Context) {
 	if contentType == "" {
 		contentType = "application/octet-stream"
 	}
+	entityTag := faviconEntityTag(faviconData)
 	context.Header("Cache-Control", "public, max-age=300")
-	context.Data(http.StatusOK, contentType, site.FaviconData)
+	context.Header("ETag", entityTag)
+	context.Header("X-Content-Type-Options", "nosniff")
+	if strings.Contains(strings.ToLower(contentType), "svg") {
+		context.Header("Content-Security-Policy", faviconSVGContentSecurityPolicy)
+	}
+	if entityTagMatches(context.GetHeader("If-None-Match"), entityTag) {
+		context.AbortWithStatus(http.StatusNotModified)
+		return
+	}
+	context.Data(http.StatusOK, contentType, faviconData)
 }
 
 func (handlers *SiteHandlers) StreamFaviconUpdates(ginContext *gin.Context) {
@@ -484,32 +535,47 @@ func (handlers *SiteHandlers) StreamFaviconUpdates(ginContext *gin.Context) {
 	ginContext.Writer.WriteHeaderNow()
 	flusher.Flush()
 
+	heartbeat := time.NewTicker(handlers.heartbeatInterval())
+	defer heartbeat.Stop()
 	requestContext := ginContext.Request.Context()
 
 	for {
 		select {
 		case <-requestContext.Done():
 			return
+		case <-heartbeat.C:
+			if !writeServerSentHeartbeat(ginContext.Writer, flusher) {
+				return
+			}
 		case event, ok := <-subscription.Events():
 			if !ok {
+				writeServerShutdownEvent(ginContext.Writer, flusher)
 				return
 			}
 			if !handlers.userCanAccessSite(context.Background(), currentUser, event.SiteID) {
 				continue
 			}
 			payload := struct {
-				SiteID     string `json:"site_id"`
-				FaviconURL string `json:"favicon_url"`
-				UpdatedAt  int64  `json:"updated_at"`
+				SiteID           string `json:"site_id"`
+				FaviconURL       string `json:"favicon_url"`
+				PageTitle        string `json:"page_title"`
+				PageDescription  string `json:"page_description"`
+				PageImageURL     string `json:"page_image_url"`
+				PageCanonicalURL string `json:"page_canonical_url"`
+				UpdatedAt        int64  `json:"updated_at"`
 			}{
-				SiteID:     event.SiteID,
-				FaviconURL: event.FaviconURL,
-				UpdatedAt:  event.UpdatedAt.UTC().Unix(),
+				SiteID:           event.SiteID,
+				FaviconURL:       event.FaviconURL,
+				PageTitle:        event.Title,
+				PageDescription:  event.Description,
+				PageImageURL:     event.ImageURL,
+				PageCanonicalURL: event.CanonicalURL,
+				UpdatedAt:        event.UpdatedAt.UTC().Unix(),
 			}
 			serializedPayload, marshalErr := json.Marshal(payload)
 			if marshalErr != nil {
 				if handlers.logger != nil {
-					handlers.logger.Debug("marshal_favicon_event_failed", zap.Error(marshalErr))
+					requestLogger(ginContext, handlers.logger).Debug("marshal_favicon_event_failed", zap.Error(marshalErr))
 				}
 				continue
 			}
@@ -523,7 +589,7 @@ func (handlers *SiteHandlers) StreamFaviconUpdates(ginContext *gin.Context) {
 			}
 			flusher.Flush()
 			if handlers.logger != nil {
-				handlers.logger.Debug(
+				requestLogger(ginContext, handlers.logger).Debug(
 					"stream_favicon_event",
 					zap.String("site_id", event.SiteID),
 					zap.String("favicon_url", event.FaviconURL),
@@ -533,6 +599,9 @@ func (handlers *SiteHandlers) StreamFaviconUpdates(ginContext *gin.Context) {
 	}
 }
 
+// StreamFeedbackUpdates streams feedback_created events for every site the caller manages, or for the
+// sites named in site_id. Events carry IDs so a reconnecting client resumes after Last-Event-ID from the
+// broadcaster's replay buffer; events that can no longer be delivered are reported as feedback_dropped.
 func (handlers *SiteHandlers) StreamFeedbackUpdates(ginContext *gin.Context) {
 	currentUser, ok := CurrentUserFromContext(ginContext)
 	if !ok {
@@ -543,7 +612,22 @@ func (handlers *SiteHandlers) StreamFeedbackUpdates(ginContext *gin.Context) {
 		ginContext.JSON(http.StatusServiceUnavailable, gin.H{jsonKeyError: errorValueStreamUnavailable})
 		return
 	}
-	subscription := handlers.feedbackBroadcaster.Subscribe()
+	siteFilter := make(map[string]struct{})
+	for _, siteID := range requestedStreamSiteIDs(ginContext) {
+		if !handlers.userCanAccessSite(ginContext.Request.Context(), currentUser, siteID) {
+			ginContext.JSON(http.StatusForbidden, gin.H{jsonKeyError: errorValueNotAuthorized})
+			return
+		}
+		siteFilter[siteID] = struct{}{}
+	}
+
+	var subscription *FeedbackEventSubscription
+	var replay FeedbackEventReplay
+	if lastEventID, resuming := parseLastEventID(ginContext); resuming {
+		subscription, replay = handlers.feedbackBroadcaster.Resume(lastEventID)
+	} else {
+		subscription = handlers.feedbackBroadcaster.Subscribe()
+	}
 	if subscription == nil {
 		ginContext.JSON(http.StatusServiceUnavailable, gin.H{jsonKeyError: errorValueStreamUnavailable})
 		return
@@ -563,6 +647,21 @@ func (handlers *SiteHandlers) StreamFeedbackUpdates(ginContext *gin.Context) {
 	ginContext.Writer.WriteHeaderNow()
 	flusher.Flush()
 
+	stream := &feedbackEventStream{
+		handlers:    handlers,
+		logger:      requestLogger(ginContext, handlers.logger),
+		writer:      ginContext.Writer,
+		flusher:     flusher,
+		currentUser: currentUser,
+		siteFilter:  siteFilter,
+		access:      make(map[string]feedbackStreamAccess),
+	}
+	if !stream.writeReplay(replay) {
+		return
+	}
+
+	heartbeat := time.NewTicker(handlers.heartbeatInterval())
+	defer heartbeat.Stop()
 	requestContext := ginContext.Request.Context()
 
 	for {
@@ -571,58 +670,144 @@ func (handlers *SiteHandlers) StreamFeedbackUpdates(ginContext *gin.Context) {
 			return
 		case event, ok := <-subscription.Events():
 			if !ok {
+				writeServerShutdownEvent(ginContext.Writer, flusher)
 				return
 			}
-			if event.SiteID == "" {
-				continue
-			}
-			if !handlers.userCanAccessSite(context.Background(), currentUser, event.SiteID) {
-				continue
-			}
-			createdAt := event.CreatedAt.UTC().Unix()
-			if createdAt <= 0 {
-				createdAt = time.Now().UTC().Unix()
-			}
-			payload := struct {
-				SiteID        string `json:"site_id"`
-				FeedbackID    string `json:"feedback_id,omitempty"`
-				CreatedAt     int64  `json:"created_at"`
-				FeedbackCount int64  `json:"feedback_count"`
-			}{
-				SiteID:        event.SiteID,
-				FeedbackID:    event.FeedbackID,
-				CreatedAt:     createdAt,
-				FeedbackCount: event.FeedbackCount,
+			if !stream.recoverDropped(subscription) {
+				return
 			}
-			serializedPayload, marshalErr := json.Marshal(payload)
-			if marshalErr != nil {
-				if handlers.logger != nil {
-					handlers.logger.Debug("marshal_feedback_event_failed", zap.Error(marshalErr))
-				}
-				continue
+			if !stream.writeEvent(event) {
+				return
 			}
-			var buffer bytes.Buffer
-			buffer.WriteString("event: ")
-			buffer.WriteString(feedbackCreatedEventName)
-			buffer.WriteString("\n")
-			buffer.WriteString("data: ")
-			buffer.Write(serializedPayload)
-			buffer.WriteString("\n\n")
-			if _, writeErr := ginContext.Writer.Write(buffer.Bytes()); writeErr != nil {
+		case <-heartbeat.C:
+			if !stream.recoverDropped(subscription) {
 				return
 			}
-			flusher.Flush()
-			if handlers.logger != nil {
-				handlers.logger.Debug(
-					"stream_feedback_event",
-					zap.String("site_id", event.SiteID),
-					zap.String("feedback_id", event.FeedbackID),
-				)
+			if !writeServerSentHeartbeat(ginContext.Writer, flusher) {
+				return
 			}
 		}
 	}
 }
 
+type feedbackStreamAccess struct {
+	allowed   bool
+	checkedAt time.Time
+}
+
+// feedbackEventStream tracks the state of one feedback SSE connection.
+type feedbackEventStream struct {
+	handlers    *SiteHandlers
+	logger      *zap.Logger
+	writer      http.ResponseWriter
+	flusher     http.Flusher
+	currentUser *CurrentUser
+	siteFilter  map[string]struct{}
+	access      map[string]feedbackStreamAccess
+	lastSentID  int64
+}
+
+// recoverDropped replays from the buffer whatever the subscription missed while the client was slow.
+func (stream *feedbackEventStream) recoverDropped(subscription *FeedbackEventSubscription) bool {
+	droppedCount := subscription.Dropped()
+	if droppedCount == 0 {
+		return true
+	}
+	if stream.logger != nil {
+		stream.logger.Warn("feedback_stream_events_dropped", zap.Int64("dropped", droppedCount), zap.String("user_email", stream.currentUser.Email))
+	}
+	return stream.writeReplay(stream.handlers.feedbackBroadcaster.EventsAfter(stream.lastSentID))
+}
+
+func (stream *feedbackEventStream) writeReplay(replay FeedbackEventReplay) bool {
+	if replay.Missed > 0 {
+		payload, marshalErr := json.Marshal(struct {
+			Dropped     int64 `json:"dropped"`
+			LastEventID int64 `json:"last_event_id"`
+		}{
+			Dropped:     replay.Missed,
+			LastEventID: replay.LastEventID,
+		})
+		if marshalErr == nil && !writeServerSentEvent(stream.writer, stream.flusher, 0, feedbackDroppedEventName, payload) {
+			return false
+		}
+	}
+	for _, event := range replay.Events {
+		if !stream.writeEvent(event) {
+			return false
+		}
+	}
+	if replay.LastEventID > stream.lastSentID {
+		stream.lastSentID = replay.LastEventID
+	}
+	return true
+}
+
+func (stream *feedbackEventStream) writeEvent(event FeedbackEvent) bool {
+	if event.ID > 0 {
+		if event.ID <= stream.lastSentID {
+			return true
+		}
+		stream.lastSentID = event.ID
+	}
+	if event.SiteID == "" {
+		return true
+	}
+	if len(stream.siteFilter) > 0 {
+		if _, wanted := stream.siteFilter[event.SiteID]; !wanted {
+			return true
+		}
+	}
+	if !stream.canAccessSite(event.SiteID) {
+		return true
+	}
+	createdAt := event.CreatedAt.UTC().Unix()
+	if createdAt <= 0 {
+		createdAt = time.Now().UTC().Unix()
+	}
+	payload := struct {
+		SiteID        string `json:"site_id"`
+		FeedbackID    string `json:"feedback_id,omitempty"`
+		CreatedAt     int64  `json:"created_at"`
+		FeedbackCount int64  `json:"feedback_count"`
+	}{
+		SiteID:        event.SiteID,
+		FeedbackID:    event.FeedbackID,
+		CreatedAt:     createdAt,
+		FeedbackCount: event.FeedbackCount,
+	}
+	serializedPayload, marshalErr := json.Marshal(payload)
+	if marshalErr != nil {
+		if stream.logger != nil {
+			stream.logger.Debug("marshal_feedback_event_failed", zap.Error(marshalErr))
+		}
+		return true
+	}
+	if !writeServerSentEvent(stream.writer, stream.flusher, event.ID, feedbackCreatedEventName, serializedPayload) {
+		return false
+	}
+	if stream.logger != nil {
+		stream.logger.Debug(
+			"stream_feedback_event",
+			zap.String("site_id", event.SiteID),
+			zap.String("feedback_id", event.FeedbackID),
+		)
+	}
+	return true
+}
+
+// canAccessSite caches authorization per site for a short while so busy streams do not query the
+// database for every event, while still noticing revoked access.
+func (stream *feedbackEventStream) canAccessSite(siteID string) bool {
+	now := time.Now()
+	if cached, exists := stream.access[siteID]; exists && now.Sub(cached.checkedAt) < eventStreamAccessCacheTTL {
+		return cached.allowed
+	}
+	allowed := stream.handlers.userCanAccessSite(context.Background(), stream.currentUser, siteID)
+	stream.access[siteID] = feedbackStreamAccess{allowed: allowed, checkedAt: now}
+	return allowed
+}
+
 func (handlers *SiteHandlers) UpdateSite(context *gin.Context) {
 	siteIdentifier := strings.TrimSpace(context.Param("id"))
 	if siteIdentifier == "" {
@@ -642,7 +827,7 @@ func (handlers *SiteHandlers) UpdateSite(context *gin.Context) {
 		return
 	}
 
-	if payload.Name == nil && payload.AllowedOrigin == nil && payload.SubscribeAllowedOrigins == nil && payload.WidgetAllowedOrigins == nil && payload.TrafficAllowedOrigins == nil && payload.OwnerEmail == nil && payload.WidgetBubbleSide == nil && payload.WidgetBubbleBottomOffset == nil {
+	if payload.Name == nil && payload.AllowedOrigin == nil && payload.SubscribeAllowedOrigins == nil && payload.WidgetAllowedOrigins == nil && payload.TrafficAllowedOrigins == nil && payload.OwnerEmail == nil && payload.WidgetBubbleSide == nil && payload.WidgetBubbleBottomOffset == nil && payload.WidgetConfig == nil {
 		context.JSON(http.StatusBadRequest, gin.H{jsonKeyError: errorValueNothingToUpdate})
 		return
 	}
@@ -677,7 +862,7 @@ func (handlers *SiteHandlers) UpdateSite(context *gin.Context) {
 		if !strings.EqualFold(strings.TrimSpace(site.AllowedOrigin), trimmed) {
 			conflictExists, conflictCheckErr := handlers.allowedOriginConflictExists(trimmed, site.ID)
 			if conflictCheckErr != nil {
-				handlers.logger.Warn("check_allowed_origin_conflict", zap.Error(conflictCheckErr))
+				requestLogger(context, handlers.logger).Warn("check_allowed_origin_conflict", zap.Error(conflictCheckErr))
 				context.JSON(http.StatusInternalServerError, gin.H{jsonKeyError: errorValueQueryFailed})
 				return
 			}
@@ -727,21 +912,38 @@ func (handlers *SiteHandlers) UpdateSite(context *gin.Context) {
 		site.WidgetBubbleBottomOffsetPx = offset
 	}
 
+	if payload.WidgetConfig != nil {
+		normalizedConfig, configErr := model.NewWidgetConfig(*payload.WidgetConfig)
+		if configErr != nil {
+			respondInvalidWidgetConfig(context, configErr)
+			return
+		}
+		site.WidgetConfig = normalizedConfig
+	}
+
 	primaryOriginValue := primaryAllowedOrigin(site.AllowedOrigin)
 	normalizedPrimaryOrigin := strings.TrimSpace(primaryOriginValue)
 
 	if originChanged {
 		site.FaviconData = nil
+		site.FaviconData16 = nil
+		site.FaviconData32 = nil
 		site.FaviconContentType = ""
 		site.FaviconFetchedAt = time.Time{}
 		site.FaviconLastAttemptAt = time.Time{}
+		site.FaviconSource = ""
+		site.FaviconSourceReason = ""
+		site.PageTitle = ""
+		site.PageDescription = ""
+		site.PageImageURL = ""
+		site.PageCanonicalURL = ""
 		site.FaviconOrigin = normalizedPrimaryOrigin
 	} else if strings.TrimSpace(site.FaviconOrigin) == "" {
 		site.FaviconOrigin = normalizedPrimaryOrigin
 	}
 
 	if err := handlers.database.Save(&site).Error; err != nil {
-		handlers.logger.Warn("update_site", zap.Error(err))
+		requestLogger(context, handlers.logger).Warn("update_site", zap.Error(err))
 		context.JSON(http.StatusInternalServerError, gin.H{jsonKeyError: errorValueSaveFailed})
 		return
 	}
@@ -777,20 +979,34 @@ func (handlers *SiteHandlers) DeleteSite(context *gin.Context) {
 		return
 	}
 
+	var attachmentStorageKeys []string
 	deleteErr := handlers.database.Transaction(func(transaction *gorm.DB) error {
+		if err := transaction.Model(&model.FeedbackAttachment{}).Where("site_id = ?", site.ID).Pluck("storage_key", &attachmentStorageKeys).Error; err != nil {
+			return err
+		}
+		if err := transaction.Where("site_id = ?", site.ID).Delete(&model.FeedbackAttachment{}).Error; err != nil {
+			return err
+		}
 		if err := transaction.Where("site_id = ?", site.ID).Delete(&model.Feedback{}).Error; err != nil {
 			return err
 		}
+		if err := transaction.Where("site_id = ?", site.ID).Delete(&model.TrafficAlert{}).Error; err != nil {
+			return err
+		}
+		if err := transaction.Where("site_id = ?", site.ID).Delete(&model.TrafficAlertRule{}).Error; err != nil {
+			return err
+		}
 		if err := transaction.Delete(&model.Site{ID: site.ID}).Error; err != nil {
 			return err
 		}
 		return nil
 	})
 	if deleteErr != nil {
-		handlers.logger.Warn("delete_site", zap.Error(deleteErr))
+		requestLogger(context, handlers.logger).Warn("delete_site", zap.Error(deleteErr))
 		context.JSON(http.StatusInternalServerError, gin.H{jsonKeyError: errorValueDeleteFailed})
 		return
 	}
+	handlers.deleteAttachmentBlobs(context.Request.Context(), attachmentStorageKeys)
 
 	context.Status(http.StatusNoContent)
 	context.Writer.WriteHeaderNow()
@@ -829,16 +1045,28 @@ func (handlers *SiteHandlers) ListMessagesBySite(context *gin.Context) {
 		return
 	}
 
+	attachmentsByFeedback, attachmentsErr := handlers.feedbackAttachmentsByFeedback(context.Request.Context(), site.ID)
+	if attachmentsErr != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{jsonKeyError: errorValueQueryFailed})
+		return
+	}
+
 	messageResponses := make([]feedbackMessageResponse, 0, len(feedbacks))
 	for _, feedback := range feedbacks {
 		messageResponses = append(messageResponses, feedbackMessageResponse{
-			ID:        feedback.ID,
-			Contact:   feedback.Contact,
-			Message:   feedback.Message,
-			IP:        feedback.IP,
-			UserAgent: feedback.UserAgent,
-			CreatedAt: feedback.CreatedAt.Unix(),
-			Delivery:  feedback.Delivery,
+			ID:          feedback.ID,
+			Contact:     feedback.Contact,
+			Message:     feedback.Message,
+			ExtraField:  feedback.ExtraField,
+			Rating:      feedback.Rating,
+			NPSScore:    feedback.NPSScore,
+			Category:    feedback.Category,
+			PageURL:     feedback.PageURL,
+			Attachments: attachmentsByFeedback[feedback.ID],
+			IP:          feedback.IP,
+			UserAgent:   feedback.UserAgent,
+			CreatedAt:   feedback.CreatedAt.Unix(),
+			Delivery:    feedback.Delivery,
 		})
 	}
 
@@ -1192,6 +1420,10 @@ func (handlers *SiteHandlers) DeleteSubscriber(context *gin.Context) {
 }
 
 func (handlers *SiteHandlers) resolveAuthorizedSite(context *gin.Context) (model.Site, *CurrentUser, bool) {
+	return resolveManageableSite(context, handlers.database)
+}
+
+func resolveManageableSite(context *gin.Context, database *gorm.DB) (model.Site, *CurrentUser, bool) {
 	siteIdentifier := strings.TrimSpace(context.Param("id"))
 	if siteIdentifier == "" {
 		context.JSON(http.StatusBadRequest, gin.H{jsonKeyError: errorValueMissingSite})
@@ -1205,7 +1437,7 @@ func (handlers *SiteHandlers) resolveAuthorizedSite(context *gin.Context) (model
 	}
 
 	var site model.Site
-	if err := handlers.database.First(&site, "id = ?", siteIdentifier).Error; err != nil {
+	if err := database.First(&site, "id = ?", siteIdentifier).Error; err != nil {
 		context.JSON(http.StatusNotFound, gin.H{jsonKeyError: errorValueUnknownSite})
 		return model.Site{}, nil, false
 	}
@@ -1217,6 +1449,7 @@ func (handlers *SiteHandlers) resolveAuthorizedSite(context *gin.Context) (model
 
 	return site, currentUser, true
 }
+
 func (handlers *SiteHandlers) toSiteResponse(ctx context.Context, site model.Site, feedbackCount int64, requestOrigin string) siteResponse {
 	widgetBase := handlers.widgetBaseURL
 	if widgetBase == "" {
@@ -1239,6 +1472,12 @@ func (handlers *SiteHandlers) toSiteResponse(ctx context.Context, site model.Sit
 		TrafficAllowedOrigins:    site.TrafficAllowedOrigins,
 		OwnerEmail:               site.OwnerEmail,
 		FaviconURL:               faviconURL,
+		FaviconSource:            site.FaviconSource,
+		FaviconSourceReason:      site.FaviconSourceReason,
+		PageTitle:                site.PageTitle,
+		PageDescription:          site.PageDescription,
+		PageImageURL:             site.PageImageURL,
+		PageCanonicalURL:         site.PageCanonicalURL,
 		Widget:                   buildWidgetSnippet(widgetBase, site.ID, requestOrigin),
 		CreatedAt:                site.CreatedAt.UTC().Unix(),
 		FeedbackCount:            feedbackCount,
@@ -1247,6 +1486,7 @@ func (handlers *SiteHandlers) toSiteResponse(ctx context.Context, site model.Sit
 		UniqueVisitorCount:       handlers.uniqueVisitorCount(ctx, site.ID),
 		WidgetBubbleSide:         site.WidgetBubbleSide,
 		WidgetBubbleBottomOffset: site.WidgetBubbleBottomOffsetPx,
+		WidgetConfig:             resolvedWidgetConfig(site.WidgetConfig),
 	}
 }
 
@@ -1261,7 +1501,7 @@ func (handlers *SiteHandlers) feedbackCount(ctx context.Context, siteID string)
 	}
 	count, err := handlers.statsProvider.FeedbackCount(ctx, siteID)
 	if err != nil && handlers.logger != nil {
-		handlers.logger.Debug("feedback_count_failed", zap.String("site_id", siteID), zap.Error(err))
+		requestLogger(ctx, handlers.logger).Debug("feedback_count_failed", zap.String("site_id", siteID), zap.Error(err))
 		return 0
 	}
 	return count
@@ -1273,7 +1513,7 @@ func (handlers *SiteHandlers) subscriberCount(ctx context.Context, siteID string
 	}
 	count, err := handlers.statsProvider.SubscriberCount(ctx, siteID)
 	if err != nil && handlers.logger != nil {
-		handlers.logger.Debug("subscriber_count_failed", zap.String("site_id", siteID), zap.Error(err))
+		requestLogger(ctx, handlers.logger).Debug("subscriber_count_failed", zap.String("site_id", siteID), zap.Error(err))
 		return 0
 	}
 	return count
@@ -1285,7 +1525,7 @@ func (handlers *SiteHandlers) visitCount(ctx context.Context, siteID string) int
 	}
 	count, err := handlers.statsProvider.VisitCount(ctx, siteID)
 	if err != nil && handlers.logger != nil {
-		handlers.logger.Debug("visit_count_failed", zap.String("site_id", siteID), zap.Error(err))
+		requestLogger(ctx, handlers.logger).Debug("visit_count_failed", zap.String("site_id", siteID), zap.Error(err))
 		return 0
 	}
 	return count
@@ -1297,7 +1537,7 @@ func (handlers *SiteHandlers) uniqueVisitorCount(ctx context.Context, siteID str
 	}
 	count, err := handlers.statsProvider.UniqueVisitorCount(ctx, siteID)
 	if err != nil && handlers.logger != nil {
-		handlers.logger.Debug("unique_visitor_count_failed", zap.String("site_id", siteID), zap.Error(err))
+		requestLogger(ctx, handlers.logger).Debug("unique_visitor_count_failed", zap.String("site_id", siteID), zap.Error(err))
 		return 0
 	}
 	return count
@@ -1497,6 +1737,21 @@ func sanitizeWidgetBubbleBottomOffset(value *int) (int, error) {
 	return offset, nil
 }
 
+func resolvedWidgetConfig(config model.WidgetConfig) model.WidgetConfig {
+	config.Theme = config.ResolvedTheme()
+	config.ContactMode = config.ResolvedContactMode()
+	return config
+}
+
+func respondInvalidWidgetConfig(context *gin.Context, configErr error) {
+	var widgetConfigError *model.WidgetConfigError
+	if errors.As(configErr, &widgetConfigError) {
+		context.JSON(http.StatusBadRequest, gin.H{jsonKeyError: errorValueInvalidWidgetConfig, jsonKeyField: widgetConfigError.Field})
+		return
+	}
+	context.JSON(http.StatusBadRequest, gin.H{jsonKeyError: errorValueInvalidWidgetConfig})
+}
+
 func ensureWidgetBubblePlacementDefaults(site *model.Site) {
 	if site == nil {
 		return
@@ -81,6 +81,10 @@ func (provider *failingStatsProvider) TopPages(context.Context, string, int) ([]
 	return nil, provider.topPagesError
 }
 
+func (provider *failingStatsProvider) TopPagesBetween(context.Context, string, time.Time, time.Time, int) ([]api.TopPageStat, error) {
+	return nil, provider.topPagesError
+}
+
 func (provider *failingStatsProvider) VisitTrend(context.Context, string, int) ([]api.DailyVisitTrendStat, error) {
 	return nil, provider.visitTrendError
 }
@@ -108,6 +112,18 @@ func newSiteTestHarness(testingT *testing.T) siteTestHarness {
 	return siteTestHarness{handlers: handlers, database: database}
 }
 
+// newAuthenticatedRouter returns a router whose requests run as currentUser; a nil user leaves them anonymous.
+func newAuthenticatedRouter(currentUser *api.CurrentUser) *gin.Engine {
+	router := gin.New()
+	if currentUser != nil {
+		router.Use(func(context *gin.Context) {
+			context.Set(testSessionContextKey, currentUser)
+			context.Next()
+		})
+	}
+	return router
+}
+
 func TestCurrentUserReturnsAvatarPayload(testingT *testing.T) {
 	harness := newSiteTestHarness(testingT)
 
@@ -284,7 +300,11 @@ func TestListSitesUsesPublicBaseURLForWidget(testingT *testing.T) {
 		FaviconData:                []byte{0x01, 0x02, 0x03},
 		FaviconContentType:         "image/png",
 		FaviconOrigin:              "https://client.example",
+		FaviconSource:              "https://client.example/apple-touch-icon.png",
+		FaviconSourceReason:        "apple_touch_icon image/png: best of 2 declared icons",
 		FaviconFetchedAt:           time.Now(),
+		PageTitle:                  "Client Example",
+		PageCanonicalURL:           "https://client.example/",
 	}
 	require.NoError(testingT, harness.database.Create(&site).Error)
 	for index := 0; index < 5; index++ {
@@ -307,6 +327,10 @@ func TestListSitesUsesPublicBaseURLForWidget(testingT *testing.T) {
 			Identifier               string `json:"id"`
 			Widget                   string `json:"widget"`
 			FaviconURL               string `json:"favicon_url"`
+			FaviconSource            string `json:"favicon_source"`
+			FaviconSourceReason      string `json:"favicon_source_reason"`
+			PageTitle                string `json:"page_title"`
+			PageCanonicalURL         string `json:"page_canonical_url"`
 			FeedbackCount            int64  `json:"feedback_count"`
 			WidgetBubbleSide         string `json:"widget_bubble_side"`
 			WidgetBubbleBottomOffset int    `json:"widget_bubble_bottom_offset"`
@@ -320,6 +344,10 @@ func TestListSitesUsesPublicBaseURLForWidget(testingT *testing.T) {
 	require.Equal(testingT, expectedWidget, responseBody.Sites[0].Widget)
 	expectedFavicon := fmt.Sprintf("/api/sites/%s/favicon?ts=%d", site.ID, site.FaviconFetchedAt.UTC().Unix())
 	require.Equal(testingT, expectedFavicon, responseBody.Sites[0].FaviconURL)
+	require.Equal(testingT, site.FaviconSource, responseBody.Sites[0].FaviconSource)
+	require.Equal(testingT, site.FaviconSourceReason, responseBody.Sites[0].FaviconSourceReason)
+	require.Equal(testingT, site.PageTitle, responseBody.Sites[0].PageTitle)
+	require.Equal(testingT, site.PageCanonicalURL, responseBody.Sites[0].PageCanonicalURL)
 	require.Equal(testingT, int64(5), responseBody.Sites[0].FeedbackCount)
 	require.Equal(testingT, defaultWidgetTestBubbleSide, responseBody.Sites[0].WidgetBubbleSide)
 	require.Equal(testingT, defaultWidgetTestBottomOffsetPixels, responseBody.Sites[0].WidgetBubbleBottomOffset)
@@ -605,6 +633,76 @@ func TestSiteFaviconReturnsStoredIcon(testingT *testing.T) {
 	require.Equal(testingT, []byte{0x10, 0x20, 0x30}, recorder.Body.Bytes())
 }
 
+func TestSiteFaviconSelectsSizeAndHonorsETag(testingT *testing.T) {
+	harness := newSiteTestHarness(testingT)
+
+	site := model.Site{
+		ID:                 storage.NewID(),
+		Name:               "Sized Icon Site",
+		AllowedOrigin:      "https://sized-icon.example",
+		OwnerEmail:         testAdminEmailAddress,
+		FaviconData:        []byte{0x64},
+		FaviconData16:      []byte{0x16},
+		FaviconData32:      []byte{0x32},
+		FaviconContentType: "image/png",
+	}
+	require.NoError(testingT, harness.database.Create(&site).Error)
+
+	serveFavicon := func(query string, ifNoneMatch string) *httptest.ResponseRecorder {
+		recorder, context := newJSONContext(http.MethodGet, "/api/sites/"+site.ID+"/favicon"+query, nil)
+		if ifNoneMatch != "" {
+			context.Request.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		context.Params = gin.Params{{Key: "id", Value: site.ID}}
+		context.Set(testSessionContextKey, &api.CurrentUser{Email: testAdminEmailAddress, Role: api.RoleAdmin})
+		harness.handlers.SiteFavicon(context)
+		return recorder
+	}
+
+	for query, expected := range map[string][]byte{"": {0x64}, "?size=16": {0x16}, "?size=24": {0x32}, "?size=32": {0x32}, "?size=512": {0x64}} {
+		recorder := serveFavicon(query, "")
+		require.Equal(testingT, http.StatusOK, recorder.Code, query)
+		require.Equal(testingT, expected, recorder.Body.Bytes(), query)
+	}
+
+	recorder := serveFavicon("?size=16", "")
+	entityTag := recorder.Header().Get("ETag")
+	require.NotEmpty(testingT, entityTag)
+	require.NotEqual(testingT, entityTag, serveFavicon("?size=32", "").Header().Get("ETag"))
+
+	notModified := serveFavicon("?size=16", `"other", W/`+entityTag)
+	require.Equal(testingT, http.StatusNotModified, notModified.Code)
+	require.Empty(testingT, notModified.Body.Bytes())
+	require.Equal(testingT, entityTag, notModified.Header().Get("ETag"))
+
+	require.Equal(testingT, http.StatusOK, serveFavicon("?size=32", entityTag).Code)
+	require.Equal(testingT, http.StatusBadRequest, serveFavicon("?size=large", "").Code)
+}
+
+func TestSiteFaviconServesSVGWithRestrictivePolicy(testingT *testing.T) {
+	harness := newSiteTestHarness(testingT)
+
+	site := model.Site{
+		ID:                 storage.NewID(),
+		Name:               "Vector Icon Site",
+		AllowedOrigin:      "https://vector-icon.example",
+		OwnerEmail:         testAdminEmailAddress,
+		FaviconData:        []byte(`<svg xmlns="http://www.w3.org/2000/svg"></svg>`),
+		FaviconContentType: "image/svg+xml",
+	}
+	require.NoError(testingT, harness.database.Create(&site).Error)
+
+	recorder, context := newJSONContext(http.MethodGet, "/api/sites/"+site.ID+"/favicon?size=16", nil)
+	context.Params = gin.Params{{Key: "id", Value: site.ID}}
+	context.Set(testSessionContextKey, &api.CurrentUser{Email: testAdminEmailAddress, Role: api.RoleAdmin})
+
+	harness.handlers.SiteFavicon(context)
+	require.Equal(testingT, http.StatusOK, recorder.Code)
+	require.Equal(testingT, site.FaviconData, recorder.Body.Bytes())
+	require.Contains(testingT, recorder.Header().Get("Content-Security-Policy"), "default-src 'none'")
+	require.Equal(testingT, "nosniff", recorder.Header().Get("X-Content-Type-Options"))
+}
+
 func TestStreamFaviconUpdatesRequiresAuth(testingT *testing.T) {
 	harness := newSiteTestHarness(testingT)
 
@@ -684,7 +782,7 @@ func TestStreamFaviconUpdatesEmitsEvents(testingT *testing.T) {
 	}
 	require.NoError(testingT, database.Create(&site).Error)
 
-	resolver := &streamStubFaviconResolver{asset: &favicon.Asset{ContentType: "image/png", Data: []byte{0x05}}}
+	resolver := &streamStubFaviconResolver{asset: &favicon.Asset{ContentType: "image/png", Data: encodeTestFaviconPNG(testingT, 0x05)}}
 	service := favicon.NewService(resolver)
 	faviconManager := api.NewSiteFaviconManager(
 		database,
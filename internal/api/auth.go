@@ -15,6 +15,7 @@ import (
 	"gorm.io/gorm"
 
 	"github.com/MarkoPoloResearchLab/loopaware/internal/model"
+	"github.com/MarkoPoloResearchLab/loopaware/pkg/egress"
 )
 
 const (
@@ -125,7 +126,7 @@ func NewAuthManager(database *gorm.DB, logger *zap.Logger, adminEmails []string,
 
 	client := httpClient
 	if client == nil {
-		client = &http.Client{Timeout: defaultAvatarFetchTimeout}
+		client = egress.NewClient(egress.WithLogger(logger), egress.WithRequestTimeout(defaultAvatarFetchTimeout))
 	}
 
 	validatorConfig := sessionvalidator.Config{
@@ -195,18 +196,18 @@ func (authManager *AuthManager) ensureUser(context *gin.Context) (*CurrentUser,
 	}
 
 	if authManager.sessionValidator == nil {
-		authManager.logger.Warn(logEventLoadSession, zap.Error(sessionvalidator.ErrMissingSigningKey))
+		requestLogger(context, authManager.logger).Warn(logEventLoadSession, zap.Error(sessionvalidator.ErrMissingSigningKey))
 		return nil, false
 	}
 
 	claims, validationErr := authManager.sessionValidator.ValidateRequest(context.Request)
 	if validationErr != nil {
-		authManager.logger.Warn(logEventLoadSession, zap.Error(validationErr))
+		requestLogger(context, authManager.logger).Warn(logEventLoadSession, zap.Error(validationErr))
 		return nil, false
 	}
 	expectedTenantID := authManager.expectedTenantID
 	if expectedTenantID != "" && !strings.EqualFold(claims.GetTenantID(), expectedTenantID) {
-		authManager.logger.Warn(logEventLoadSession, zap.Error(sessionvalidator.ErrInvalidToken))
+		requestLogger(context, authManager.logger).Warn(logEventLoadSession, zap.Error(sessionvalidator.ErrInvalidToken))
 		return nil, false
 	}
 
@@ -227,7 +228,7 @@ func (authManager *AuthManager) ensureUser(context *gin.Context) (*CurrentUser,
 	if authManager.database != nil {
 		persistedPath, persistErr := authManager.persistUser(context.Request.Context(), lowercaseEmail, name, pictureURL)
 		if persistErr != nil {
-			authManager.logger.Warn(logEventPersistUser, zap.Error(persistErr))
+			requestLogger(context, authManager.logger).Warn(logEventPersistUser, zap.Error(persistErr))
 		} else {
 			localAvatarPath = persistedPath
 		}
@@ -268,7 +269,7 @@ func (authManager *AuthManager) persistUser(ctx context.Context, lowercaseEmail
 		if trimmedPictureURL != "" {
 			avatarData, contentType, fetchErr := authManager.fetchAvatar(ctx, trimmedPictureURL)
 			if fetchErr != nil {
-				authManager.logger.Warn(logEventFetchAvatar, zap.Error(fetchErr))
+				requestLogger(ctx, authManager.logger).Warn(logEventFetchAvatar, zap.Error(fetchErr))
 			} else {
 				user.AvatarData = avatarData
 				user.AvatarContentType = contentType
@@ -300,7 +301,7 @@ func (authManager *AuthManager) persistUser(ctx context.Context, lowercaseEmail
 	if shouldFetchAvatar {
 		avatarData, contentType, fetchErr := authManager.fetchAvatar(ctx, trimmedPictureURL)
 		if fetchErr != nil {
-			authManager.logger.Warn(logEventFetchAvatar, zap.Error(fetchErr))
+			requestLogger(ctx, authManager.logger).Warn(logEventFetchAvatar, zap.Error(fetchErr))
 		} else {
 			updates["avatar_data"] = avatarData
 			updates["avatar_content_type"] = contentType
@@ -0,0 +1,401 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"strings"
+	"time"
+
+	"github.com/yuin/goldmark"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/MarkoPoloResearchLab/loopaware/internal/mailer"
+	"github.com/MarkoPoloResearchLab/loopaware/internal/model"
+)
+
+const (
+	defaultCampaignSendInterval    = 200 * time.Millisecond
+	campaignDeliveryBatchSize      = 100
+	campaignUnsubscribeTokenTTL    = 365 * 24 * time.Hour
+	campaignDeliveryErrorNotActive = "subscriber not confirmed"
+	campaignDeliveryErrorToken     = "unsubscribe token failed"
+	campaignDeliveryErrorLink      = "unsubscribe url failed"
+)
+
+var errCampaignEmailSenderUnavailable = errors.New("campaign email sender unavailable")
+
+type CampaignDispatcherOption func(*CampaignDispatcher)
+
+// CampaignDispatcher sends scheduled campaigns to confirmed subscribers at a throttled rate.
+type CampaignDispatcher struct {
+	database      *gorm.DB
+	logger        *zap.Logger
+	emailSender   EmailSender
+	publicBaseURL string
+	tokenSecret   string
+	sendInterval  time.Duration
+	now           func() time.Time
+	trigger       func()
+}
+
+// NewCampaignDispatcher constructs a dispatcher that delivers campaigns through emailSender. The job runner calls
+// DispatchDue on its schedule; trigger asks it for an immediate pass.
+func NewCampaignDispatcher(database *gorm.DB, logger *zap.Logger, emailSender EmailSender, publicBaseURL string, tokenSecret string, trigger func(), options ...CampaignDispatcherOption) *CampaignDispatcher {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	if trigger == nil {
+		trigger = func() {}
+	}
+	dispatcher := &CampaignDispatcher{
+		database:      database,
+		logger:        logger,
+		emailSender:   emailSender,
+		publicBaseURL: strings.TrimSpace(publicBaseURL),
+		tokenSecret:   strings.TrimSpace(tokenSecret),
+		sendInterval:  defaultCampaignSendInterval,
+		now:           time.Now,
+		trigger:       trigger,
+	}
+	for _, option := range options {
+		if option != nil {
+			option(dispatcher)
+		}
+	}
+	return dispatcher
+}
+
+// WithCampaignSendInterval sets the pause between consecutive campaign emails.
+func WithCampaignSendInterval(sendInterval time.Duration) CampaignDispatcherOption {
+	return func(dispatcher *CampaignDispatcher) {
+		if sendInterval >= 0 {
+			dispatcher.sendInterval = sendInterval
+		}
+	}
+}
+
+// WithCampaignClock overrides the dispatcher clock.
+func WithCampaignClock(clock func() time.Time) CampaignDispatcherOption {
+	return func(dispatcher *CampaignDispatcher) {
+		if clock != nil {
+			dispatcher.now = clock
+		}
+	}
+}
+
+// Trigger requests an immediate dispatch pass.
+func (dispatcher *CampaignDispatcher) Trigger() {
+	if dispatcher == nil {
+		return
+	}
+	dispatcher.trigger()
+}
+
+// DispatchDue sends every scheduled campaign whose send time has passed and resumes interrupted sends.
+func (dispatcher *CampaignDispatcher) DispatchDue(ctx context.Context) error {
+	if dispatcher.emailSender == nil {
+		return errCampaignEmailSenderUnavailable
+	}
+
+	var campaigns []model.Campaign
+	findErr := dispatcher.database.WithContext(ctx).
+		Where("(status = ? AND scheduled_at <= ?) OR status = ?", model.CampaignStatusScheduled, dispatcher.now().UTC(), model.CampaignStatusSending).
+		Order("scheduled_at asc").
+		Find(&campaigns).Error
+	if findErr != nil {
+		return fmt.Errorf("load due campaigns: %w", findErr)
+	}
+
+	for _, campaign := range campaigns {
+		if err := dispatcher.sendCampaign(ctx, campaign); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			dispatcher.logger.Warn("campaign_send_failed", zap.Error(err), zap.String("campaign_id", campaign.ID), zap.String("site_id", campaign.SiteID))
+		}
+	}
+	return nil
+}
+
+func (dispatcher *CampaignDispatcher) sendCampaign(ctx context.Context, campaign model.Campaign) error {
+	if campaign.Status == model.CampaignStatusScheduled {
+		sendStartedAt := dispatcher.now().UTC()
+		claimResult := dispatcher.database.WithContext(ctx).
+			Model(&model.Campaign{}).
+			Where("id = ? AND status = ?", campaign.ID, model.CampaignStatusScheduled).
+			Updates(map[string]any{
+				"status":          model.CampaignStatusSending,
+				"send_started_at": sendStartedAt,
+			})
+		if claimResult.Error != nil {
+			return fmt.Errorf("claim campaign: %w", claimResult.Error)
+		}
+		if claimResult.RowsAffected == 0 {
+			return nil
+		}
+		campaign.Status = model.CampaignStatusSending
+		campaign.SendStartedAt = sendStartedAt
+	}
+
+	var site model.Site
+	if err := dispatcher.database.WithContext(ctx).First(&site, "id = ?", campaign.SiteID).Error; err != nil {
+		return fmt.Errorf("load campaign site: %w", err)
+	}
+
+	if err := dispatcher.enqueueRecipients(ctx, campaign); err != nil {
+		return err
+	}
+
+	if err := dispatcher.deliverPending(ctx, site, campaign); err != nil {
+		return err
+	}
+
+	return dispatcher.finalizeCampaign(ctx, campaign)
+}
+
+// enqueueRecipients records a delivery for every subscriber confirmed when the send started. A resumed send repeats
+// the same query, so subscribers who joined or confirmed after SendStartedAt never get added to a send in progress.
+func (dispatcher *CampaignDispatcher) enqueueRecipients(ctx context.Context, campaign model.Campaign) error {
+	recipientQuery := dispatcher.database.WithContext(ctx).
+		Where("site_id = ? AND status = ?", campaign.SiteID, model.SubscriberStatusConfirmed)
+	if !campaign.SendStartedAt.IsZero() {
+		recipientQuery = recipientQuery.Where("created_at <= ? AND confirmed_at <= ?", campaign.SendStartedAt, campaign.SendStartedAt)
+	}
+	var subscribers []model.Subscriber
+	findErr := recipientQuery.
+		Order("created_at asc").
+		Find(&subscribers).Error
+	if findErr != nil {
+		return fmt.Errorf("load campaign recipients: %w", findErr)
+	}
+
+	deliveries := make([]model.CampaignDelivery, 0, len(subscribers))
+	for _, subscriber := range subscribers {
+		delivery, deliveryErr := model.NewCampaignDelivery(campaign.ID, subscriber.ID, subscriber.Email)
+		if deliveryErr != nil {
+			dispatcher.logger.Warn("campaign_delivery_invalid", zap.Error(deliveryErr), zap.String("campaign_id", campaign.ID), zap.String("subscriber_id", subscriber.ID))
+			continue
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	if len(deliveries) > 0 {
+		createErr := dispatcher.database.WithContext(ctx).
+			Clauses(clause.OnConflict{DoNothing: true}).
+			CreateInBatches(&deliveries, campaignDeliveryBatchSize).Error
+		if createErr != nil {
+			return fmt.Errorf("enqueue campaign deliveries: %w", createErr)
+		}
+	}
+
+	var recipientCount int64
+	if err := dispatcher.database.WithContext(ctx).Model(&model.CampaignDelivery{}).Where("campaign_id = ?", campaign.ID).Count(&recipientCount).Error; err != nil {
+		return fmt.Errorf("count campaign deliveries: %w", err)
+	}
+	return dispatcher.database.WithContext(ctx).Model(&model.Campaign{ID: campaign.ID}).Update("recipient_count", recipientCount).Error
+}
+
+func (dispatcher *CampaignDispatcher) deliverPending(ctx context.Context, site model.Site, campaign model.Campaign) error {
+	sentAny := false
+	for {
+		var deliveries []model.CampaignDelivery
+		findErr := dispatcher.database.WithContext(ctx).
+			Where("campaign_id = ? AND status = ?", campaign.ID, model.CampaignDeliveryStatusPending).
+			Order("created_at asc, id asc").
+			Limit(campaignDeliveryBatchSize).
+			Find(&deliveries).Error
+		if findErr != nil {
+			return fmt.Errorf("load pending deliveries: %w", findErr)
+		}
+		if len(deliveries) == 0 {
+			return nil
+		}
+
+		for _, delivery := range deliveries {
+			if sentAny {
+				if err := dispatcher.wait(ctx); err != nil {
+					return err
+				}
+			}
+			sentAny = dispatcher.deliver(ctx, site, campaign, delivery) || sentAny
+		}
+	}
+}
+
+func (dispatcher *CampaignDispatcher) deliver(ctx context.Context, site model.Site, campaign model.Campaign, delivery model.CampaignDelivery) bool {
+	var subscriber model.Subscriber
+	findErr := dispatcher.database.WithContext(ctx).First(&subscriber, "id = ? AND site_id = ?", delivery.SubscriberID, campaign.SiteID).Error
+	if findErr != nil || subscriber.Status != model.SubscriberStatusConfirmed {
+		dispatcher.recordDelivery(ctx, delivery, model.CampaignDeliveryStatusSkipped, campaignDeliveryErrorNotActive, false)
+		return false
+	}
+
+	unsubscribeLinks, linkErr := dispatcher.buildUnsubscribeLinks(subscriber)
+	if linkErr != nil {
+		dispatcher.logger.Warn("campaign_unsubscribe_link_failed", zap.Error(linkErr), zap.String("campaign_id", campaign.ID), zap.String("subscriber_id", subscriber.ID))
+		errorMessage := campaignDeliveryErrorLink
+		if errors.Is(linkErr, ErrInvalidSubscriptionConfirmationToken) {
+			errorMessage = campaignDeliveryErrorToken
+		}
+		dispatcher.recordDelivery(ctx, delivery, model.CampaignDeliveryStatusFailed, errorMessage, true)
+		return false
+	}
+
+	message := mailer.Message{
+		Recipient:      subscriber.Email,
+		Subject:        campaign.Subject,
+		TextBody:       composeCampaignMessage(site, campaign, unsubscribeLinks.pageURL),
+		HTMLBody:       composeCampaignHTML(site, campaign, unsubscribeLinks.pageURL),
+		UnsubscribeURL: unsubscribeLinks.oneClickURL,
+	}
+	if sendErr := sendEmailMessage(ctx, dispatcher.emailSender, message); sendErr != nil {
+		dispatcher.logger.Warn("campaign_email_failed", zap.Error(sendErr), zap.String("campaign_id", campaign.ID), zap.String("subscriber_id", subscriber.ID))
+		dispatcher.recordDelivery(ctx, delivery, model.CampaignDeliveryStatusFailed, sendErr.Error(), true)
+		return true
+	}
+
+	dispatcher.recordDelivery(ctx, delivery, model.CampaignDeliveryStatusSent, "", true)
+	return true
+}
+
+func (dispatcher *CampaignDispatcher) recordDelivery(ctx context.Context, delivery model.CampaignDelivery, status string, errorMessage string, attempted bool) {
+	updates := map[string]any{
+		"status": status,
+		"error":  model.TruncateCampaignDeliveryError(errorMessage),
+	}
+	if attempted {
+		updates["attempts"] = delivery.Attempts + 1
+	}
+	if status == model.CampaignDeliveryStatusSent {
+		updates["sent_at"] = dispatcher.now().UTC()
+	}
+	if err := dispatcher.database.WithContext(context.WithoutCancel(ctx)).Model(&model.CampaignDelivery{ID: delivery.ID}).Updates(updates).Error; err != nil {
+		dispatcher.logger.Warn("campaign_delivery_update_failed", zap.Error(err), zap.String("delivery_id", delivery.ID))
+	}
+}
+
+func (dispatcher *CampaignDispatcher) finalizeCampaign(ctx context.Context, campaign model.Campaign) error {
+	type statusCount struct {
+		Status string
+		Total  int64
+	}
+	var counts []statusCount
+	countErr := dispatcher.database.WithContext(ctx).
+		Model(&model.CampaignDelivery{}).
+		Select("status, COUNT(*) as total").
+		Where("campaign_id = ?", campaign.ID).
+		Group("status").
+		Scan(&counts).Error
+	if countErr != nil {
+		return fmt.Errorf("count campaign results: %w", countErr)
+	}
+
+	var sentCount, failedCount int64
+	for _, count := range counts {
+		switch count.Status {
+		case model.CampaignDeliveryStatusSent:
+			sentCount = count.Total
+		case model.CampaignDeliveryStatusFailed:
+			failedCount = count.Total
+		}
+	}
+
+	return dispatcher.database.WithContext(ctx).
+		Model(&model.Campaign{ID: campaign.ID}).
+		Updates(map[string]any{
+			"status":       model.CampaignStatusSent,
+			"sent_at":      dispatcher.now().UTC(),
+			"sent_count":   sentCount,
+			"failed_count": failedCount,
+		}).Error
+}
+
+func (dispatcher *CampaignDispatcher) wait(ctx context.Context) error {
+	if dispatcher.sendInterval <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(dispatcher.sendInterval)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+type campaignUnsubscribeLinks struct {
+	pageURL     string
+	oneClickURL string
+}
+
+func (dispatcher *CampaignDispatcher) buildUnsubscribeLinks(subscriber model.Subscriber) (campaignUnsubscribeLinks, error) {
+	token, tokenErr := buildSubscriptionConfirmationToken(dispatcher.tokenSecret, subscriber.ID, subscriber.SiteID, subscriber.Email, dispatcher.now().UTC(), campaignUnsubscribeTokenTTL)
+	if tokenErr != nil {
+		return campaignUnsubscribeLinks{}, tokenErr
+	}
+	if dispatcher.publicBaseURL == "" {
+		return campaignUnsubscribeLinks{}, errors.New("missing public base url")
+	}
+	baseURL := strings.TrimRight(dispatcher.publicBaseURL, "/")
+	pageURL, pageErr := buildSubscriptionTokenURL(baseURL, subscriptionUnsubscribePath, token)
+	if pageErr != nil {
+		return campaignUnsubscribeLinks{}, pageErr
+	}
+	oneClickURL, oneClickErr := buildSubscriptionTokenURL(baseURL, subscriptionOneClickUnsubscribePath, token)
+	if oneClickErr != nil {
+		return campaignUnsubscribeLinks{}, oneClickErr
+	}
+	return campaignUnsubscribeLinks{pageURL: pageURL, oneClickURL: oneClickURL}, nil
+}
+
+func composeCampaignMessage(site model.Site, campaign model.Campaign, unsubscribeURL string) string {
+	siteName := campaignSiteName(site)
+	messageBuilder := &strings.Builder{}
+	_, _ = fmt.Fprintf(messageBuilder, "%s\n\n", campaign.Body)
+	_, _ = fmt.Fprintf(messageBuilder, "--\nYou are receiving this email because you subscribed to %s.\n", siteName)
+	_, _ = fmt.Fprintf(messageBuilder, "Unsubscribe: %s\n", unsubscribeURL)
+	return messageBuilder.String()
+}
+
+// campaignMarkdown renders markdown bodies with goldmark's defaults, which omit raw HTML and drop links with
+// dangerous schemes such as javascript:, so subscriber mail never carries owner-supplied markup.
+var campaignMarkdown = goldmark.New()
+
+func composeCampaignHTML(site model.Site, campaign model.Campaign, unsubscribeURL string) string {
+	messageBuilder := &strings.Builder{}
+	if campaign.BodyFormat == model.CampaignBodyFormatMarkdown {
+		var rendered bytes.Buffer
+		if convertErr := campaignMarkdown.Convert([]byte(campaign.Body), &rendered); convertErr == nil {
+			messageBuilder.Write(rendered.Bytes())
+			return appendCampaignFooterHTML(messageBuilder, site, unsubscribeURL)
+		}
+	}
+	for _, paragraph := range strings.Split(strings.ReplaceAll(campaign.Body, "\r\n", "\n"), "\n\n") {
+		trimmedParagraph := strings.TrimSpace(paragraph)
+		if trimmedParagraph == "" {
+			continue
+		}
+		escapedLines := strings.Split(html.EscapeString(trimmedParagraph), "\n")
+		_, _ = fmt.Fprintf(messageBuilder, "<p>%s</p>\n", strings.Join(escapedLines, "<br>\n"))
+	}
+	return appendCampaignFooterHTML(messageBuilder, site, unsubscribeURL)
+}
+
+func appendCampaignFooterHTML(messageBuilder *strings.Builder, site model.Site, unsubscribeURL string) string {
+	_, _ = fmt.Fprintf(messageBuilder, "<hr>\n<p>You are receiving this email because you subscribed to %s.</p>\n", html.EscapeString(campaignSiteName(site)))
+	_, _ = fmt.Fprintf(messageBuilder, "<p><a href=\"%s\">Unsubscribe</a></p>\n", html.EscapeString(unsubscribeURL))
+	return messageBuilder.String()
+}
+
+func campaignSiteName(site model.Site) string {
+	siteName := strings.TrimSpace(site.Name)
+	if siteName == "" {
+		return "LoopAware"
+	}
+	return siteName
+}
@@ -0,0 +1,476 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"github.com/MarkoPoloResearchLab/loopaware/internal/model"
+)
+
+const (
+	errorValueInvalidCampaign       = "invalid_campaign"
+	errorValueInvalidSubject        = "invalid_subject"
+	errorValueInvalidBody           = "invalid_body"
+	errorValueInvalidBodyFormat     = "invalid_body_format"
+	errorValueUnknownCampaign       = "unknown_campaign"
+	errorValueCampaignNotEditable   = "campaign_not_editable"
+	errorValueCampaignNotScheduled  = "campaign_not_scheduled"
+	errorValueInvalidScheduledAt    = "invalid_scheduled_at"
+	errorValueInvalidDeliveryStatus = "invalid_delivery_status"
+	errorValueInvalidOffset         = "invalid_offset"
+
+	defaultCampaignDeliveryPageSize = 100
+	maxCampaignDeliveryPageSize     = 500
+)
+
+// CampaignHandlers manages newsletter campaigns for a site.
+type CampaignHandlers struct {
+	database   *gorm.DB
+	logger     *zap.Logger
+	dispatcher *CampaignDispatcher
+	now        func() time.Time
+}
+
+// NewCampaignHandlers constructs campaign handlers; dispatcher may be nil when sending is disabled.
+func NewCampaignHandlers(database *gorm.DB, logger *zap.Logger, dispatcher *CampaignDispatcher) *CampaignHandlers {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &CampaignHandlers{
+		database:   database,
+		logger:     logger,
+		dispatcher: dispatcher,
+		now:        time.Now,
+	}
+}
+
+type campaignContentRequest struct {
+	Subject    *string `json:"subject"`
+	Body       *string `json:"body"`
+	BodyFormat *string `json:"body_format"`
+}
+
+type campaignSendRequest struct {
+	ScheduledAt *int64 `json:"scheduled_at"`
+}
+
+// CampaignResponse describes a campaign and its delivery progress.
+type CampaignResponse struct {
+	ID             string `json:"id"`
+	SiteID         string `json:"site_id"`
+	Subject        string `json:"subject"`
+	Body           string `json:"body"`
+	BodyFormat     string `json:"body_format"`
+	Status         string `json:"status"`
+	CreatedByEmail string `json:"created_by_email"`
+	ScheduledAt    int64  `json:"scheduled_at"`
+	SendStartedAt  int64  `json:"send_started_at"`
+	SentAt         int64  `json:"sent_at"`
+	RecipientCount int64  `json:"recipient_count"`
+	SentCount      int64  `json:"sent_count"`
+	FailedCount    int64  `json:"failed_count"`
+	CreatedAt      int64  `json:"created_at"`
+	UpdatedAt      int64  `json:"updated_at"`
+}
+
+// SiteCampaignsResponse lists the campaigns for a site.
+type SiteCampaignsResponse struct {
+	SiteID    string             `json:"site_id"`
+	Campaigns []CampaignResponse `json:"campaigns"`
+}
+
+// CampaignDeliveryRecord describes the delivery state for one recipient.
+type CampaignDeliveryRecord struct {
+	ID           string `json:"id"`
+	SubscriberID string `json:"subscriber_id"`
+	Email        string `json:"email"`
+	Status       string `json:"status"`
+	Error        string `json:"error"`
+	Attempts     int    `json:"attempts"`
+	SentAt       int64  `json:"sent_at"`
+}
+
+// CampaignDeliveriesResponse lists per-recipient delivery records for a campaign.
+type CampaignDeliveriesResponse struct {
+	CampaignID string                   `json:"campaign_id"`
+	Total      int64                    `json:"total"`
+	Deliveries []CampaignDeliveryRecord `json:"deliveries"`
+}
+
+// ListCampaigns returns all campaigns for a site, newest first.
+func (handlers *CampaignHandlers) ListCampaigns(context *gin.Context) {
+	site, _, ok := resolveManageableSite(context, handlers.database)
+	if !ok {
+		return
+	}
+
+	var campaigns []model.Campaign
+	if err := handlers.database.Where("site_id = ?", site.ID).Order("created_at desc").Find(&campaigns).Error; err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{jsonKeyError: errorValueQueryFailed})
+		return
+	}
+
+	response := SiteCampaignsResponse{SiteID: site.ID, Campaigns: make([]CampaignResponse, 0, len(campaigns))}
+	for _, campaign := range campaigns {
+		response.Campaigns = append(response.Campaigns, toCampaignResponse(campaign))
+	}
+	context.JSON(http.StatusOK, response)
+}
+
+// CreateCampaign stores a new draft campaign.
+func (handlers *CampaignHandlers) CreateCampaign(context *gin.Context) {
+	site, currentUser, ok := resolveManageableSite(context, handlers.database)
+	if !ok {
+		return
+	}
+
+	var request campaignContentRequest
+	if err := context.ShouldBindJSON(&request); err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{jsonKeyError: errorValueInvalidJSON})
+		return
+	}
+
+	campaign, campaignErr := model.NewCampaign(model.CampaignInput{
+		SiteID:         site.ID,
+		Subject:        stringValue(request.Subject),
+		Body:           stringValue(request.Body),
+		BodyFormat:     stringValue(request.BodyFormat),
+		CreatedByEmail: currentUser.normalizedEmail(),
+	})
+	if campaignErr != nil {
+		context.JSON(http.StatusBadRequest, gin.H{jsonKeyError: campaignContentErrorValue(campaignErr)})
+		return
+	}
+
+	if err := handlers.database.Create(&campaign).Error; err != nil {
+		requestLogger(context, handlers.logger).Warn("create_campaign", zap.Error(err), zap.String("site_id", site.ID))
+		context.JSON(http.StatusInternalServerError, gin.H{jsonKeyError: errorValueSaveFailed})
+		return
+	}
+
+	context.JSON(http.StatusCreated, toCampaignResponse(campaign))
+}
+
+// GetCampaign returns a single campaign.
+func (handlers *CampaignHandlers) GetCampaign(context *gin.Context) {
+	campaign, ok := handlers.resolveCampaign(context)
+	if !ok {
+		return
+	}
+	context.JSON(http.StatusOK, toCampaignResponse(campaign))
+}
+
+// UpdateCampaign edits the content of a draft or scheduled campaign.
+func (handlers *CampaignHandlers) UpdateCampaign(context *gin.Context) {
+	campaign, ok := handlers.resolveCampaign(context)
+	if !ok {
+		return
+	}
+
+	var request campaignContentRequest
+	if err := context.ShouldBindJSON(&request); err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{jsonKeyError: errorValueInvalidJSON})
+		return
+	}
+	if request.Subject == nil && request.Body == nil && request.BodyFormat == nil {
+		context.JSON(http.StatusBadRequest, gin.H{jsonKeyError: errorValueNothingToUpdate})
+		return
+	}
+	if !campaign.IsEditable() {
+		context.JSON(http.StatusConflict, gin.H{jsonKeyError: errorValueCampaignNotEditable})
+		return
+	}
+
+	subject := campaign.Subject
+	if request.Subject != nil {
+		subject = *request.Subject
+	}
+	body := campaign.Body
+	if request.Body != nil {
+		body = *request.Body
+	}
+	bodyFormat := campaign.BodyFormat
+	if request.BodyFormat != nil {
+		bodyFormat = *request.BodyFormat
+	}
+	normalizedSubject, normalizedBody, normalizedFormat, contentErr := model.NormalizeCampaignContent(subject, body, bodyFormat)
+	if contentErr != nil {
+		context.JSON(http.StatusBadRequest, gin.H{jsonKeyError: campaignContentErrorValue(contentErr)})
+		return
+	}
+
+	updateResult := handlers.database.Model(&model.Campaign{}).
+		Where("id = ? AND status IN ?", campaign.ID, []string{model.CampaignStatusDraft, model.CampaignStatusScheduled}).
+		Updates(map[string]any{
+			"subject":     normalizedSubject,
+			"body":        normalizedBody,
+			"body_format": normalizedFormat,
+		})
+	if updateResult.Error != nil {
+		requestLogger(context, handlers.logger).Warn("update_campaign", zap.Error(updateResult.Error), zap.String("campaign_id", campaign.ID))
+		context.JSON(http.StatusInternalServerError, gin.H{jsonKeyError: errorValueSaveFailed})
+		return
+	}
+	if updateResult.RowsAffected == 0 {
+		context.JSON(http.StatusConflict, gin.H{jsonKeyError: errorValueCampaignNotEditable})
+		return
+	}
+
+	handlers.respondWithCampaign(context, campaign.ID)
+}
+
+// DeleteCampaign removes a campaign that has not started sending.
+func (handlers *CampaignHandlers) DeleteCampaign(context *gin.Context) {
+	campaign, ok := handlers.resolveCampaign(context)
+	if !ok {
+		return
+	}
+	if !campaign.IsEditable() {
+		context.JSON(http.StatusConflict, gin.H{jsonKeyError: errorValueCampaignNotEditable})
+		return
+	}
+
+	deleteResult := handlers.database.
+		Where("id = ? AND status IN ?", campaign.ID, []string{model.CampaignStatusDraft, model.CampaignStatusScheduled}).
+		Delete(&model.Campaign{})
+	if deleteResult.Error != nil {
+		requestLogger(context, handlers.logger).Warn("delete_campaign", zap.Error(deleteResult.Error), zap.String("campaign_id", campaign.ID))
+		context.JSON(http.StatusInternalServerError, gin.H{jsonKeyError: errorValueDeleteFailed})
+		return
+	}
+	if deleteResult.RowsAffected == 0 {
+		context.JSON(http.StatusConflict, gin.H{jsonKeyError: errorValueCampaignNotEditable})
+		return
+	}
+
+	context.Status(http.StatusNoContent)
+	context.Writer.WriteHeaderNow()
+}
+
+// SendCampaign schedules a campaign for immediate or later delivery.
+func (handlers *CampaignHandlers) SendCampaign(context *gin.Context) {
+	campaign, ok := handlers.resolveCampaign(context)
+	if !ok {
+		return
+	}
+
+	var request campaignSendRequest
+	if context.Request.ContentLength != 0 {
+		if err := context.ShouldBindJSON(&request); err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{jsonKeyError: errorValueInvalidJSON})
+			return
+		}
+	}
+
+	now := handlers.now().UTC()
+	scheduledAt := now
+	if request.ScheduledAt != nil {
+		if *request.ScheduledAt <= 0 {
+			context.JSON(http.StatusBadRequest, gin.H{jsonKeyError: errorValueInvalidScheduledAt})
+			return
+		}
+		requestedTime := time.Unix(*request.ScheduledAt, 0).UTC()
+		if requestedTime.After(now) {
+			scheduledAt = requestedTime
+		}
+	}
+
+	if !campaign.IsEditable() {
+		context.JSON(http.StatusConflict, gin.H{jsonKeyError: errorValueCampaignNotEditable})
+		return
+	}
+
+	updateResult := handlers.database.Model(&model.Campaign{}).
+		Where("id = ? AND status IN ?", campaign.ID, []string{model.CampaignStatusDraft, model.CampaignStatusScheduled}).
+		Updates(map[string]any{
+			"status":       model.CampaignStatusScheduled,
+			"scheduled_at": scheduledAt,
+		})
+	if updateResult.Error != nil {
+		requestLogger(context, handlers.logger).Warn("schedule_campaign", zap.Error(updateResult.Error), zap.String("campaign_id", campaign.ID))
+		context.JSON(http.StatusInternalServerError, gin.H{jsonKeyError: errorValueSaveFailed})
+		return
+	}
+	if updateResult.RowsAffected == 0 {
+		context.JSON(http.StatusConflict, gin.H{jsonKeyError: errorValueCampaignNotEditable})
+		return
+	}
+
+	if !scheduledAt.After(now) {
+		handlers.dispatcher.Trigger()
+	}
+
+	handlers.respondWithCampaign(context, campaign.ID)
+}
+
+// CancelCampaign returns a scheduled campaign to draft.
+func (handlers *CampaignHandlers) CancelCampaign(context *gin.Context) {
+	campaign, ok := handlers.resolveCampaign(context)
+	if !ok {
+		return
+	}
+
+	updateResult := handlers.database.Model(&model.Campaign{}).
+		Where("id = ? AND status = ?", campaign.ID, model.CampaignStatusScheduled).
+		Updates(map[string]any{
+			"status":       model.CampaignStatusDraft,
+			"scheduled_at": time.Time{},
+		})
+	if updateResult.Error != nil {
+		requestLogger(context, handlers.logger).Warn("cancel_campaign", zap.Error(updateResult.Error), zap.String("campaign_id", campaign.ID))
+		context.JSON(http.StatusInternalServerError, gin.H{jsonKeyError: errorValueSaveFailed})
+		return
+	}
+	if updateResult.RowsAffected == 0 {
+		context.JSON(http.StatusConflict, gin.H{jsonKeyError: errorValueCampaignNotScheduled})
+		return
+	}
+
+	handlers.respondWithCampaign(context, campaign.ID)
+}
+
+// ListCampaignDeliveries returns per-recipient delivery status for a campaign.
+func (handlers *CampaignHandlers) ListCampaignDeliveries(context *gin.Context) {
+	campaign, ok := handlers.resolveCampaign(context)
+	if !ok {
+		return
+	}
+
+	limit := defaultCampaignDeliveryPageSize
+	if rawLimit := strings.TrimSpace(context.Query("limit")); rawLimit != "" {
+		parsedLimit, parseErr := strconv.Atoi(rawLimit)
+		if parseErr != nil || parsedLimit <= 0 || parsedLimit > maxCampaignDeliveryPageSize {
+			context.JSON(http.StatusBadRequest, gin.H{jsonKeyError: errorValueInvalidLimit})
+			return
+		}
+		limit = parsedLimit
+	}
+	offset := 0
+	if rawOffset := strings.TrimSpace(context.Query("offset")); rawOffset != "" {
+		parsedOffset, parseErr := strconv.Atoi(rawOffset)
+		if parseErr != nil || parsedOffset < 0 {
+			context.JSON(http.StatusBadRequest, gin.H{jsonKeyError: errorValueInvalidOffset})
+			return
+		}
+		offset = parsedOffset
+	}
+
+	query := handlers.database.Model(&model.CampaignDelivery{}).Where("campaign_id = ?", campaign.ID)
+	if statusFilter := strings.ToLower(strings.TrimSpace(context.Query("status"))); statusFilter != "" {
+		switch statusFilter {
+		case model.CampaignDeliveryStatusPending, model.CampaignDeliveryStatusSent, model.CampaignDeliveryStatusFailed, model.CampaignDeliveryStatusSkipped:
+			query = query.Where("status = ?", statusFilter)
+		default:
+			context.JSON(http.StatusBadRequest, gin.H{jsonKeyError: errorValueInvalidDeliveryStatus})
+			return
+		}
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{jsonKeyError: errorValueQueryFailed})
+		return
+	}
+	var deliveries []model.CampaignDelivery
+	if err := query.Order("created_at asc, id asc").Limit(limit).Offset(offset).Find(&deliveries).Error; err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{jsonKeyError: errorValueQueryFailed})
+		return
+	}
+
+	response := CampaignDeliveriesResponse{CampaignID: campaign.ID, Total: total, Deliveries: make([]CampaignDeliveryRecord, 0, len(deliveries))}
+	for _, delivery := range deliveries {
+		response.Deliveries = append(response.Deliveries, CampaignDeliveryRecord{
+			ID:           delivery.ID,
+			SubscriberID: delivery.SubscriberID,
+			Email:        delivery.Email,
+			Status:       delivery.Status,
+			Error:        delivery.Error,
+			Attempts:     delivery.Attempts,
+			SentAt:       unixSecondsOrZero(delivery.SentAt),
+		})
+	}
+	context.JSON(http.StatusOK, response)
+}
+
+func (handlers *CampaignHandlers) resolveCampaign(context *gin.Context) (model.Campaign, bool) {
+	site, _, ok := resolveManageableSite(context, handlers.database)
+	if !ok {
+		return model.Campaign{}, false
+	}
+
+	campaignID := strings.TrimSpace(context.Param("campaign_id"))
+	var campaign model.Campaign
+	findErr := handlers.database.First(&campaign, "id = ? AND site_id = ?", campaignID, site.ID).Error
+	if findErr != nil {
+		if errors.Is(findErr, gorm.ErrRecordNotFound) {
+			context.JSON(http.StatusNotFound, gin.H{jsonKeyError: errorValueUnknownCampaign})
+			return model.Campaign{}, false
+		}
+		context.JSON(http.StatusInternalServerError, gin.H{jsonKeyError: errorValueQueryFailed})
+		return model.Campaign{}, false
+	}
+	return campaign, true
+}
+
+func (handlers *CampaignHandlers) respondWithCampaign(context *gin.Context, campaignID string) {
+	var campaign model.Campaign
+	if err := handlers.database.First(&campaign, "id = ?", campaignID).Error; err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{jsonKeyError: errorValueQueryFailed})
+		return
+	}
+	context.JSON(http.StatusOK, toCampaignResponse(campaign))
+}
+
+func toCampaignResponse(campaign model.Campaign) CampaignResponse {
+	return CampaignResponse{
+		ID:             campaign.ID,
+		SiteID:         campaign.SiteID,
+		Subject:        campaign.Subject,
+		Body:           campaign.Body,
+		BodyFormat:     campaign.BodyFormat,
+		Status:         campaign.Status,
+		CreatedByEmail: campaign.CreatedByEmail,
+		ScheduledAt:    unixSecondsOrZero(campaign.ScheduledAt),
+		SendStartedAt:  unixSecondsOrZero(campaign.SendStartedAt),
+		SentAt:         unixSecondsOrZero(campaign.SentAt),
+		RecipientCount: campaign.RecipientCount,
+		SentCount:      campaign.SentCount,
+		FailedCount:    campaign.FailedCount,
+		CreatedAt:      campaign.CreatedAt.Unix(),
+		UpdatedAt:      campaign.UpdatedAt.Unix(),
+	}
+}
+
+func campaignContentErrorValue(contentErr error) string {
+	switch {
+	case errors.Is(contentErr, model.ErrInvalidCampaignSubject):
+		return errorValueInvalidSubject
+	case errors.Is(contentErr, model.ErrInvalidCampaignBody):
+		return errorValueInvalidBody
+	case errors.Is(contentErr, model.ErrInvalidCampaignBodyFormat):
+		return errorValueInvalidBodyFormat
+	default:
+		return errorValueInvalidCampaign
+	}
+}
+
+func unixSecondsOrZero(timestamp time.Time) int64 {
+	if timestamp.IsZero() {
+		return 0
+	}
+	return timestamp.Unix()
+}
+
+func stringValue(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
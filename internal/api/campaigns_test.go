@@ -0,0 +1,338 @@
+package api_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"github.com/MarkoPoloResearchLab/loopaware/internal/api"
+	"github.com/MarkoPoloResearchLab/loopaware/internal/model"
+)
+
+const (
+	testCampaignBaseURL     = "https://loopaware.example.com"
+	testCampaignTokenSecret = "campaign-secret"
+	testCampaignSubject     = "Spring release notes"
+	testCampaignBody        = "We shipped a lot this month."
+)
+
+var unsubscribeLinkPattern = regexp.MustCompile(`Unsubscribe: (\S+)`)
+
+type campaignHarness struct {
+	router     *gin.Engine
+	database   *gorm.DB
+	site       model.Site
+	sender     *recordingEmailSender
+	dispatcher *api.CampaignDispatcher
+	triggers   *atomic.Int32
+}
+
+func newCampaignHarness(testingT *testing.T, currentUser *api.CurrentUser) campaignHarness {
+	testingT.Helper()
+
+	database := newSiteTestHarness(testingT).database
+
+	site := insertSite(testingT, database, "Campaign Site", "http://example.com", testAdminEmailAddress)
+
+	sender := &recordingEmailSender{testingT: testingT}
+	triggers := &atomic.Int32{}
+	dispatcher := api.NewCampaignDispatcher(database, zap.NewNop(), sender, testCampaignBaseURL, testCampaignTokenSecret, func() { triggers.Add(1) }, api.WithCampaignSendInterval(0))
+	handlers := api.NewCampaignHandlers(database, zap.NewNop(), dispatcher)
+	publicHandlers := api.NewPublicHandlers(database, zap.NewNop(), nil, nil, nil, nil, false, testCampaignBaseURL, testCampaignTokenSecret, nil)
+
+	router := newAuthenticatedRouter(currentUser)
+	apiGroup := router.Group("/api")
+	apiGroup.GET("/sites/:id/campaigns", handlers.ListCampaigns)
+	apiGroup.POST("/sites/:id/campaigns", handlers.CreateCampaign)
+	apiGroup.GET("/sites/:id/campaigns/:campaign_id", handlers.GetCampaign)
+	apiGroup.PATCH("/sites/:id/campaigns/:campaign_id", handlers.UpdateCampaign)
+	apiGroup.DELETE("/sites/:id/campaigns/:campaign_id", handlers.DeleteCampaign)
+	apiGroup.POST("/sites/:id/campaigns/:campaign_id/send", handlers.SendCampaign)
+	apiGroup.POST("/sites/:id/campaigns/:campaign_id/cancel", handlers.CancelCampaign)
+	apiGroup.GET("/sites/:id/campaigns/:campaign_id/deliveries", handlers.ListCampaignDeliveries)
+	router.GET("/public/subscriptions/unsubscribe-link", publicHandlers.UnsubscribeSubscriptionLinkJSON)
+
+	return campaignHarness{router: router, database: database, site: site, sender: sender, dispatcher: dispatcher, triggers: triggers}
+}
+
+func (harness campaignHarness) perform(testingT *testing.T, method string, path string, body any) *httptest.ResponseRecorder {
+	testingT.Helper()
+	var requestBody *bytes.Reader
+	if body != nil {
+		encoded, marshalErr := json.Marshal(body)
+		require.NoError(testingT, marshalErr)
+		requestBody = bytes.NewReader(encoded)
+	} else {
+		requestBody = bytes.NewReader(nil)
+	}
+	request := httptest.NewRequest(method, path, requestBody)
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+	recorder := httptest.NewRecorder()
+	harness.router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func (harness campaignHarness) createSubscriber(testingT *testing.T, email string, status string) model.Subscriber {
+	testingT.Helper()
+	subscriber, subscriberErr := model.NewSubscriber(model.SubscriberInput{SiteID: harness.site.ID, Email: email, Status: status})
+	require.NoError(testingT, subscriberErr)
+	require.NoError(testingT, harness.database.Create(&subscriber).Error)
+	return subscriber
+}
+
+func (harness campaignHarness) createCampaign(testingT *testing.T) api.CampaignResponse {
+	testingT.Helper()
+	recorder := harness.perform(testingT, http.MethodPost, fmt.Sprintf("/api/sites/%s/campaigns", harness.site.ID), map[string]any{
+		"subject":     testCampaignSubject,
+		"body":        testCampaignBody,
+		"body_format": "markdown",
+	})
+	require.Equal(testingT, http.StatusCreated, recorder.Code)
+	var campaign api.CampaignResponse
+	require.NoError(testingT, json.Unmarshal(recorder.Body.Bytes(), &campaign))
+	return campaign
+}
+
+func TestCampaignLifecycleFromDraftToSent(testingT *testing.T) {
+	harness := newCampaignHarness(testingT, &api.CurrentUser{Email: testAdminEmailAddress, Role: api.RoleAdmin})
+	confirmed := harness.createSubscriber(testingT, "reader@example.com", model.SubscriberStatusConfirmed)
+	harness.createSubscriber(testingT, "pending@example.com", model.SubscriberStatusPending)
+	harness.createSubscriber(testingT, "gone@example.com", model.SubscriberStatusUnsubscribed)
+
+	campaign := harness.createCampaign(testingT)
+	require.Equal(testingT, model.CampaignStatusDraft, campaign.Status)
+	require.Equal(testingT, model.CampaignBodyFormatMarkdown, campaign.BodyFormat)
+	require.Equal(testingT, testAdminEmailAddress, campaign.CreatedByEmail)
+
+	campaignPath := fmt.Sprintf("/api/sites/%s/campaigns/%s", harness.site.ID, campaign.ID)
+	updateRecorder := harness.perform(testingT, http.MethodPatch, campaignPath, map[string]any{"subject": "Updated subject"})
+	require.Equal(testingT, http.StatusOK, updateRecorder.Code)
+
+	sendRecorder := harness.perform(testingT, http.MethodPost, campaignPath+"/send", nil)
+	require.Equal(testingT, http.StatusOK, sendRecorder.Code)
+	var scheduled api.CampaignResponse
+	require.NoError(testingT, json.Unmarshal(sendRecorder.Body.Bytes(), &scheduled))
+	require.Equal(testingT, model.CampaignStatusScheduled, scheduled.Status)
+	require.NotZero(testingT, scheduled.ScheduledAt)
+	require.Equal(testingT, int32(1), harness.triggers.Load())
+
+	require.NoError(testingT, harness.dispatcher.DispatchDue(context.Background()))
+
+	detailRecorder := harness.perform(testingT, http.MethodGet, campaignPath, nil)
+	require.Equal(testingT, http.StatusOK, detailRecorder.Code)
+	var sent api.CampaignResponse
+	require.NoError(testingT, json.Unmarshal(detailRecorder.Body.Bytes(), &sent))
+	require.Equal(testingT, model.CampaignStatusSent, sent.Status)
+	require.Equal(testingT, int64(1), sent.RecipientCount)
+	require.Equal(testingT, int64(1), sent.SentCount)
+	require.Zero(testingT, sent.FailedCount)
+	require.NotZero(testingT, sent.SentAt)
+
+	require.Equal(testingT, 1, harness.sender.CallCount())
+	call := harness.sender.LastCall()
+	require.Equal(testingT, confirmed.Email, call.Recipient)
+	require.Equal(testingT, "Updated subject", call.Subject)
+	require.Contains(testingT, call.Message, testCampaignBody)
+
+	deliveriesRecorder := harness.perform(testingT, http.MethodGet, campaignPath+"/deliveries", nil)
+	require.Equal(testingT, http.StatusOK, deliveriesRecorder.Code)
+	var deliveries api.CampaignDeliveriesResponse
+	require.NoError(testingT, json.Unmarshal(deliveriesRecorder.Body.Bytes(), &deliveries))
+	require.Equal(testingT, int64(1), deliveries.Total)
+	require.Equal(testingT, confirmed.ID, deliveries.Deliveries[0].SubscriberID)
+	require.Equal(testingT, model.CampaignDeliveryStatusSent, deliveries.Deliveries[0].Status)
+	require.Equal(testingT, 1, deliveries.Deliveries[0].Attempts)
+
+	lockedRecorder := harness.perform(testingT, http.MethodPatch, campaignPath, map[string]any{"body": "too late"})
+	require.Equal(testingT, http.StatusConflict, lockedRecorder.Code)
+}
+
+func TestCampaignEmailsCarryWorkingUnsubscribeLinks(testingT *testing.T) {
+	harness := newCampaignHarness(testingT, &api.CurrentUser{Email: testAdminEmailAddress, Role: api.RoleAdmin})
+	firstReader := harness.createSubscriber(testingT, "first@example.com", model.SubscriberStatusConfirmed)
+	harness.createSubscriber(testingT, "second@example.com", model.SubscriberStatusConfirmed)
+
+	campaign := harness.createCampaign(testingT)
+	sendRecorder := harness.perform(testingT, http.MethodPost, fmt.Sprintf("/api/sites/%s/campaigns/%s/send", harness.site.ID, campaign.ID), nil)
+	require.Equal(testingT, http.StatusOK, sendRecorder.Code)
+	require.NoError(testingT, harness.dispatcher.DispatchDue(context.Background()))
+	require.Equal(testingT, 2, harness.sender.CallCount())
+
+	var firstLink string
+	seenLinks := map[string]struct{}{}
+	for _, call := range harness.sender.calls {
+		match := unsubscribeLinkPattern.FindStringSubmatch(call.Message)
+		require.Len(testingT, match, 2)
+		seenLinks[match[1]] = struct{}{}
+		if call.Recipient == firstReader.Email {
+			firstLink = match[1]
+		}
+	}
+	require.Len(testingT, seenLinks, 2)
+	require.NotEmpty(testingT, firstLink)
+
+	parsedLink, parseErr := url.Parse(firstLink)
+	require.NoError(testingT, parseErr)
+	require.Equal(testingT, "/subscriptions/unsubscribe", parsedLink.Path)
+
+	unsubscribeRequest := httptest.NewRequest(http.MethodGet, "/public/subscriptions/unsubscribe-link?"+parsedLink.RawQuery, nil)
+	unsubscribeRecorder := httptest.NewRecorder()
+	harness.router.ServeHTTP(unsubscribeRecorder, unsubscribeRequest)
+	require.Equal(testingT, http.StatusOK, unsubscribeRecorder.Code)
+
+	var stored model.Subscriber
+	require.NoError(testingT, harness.database.First(&stored, "id = ?", firstReader.ID).Error)
+	require.Equal(testingT, model.SubscriberStatusUnsubscribed, stored.Status)
+}
+
+func TestCampaignDispatcherRendersSanitizedMarkdownHTML(testingT *testing.T) {
+	harness := newCampaignHarness(testingT, &api.CurrentUser{Email: testAdminEmailAddress, Role: api.RoleAdmin})
+	harness.createSubscriber(testingT, "reader@example.com", model.SubscriberStatusConfirmed)
+	sender := &recordingMessageSender{}
+	dispatcher := api.NewCampaignDispatcher(harness.database, zap.NewNop(), sender, testCampaignBaseURL, testCampaignTokenSecret, nil, api.WithCampaignSendInterval(0))
+
+	markdownBody := "## Release notes\n\nWe shipped **dark mode**. Read [the post](https://example.com/post).\n\n- Faster exports\n- Fewer bugs\n\n<script>alert(1)</script>\n\n[bad link](javascript:alert(1))"
+	createRecorder := harness.perform(testingT, http.MethodPost, fmt.Sprintf("/api/sites/%s/campaigns", harness.site.ID), map[string]any{
+		"subject":     testCampaignSubject,
+		"body":        markdownBody,
+		"body_format": model.CampaignBodyFormatMarkdown,
+	})
+	require.Equal(testingT, http.StatusCreated, createRecorder.Code)
+	var campaign api.CampaignResponse
+	require.NoError(testingT, json.Unmarshal(createRecorder.Body.Bytes(), &campaign))
+	sendRecorder := harness.perform(testingT, http.MethodPost, fmt.Sprintf("/api/sites/%s/campaigns/%s/send", harness.site.ID, campaign.ID), nil)
+	require.Equal(testingT, http.StatusOK, sendRecorder.Code)
+	require.NoError(testingT, dispatcher.DispatchDue(context.Background()))
+
+	messages := sender.Messages()
+	require.Len(testingT, messages, 1)
+	htmlBody := messages[0].HTMLBody
+	require.Contains(testingT, htmlBody, "<h2>Release notes</h2>")
+	require.Contains(testingT, htmlBody, "<strong>dark mode</strong>")
+	require.Contains(testingT, htmlBody, `<a href="https://example.com/post">the post</a>`)
+	require.Contains(testingT, htmlBody, "<li>Faster exports</li>")
+	require.Contains(testingT, htmlBody, ">Unsubscribe</a>")
+	require.NotContains(testingT, htmlBody, "<script>")
+	require.NotContains(testingT, htmlBody, "javascript:")
+	require.NotContains(testingT, htmlBody, "**")
+	require.Contains(testingT, messages[0].TextBody, markdownBody)
+}
+
+func TestCampaignDispatcherRecordsFailedDeliveries(testingT *testing.T) {
+	harness := newCampaignHarness(testingT, &api.CurrentUser{Email: testAdminEmailAddress, Role: api.RoleAdmin})
+	harness.createSubscriber(testingT, "reader@example.com", model.SubscriberStatusConfirmed)
+	harness.sender.callErr = errors.New("smtp unavailable")
+
+	campaign := harness.createCampaign(testingT)
+	sendRecorder := harness.perform(testingT, http.MethodPost, fmt.Sprintf("/api/sites/%s/campaigns/%s/send", harness.site.ID, campaign.ID), nil)
+	require.Equal(testingT, http.StatusOK, sendRecorder.Code)
+	require.NoError(testingT, harness.dispatcher.DispatchDue(context.Background()))
+
+	var stored model.Campaign
+	require.NoError(testingT, harness.database.First(&stored, "id = ?", campaign.ID).Error)
+	require.Equal(testingT, model.CampaignStatusSent, stored.Status)
+	require.Equal(testingT, int64(1), stored.FailedCount)
+
+	deliveriesRecorder := harness.perform(testingT, http.MethodGet, fmt.Sprintf("/api/sites/%s/campaigns/%s/deliveries?status=failed", harness.site.ID, campaign.ID), nil)
+	require.Equal(testingT, http.StatusOK, deliveriesRecorder.Code)
+	var deliveries api.CampaignDeliveriesResponse
+	require.NoError(testingT, json.Unmarshal(deliveriesRecorder.Body.Bytes(), &deliveries))
+	require.Len(testingT, deliveries.Deliveries, 1)
+	require.Equal(testingT, "smtp unavailable", deliveries.Deliveries[0].Error)
+}
+
+func TestCampaignDispatcherResumesWithoutAddingLaterSubscribers(testingT *testing.T) {
+	harness := newCampaignHarness(testingT, &api.CurrentUser{Email: testAdminEmailAddress, Role: api.RoleAdmin})
+	sendStartedAt := time.Now().UTC().Add(-time.Hour)
+	original := harness.createSubscriber(testingT, "reader@example.com", model.SubscriberStatusConfirmed)
+	lateConfirmed := harness.createSubscriber(testingT, "late@example.com", model.SubscriberStatusConfirmed)
+	require.NoError(testingT, harness.database.Model(&model.Subscriber{}).Where("id IN ?", []string{original.ID, lateConfirmed.ID}).Update("created_at", sendStartedAt.Add(-time.Hour)).Error)
+	require.NoError(testingT, harness.database.Model(&model.Subscriber{}).Where("id = ?", lateConfirmed.ID).Update("confirmed_at", sendStartedAt.Add(time.Minute)).Error)
+	harness.createSubscriber(testingT, "newcomer@example.com", model.SubscriberStatusConfirmed)
+
+	campaign := harness.createCampaign(testingT)
+	require.NoError(testingT, harness.database.Model(&model.Campaign{}).Where("id = ?", campaign.ID).Updates(map[string]any{
+		"status":          model.CampaignStatusSending,
+		"send_started_at": sendStartedAt,
+	}).Error)
+
+	require.NoError(testingT, harness.dispatcher.DispatchDue(context.Background()))
+
+	require.Equal(testingT, 1, harness.sender.CallCount())
+	require.Equal(testingT, original.Email, harness.sender.LastCall().Recipient)
+	var stored model.Campaign
+	require.NoError(testingT, harness.database.First(&stored, "id = ?", campaign.ID).Error)
+	require.Equal(testingT, model.CampaignStatusSent, stored.Status)
+	require.Equal(testingT, int64(1), stored.RecipientCount)
+}
+
+func TestCampaignSchedulingInTheFutureWaitsAndCanBeCancelled(testingT *testing.T) {
+	harness := newCampaignHarness(testingT, &api.CurrentUser{Email: testAdminEmailAddress, Role: api.RoleAdmin})
+	harness.createSubscriber(testingT, "reader@example.com", model.SubscriberStatusConfirmed)
+
+	campaign := harness.createCampaign(testingT)
+	campaignPath := fmt.Sprintf("/api/sites/%s/campaigns/%s", harness.site.ID, campaign.ID)
+	futureTime := time.Now().Add(2 * time.Hour).Unix()
+	sendRecorder := harness.perform(testingT, http.MethodPost, campaignPath+"/send", map[string]any{"scheduled_at": futureTime})
+	require.Equal(testingT, http.StatusOK, sendRecorder.Code)
+	var scheduled api.CampaignResponse
+	require.NoError(testingT, json.Unmarshal(sendRecorder.Body.Bytes(), &scheduled))
+	require.Equal(testingT, futureTime, scheduled.ScheduledAt)
+
+	require.NoError(testingT, harness.dispatcher.DispatchDue(context.Background()))
+	require.Zero(testingT, harness.sender.CallCount())
+
+	cancelRecorder := harness.perform(testingT, http.MethodPost, campaignPath+"/cancel", nil)
+	require.Equal(testingT, http.StatusOK, cancelRecorder.Code)
+	var cancelled api.CampaignResponse
+	require.NoError(testingT, json.Unmarshal(cancelRecorder.Body.Bytes(), &cancelled))
+	require.Equal(testingT, model.CampaignStatusDraft, cancelled.Status)
+	require.Zero(testingT, cancelled.ScheduledAt)
+
+	secondCancelRecorder := harness.perform(testingT, http.MethodPost, campaignPath+"/cancel", nil)
+	require.Equal(testingT, http.StatusConflict, secondCancelRecorder.Code)
+
+	deleteRecorder := harness.perform(testingT, http.MethodDelete, campaignPath, nil)
+	require.Equal(testingT, http.StatusNoContent, deleteRecorder.Code)
+
+	listRecorder := harness.perform(testingT, http.MethodGet, fmt.Sprintf("/api/sites/%s/campaigns", harness.site.ID), nil)
+	require.Equal(testingT, http.StatusOK, listRecorder.Code)
+	var listed api.SiteCampaignsResponse
+	require.NoError(testingT, json.Unmarshal(listRecorder.Body.Bytes(), &listed))
+	require.Empty(testingT, listed.Campaigns)
+}
+
+func TestCreateCampaignValidatesContentAndAccess(testingT *testing.T) {
+	harness := newCampaignHarness(testingT, &api.CurrentUser{Email: testAdminEmailAddress, Role: api.RoleAdmin})
+
+	recorder := harness.perform(testingT, http.MethodPost, fmt.Sprintf("/api/sites/%s/campaigns", harness.site.ID), map[string]any{"subject": "", "body": testCampaignBody})
+	require.Equal(testingT, http.StatusBadRequest, recorder.Code)
+	require.JSONEq(testingT, `{"error":"invalid_subject"}`, recorder.Body.String())
+
+	recorder = harness.perform(testingT, http.MethodPost, fmt.Sprintf("/api/sites/%s/campaigns", harness.site.ID), map[string]any{"subject": testCampaignSubject, "body": testCampaignBody, "body_format": "html"})
+	require.Equal(testingT, http.StatusBadRequest, recorder.Code)
+	require.JSONEq(testingT, `{"error":"invalid_body_format"}`, recorder.Body.String())
+
+	missingRecorder := harness.perform(testingT, http.MethodGet, fmt.Sprintf("/api/sites/%s/campaigns/%s", harness.site.ID, "missing"), nil)
+	require.Equal(testingT, http.StatusNotFound, missingRecorder.Code)
+
+	outsider := newCampaignHarness(testingT, &api.CurrentUser{Email: testUserEmailAddress, Role: api.RoleUser})
+	forbiddenRecorder := outsider.perform(testingT, http.MethodGet, fmt.Sprintf("/api/sites/%s/campaigns", outsider.site.ID), nil)
+	require.Equal(testingT, http.StatusForbidden, forbiddenRecorder.Code)
+}
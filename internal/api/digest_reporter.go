@@ -0,0 +1,330 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"github.com/MarkoPoloResearchLab/loopaware/internal/mailer"
+	"github.com/MarkoPoloResearchLab/loopaware/internal/model"
+)
+
+const (
+	digestUserBatchSize   = 100
+	digestExcerptCount    = 3
+	digestExcerptMaxRunes = 160
+	digestTopPageCount    = 5
+	digestUnsubscribePath = "/public/digests/unsubscribe"
+	digestProductName     = "LoopAware"
+)
+
+type DigestReporterOption func(*DigestReporter)
+
+// DigestReporterResult reports the work done by a single digest run.
+type DigestReporterResult struct {
+	Sent int
+}
+
+// DigestReporter emails site owners a daily or weekly summary of feedback, subscribers, and traffic.
+type DigestReporter struct {
+	database      *gorm.DB
+	logger        *zap.Logger
+	emailSender   EmailSender
+	statsProvider SiteStatisticsProvider
+	publicBaseURL string
+	tokenSecret   string
+	now           func() time.Time
+}
+
+// NewDigestReporter constructs a reporter that reads traffic through statsProvider and sends digests through emailSender.
+func NewDigestReporter(database *gorm.DB, logger *zap.Logger, emailSender EmailSender, statsProvider SiteStatisticsProvider, publicBaseURL string, tokenSecret string, options ...DigestReporterOption) *DigestReporter {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	if statsProvider == nil {
+		statsProvider = NewDatabaseSiteStatisticsProvider(database)
+	}
+	reporter := &DigestReporter{
+		database:      database,
+		logger:        logger,
+		emailSender:   emailSender,
+		statsProvider: statsProvider,
+		publicBaseURL: strings.TrimRight(strings.TrimSpace(publicBaseURL), "/"),
+		tokenSecret:   strings.TrimSpace(tokenSecret),
+		now:           time.Now,
+	}
+	for _, option := range options {
+		if option != nil {
+			option(reporter)
+		}
+	}
+	return reporter
+}
+
+// WithDigestClock overrides the reporter clock.
+func WithDigestClock(clock func() time.Time) DigestReporterOption {
+	return func(reporter *DigestReporter) {
+		if clock != nil {
+			reporter.now = clock
+		}
+	}
+}
+
+func (reporter *DigestReporter) available() bool {
+	return reporter != nil && reporter.emailSender != nil && reporter.publicBaseURL != "" && reporter.tokenSecret != ""
+}
+
+// SendDueDigests emails every opted-in user whose last digest is at least one period old.
+// Users who manage no sites are skipped without being marked as sent.
+func (reporter *DigestReporter) SendDueDigests(ctx context.Context) (DigestReporterResult, error) {
+	var result DigestReporterResult
+	if !reporter.available() {
+		return result, nil
+	}
+
+	lastEmail := ""
+	for {
+		var users []model.User
+		findErr := reporter.database.WithContext(ctx).
+			Select("email", "name", "digest_frequency", "digest_last_sent_at").
+			Where("digest_frequency IN ? AND email > ?", []string{model.DigestFrequencyDaily, model.DigestFrequencyWeekly}, lastEmail).
+			Order("email asc").
+			Limit(digestUserBatchSize).
+			Find(&users).Error
+		if findErr != nil {
+			return result, fmt.Errorf("load digest users: %w", findErr)
+		}
+		if len(users) == 0 {
+			if result.Sent > 0 {
+				reporter.logger.Info("digest_run", zap.Int("sent", result.Sent))
+			}
+			return result, nil
+		}
+
+		for _, user := range users {
+			lastEmail = user.Email
+			if ctx.Err() != nil {
+				return result, ctx.Err()
+			}
+			now := reporter.now().UTC()
+			period := model.DigestPeriod(user.DigestFrequency)
+			if !digestDue(user.DigestLastSentAt, period, now) {
+				continue
+			}
+			sent, sendErr := reporter.sendDigest(ctx, user, period, now)
+			if sendErr != nil {
+				reporter.logger.Warn("digest_send_failed", zap.Error(sendErr), zap.String("email", user.Email))
+				continue
+			}
+			if !sent {
+				continue
+			}
+			updateErr := reporter.database.WithContext(context.WithoutCancel(ctx)).
+				Model(&model.User{}).
+				Where("email = ?", user.Email).
+				Update("digest_last_sent_at", now).Error
+			if updateErr != nil {
+				reporter.logger.Warn("digest_mark_sent_failed", zap.Error(updateErr), zap.String("email", user.Email))
+				continue
+			}
+			result.Sent++
+		}
+	}
+}
+
+// digestDue compares whole hours so that an hourly run starting a few seconds earlier than the previous one does not
+// push the digest back by a full run.
+func digestDue(lastSentAt time.Time, period time.Duration, now time.Time) bool {
+	if period <= 0 {
+		return false
+	}
+	if lastSentAt.IsZero() {
+		return true
+	}
+	return !now.Truncate(time.Hour).Before(lastSentAt.UTC().Truncate(time.Hour).Add(period))
+}
+
+type digestSiteReport struct {
+	SiteName          string
+	FeedbackCount     int64
+	FeedbackExcerpts  []string
+	NewSubscribers    int64
+	UnsubscribedCount int64
+	PageViews         int64
+	UniqueVisitors    int64
+	TopPages          []TopPageStat
+}
+
+func (reporter *DigestReporter) sendDigest(ctx context.Context, user model.User, period time.Duration, now time.Time) (bool, error) {
+	normalizedEmail := strings.ToLower(strings.TrimSpace(user.Email))
+	var sites []model.Site
+	if err := reporter.database.WithContext(ctx).
+		Select("id", "name").
+		Where("LOWER(owner_email) = ? OR LOWER(creator_email) = ?", normalizedEmail, normalizedEmail).
+		Order("name asc").
+		Find(&sites).Error; err != nil {
+		return false, fmt.Errorf("load digest sites: %w", err)
+	}
+	if len(sites) == 0 {
+		return false, nil
+	}
+
+	since := now.Add(-period)
+	reports := make([]digestSiteReport, 0, len(sites))
+	for _, site := range sites {
+		report, reportErr := reporter.compileSiteReport(ctx, site, since, now, period)
+		if reportErr != nil {
+			return false, reportErr
+		}
+		reports = append(reports, report)
+	}
+
+	token, tokenErr := buildDigestUnsubscribeToken(reporter.tokenSecret, normalizedEmail, now)
+	if tokenErr != nil {
+		return false, tokenErr
+	}
+	unsubscribeURL, urlErr := buildSubscriptionTokenURL(reporter.publicBaseURL, digestUnsubscribePath, token)
+	if urlErr != nil {
+		return false, urlErr
+	}
+
+	periodLabel := digestPeriodLabel(user.DigestFrequency)
+	message := mailer.Message{
+		Recipient:      normalizedEmail,
+		Subject:        fmt.Sprintf("Your %s %s digest", digestProductName, periodLabel),
+		TextBody:       composeDigestText(periodLabel, since, now, reports, unsubscribeURL),
+		HTMLBody:       composeDigestHTML(periodLabel, since, now, reports, unsubscribeURL),
+		UnsubscribeURL: unsubscribeURL,
+	}
+	if sendErr := sendEmailMessage(ctx, reporter.emailSender, message); sendErr != nil {
+		return false, sendErr
+	}
+	return true, nil
+}
+
+func (reporter *DigestReporter) compileSiteReport(ctx context.Context, site model.Site, since time.Time, now time.Time, period time.Duration) (digestSiteReport, error) {
+	report := digestSiteReport{SiteName: campaignSiteName(site)}
+	database := reporter.database.WithContext(ctx)
+
+	feedbackWindow := database.Model(&model.Feedback{}).Where("site_id = ? AND created_at >= ? AND created_at < ?", site.ID, since, now)
+	if err := feedbackWindow.Count(&report.FeedbackCount).Error; err != nil {
+		return digestSiteReport{}, fmt.Errorf("count digest feedback: %w", err)
+	}
+	if report.FeedbackCount > 0 {
+		var messages []string
+		if err := database.Model(&model.Feedback{}).
+			Where("site_id = ? AND created_at >= ? AND created_at < ?", site.ID, since, now).
+			Order("created_at desc").
+			Limit(digestExcerptCount).
+			Pluck("message", &messages).Error; err != nil {
+			return digestSiteReport{}, fmt.Errorf("load digest feedback: %w", err)
+		}
+		for _, message := range messages {
+			report.FeedbackExcerpts = append(report.FeedbackExcerpts, digestExcerpt(message))
+		}
+	}
+
+	if err := database.Model(&model.Subscriber{}).
+		Where("site_id = ? AND created_at >= ? AND created_at < ?", site.ID, since, now).
+		Count(&report.NewSubscribers).Error; err != nil {
+		return digestSiteReport{}, fmt.Errorf("count digest subscribers: %w", err)
+	}
+	if err := database.Model(&model.Subscriber{}).
+		Where("site_id = ? AND status = ? AND unsubscribed_at >= ? AND unsubscribed_at < ?", site.ID, model.SubscriberStatusUnsubscribed, since, now).
+		Count(&report.UnsubscribedCount).Error; err != nil {
+		return digestSiteReport{}, fmt.Errorf("count digest unsubscribes: %w", err)
+	}
+
+	// VisitTrend includes the current, partial day; ask for one extra day and keep only the completed ones.
+	periodDays := int(period / (24 * time.Hour))
+	today := now.Truncate(24 * time.Hour)
+	trend, trendErr := reporter.statsProvider.VisitTrend(ctx, site.ID, periodDays+1)
+	if trendErr != nil {
+		reporter.logger.Warn("digest_visit_trend_failed", zap.Error(trendErr), zap.String("site_id", site.ID))
+	}
+	for _, point := range trend {
+		if !point.Date.Before(today) {
+			continue
+		}
+		report.PageViews += point.PageViews
+		report.UniqueVisitors += point.UniqueVisitors
+	}
+	topPages, topPagesErr := reporter.statsProvider.TopPagesBetween(ctx, site.ID, today.Add(-time.Duration(periodDays)*24*time.Hour), today, digestTopPageCount)
+	if topPagesErr != nil {
+		reporter.logger.Warn("digest_top_pages_failed", zap.Error(topPagesErr), zap.String("site_id", site.ID))
+	}
+	report.TopPages = topPages
+	return report, nil
+}
+
+func digestPeriodLabel(frequency string) string {
+	if frequency == model.DigestFrequencyWeekly {
+		return "weekly"
+	}
+	return "daily"
+}
+
+func digestExcerpt(message string) string {
+	collapsedMessage := strings.Join(strings.Fields(message), " ")
+	runes := []rune(collapsedMessage)
+	if len(runes) <= digestExcerptMaxRunes {
+		return collapsedMessage
+	}
+	return strings.TrimSpace(string(runes[:digestExcerptMaxRunes])) + "…"
+}
+
+func formatDigestWindow(since time.Time, now time.Time) string {
+	return fmt.Sprintf("%s – %s UTC", since.UTC().Format("Jan 2 15:04"), now.UTC().Format("Jan 2 15:04"))
+}
+
+func composeDigestText(periodLabel string, since time.Time, now time.Time, reports []digestSiteReport, unsubscribeURL string) string {
+	messageBuilder := &strings.Builder{}
+	_, _ = fmt.Fprintf(messageBuilder, "Your %s %s digest (%s)\n", digestProductName, periodLabel, formatDigestWindow(since, now))
+	for _, report := range reports {
+		_, _ = fmt.Fprintf(messageBuilder, "\n== %s ==\n", report.SiteName)
+		_, _ = fmt.Fprintf(messageBuilder, "New feedback: %d\n", report.FeedbackCount)
+		for _, excerpt := range report.FeedbackExcerpts {
+			_, _ = fmt.Fprintf(messageBuilder, "  - %s\n", excerpt)
+		}
+		_, _ = fmt.Fprintf(messageBuilder, "Subscribers: +%d new, -%d unsubscribed\n", report.NewSubscribers, report.UnsubscribedCount)
+		_, _ = fmt.Fprintf(messageBuilder, "Page views: %d, unique visitors: %d\n", report.PageViews, report.UniqueVisitors)
+		if len(report.TopPages) > 0 {
+			_, _ = fmt.Fprintf(messageBuilder, "Top pages:\n")
+			for _, page := range report.TopPages {
+				_, _ = fmt.Fprintf(messageBuilder, "  %s (%d)\n", page.Path, page.VisitCount)
+			}
+		}
+	}
+	_, _ = fmt.Fprintf(messageBuilder, "\n--\nYou are receiving this email because you turned on %s digests.\n", periodLabel)
+	_, _ = fmt.Fprintf(messageBuilder, "Stop digests: %s\n", unsubscribeURL)
+	return messageBuilder.String()
+}
+
+func composeDigestHTML(periodLabel string, since time.Time, now time.Time, reports []digestSiteReport, unsubscribeURL string) string {
+	messageBuilder := &strings.Builder{}
+	_, _ = fmt.Fprintf(messageBuilder, "<h1>Your %s %s digest</h1>\n<p>%s</p>\n", digestProductName, periodLabel, html.EscapeString(formatDigestWindow(since, now)))
+	for _, report := range reports {
+		_, _ = fmt.Fprintf(messageBuilder, "<h2>%s</h2>\n<ul>\n", html.EscapeString(report.SiteName))
+		_, _ = fmt.Fprintf(messageBuilder, "<li>New feedback: %d</li>\n", report.FeedbackCount)
+		_, _ = fmt.Fprintf(messageBuilder, "<li>Subscribers: +%d new, -%d unsubscribed</li>\n", report.NewSubscribers, report.UnsubscribedCount)
+		_, _ = fmt.Fprintf(messageBuilder, "<li>Page views: %d, unique visitors: %d</li>\n</ul>\n", report.PageViews, report.UniqueVisitors)
+		for _, excerpt := range report.FeedbackExcerpts {
+			_, _ = fmt.Fprintf(messageBuilder, "<blockquote>%s</blockquote>\n", html.EscapeString(excerpt))
+		}
+		if len(report.TopPages) > 0 {
+			_, _ = fmt.Fprintf(messageBuilder, "<p>Top pages:</p>\n<ol>\n")
+			for _, page := range report.TopPages {
+				_, _ = fmt.Fprintf(messageBuilder, "<li>%s (%d)</li>\n", html.EscapeString(page.Path), page.VisitCount)
+			}
+			_, _ = fmt.Fprintf(messageBuilder, "</ol>\n")
+		}
+	}
+	_, _ = fmt.Fprintf(messageBuilder, "<hr>\n<p>You are receiving this email because you turned on %s digests.</p>\n", periodLabel)
+	_, _ = fmt.Fprintf(messageBuilder, "<p><a href=\"%s\">Stop digests</a></p>\n", html.EscapeString(unsubscribeURL))
+	return messageBuilder.String()
+}
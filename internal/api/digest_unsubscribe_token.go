@@ -0,0 +1,86 @@
+package api
+
+import (
+	"crypto/hmac"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var ErrInvalidDigestUnsubscribeToken = errors.New("invalid_digest_unsubscribe_token")
+
+const (
+	digestUnsubscribeTokenPurpose = "digest_unsubscribe"
+	digestUnsubscribeTokenTTL     = 90 * 24 * time.Hour
+)
+
+type digestUnsubscribeTokenPayload struct {
+	Purpose   string `json:"purpose"`
+	Email     string `json:"email"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// buildDigestUnsubscribeToken signs an email address for the digest opt-out link.
+// The purpose field keeps these tokens from being accepted as subscription tokens and vice versa.
+func buildDigestUnsubscribeToken(secret string, email string, now time.Time) (string, error) {
+	trimmedSecret := strings.TrimSpace(secret)
+	if trimmedSecret == "" {
+		return "", fmt.Errorf("%w: missing secret", ErrInvalidDigestUnsubscribeToken)
+	}
+	normalizedEmail := strings.TrimSpace(strings.ToLower(email))
+	if normalizedEmail == "" {
+		return "", fmt.Errorf("%w: missing email", ErrInvalidDigestUnsubscribeToken)
+	}
+
+	encodedPayload, marshalErr := json.Marshal(digestUnsubscribeTokenPayload{
+		Purpose:   digestUnsubscribeTokenPurpose,
+		Email:     normalizedEmail,
+		ExpiresAt: now.Add(digestUnsubscribeTokenTTL).Unix(),
+	})
+	if marshalErr != nil {
+		return "", fmt.Errorf("%w: encode payload: %v", ErrInvalidDigestUnsubscribeToken, marshalErr)
+	}
+
+	encodedPayloadSegment := base64.RawURLEncoding.EncodeToString(encodedPayload)
+	signature := signSubscriptionConfirmationToken(trimmedSecret, encodedPayloadSegment)
+	return encodedPayloadSegment + subscriptionConfirmationTokenSeparator + signature, nil
+}
+
+func parseDigestUnsubscribeToken(secret string, rawToken string, now time.Time) (string, error) {
+	trimmedSecret := strings.TrimSpace(secret)
+	if trimmedSecret == "" {
+		return "", fmt.Errorf("%w: missing secret", ErrInvalidDigestUnsubscribeToken)
+	}
+
+	parts := strings.Split(strings.TrimSpace(rawToken), subscriptionConfirmationTokenSeparator)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", fmt.Errorf("%w: malformed", ErrInvalidDigestUnsubscribeToken)
+	}
+	expectedSignature := signSubscriptionConfirmationToken(trimmedSecret, parts[0])
+	if !hmac.Equal([]byte(parts[1]), []byte(expectedSignature)) {
+		return "", fmt.Errorf("%w: signature mismatch", ErrInvalidDigestUnsubscribeToken)
+	}
+
+	decodedPayload, decodeErr := base64.RawURLEncoding.DecodeString(parts[0])
+	if decodeErr != nil {
+		return "", fmt.Errorf("%w: decode payload", ErrInvalidDigestUnsubscribeToken)
+	}
+	var payload digestUnsubscribeTokenPayload
+	if unmarshalErr := json.Unmarshal(decodedPayload, &payload); unmarshalErr != nil {
+		return "", fmt.Errorf("%w: unmarshal payload", ErrInvalidDigestUnsubscribeToken)
+	}
+	if payload.Purpose != digestUnsubscribeTokenPurpose {
+		return "", fmt.Errorf("%w: wrong purpose", ErrInvalidDigestUnsubscribeToken)
+	}
+	normalizedEmail := strings.TrimSpace(strings.ToLower(payload.Email))
+	if normalizedEmail == "" {
+		return "", fmt.Errorf("%w: missing email", ErrInvalidDigestUnsubscribeToken)
+	}
+	if payload.ExpiresAt <= 0 || now.Unix() > payload.ExpiresAt {
+		return "", fmt.Errorf("%w: expired", ErrInvalidDigestUnsubscribeToken)
+	}
+	return normalizedEmail, nil
+}
@@ -0,0 +1,150 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"github.com/MarkoPoloResearchLab/loopaware/internal/model"
+)
+
+const (
+	errorValueInvalidDigestFrequency = "invalid_digest_frequency"
+	errorValueInvalidDigestToken     = "invalid_digest_token"
+	errorValueDigestUnavailable      = "digest_unavailable"
+	digestStatusUnsubscribed         = "unsubscribed"
+)
+
+// DigestHandlers manages the caller's digest preference and the emailed opt-out link.
+type DigestHandlers struct {
+	database    *gorm.DB
+	logger      *zap.Logger
+	tokenSecret string
+	now         func() time.Time
+}
+
+// NewDigestHandlers constructs handlers that verify opt-out links with tokenSecret.
+func NewDigestHandlers(database *gorm.DB, logger *zap.Logger, tokenSecret string) *DigestHandlers {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &DigestHandlers{
+		database:    database,
+		logger:      logger,
+		tokenSecret: strings.TrimSpace(tokenSecret),
+		now:         time.Now,
+	}
+}
+
+type digestPreferenceResponse struct {
+	Frequency  string `json:"frequency"`
+	LastSentAt int64  `json:"last_sent_at,omitempty"`
+}
+
+// Preference returns the caller's digest frequency.
+func (handlers *DigestHandlers) Preference(context *gin.Context) {
+	currentUser, ok := CurrentUserFromContext(context)
+	if !ok {
+		context.JSON(http.StatusUnauthorized, gin.H{jsonKeyError: authErrorUnauthorized})
+		return
+	}
+
+	var user model.User
+	findErr := handlers.database.WithContext(context.Request.Context()).
+		Select("email", "digest_frequency", "digest_last_sent_at").
+		First(&user, "email = ?", currentUser.normalizedEmail()).Error
+	if errors.Is(findErr, gorm.ErrRecordNotFound) {
+		context.JSON(http.StatusOK, digestPreferenceResponse{Frequency: model.DigestFrequencyOff})
+		return
+	}
+	if findErr != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{jsonKeyError: errorValueQueryFailed})
+		return
+	}
+	context.JSON(http.StatusOK, buildDigestPreferenceResponse(user))
+}
+
+// UpdatePreference sets the caller's digest frequency to off, daily, or weekly.
+func (handlers *DigestHandlers) UpdatePreference(context *gin.Context) {
+	currentUser, ok := CurrentUserFromContext(context)
+	if !ok {
+		context.JSON(http.StatusUnauthorized, gin.H{jsonKeyError: authErrorUnauthorized})
+		return
+	}
+
+	var payload struct {
+		Frequency string `json:"frequency"`
+	}
+	if bindErr := context.ShouldBindJSON(&payload); bindErr != nil {
+		context.JSON(http.StatusBadRequest, gin.H{jsonKeyError: errorValueInvalidJSON})
+		return
+	}
+	frequency, frequencyErr := model.NormalizeDigestFrequency(payload.Frequency)
+	if frequencyErr != nil {
+		context.JSON(http.StatusBadRequest, gin.H{jsonKeyError: errorValueInvalidDigestFrequency})
+		return
+	}
+
+	database := handlers.database.WithContext(context.Request.Context())
+	normalizedEmail := currentUser.normalizedEmail()
+	updateResult := database.Model(&model.User{}).Where("email = ?", normalizedEmail).Update("digest_frequency", frequency)
+	if updateResult.Error != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{jsonKeyError: errorValueQueryFailed})
+		return
+	}
+	if updateResult.RowsAffected == 0 {
+		user := model.User{Email: normalizedEmail, Name: strings.TrimSpace(currentUser.Name), DigestFrequency: frequency}
+		if createErr := database.Create(&user).Error; createErr != nil {
+			context.JSON(http.StatusInternalServerError, gin.H{jsonKeyError: errorValueSaveFailed})
+			return
+		}
+	}
+
+	var user model.User
+	if err := database.Select("email", "digest_frequency", "digest_last_sent_at").First(&user, "email = ?", normalizedEmail).Error; err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{jsonKeyError: errorValueQueryFailed})
+		return
+	}
+	context.JSON(http.StatusOK, buildDigestPreferenceResponse(user))
+}
+
+// Unsubscribe turns digests off for the address in a signed opt-out link; it accepts GET and one-click POST requests.
+func (handlers *DigestHandlers) Unsubscribe(context *gin.Context) {
+	if handlers.tokenSecret == "" {
+		context.JSON(http.StatusServiceUnavailable, gin.H{"error": errorValueDigestUnavailable})
+		return
+	}
+	email, tokenErr := parseDigestUnsubscribeToken(handlers.tokenSecret, context.Query("token"), handlers.now().UTC())
+	if tokenErr != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"error": errorValueInvalidDigestToken})
+		return
+	}
+
+	updateErr := handlers.database.WithContext(context.Request.Context()).
+		Model(&model.User{}).
+		Where("email = ?", email).
+		Update("digest_frequency", model.DigestFrequencyOff).Error
+	if updateErr != nil {
+		requestLogger(context, handlers.logger).Warn("digest_unsubscribe_failed", zap.Error(updateErr))
+		context.JSON(http.StatusInternalServerError, gin.H{"error": errorValueSaveFailed})
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{"status": digestStatusUnsubscribed, "email": email})
+}
+
+func buildDigestPreferenceResponse(user model.User) digestPreferenceResponse {
+	frequency, frequencyErr := model.NormalizeDigestFrequency(user.DigestFrequency)
+	if frequencyErr != nil {
+		frequency = model.DigestFrequencyOff
+	}
+	response := digestPreferenceResponse{Frequency: frequency}
+	if !user.DigestLastSentAt.IsZero() {
+		response.LastSentAt = user.DigestLastSentAt.Unix()
+	}
+	return response
+}
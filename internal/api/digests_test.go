@@ -0,0 +1,226 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"github.com/MarkoPoloResearchLab/loopaware/internal/api"
+	"github.com/MarkoPoloResearchLab/loopaware/internal/mailer"
+	"github.com/MarkoPoloResearchLab/loopaware/internal/model"
+)
+
+const (
+	testDigestBaseURL     = "https://loopaware.example.com"
+	testDigestTokenSecret = "digest-secret"
+	testDigestOwnerEmail  = "owner@example.com"
+)
+
+type recordingMessageSender struct {
+	mu       sync.Mutex
+	messages []mailer.Message
+}
+
+func (sender *recordingMessageSender) SendEmail(ctx context.Context, recipient string, subject string, message string) error {
+	return sender.SendEmailMessage(ctx, mailer.Message{Recipient: recipient, Subject: subject, TextBody: message})
+}
+
+func (sender *recordingMessageSender) SendEmailMessage(_ context.Context, message mailer.Message) error {
+	sender.mu.Lock()
+	defer sender.mu.Unlock()
+	sender.messages = append(sender.messages, message)
+	return nil
+}
+
+func (sender *recordingMessageSender) Messages() []mailer.Message {
+	sender.mu.Lock()
+	defer sender.mu.Unlock()
+	return append([]mailer.Message(nil), sender.messages...)
+}
+
+type digestHarness struct {
+	database *gorm.DB
+	site     model.Site
+	sender   *recordingMessageSender
+	now      time.Time
+}
+
+func newDigestHarness(testingT *testing.T) *digestHarness {
+	testingT.Helper()
+
+	database := newSiteTestHarness(testingT).database
+
+	site := insertSite(testingT, database, "Digest Site", "http://digest.example", testDigestOwnerEmail)
+	insertSite(testingT, database, "Someone Else", "http://else.example", "else@example.com")
+
+	return &digestHarness{
+		database: database,
+		site:     site,
+		sender:   &recordingMessageSender{},
+		now:      time.Now().UTC(),
+	}
+}
+
+func (harness *digestHarness) reporter() *api.DigestReporter {
+	return api.NewDigestReporter(harness.database, zap.NewNop(), harness.sender, nil, testDigestBaseURL, testDigestTokenSecret,
+		api.WithDigestClock(func() time.Time { return harness.now }))
+}
+
+func (harness *digestHarness) createUser(testingT *testing.T, email string, frequency string) {
+	testingT.Helper()
+	require.NoError(testingT, harness.database.Create(&model.User{Email: email, Name: "Owner", DigestFrequency: frequency}).Error)
+}
+
+func (harness *digestHarness) insertFeedback(testingT *testing.T, message string, age time.Duration) {
+	testingT.Helper()
+	feedback, feedbackErr := model.NewFeedback(model.FeedbackInput{SiteID: harness.site.ID, Message: message})
+	require.NoError(testingT, feedbackErr)
+	feedback.CreatedAt = harness.now.Add(-age)
+	require.NoError(testingT, harness.database.Create(&feedback).Error)
+}
+
+func TestDigestReporterSendsDueDigestsOnce(testingT *testing.T) {
+	harness := newDigestHarness(testingT)
+	harness.createUser(testingT, testDigestOwnerEmail, model.DigestFrequencyDaily)
+	harness.createUser(testingT, "quiet@example.com", model.DigestFrequencyOff)
+	harness.createUser(testingT, "else@example.com", model.DigestFrequencyWeekly)
+	harness.createUser(testingT, "siteless@example.com", model.DigestFrequencyDaily)
+
+	harness.insertFeedback(testingT, "Checkout <button> is broken", time.Hour)
+	harness.insertFeedback(testingT, "Old news", 48*time.Hour)
+	subscriber, subscriberErr := model.NewSubscriber(model.SubscriberInput{SiteID: harness.site.ID, Email: "reader@example.com"})
+	require.NoError(testingT, subscriberErr)
+	subscriber.CreatedAt = harness.now.Add(-time.Hour)
+	require.NoError(testingT, harness.database.Create(&subscriber).Error)
+	yesterday := harness.now.Truncate(24 * time.Hour).Add(-12 * time.Hour)
+	for _, path := range []string{"/pricing", "/pricing", "/docs"} {
+		visit, visitErr := model.NewSiteVisit(model.SiteVisitInput{SiteID: harness.site.ID, URL: "http://digest.example" + path, Occurred: yesterday})
+		require.NoError(testingT, visitErr)
+		require.NoError(testingT, harness.database.Create(&visit).Error)
+	}
+	for path, occurred := range map[string]time.Time{"/stale": yesterday.Add(-48 * time.Hour), "/today": harness.now.Truncate(24 * time.Hour)} {
+		visit, visitErr := model.NewSiteVisit(model.SiteVisitInput{SiteID: harness.site.ID, URL: "http://digest.example" + path, Occurred: occurred})
+		require.NoError(testingT, visitErr)
+		require.NoError(testingT, harness.database.Create(&visit).Error)
+	}
+
+	reporter := harness.reporter()
+	result, sendErr := reporter.SendDueDigests(context.Background())
+	require.NoError(testingT, sendErr)
+	require.Equal(testingT, 2, result.Sent)
+
+	messages := harness.sender.Messages()
+	require.Len(testingT, messages, 2)
+	ownerMessage := messages[1]
+	require.Equal(testingT, "else@example.com", messages[0].Recipient)
+	require.Equal(testingT, testDigestOwnerEmail, ownerMessage.Recipient)
+	require.Equal(testingT, "Your LoopAware daily digest", ownerMessage.Subject)
+	require.Contains(testingT, ownerMessage.TextBody, "== Digest Site ==")
+	require.NotContains(testingT, ownerMessage.TextBody, "Someone Else")
+	require.Contains(testingT, ownerMessage.TextBody, "New feedback: 1")
+	require.Contains(testingT, ownerMessage.TextBody, "Checkout <button> is broken")
+	require.NotContains(testingT, ownerMessage.TextBody, "Old news")
+	require.Contains(testingT, ownerMessage.TextBody, "Subscribers: +1 new, -0 unsubscribed")
+	require.Contains(testingT, ownerMessage.TextBody, "Page views: 3")
+	require.Contains(testingT, ownerMessage.TextBody, "/pricing (2)")
+	require.NotContains(testingT, ownerMessage.TextBody, "/stale")
+	require.NotContains(testingT, ownerMessage.TextBody, "/today")
+	require.Contains(testingT, ownerMessage.HTMLBody, "Checkout &lt;button&gt; is broken")
+	require.True(testingT, strings.HasPrefix(ownerMessage.UnsubscribeURL, testDigestBaseURL+"/public/digests/unsubscribe?token="))
+	require.Contains(testingT, ownerMessage.TextBody, ownerMessage.UnsubscribeURL)
+
+	var owner model.User
+	require.NoError(testingT, harness.database.First(&owner, "email = ?", testDigestOwnerEmail).Error)
+	require.Equal(testingT, harness.now, owner.DigestLastSentAt.UTC())
+
+	harness.now = harness.now.Add(23 * time.Hour)
+	secondResult, secondErr := reporter.SendDueDigests(context.Background())
+	require.NoError(testingT, secondErr)
+	require.Zero(testingT, secondResult.Sent)
+
+	harness.now = harness.now.Add(time.Hour)
+	thirdResult, thirdErr := reporter.SendDueDigests(context.Background())
+	require.NoError(testingT, thirdErr)
+	require.Equal(testingT, 1, thirdResult.Sent)
+}
+
+func TestDigestReporterToleratesEarlierRunWithinTheHour(testingT *testing.T) {
+	harness := newDigestHarness(testingT)
+	harness.now = time.Date(2026, time.March, 2, 9, 5, 40, 0, time.UTC)
+	harness.createUser(testingT, testDigestOwnerEmail, model.DigestFrequencyDaily)
+
+	reporter := harness.reporter()
+	firstResult, firstErr := reporter.SendDueDigests(context.Background())
+	require.NoError(testingT, firstErr)
+	require.Equal(testingT, 1, firstResult.Sent)
+
+	harness.now = time.Date(2026, time.March, 3, 9, 5, 2, 0, time.UTC)
+	secondResult, secondErr := reporter.SendDueDigests(context.Background())
+	require.NoError(testingT, secondErr)
+	require.Equal(testingT, 1, secondResult.Sent)
+	require.Len(testingT, harness.sender.Messages(), 2)
+}
+
+func TestDigestReporterSkipsWithoutUnsubscribeLink(testingT *testing.T) {
+	harness := newDigestHarness(testingT)
+	harness.createUser(testingT, testDigestOwnerEmail, model.DigestFrequencyDaily)
+
+	reporter := api.NewDigestReporter(harness.database, zap.NewNop(), harness.sender, nil, "", testDigestTokenSecret)
+	result, sendErr := reporter.SendDueDigests(context.Background())
+	require.NoError(testingT, sendErr)
+	require.Zero(testingT, result.Sent)
+	require.Empty(testingT, harness.sender.Messages())
+}
+
+func TestDigestPreferenceRoundTripAndUnsubscribeLink(testingT *testing.T) {
+	harness := newDigestHarness(testingT)
+	handlers := api.NewDigestHandlers(harness.database, zap.NewNop(), testDigestTokenSecret)
+	router := newAuthenticatedRouter(&api.CurrentUser{Email: "Owner@Example.com", Name: "Owner", Role: api.RoleUser})
+	router.GET("/api/me/digest", handlers.Preference)
+	router.PATCH("/api/me/digest", handlers.UpdatePreference)
+	router.GET("/public/digests/unsubscribe", handlers.Unsubscribe)
+	router.POST("/public/digests/unsubscribe", handlers.Unsubscribe)
+
+	recorder := performJSONRequest(testingT, router, http.MethodGet, "/api/me/digest", nil, nil)
+	require.Equal(testingT, http.StatusOK, recorder.Code)
+	require.JSONEq(testingT, `{"frequency":"off"}`, recorder.Body.String())
+
+	recorder = performJSONRequest(testingT, router, http.MethodPatch, "/api/me/digest", map[string]string{"frequency": "monthly"}, nil)
+	require.Equal(testingT, http.StatusBadRequest, recorder.Code)
+
+	recorder = performJSONRequest(testingT, router, http.MethodPatch, "/api/me/digest", map[string]string{"frequency": "Weekly"}, nil)
+	require.Equal(testingT, http.StatusOK, recorder.Code, recorder.Body.String())
+	require.JSONEq(testingT, `{"frequency":"weekly"}`, recorder.Body.String())
+
+	reporter := harness.reporter()
+	result, sendErr := reporter.SendDueDigests(context.Background())
+	require.NoError(testingT, sendErr)
+	require.Equal(testingT, 1, result.Sent)
+	unsubscribeURL, parseErr := url.Parse(harness.sender.Messages()[0].UnsubscribeURL)
+	require.NoError(testingT, parseErr)
+
+	recorder = performJSONRequest(testingT, router, http.MethodPost, "/public/digests/unsubscribe?token=tampered"+unsubscribeURL.Query().Get("token"), nil, nil)
+	require.Equal(testingT, http.StatusBadRequest, recorder.Code)
+
+	recorder = performJSONRequest(testingT, router, http.MethodPost, unsubscribeURL.RequestURI(), nil, nil)
+	require.Equal(testingT, http.StatusOK, recorder.Code, recorder.Body.String())
+	var unsubscribeResponse map[string]string
+	require.NoError(testingT, json.Unmarshal(recorder.Body.Bytes(), &unsubscribeResponse))
+	require.Equal(testingT, testDigestOwnerEmail, unsubscribeResponse["email"])
+
+	recorder = performJSONRequest(testingT, router, http.MethodGet, "/api/me/digest", nil, nil)
+	require.Equal(testingT, http.StatusOK, recorder.Code)
+	var preference map[string]any
+	require.NoError(testingT, json.Unmarshal(recorder.Body.Bytes(), &preference))
+	require.Equal(testingT, model.DigestFrequencyOff, preference["frequency"])
+	require.NotZero(testingT, preference["last_sent_at"])
+}
@@ -1,8 +1,26 @@
 package api
 
-import "context"
+import (
+	"context"
+
+	"github.com/MarkoPoloResearchLab/loopaware/internal/mailer"
+)
 
 // EmailSender sends an email message to a recipient.
 type EmailSender interface {
 	SendEmail(ctx context.Context, recipient string, subject string, message string) error
 }
+
+// EmailMessageSender sends multipart email with an HTML alternative and list-unsubscribe headers.
+type EmailMessageSender interface {
+	SendEmailMessage(ctx context.Context, message mailer.Message) error
+}
+
+// sendEmailMessage delivers the full message when the sender supports it (SMTP) and otherwise sends only the text
+// body, dropping the HTML part and List-Unsubscribe headers (Pinguin).
+func sendEmailMessage(ctx context.Context, emailSender EmailSender, message mailer.Message) error {
+	if messageSender, ok := emailSender.(EmailMessageSender); ok {
+		return messageSender.SendEmailMessage(ctx, message)
+	}
+	return emailSender.SendEmail(ctx, message.Recipient, message.Subject, message.TextBody)
+}
@@ -0,0 +1,88 @@
+package api_test
+
+import (
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/MarkoPoloResearchLab/loopaware/internal/api"
+	"github.com/MarkoPoloResearchLab/loopaware/internal/mailer"
+	"github.com/MarkoPoloResearchLab/loopaware/internal/model"
+	"github.com/MarkoPoloResearchLab/loopaware/internal/storage"
+	"github.com/MarkoPoloResearchLab/loopaware/internal/testutil"
+)
+
+func TestConfirmationEmailOverSMTPSupportsOneClickUnsubscribe(testingT *testing.T) {
+	gin.SetMode(gin.TestMode)
+	sqliteDatabase := testutil.NewSQLiteTestDatabase(testingT)
+	database, openErr := storage.OpenDatabase(sqliteDatabase.Configuration())
+	require.NoError(testingT, openErr)
+	require.NoError(testingT, storage.AutoMigrate(database))
+
+	smtpServer := testutil.NewSMTPTestServer(testingT, testutil.SMTPModeStartTLS, "", "")
+	smtpSender, senderErr := mailer.NewSMTPSender(zap.NewNop(), mailer.SMTPConfig{
+		Host:      smtpServer.Host,
+		Port:      smtpServer.Port,
+		From:      "noreply@loopaware.test",
+		TLSConfig: smtpServer.ClientTLSConfig(),
+		Timeout:   5 * time.Second,
+	})
+	require.NoError(testingT, senderErr)
+
+	publicHandlers := api.NewPublicHandlers(database, zap.NewNop(), nil, nil, nil, nil, false, "http://loopaware.test", "unit-test-session-secret", smtpSender)
+	router := gin.New()
+	router.POST("/public/subscriptions", publicHandlers.CreateSubscription)
+	router.POST("/public/subscriptions/unsubscribe-link", publicHandlers.UnsubscribeSubscriptionLinkJSON)
+
+	site := insertSite(testingT, database, "SMTP Site", "http://smtp.example", "owner@example.com")
+	subscribeRecorder := performJSONRequest(testingT, router, http.MethodPost, "/public/subscriptions", map[string]any{
+		"site_id": site.ID,
+		"email":   "reader@example.com",
+	}, map[string]string{"Origin": "http://smtp.example"})
+	require.Equal(testingT, http.StatusOK, subscribeRecorder.Code, subscribeRecorder.Body.String())
+
+	messages := smtpServer.Messages()
+	require.Len(testingT, messages, 1)
+	require.True(testingT, messages[0].TLS)
+	parsed, parseErr := mail.ReadMessage(strings.NewReader(messages[0].Data))
+	require.NoError(testingT, parseErr)
+	require.Equal(testingT, "Confirm your subscription to SMTP Site", parsed.Header.Get("Subject"))
+	require.Equal(testingT, "List-Unsubscribe=One-Click", parsed.Header.Get("List-Unsubscribe-Post"))
+
+	mediaType, parameters, mediaErr := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	require.NoError(testingT, mediaErr)
+	require.Equal(testingT, "multipart/alternative", mediaType)
+	partsReader := multipart.NewReader(parsed.Body, parameters["boundary"])
+	contentTypes := []string{}
+	for {
+		part, partErr := partsReader.NextPart()
+		if partErr == io.EOF {
+			break
+		}
+		require.NoError(testingT, partErr)
+		contentTypes = append(contentTypes, part.Header.Get("Content-Type"))
+	}
+	require.Equal(testingT, []string{"text/plain; charset=utf-8", "text/html; charset=utf-8"}, contentTypes)
+
+	listUnsubscribe := strings.Trim(parsed.Header.Get("List-Unsubscribe"), "<>")
+	unsubscribeURL, urlErr := url.Parse(listUnsubscribe)
+	require.NoError(testingT, urlErr)
+	require.Equal(testingT, "/public/subscriptions/unsubscribe-link", unsubscribeURL.Path)
+
+	oneClickRecorder := performJSONRequest(testingT, router, http.MethodPost, unsubscribeURL.RequestURI(), nil, nil)
+	require.Equal(testingT, http.StatusOK, oneClickRecorder.Code, oneClickRecorder.Body.String())
+
+	var subscriber model.Subscriber
+	require.NoError(testingT, database.First(&subscriber, "email = ?", "reader@example.com").Error)
+	require.Equal(testingT, model.SubscriberStatusUnsubscribed, subscriber.Status)
+}
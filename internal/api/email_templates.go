@@ -0,0 +1,344 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/MarkoPoloResearchLab/loopaware/internal/emailtemplate"
+	"github.com/MarkoPoloResearchLab/loopaware/internal/model"
+)
+
+const (
+	errorValueInvalidTemplateKind = "invalid_template_kind"
+	errorValueInvalidLocale       = "invalid_locale"
+	errorValueInvalidTemplate     = "invalid_template"
+	errorValueUnknownTemplate     = "unknown_template"
+
+	jsonKeyField = "field"
+
+	emailTemplateRouteParamKind = "kind"
+	emailTemplateQueryLocale    = "locale"
+)
+
+// DatabaseEmailTemplateSource loads per-site email template overrides from the database.
+type DatabaseEmailTemplateSource struct {
+	database *gorm.DB
+}
+
+// NewDatabaseEmailTemplateSource constructs a template source backed by the email_templates table.
+func NewDatabaseEmailTemplateSource(database *gorm.DB) *DatabaseEmailTemplateSource {
+	return &DatabaseEmailTemplateSource{database: database}
+}
+
+// FindEmailTemplate returns the stored template for a site, kind, and exact locale.
+func (source *DatabaseEmailTemplateSource) FindEmailTemplate(ctx context.Context, siteID string, kind string, locale string) (emailtemplate.Content, bool, error) {
+	if source == nil || source.database == nil {
+		return emailtemplate.Content{}, false, nil
+	}
+	var stored model.EmailTemplate
+	findErr := source.database.WithContext(ctx).
+		Where("site_id = ? AND kind = ? AND locale = ?", siteID, kind, locale).
+		First(&stored).Error
+	if errors.Is(findErr, gorm.ErrRecordNotFound) {
+		return emailtemplate.Content{}, false, nil
+	}
+	if findErr != nil {
+		return emailtemplate.Content{}, false, findErr
+	}
+	return emailTemplateContent(stored), true, nil
+}
+
+// EmailTemplateHandlers manages per-site email templates.
+type EmailTemplateHandlers struct {
+	database *gorm.DB
+	logger   *zap.Logger
+	renderer *emailtemplate.Renderer
+}
+
+// NewEmailTemplateHandlers constructs handlers for email template management.
+func NewEmailTemplateHandlers(database *gorm.DB, logger *zap.Logger, renderer *emailtemplate.Renderer) *EmailTemplateHandlers {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &EmailTemplateHandlers{
+		database: database,
+		logger:   logger,
+		renderer: renderer,
+	}
+}
+
+type emailTemplateRequest struct {
+	Locale   string `json:"locale"`
+	Subject  string `json:"subject"`
+	TextBody string `json:"text_body"`
+	HTMLBody string `json:"html_body"`
+}
+
+type emailTemplatePreviewRequest struct {
+	Locale   string  `json:"locale"`
+	Subject  *string `json:"subject"`
+	TextBody *string `json:"text_body"`
+	HTMLBody *string `json:"html_body"`
+}
+
+// EmailTemplateKindResponse describes a template kind, its variables, and its built-in default.
+type EmailTemplateKindResponse struct {
+	Kind            string   `json:"kind"`
+	Variables       []string `json:"variables"`
+	DefaultSubject  string   `json:"default_subject"`
+	DefaultTextBody string   `json:"default_text_body"`
+	DefaultHTMLBody string   `json:"default_html_body"`
+}
+
+// EmailTemplateResponse describes a stored site template.
+type EmailTemplateResponse struct {
+	ID             string `json:"id"`
+	SiteID         string `json:"site_id"`
+	Kind           string `json:"kind"`
+	Locale         string `json:"locale"`
+	Subject        string `json:"subject"`
+	TextBody       string `json:"text_body"`
+	HTMLBody       string `json:"html_body"`
+	UpdatedByEmail string `json:"updated_by_email"`
+	UpdatedAt      int64  `json:"updated_at"`
+}
+
+// SiteEmailTemplatesResponse lists the template kinds and stored overrides for a site.
+type SiteEmailTemplatesResponse struct {
+	SiteID    string                      `json:"site_id"`
+	Kinds     []EmailTemplateKindResponse `json:"kinds"`
+	Templates []EmailTemplateResponse     `json:"templates"`
+}
+
+// EmailTemplatePreviewResponse contains a template rendered with sample data.
+type EmailTemplatePreviewResponse struct {
+	Kind     string `json:"kind"`
+	Locale   string `json:"locale"`
+	Subject  string `json:"subject"`
+	TextBody string `json:"text_body"`
+	HTMLBody string `json:"html_body"`
+}
+
+// ListEmailTemplates returns the supported template kinds and the site's stored overrides.
+func (handlers *EmailTemplateHandlers) ListEmailTemplates(context *gin.Context) {
+	site, _, ok := resolveManageableSite(context, handlers.database)
+	if !ok {
+		return
+	}
+
+	var storedTemplates []model.EmailTemplate
+	if err := handlers.database.Where("site_id = ?", site.ID).Order("kind asc, locale asc").Find(&storedTemplates).Error; err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{jsonKeyError: errorValueQueryFailed})
+		return
+	}
+
+	response := SiteEmailTemplatesResponse{
+		SiteID:    site.ID,
+		Kinds:     make([]EmailTemplateKindResponse, 0, len(model.EmailTemplateKinds)),
+		Templates: make([]EmailTemplateResponse, 0, len(storedTemplates)),
+	}
+	for _, kind := range model.EmailTemplateKinds {
+		defaultContent, _ := emailtemplate.Default(kind)
+		response.Kinds = append(response.Kinds, EmailTemplateKindResponse{
+			Kind:            kind,
+			Variables:       emailtemplate.Variables(kind),
+			DefaultSubject:  defaultContent.Subject,
+			DefaultTextBody: defaultContent.Text,
+			DefaultHTMLBody: defaultContent.HTML,
+		})
+	}
+	for _, storedTemplate := range storedTemplates {
+		response.Templates = append(response.Templates, toEmailTemplateResponse(storedTemplate))
+	}
+	context.JSON(http.StatusOK, response)
+}
+
+// SaveEmailTemplate validates and stores a site template for a kind and locale.
+func (handlers *EmailTemplateHandlers) SaveEmailTemplate(context *gin.Context) {
+	site, currentUser, ok := resolveManageableSite(context, handlers.database)
+	if !ok {
+		return
+	}
+
+	var request emailTemplateRequest
+	if err := context.ShouldBindJSON(&request); err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{jsonKeyError: errorValueInvalidJSON})
+		return
+	}
+
+	emailTemplate, templateErr := model.NewEmailTemplate(model.EmailTemplateInput{
+		SiteID:         site.ID,
+		Kind:           context.Param(emailTemplateRouteParamKind),
+		Locale:         request.Locale,
+		Subject:        request.Subject,
+		TextBody:       request.TextBody,
+		HTMLBody:       request.HTMLBody,
+		UpdatedByEmail: currentUser.normalizedEmail(),
+	})
+	if templateErr != nil {
+		context.JSON(http.StatusBadRequest, gin.H{jsonKeyError: emailTemplateErrorValue(templateErr)})
+		return
+	}
+
+	if validationErr := emailtemplate.Validate(emailTemplate.Kind, emailTemplateContent(emailTemplate)); validationErr != nil {
+		respondWithTemplateValidationError(context, validationErr)
+		return
+	}
+
+	saveErr := handlers.database.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "site_id"}, {Name: "kind"}, {Name: "locale"}},
+		DoUpdates: clause.AssignmentColumns([]string{"subject", "text_body", "html_body", "updated_by_email", "updated_at"}),
+	}).Create(&emailTemplate).Error
+	if saveErr != nil {
+		requestLogger(context, handlers.logger).Warn("save_email_template", zap.Error(saveErr), zap.String("site_id", site.ID), zap.String("kind", emailTemplate.Kind))
+		context.JSON(http.StatusInternalServerError, gin.H{jsonKeyError: errorValueSaveFailed})
+		return
+	}
+
+	var storedTemplate model.EmailTemplate
+	findErr := handlers.database.
+		Where("site_id = ? AND kind = ? AND locale = ?", site.ID, emailTemplate.Kind, emailTemplate.Locale).
+		First(&storedTemplate).Error
+	if findErr != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{jsonKeyError: errorValueQueryFailed})
+		return
+	}
+	context.JSON(http.StatusOK, toEmailTemplateResponse(storedTemplate))
+}
+
+// DeleteEmailTemplate removes a site template so the built-in default applies again.
+func (handlers *EmailTemplateHandlers) DeleteEmailTemplate(context *gin.Context) {
+	site, _, ok := resolveManageableSite(context, handlers.database)
+	if !ok {
+		return
+	}
+
+	kind, kindErr := model.NormalizeEmailTemplateKind(context.Param(emailTemplateRouteParamKind))
+	if kindErr != nil {
+		context.JSON(http.StatusBadRequest, gin.H{jsonKeyError: errorValueInvalidTemplateKind})
+		return
+	}
+	locale, localeErr := model.NormalizeLocale(context.Query(emailTemplateQueryLocale))
+	if localeErr != nil {
+		context.JSON(http.StatusBadRequest, gin.H{jsonKeyError: errorValueInvalidLocale})
+		return
+	}
+
+	deleteResult := handlers.database.
+		Where("site_id = ? AND kind = ? AND locale = ?", site.ID, kind, locale).
+		Delete(&model.EmailTemplate{})
+	if deleteResult.Error != nil {
+		requestLogger(context, handlers.logger).Warn("delete_email_template", zap.Error(deleteResult.Error), zap.String("site_id", site.ID), zap.String("kind", kind))
+		context.JSON(http.StatusInternalServerError, gin.H{jsonKeyError: errorValueDeleteFailed})
+		return
+	}
+	if deleteResult.RowsAffected == 0 {
+		context.JSON(http.StatusNotFound, gin.H{jsonKeyError: errorValueUnknownTemplate})
+		return
+	}
+	context.Status(http.StatusNoContent)
+}
+
+// PreviewEmailTemplate renders the supplied template, or the effective template when none is supplied, with sample data.
+func (handlers *EmailTemplateHandlers) PreviewEmailTemplate(context *gin.Context) {
+	site, _, ok := resolveManageableSite(context, handlers.database)
+	if !ok {
+		return
+	}
+
+	kind, kindErr := model.NormalizeEmailTemplateKind(context.Param(emailTemplateRouteParamKind))
+	if kindErr != nil {
+		context.JSON(http.StatusBadRequest, gin.H{jsonKeyError: errorValueInvalidTemplateKind})
+		return
+	}
+
+	var request emailTemplatePreviewRequest
+	if context.Request.ContentLength != 0 {
+		if err := context.ShouldBindJSON(&request); err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{jsonKeyError: errorValueInvalidJSON})
+			return
+		}
+	}
+	locale, localeErr := model.NormalizeLocale(request.Locale)
+	if localeErr != nil {
+		context.JSON(http.StatusBadRequest, gin.H{jsonKeyError: errorValueInvalidLocale})
+		return
+	}
+
+	content := handlers.renderer.Resolve(context.Request.Context(), site.ID, kind, locale)
+	if request.Subject != nil {
+		content.Subject = *request.Subject
+	}
+	if request.TextBody != nil {
+		content.Text = *request.TextBody
+	}
+	if request.HTMLBody != nil {
+		content.HTML = *request.HTMLBody
+	}
+
+	message, renderErr := emailtemplate.Render(kind, content, emailtemplate.SampleData(site.Name))
+	if renderErr != nil {
+		respondWithTemplateValidationError(context, renderErr)
+		return
+	}
+
+	context.JSON(http.StatusOK, EmailTemplatePreviewResponse{
+		Kind:     kind,
+		Locale:   locale,
+		Subject:  message.Subject,
+		TextBody: message.Text,
+		HTMLBody: message.HTML,
+	})
+}
+
+func respondWithTemplateValidationError(context *gin.Context, validationErr error) {
+	response := gin.H{jsonKeyError: errorValueInvalidTemplate}
+	var templateValidationError *emailtemplate.ValidationError
+	if errors.As(validationErr, &templateValidationError) {
+		response[jsonKeyField] = templateValidationError.Field
+	}
+	context.JSON(http.StatusBadRequest, response)
+}
+
+func emailTemplateErrorValue(templateErr error) string {
+	switch {
+	case errors.Is(templateErr, model.ErrInvalidEmailTemplateKind):
+		return errorValueInvalidTemplateKind
+	case errors.Is(templateErr, model.ErrInvalidLocale):
+		return errorValueInvalidLocale
+	case errors.Is(templateErr, model.ErrInvalidEmailTemplateSubject):
+		return errorValueInvalidSubject
+	case errors.Is(templateErr, model.ErrInvalidEmailTemplateBody):
+		return errorValueInvalidBody
+	default:
+		return errorValueInvalidTemplate
+	}
+}
+
+func emailTemplateContent(emailTemplate model.EmailTemplate) emailtemplate.Content {
+	return emailtemplate.Content{
+		Subject: emailTemplate.Subject,
+		Text:    emailTemplate.TextBody,
+		HTML:    emailTemplate.HTMLBody,
+	}
+}
+
+func toEmailTemplateResponse(emailTemplate model.EmailTemplate) EmailTemplateResponse {
+	return EmailTemplateResponse{
+		ID:             emailTemplate.ID,
+		SiteID:         emailTemplate.SiteID,
+		Kind:           emailTemplate.Kind,
+		Locale:         emailTemplate.Locale,
+		Subject:        emailTemplate.Subject,
+		TextBody:       emailTemplate.TextBody,
+		HTMLBody:       emailTemplate.HTMLBody,
+		UpdatedByEmail: emailTemplate.UpdatedByEmail,
+		UpdatedAt:      unixSecondsOrZero(emailTemplate.UpdatedAt),
+	}
+}
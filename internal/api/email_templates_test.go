@@ -0,0 +1,213 @@
+package api_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"github.com/MarkoPoloResearchLab/loopaware/internal/api"
+	"github.com/MarkoPoloResearchLab/loopaware/internal/emailtemplate"
+	"github.com/MarkoPoloResearchLab/loopaware/internal/model"
+)
+
+const (
+	testTemplateSiteOrigin = "http://templates.example"
+	testTemplateSubject    = "Bem-vindo a {{.SiteName}}"
+	testTemplateTextBody   = "Confirme aqui: {{.ConfirmationURL}}"
+)
+
+type emailTemplateHarness struct {
+	router   *gin.Engine
+	database *gorm.DB
+	site     model.Site
+	sender   *recordingEmailSender
+}
+
+func newEmailTemplateHarness(testingT *testing.T, currentUser *api.CurrentUser) emailTemplateHarness {
+	testingT.Helper()
+
+	database := newSiteTestHarness(testingT).database
+
+	site := insertSite(testingT, database, "Template Site", testTemplateSiteOrigin, testAdminEmailAddress)
+	sender := &recordingEmailSender{testingT: testingT}
+	renderer := emailtemplate.NewRenderer(api.NewDatabaseEmailTemplateSource(database), zap.NewNop())
+	handlers := api.NewEmailTemplateHandlers(database, zap.NewNop(), renderer)
+	publicHandlers := api.NewPublicHandlers(database, zap.NewNop(), nil, nil, nil, nil, false, "http://loopaware.test", "unit-test-session-secret", sender).WithEmailTemplates(renderer)
+
+	router := newAuthenticatedRouter(currentUser)
+	apiGroup := router.Group("/api")
+	apiGroup.GET("/sites/:id/email-templates", handlers.ListEmailTemplates)
+	apiGroup.PUT("/sites/:id/email-templates/:kind", handlers.SaveEmailTemplate)
+	apiGroup.DELETE("/sites/:id/email-templates/:kind", handlers.DeleteEmailTemplate)
+	apiGroup.POST("/sites/:id/email-templates/:kind/preview", handlers.PreviewEmailTemplate)
+	router.POST("/public/subscriptions", publicHandlers.CreateSubscription)
+
+	return emailTemplateHarness{router: router, database: database, site: site, sender: sender}
+}
+
+func (harness emailTemplateHarness) templatePath(kind string) string {
+	return fmt.Sprintf("/api/sites/%s/email-templates/%s", harness.site.ID, kind)
+}
+
+func TestEmailTemplateSaveListAndDelete(testingT *testing.T) {
+	harness := newEmailTemplateHarness(testingT, &api.CurrentUser{Email: testAdminEmailAddress, Role: api.RoleAdmin})
+	confirmationPath := harness.templatePath(model.EmailTemplateKindSubscriptionConfirmation)
+
+	saveRecorder := performJSONRequest(testingT, harness.router, http.MethodPut, confirmationPath, map[string]any{
+		"locale":    "pt_BR",
+		"subject":   testTemplateSubject,
+		"text_body": testTemplateTextBody,
+	}, nil)
+	require.Equal(testingT, http.StatusOK, saveRecorder.Code, saveRecorder.Body.String())
+	var saved api.EmailTemplateResponse
+	require.NoError(testingT, json.Unmarshal(saveRecorder.Body.Bytes(), &saved))
+	require.Equal(testingT, "pt-br", saved.Locale)
+	require.Equal(testingT, testAdminEmailAddress, saved.UpdatedByEmail)
+
+	updateRecorder := performJSONRequest(testingT, harness.router, http.MethodPut, confirmationPath, map[string]any{
+		"locale":    "pt-br",
+		"subject":   "Confirme {{.SiteName}}",
+		"text_body": testTemplateTextBody,
+	}, nil)
+	require.Equal(testingT, http.StatusOK, updateRecorder.Code)
+
+	listRecorder := performJSONRequest(testingT, harness.router, http.MethodGet, fmt.Sprintf("/api/sites/%s/email-templates", harness.site.ID), nil, nil)
+	require.Equal(testingT, http.StatusOK, listRecorder.Code)
+	var listed api.SiteEmailTemplatesResponse
+	require.NoError(testingT, json.Unmarshal(listRecorder.Body.Bytes(), &listed))
+	require.Len(testingT, listed.Kinds, len(model.EmailTemplateKinds))
+	require.Contains(testingT, listed.Kinds[0].Variables, emailtemplate.VariableConfirmationURL)
+	require.Len(testingT, listed.Templates, 1)
+	require.Equal(testingT, "Confirme {{.SiteName}}", listed.Templates[0].Subject)
+
+	deleteRecorder := performJSONRequest(testingT, harness.router, http.MethodDelete, confirmationPath+"?locale=pt-BR", nil, nil)
+	require.Equal(testingT, http.StatusNoContent, deleteRecorder.Code)
+
+	missingRecorder := performJSONRequest(testingT, harness.router, http.MethodDelete, confirmationPath+"?locale=pt-BR", nil, nil)
+	require.Equal(testingT, http.StatusNotFound, missingRecorder.Code)
+}
+
+func TestEmailTemplateSaveRejectsInvalidTemplates(testingT *testing.T) {
+	harness := newEmailTemplateHarness(testingT, &api.CurrentUser{Email: testAdminEmailAddress, Role: api.RoleAdmin})
+
+	testCases := []struct {
+		name          string
+		kind          string
+		payload       map[string]any
+		expectedError string
+		expectedField string
+	}{
+		{
+			name:          "unknown kind",
+			kind:          "welcome",
+			payload:       map[string]any{"subject": "Hi", "text_body": "Body"},
+			expectedError: "invalid_template_kind",
+		},
+		{
+			name:          "invalid locale",
+			kind:          model.EmailTemplateKindFeedbackNotification,
+			payload:       map[string]any{"locale": "not a locale", "subject": "Hi", "text_body": "Body"},
+			expectedError: "invalid_locale",
+		},
+		{
+			name:          "variable from another kind",
+			kind:          model.EmailTemplateKindFeedbackNotification,
+			payload:       map[string]any{"subject": "Hi", "text_body": "{{.ConfirmationURL}}"},
+			expectedError: "invalid_template",
+			expectedField: emailtemplate.FieldTextBody,
+		},
+		{
+			name:          "html syntax error",
+			kind:          model.EmailTemplateKindFeedbackNotification,
+			payload:       map[string]any{"subject": "Hi", "text_body": "Body", "html_body": "<p>{{.FeedbackMessage</p>"},
+			expectedError: "invalid_template",
+			expectedField: emailtemplate.FieldHTMLBody,
+		},
+	}
+
+	for _, testCase := range testCases {
+		testingT.Run(testCase.name, func(subTestingT *testing.T) {
+			recorder := performJSONRequest(subTestingT, harness.router, http.MethodPut, harness.templatePath(testCase.kind), testCase.payload, nil)
+			require.Equal(subTestingT, http.StatusBadRequest, recorder.Code)
+			var response map[string]string
+			require.NoError(subTestingT, json.Unmarshal(recorder.Body.Bytes(), &response))
+			require.Equal(subTestingT, testCase.expectedError, response["error"])
+			require.Equal(subTestingT, testCase.expectedField, response["field"])
+		})
+	}
+
+	var storedCount int64
+	require.NoError(testingT, harness.database.Model(&model.EmailTemplate{}).Count(&storedCount).Error)
+	require.Zero(testingT, storedCount)
+}
+
+func TestEmailTemplatePreviewRendersSampleData(testingT *testing.T) {
+	harness := newEmailTemplateHarness(testingT, &api.CurrentUser{Email: testAdminEmailAddress, Role: api.RoleAdmin})
+	previewPath := harness.templatePath(model.EmailTemplateKindFeedbackNotification) + "/preview"
+
+	defaultRecorder := performJSONRequest(testingT, harness.router, http.MethodPost, previewPath, nil, nil)
+	require.Equal(testingT, http.StatusOK, defaultRecorder.Code, defaultRecorder.Body.String())
+	var defaultPreview api.EmailTemplatePreviewResponse
+	require.NoError(testingT, json.Unmarshal(defaultRecorder.Body.Bytes(), &defaultPreview))
+	require.Equal(testingT, "New feedback for Template Site", defaultPreview.Subject)
+	require.Contains(testingT, defaultPreview.TextBody, emailtemplate.SampleData("").FeedbackMessage)
+
+	draftRecorder := performJSONRequest(testingT, harness.router, http.MethodPost, previewPath, map[string]any{
+		"subject":   "Feedback from {{.FeedbackContact}}",
+		"html_body": "<p>{{.FeedbackMessage}}</p>",
+	}, nil)
+	require.Equal(testingT, http.StatusOK, draftRecorder.Code)
+	var draftPreview api.EmailTemplatePreviewResponse
+	require.NoError(testingT, json.Unmarshal(draftRecorder.Body.Bytes(), &draftPreview))
+	require.Equal(testingT, "Feedback from reader@example.com", draftPreview.Subject)
+	require.Contains(testingT, draftPreview.HTMLBody, "<p>I love the new dashboard")
+
+	invalidRecorder := performJSONRequest(testingT, harness.router, http.MethodPost, previewPath, map[string]any{"text_body": "{{.UnsubscribeURL}}"}, nil)
+	require.Equal(testingT, http.StatusBadRequest, invalidRecorder.Code)
+}
+
+func TestEmailTemplatesRequireSiteAccess(testingT *testing.T) {
+	harness := newEmailTemplateHarness(testingT, &api.CurrentUser{Email: testUserEmailAddress, Role: api.RoleUser})
+
+	recorder := performJSONRequest(testingT, harness.router, http.MethodGet, fmt.Sprintf("/api/sites/%s/email-templates", harness.site.ID), nil, nil)
+	require.Equal(testingT, http.StatusForbidden, recorder.Code)
+}
+
+func TestConfirmationEmailUsesLocalizedSiteTemplate(testingT *testing.T) {
+	harness := newEmailTemplateHarness(testingT, &api.CurrentUser{Email: testAdminEmailAddress, Role: api.RoleAdmin})
+
+	saveRecorder := performJSONRequest(testingT, harness.router, http.MethodPut, harness.templatePath(model.EmailTemplateKindSubscriptionConfirmation), map[string]any{
+		"locale":    "pt",
+		"subject":   testTemplateSubject,
+		"text_body": testTemplateTextBody,
+	}, nil)
+	require.Equal(testingT, http.StatusOK, saveRecorder.Code)
+
+	subscribeRecorder := performJSONRequest(testingT, harness.router, http.MethodPost, "/public/subscriptions", map[string]any{
+		"site_id": harness.site.ID,
+		"email":   "leitor@example.com",
+	}, map[string]string{"Origin": testTemplateSiteOrigin, "Accept-Language": "pt-BR,pt;q=0.9,en;q=0.8"})
+	require.Equal(testingT, http.StatusOK, subscribeRecorder.Code, subscribeRecorder.Body.String())
+
+	require.Equal(testingT, 1, harness.sender.CallCount())
+	call := harness.sender.LastCall()
+	require.Equal(testingT, "Bem-vindo a Template Site", call.Subject)
+	require.Contains(testingT, call.Message, "Confirme aqui: http://loopaware.test/subscriptions/confirm?token=")
+
+	var subscriber model.Subscriber
+	require.NoError(testingT, harness.database.First(&subscriber, "email = ?", "leitor@example.com").Error)
+	require.Equal(testingT, "pt-br", subscriber.Locale)
+
+	englishRecorder := performJSONRequest(testingT, harness.router, http.MethodPost, "/public/subscriptions", map[string]any{
+		"site_id": harness.site.ID,
+		"email":   "reader@example.com",
+	}, map[string]string{"Origin": testTemplateSiteOrigin, "Accept-Language": "en-US"})
+	require.Equal(testingT, http.StatusOK, englishRecorder.Code)
+	require.Equal(testingT, "Confirm your subscription to Template Site", harness.sender.LastCall().Subject)
+}
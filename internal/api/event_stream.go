@@ -0,0 +1,162 @@
+package api
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultEventStreamHeartbeatInterval = 20 * time.Second
+	eventStreamAccessCacheTTL           = time.Minute
+	eventStreamHeartbeatComment         = ": heartbeat\n\n"
+	headerLastEventID                   = "Last-Event-ID"
+	eventStreamQueryLastEventID         = "last_event_id"
+	eventStreamQuerySiteID              = "site_id"
+	feedbackDroppedEventName            = "feedback_dropped"
+	serverShutdownEventName             = "server_shutdown"
+	serverShutdownReconnectDelay        = 5 * time.Second
+)
+
+// WithoutConnectionDeadlines clears the server read and write deadlines for the request so a long-lived event stream
+// is not cut off by the server's ReadTimeout or WriteTimeout. Writers that cannot change deadlines are left alone.
+func WithoutConnectionDeadlines() gin.HandlerFunc {
+	return func(context *gin.Context) {
+		controller := http.NewResponseController(context.Writer)
+		_ = controller.SetReadDeadline(time.Time{})
+		_ = controller.SetWriteDeadline(time.Time{})
+		context.Next()
+	}
+}
+
+// WithRollingWriteDeadline lets exports and downloads outlive the server's WriteTimeout while still bounding each
+// chunk: every write pushes the write deadline window into the future, so a client that stops reading is dropped
+// after window. The read deadline is cleared because an expired one cancels the request context mid-stream. A
+// non-positive window clears the write deadline as well.
+func WithRollingWriteDeadline(window time.Duration) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		controller := http.NewResponseController(context.Writer)
+		_ = controller.SetReadDeadline(time.Time{})
+		if window <= 0 {
+			_ = controller.SetWriteDeadline(time.Time{})
+			context.Next()
+			return
+		}
+		context.Writer = &rollingDeadlineWriter{ResponseWriter: context.Writer, controller: controller, window: window}
+		context.Next()
+	}
+}
+
+type rollingDeadlineWriter struct {
+	gin.ResponseWriter
+	controller *http.ResponseController
+	window     time.Duration
+}
+
+func (writer *rollingDeadlineWriter) extendDeadline() {
+	_ = writer.controller.SetWriteDeadline(time.Now().Add(writer.window))
+}
+
+func (writer *rollingDeadlineWriter) Write(data []byte) (int, error) {
+	writer.extendDeadline()
+	return writer.ResponseWriter.Write(data)
+}
+
+func (writer *rollingDeadlineWriter) WriteString(data string) (int, error) {
+	writer.extendDeadline()
+	return writer.ResponseWriter.WriteString(data)
+}
+
+func (writer *rollingDeadlineWriter) Flush() {
+	writer.extendDeadline()
+	writer.ResponseWriter.Flush()
+}
+
+// writeServerSentEvent writes one SSE frame and flushes it. The id line is omitted when eventID is zero.
+func writeServerSentEvent(writer http.ResponseWriter, flusher http.Flusher, eventID int64, eventName string, payload []byte) bool {
+	var buffer bytes.Buffer
+	if eventID > 0 {
+		buffer.WriteString("id: ")
+		buffer.WriteString(strconv.FormatInt(eventID, 10))
+		buffer.WriteString("\n")
+	}
+	buffer.WriteString("event: ")
+	buffer.WriteString(eventName)
+	buffer.WriteString("\n")
+	buffer.WriteString("data: ")
+	buffer.Write(payload)
+	buffer.WriteString("\n\n")
+	if _, writeErr := writer.Write(buffer.Bytes()); writeErr != nil {
+		return false
+	}
+	flusher.Flush()
+	return true
+}
+
+// writeServerSentHeartbeat writes an SSE comment so proxies keep idle streams open and dead clients surface as write errors.
+func writeServerSentHeartbeat(writer http.ResponseWriter, flusher http.Flusher) bool {
+	if _, writeErr := writer.Write([]byte(eventStreamHeartbeatComment)); writeErr != nil {
+		return false
+	}
+	flusher.Flush()
+	return true
+}
+
+// writeServerShutdownEvent tells the client that the stream ends because the server is shutting down. The retry
+// field asks EventSource to wait before reconnecting so the reconnect lands on the replacement instance.
+func writeServerShutdownEvent(writer http.ResponseWriter, flusher http.Flusher) bool {
+	reconnectAfter := serverShutdownReconnectDelay.Milliseconds()
+	if _, writeErr := writer.Write([]byte("retry: " + strconv.FormatInt(reconnectAfter, 10) + "\n")); writeErr != nil {
+		return false
+	}
+	payload := []byte(`{"reconnect_after_ms":` + strconv.FormatInt(reconnectAfter, 10) + `}`)
+	return writeServerSentEvent(writer, flusher, 0, serverShutdownEventName, payload)
+}
+
+// parseLastEventID reads the resume point from the Last-Event-ID header that EventSource sends on reconnect,
+// falling back to the last_event_id query parameter for the first connection.
+func parseLastEventID(context *gin.Context) (int64, bool) {
+	rawValue := strings.TrimSpace(context.GetHeader(headerLastEventID))
+	if rawValue == "" {
+		rawValue = strings.TrimSpace(context.Query(eventStreamQueryLastEventID))
+	}
+	if rawValue == "" {
+		return 0, false
+	}
+	lastEventID, parseErr := strconv.ParseInt(rawValue, 10, 64)
+	if parseErr != nil || lastEventID < 0 {
+		return 0, false
+	}
+	return lastEventID, true
+}
+
+// requestedStreamSiteIDs collects site_id filters given as repeated or comma-separated query values.
+func requestedStreamSiteIDs(context *gin.Context) []string {
+	seen := make(map[string]struct{})
+	siteIDs := make([]string, 0)
+	for _, rawValue := range context.QueryArray(eventStreamQuerySiteID) {
+		for _, part := range strings.Split(rawValue, ",") {
+			siteID := strings.TrimSpace(part)
+			if siteID == "" {
+				continue
+			}
+			if _, duplicate := seen[siteID]; duplicate {
+				continue
+			}
+			seen[siteID] = struct{}{}
+			siteIDs = append(siteIDs, siteID)
+		}
+	}
+	return siteIDs
+}
+
+func (handlers *SiteHandlers) heartbeatInterval() time.Duration {
+	if handlers.streamHeartbeatInterval <= 0 {
+		return defaultEventStreamHeartbeatInterval
+	}
+	return handlers.streamHeartbeatInterval
+}
@@ -0,0 +1,194 @@
+package api
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/MarkoPoloResearchLab/loopaware/internal/model"
+)
+
+const (
+	exportFormatQueryParam = "format"
+	exportFromQueryParam   = "from"
+	exportToQueryParam     = "to"
+
+	exportFormatCSV   = "csv"
+	exportFormatJSONL = "jsonl"
+
+	exportContentTypeCSV   = "text/csv; charset=utf-8"
+	exportContentTypeJSONL = "application/x-ndjson"
+	exportDateLayout       = "2006-01-02"
+
+	// exportUTF8ByteOrderMark makes spreadsheet applications read CSV exports as UTF-8 instead of the legacy code page.
+	exportUTF8ByteOrderMark = "\ufeff"
+
+	errorValueInvalidExportFormat = "invalid_format"
+	errorValueInvalidDateRange    = "invalid_date_range"
+)
+
+// exportFormulaPrefixes lists leading characters spreadsheets evaluate as formulas.
+var exportFormulaPrefixes = [...]string{"=", "+", "-", "@", "\t", "\r"}
+
+// exportDateRange bounds an export to created_at values in [From, To); zero values leave that side open.
+type exportDateRange struct {
+	From time.Time
+	To   time.Time
+}
+
+func (dateRange exportDateRange) apply(column string) (string, []any) {
+	var conditions []string
+	var arguments []any
+	if !dateRange.From.IsZero() {
+		conditions = append(conditions, column+" >= ?")
+		arguments = append(arguments, dateRange.From)
+	}
+	if !dateRange.To.IsZero() {
+		conditions = append(conditions, column+" < ?")
+		arguments = append(arguments, dateRange.To)
+	}
+	return strings.Join(conditions, " AND "), arguments
+}
+
+// resolveExportRequest authorizes the site and validates the shared export query parameters, writing the error response on failure.
+func (handlers *SiteHandlers) resolveExportRequest(context *gin.Context) (model.Site, string, exportDateRange, bool) {
+	site, _, ok := handlers.resolveAuthorizedSite(context)
+	if !ok {
+		return model.Site{}, "", exportDateRange{}, false
+	}
+	format, formatOK := parseExportFormat(context)
+	if !formatOK {
+		context.JSON(http.StatusBadRequest, gin.H{jsonKeyError: errorValueInvalidExportFormat})
+		return model.Site{}, "", exportDateRange{}, false
+	}
+	dateRange, dateRangeOK := parseExportDateRange(context)
+	if !dateRangeOK {
+		context.JSON(http.StatusBadRequest, gin.H{jsonKeyError: errorValueInvalidDateRange})
+		return model.Site{}, "", exportDateRange{}, false
+	}
+	return site, format, dateRange, true
+}
+
+func parseExportFormat(context *gin.Context) (string, bool) {
+	format := strings.ToLower(strings.TrimSpace(context.Query(exportFormatQueryParam)))
+	switch format {
+	case "":
+		return exportFormatCSV, true
+	case exportFormatCSV, exportFormatJSONL:
+		return format, true
+	default:
+		return "", false
+	}
+}
+
+// parseExportDateRange reads the from and to query parameters as RFC 3339 timestamps or calendar days in UTC.
+// A calendar day passed as to includes that whole day.
+func parseExportDateRange(context *gin.Context) (exportDateRange, bool) {
+	from, fromOK := parseExportBoundary(context.Query(exportFromQueryParam), false)
+	to, toOK := parseExportBoundary(context.Query(exportToQueryParam), true)
+	if !fromOK || !toOK {
+		return exportDateRange{}, false
+	}
+	if !from.IsZero() && !to.IsZero() && !from.Before(to) {
+		return exportDateRange{}, false
+	}
+	return exportDateRange{From: from, To: to}, true
+}
+
+func parseExportBoundary(rawValue string, inclusiveDay bool) (time.Time, bool) {
+	trimmedValue := strings.TrimSpace(rawValue)
+	if trimmedValue == "" {
+		return time.Time{}, true
+	}
+	if parsedDay, dayErr := time.Parse(exportDateLayout, trimmedValue); dayErr == nil {
+		if inclusiveDay {
+			return parsedDay.AddDate(0, 0, 1), true
+		}
+		return parsedDay, true
+	}
+	parsedTime, timeErr := time.Parse(time.RFC3339, trimmedValue)
+	if timeErr != nil {
+		return time.Time{}, false
+	}
+	return parsedTime.UTC(), true
+}
+
+func exportFileName(prefix string, siteID string, format string) string {
+	return fmt.Sprintf("%s-%s.%s", prefix, siteID, format)
+}
+
+// writeExportHeaders starts a 200 download response; errors after this point can only be logged.
+func writeExportHeaders(context *gin.Context, fileName string, format string) {
+	contentType := exportContentTypeCSV
+	if format == exportFormatJSONL {
+		contentType = exportContentTypeJSONL
+	}
+	context.Header("Content-Type", contentType)
+	context.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, fileName))
+	context.Header("Cache-Control", "no-store")
+	context.Status(http.StatusOK)
+}
+
+// exportRowWriter streams export rows as spreadsheet-safe CSV or as one JSON document per line.
+type exportRowWriter struct {
+	csvWriter  *csv.Writer
+	jsonWriter *json.Encoder
+}
+
+func newExportRowWriter(writer io.Writer, format string, csvHeader []string) (*exportRowWriter, error) {
+	if format == exportFormatJSONL {
+		return &exportRowWriter{jsonWriter: json.NewEncoder(writer)}, nil
+	}
+	if _, err := io.WriteString(writer, exportUTF8ByteOrderMark); err != nil {
+		return nil, err
+	}
+	rowWriter := &exportRowWriter{csvWriter: csv.NewWriter(writer)}
+	if err := rowWriter.csvWriter.Write(csvHeader); err != nil {
+		return nil, err
+	}
+	return rowWriter, nil
+}
+
+// Write emits document for JSON Lines exports and record for CSV exports.
+func (rowWriter *exportRowWriter) Write(document any, record []string) error {
+	if rowWriter.jsonWriter != nil {
+		return rowWriter.jsonWriter.Encode(document)
+	}
+	safeRecord := make([]string, len(record))
+	for index, value := range record {
+		safeRecord[index] = spreadsheetSafeCell(value)
+	}
+	return rowWriter.csvWriter.Write(safeRecord)
+}
+
+// Flush pushes buffered CSV rows to the response.
+func (rowWriter *exportRowWriter) Flush() error {
+	if rowWriter.csvWriter == nil {
+		return nil
+	}
+	rowWriter.csvWriter.Flush()
+	return rowWriter.csvWriter.Error()
+}
+
+// spreadsheetSafeCell prefixes visitor-supplied values that a spreadsheet would otherwise run as a formula.
+func spreadsheetSafeCell(value string) string {
+	for _, prefix := range exportFormulaPrefixes {
+		if strings.HasPrefix(value, prefix) {
+			return "'" + value
+		}
+	}
+	return value
+}
+
+func formatExportOptionalInt(value *int) string {
+	if value == nil {
+		return ""
+	}
+	return fmt.Sprintf("%d", *value)
+}
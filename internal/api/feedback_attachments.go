@@ -0,0 +1,276 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"github.com/MarkoPoloResearchLab/loopaware/internal/blobstore"
+	"github.com/MarkoPoloResearchLab/loopaware/internal/model"
+)
+
+const (
+	// DefaultFeedbackAttachmentMaxBytes caps an uploaded attachment when no explicit limit is configured.
+	DefaultFeedbackAttachmentMaxBytes int64 = 5 << 20
+
+	feedbackAttachmentFormSiteID        = "site_id"
+	feedbackAttachmentFormFeedbackID    = "feedback_id"
+	feedbackAttachmentFormFile          = "attachment"
+	feedbackAttachmentMultipartOverhead = 64 << 10
+	feedbackAttachmentUploadWindow      = 10 * time.Minute
+	feedbackAttachmentsPerFeedback      = 1
+	feedbackAttachmentURLTemplate       = "/api/sites/%s/attachments/%s"
+	feedbackAttachmentParamID           = "attachment_id"
+	feedbackAttachmentCacheControl      = "private, max-age=3600"
+	feedbackAttachmentContentSecurity   = "default-src 'none'; sandbox"
+
+	errorValueAttachmentsDisabled    = "attachments_disabled"
+	errorValueAttachmentTooLarge     = "attachment_too_large"
+	errorValueAttachmentUnsupported  = "unsupported_attachment"
+	errorValueAttachmentWindowClosed = "attachment_window_closed"
+	errorValueAttachmentExists       = "attachment_exists"
+	errorValueUnknownFeedback        = "unknown_feedback"
+	errorValueUnknownAttachment      = "unknown_attachment"
+	errorValueInvalidMultipart       = "invalid_multipart"
+)
+
+type feedbackAttachmentResponse struct {
+	ID          string `json:"id"`
+	FileName    string `json:"file_name"`
+	ContentType string `json:"content_type"`
+	SizeBytes   int64  `json:"size_bytes"`
+	URL         string `json:"url"`
+	CreatedAt   int64  `json:"created_at"`
+}
+
+// WithAttachments enables feedback attachment uploads stored in store, each at most maxBytes long.
+func (h *PublicHandlers) WithAttachments(store blobstore.Store, maxBytes int64) *PublicHandlers {
+	if maxBytes <= 0 {
+		maxBytes = DefaultFeedbackAttachmentMaxBytes
+	}
+	h.attachmentStore = store
+	h.attachmentMaxBytes = maxBytes
+	return h
+}
+
+// WithAttachmentStore lets site handlers stream and clean up feedback attachments held in store.
+func (handlers *SiteHandlers) WithAttachmentStore(store blobstore.Store) *SiteHandlers {
+	handlers.attachmentStore = store
+	return handlers
+}
+
+// CreateFeedbackAttachment accepts a multipart image upload for feedback the same visitor submitted moments earlier.
+func (h *PublicHandlers) CreateFeedbackAttachment(context *gin.Context) {
+	clientIP := context.ClientIP()
+	if h.isRateLimited(clientIP) {
+		context.JSON(http.StatusTooManyRequests, gin.H{"error": "rate_limited"})
+		return
+	}
+	if h.attachmentStore == nil {
+		context.JSON(http.StatusForbidden, gin.H{"error": errorValueAttachmentsDisabled})
+		return
+	}
+
+	context.Request.Body = http.MaxBytesReader(context.Writer, context.Request.Body, h.attachmentMaxBytes+feedbackAttachmentMultipartOverhead)
+	if parseErr := context.Request.ParseMultipartForm(h.attachmentMaxBytes + feedbackAttachmentMultipartOverhead); parseErr != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(parseErr, &maxBytesErr) {
+			context.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": errorValueAttachmentTooLarge})
+			return
+		}
+		context.JSON(http.StatusBadRequest, gin.H{"error": errorValueInvalidMultipart})
+		return
+	}
+	defer context.Request.MultipartForm.RemoveAll()
+
+	siteID := strings.TrimSpace(context.Request.FormValue(feedbackAttachmentFormSiteID))
+	feedbackID := strings.TrimSpace(context.Request.FormValue(feedbackAttachmentFormFeedbackID))
+	file, fileHeader, fileErr := context.Request.FormFile(feedbackAttachmentFormFile)
+	if siteID == "" || feedbackID == "" || fileErr != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"error": "missing_fields"})
+		return
+	}
+	defer file.Close()
+
+	var site model.Site
+	if err := h.database.First(&site, "id = ?", siteID).Error; err != nil {
+		context.JSON(http.StatusNotFound, gin.H{"error": "unknown_site"})
+		return
+	}
+
+	originHeader := strings.TrimSpace(context.GetHeader("Origin"))
+	refererHeader := strings.TrimSpace(context.GetHeader("Referer"))
+	allowedOrigins := mergedAllowedOrigins(site.AllowedOrigin, site.WidgetAllowedOrigins)
+	if !isOriginAllowed(allowedOrigins, originHeader, refererHeader, "") {
+		context.JSON(http.StatusForbidden, gin.H{"error": "origin_forbidden"})
+		return
+	}
+
+	if !h.attachmentsEnabled(site) {
+		context.JSON(http.StatusForbidden, gin.H{"error": errorValueAttachmentsDisabled})
+		return
+	}
+
+	var feedback model.Feedback
+	if err := h.database.First(&feedback, "id = ? AND site_id = ?", feedbackID, site.ID).Error; err != nil {
+		context.JSON(http.StatusNotFound, gin.H{"error": errorValueUnknownFeedback})
+		return
+	}
+	if feedback.IP != truncate(clientIP, subscriptionIPMaxLength) || time.Since(feedback.CreatedAt) > feedbackAttachmentUploadWindow {
+		context.JSON(http.StatusForbidden, gin.H{"error": errorValueAttachmentWindowClosed})
+		return
+	}
+
+	var existingAttachments int64
+	if err := h.database.Model(&model.FeedbackAttachment{}).Where("feedback_id = ?", feedback.ID).Count(&existingAttachments).Error; err != nil {
+		requestLogger(context, h.logger).Warn("count_feedback_attachments", zap.Error(err), zap.String("feedback_id", feedback.ID))
+		context.JSON(http.StatusInternalServerError, gin.H{"error": "save_failed"})
+		return
+	}
+	if existingAttachments >= feedbackAttachmentsPerFeedback {
+		context.JSON(http.StatusConflict, gin.H{"error": errorValueAttachmentExists})
+		return
+	}
+
+	data, readErr := io.ReadAll(io.LimitReader(file, h.attachmentMaxBytes+1))
+	if readErr != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"error": errorValueInvalidMultipart})
+		return
+	}
+	if int64(len(data)) > h.attachmentMaxBytes {
+		context.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": errorValueAttachmentTooLarge})
+		return
+	}
+
+	attachment, attachmentErr := model.NewFeedbackAttachment(model.FeedbackAttachmentInput{
+		FeedbackID:          feedback.ID,
+		SiteID:              site.ID,
+		FileName:            fileHeader.Filename,
+		DeclaredContentType: fileHeader.Header.Get("Content-Type"),
+		Data:                data,
+	})
+	if attachmentErr != nil {
+		context.JSON(http.StatusUnsupportedMediaType, gin.H{"error": errorValueAttachmentUnsupported})
+		return
+	}
+
+	ctx := context.Request.Context()
+	if putErr := h.attachmentStore.Put(ctx, attachment.StorageKey, data); putErr != nil {
+		requestLogger(context, h.logger).Warn("store_feedback_attachment", zap.Error(putErr), zap.String("feedback_id", feedback.ID))
+		context.JSON(http.StatusInternalServerError, gin.H{"error": "save_failed"})
+		return
+	}
+	if err := h.database.Create(&attachment).Error; err != nil {
+		requestLogger(context, h.logger).Warn("save_feedback_attachment", zap.Error(err), zap.String("feedback_id", feedback.ID))
+		if deleteErr := h.attachmentStore.Delete(ctx, attachment.StorageKey); deleteErr != nil {
+			requestLogger(context, h.logger).Warn("delete_orphaned_attachment", zap.Error(deleteErr), zap.String("storage_key", attachment.StorageKey))
+		}
+		context.JSON(http.StatusInternalServerError, gin.H{"error": "save_failed"})
+		return
+	}
+
+	context.JSON(http.StatusOK, gin.H{"status": "ok", "attachment_id": attachment.ID})
+}
+
+// FeedbackAttachment streams a stored attachment to a user who can manage the site.
+func (handlers *SiteHandlers) FeedbackAttachment(context *gin.Context) {
+	site, _, ok := resolveManageableSite(context, handlers.database)
+	if !ok {
+		return
+	}
+
+	attachmentID := strings.TrimSpace(context.Param(feedbackAttachmentParamID))
+	var attachment model.FeedbackAttachment
+	if attachmentID == "" || handlers.database.First(&attachment, "id = ? AND site_id = ?", attachmentID, site.ID).Error != nil || handlers.attachmentStore == nil {
+		context.JSON(http.StatusNotFound, gin.H{jsonKeyError: errorValueUnknownAttachment})
+		return
+	}
+
+	reader, openErr := handlers.attachmentStore.Open(context.Request.Context(), attachment.StorageKey)
+	if errors.Is(openErr, blobstore.ErrNotFound) {
+		context.JSON(http.StatusNotFound, gin.H{jsonKeyError: errorValueUnknownAttachment})
+		return
+	}
+	if openErr != nil {
+		requestLogger(context, handlers.logger).Warn("open_feedback_attachment", zap.Error(openErr), zap.String("attachment_id", attachment.ID))
+		context.JSON(http.StatusInternalServerError, gin.H{jsonKeyError: errorValueQueryFailed})
+		return
+	}
+	defer reader.Close()
+
+	headers := context.Writer.Header()
+	headers.Set("Content-Type", attachment.ContentType)
+	headers.Set("Content-Length", strconv.FormatInt(attachment.SizeBytes, 10))
+	headers.Set("Content-Disposition", mime.FormatMediaType("inline", map[string]string{"filename": attachment.FileName}))
+	headers.Set("X-Content-Type-Options", "nosniff")
+	headers.Set("Content-Security-Policy", feedbackAttachmentContentSecurity)
+	headers.Set("Cache-Control", feedbackAttachmentCacheControl)
+	context.Status(http.StatusOK)
+	if _, copyErr := io.Copy(context.Writer, reader); copyErr != nil {
+		requestLogger(context, handlers.logger).Debug("stream_feedback_attachment", zap.Error(copyErr), zap.String("attachment_id", attachment.ID))
+	}
+}
+
+func (h *PublicHandlers) attachmentsEnabled(site model.Site) bool {
+	return h.attachmentStore != nil && site.WidgetConfig.AllowAttachments
+}
+
+func (h *PublicHandlers) widgetAttachmentMaxBytes(site model.Site) int64 {
+	if !h.attachmentsEnabled(site) {
+		return 0
+	}
+	return h.attachmentMaxBytes
+}
+
+func (handlers *SiteHandlers) feedbackAttachmentsByFeedback(ctx context.Context, siteID string) (map[string][]feedbackAttachmentResponse, error) {
+	return handlers.loadFeedbackAttachments(siteID, handlers.database.WithContext(ctx).Where("site_id = ?", siteID))
+}
+
+// feedbackAttachmentsForFeedbacks loads the attachments of the given feedback entries only.
+func (handlers *SiteHandlers) feedbackAttachmentsForFeedbacks(ctx context.Context, siteID string, feedbackIDs []string) (map[string][]feedbackAttachmentResponse, error) {
+	if len(feedbackIDs) == 0 {
+		return map[string][]feedbackAttachmentResponse{}, nil
+	}
+	return handlers.loadFeedbackAttachments(siteID, handlers.database.WithContext(ctx).Where("site_id = ? AND feedback_id IN ?", siteID, feedbackIDs))
+}
+
+func (handlers *SiteHandlers) loadFeedbackAttachments(siteID string, query *gorm.DB) (map[string][]feedbackAttachmentResponse, error) {
+	var attachments []model.FeedbackAttachment
+	if err := query.Order("created_at asc").Find(&attachments).Error; err != nil {
+		return nil, err
+	}
+	attachmentsByFeedback := make(map[string][]feedbackAttachmentResponse, len(attachments))
+	for _, attachment := range attachments {
+		attachmentsByFeedback[attachment.FeedbackID] = append(attachmentsByFeedback[attachment.FeedbackID], feedbackAttachmentResponse{
+			ID:          attachment.ID,
+			FileName:    attachment.FileName,
+			ContentType: attachment.ContentType,
+			SizeBytes:   attachment.SizeBytes,
+			URL:         fmt.Sprintf(feedbackAttachmentURLTemplate, url.PathEscape(siteID), url.PathEscape(attachment.ID)),
+			CreatedAt:   attachment.CreatedAt.Unix(),
+		})
+	}
+	return attachmentsByFeedback, nil
+}
+
+func (handlers *SiteHandlers) deleteAttachmentBlobs(ctx context.Context, storageKeys []string) {
+	if handlers.attachmentStore == nil {
+		return
+	}
+	for _, storageKey := range storageKeys {
+		if deleteErr := handlers.attachmentStore.Delete(ctx, storageKey); deleteErr != nil {
+			requestLogger(ctx, handlers.logger).Warn("delete_feedback_attachment_blob", zap.Error(deleteErr), zap.String("storage_key", storageKey))
+		}
+	}
+}
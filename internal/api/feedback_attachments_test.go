@@ -0,0 +1,235 @@
+package api_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"github.com/MarkoPoloResearchLab/loopaware/internal/api"
+	"github.com/MarkoPoloResearchLab/loopaware/internal/blobstore"
+	"github.com/MarkoPoloResearchLab/loopaware/internal/model"
+)
+
+const (
+	testAttachmentOrigin    = "http://attachments.example"
+	testAttachmentOwner     = "owner@attachments.example"
+	testAttachmentMaxBytes  = 1024
+	testAttachmentRemoteIP  = "198.51.100.7:5000"
+	testAttachmentOtherIP   = "203.0.113.9:5000"
+	testAttachmentRoutePath = "/public/feedback/attachments"
+)
+
+var testAttachmentPNG = append([]byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"), bytes.Repeat([]byte{0}, 32)...)
+
+type feedbackAttachmentHarness struct {
+	router       *gin.Engine
+	database     *gorm.DB
+	store        blobstore.Store
+	siteHandlers *api.SiteHandlers
+}
+
+func newFeedbackAttachmentHarness(testingT *testing.T) feedbackAttachmentHarness {
+	testingT.Helper()
+
+	siteHarness := newSiteTestHarness(testingT)
+	database := siteHarness.database
+
+	store := blobstore.NewDatabaseStore(database)
+	publicHandlers := api.NewPublicHandlers(database, zap.NewNop(), nil, nil, nil, nil, false, "http://loopaware.test", "unit-test-session-secret", nil).
+		WithAttachments(store, testAttachmentMaxBytes)
+	router := gin.New()
+	router.POST("/public/feedback", publicHandlers.CreateFeedback)
+	router.POST(testAttachmentRoutePath, publicHandlers.CreateFeedbackAttachment)
+	router.GET("/public/widget-config", publicHandlers.WidgetConfig)
+
+	return feedbackAttachmentHarness{
+		router:       router,
+		database:     database,
+		store:        store,
+		siteHandlers: siteHarness.handlers.WithAttachmentStore(store),
+	}
+}
+
+func (harness feedbackAttachmentHarness) insertAttachmentSite(testingT *testing.T, name string, allowAttachments bool) model.Site {
+	testingT.Helper()
+	site := insertSite(testingT, harness.database, name, testAttachmentOrigin, testAttachmentOwner)
+	site.WidgetConfig = model.WidgetConfig{ContactMode: model.WidgetContactOptional, AllowAttachments: allowAttachments}
+	require.NoError(testingT, harness.database.Save(&site).Error)
+	return site
+}
+
+func (harness feedbackAttachmentHarness) submitFeedback(testingT *testing.T, siteID string) string {
+	testingT.Helper()
+	encoded, encodeErr := json.Marshal(map[string]any{"site_id": siteID, "message": "See screenshot"})
+	require.NoError(testingT, encodeErr)
+	request := httptest.NewRequest(http.MethodPost, "/public/feedback", bytes.NewReader(encoded))
+	request.RemoteAddr = testAttachmentRemoteIP
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Origin", testAttachmentOrigin)
+	recorder := httptest.NewRecorder()
+	harness.router.ServeHTTP(recorder, request)
+	require.Equal(testingT, http.StatusOK, recorder.Code, recorder.Body.String())
+
+	var body struct {
+		FeedbackID string `json:"feedback_id"`
+	}
+	require.NoError(testingT, json.Unmarshal(recorder.Body.Bytes(), &body))
+	require.NotEmpty(testingT, body.FeedbackID)
+	return body.FeedbackID
+}
+
+func (harness feedbackAttachmentHarness) uploadAttachment(testingT *testing.T, remoteAddr string, siteID string, feedbackID string, fileName string, contentType string, data []byte) *httptest.ResponseRecorder {
+	testingT.Helper()
+	var requestBody bytes.Buffer
+	writer := multipart.NewWriter(&requestBody)
+	require.NoError(testingT, writer.WriteField("site_id", siteID))
+	require.NoError(testingT, writer.WriteField("feedback_id", feedbackID))
+	partHeader := make(textproto.MIMEHeader)
+	partHeader.Set("Content-Disposition", `form-data; name="attachment"; filename="`+fileName+`"`)
+	partHeader.Set("Content-Type", contentType)
+	part, partErr := writer.CreatePart(partHeader)
+	require.NoError(testingT, partErr)
+	_, writeErr := part.Write(data)
+	require.NoError(testingT, writeErr)
+	require.NoError(testingT, writer.Close())
+
+	request := httptest.NewRequest(http.MethodPost, testAttachmentRoutePath, &requestBody)
+	request.RemoteAddr = remoteAddr
+	request.Header.Set("Content-Type", writer.FormDataContentType())
+	request.Header.Set("Origin", testAttachmentOrigin)
+	recorder := httptest.NewRecorder()
+	harness.router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestFeedbackAttachmentUploadAndDownload(testingT *testing.T) {
+	harness := newFeedbackAttachmentHarness(testingT)
+	site := harness.insertAttachmentSite(testingT, "Screenshots", true)
+	feedbackID := harness.submitFeedback(testingT, site.ID)
+
+	uploadRecorder := harness.uploadAttachment(testingT, testAttachmentRemoteIP, site.ID, feedbackID, "screen.png", "image/png", testAttachmentPNG)
+	require.Equal(testingT, http.StatusOK, uploadRecorder.Code, uploadRecorder.Body.String())
+	var uploadBody struct {
+		AttachmentID string `json:"attachment_id"`
+	}
+	require.NoError(testingT, json.Unmarshal(uploadRecorder.Body.Bytes(), &uploadBody))
+
+	duplicateRecorder := harness.uploadAttachment(testingT, testAttachmentRemoteIP, site.ID, feedbackID, "again.png", "image/png", testAttachmentPNG)
+	require.Equal(testingT, http.StatusConflict, duplicateRecorder.Code)
+
+	ownerUser := &api.CurrentUser{Email: testAttachmentOwner, Role: api.RoleUser}
+	listRecorder, listContext := newJSONContext(http.MethodGet, "/api/sites/"+site.ID+"/messages", nil)
+	listContext.Params = gin.Params{{Key: "id", Value: site.ID}}
+	listContext.Set(testSessionContextKey, ownerUser)
+	harness.siteHandlers.ListMessagesBySite(listContext)
+	require.Equal(testingT, http.StatusOK, listRecorder.Code)
+	var listBody struct {
+		Messages []struct {
+			ID          string `json:"id"`
+			Attachments []struct {
+				ID          string `json:"id"`
+				FileName    string `json:"file_name"`
+				ContentType string `json:"content_type"`
+				URL         string `json:"url"`
+			} `json:"attachments"`
+		} `json:"messages"`
+	}
+	require.NoError(testingT, json.Unmarshal(listRecorder.Body.Bytes(), &listBody))
+	require.Len(testingT, listBody.Messages, 1)
+	require.Len(testingT, listBody.Messages[0].Attachments, 1)
+	listedAttachment := listBody.Messages[0].Attachments[0]
+	require.Equal(testingT, uploadBody.AttachmentID, listedAttachment.ID)
+	require.Equal(testingT, "screen.png", listedAttachment.FileName)
+	require.Equal(testingT, "/api/sites/"+site.ID+"/attachments/"+uploadBody.AttachmentID, listedAttachment.URL)
+
+	downloadRecorder, downloadContext := newJSONContext(http.MethodGet, listedAttachment.URL, nil)
+	downloadContext.Params = gin.Params{{Key: "id", Value: site.ID}, {Key: "attachment_id", Value: listedAttachment.ID}}
+	downloadContext.Set(testSessionContextKey, ownerUser)
+	harness.siteHandlers.FeedbackAttachment(downloadContext)
+	require.Equal(testingT, http.StatusOK, downloadRecorder.Code)
+	require.Equal(testingT, model.FeedbackAttachmentContentTypePNG, downloadRecorder.Header().Get("Content-Type"))
+	require.Equal(testingT, "nosniff", downloadRecorder.Header().Get("X-Content-Type-Options"))
+	require.Equal(testingT, `inline; filename=screen.png`, downloadRecorder.Header().Get("Content-Disposition"))
+	require.Equal(testingT, testAttachmentPNG, downloadRecorder.Body.Bytes())
+
+	strangerRecorder, strangerContext := newJSONContext(http.MethodGet, listedAttachment.URL, nil)
+	strangerContext.Params = downloadContext.Params
+	strangerContext.Set(testSessionContextKey, &api.CurrentUser{Email: testUserEmailAddress, Role: api.RoleUser})
+	harness.siteHandlers.FeedbackAttachment(strangerContext)
+	require.Equal(testingT, http.StatusForbidden, strangerRecorder.Code)
+
+	deleteRecorder, deleteContext := newJSONContext(http.MethodDelete, "/api/sites/"+site.ID, nil)
+	deleteContext.Params = gin.Params{{Key: "id", Value: site.ID}}
+	deleteContext.Set(testSessionContextKey, ownerUser)
+	harness.siteHandlers.DeleteSite(deleteContext)
+	require.Equal(testingT, http.StatusNoContent, deleteRecorder.Code)
+
+	var remainingAttachments int64
+	require.NoError(testingT, harness.database.Model(&model.FeedbackAttachment{}).Count(&remainingAttachments).Error)
+	require.Zero(testingT, remainingAttachments)
+	_, openErr := harness.store.Open(context.Background(), listedAttachment.ID)
+	require.ErrorIs(testingT, openErr, blobstore.ErrNotFound)
+}
+
+func TestFeedbackAttachmentUploadRejections(testingT *testing.T) {
+	harness := newFeedbackAttachmentHarness(testingT)
+	site := harness.insertAttachmentSite(testingT, "Guarded Screenshots", true)
+	feedbackID := harness.submitFeedback(testingT, site.ID)
+
+	oversized := append(append([]byte{}, testAttachmentPNG...), bytes.Repeat([]byte{0}, testAttachmentMaxBytes)...)
+	oversizedRecorder := harness.uploadAttachment(testingT, testAttachmentRemoteIP, site.ID, feedbackID, "big.png", "image/png", oversized)
+	require.Equal(testingT, http.StatusRequestEntityTooLarge, oversizedRecorder.Code)
+
+	disguisedRecorder := harness.uploadAttachment(testingT, testAttachmentRemoteIP, site.ID, feedbackID, "x.png", "image/png", []byte("<html><script>alert(1)</script></html>"))
+	require.Equal(testingT, http.StatusUnsupportedMediaType, disguisedRecorder.Code)
+
+	strangerRecorder := harness.uploadAttachment(testingT, testAttachmentOtherIP, site.ID, feedbackID, "x.png", "image/png", testAttachmentPNG)
+	require.Equal(testingT, http.StatusForbidden, strangerRecorder.Code)
+	require.JSONEq(testingT, `{"error":"attachment_window_closed"}`, strangerRecorder.Body.String())
+
+	require.NoError(testingT, harness.database.Model(&model.Feedback{}).Where("id = ?", feedbackID).Update("created_at", time.Now().Add(-time.Hour)).Error)
+	expiredRecorder := harness.uploadAttachment(testingT, testAttachmentRemoteIP, site.ID, feedbackID, "x.png", "image/png", testAttachmentPNG)
+	require.Equal(testingT, http.StatusForbidden, expiredRecorder.Code)
+
+	disabledSite := harness.insertAttachmentSite(testingT, "No Screenshots", false)
+	disabledRecorder := harness.uploadAttachment(testingT, testAttachmentRemoteIP, disabledSite.ID, feedbackID, "x.png", "image/png", testAttachmentPNG)
+	require.Equal(testingT, http.StatusForbidden, disabledRecorder.Code)
+	require.JSONEq(testingT, `{"error":"attachments_disabled"}`, disabledRecorder.Body.String())
+
+	var storedAttachments int64
+	require.NoError(testingT, harness.database.Model(&model.FeedbackAttachment{}).Count(&storedAttachments).Error)
+	require.Zero(testingT, storedAttachments)
+}
+
+func TestWidgetConfigAdvertisesAttachments(testingT *testing.T) {
+	harness := newFeedbackAttachmentHarness(testingT)
+	site := harness.insertAttachmentSite(testingT, "Advertised Screenshots", true)
+
+	request := httptest.NewRequest(http.MethodGet, "/public/widget-config?site_id="+site.ID, nil)
+	request.Header.Set("Origin", testAttachmentOrigin)
+	recorder := httptest.NewRecorder()
+	harness.router.ServeHTTP(recorder, request)
+	require.Equal(testingT, http.StatusOK, recorder.Code)
+
+	var body struct {
+		AllowAttachments   bool             `json:"allow_attachments"`
+		AttachmentMaxBytes int64            `json:"attachment_max_bytes"`
+		Copy               model.WidgetCopy `json:"copy"`
+	}
+	require.NoError(testingT, json.Unmarshal(recorder.Body.Bytes(), &body))
+	require.True(testingT, body.AllowAttachments)
+	require.Equal(testingT, int64(testAttachmentMaxBytes), body.AttachmentMaxBytes)
+	require.True(testingT, strings.Contains(body.Copy.AttachmentLabel, "screenshot"))
+}
@@ -12,29 +12,50 @@ import (
 )
 
 // FeedbackEvent represents a feedback creation notification for SSE clients.
+// ID is assigned by the broadcaster and increases by one per broadcast event.
 type FeedbackEvent struct {
+	ID            int64
 	SiteID        string
 	FeedbackID    string
 	CreatedAt     time.Time
 	FeedbackCount int64
 }
 
-// FeedbackEventBroadcaster fan-outs feedback events to subscribed clients.
+// FeedbackEventBroadcaster fan-outs feedback events to subscribed clients and keeps the most recent
+// events so reconnecting clients can resume where they left off.
 type FeedbackEventBroadcaster struct {
-	mutex        sync.Mutex
-	nextID       int64
-	subscribers  map[int64]chan FeedbackEvent
-	closed       bool
-	bufferLength int
+	mutex         sync.Mutex
+	nextID        int64
+	subscribers   map[int64]chan FeedbackEvent
+	dropped       map[int64]int64
+	closed        bool
+	bufferLength  int
+	lastEventID   int64
+	history       []FeedbackEvent
+	historyLength int
 }
 
-const feedbackEventDefaultBuffer = 8
+// FeedbackEventReplay holds the buffered events after a resume point.
+// Missed counts the events after the resume point that were already evicted from the buffer,
+// and LastEventID is the newest event ID the broadcaster had assigned when the replay was taken.
+type FeedbackEventReplay struct {
+	Events      []FeedbackEvent
+	Missed      int64
+	LastEventID int64
+}
+
+const (
+	feedbackEventDefaultBuffer  = 8
+	feedbackEventDefaultHistory = 256
+)
 
 // NewFeedbackEventBroadcaster constructs a broadcaster for feedback events.
 func NewFeedbackEventBroadcaster() *FeedbackEventBroadcaster {
 	return &FeedbackEventBroadcaster{
-		subscribers:  make(map[int64]chan FeedbackEvent),
-		bufferLength: feedbackEventDefaultBuffer,
+		subscribers:   make(map[int64]chan FeedbackEvent),
+		dropped:       make(map[int64]int64),
+		bufferLength:  feedbackEventDefaultBuffer,
+		historyLength: feedbackEventDefaultHistory,
 	}
 }
 
@@ -45,6 +66,10 @@ func (broadcaster *FeedbackEventBroadcaster) Subscribe() *FeedbackEventSubscript
 	if broadcaster.closed {
 		return nil
 	}
+	return broadcaster.subscribeLocked()
+}
+
+func (broadcaster *FeedbackEventBroadcaster) subscribeLocked() *FeedbackEventSubscription {
 	subscriptionID := broadcaster.nextID
 	broadcaster.nextID++
 	eventChannel := make(chan FeedbackEvent, broadcaster.bufferLength)
@@ -57,21 +82,84 @@ func (broadcaster *FeedbackEventBroadcaster) Subscribe() *FeedbackEventSubscript
 	}
 }
 
-// Broadcast delivers the event to all active subscribers.
+// Resume subscribes like Subscribe and also returns the buffered events after lastEventID, taken atomically
+// with the subscription so nothing falls between the replay and the live events. A lastEventID newer than
+// anything the broadcaster assigned (for example from before a restart) replays the whole buffer.
+func (broadcaster *FeedbackEventBroadcaster) Resume(lastEventID int64) (*FeedbackEventSubscription, FeedbackEventReplay) {
+	broadcaster.mutex.Lock()
+	defer broadcaster.mutex.Unlock()
+	if broadcaster.closed {
+		return nil, FeedbackEventReplay{}
+	}
+	subscription := broadcaster.subscribeLocked()
+	if lastEventID > broadcaster.lastEventID {
+		lastEventID = 0
+	}
+	return subscription, broadcaster.replayAfterLocked(lastEventID)
+}
+
+// EventsAfter returns the buffered events after lastEventID.
+func (broadcaster *FeedbackEventBroadcaster) EventsAfter(lastEventID int64) FeedbackEventReplay {
+	broadcaster.mutex.Lock()
+	defer broadcaster.mutex.Unlock()
+	return broadcaster.replayAfterLocked(lastEventID)
+}
+
+func (broadcaster *FeedbackEventBroadcaster) replayAfterLocked(lastEventID int64) FeedbackEventReplay {
+	replay := FeedbackEventReplay{LastEventID: broadcaster.lastEventID}
+	if lastEventID >= broadcaster.lastEventID {
+		return replay
+	}
+	oldestBufferedID := broadcaster.lastEventID + 1
+	if len(broadcaster.history) > 0 {
+		oldestBufferedID = broadcaster.history[0].ID
+	}
+	if lastEventID+1 < oldestBufferedID {
+		replay.Missed = oldestBufferedID - lastEventID - 1
+	}
+	for _, event := range broadcaster.history {
+		if event.ID > lastEventID {
+			replay.Events = append(replay.Events, event)
+		}
+	}
+	return replay
+}
+
+// Broadcast assigns the next event ID, buffers the event for replay, and delivers it to all active subscribers.
+// Subscribers whose buffer is full miss the event; the miss is counted and reported through Dropped.
 func (broadcaster *FeedbackEventBroadcaster) Broadcast(event FeedbackEvent) {
 	broadcaster.mutex.Lock()
 	defer broadcaster.mutex.Unlock()
-	if broadcaster.closed || len(broadcaster.subscribers) == 0 {
+	if broadcaster.closed {
 		return
 	}
-	for _, channel := range broadcaster.subscribers {
+	broadcaster.lastEventID++
+	event.ID = broadcaster.lastEventID
+	if broadcaster.historyLength > 0 {
+		if len(broadcaster.history) >= broadcaster.historyLength {
+			broadcaster.history = append(broadcaster.history[:0], broadcaster.history[1:]...)
+		}
+		broadcaster.history = append(broadcaster.history, event)
+	}
+	for identifier, channel := range broadcaster.subscribers {
 		select {
 		case channel <- event:
 		default:
+			broadcaster.dropped[identifier]++
 		}
 	}
 }
 
+// SubscriberCount reports the number of open feedback event subscriptions.
+func (broadcaster *FeedbackEventBroadcaster) SubscriberCount() int {
+	if broadcaster == nil {
+		return 0
+	}
+	broadcaster.mutex.Lock()
+	defer broadcaster.mutex.Unlock()
+	return len(broadcaster.subscribers)
+}
+
 // Close stops the broadcaster and closes all subscriber channels.
 func (broadcaster *FeedbackEventBroadcaster) Close() {
 	broadcaster.mutex.Lock()
@@ -94,9 +182,18 @@ func (broadcaster *FeedbackEventBroadcaster) remove(identifier int64) {
 		delete(broadcaster.subscribers, identifier)
 		close(channel)
 	}
+	delete(broadcaster.dropped, identifier)
 	broadcaster.mutex.Unlock()
 }
 
+func (broadcaster *FeedbackEventBroadcaster) takeDropped(identifier int64) int64 {
+	broadcaster.mutex.Lock()
+	defer broadcaster.mutex.Unlock()
+	droppedCount := broadcaster.dropped[identifier]
+	delete(broadcaster.dropped, identifier)
+	return droppedCount
+}
+
 // FeedbackEventSubscription represents a single subscriber to feedback events.
 type FeedbackEventSubscription struct {
 	broadcaster *FeedbackEventBroadcaster
@@ -113,6 +210,14 @@ func (subscription *FeedbackEventSubscription) Events() <-chan FeedbackEvent {
 	return subscription.events
 }
 
+// Dropped returns how many events missed this subscription because its buffer was full since the last call.
+func (subscription *FeedbackEventSubscription) Dropped() int64 {
+	if subscription == nil || subscription.broadcaster == nil {
+		return 0
+	}
+	return subscription.broadcaster.takeDropped(subscription.identifier)
+}
+
 // Close unregisters the subscription and closes its channel.
 func (subscription *FeedbackEventSubscription) Close() {
 	if subscription == nil {
@@ -140,7 +245,7 @@ func broadcastFeedbackEvent(database *gorm.DB, logger *zap.Logger, broadcaster *
 			Count(&totalCount).Error
 		if queryErr != nil {
 			if logger != nil {
-				logger.Debug("count_feedback_event_failed", zap.Error(queryErr))
+				requestLogger(ctx, logger).Debug("count_feedback_event_failed", zap.Error(queryErr))
 			}
 			totalCount = 0
 		}
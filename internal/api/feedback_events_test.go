@@ -54,3 +54,45 @@ func TestFeedbackEventBroadcasterBroadcastWithConcurrentCloseDoesNotPanic(t *tes
 	default:
 	}
 }
+
+func TestFeedbackEventBroadcasterAssignsIDsAndReplaysHistory(t *testing.T) {
+	broadcaster := NewFeedbackEventBroadcaster()
+	broadcaster.historyLength = 3
+	for index := 0; index < 5; index++ {
+		broadcaster.Broadcast(FeedbackEvent{SiteID: "site"})
+	}
+
+	subscription, replay := broadcaster.Resume(3)
+	if subscription == nil {
+		t.Fatalf("expected subscription")
+	}
+	defer subscription.Close()
+	if replay.LastEventID != 5 || replay.Missed != 0 || len(replay.Events) != 2 || replay.Events[0].ID != 4 {
+		t.Fatalf("unexpected replay after 3: %+v", replay)
+	}
+
+	replay = broadcaster.EventsAfter(0)
+	if replay.Missed != 2 || len(replay.Events) != 3 || replay.Events[0].ID != 3 {
+		t.Fatalf("unexpected replay after 0: %+v", replay)
+	}
+
+	_, replay = broadcaster.Resume(99)
+	if len(replay.Events) != 3 || replay.Missed != 2 {
+		t.Fatalf("expected a future resume point to replay the whole buffer: %+v", replay)
+	}
+}
+
+func TestFeedbackEventBroadcasterCountsDroppedEvents(t *testing.T) {
+	broadcaster := NewFeedbackEventBroadcaster()
+	subscription := broadcaster.Subscribe()
+	defer subscription.Close()
+	for index := 0; index < feedbackEventDefaultBuffer+3; index++ {
+		broadcaster.Broadcast(FeedbackEvent{SiteID: "site"})
+	}
+	if dropped := subscription.Dropped(); dropped != 3 {
+		t.Fatalf("expected 3 dropped events, got %d", dropped)
+	}
+	if dropped := subscription.Dropped(); dropped != 0 {
+		t.Fatalf("expected dropped counter to reset, got %d", dropped)
+	}
+}
@@ -0,0 +1,144 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/MarkoPoloResearchLab/loopaware/internal/model"
+)
+
+const (
+	feedbackExportFilePrefix          = "feedback"
+	feedbackExportAttachmentSeparator = " "
+	feedbackExportBatchSize           = 200
+)
+
+var feedbackExportCSVHeader = []string{
+	"id",
+	"contact",
+	"message",
+	"extra_field",
+	"rating",
+	"nps_score",
+	"category",
+	"page_url",
+	"attachments",
+	"ip",
+	"user_agent",
+	"created_at",
+	"delivery",
+}
+
+// ExportMessages streams a site's feedback, newest first, as CSV or JSON Lines using the message list field names.
+func (handlers *SiteHandlers) ExportMessages(context *gin.Context) {
+	site, format, dateRange, ok := handlers.resolveExportRequest(context)
+	if !ok {
+		return
+	}
+
+	requestContext := context.Request.Context()
+	query := handlers.database.WithContext(requestContext).Model(&model.Feedback{}).Where("site_id = ?", site.ID)
+	if condition, arguments := dateRange.apply("created_at"); condition != "" {
+		query = query.Where(condition, arguments...)
+	}
+	rows, rowsErr := query.Order("created_at desc").Order("id").Rows()
+	if rowsErr != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{jsonKeyError: errorValueQueryFailed})
+		return
+	}
+	defer rows.Close()
+
+	writeExportHeaders(context, exportFileName(feedbackExportFilePrefix, site.ID, format), format)
+	rowWriter, writerErr := newExportRowWriter(context.Writer, format, feedbackExportCSVHeader)
+	if writerErr != nil {
+		requestLogger(context, handlers.logger).Warn("export_messages_write", zap.Error(writerErr), zap.String("site_id", site.ID))
+		return
+	}
+
+	// Attachments are loaded for one batch of streamed rows at a time so memory stays bounded by the batch size.
+	batch := make([]model.Feedback, 0, feedbackExportBatchSize)
+	writeBatch := func() bool {
+		if len(batch) == 0 {
+			return true
+		}
+		feedbackIDs := make([]string, 0, len(batch))
+		for _, feedback := range batch {
+			feedbackIDs = append(feedbackIDs, feedback.ID)
+		}
+		attachmentsByFeedback, attachmentsErr := handlers.feedbackAttachmentsForFeedbacks(requestContext, site.ID, feedbackIDs)
+		if attachmentsErr != nil {
+			requestLogger(context, handlers.logger).Warn("export_messages_attachments", zap.Error(attachmentsErr), zap.String("site_id", site.ID))
+			return false
+		}
+		for _, feedback := range batch {
+			message := feedbackMessageResponse{
+				ID:          feedback.ID,
+				Contact:     feedback.Contact,
+				Message:     feedback.Message,
+				ExtraField:  feedback.ExtraField,
+				Rating:      feedback.Rating,
+				NPSScore:    feedback.NPSScore,
+				Category:    feedback.Category,
+				PageURL:     feedback.PageURL,
+				Attachments: attachmentsByFeedback[feedback.ID],
+				IP:          feedback.IP,
+				UserAgent:   feedback.UserAgent,
+				CreatedAt:   feedback.CreatedAt.Unix(),
+				Delivery:    feedback.Delivery,
+			}
+			if writeErr := rowWriter.Write(message, feedbackExportRecord(message)); writeErr != nil {
+				requestLogger(context, handlers.logger).Warn("export_messages_write", zap.Error(writeErr), zap.String("site_id", site.ID))
+				return false
+			}
+		}
+		batch = batch[:0]
+		return true
+	}
+
+	for rows.Next() {
+		var feedback model.Feedback
+		if scanErr := handlers.database.ScanRows(rows, &feedback); scanErr != nil {
+			requestLogger(context, handlers.logger).Warn("export_messages_scan", zap.Error(scanErr), zap.String("site_id", site.ID))
+			return
+		}
+		batch = append(batch, feedback)
+		if len(batch) == feedbackExportBatchSize && !writeBatch() {
+			return
+		}
+	}
+	if iterationErr := rows.Err(); iterationErr != nil {
+		requestLogger(context, handlers.logger).Warn("export_messages_rows", zap.Error(iterationErr), zap.String("site_id", site.ID))
+	}
+	if !writeBatch() {
+		return
+	}
+	if flushErr := rowWriter.Flush(); flushErr != nil {
+		requestLogger(context, handlers.logger).Warn("export_messages_write", zap.Error(flushErr), zap.String("site_id", site.ID))
+	}
+}
+
+func feedbackExportRecord(message feedbackMessageResponse) []string {
+	attachmentURLs := make([]string, 0, len(message.Attachments))
+	for _, attachment := range message.Attachments {
+		attachmentURLs = append(attachmentURLs, attachment.URL)
+	}
+	return []string{
+		message.ID,
+		message.Contact,
+		message.Message,
+		message.ExtraField,
+		formatExportOptionalInt(message.Rating),
+		formatExportOptionalInt(message.NPSScore),
+		message.Category,
+		message.PageURL,
+		strings.Join(attachmentURLs, feedbackExportAttachmentSeparator),
+		message.IP,
+		message.UserAgent,
+		fmt.Sprintf("%d", message.CreatedAt),
+		message.Delivery,
+	}
+}
@@ -0,0 +1,165 @@
+package api_test
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+
+	"github.com/MarkoPoloResearchLab/loopaware/internal/api"
+	"github.com/MarkoPoloResearchLab/loopaware/internal/model"
+)
+
+type feedbackExportHarness struct {
+	siteTestHarness
+	site   model.Site
+	router *gin.Engine
+}
+
+func newFeedbackExportHarness(testingT *testing.T, currentUser *api.CurrentUser) feedbackExportHarness {
+	testingT.Helper()
+	harness := newSiteTestHarness(testingT)
+	site := insertSite(testingT, harness.database, "Export Site", "http://export.example", testUserEmailAddress)
+
+	router := newAuthenticatedRouter(currentUser)
+	router.GET("/api/sites/:id/messages/export", harness.handlers.ExportMessages)
+	return feedbackExportHarness{siteTestHarness: harness, site: site, router: router}
+}
+
+func (harness feedbackExportHarness) insertFeedback(testingT *testing.T, input model.FeedbackInput, createdAt time.Time) model.Feedback {
+	testingT.Helper()
+	input.SiteID = harness.site.ID
+	feedback, feedbackErr := model.NewFeedback(input)
+	require.NoError(testingT, feedbackErr)
+	feedback.CreatedAt = createdAt
+	require.NoError(testingT, harness.database.Create(&feedback).Error)
+	return feedback
+}
+
+func TestExportMessagesStreamsSpreadsheetSafeCSV(testingT *testing.T) {
+	harness := newFeedbackExportHarness(testingT, &api.CurrentUser{Email: testUserEmailAddress, Role: api.RoleUser})
+	rating := 4
+	older := harness.insertFeedback(testingT, model.FeedbackInput{Contact: "a@example.com", Message: "=HYPERLINK(\"http://evil\")", Rating: &rating, Category: "Bug"}, time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+	newer := harness.insertFeedback(testingT, model.FeedbackInput{Contact: "b@example.com", Message: "Line one,\nline \"two\""}, time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC))
+
+	recorder := performJSONRequest(testingT, harness.router, http.MethodGet, "/api/sites/"+harness.site.ID+"/messages/export", nil, nil)
+	require.Equal(testingT, http.StatusOK, recorder.Code, recorder.Body.String())
+	require.Equal(testingT, "text/csv; charset=utf-8", recorder.Header().Get("Content-Type"))
+	require.Contains(testingT, recorder.Header().Get("Content-Disposition"), "feedback-"+harness.site.ID+".csv")
+
+	body := recorder.Body.String()
+	require.True(testingT, strings.HasPrefix(body, "\ufeff"))
+	records, parseErr := csv.NewReader(strings.NewReader(strings.TrimPrefix(body, "\ufeff"))).ReadAll()
+	require.NoError(testingT, parseErr)
+	require.Len(testingT, records, 3)
+	require.Equal(testingT, []string{"id", "contact", "message", "extra_field", "rating", "nps_score", "category", "page_url", "attachments", "ip", "user_agent", "created_at", "delivery"}, records[0])
+	require.Equal(testingT, newer.ID, records[1][0])
+	require.Equal(testingT, "Line one,\nline \"two\"", records[1][2])
+	require.Equal(testingT, "", records[1][4])
+	require.Equal(testingT, older.ID, records[2][0])
+	require.Equal(testingT, "'=HYPERLINK(\"http://evil\")", records[2][2])
+	require.Equal(testingT, "4", records[2][4])
+	require.Equal(testingT, "Bug", records[2][6])
+	require.Equal(testingT, "1772366400", records[2][11])
+}
+
+func TestExportMessagesStreamsJSONLinesWithinDateRange(testingT *testing.T) {
+	harness := newFeedbackExportHarness(testingT, &api.CurrentUser{Email: testUserEmailAddress, Role: api.RoleUser})
+	harness.insertFeedback(testingT, model.FeedbackInput{Message: "Too early"}, time.Date(2026, 2, 28, 23, 59, 0, 0, time.UTC))
+	first := harness.insertFeedback(testingT, model.FeedbackInput{Message: "First day"}, time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC))
+	last := harness.insertFeedback(testingT, model.FeedbackInput{Message: "Last day"}, time.Date(2026, 3, 2, 23, 59, 0, 0, time.UTC))
+	harness.insertFeedback(testingT, model.FeedbackInput{Message: "Too late"}, time.Date(2026, 3, 3, 0, 0, 0, 0, time.UTC))
+
+	recorder := performJSONRequest(testingT, harness.router, http.MethodGet, "/api/sites/"+harness.site.ID+"/messages/export?format=jsonl&from=2026-03-01&to=2026-03-02", nil, nil)
+	require.Equal(testingT, http.StatusOK, recorder.Code, recorder.Body.String())
+	require.Equal(testingT, "application/x-ndjson", recorder.Header().Get("Content-Type"))
+
+	var exportedMessages []map[string]any
+	scanner := bufio.NewScanner(recorder.Body)
+	for scanner.Scan() {
+		var exportedMessage map[string]any
+		require.NoError(testingT, json.Unmarshal(scanner.Bytes(), &exportedMessage))
+		exportedMessages = append(exportedMessages, exportedMessage)
+	}
+	require.Len(testingT, exportedMessages, 2)
+	require.Equal(testingT, last.ID, exportedMessages[0]["id"])
+	require.Equal(testingT, first.ID, exportedMessages[1]["id"])
+	require.Equal(testingT, "First day", exportedMessages[1]["message"])
+	require.Contains(testingT, exportedMessages[1], "created_at")
+	require.Contains(testingT, exportedMessages[1], "delivery")
+}
+
+func TestExportMessagesAttachesFilesAcrossBatches(testingT *testing.T) {
+	harness := newFeedbackExportHarness(testingT, &api.CurrentUser{Email: testUserEmailAddress, Role: api.RoleUser})
+	firstDay := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	feedbackCount := 250
+	feedbacks := make([]model.Feedback, 0, feedbackCount)
+	for index := 0; index < feedbackCount; index++ {
+		feedbacks = append(feedbacks, harness.insertFeedback(testingT, model.FeedbackInput{Message: fmt.Sprintf("Message %d", index)}, firstDay.Add(time.Duration(index)*time.Minute)))
+	}
+	outsideRange := harness.insertFeedback(testingT, model.FeedbackInput{Message: "Too late"}, firstDay.Add(72*time.Hour))
+	oldest, newest := feedbacks[0], feedbacks[feedbackCount-1]
+	for _, feedback := range []model.Feedback{oldest, newest, outsideRange} {
+		require.NoError(testingT, harness.database.Create(&model.FeedbackAttachment{
+			ID:          "attachment-" + feedback.ID,
+			FeedbackID:  feedback.ID,
+			SiteID:      harness.site.ID,
+			FileName:    "shot.png",
+			ContentType: "image/png",
+			SizeBytes:   10,
+			StorageKey:  "key-" + feedback.ID,
+		}).Error)
+	}
+
+	recorder := performJSONRequest(testingT, harness.router, http.MethodGet, "/api/sites/"+harness.site.ID+"/messages/export?from=2026-03-01&to=2026-03-02", nil, nil)
+	require.Equal(testingT, http.StatusOK, recorder.Code, recorder.Body.String())
+
+	records, parseErr := csv.NewReader(strings.NewReader(strings.TrimPrefix(recorder.Body.String(), "\ufeff"))).ReadAll()
+	require.NoError(testingT, parseErr)
+	require.Len(testingT, records, feedbackCount+1)
+	require.Equal(testingT, newest.ID, records[1][0])
+	require.Contains(testingT, records[1][8], "attachment-"+newest.ID)
+	require.Equal(testingT, oldest.ID, records[feedbackCount][0])
+	require.Contains(testingT, records[feedbackCount][8], "attachment-"+oldest.ID)
+	for _, record := range records[2:feedbackCount] {
+		require.Empty(testingT, record[8])
+	}
+}
+
+func TestExportMessagesRejectsInvalidParameters(testingT *testing.T) {
+	harness := newFeedbackExportHarness(testingT, &api.CurrentUser{Email: testUserEmailAddress, Role: api.RoleUser})
+
+	testCases := []struct {
+		name          string
+		rawQuery      string
+		expectedError string
+	}{
+		{name: "unknown format", rawQuery: "format=xml", expectedError: "invalid_format"},
+		{name: "malformed from", rawQuery: "from=yesterday", expectedError: "invalid_date_range"},
+		{name: "reversed range", rawQuery: "from=2026-03-05&to=2026-03-01", expectedError: "invalid_date_range"},
+	}
+
+	for _, testCase := range testCases {
+		testingT.Run(testCase.name, func(testingT *testing.T) {
+			recorder := performJSONRequest(testingT, harness.router, http.MethodGet, "/api/sites/"+harness.site.ID+"/messages/export?"+testCase.rawQuery, nil, nil)
+			require.Equal(testingT, http.StatusBadRequest, recorder.Code)
+			var payload map[string]string
+			require.NoError(testingT, json.Unmarshal(recorder.Body.Bytes(), &payload))
+			require.Equal(testingT, testCase.expectedError, payload[jsonErrorKey])
+		})
+	}
+}
+
+func TestExportMessagesRejectsOtherOwners(testingT *testing.T) {
+	harness := newFeedbackExportHarness(testingT, &api.CurrentUser{Email: "stranger@example.com", Role: api.RoleUser})
+
+	recorder := performJSONRequest(testingT, harness.router, http.MethodGet, "/api/sites/"+harness.site.ID+"/messages/export", nil, nil)
+	require.Equal(testingT, http.StatusForbidden, recorder.Code)
+}
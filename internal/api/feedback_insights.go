@@ -0,0 +1,326 @@
+package api
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"github.com/MarkoPoloResearchLab/loopaware/internal/model"
+)
+
+const (
+	feedbackInsightsDefaultDays = 30
+	feedbackInsightsMaxDays     = 365
+	feedbackInsightsQueryDays   = "days"
+)
+
+// FeedbackInsightsHandlers aggregates structured feedback into rating, NPS, and category reports.
+type FeedbackInsightsHandlers struct {
+	database *gorm.DB
+	logger   *zap.Logger
+	now      func() time.Time
+}
+
+// NewFeedbackInsightsHandlers constructs handlers that report on a site's structured feedback.
+func NewFeedbackInsightsHandlers(database *gorm.DB, logger *zap.Logger) *FeedbackInsightsHandlers {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &FeedbackInsightsHandlers{
+		database: database,
+		logger:   logger,
+		now:      time.Now,
+	}
+}
+
+type feedbackRatingBucket struct {
+	Rating int   `json:"rating"`
+	Count  int64 `json:"count"`
+}
+
+type feedbackRatingTrendPoint struct {
+	Date          string  `json:"date"`
+	RatedCount    int64   `json:"rated_count"`
+	AverageRating float64 `json:"average_rating"`
+}
+
+type feedbackRatingsResponse struct {
+	SiteID        string                     `json:"site_id"`
+	Days          int                        `json:"days"`
+	RatedCount    int64                      `json:"rated_count"`
+	AverageRating float64                    `json:"average_rating"`
+	Distribution  []feedbackRatingBucket     `json:"distribution"`
+	Trend         []feedbackRatingTrendPoint `json:"trend"`
+}
+
+type feedbackNPSBreakdown struct {
+	ResponseCount int64   `json:"response_count"`
+	Promoters     int64   `json:"promoters"`
+	Passives      int64   `json:"passives"`
+	Detractors    int64   `json:"detractors"`
+	Score         float64 `json:"score"`
+}
+
+type feedbackNPSTrendPoint struct {
+	Date string `json:"date"`
+	feedbackNPSBreakdown
+}
+
+type feedbackNPSResponse struct {
+	SiteID string `json:"site_id"`
+	Days   int    `json:"days"`
+	feedbackNPSBreakdown
+	Trend []feedbackNPSTrendPoint `json:"trend"`
+}
+
+type feedbackCategoryCount struct {
+	Category string `json:"category"`
+	Count    int64  `json:"count"`
+}
+
+type feedbackCategoryTrendPoint struct {
+	Date   string           `json:"date"`
+	Counts map[string]int64 `json:"counts"`
+}
+
+type feedbackCategoriesResponse struct {
+	SiteID     string                       `json:"site_id"`
+	Days       int                          `json:"days"`
+	Categories []feedbackCategoryCount      `json:"categories"`
+	Trend      []feedbackCategoryTrendPoint `json:"trend"`
+}
+
+type feedbackRatingTotals struct {
+	count int64
+	sum   int64
+}
+
+// structuredFeedbackCount is the number of feedback entries created on Day with one rating, NPS score, or category.
+type structuredFeedbackCount struct {
+	Day      string
+	Rating   int
+	NPSScore int `gorm:"column:nps_score"`
+	Category string
+	Count    int64
+}
+
+// RatingSummary reports the average star rating, its distribution, and a daily trend.
+func (handlers *FeedbackInsightsHandlers) RatingSummary(context *gin.Context) {
+	site, days, rows, ok := handlers.countStructuredFeedback(context, "rating", "rating IS NOT NULL")
+	if !ok {
+		return
+	}
+
+	distribution := make([]feedbackRatingBucket, 0, model.FeedbackRatingMax-model.FeedbackRatingMin+1)
+	for rating := model.FeedbackRatingMin; rating <= model.FeedbackRatingMax; rating++ {
+		distribution = append(distribution, feedbackRatingBucket{Rating: rating})
+	}
+	totalsByDay := make(map[string]feedbackRatingTotals)
+	var ratedCount, ratingSum int64
+	for _, row := range rows {
+		if row.Rating < model.FeedbackRatingMin || row.Rating > model.FeedbackRatingMax {
+			continue
+		}
+		distribution[row.Rating-model.FeedbackRatingMin].Count += row.Count
+		ratedCount += row.Count
+		ratingSum += int64(row.Rating) * row.Count
+		dayTotals := totalsByDay[row.Day]
+		dayTotals.count += row.Count
+		dayTotals.sum += int64(row.Rating) * row.Count
+		totalsByDay[row.Day] = dayTotals
+	}
+
+	trend := make([]feedbackRatingTrendPoint, 0, days)
+	for _, dayKey := range handlers.trendDays(days) {
+		dayTotals := totalsByDay[dayKey]
+		trend = append(trend, feedbackRatingTrendPoint{
+			Date:          dayKey,
+			RatedCount:    dayTotals.count,
+			AverageRating: roundedRatio(dayTotals.sum, dayTotals.count, 1),
+		})
+	}
+
+	context.JSON(http.StatusOK, feedbackRatingsResponse{
+		SiteID:        site.ID,
+		Days:          days,
+		RatedCount:    ratedCount,
+		AverageRating: roundedRatio(ratingSum, ratedCount, 1),
+		Distribution:  distribution,
+		Trend:         trend,
+	})
+}
+
+// NPSSummary reports promoters, passives, detractors, and the net promoter score with a daily trend.
+func (handlers *FeedbackInsightsHandlers) NPSSummary(context *gin.Context) {
+	site, days, rows, ok := handlers.countStructuredFeedback(context, "nps_score", "nps_score IS NOT NULL")
+	if !ok {
+		return
+	}
+
+	var overall feedbackNPSBreakdown
+	breakdownByDay := make(map[string]feedbackNPSBreakdown)
+	for _, row := range rows {
+		segment := model.NPSSegment(row.NPSScore)
+		overall = overall.add(segment, row.Count)
+		breakdownByDay[row.Day] = breakdownByDay[row.Day].add(segment, row.Count)
+	}
+
+	trend := make([]feedbackNPSTrendPoint, 0, days)
+	for _, dayKey := range handlers.trendDays(days) {
+		trend = append(trend, feedbackNPSTrendPoint{Date: dayKey, feedbackNPSBreakdown: breakdownByDay[dayKey].scored()})
+	}
+
+	context.JSON(http.StatusOK, feedbackNPSResponse{
+		SiteID:               site.ID,
+		Days:                 days,
+		feedbackNPSBreakdown: overall.scored(),
+		Trend:                trend,
+	})
+}
+
+// CategorySummary reports feedback counts per category overall and per day.
+func (handlers *FeedbackInsightsHandlers) CategorySummary(context *gin.Context) {
+	site, days, rows, ok := handlers.countStructuredFeedback(context, "category", "category <> ''")
+	if !ok {
+		return
+	}
+
+	totals := make(map[string]int64)
+	countsByDay := make(map[string]map[string]int64)
+	for _, row := range rows {
+		totals[row.Category] += row.Count
+		if countsByDay[row.Day] == nil {
+			countsByDay[row.Day] = make(map[string]int64)
+		}
+		countsByDay[row.Day][row.Category] += row.Count
+	}
+
+	categories := make([]feedbackCategoryCount, 0, len(totals))
+	for category, count := range totals {
+		categories = append(categories, feedbackCategoryCount{Category: category, Count: count})
+	}
+	sort.Slice(categories, func(left, right int) bool {
+		if categories[left].Count != categories[right].Count {
+			return categories[left].Count > categories[right].Count
+		}
+		return categories[left].Category < categories[right].Category
+	})
+
+	trend := make([]feedbackCategoryTrendPoint, 0, days)
+	for _, dayKey := range handlers.trendDays(days) {
+		dayCounts := countsByDay[dayKey]
+		if dayCounts == nil {
+			dayCounts = map[string]int64{}
+		}
+		trend = append(trend, feedbackCategoryTrendPoint{Date: dayKey, Counts: dayCounts})
+	}
+
+	context.JSON(http.StatusOK, feedbackCategoriesResponse{
+		SiteID:     site.ID,
+		Days:       days,
+		Categories: categories,
+		Trend:      trend,
+	})
+}
+
+// countStructuredFeedback counts the site's feedback in the requested window per day and value of column, keeping
+// only rows that match presentCondition.
+func (handlers *FeedbackInsightsHandlers) countStructuredFeedback(context *gin.Context, column string, presentCondition string) (model.Site, int, []structuredFeedbackCount, bool) {
+	site, _, ok := resolveManageableSite(context, handlers.database)
+	if !ok {
+		return model.Site{}, 0, nil, false
+	}
+
+	days, daysErr := parseFeedbackInsightsDays(context.Query(feedbackInsightsQueryDays))
+	if daysErr != nil {
+		context.JSON(http.StatusBadRequest, gin.H{jsonKeyError: errorValueInvalidDays})
+		return model.Site{}, 0, nil, false
+	}
+
+	var rows []structuredFeedbackCount
+	queryErr := handlers.database.WithContext(context.Request.Context()).
+		Model(&model.Feedback{}).
+		Select("DATE(created_at) as day, "+column+", COUNT(*) as count").
+		Where("site_id = ? AND created_at >= ?", site.ID, handlers.windowStart(days)).
+		Where(presentCondition).
+		Group("DATE(created_at), " + column).
+		Scan(&rows).Error
+	if queryErr == nil {
+		for index := range rows {
+			dayKey, _, normalizeErr := normalizeVisitTrendMapKey(rows[index].Day)
+			if normalizeErr != nil {
+				queryErr = normalizeErr
+				break
+			}
+			rows[index].Day = dayKey
+		}
+	}
+	if queryErr != nil {
+		requestLogger(context, handlers.logger).Warn("feedback_insights_query", zap.Error(queryErr), zap.String("site_id", site.ID))
+		context.JSON(http.StatusInternalServerError, gin.H{jsonKeyError: errorValueQueryFailed})
+		return model.Site{}, 0, nil, false
+	}
+	return site, days, rows, true
+}
+
+func (handlers *FeedbackInsightsHandlers) windowStart(days int) time.Time {
+	return handlers.now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -(days - 1))
+}
+
+func (handlers *FeedbackInsightsHandlers) trendDays(days int) []string {
+	startDay := handlers.windowStart(days)
+	dayKeys := make([]string, 0, days)
+	for dayIndex := 0; dayIndex < days; dayIndex++ {
+		dayKeys = append(dayKeys, startDay.AddDate(0, 0, dayIndex).Format(visitTrendDateFormat))
+	}
+	return dayKeys
+}
+
+func (breakdown feedbackNPSBreakdown) add(segment string, count int64) feedbackNPSBreakdown {
+	breakdown.ResponseCount += count
+	switch segment {
+	case model.FeedbackNPSSegmentPromoter:
+		breakdown.Promoters += count
+	case model.FeedbackNPSSegmentDetractor:
+		breakdown.Detractors += count
+	default:
+		breakdown.Passives += count
+	}
+	return breakdown
+}
+
+func (breakdown feedbackNPSBreakdown) scored() feedbackNPSBreakdown {
+	breakdown.Score = roundedRatio(100*(breakdown.Promoters-breakdown.Detractors), breakdown.ResponseCount, 1)
+	return breakdown
+}
+
+func roundedRatio(numerator int64, denominator int64, decimals int) float64 {
+	if denominator == 0 {
+		return 0
+	}
+	scale := math.Pow10(decimals)
+	return math.Round(float64(numerator)/float64(denominator)*scale) / scale
+}
+
+func parseFeedbackInsightsDays(rawValue string) (int, error) {
+	trimmedValue := strings.TrimSpace(rawValue)
+	if trimmedValue == "" {
+		return feedbackInsightsDefaultDays, nil
+	}
+	days, parseErr := strconv.Atoi(trimmedValue)
+	if parseErr != nil {
+		return 0, parseErr
+	}
+	if days <= 0 || days > feedbackInsightsMaxDays {
+		return 0, errors.New("feedback insights days out of range")
+	}
+	return days, nil
+}
@@ -0,0 +1,231 @@
+package api_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"github.com/MarkoPoloResearchLab/loopaware/internal/api"
+	"github.com/MarkoPoloResearchLab/loopaware/internal/model"
+)
+
+const testFeedbackInsightsOrigin = "http://insights.example"
+
+type feedbackInsightsHarness struct {
+	database *gorm.DB
+	site     model.Site
+	router   *gin.Engine
+}
+
+func newFeedbackInsightsHarness(testingT *testing.T, currentUser *api.CurrentUser) feedbackInsightsHarness {
+	testingT.Helper()
+
+	database := newSiteTestHarness(testingT).database
+
+	site := insertSite(testingT, database, "Insights Site", testFeedbackInsightsOrigin, testAdminEmailAddress)
+
+	handlers := api.NewFeedbackInsightsHandlers(database, zap.NewNop())
+	router := newAuthenticatedRouter(currentUser)
+	router.GET("/api/sites/:id/feedback/ratings", handlers.RatingSummary)
+	router.GET("/api/sites/:id/feedback/nps", handlers.NPSSummary)
+	router.GET("/api/sites/:id/feedback/categories", handlers.CategorySummary)
+
+	return feedbackInsightsHarness{database: database, site: site, router: router}
+}
+
+func (harness feedbackInsightsHarness) insertFeedback(testingT *testing.T, input model.FeedbackInput, age time.Duration) {
+	testingT.Helper()
+	input.SiteID = harness.site.ID
+	if input.Message == "" {
+		input.Message = "structured"
+	}
+	feedback, feedbackErr := model.NewFeedback(input)
+	require.NoError(testingT, feedbackErr)
+	require.NoError(testingT, harness.database.Create(&feedback).Error)
+	require.NoError(testingT, harness.database.Model(&model.Feedback{}).Where("id = ?", feedback.ID).UpdateColumn("created_at", time.Now().UTC().Add(-age)).Error)
+}
+
+func intPointer(value int) *int {
+	return &value
+}
+
+func TestFeedbackRatingSummaryAggregatesWindow(testingT *testing.T) {
+	harness := newFeedbackInsightsHarness(testingT, &api.CurrentUser{Email: testAdminEmailAddress, Role: api.RoleUser})
+	harness.insertFeedback(testingT, model.FeedbackInput{Rating: intPointer(5)}, time.Minute)
+	harness.insertFeedback(testingT, model.FeedbackInput{Rating: intPointer(5)}, time.Minute)
+	harness.insertFeedback(testingT, model.FeedbackInput{Rating: intPointer(4)}, time.Minute)
+	harness.insertFeedback(testingT, model.FeedbackInput{Rating: intPointer(2)}, 48*time.Hour)
+	harness.insertFeedback(testingT, model.FeedbackInput{Rating: intPointer(1)}, 40*24*time.Hour)
+	harness.insertFeedback(testingT, model.FeedbackInput{}, time.Minute)
+
+	recorder := performJSONRequest(testingT, harness.router, http.MethodGet, fmt.Sprintf("/api/sites/%s/feedback/ratings?days=7", harness.site.ID), nil, nil)
+	require.Equal(testingT, http.StatusOK, recorder.Code, recorder.Body.String())
+
+	var response struct {
+		Days          int     `json:"days"`
+		RatedCount    int64   `json:"rated_count"`
+		AverageRating float64 `json:"average_rating"`
+		Distribution  []struct {
+			Rating int   `json:"rating"`
+			Count  int64 `json:"count"`
+		} `json:"distribution"`
+		Trend []struct {
+			Date          string  `json:"date"`
+			RatedCount    int64   `json:"rated_count"`
+			AverageRating float64 `json:"average_rating"`
+		} `json:"trend"`
+	}
+	require.NoError(testingT, json.Unmarshal(recorder.Body.Bytes(), &response))
+	require.Equal(testingT, 7, response.Days)
+	require.Equal(testingT, int64(4), response.RatedCount)
+	require.Equal(testingT, 4.0, response.AverageRating)
+	require.Len(testingT, response.Distribution, 5)
+	require.Equal(testingT, int64(1), response.Distribution[1].Count)
+	require.Equal(testingT, int64(2), response.Distribution[4].Count)
+	require.Zero(testingT, response.Distribution[0].Count)
+	require.Len(testingT, response.Trend, 7)
+	today := response.Trend[len(response.Trend)-1]
+	require.Equal(testingT, time.Now().UTC().Format("2006-01-02"), today.Date)
+	require.Equal(testingT, int64(3), today.RatedCount)
+	require.Equal(testingT, 4.7, today.AverageRating)
+}
+
+func TestFeedbackNPSSummaryComputesScore(testingT *testing.T) {
+	harness := newFeedbackInsightsHarness(testingT, &api.CurrentUser{Email: testAdminEmailAddress, Role: api.RoleUser})
+	for _, score := range []int{10, 9, 9, 8, 3} {
+		harness.insertFeedback(testingT, model.FeedbackInput{NPSScore: intPointer(score)}, time.Minute)
+	}
+
+	recorder := performJSONRequest(testingT, harness.router, http.MethodGet, fmt.Sprintf("/api/sites/%s/feedback/nps", harness.site.ID), nil, nil)
+	require.Equal(testingT, http.StatusOK, recorder.Code, recorder.Body.String())
+
+	var response struct {
+		Days          int     `json:"days"`
+		ResponseCount int64   `json:"response_count"`
+		Promoters     int64   `json:"promoters"`
+		Passives      int64   `json:"passives"`
+		Detractors    int64   `json:"detractors"`
+		Score         float64 `json:"score"`
+		Trend         []struct {
+			ResponseCount int64   `json:"response_count"`
+			Score         float64 `json:"score"`
+		} `json:"trend"`
+	}
+	require.NoError(testingT, json.Unmarshal(recorder.Body.Bytes(), &response))
+	require.Equal(testingT, 30, response.Days)
+	require.Equal(testingT, int64(5), response.ResponseCount)
+	require.Equal(testingT, int64(3), response.Promoters)
+	require.Equal(testingT, int64(1), response.Passives)
+	require.Equal(testingT, int64(1), response.Detractors)
+	require.Equal(testingT, 40.0, response.Score)
+	require.Len(testingT, response.Trend, 30)
+	require.Equal(testingT, int64(5), response.Trend[29].ResponseCount)
+	require.Zero(testingT, response.Trend[0].ResponseCount)
+}
+
+func TestFeedbackCategorySummaryCountsPerDay(testingT *testing.T) {
+	harness := newFeedbackInsightsHarness(testingT, &api.CurrentUser{Email: testAdminEmailAddress, Role: api.RoleUser})
+	harness.insertFeedback(testingT, model.FeedbackInput{Category: "Bug"}, time.Minute)
+	harness.insertFeedback(testingT, model.FeedbackInput{Category: "Bug"}, 24*time.Hour)
+	harness.insertFeedback(testingT, model.FeedbackInput{Category: "Idea"}, time.Minute)
+	harness.insertFeedback(testingT, model.FeedbackInput{}, time.Minute)
+
+	recorder := performJSONRequest(testingT, harness.router, http.MethodGet, fmt.Sprintf("/api/sites/%s/feedback/categories?days=3", harness.site.ID), nil, nil)
+	require.Equal(testingT, http.StatusOK, recorder.Code, recorder.Body.String())
+
+	var response struct {
+		Categories []struct {
+			Category string `json:"category"`
+			Count    int64  `json:"count"`
+		} `json:"categories"`
+		Trend []struct {
+			Date   string           `json:"date"`
+			Counts map[string]int64 `json:"counts"`
+		} `json:"trend"`
+	}
+	require.NoError(testingT, json.Unmarshal(recorder.Body.Bytes(), &response))
+	require.Len(testingT, response.Categories, 2)
+	require.Equal(testingT, "Bug", response.Categories[0].Category)
+	require.Equal(testingT, int64(2), response.Categories[0].Count)
+	require.Len(testingT, response.Trend, 3)
+	require.Equal(testingT, map[string]int64{"Bug": 1, "Idea": 1}, response.Trend[2].Counts)
+	require.Equal(testingT, map[string]int64{"Bug": 1}, response.Trend[1].Counts)
+	require.Empty(testingT, response.Trend[0].Counts)
+}
+
+func TestFeedbackInsightsValidateRequest(testingT *testing.T) {
+	harness := newFeedbackInsightsHarness(testingT, &api.CurrentUser{Email: testAdminEmailAddress, Role: api.RoleUser})
+	invalidDaysRecorder := performJSONRequest(testingT, harness.router, http.MethodGet, fmt.Sprintf("/api/sites/%s/feedback/ratings?days=400", harness.site.ID), nil, nil)
+	require.Equal(testingT, http.StatusBadRequest, invalidDaysRecorder.Code)
+
+	strangerHarness := newFeedbackInsightsHarness(testingT, &api.CurrentUser{Email: testUserEmailAddress, Role: api.RoleUser})
+	forbiddenRecorder := performJSONRequest(testingT, strangerHarness.router, http.MethodGet, fmt.Sprintf("/api/sites/%s/feedback/nps", strangerHarness.site.ID), nil, nil)
+	require.Equal(testingT, http.StatusForbidden, forbiddenRecorder.Code)
+}
+
+func TestCreateFeedbackStoresStructuredFields(testingT *testing.T) {
+	apiHarness := buildAPIHarness(testingT, nil, nil, nil)
+	site := insertSite(testingT, apiHarness.database, "Structured", testFeedbackInsightsOrigin, "owner@example.com")
+	headers := map[string]string{"Origin": testFeedbackInsightsOrigin}
+
+	recorder := performJSONRequest(testingT, apiHarness.router, http.MethodPost, "/public/feedback", map[string]any{
+		"site_id":   site.ID,
+		"contact":   "user@example.com",
+		"message":   "Checkout is slow",
+		"rating":    2,
+		"nps_score": 6,
+		"category":  "Performance",
+		"page_url":  testFeedbackInsightsOrigin + "/checkout",
+	}, headers)
+	require.Equal(testingT, http.StatusOK, recorder.Code, recorder.Body.String())
+
+	var stored model.Feedback
+	require.NoError(testingT, apiHarness.database.First(&stored, "site_id = ?", site.ID).Error)
+	require.Equal(testingT, 2, *stored.Rating)
+	require.Equal(testingT, 6, *stored.NPSScore)
+	require.Equal(testingT, "Performance", stored.Category)
+	require.Equal(testingT, testFeedbackInsightsOrigin+"/checkout", stored.PageURL)
+
+	invalidCases := map[string]map[string]any{
+		"invalid_rating":    {"rating": 7},
+		"invalid_nps_score": {"nps_score": 11},
+		"invalid_page_url":  {"page_url": "ftp://example.com"},
+	}
+	for expectedError, overrides := range invalidCases {
+		body := map[string]any{"site_id": site.ID, "contact": "user@example.com", "message": "Hi"}
+		for key, value := range overrides {
+			body[key] = value
+		}
+		invalidRecorder := performJSONRequest(testingT, apiHarness.router, http.MethodPost, "/public/feedback", body, headers)
+		require.Equal(testingT, http.StatusBadRequest, invalidRecorder.Code)
+		require.JSONEq(testingT, fmt.Sprintf(`{"error":%q}`, expectedError), invalidRecorder.Body.String())
+	}
+}
+
+func TestCreateFeedbackMapsWidgetExtraFieldToStructuredFields(testingT *testing.T) {
+	apiHarness := buildAPIHarness(testingT, nil, nil, nil)
+	headers := map[string]string{"Origin": testFeedbackInsightsOrigin}
+	ratedSite := insertSite(testingT, apiHarness.database, "Rated", testFeedbackInsightsOrigin, "owner@example.com")
+	ratedSite.WidgetConfig = model.WidgetConfig{ExtraField: &model.WidgetExtraField{Type: model.WidgetExtraFieldRating}}
+	require.NoError(testingT, apiHarness.database.Save(&ratedSite).Error)
+
+	recorder := performJSONRequest(testingT, apiHarness.router, http.MethodPost, "/public/feedback", map[string]any{
+		"site_id":     ratedSite.ID,
+		"contact":     "user@example.com",
+		"message":     "Lovely",
+		"extra_field": "4",
+	}, headers)
+	require.Equal(testingT, http.StatusOK, recorder.Code, recorder.Body.String())
+
+	var stored model.Feedback
+	require.NoError(testingT, apiHarness.database.First(&stored, "site_id = ?", ratedSite.ID).Error)
+	require.Equal(testingT, 4, *stored.Rating)
+	require.Equal(testingT, "4", stored.ExtraField)
+}
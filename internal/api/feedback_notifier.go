@@ -41,7 +41,7 @@ func applyFeedbackNotification(ctx context.Context, database *gorm.DB, logger *z
 	delivery, notifyErr := notifier.NotifyFeedback(ctx, site, payload)
 	if notifyErr != nil {
 		if logger != nil {
-			logger.Warn("feedback_notification_failed", zap.Error(notifyErr), zap.String("site_id", site.ID), zap.String("feedback_id", feedback.ID))
+			requestLogger(ctx, logger).Warn("feedback_notification_failed", zap.Error(notifyErr), zap.String("site_id", site.ID), zap.String("feedback_id", feedback.ID))
 		}
 		intendedDelivery = model.FeedbackDeliveryNone
 	}
@@ -61,7 +61,7 @@ func applyFeedbackNotification(ctx context.Context, database *gorm.DB, logger *z
 	updateErr := database.Exec("UPDATE feedbacks SET delivery = ? WHERE id = ?", intendedDelivery, feedback.ID).Error
 	if updateErr != nil {
 		if logger != nil {
-			logger.Warn("update_feedback_delivery_failed", zap.Error(updateErr), zap.String("feedback_id", feedback.ID))
+			requestLogger(ctx, logger).Warn("update_feedback_delivery_failed", zap.Error(updateErr), zap.String("feedback_id", feedback.ID))
 		}
 		return
 	}
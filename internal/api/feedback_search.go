@@ -0,0 +1,305 @@
+package api
+
+import (
+	"html"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"golang.org/x/text/unicode/norm"
+	"gorm.io/gorm"
+
+	"github.com/MarkoPoloResearchLab/loopaware/internal/model"
+	"github.com/MarkoPoloResearchLab/loopaware/internal/storage"
+)
+
+const (
+	feedbackSearchQueryParam       = "q"
+	feedbackSearchDefaultPageSize  = 20
+	feedbackSearchMaxPageSize      = 100
+	feedbackSearchMaxQueryLength   = 200
+	feedbackSearchMaxTerms         = 8
+	feedbackSearchSnippetRunes     = 240
+	feedbackSearchSnippetLeadRunes = 60
+	feedbackSearchEllipsis         = "…"
+	feedbackSearchHighlightOpen    = "<mark>"
+	feedbackSearchHighlightClose   = "</mark>"
+
+	errorValueInvalidQuery      = "invalid_query"
+	errorValueSearchUnavailable = "search_unavailable"
+)
+
+// FeedbackSearchHandlers searches feedback text across every site the caller can manage.
+type FeedbackSearchHandlers struct {
+	database *gorm.DB
+	logger   *zap.Logger
+}
+
+// NewFeedbackSearchHandlers constructs handlers backed by the full-text feedback index.
+func NewFeedbackSearchHandlers(database *gorm.DB, logger *zap.Logger) *FeedbackSearchHandlers {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &FeedbackSearchHandlers{
+		database: database,
+		logger:   logger,
+	}
+}
+
+type feedbackSearchResult struct {
+	ID         string `json:"id"`
+	SiteID     string `json:"site_id"`
+	SiteName   string `json:"site_name"`
+	FaviconURL string `json:"favicon_url,omitempty"`
+	Contact    string `json:"contact"`
+	Message    string `json:"message"`
+	Highlight  string `json:"highlight"`
+	Category   string `json:"category,omitempty"`
+	Rating     *int   `json:"rating,omitempty"`
+	CreatedAt  int64  `json:"created_at"`
+}
+
+type feedbackSearchResponse struct {
+	Query   string                 `json:"query"`
+	Total   int64                  `json:"total"`
+	Limit   int                    `json:"limit"`
+	Offset  int                    `json:"offset"`
+	Results []feedbackSearchResult `json:"results"`
+}
+
+type feedbackSearchSite struct {
+	ID               string
+	Name             string
+	FaviconFetchedAt time.Time
+	HasFavicon       bool
+}
+
+type feedbackSearchRow struct {
+	ID        string
+	SiteID    string
+	Contact   string
+	Message   string
+	Category  string
+	Rating    *int
+	CreatedAt time.Time
+}
+
+// Search ranks feedback matching the q query parameter by relevance and highlights the matched words.
+func (handlers *FeedbackSearchHandlers) Search(context *gin.Context) {
+	currentUser, ok := CurrentUserFromContext(context)
+	if !ok {
+		context.JSON(http.StatusUnauthorized, gin.H{jsonKeyError: authErrorUnauthorized})
+		return
+	}
+
+	query := strings.TrimSpace(context.Query(feedbackSearchQueryParam))
+	terms := feedbackSearchTerms(query)
+	if len(terms) == 0 || utf8.RuneCountInString(query) > feedbackSearchMaxQueryLength {
+		context.JSON(http.StatusBadRequest, gin.H{jsonKeyError: errorValueInvalidQuery})
+		return
+	}
+
+	limit := feedbackSearchDefaultPageSize
+	if rawLimit := strings.TrimSpace(context.Query("limit")); rawLimit != "" {
+		parsedLimit, parseErr := strconv.Atoi(rawLimit)
+		if parseErr != nil || parsedLimit <= 0 || parsedLimit > feedbackSearchMaxPageSize {
+			context.JSON(http.StatusBadRequest, gin.H{jsonKeyError: errorValueInvalidLimit})
+			return
+		}
+		limit = parsedLimit
+	}
+	offset := 0
+	if rawOffset := strings.TrimSpace(context.Query("offset")); rawOffset != "" {
+		parsedOffset, parseErr := strconv.Atoi(rawOffset)
+		if parseErr != nil || parsedOffset < 0 {
+			context.JSON(http.StatusBadRequest, gin.H{jsonKeyError: errorValueInvalidOffset})
+			return
+		}
+		offset = parsedOffset
+	}
+
+	if !storage.SupportsFeedbackSearch(handlers.database) {
+		context.JSON(http.StatusServiceUnavailable, gin.H{jsonKeyError: errorValueSearchUnavailable})
+		return
+	}
+
+	requestContext := context.Request.Context()
+	siteQuery := handlers.database.WithContext(requestContext).
+		Model(&model.Site{}).
+		Select("id, name, favicon_fetched_at, CASE WHEN favicon_data IS NOT NULL AND LENGTH(favicon_data) > 0 THEN 1 ELSE 0 END AS has_favicon")
+	if !currentUser.hasRole(RoleAdmin) {
+		normalizedEmail := currentUser.normalizedEmail()
+		siteQuery = siteQuery.Where("(LOWER(owner_email) = ? OR LOWER(creator_email) = ?)", normalizedEmail, normalizedEmail)
+	}
+	var sites []feedbackSearchSite
+	if err := siteQuery.Scan(&sites).Error; err != nil {
+		requestLogger(context, handlers.logger).Warn("feedback_search_sites", zap.Error(err))
+		context.JSON(http.StatusInternalServerError, gin.H{jsonKeyError: errorValueQueryFailed})
+		return
+	}
+
+	response := feedbackSearchResponse{Query: query, Limit: limit, Offset: offset, Results: []feedbackSearchResult{}}
+	if len(sites) == 0 {
+		context.JSON(http.StatusOK, response)
+		return
+	}
+	sitesByID := make(map[string]feedbackSearchSite, len(sites))
+	siteIDs := make([]string, 0, len(sites))
+	for _, site := range sites {
+		sitesByID[site.ID] = site
+		siteIDs = append(siteIDs, site.ID)
+	}
+
+	matchQuery := func() *gorm.DB {
+		return handlers.database.WithContext(requestContext).
+			Table(storage.FeedbackSearchTable).
+			Joins("JOIN feedbacks ON feedbacks.id = "+storage.FeedbackSearchTable+".feedback_id").
+			Where(storage.FeedbackSearchTable+" MATCH ?", feedbackSearchMatchExpression(terms)).
+			Where("feedbacks.site_id IN ?", siteIDs)
+	}
+
+	if err := matchQuery().Count(&response.Total).Error; err != nil {
+		requestLogger(context, handlers.logger).Warn("feedback_search_count", zap.Error(err))
+		context.JSON(http.StatusInternalServerError, gin.H{jsonKeyError: errorValueQueryFailed})
+		return
+	}
+
+	var rows []feedbackSearchRow
+	if err := matchQuery().
+		Select("feedbacks.id, feedbacks.site_id, feedbacks.contact, feedbacks.message, feedbacks.category, feedbacks.rating, feedbacks.created_at").
+		Order("bm25(" + storage.FeedbackSearchTable + ")").
+		Order("feedbacks.created_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Scan(&rows).Error; err != nil {
+		requestLogger(context, handlers.logger).Warn("feedback_search_query", zap.Error(err))
+		context.JSON(http.StatusInternalServerError, gin.H{jsonKeyError: errorValueQueryFailed})
+		return
+	}
+
+	for _, row := range rows {
+		site := sitesByID[row.SiteID]
+		faviconURL := ""
+		if site.HasFavicon {
+			faviconURL = versionedSiteFaviconURL(site.ID, site.FaviconFetchedAt)
+		}
+		response.Results = append(response.Results, feedbackSearchResult{
+			ID:         row.ID,
+			SiteID:     row.SiteID,
+			SiteName:   site.Name,
+			FaviconURL: faviconURL,
+			Contact:    row.Contact,
+			Message:    row.Message,
+			Highlight:  highlightFeedbackSearchTerms(row.Message, terms),
+			Category:   row.Category,
+			Rating:     row.Rating,
+			CreatedAt:  row.CreatedAt.Unix(),
+		})
+	}
+
+	context.JSON(http.StatusOK, response)
+}
+
+// feedbackSearchTerms splits a free-form query into lowercase words, dropping FTS5 operators and punctuation.
+func feedbackSearchTerms(query string) []string {
+	words := strings.FieldsFunc(strings.ToLower(query), func(character rune) bool {
+		return !unicode.IsLetter(character) && !unicode.IsDigit(character)
+	})
+	seenWords := make(map[string]struct{}, len(words))
+	terms := make([]string, 0, len(words))
+	for _, word := range words {
+		if _, duplicate := seenWords[word]; duplicate {
+			continue
+		}
+		seenWords[word] = struct{}{}
+		terms = append(terms, word)
+		if len(terms) == feedbackSearchMaxTerms {
+			break
+		}
+	}
+	return terms
+}
+
+// feedbackSearchMatchExpression requires every term, each matched as a word prefix.
+func feedbackSearchMatchExpression(terms []string) string {
+	quotedTerms := make([]string, 0, len(terms))
+	for _, term := range terms {
+		quotedTerms = append(quotedTerms, `"`+term+`"*`)
+	}
+	return strings.Join(quotedTerms, " ")
+}
+
+// highlightFeedbackSearchTerms returns an HTML-escaped excerpt of text with words starting with any term wrapped in <mark>.
+func highlightFeedbackSearchTerms(text string, terms []string) string {
+	runes := []rune(text)
+	type wordSpan struct{ start, end int }
+	var matches []wordSpan
+	for index := 0; index < len(runes); {
+		if !unicode.IsLetter(runes[index]) && !unicode.IsDigit(runes[index]) {
+			index++
+			continue
+		}
+		wordStart := index
+		for index < len(runes) && (unicode.IsLetter(runes[index]) || unicode.IsDigit(runes[index])) {
+			index++
+		}
+		word := foldFeedbackSearchText(string(runes[wordStart:index]))
+		for _, term := range terms {
+			if strings.HasPrefix(word, foldFeedbackSearchText(term)) {
+				matches = append(matches, wordSpan{start: wordStart, end: index})
+				break
+			}
+		}
+	}
+
+	excerptStart, excerptEnd := 0, len(runes)
+	if len(runes) > feedbackSearchSnippetRunes {
+		if len(matches) > 0 && matches[0].start > feedbackSearchSnippetLeadRunes {
+			excerptStart = matches[0].start - feedbackSearchSnippetLeadRunes
+		}
+		excerptEnd = excerptStart + feedbackSearchSnippetRunes
+		if excerptEnd > len(runes) {
+			excerptEnd = len(runes)
+			excerptStart = excerptEnd - feedbackSearchSnippetRunes
+		}
+	}
+
+	var builder strings.Builder
+	if excerptStart > 0 {
+		builder.WriteString(feedbackSearchEllipsis)
+	}
+	cursor := excerptStart
+	for _, match := range matches {
+		if match.end <= excerptStart || match.start >= excerptEnd {
+			continue
+		}
+		start, end := max(match.start, excerptStart), min(match.end, excerptEnd)
+		builder.WriteString(html.EscapeString(string(runes[cursor:start])))
+		builder.WriteString(feedbackSearchHighlightOpen)
+		builder.WriteString(html.EscapeString(string(runes[start:end])))
+		builder.WriteString(feedbackSearchHighlightClose)
+		cursor = end
+	}
+	builder.WriteString(html.EscapeString(string(runes[cursor:excerptEnd])))
+	if excerptEnd < len(runes) {
+		builder.WriteString(feedbackSearchEllipsis)
+	}
+	return builder.String()
+}
+
+// foldFeedbackSearchText lowercases text and strips combining marks so highlighting agrees with the index tokenizer.
+func foldFeedbackSearchText(text string) string {
+	var builder strings.Builder
+	for _, character := range norm.NFD.String(strings.ToLower(text)) {
+		if unicode.Is(unicode.Mn, character) {
+			continue
+		}
+		builder.WriteRune(character)
+	}
+	return builder.String()
+}
@@ -0,0 +1,188 @@
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"github.com/MarkoPoloResearchLab/loopaware/internal/api"
+	"github.com/MarkoPoloResearchLab/loopaware/internal/model"
+)
+
+const testFeedbackSearchOtherOwner = "other@example.com"
+
+type feedbackSearchTestResult struct {
+	ID         string `json:"id"`
+	SiteID     string `json:"site_id"`
+	SiteName   string `json:"site_name"`
+	FaviconURL string `json:"favicon_url"`
+	Message    string `json:"message"`
+	Highlight  string `json:"highlight"`
+}
+
+type feedbackSearchTestResponse struct {
+	Query   string                     `json:"query"`
+	Total   int64                      `json:"total"`
+	Limit   int                        `json:"limit"`
+	Offset  int                        `json:"offset"`
+	Results []feedbackSearchTestResult `json:"results"`
+}
+
+type feedbackSearchHarness struct {
+	database  *gorm.DB
+	ownedSite model.Site
+	otherSite model.Site
+	router    func(currentUser *api.CurrentUser) *gin.Engine
+}
+
+func newFeedbackSearchHarness(testingT *testing.T) feedbackSearchHarness {
+	testingT.Helper()
+
+	database := newSiteTestHarness(testingT).database
+
+	ownedSite := insertSite(testingT, database, "Owned Site", "http://owned.example", testUserEmailAddress)
+	ownedSite.FaviconData = []byte{0x01}
+	ownedSite.FaviconFetchedAt = time.Now().UTC()
+	require.NoError(testingT, database.Save(&ownedSite).Error)
+	otherSite := insertSite(testingT, database, "Other Site", "http://other.example", testFeedbackSearchOtherOwner)
+
+	handlers := api.NewFeedbackSearchHandlers(database, zap.NewNop())
+	buildRouter := func(currentUser *api.CurrentUser) *gin.Engine {
+		router := newAuthenticatedRouter(currentUser)
+		router.GET("/api/feedback/search", handlers.Search)
+		return router
+	}
+
+	return feedbackSearchHarness{database: database, ownedSite: ownedSite, otherSite: otherSite, router: buildRouter}
+}
+
+func (harness feedbackSearchHarness) insertFeedback(testingT *testing.T, site model.Site, message string) model.Feedback {
+	testingT.Helper()
+	feedback, feedbackErr := model.NewFeedback(model.FeedbackInput{SiteID: site.ID, Contact: "visitor@example.com", Message: message})
+	require.NoError(testingT, feedbackErr)
+	require.NoError(testingT, harness.database.Create(&feedback).Error)
+	return feedback
+}
+
+func (harness feedbackSearchHarness) search(testingT *testing.T, currentUser *api.CurrentUser, rawQuery string) (int, feedbackSearchTestResponse) {
+	testingT.Helper()
+	recorder := performJSONRequest(testingT, harness.router(currentUser), http.MethodGet, "/api/feedback/search?"+rawQuery, nil, nil)
+	var response feedbackSearchTestResponse
+	if recorder.Code == http.StatusOK {
+		require.NoError(testingT, json.Unmarshal(recorder.Body.Bytes(), &response))
+	}
+	return recorder.Code, response
+}
+
+func TestFeedbackSearchScopesResultsToManageableSites(testingT *testing.T) {
+	harness := newFeedbackSearchHarness(testingT)
+	ownedFeedback := harness.insertFeedback(testingT, harness.ownedSite, "The checkout button is broken on mobile")
+	otherFeedback := harness.insertFeedback(testingT, harness.otherSite, "Checkout fails after coupon")
+	harness.insertFeedback(testingT, harness.ownedSite, "Love the new dashboard")
+
+	statusCode, response := harness.search(testingT, &api.CurrentUser{Email: testUserEmailAddress, Role: api.RoleUser}, "q=checkout")
+	require.Equal(testingT, http.StatusOK, statusCode)
+	require.Equal(testingT, int64(1), response.Total)
+	require.Len(testingT, response.Results, 1)
+	require.Equal(testingT, ownedFeedback.ID, response.Results[0].ID)
+	require.Equal(testingT, "Owned Site", response.Results[0].SiteName)
+	require.NotEmpty(testingT, response.Results[0].FaviconURL)
+	require.Equal(testingT, "The <mark>checkout</mark> button is broken on mobile", response.Results[0].Highlight)
+
+	statusCode, response = harness.search(testingT, &api.CurrentUser{Email: testAdminEmailAddress, Role: api.RoleAdmin}, "q=checkout")
+	require.Equal(testingT, http.StatusOK, statusCode)
+	require.Equal(testingT, int64(2), response.Total)
+	resultIDs := []string{response.Results[0].ID, response.Results[1].ID}
+	require.ElementsMatch(testingT, []string{ownedFeedback.ID, otherFeedback.ID}, resultIDs)
+	for _, result := range response.Results {
+		if result.SiteID == harness.otherSite.ID {
+			require.Equal(testingT, "Other Site", result.SiteName)
+			require.Empty(testingT, result.FaviconURL)
+		}
+	}
+
+	statusCode, response = harness.search(testingT, &api.CurrentUser{Email: "stranger@example.com", Role: api.RoleUser}, "q=checkout")
+	require.Equal(testingT, http.StatusOK, statusCode)
+	require.Zero(testingT, response.Total)
+	require.Empty(testingT, response.Results)
+}
+
+func TestFeedbackSearchRanksByRelevanceAndPaginates(testingT *testing.T) {
+	harness := newFeedbackSearchHarness(testingT)
+	weakMatch := harness.insertFeedback(testingT, harness.ownedSite, "Search works, but the export page is slow to load and the colors are a bit dull")
+	strongMatch := harness.insertFeedback(testingT, harness.ownedSite, "Search search search")
+	harness.insertFeedback(testingT, harness.ownedSite, "Nothing relevant here")
+
+	currentUser := &api.CurrentUser{Email: testUserEmailAddress, Role: api.RoleUser}
+	statusCode, response := harness.search(testingT, currentUser, "q=search&limit=1")
+	require.Equal(testingT, http.StatusOK, statusCode)
+	require.Equal(testingT, int64(2), response.Total)
+	require.Equal(testingT, 1, response.Limit)
+	require.Len(testingT, response.Results, 1)
+	require.Equal(testingT, strongMatch.ID, response.Results[0].ID)
+
+	statusCode, response = harness.search(testingT, currentUser, "q=search&limit=1&offset=1")
+	require.Equal(testingT, http.StatusOK, statusCode)
+	require.Len(testingT, response.Results, 1)
+	require.Equal(testingT, weakMatch.ID, response.Results[0].ID)
+}
+
+func TestFeedbackSearchMatchesPrefixesAndEscapesHighlights(testingT *testing.T) {
+	harness := newFeedbackSearchHarness(testingT)
+	harness.insertFeedback(testingT, harness.ownedSite, "<script>alert(1)</script> Café menu is confusing")
+
+	statusCode, response := harness.search(testingT, &api.CurrentUser{Email: testUserEmailAddress, Role: api.RoleUser}, "q="+url.QueryEscape("cafe conf"))
+	require.Equal(testingT, http.StatusOK, statusCode)
+	require.Len(testingT, response.Results, 1)
+	require.Equal(testingT, "&lt;script&gt;alert(1)&lt;/script&gt; <mark>Café</mark> menu is <mark>confusing</mark>", response.Results[0].Highlight)
+}
+
+func TestFeedbackSearchExcerptsLongMessages(testingT *testing.T) {
+	harness := newFeedbackSearchHarness(testingT)
+	message := strings.Repeat("filler ", 100) + "needle " + strings.Repeat("padding ", 100)
+	harness.insertFeedback(testingT, harness.ownedSite, message)
+
+	statusCode, response := harness.search(testingT, &api.CurrentUser{Email: testUserEmailAddress, Role: api.RoleUser}, "q=needle")
+	require.Equal(testingT, http.StatusOK, statusCode)
+	require.Len(testingT, response.Results, 1)
+	highlight := response.Results[0].Highlight
+	require.True(testingT, strings.HasPrefix(highlight, "…"))
+	require.True(testingT, strings.HasSuffix(highlight, "…"))
+	require.Contains(testingT, highlight, "<mark>needle</mark>")
+	require.Equal(testingT, strings.TrimSpace(message), response.Results[0].Message)
+}
+
+func TestFeedbackSearchRejectsInvalidParameters(testingT *testing.T) {
+	harness := newFeedbackSearchHarness(testingT)
+	currentUser := &api.CurrentUser{Email: testUserEmailAddress, Role: api.RoleUser}
+
+	testCases := []struct {
+		name     string
+		rawQuery string
+	}{
+		{name: "missing query", rawQuery: ""},
+		{name: "operators only", rawQuery: "q=" + url.QueryEscape(`"*" -`)},
+		{name: "query too long", rawQuery: "q=" + strings.Repeat("a", 201)},
+		{name: "zero limit", rawQuery: "q=checkout&limit=0"},
+		{name: "limit above maximum", rawQuery: "q=checkout&limit=101"},
+		{name: "negative offset", rawQuery: "q=checkout&offset=-1"},
+	}
+
+	for _, testCase := range testCases {
+		testingT.Run(testCase.name, func(testingT *testing.T) {
+			statusCode, _ := harness.search(testingT, currentUser, testCase.rawQuery)
+			require.Equal(testingT, http.StatusBadRequest, statusCode)
+		})
+	}
+
+	recorder := performJSONRequest(testingT, harness.router(nil), http.MethodGet, "/api/feedback/search?q=checkout", nil, nil)
+	require.Equal(testingT, http.StatusUnauthorized, recorder.Code)
+}
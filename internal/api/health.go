@@ -0,0 +1,127 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultReadinessCheckTimeout = 2 * time.Second
+	healthStatusOK               = "ok"
+	healthStatusReady            = "ready"
+	healthStatusNotReady         = "not_ready"
+)
+
+// ErrFaviconWorkerStopped reports a favicon manager whose fetch worker is not running.
+var ErrFaviconWorkerStopped = errors.New("favicon_worker_stopped")
+
+// ReadinessCheck is one named dependency probed by the readiness endpoint.
+type ReadinessCheck struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+// HealthHandlers serves liveness and readiness probes.
+type HealthHandlers struct {
+	logger       *zap.Logger
+	checks       []ReadinessCheck
+	checkTimeout time.Duration
+}
+
+// ReadinessResponse reports the overall readiness and the result of each check ("ok" or the error).
+type ReadinessResponse struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks"`
+}
+
+// NewHealthHandlers constructs probes that run checks on every readiness request.
+func NewHealthHandlers(logger *zap.Logger, checks ...ReadinessCheck) *HealthHandlers {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &HealthHandlers{
+		logger:       logger,
+		checks:       checks,
+		checkTimeout: defaultReadinessCheckTimeout,
+	}
+}
+
+// Healthz reports that the process is serving requests. It checks no dependencies, so an orchestrator only
+// restarts the process when it stops answering.
+func (handlers *HealthHandlers) Healthz(context *gin.Context) {
+	context.JSON(http.StatusOK, gin.H{"status": healthStatusOK})
+}
+
+// Readyz runs every readiness check concurrently and answers 503 when any of them fails.
+func (handlers *HealthHandlers) Readyz(context *gin.Context) {
+	response := handlers.runChecks(context.Request.Context())
+	status := http.StatusOK
+	if response.Status != healthStatusReady {
+		status = http.StatusServiceUnavailable
+	}
+	context.JSON(status, response)
+}
+
+func (handlers *HealthHandlers) runChecks(ctx context.Context) ReadinessResponse {
+	type checkResult struct {
+		name string
+		err  error
+	}
+	results := make(chan checkResult, len(handlers.checks))
+	for _, check := range handlers.checks {
+		go func(check ReadinessCheck) {
+			checkCtx, cancel := context.WithTimeout(ctx, handlers.checkTimeout)
+			defer cancel()
+			results <- checkResult{name: check.Name, err: check.Check(checkCtx)}
+		}(check)
+	}
+
+	response := ReadinessResponse{Status: healthStatusReady, Checks: make(map[string]string, len(handlers.checks))}
+	for range handlers.checks {
+		result := <-results
+		if result.err != nil {
+			response.Status = healthStatusNotReady
+			response.Checks[result.name] = result.err.Error()
+			handlers.logger.Warn("readiness_check_failed", zap.String("check", result.name), zap.Error(result.err))
+			continue
+		}
+		response.Checks[result.name] = healthStatusOK
+	}
+	return response
+}
+
+// DatabaseReadinessCheck pings the database connection pool.
+func DatabaseReadinessCheck(database *gorm.DB) ReadinessCheck {
+	return ReadinessCheck{
+		Name: "database",
+		Check: func(ctx context.Context) error {
+			if database == nil {
+				return errors.New("database not configured")
+			}
+			sqlDatabase, sqlErr := database.DB()
+			if sqlErr != nil {
+				return sqlErr
+			}
+			return sqlDatabase.PingContext(ctx)
+		},
+	}
+}
+
+// FaviconWorkerReadinessCheck fails when the favicon fetch worker is not running.
+func FaviconWorkerReadinessCheck(manager *SiteFaviconManager) ReadinessCheck {
+	return ReadinessCheck{
+		Name: "favicon_worker",
+		Check: func(context.Context) error {
+			if !manager.WorkerRunning() {
+				return ErrFaviconWorkerStopped
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,71 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/MarkoPoloResearchLab/loopaware/internal/api"
+)
+
+func newHealthRouter(checks ...api.ReadinessCheck) *gin.Engine {
+	handlers := api.NewHealthHandlers(zap.NewNop(), checks...)
+	router := gin.New()
+	router.GET("/healthz", handlers.Healthz)
+	router.GET("/readyz", handlers.Readyz)
+	return router
+}
+
+func TestHealthHandlersReportReadiness(testingT *testing.T) {
+	harness := newSiteTestHarness(testingT)
+	faviconManager := api.NewSiteFaviconManager(harness.database, nil, zap.NewNop())
+	router := newHealthRouter(api.DatabaseReadinessCheck(harness.database), api.FaviconWorkerReadinessCheck(faviconManager))
+
+	healthRecorder := httptest.NewRecorder()
+	router.ServeHTTP(healthRecorder, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	require.Equal(testingT, http.StatusOK, healthRecorder.Code)
+
+	stoppedRecorder := httptest.NewRecorder()
+	router.ServeHTTP(stoppedRecorder, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	require.Equal(testingT, http.StatusServiceUnavailable, stoppedRecorder.Code)
+	var stopped api.ReadinessResponse
+	require.NoError(testingT, json.Unmarshal(stoppedRecorder.Body.Bytes(), &stopped))
+	require.Equal(testingT, "not_ready", stopped.Status)
+	require.Equal(testingT, "ok", stopped.Checks["database"])
+	require.Equal(testingT, api.ErrFaviconWorkerStopped.Error(), stopped.Checks["favicon_worker"])
+
+	faviconManager.Start(context.Background())
+	readyRecorder := httptest.NewRecorder()
+	router.ServeHTTP(readyRecorder, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	require.Equal(testingT, http.StatusOK, readyRecorder.Code)
+	require.JSONEq(testingT, `{"status":"ready","checks":{"database":"ok","favicon_worker":"ok"}}`, readyRecorder.Body.String())
+
+	faviconManager.Stop()
+	require.False(testingT, faviconManager.WorkerRunning())
+}
+
+func TestHealthHandlersFailWhenDatabaseIsClosed(testingT *testing.T) {
+	harness := newSiteTestHarness(testingT)
+	sqlDatabase, sqlErr := harness.database.DB()
+	require.NoError(testingT, sqlErr)
+	require.NoError(testingT, sqlDatabase.Close())
+	router := newHealthRouter(
+		api.DatabaseReadinessCheck(harness.database),
+		api.ReadinessCheck{Name: "pinguin", Check: func(context.Context) error { return errors.New("pinguin_unavailable") }},
+	)
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	require.Equal(testingT, http.StatusServiceUnavailable, recorder.Code)
+	var response api.ReadinessResponse
+	require.NoError(testingT, json.Unmarshal(recorder.Body.Bytes(), &response))
+	require.NotEqual(testingT, "ok", response.Checks["database"])
+	require.Equal(testingT, "pinguin_unavailable", response.Checks["pinguin"])
+}
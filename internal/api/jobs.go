@@ -0,0 +1,153 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/MarkoPoloResearchLab/loopaware/internal/task"
+)
+
+const (
+	errorValueUnknownJob      = "unknown_job"
+	errorValueJobsUnavailable = "jobs_unavailable"
+	jobNameParam              = "name"
+)
+
+type jobAction func(ctx context.Context, name string) (task.JobStatus, error)
+
+// JobHandlers exposes the background job registry to administrators.
+type JobHandlers struct {
+	registry *task.Registry
+	logger   *zap.Logger
+}
+
+// NewJobHandlers constructs job handlers backed by registry.
+func NewJobHandlers(registry *task.Registry, logger *zap.Logger) *JobHandlers {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &JobHandlers{
+		registry: registry,
+		logger:   logger,
+	}
+}
+
+// JobResponse describes a background job, its schedule, and the outcome of its last run.
+type JobResponse struct {
+	Name             string `json:"name"`
+	Description      string `json:"description"`
+	Schedule         string `json:"schedule"`
+	Paused           bool   `json:"paused"`
+	Running          bool   `json:"running"`
+	Healthy          bool   `json:"healthy"`
+	TriggerRequested bool   `json:"trigger_requested"`
+	NextRunAt        int64  `json:"next_run_at"`
+	LastStartedAt    int64  `json:"last_started_at"`
+	LastFinishedAt   int64  `json:"last_finished_at"`
+	LastDurationMs   int64  `json:"last_duration_ms"`
+	LastError        string `json:"last_error"`
+	RunCount         int64  `json:"run_count"`
+	FailureCount     int64  `json:"failure_count"`
+	LeaseOwner       string `json:"lease_owner"`
+}
+
+// JobsResponse lists the background jobs; Healthy is false when any job failed its last run or is overdue.
+type JobsResponse struct {
+	Healthy bool          `json:"healthy"`
+	Jobs    []JobResponse `json:"jobs"`
+}
+
+// ListJobs reports every registered job and whether all of them are healthy.
+func (handlers *JobHandlers) ListJobs(context *gin.Context) {
+	if !handlers.authorize(context) {
+		return
+	}
+	statuses, listErr := handlers.registry.Jobs(context.Request.Context())
+	if listErr != nil {
+		requestLogger(context, handlers.logger).Warn("list_jobs", zap.Error(listErr))
+		context.JSON(http.StatusInternalServerError, gin.H{jsonKeyError: errorValueQueryFailed})
+		return
+	}
+	response := JobsResponse{Healthy: true, Jobs: make([]JobResponse, 0, len(statuses))}
+	for _, status := range statuses {
+		response.Healthy = response.Healthy && status.Healthy
+		response.Jobs = append(response.Jobs, toJobResponse(status))
+	}
+	context.JSON(http.StatusOK, response)
+}
+
+// PauseJob stops scheduled runs of a job on every replica.
+func (handlers *JobHandlers) PauseJob(context *gin.Context) {
+	handlers.applyAction(context, "pause_job", http.StatusOK, handlers.registry.Pause)
+}
+
+// ResumeJob re-enables scheduled runs of a paused job.
+func (handlers *JobHandlers) ResumeJob(context *gin.Context) {
+	handlers.applyAction(context, "resume_job", http.StatusOK, handlers.registry.Resume)
+}
+
+// TriggerJob requests an immediate run of a job; the response is sent before the run starts.
+func (handlers *JobHandlers) TriggerJob(context *gin.Context) {
+	handlers.applyAction(context, "trigger_job", http.StatusAccepted, handlers.registry.Trigger)
+}
+
+func (handlers *JobHandlers) applyAction(context *gin.Context, logEvent string, successStatus int, action jobAction) {
+	if !handlers.authorize(context) {
+		return
+	}
+	currentUser, _ := CurrentUserFromContext(context)
+	name := context.Param(jobNameParam)
+	status, actionErr := action(context.Request.Context(), name)
+	if actionErr != nil {
+		if errors.Is(actionErr, task.ErrJobNotFound) {
+			context.JSON(http.StatusNotFound, gin.H{jsonKeyError: errorValueUnknownJob})
+			return
+		}
+		requestLogger(context, handlers.logger).Warn(logEvent, zap.String("job", name), zap.Error(actionErr))
+		context.JSON(http.StatusInternalServerError, gin.H{jsonKeyError: errorValueSaveFailed})
+		return
+	}
+	requestLogger(context, handlers.logger).Info(logEvent, zap.String("job", status.Name), zap.String("actor_email", currentUser.normalizedEmail()))
+	context.JSON(successStatus, toJobResponse(status))
+}
+
+func (handlers *JobHandlers) authorize(context *gin.Context) bool {
+	currentUser, ok := CurrentUserFromContext(context)
+	if !ok {
+		context.JSON(http.StatusUnauthorized, gin.H{jsonKeyError: authErrorUnauthorized})
+		return false
+	}
+	if !currentUser.hasRole(RoleAdmin) {
+		context.JSON(http.StatusForbidden, gin.H{jsonKeyError: authErrorForbidden})
+		return false
+	}
+	if handlers.registry == nil {
+		context.JSON(http.StatusServiceUnavailable, gin.H{jsonKeyError: errorValueJobsUnavailable})
+		return false
+	}
+	return true
+}
+
+func toJobResponse(status task.JobStatus) JobResponse {
+	return JobResponse{
+		Name:             status.Name,
+		Description:      status.Description,
+		Schedule:         status.Schedule,
+		Paused:           status.Paused,
+		Running:          status.Running,
+		Healthy:          status.Healthy,
+		TriggerRequested: status.TriggerRequested,
+		NextRunAt:        unixSecondsOrZero(status.NextRunAt),
+		LastStartedAt:    unixSecondsOrZero(status.LastStartedAt),
+		LastFinishedAt:   unixSecondsOrZero(status.LastFinishedAt),
+		LastDurationMs:   status.LastDuration.Milliseconds(),
+		LastError:        status.LastError,
+		RunCount:         status.RunCount,
+		FailureCount:     status.FailureCount,
+		LeaseOwner:       status.LeaseOwner,
+	}
+}
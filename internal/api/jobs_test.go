@@ -0,0 +1,102 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/MarkoPoloResearchLab/loopaware/internal/api"
+	"github.com/MarkoPoloResearchLab/loopaware/internal/task"
+)
+
+const testJobName = "nightly_cleanup"
+
+func newJobRouter(testingT *testing.T, registry *task.Registry, currentUser *api.CurrentUser) *gin.Engine {
+	testingT.Helper()
+	handlers := api.NewJobHandlers(registry, zap.NewNop())
+	router := newAuthenticatedRouter(currentUser)
+	router.GET("/api/admin/jobs", handlers.ListJobs)
+	router.POST("/api/admin/jobs/:name/pause", handlers.PauseJob)
+	router.POST("/api/admin/jobs/:name/resume", handlers.ResumeJob)
+	router.POST("/api/admin/jobs/:name/trigger", handlers.TriggerJob)
+	return router
+}
+
+func newTestJobRegistry(testingT *testing.T) *task.Registry {
+	testingT.Helper()
+	harness := newSiteTestHarness(testingT)
+	registry := task.NewRegistry(harness.database, zap.NewNop(), task.WithRegistryInstanceID("test-instance"))
+	require.NoError(testingT, registry.Register(task.Job{
+		Name:        testJobName,
+		Description: "Removes expired rows",
+		Schedule:    task.Every(time.Hour),
+		Run:         func(context.Context) error { return nil },
+	}))
+	return registry
+}
+
+func performJobRequest(router *gin.Engine, method string, path string) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(method, path, nil))
+	return recorder
+}
+
+func TestJobHandlersListAndControlJobs(testingT *testing.T) {
+	registry := newTestJobRegistry(testingT)
+	router := newJobRouter(testingT, registry, &api.CurrentUser{Email: testAdminEmailAddress, Role: api.RoleAdmin})
+
+	listRecorder := performJobRequest(router, http.MethodGet, "/api/admin/jobs")
+	require.Equal(testingT, http.StatusOK, listRecorder.Code)
+	var listed api.JobsResponse
+	require.NoError(testingT, json.Unmarshal(listRecorder.Body.Bytes(), &listed))
+	require.True(testingT, listed.Healthy)
+	require.Len(testingT, listed.Jobs, 1)
+	require.Equal(testingT, testJobName, listed.Jobs[0].Name)
+	require.Equal(testingT, "@every 1h0m0s", listed.Jobs[0].Schedule)
+	require.Equal(testingT, "Removes expired rows", listed.Jobs[0].Description)
+	require.NotZero(testingT, listed.Jobs[0].NextRunAt)
+
+	pauseRecorder := performJobRequest(router, http.MethodPost, "/api/admin/jobs/"+testJobName+"/pause")
+	require.Equal(testingT, http.StatusOK, pauseRecorder.Code)
+	var paused api.JobResponse
+	require.NoError(testingT, json.Unmarshal(pauseRecorder.Body.Bytes(), &paused))
+	require.True(testingT, paused.Paused)
+
+	triggerRecorder := performJobRequest(router, http.MethodPost, "/api/admin/jobs/"+testJobName+"/trigger")
+	require.Equal(testingT, http.StatusAccepted, triggerRecorder.Code)
+	var triggered api.JobResponse
+	require.NoError(testingT, json.Unmarshal(triggerRecorder.Body.Bytes(), &triggered))
+	require.True(testingT, triggered.TriggerRequested)
+
+	resumeRecorder := performJobRequest(router, http.MethodPost, "/api/admin/jobs/"+testJobName+"/resume")
+	require.Equal(testingT, http.StatusOK, resumeRecorder.Code)
+	var resumed api.JobResponse
+	require.NoError(testingT, json.Unmarshal(resumeRecorder.Body.Bytes(), &resumed))
+	require.False(testingT, resumed.Paused)
+}
+
+func TestJobHandlersRejectUnknownJobsAndNonAdmins(testingT *testing.T) {
+	registry := newTestJobRegistry(testingT)
+
+	adminRouter := newJobRouter(testingT, registry, &api.CurrentUser{Email: testAdminEmailAddress, Role: api.RoleAdmin})
+	unknownRecorder := performJobRequest(adminRouter, http.MethodPost, "/api/admin/jobs/missing/trigger")
+	require.Equal(testingT, http.StatusNotFound, unknownRecorder.Code)
+	require.JSONEq(testingT, `{"error":"unknown_job"}`, unknownRecorder.Body.String())
+
+	userRouter := newJobRouter(testingT, registry, &api.CurrentUser{Email: testUserEmailAddress, Role: api.RoleUser})
+	require.Equal(testingT, http.StatusForbidden, performJobRequest(userRouter, http.MethodGet, "/api/admin/jobs").Code)
+	require.Equal(testingT, http.StatusForbidden, performJobRequest(userRouter, http.MethodPost, "/api/admin/jobs/"+testJobName+"/pause").Code)
+
+	anonymousRouter := newJobRouter(testingT, registry, nil)
+	require.Equal(testingT, http.StatusUnauthorized, performJobRequest(anonymousRouter, http.MethodGet, "/api/admin/jobs").Code)
+
+	unavailableRouter := newJobRouter(testingT, nil, &api.CurrentUser{Email: testAdminEmailAddress, Role: api.RoleAdmin})
+	require.Equal(testingT, http.StatusServiceUnavailable, performJobRequest(unavailableRouter, http.MethodGet, "/api/admin/jobs").Code)
+}
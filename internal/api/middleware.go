@@ -1,23 +1,65 @@
 package api
 
 import (
+	"context"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
+
+	"github.com/MarkoPoloResearchLab/loopaware/internal/logging"
 )
 
-func RequestLogger(logger *zap.Logger) gin.HandlerFunc {
+// RequestObserver receives the method, matched route template, status, and latency of every request.
+type RequestObserver interface {
+	ObserveRequest(method string, route string, status int, duration time.Duration)
+}
+
+// RequestID reuses a well-formed X-Request-ID header or generates a new ID, echoes it on the response, and stores it
+// in the request context so handler logs carry it.
+func RequestID() gin.HandlerFunc {
+	return func(context *gin.Context) {
+		requestID := context.GetHeader(logging.HeaderRequestID)
+		if !logging.ValidRequestID(requestID) {
+			requestID = logging.NewRequestID()
+		}
+		context.Header(logging.HeaderRequestID, requestID)
+		context.Request = context.Request.WithContext(logging.ContextWithRequestID(context.Request.Context(), requestID))
+		context.Next()
+	}
+}
+
+// RequestLogger logs every request, with the request ID and the trace and span IDs when the request ID and tracing
+// middleware run before it, and reports it to observers.
+func RequestLogger(logger *zap.Logger, observers ...RequestObserver) gin.HandlerFunc {
 	return func(context *gin.Context) {
 		start := time.Now()
 		context.Next()
-		logger.Info("http",
+		duration := time.Since(start)
+		requestLogger(context, logger).Info("http",
 			zap.String("method", context.Request.Method),
 			zap.String("path", context.Request.URL.Path),
 			zap.Int("status", context.Writer.Status()),
-			zap.Duration("dur", time.Since(start)),
+			zap.Duration("dur", duration),
 			zap.String("ip", context.ClientIP()),
 			zap.String("ua", context.Request.UserAgent()),
 		)
+		for _, observer := range observers {
+			if observer != nil {
+				observer.ObserveRequest(context.Request.Method, context.FullPath(), context.Writer.Status(), duration)
+			}
+		}
+	}
+}
+
+// requestLogger annotates logger with the request ID and trace IDs of ctx. A *gin.Context is resolved to its request
+// context because gin does not fall back to it for values.
+func requestLogger(ctx context.Context, logger *zap.Logger) *zap.Logger {
+	if ginContext, ok := ctx.(*gin.Context); ok {
+		if ginContext == nil || ginContext.Request == nil {
+			return logger
+		}
+		ctx = ginContext.Request.Context()
 	}
+	return logging.WithContext(ctx, logger)
 }
@@ -0,0 +1,153 @@
+package api_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/MarkoPoloResearchLab/loopaware/internal/api"
+	"github.com/MarkoPoloResearchLab/loopaware/internal/logging"
+)
+
+func TestRequestLoggerAddsTraceIDsFromTracingMiddleware(testingT *testing.T) {
+	spanRecorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spanRecorder))
+	core, logs := observer.New(zapcore.InfoLevel)
+
+	router := gin.New()
+	router.Use(otelgin.Middleware("loopaware-test", otelgin.WithTracerProvider(provider)))
+	router.Use(api.RequestLogger(zap.New(core)))
+	router.GET("/sites/:id", func(context *gin.Context) {
+		context.Status(http.StatusNoContent)
+	})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/sites/site-1", nil))
+
+	spans := spanRecorder.Ended()
+	require.Len(testingT, spans, 1)
+	require.Equal(testingT, "GET /sites/:id", spans[0].Name())
+	entries := logs.FilterMessage("http").All()
+	require.Len(testingT, entries, 1)
+	fields := entries[0].ContextMap()
+	require.Equal(testingT, spans[0].SpanContext().TraceID().String(), fields["trace_id"])
+	require.Equal(testingT, spans[0].SpanContext().SpanID().String(), fields["span_id"])
+}
+
+func TestRequestLoggerOmitsTraceIDsWithoutTracing(testingT *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	router := gin.New()
+	router.Use(api.RequestLogger(zap.New(core)))
+	router.GET("/healthz", func(context *gin.Context) {
+		context.Status(http.StatusOK)
+	})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	entries := logs.FilterMessage("http").All()
+	require.Len(testingT, entries, 1)
+	require.NotContains(testingT, entries[0].ContextMap(), "trace_id")
+}
+
+func TestRequestIDPropagatesOrGeneratesIDAndTagsRequestLog(testingT *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	router := gin.New()
+	router.Use(api.RequestID())
+	router.Use(api.RequestLogger(zap.New(core)))
+	var handlerRequestID string
+	router.GET("/feedback", func(context *gin.Context) {
+		handlerRequestID = logging.RequestIDFromContext(context.Request.Context())
+		context.Status(http.StatusNoContent)
+	})
+
+	propagatedRequest := httptest.NewRequest(http.MethodGet, "/feedback", nil)
+	propagatedRequest.Header.Set(logging.HeaderRequestID, "edge-req-42")
+	propagatedRecorder := httptest.NewRecorder()
+	router.ServeHTTP(propagatedRecorder, propagatedRequest)
+	require.Equal(testingT, "edge-req-42", propagatedRecorder.Header().Get(logging.HeaderRequestID))
+	require.Equal(testingT, "edge-req-42", handlerRequestID)
+
+	forgedRequest := httptest.NewRequest(http.MethodGet, "/feedback", nil)
+	forgedRequest.Header.Set(logging.HeaderRequestID, "forged id\" level=error")
+	forgedRecorder := httptest.NewRecorder()
+	router.ServeHTTP(forgedRecorder, forgedRequest)
+	generatedRequestID := forgedRecorder.Header().Get(logging.HeaderRequestID)
+	require.True(testingT, logging.ValidRequestID(generatedRequestID))
+	require.Equal(testingT, generatedRequestID, handlerRequestID)
+
+	entries := logs.FilterMessage("http").All()
+	require.Len(testingT, entries, 2)
+	require.Equal(testingT, "edge-req-42", entries[0].ContextMap()["request_id"])
+	require.Equal(testingT, generatedRequestID, entries[1].ContextMap()["request_id"])
+}
+
+func TestWithoutConnectionDeadlinesKeepsStreamsOpenPastWriteTimeout(testingT *testing.T) {
+	streamHandler := func(context *gin.Context) {
+		context.Writer.WriteString("first\n")
+		context.Writer.Flush()
+		time.Sleep(150 * time.Millisecond)
+		context.Writer.WriteString("second\n")
+		context.Writer.Flush()
+	}
+	router := gin.New()
+	router.GET("/exempt", api.WithoutConnectionDeadlines(), streamHandler)
+	router.GET("/bounded", streamHandler)
+
+	server := httptest.NewUnstartedServer(router)
+	server.Config.WriteTimeout = 50 * time.Millisecond
+	server.Start()
+	defer server.Close()
+
+	readBody := func(path string) string {
+		response, requestErr := http.Get(server.URL + path)
+		require.NoError(testingT, requestErr)
+		defer response.Body.Close()
+		body, _ := io.ReadAll(response.Body)
+		return string(body)
+	}
+	require.Equal(testingT, "first\nsecond\n", readBody("/exempt"))
+	require.NotContains(testingT, readBody("/bounded"), "second")
+}
+
+func TestWithRollingWriteDeadlineExtendsDeadlinePerChunk(testingT *testing.T) {
+	chunkedHandler := func(context *gin.Context) {
+		for chunk := 0; chunk < 4; chunk++ {
+			time.Sleep(40 * time.Millisecond)
+			context.Writer.WriteString("chunk\n")
+			context.Writer.Flush()
+		}
+	}
+	router := gin.New()
+	router.GET("/rolling", api.WithRollingWriteDeadline(100*time.Millisecond), chunkedHandler)
+	router.GET("/unbounded", api.WithRollingWriteDeadline(0), chunkedHandler)
+	router.GET("/bounded", chunkedHandler)
+
+	server := httptest.NewUnstartedServer(router)
+	server.Config.WriteTimeout = 60 * time.Millisecond
+	server.Start()
+	defer server.Close()
+
+	readBody := func(path string) string {
+		response, requestErr := http.Get(server.URL + path)
+		if requestErr != nil {
+			return ""
+		}
+		defer response.Body.Close()
+		body, _ := io.ReadAll(response.Body)
+		return string(body)
+	}
+	require.Equal(testingT, strings.Repeat("chunk\n", 4), readBody("/rolling"))
+	require.Equal(testingT, strings.Repeat("chunk\n", 4), readBody("/unbounded"))
+	require.NotEqual(testingT, strings.Repeat("chunk\n", 4), readBody("/bounded"))
+}
@@ -0,0 +1,242 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"github.com/MarkoPoloResearchLab/loopaware/internal/emailtemplate"
+	"github.com/MarkoPoloResearchLab/loopaware/internal/model"
+)
+
+const (
+	// PendingSubscriberExpiryArchive marks stale pending subscribers as expired.
+	PendingSubscriberExpiryArchive = "archive"
+	// PendingSubscriberExpiryDelete removes stale pending subscribers.
+	PendingSubscriberExpiryDelete = "delete"
+)
+
+const (
+	defaultPendingSubscriberReminderAfter = 24 * time.Hour
+	defaultPendingSubscriberExpiryAfter   = 7 * 24 * time.Hour
+	pendingSubscriberSweepBatchSize       = 100
+)
+
+var (
+	errPendingConfirmationUnavailable = errors.New("confirmation email unavailable")
+	errPendingConfirmationFailed      = errors.New("confirmation email failed")
+)
+
+type PendingSubscriberSweeperOption func(*PendingSubscriberSweeper)
+
+// PendingSubscriberSweeperResult reports the work done by a single sweep.
+type PendingSubscriberSweeperResult struct {
+	Reminded int
+	Expired  int64
+}
+
+// PendingSubscriberSweeper reminds pending subscribers before their confirmation link expires and retires stale ones.
+type PendingSubscriberSweeper struct {
+	database       *gorm.DB
+	logger         *zap.Logger
+	emailSender    EmailSender
+	emailTemplates *emailtemplate.Renderer
+	publicBaseURL  string
+	tokenSecret    string
+	tokenTTL       time.Duration
+	reminderAfter  time.Duration
+	expiryAfter    time.Duration
+	expiryAction   string
+	now            func() time.Time
+}
+
+// NewPendingSubscriberSweeper constructs a sweeper that sends reminders through emailSender.
+func NewPendingSubscriberSweeper(database *gorm.DB, logger *zap.Logger, emailSender EmailSender, publicBaseURL string, tokenSecret string, options ...PendingSubscriberSweeperOption) *PendingSubscriberSweeper {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	sweeper := &PendingSubscriberSweeper{
+		database:      database,
+		logger:        logger,
+		emailSender:   emailSender,
+		publicBaseURL: strings.TrimRight(strings.TrimSpace(publicBaseURL), "/"),
+		tokenSecret:   strings.TrimSpace(tokenSecret),
+		tokenTTL:      defaultSubscriptionConfirmationTokenTTL,
+		reminderAfter: defaultPendingSubscriberReminderAfter,
+		expiryAfter:   defaultPendingSubscriberExpiryAfter,
+		expiryAction:  PendingSubscriberExpiryArchive,
+		now:           time.Now,
+	}
+	for _, option := range options {
+		if option != nil {
+			option(sweeper)
+		}
+	}
+	return sweeper
+}
+
+// WithPendingSubscriberTemplates renders reminder emails through the site template renderer.
+func WithPendingSubscriberTemplates(renderer *emailtemplate.Renderer) PendingSubscriberSweeperOption {
+	return func(sweeper *PendingSubscriberSweeper) {
+		sweeper.emailTemplates = renderer
+	}
+}
+
+// WithPendingSubscriberReminderAfter sets how long after the confirmation email a reminder is sent; zero disables reminders.
+func WithPendingSubscriberReminderAfter(reminderAfter time.Duration) PendingSubscriberSweeperOption {
+	return func(sweeper *PendingSubscriberSweeper) {
+		if reminderAfter >= 0 {
+			sweeper.reminderAfter = reminderAfter
+		}
+	}
+}
+
+// WithPendingSubscriberExpiryAfter sets how long a subscriber may stay pending without activity; zero disables expiry.
+func WithPendingSubscriberExpiryAfter(expiryAfter time.Duration) PendingSubscriberSweeperOption {
+	return func(sweeper *PendingSubscriberSweeper) {
+		if expiryAfter >= 0 {
+			sweeper.expiryAfter = expiryAfter
+		}
+	}
+}
+
+// WithPendingSubscriberExpiryAction selects whether stale pending subscribers are archived or deleted.
+func WithPendingSubscriberExpiryAction(action string) PendingSubscriberSweeperOption {
+	return func(sweeper *PendingSubscriberSweeper) {
+		normalizedAction := strings.ToLower(strings.TrimSpace(action))
+		if normalizedAction == PendingSubscriberExpiryArchive || normalizedAction == PendingSubscriberExpiryDelete {
+			sweeper.expiryAction = normalizedAction
+		}
+	}
+}
+
+// WithPendingSubscriberClock overrides the sweeper clock.
+func WithPendingSubscriberClock(clock func() time.Time) PendingSubscriberSweeperOption {
+	return func(sweeper *PendingSubscriberSweeper) {
+		if clock != nil {
+			sweeper.now = clock
+		}
+	}
+}
+
+// Sweep sends due reminders and then archives or deletes pending subscribers past the expiry window.
+func (sweeper *PendingSubscriberSweeper) Sweep(ctx context.Context) (PendingSubscriberSweeperResult, error) {
+	var result PendingSubscriberSweeperResult
+	reminded, remindErr := sweeper.sendReminders(ctx)
+	result.Reminded = reminded
+	if remindErr != nil {
+		return result, remindErr
+	}
+	expired, expireErr := sweeper.expireStale(ctx)
+	result.Expired = expired
+	if expireErr == nil && (result.Reminded > 0 || result.Expired > 0) {
+		sweeper.logger.Info("pending_subscriber_sweep", zap.Int("reminded", result.Reminded), zap.Int64("expired", result.Expired), zap.String("action", sweeper.expiryAction))
+	}
+	return result, expireErr
+}
+
+// ResendConfirmation sends a fresh confirmation email to a pending subscriber.
+func (sweeper *PendingSubscriberSweeper) ResendConfirmation(ctx context.Context, site model.Site, subscriber model.Subscriber) (time.Time, error) {
+	if !sweeper.confirmationAvailable() {
+		return time.Time{}, errPendingConfirmationUnavailable
+	}
+	if !sendSubscriptionConfirmationEmail(ctx, sweeper.logger, nil, sweeper.emailSender, sweeper.emailTemplates, sweeper.publicBaseURL, sweeper.tokenSecret, sweeper.tokenTTL, site, subscriber) {
+		return time.Time{}, errPendingConfirmationFailed
+	}
+	sentAt := sweeper.now().UTC()
+	markSubscriptionConfirmationSent(ctx, sweeper.database, sweeper.logger, subscriber.ID, sentAt)
+	return sentAt, nil
+}
+
+func (sweeper *PendingSubscriberSweeper) confirmationAvailable() bool {
+	return sweeper != nil && sweeper.emailSender != nil && sweeper.publicBaseURL != "" && sweeper.tokenSecret != ""
+}
+
+func (sweeper *PendingSubscriberSweeper) sendReminders(ctx context.Context) (int, error) {
+	if sweeper.reminderAfter <= 0 || sweeper.reminderAfter >= sweeper.tokenTTL || !sweeper.confirmationAvailable() {
+		return 0, nil
+	}
+
+	now := sweeper.now().UTC()
+	reminderCutoff := now.Add(-sweeper.reminderAfter)
+	tokenExpiryCutoff := now.Add(-sweeper.tokenTTL)
+	sitesByID := make(map[string]model.Site)
+	reminded := 0
+	lastSubscriberID := ""
+	for {
+		var subscribers []model.Subscriber
+		findErr := sweeper.database.WithContext(ctx).
+			Where("status = ? AND reminder_sent_at = ? AND confirmation_sent_at > ? AND confirmation_sent_at <= ? AND id > ?", model.SubscriberStatusPending, time.Time{}, tokenExpiryCutoff, reminderCutoff, lastSubscriberID).
+			Order("id asc").
+			Limit(pendingSubscriberSweepBatchSize).
+			Find(&subscribers).Error
+		if findErr != nil {
+			return reminded, fmt.Errorf("load pending subscribers: %w", findErr)
+		}
+		if len(subscribers) == 0 {
+			return reminded, nil
+		}
+
+		for _, subscriber := range subscribers {
+			lastSubscriberID = subscriber.ID
+			if ctx.Err() != nil {
+				return reminded, ctx.Err()
+			}
+			site, siteErr := sweeper.loadSite(ctx, sitesByID, subscriber.SiteID)
+			if siteErr != nil {
+				sweeper.logger.Warn("pending_subscriber_site_missing", zap.Error(siteErr), zap.String("subscriber_id", subscriber.ID))
+				continue
+			}
+			if !sendSubscriptionConfirmationEmail(ctx, sweeper.logger, nil, sweeper.emailSender, sweeper.emailTemplates, sweeper.publicBaseURL, sweeper.tokenSecret, sweeper.tokenTTL, site, subscriber) {
+				continue
+			}
+			updateErr := sweeper.database.WithContext(context.WithoutCancel(ctx)).
+				Model(&model.Subscriber{}).
+				Where("id = ? AND status = ?", subscriber.ID, model.SubscriberStatusPending).
+				Update("reminder_sent_at", sweeper.now().UTC()).Error
+			if updateErr != nil {
+				sweeper.logger.Warn("pending_subscriber_reminder_mark_failed", zap.Error(updateErr), zap.String("subscriber_id", subscriber.ID))
+				continue
+			}
+			reminded++
+		}
+	}
+}
+
+func (sweeper *PendingSubscriberSweeper) expireStale(ctx context.Context) (int64, error) {
+	if sweeper.expiryAfter <= 0 {
+		return 0, nil
+	}
+
+	cutoff := sweeper.now().UTC().Add(-sweeper.expiryAfter)
+	staleQuery := sweeper.database.WithContext(ctx).
+		Where("status = ? AND created_at <= ? AND confirmation_sent_at <= ? AND reminder_sent_at <= ?", model.SubscriberStatusPending, cutoff, cutoff, cutoff)
+
+	var expireResult *gorm.DB
+	if sweeper.expiryAction == PendingSubscriberExpiryDelete {
+		expireResult = staleQuery.Delete(&model.Subscriber{})
+	} else {
+		expireResult = staleQuery.Model(&model.Subscriber{}).Update("status", model.SubscriberStatusExpired)
+	}
+	if expireResult.Error != nil {
+		return 0, fmt.Errorf("expire pending subscribers: %w", expireResult.Error)
+	}
+	return expireResult.RowsAffected, nil
+}
+
+func (sweeper *PendingSubscriberSweeper) loadSite(ctx context.Context, sitesByID map[string]model.Site, siteID string) (model.Site, error) {
+	if site, ok := sitesByID[siteID]; ok {
+		return site, nil
+	}
+	var site model.Site
+	if err := sweeper.database.WithContext(ctx).First(&site, "id = ?", siteID).Error; err != nil {
+		return model.Site{}, err
+	}
+	sitesByID[siteID] = site
+	return site, nil
+}
@@ -0,0 +1,89 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"github.com/MarkoPoloResearchLab/loopaware/internal/model"
+)
+
+const (
+	errorValueSubscriberNotPending      = "subscriber_not_pending"
+	errorValueConfirmationUnavailable   = "confirmation_unavailable"
+	errorValueConfirmationFailed        = "confirmation_failed"
+	errorValueConfirmationResendTooSoon = "resend_too_soon"
+
+	pendingSubscriberResendCooldown = time.Minute
+)
+
+// PendingSubscriberHandlers lets site owners manage subscribers that have not confirmed yet.
+type PendingSubscriberHandlers struct {
+	database *gorm.DB
+	logger   *zap.Logger
+	sweeper  *PendingSubscriberSweeper
+	now      func() time.Time
+}
+
+// NewPendingSubscriberHandlers constructs handlers that resend confirmations through sweeper.
+func NewPendingSubscriberHandlers(database *gorm.DB, logger *zap.Logger, sweeper *PendingSubscriberSweeper) *PendingSubscriberHandlers {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &PendingSubscriberHandlers{
+		database: database,
+		logger:   logger,
+		sweeper:  sweeper,
+		now:      time.Now,
+	}
+}
+
+// ResendSubscriberConfirmation emails a fresh confirmation link to a pending subscriber.
+func (handlers *PendingSubscriberHandlers) ResendSubscriberConfirmation(context *gin.Context) {
+	site, _, ok := resolveManageableSite(context, handlers.database)
+	if !ok {
+		return
+	}
+
+	subscriberID := strings.TrimSpace(context.Param("subscriber_id"))
+	if subscriberID == "" {
+		context.JSON(http.StatusBadRequest, gin.H{jsonKeyError: errorValueMissingFields})
+		return
+	}
+
+	var subscriber model.Subscriber
+	if err := handlers.database.Where("id = ? AND site_id = ?", subscriberID, site.ID).First(&subscriber).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			context.JSON(http.StatusNotFound, gin.H{jsonKeyError: errorValueUnknownSubscription})
+			return
+		}
+		context.JSON(http.StatusInternalServerError, gin.H{jsonKeyError: errorValueQueryFailed})
+		return
+	}
+	if subscriber.Status != model.SubscriberStatusPending {
+		context.JSON(http.StatusConflict, gin.H{jsonKeyError: errorValueSubscriberNotPending})
+		return
+	}
+	if !subscriber.ConfirmationSentAt.IsZero() && handlers.now().Sub(subscriber.ConfirmationSentAt) < pendingSubscriberResendCooldown {
+		context.JSON(http.StatusTooManyRequests, gin.H{jsonKeyError: errorValueConfirmationResendTooSoon})
+		return
+	}
+
+	sentAt, resendErr := handlers.sweeper.ResendConfirmation(context.Request.Context(), site, subscriber)
+	if resendErr != nil {
+		if errors.Is(resendErr, errPendingConfirmationUnavailable) {
+			context.JSON(http.StatusServiceUnavailable, gin.H{jsonKeyError: errorValueConfirmationUnavailable})
+			return
+		}
+		requestLogger(context, handlers.logger).Warn("subscriber_confirmation_resend_failed", zap.Error(resendErr), zap.String("site_id", site.ID), zap.String("subscriber_id", subscriber.ID))
+		context.JSON(http.StatusBadGateway, gin.H{jsonKeyError: errorValueConfirmationFailed})
+		return
+	}
+
+	context.JSON(http.StatusOK, gin.H{"status": "ok", "confirmation_sent_at": sentAt.Unix()})
+}
@@ -0,0 +1,189 @@
+package api_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"github.com/MarkoPoloResearchLab/loopaware/internal/api"
+	"github.com/MarkoPoloResearchLab/loopaware/internal/model"
+)
+
+const (
+	testPendingBaseURL     = "https://loopaware.example.com"
+	testPendingTokenSecret = "pending-secret"
+)
+
+type pendingSubscriberHarness struct {
+	database *gorm.DB
+	site     model.Site
+	sender   *recordingEmailSender
+	now      time.Time
+}
+
+func newPendingSubscriberHarness(testingT *testing.T) *pendingSubscriberHarness {
+	testingT.Helper()
+
+	database := newSiteTestHarness(testingT).database
+
+	return &pendingSubscriberHarness{
+		database: database,
+		site:     insertSite(testingT, database, "Pending Site", "http://example.com", testAdminEmailAddress),
+		sender:   &recordingEmailSender{testingT: testingT},
+		now:      time.Date(2026, time.March, 10, 12, 0, 0, 0, time.UTC),
+	}
+}
+
+func (harness *pendingSubscriberHarness) sweeper(options ...api.PendingSubscriberSweeperOption) *api.PendingSubscriberSweeper {
+	options = append(options, api.WithPendingSubscriberClock(func() time.Time { return harness.now }))
+	return api.NewPendingSubscriberSweeper(harness.database, zap.NewNop(), harness.sender, testPendingBaseURL, testPendingTokenSecret, options...)
+}
+
+func (harness *pendingSubscriberHarness) createSubscriber(testingT *testing.T, email string, status string, age time.Duration, confirmationSentAge time.Duration) model.Subscriber {
+	testingT.Helper()
+	subscriber, subscriberErr := model.NewSubscriber(model.SubscriberInput{SiteID: harness.site.ID, Email: email, Status: status})
+	require.NoError(testingT, subscriberErr)
+	require.NoError(testingT, harness.database.Create(&subscriber).Error)
+	updates := map[string]any{"created_at": harness.now.Add(-age)}
+	if confirmationSentAge > 0 {
+		updates["confirmation_sent_at"] = harness.now.Add(-confirmationSentAge)
+	}
+	require.NoError(testingT, harness.database.Model(&model.Subscriber{}).Where("id = ?", subscriber.ID).UpdateColumns(updates).Error)
+	return subscriber
+}
+
+func (harness *pendingSubscriberHarness) reload(testingT *testing.T, subscriberID string) model.Subscriber {
+	testingT.Helper()
+	var subscriber model.Subscriber
+	require.NoError(testingT, harness.database.First(&subscriber, "id = ?", subscriberID).Error)
+	return subscriber
+}
+
+func TestPendingSubscriberSweeperSendsOneReminderBeforeExpiry(testingT *testing.T) {
+	harness := newPendingSubscriberHarness(testingT)
+	dueSubscriber := harness.createSubscriber(testingT, "due@example.com", model.SubscriberStatusPending, 25*time.Hour, 25*time.Hour)
+	harness.createSubscriber(testingT, "fresh@example.com", model.SubscriberStatusPending, time.Hour, time.Hour)
+	harness.createSubscriber(testingT, "lapsed@example.com", model.SubscriberStatusPending, 50*time.Hour, 50*time.Hour)
+	harness.createSubscriber(testingT, "confirmed@example.com", model.SubscriberStatusConfirmed, 25*time.Hour, 25*time.Hour)
+
+	sweeper := harness.sweeper()
+	result, sweepErr := sweeper.Sweep(context.Background())
+	require.NoError(testingT, sweepErr)
+	require.Equal(testingT, 1, result.Reminded)
+	require.Equal(testingT, 1, harness.sender.CallCount())
+	require.Equal(testingT, "due@example.com", harness.sender.LastCall().Recipient)
+	require.Contains(testingT, harness.sender.LastCall().Message, testPendingBaseURL+"/subscriptions/confirm?token=")
+	require.Equal(testingT, harness.now, harness.reload(testingT, dueSubscriber.ID).ReminderSentAt.UTC())
+
+	harness.now = harness.now.Add(2 * time.Hour)
+	secondResult, secondErr := sweeper.Sweep(context.Background())
+	require.NoError(testingT, secondErr)
+	require.Zero(testingT, secondResult.Reminded)
+	require.Equal(testingT, 1, harness.sender.CallCount())
+}
+
+func TestPendingSubscriberSweeperExpiresStaleSubscribers(testingT *testing.T) {
+	testCases := []struct {
+		name   string
+		action string
+	}{
+		{name: "archive", action: api.PendingSubscriberExpiryArchive},
+		{name: "delete", action: api.PendingSubscriberExpiryDelete},
+	}
+
+	for _, testCase := range testCases {
+		testingT.Run(testCase.name, func(testingT *testing.T) {
+			harness := newPendingSubscriberHarness(testingT)
+			staleSubscriber := harness.createSubscriber(testingT, "stale@example.com", model.SubscriberStatusPending, 8*24*time.Hour, 8*24*time.Hour)
+			recentlyResent := harness.createSubscriber(testingT, "resent@example.com", model.SubscriberStatusPending, 8*24*time.Hour, 2*24*time.Hour)
+			confirmedSubscriber := harness.createSubscriber(testingT, "confirmed@example.com", model.SubscriberStatusConfirmed, 30*24*time.Hour, 0)
+
+			sweeper := harness.sweeper(api.WithPendingSubscriberExpiryAfter(7*24*time.Hour), api.WithPendingSubscriberExpiryAction(testCase.action))
+			result, sweepErr := sweeper.Sweep(context.Background())
+			require.NoError(testingT, sweepErr)
+			require.Equal(testingT, int64(1), result.Expired)
+
+			if testCase.action == api.PendingSubscriberExpiryArchive {
+				require.Equal(testingT, model.SubscriberStatusExpired, harness.reload(testingT, staleSubscriber.ID).Status)
+			} else {
+				var remaining int64
+				require.NoError(testingT, harness.database.Model(&model.Subscriber{}).Where("id = ?", staleSubscriber.ID).Count(&remaining).Error)
+				require.Zero(testingT, remaining)
+			}
+			require.Equal(testingT, model.SubscriberStatusPending, harness.reload(testingT, recentlyResent.ID).Status)
+			require.Equal(testingT, model.SubscriberStatusConfirmed, harness.reload(testingT, confirmedSubscriber.ID).Status)
+		})
+	}
+}
+
+func TestExpiredSubscriberCanSubscribeAgain(testingT *testing.T) {
+	harness := newPendingSubscriberHarness(testingT)
+	expiredSubscriber := harness.createSubscriber(testingT, "again@example.com", model.SubscriberStatusExpired, 10*24*time.Hour, 10*24*time.Hour)
+
+	publicHandlers := api.NewPublicHandlers(harness.database, zap.NewNop(), nil, nil, nil, nil, false, testPendingBaseURL, testPendingTokenSecret, harness.sender)
+	router := gin.New()
+	router.POST("/public/subscriptions", publicHandlers.CreateSubscription)
+
+	recorder := performJSONRequest(testingT, router, http.MethodPost, "/public/subscriptions", map[string]any{
+		"site_id": harness.site.ID,
+		"email":   "again@example.com",
+	}, map[string]string{"Origin": "http://example.com"})
+	require.Equal(testingT, http.StatusOK, recorder.Code, recorder.Body.String())
+
+	resubscribed := harness.reload(testingT, expiredSubscriber.ID)
+	require.Equal(testingT, model.SubscriberStatusPending, resubscribed.Status)
+	require.False(testingT, resubscribed.ConfirmationSentAt.IsZero())
+	require.Equal(testingT, 1, harness.sender.CallCount())
+}
+
+func TestResendSubscriberConfirmation(testingT *testing.T) {
+	harness := newPendingSubscriberHarness(testingT)
+	pendingSubscriber := harness.createSubscriber(testingT, "pending@example.com", model.SubscriberStatusPending, 2*time.Hour, 2*time.Hour)
+	confirmedSubscriber := harness.createSubscriber(testingT, "confirmed@example.com", model.SubscriberStatusConfirmed, 2*time.Hour, 0)
+
+	newRouter := func(sweeper *api.PendingSubscriberSweeper, currentUser *api.CurrentUser) *gin.Engine {
+		handlers := api.NewPendingSubscriberHandlers(harness.database, zap.NewNop(), sweeper)
+		router := newAuthenticatedRouter(currentUser)
+		router.POST("/api/sites/:id/subscribers/:subscriber_id/resend-confirmation", handlers.ResendSubscriberConfirmation)
+		return router
+	}
+	owner := &api.CurrentUser{Email: testAdminEmailAddress, Role: api.RoleUser}
+	router := newRouter(harness.sweeper(), owner)
+	resendPath := func(subscriberID string) string {
+		return fmt.Sprintf("/api/sites/%s/subscribers/%s/resend-confirmation", harness.site.ID, subscriberID)
+	}
+
+	recorder := performJSONRequest(testingT, router, http.MethodPost, resendPath(pendingSubscriber.ID), nil, nil)
+	require.Equal(testingT, http.StatusOK, recorder.Code, recorder.Body.String())
+	require.Equal(testingT, 1, harness.sender.CallCount())
+	require.Equal(testingT, "pending@example.com", harness.sender.LastCall().Recipient)
+	require.Equal(testingT, harness.now, harness.reload(testingT, pendingSubscriber.ID).ConfirmationSentAt.UTC())
+
+	harness.now = time.Now().UTC()
+	require.NoError(testingT, harness.database.Model(&model.Subscriber{}).Where("id = ?", pendingSubscriber.ID).UpdateColumn("confirmation_sent_at", harness.now).Error)
+	tooSoonRecorder := performJSONRequest(testingT, router, http.MethodPost, resendPath(pendingSubscriber.ID), nil, nil)
+	require.Equal(testingT, http.StatusTooManyRequests, tooSoonRecorder.Code)
+
+	confirmedRecorder := performJSONRequest(testingT, router, http.MethodPost, resendPath(confirmedSubscriber.ID), nil, nil)
+	require.Equal(testingT, http.StatusConflict, confirmedRecorder.Code)
+
+	unknownRecorder := performJSONRequest(testingT, router, http.MethodPost, resendPath("missing"), nil, nil)
+	require.Equal(testingT, http.StatusNotFound, unknownRecorder.Code)
+
+	strangerRouter := newRouter(harness.sweeper(), &api.CurrentUser{Email: testUserEmailAddress, Role: api.RoleUser})
+	strangerRecorder := performJSONRequest(testingT, strangerRouter, http.MethodPost, resendPath(pendingSubscriber.ID), nil, nil)
+	require.Equal(testingT, http.StatusForbidden, strangerRecorder.Code)
+
+	unavailableSweeper := api.NewPendingSubscriberSweeper(harness.database, zap.NewNop(), nil, testPendingBaseURL, testPendingTokenSecret)
+	require.NoError(testingT, harness.database.Model(&model.Subscriber{}).Where("id = ?", pendingSubscriber.ID).UpdateColumn("confirmation_sent_at", time.Time{}).Error)
+	unavailableRecorder := performJSONRequest(testingT, newRouter(unavailableSweeper, owner), http.MethodPost, resendPath(pendingSubscriber.ID), nil, nil)
+	require.Equal(testingT, http.StatusServiceUnavailable, unavailableRecorder.Code)
+	require.Equal(testingT, 1, harness.sender.CallCount())
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -14,8 +15,10 @@ import (
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 
+	"github.com/MarkoPoloResearchLab/loopaware/internal/blobstore"
+	"github.com/MarkoPoloResearchLab/loopaware/internal/emailtemplate"
+	"github.com/MarkoPoloResearchLab/loopaware/internal/metrics"
 	"github.com/MarkoPoloResearchLab/loopaware/internal/model"
-	"github.com/MarkoPoloResearchLab/loopaware/internal/storage"
 )
 
 // PublicHandlers serves unauthenticated public API endpoints.
@@ -35,6 +38,11 @@ type PublicHandlers struct {
 	subscriptionTokenSecret   string
 	subscriptionTokenTTL      time.Duration
 	confirmationEmailSender   EmailSender
+	emailTemplates            *emailtemplate.Renderer
+	attachmentStore           blobstore.Store
+	attachmentMaxBytes        int64
+	visitorPresence           *VisitorPresenceTracker
+	metrics                   *metrics.Metrics
 }
 
 const (
@@ -45,6 +53,7 @@ const (
 	errorValueUnknownSubscription  = "unknown_subscription"
 	errorValueDuplicateSubscriber  = "duplicate_subscription"
 	errorValueUnsubscribedAccount  = "unsubscribed"
+	errorValueExpiredSubscription  = "subscription_expired"
 	errorValueSaveSubscriberFailed = "save_failed"
 	errorValueInvalidSite          = "unknown_site"
 	errorValueInvalidVisitorID     = "invalid_visitor"
@@ -61,6 +70,8 @@ const (
 	subscriptionEventStatusSkipped    = "skipped"
 
 	defaultSubscriptionConfirmationTokenTTL = 48 * time.Hour
+
+	headerAcceptLanguage = "Accept-Language"
 )
 
 // NewPublicHandlers constructs a PublicHandlers instance with the provided dependencies.
@@ -82,13 +93,37 @@ func NewPublicHandlers(database *gorm.DB, logger *zap.Logger, feedbackBroadcaste
 		subscriptionTokenSecret:   normalizedTokenSecret,
 		subscriptionTokenTTL:      defaultSubscriptionConfirmationTokenTTL,
 		confirmationEmailSender:   confirmationEmailSender,
+		attachmentMaxBytes:        DefaultFeedbackAttachmentMaxBytes,
 	}
 }
 
+// WithEmailTemplates renders confirmation emails through the provided site template renderer.
+func (h *PublicHandlers) WithEmailTemplates(renderer *emailtemplate.Renderer) *PublicHandlers {
+	h.emailTemplates = renderer
+	return h
+}
+
+// WithMetrics counts accepted and rejected feedback, subscription, and visit submissions.
+func (h *PublicHandlers) WithMetrics(instance *metrics.Metrics) *PublicHandlers {
+	h.metrics = instance
+	return h
+}
+
+// rejectSubmission counts a refused submission under its error code and responds with that code.
+func (h *PublicHandlers) rejectSubmission(context *gin.Context, kind string, status int, reason string) {
+	h.metrics.SubmissionRejected(kind, reason)
+	context.JSON(status, gin.H{"error": reason})
+}
+
 type createFeedbackRequest struct {
 	SiteID      string `json:"site_id"`
 	ContactInfo string `json:"contact"`
 	MessageBody string `json:"message"`
+	ExtraField  string `json:"extra_field"`
+	Rating      *int   `json:"rating"`
+	NPSScore    *int   `json:"nps_score"`
+	Category    string `json:"category"`
+	PageURL     string `json:"page_url"`
 }
 
 type createSubscriptionRequest struct {
@@ -96,6 +131,7 @@ type createSubscriptionRequest struct {
 	Email     string `json:"email"`
 	Name      string `json:"name"`
 	SourceURL string `json:"source_url"`
+	Locale    string `json:"locale"`
 }
 
 type subscriptionMutationRequest struct {
@@ -104,9 +140,17 @@ type subscriptionMutationRequest struct {
 }
 
 type widgetConfigResponse struct {
-	SiteID                   string `json:"site_id"`
-	WidgetBubbleSide         string `json:"widget_bubble_side"`
-	WidgetBubbleBottomOffset int    `json:"widget_bubble_bottom_offset"`
+	SiteID                   string                  `json:"site_id"`
+	WidgetBubbleSide         string                  `json:"widget_bubble_side"`
+	WidgetBubbleBottomOffset int                     `json:"widget_bubble_bottom_offset"`
+	BrandColor               string                  `json:"brand_color,omitempty"`
+	Theme                    string                  `json:"theme"`
+	ContactMode              string                  `json:"contact_mode"`
+	ExtraField               *model.WidgetExtraField `json:"extra_field,omitempty"`
+	AllowAttachments         bool                    `json:"allow_attachments"`
+	AttachmentMaxBytes       int64                   `json:"attachment_max_bytes,omitempty"`
+	Copy                     model.WidgetCopy        `json:"copy"`
+	Locale                   string                  `json:"locale,omitempty"`
 }
 
 type subscriptionLinkResponse struct {
@@ -145,28 +189,34 @@ func buildSubscriptionLinkResponse(heading string, message string, site model.Si
 func (h *PublicHandlers) CreateFeedback(context *gin.Context) {
 	clientIP := context.ClientIP()
 	if h.isRateLimited(clientIP) {
-		context.JSON(429, gin.H{"error": "rate_limited"})
+		h.rejectSubmission(context, metrics.SubmissionFeedback, 429, "rate_limited")
 		return
 	}
 
 	var payload createFeedbackRequest
 	if bindErr := context.BindJSON(&payload); bindErr != nil {
-		context.JSON(400, gin.H{"error": "invalid_json"})
+		h.rejectSubmission(context, metrics.SubmissionFeedback, 400, "invalid_json")
 		return
 	}
 
 	payload.SiteID = strings.TrimSpace(payload.SiteID)
 	payload.ContactInfo = strings.TrimSpace(payload.ContactInfo)
 	payload.MessageBody = strings.TrimSpace(payload.MessageBody)
+	payload.ExtraField = strings.TrimSpace(payload.ExtraField)
 
-	if payload.SiteID == "" || payload.ContactInfo == "" || payload.MessageBody == "" {
-		context.JSON(400, gin.H{"error": "missing_fields"})
+	if payload.SiteID == "" || payload.MessageBody == "" {
+		h.rejectSubmission(context, metrics.SubmissionFeedback, 400, "missing_fields")
 		return
 	}
 
 	var site model.Site
 	if err := h.database.First(&site, "id = ?", payload.SiteID).Error; err != nil {
-		context.JSON(404, gin.H{"error": "unknown_site"})
+		h.rejectSubmission(context, metrics.SubmissionFeedback, 404, "unknown_site")
+		return
+	}
+
+	if payload.ContactInfo == "" && site.WidgetConfig.ContactRequired() {
+		h.rejectSubmission(context, metrics.SubmissionFeedback, 400, "missing_fields")
 		return
 	}
 
@@ -174,30 +224,83 @@ func (h *PublicHandlers) CreateFeedback(context *gin.Context) {
 	refererHeader := strings.TrimSpace(context.GetHeader("Referer"))
 	allowedOrigins := mergedAllowedOrigins(site.AllowedOrigin, site.WidgetAllowedOrigins)
 	if !isOriginAllowed(allowedOrigins, originHeader, refererHeader, "") {
-		context.JSON(403, gin.H{"error": "origin_forbidden"})
+		h.rejectSubmission(context, metrics.SubmissionFeedback, 403, "origin_forbidden")
 		return
 	}
 
-	feedback := model.Feedback{
-		ID:        storage.NewID(),
-		SiteID:    site.ID,
-		Contact:   truncate(payload.ContactInfo, 320),
-		Message:   truncate(payload.MessageBody, 4000),
-		IP:        clientIP,
-		UserAgent: truncate(context.Request.UserAgent(), 400),
-		Delivery:  model.FeedbackDeliveryNone,
+	extraFieldValue, extraFieldErr := site.WidgetConfig.NormalizeExtraFieldValue(payload.ExtraField)
+	if extraFieldErr != nil {
+		h.rejectSubmission(context, metrics.SubmissionFeedback, 400, "invalid_extra_field")
+		return
+	}
+
+	feedbackInput := model.FeedbackInput{
+		SiteID:     site.ID,
+		Contact:    payload.ContactInfo,
+		Message:    payload.MessageBody,
+		Rating:     payload.Rating,
+		NPSScore:   payload.NPSScore,
+		Category:   payload.Category,
+		PageURL:    payload.PageURL,
+		ExtraField: extraFieldValue,
+		IP:         clientIP,
+		UserAgent:  context.Request.UserAgent(),
+	}
+	applyWidgetExtraFieldToFeedback(site.WidgetConfig, extraFieldValue, &feedbackInput)
+
+	feedback, feedbackErr := model.NewFeedback(feedbackInput)
+	if feedbackErr != nil {
+		h.rejectSubmission(context, metrics.SubmissionFeedback, 400, feedbackValidationErrorValue(feedbackErr))
+		return
 	}
 
 	if err := h.database.Create(&feedback).Error; err != nil {
-		h.logger.Warn("save_feedback", zap.Error(err))
-		context.JSON(500, gin.H{"error": "save_failed"})
+		requestLogger(context, h.logger).Warn("save_feedback", zap.Error(err))
+		h.rejectSubmission(context, metrics.SubmissionFeedback, 500, "save_failed")
 		return
 	}
 
 	h.applyFeedbackNotification(context.Request.Context(), site, &feedback)
 
 	h.broadcastFeedbackCreated(context.Request.Context(), feedback)
-	context.JSON(200, gin.H{"status": "ok"})
+	h.metrics.SubmissionAccepted(metrics.SubmissionFeedback)
+	context.JSON(200, gin.H{"status": "ok", "feedback_id": feedback.ID})
+}
+
+// applyWidgetExtraFieldToFeedback records a widget rating or select answer as the structured rating or category
+// unless the submission already carries one.
+func applyWidgetExtraFieldToFeedback(config model.WidgetConfig, extraFieldValue string, input *model.FeedbackInput) {
+	if config.ExtraField == nil || extraFieldValue == "" {
+		return
+	}
+	switch config.ExtraField.Type {
+	case model.WidgetExtraFieldRating:
+		if input.Rating != nil {
+			return
+		}
+		if rating, parseErr := strconv.Atoi(extraFieldValue); parseErr == nil {
+			input.Rating = &rating
+		}
+	case model.WidgetExtraFieldSelect:
+		if strings.TrimSpace(input.Category) == "" {
+			input.Category = extraFieldValue
+		}
+	}
+}
+
+func feedbackValidationErrorValue(feedbackErr error) string {
+	switch {
+	case errors.Is(feedbackErr, model.ErrInvalidFeedbackRating):
+		return "invalid_rating"
+	case errors.Is(feedbackErr, model.ErrInvalidFeedbackNPSScore):
+		return "invalid_nps_score"
+	case errors.Is(feedbackErr, model.ErrInvalidFeedbackCategory):
+		return "invalid_category"
+	case errors.Is(feedbackErr, model.ErrInvalidFeedbackPageURL):
+		return "invalid_page_url"
+	default:
+		return "missing_fields"
+	}
 }
 
 func (h *PublicHandlers) applyFeedbackNotification(ctx context.Context, site model.Site, feedback *model.Feedback) {
@@ -251,7 +354,7 @@ func (h *PublicHandlers) applySubscriptionNotification(ctx context.Context, site
 		return
 	}
 	if notifyErr := h.subscriptionNotifier.NotifySubscription(ctx, site, subscriber); notifyErr != nil {
-		h.logger.Warn("subscription_notification_failed", zap.Error(notifyErr), zap.String("site_id", site.ID), zap.String("subscriber_id", subscriber.ID))
+		requestLogger(ctx, h.logger).Warn("subscription_notification_failed", zap.Error(notifyErr), zap.String("site_id", site.ID), zap.String("subscriber_id", subscriber.ID))
 		h.recordSubscriptionTestEvent(site, subscriber, subscriptionEventTypeNotification, subscriptionEventStatusError, notifyErr.Error())
 		return
 	}
@@ -262,7 +365,9 @@ func (h *PublicHandlers) sendSubscriptionConfirmation(ctx context.Context, site
 	if h == nil {
 		return
 	}
-	sendSubscriptionConfirmationEmail(ctx, h.logger, h.recordSubscriptionTestEvent, h.confirmationEmailSender, h.publicBaseURL, h.subscriptionTokenSecret, h.subscriptionTokenTTL, site, subscriber)
+	if sendSubscriptionConfirmationEmail(ctx, h.logger, h.recordSubscriptionTestEvent, h.confirmationEmailSender, h.emailTemplates, h.publicBaseURL, h.subscriptionTokenSecret, h.subscriptionTokenTTL, site, subscriber) {
+		markSubscriptionConfirmationSent(ctx, h.database, h.logger, subscriber.ID, time.Now().UTC())
+	}
 }
 
 func (h *PublicHandlers) isRateLimited(ip string) bool {
@@ -311,10 +416,19 @@ func (h *PublicHandlers) WidgetConfig(context *gin.Context) {
 	}
 
 	ensureWidgetBubblePlacementDefaults(&site)
+	locale := resolveRequestLocale(context.Query("locale"), context.GetHeader(headerAcceptLanguage))
 	context.JSON(http.StatusOK, widgetConfigResponse{
 		SiteID:                   site.ID,
 		WidgetBubbleSide:         site.WidgetBubbleSide,
 		WidgetBubbleBottomOffset: site.WidgetBubbleBottomOffsetPx,
+		BrandColor:               site.WidgetConfig.BrandColor,
+		Theme:                    site.WidgetConfig.ResolvedTheme(),
+		ContactMode:              site.WidgetConfig.ResolvedContactMode(),
+		ExtraField:               site.WidgetConfig.ExtraField,
+		AllowAttachments:         h.attachmentsEnabled(site),
+		AttachmentMaxBytes:       h.widgetAttachmentMaxBytes(site),
+		Copy:                     site.WidgetConfig.ResolveCopy(locale),
+		Locale:                   locale,
 	})
 }
 
@@ -322,13 +436,13 @@ func (h *PublicHandlers) WidgetConfig(context *gin.Context) {
 func (h *PublicHandlers) CreateSubscription(context *gin.Context) {
 	clientIP := context.ClientIP()
 	if h.isRateLimited(clientIP) {
-		context.JSON(http.StatusTooManyRequests, gin.H{"error": "rate_limited"})
+		h.rejectSubmission(context, metrics.SubmissionSubscription, http.StatusTooManyRequests, "rate_limited")
 		return
 	}
 
 	var payload createSubscriptionRequest
 	if bindErr := context.BindJSON(&payload); bindErr != nil {
-		context.JSON(http.StatusBadRequest, gin.H{"error": "invalid_json"})
+		h.rejectSubmission(context, metrics.SubmissionSubscription, http.StatusBadRequest, "invalid_json")
 		return
 	}
 
@@ -338,13 +452,13 @@ func (h *PublicHandlers) CreateSubscription(context *gin.Context) {
 	payload.SourceURL = strings.TrimSpace(payload.SourceURL)
 
 	if payload.SiteID == "" || payload.Email == "" {
-		context.JSON(http.StatusBadRequest, gin.H{"error": "missing_fields"})
+		h.rejectSubmission(context, metrics.SubmissionSubscription, http.StatusBadRequest, "missing_fields")
 		return
 	}
 
 	var site model.Site
 	if err := h.database.First(&site, "id = ?", payload.SiteID).Error; err != nil {
-		context.JSON(http.StatusNotFound, gin.H{"error": errorValueInvalidSite})
+		h.rejectSubmission(context, metrics.SubmissionSubscription, http.StatusNotFound, errorValueInvalidSite)
 		return
 	}
 
@@ -352,30 +466,32 @@ func (h *PublicHandlers) CreateSubscription(context *gin.Context) {
 	refererHeader := strings.TrimSpace(context.GetHeader("Referer"))
 	allowedOrigins := mergedAllowedOrigins(site.AllowedOrigin, site.SubscribeAllowedOrigins)
 	if !isOriginAllowed(allowedOrigins, originHeader, refererHeader, "") {
-		context.JSON(http.StatusForbidden, gin.H{"error": "origin_forbidden"})
+		h.rejectSubmission(context, metrics.SubmissionSubscription, http.StatusForbidden, "origin_forbidden")
 		return
 	}
 
 	existingSubscriber, err := findSubscriber(context.Request.Context(), h.database, site.ID, payload.Email)
 	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
-		context.JSON(http.StatusInternalServerError, gin.H{"error": errorValueSaveSubscriberFailed})
+		h.rejectSubmission(context, metrics.SubmissionSubscription, http.StatusInternalServerError, errorValueSaveSubscriberFailed)
 		return
 	}
 	if err == nil {
-		if existingSubscriber.Status == model.SubscriberStatusUnsubscribed {
+		if isResubscribableStatus(existingSubscriber.Status) {
 			now := time.Now().UTC()
 			updateErr := h.database.Model(&existingSubscriber).Updates(map[string]any{
-				"status":          model.SubscriberStatusPending,
-				"unsubscribed_at": time.Time{},
-				"confirmed_at":    time.Time{},
-				"consent_at":      now,
-				"name":            payload.Name,
-				"source_url":      payload.SourceURL,
-				"ip":              truncate(clientIP, subscriptionIPMaxLength),
-				"user_agent":      truncate(context.Request.UserAgent(), subscriptionUserAgentMaxLength),
+				"status":               model.SubscriberStatusPending,
+				"unsubscribed_at":      time.Time{},
+				"confirmed_at":         time.Time{},
+				"confirmation_sent_at": time.Time{},
+				"reminder_sent_at":     time.Time{},
+				"consent_at":           now,
+				"name":                 payload.Name,
+				"source_url":           payload.SourceURL,
+				"ip":                   truncate(clientIP, subscriptionIPMaxLength),
+				"user_agent":           truncate(context.Request.UserAgent(), subscriptionUserAgentMaxLength),
 			}).Error
 			if updateErr != nil {
-				context.JSON(http.StatusInternalServerError, gin.H{"error": errorValueSaveSubscriberFailed})
+				h.rejectSubmission(context, metrics.SubmissionSubscription, http.StatusInternalServerError, errorValueSaveSubscriberFailed)
 				return
 			}
 			existingSubscriber.Status = model.SubscriberStatusPending
@@ -388,11 +504,12 @@ func (h *PublicHandlers) CreateSubscription(context *gin.Context) {
 			existingSubscriber.UserAgent = truncate(context.Request.UserAgent(), subscriptionUserAgentMaxLength)
 			h.recordSubscriptionTestEvent(site, existingSubscriber, subscriptionEventTypeSubmission, subscriptionEventStatusSuccess, "")
 			h.sendSubscriptionConfirmation(context.Request.Context(), site, existingSubscriber)
+			h.metrics.SubmissionAccepted(metrics.SubmissionSubscription)
 			context.JSON(http.StatusOK, gin.H{"status": "ok", "subscriber_id": existingSubscriber.ID})
 			return
 		}
 		h.recordSubscriptionTestEvent(site, existingSubscriber, subscriptionEventTypeSubmission, subscriptionEventStatusError, errorValueDuplicateSubscriber)
-		context.JSON(http.StatusConflict, gin.H{"error": errorValueDuplicateSubscriber})
+		h.rejectSubmission(context, metrics.SubmissionSubscription, http.StatusConflict, errorValueDuplicateSubscriber)
 		return
 	}
 
@@ -403,6 +520,7 @@ func (h *PublicHandlers) CreateSubscription(context *gin.Context) {
 		SourceURL: payload.SourceURL,
 		IP:        truncate(clientIP, subscriptionIPMaxLength),
 		UserAgent: truncate(context.Request.UserAgent(), subscriptionUserAgentMaxLength),
+		Locale:    resolveRequestLocale(payload.Locale, context.GetHeader(headerAcceptLanguage)),
 		Status:    model.SubscriberStatusPending,
 		ConsentAt: time.Now().UTC(),
 	}
@@ -410,20 +528,21 @@ func (h *PublicHandlers) CreateSubscription(context *gin.Context) {
 	subscriber, subscriberErr := model.NewSubscriber(input)
 	if subscriberErr != nil {
 		if errors.Is(subscriberErr, model.ErrInvalidSubscriberEmail) {
-			context.JSON(http.StatusBadRequest, gin.H{"error": errorValueInvalidEmail})
+			h.rejectSubmission(context, metrics.SubmissionSubscription, http.StatusBadRequest, errorValueInvalidEmail)
 			return
 		}
-		context.JSON(http.StatusBadRequest, gin.H{"error": errorValueInvalidEmail})
+		h.rejectSubmission(context, metrics.SubmissionSubscription, http.StatusBadRequest, errorValueInvalidEmail)
 		return
 	}
 
 	if err := h.database.Create(&subscriber).Error; err != nil {
-		context.JSON(http.StatusInternalServerError, gin.H{"error": errorValueSaveSubscriberFailed})
+		h.rejectSubmission(context, metrics.SubmissionSubscription, http.StatusInternalServerError, errorValueSaveSubscriberFailed)
 		return
 	}
 
 	h.recordSubscriptionTestEvent(site, subscriber, subscriptionEventTypeSubmission, subscriptionEventStatusSuccess, "")
 	h.sendSubscriptionConfirmation(context.Request.Context(), site, subscriber)
+	h.metrics.SubmissionAccepted(metrics.SubmissionSubscription)
 	context.JSON(http.StatusOK, gin.H{"status": "ok", "subscriber_id": subscriber.ID})
 }
 
@@ -475,6 +594,10 @@ func (h *PublicHandlers) ConfirmSubscriptionLinkJSON(context *gin.Context) {
 		context.JSON(http.StatusConflict, buildSubscriptionLinkResponse("Subscription confirmation", "Subscription already unsubscribed.", site, subscriber, ""))
 		return
 	}
+	if subscriber.Status == model.SubscriberStatusExpired {
+		context.JSON(http.StatusBadRequest, buildSubscriptionLinkResponse("Subscription confirmation", "Invalid or expired token.", site, model.Subscriber{}, ""))
+		return
+	}
 	if subscriber.Status == model.SubscriberStatusConfirmed {
 		context.JSON(http.StatusOK, buildSubscriptionLinkResponse("Subscription confirmed", "Your subscription is already confirmed.", site, subscriber, token))
 		return
@@ -638,6 +761,10 @@ func (h *PublicHandlers) updateSubscriptionStatus(context *gin.Context, targetSt
 		context.JSON(http.StatusConflict, gin.H{"error": errorValueUnsubscribedAccount})
 		return
 	}
+	if targetStatus == model.SubscriberStatusConfirmed && subscriber.Status == model.SubscriberStatusExpired {
+		context.JSON(http.StatusConflict, gin.H{"error": errorValueExpiredSubscription})
+		return
+	}
 	if subscriber.Status == targetStatus {
 		context.JSON(http.StatusOK, gin.H{"status": "ok"})
 		return
@@ -780,6 +907,10 @@ func normalizeOriginValue(rawValue string) string {
 	return strings.ToLower(parsedURL.Scheme) + "://" + strings.ToLower(parsedURL.Host)
 }
 
+func isResubscribableStatus(status string) bool {
+	return status == model.SubscriberStatusUnsubscribed || status == model.SubscriberStatusExpired
+}
+
 func findSubscriber(ctx context.Context, database *gorm.DB, siteID string, email string) (model.Subscriber, error) {
 	normalizedEmail := strings.ToLower(strings.TrimSpace(email))
 	var subscriber model.Subscriber
@@ -793,3 +924,21 @@ func truncate(input string, max int) string {
 	}
 	return input[:max]
 }
+
+func resolveRequestLocale(requestedLocale string, acceptLanguage string) string {
+	candidates := []string{requestedLocale}
+	for _, languageRange := range strings.Split(acceptLanguage, ",") {
+		languageTag, _, _ := strings.Cut(languageRange, ";")
+		candidates = append(candidates, languageTag)
+	}
+	for _, candidate := range candidates {
+		if strings.TrimSpace(candidate) == "*" {
+			continue
+		}
+		locale, localeErr := model.NormalizeLocale(candidate)
+		if localeErr == nil && locale != "" {
+			return locale
+		}
+	}
+	return ""
+}
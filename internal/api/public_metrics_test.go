@@ -0,0 +1,66 @@
+package api_test
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/MarkoPoloResearchLab/loopaware/internal/api"
+	"github.com/MarkoPoloResearchLab/loopaware/internal/metrics"
+)
+
+func scrapeMetrics(testingT *testing.T, instance *metrics.Metrics) string {
+	testingT.Helper()
+	recorder := httptest.NewRecorder()
+	instance.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(testingT, http.StatusOK, recorder.Code)
+	body, readErr := io.ReadAll(recorder.Body)
+	require.NoError(testingT, readErr)
+	return string(body)
+}
+
+func TestPublicHandlersCountSubmissionsAndRequestLatency(testingT *testing.T) {
+	harness := newSiteTestHarness(testingT)
+	site := insertSite(testingT, harness.database, "Metrics Site", "http://metrics.example", testUserEmailAddress)
+	serverMetrics := metrics.New()
+	publicHandlers := api.NewPublicHandlers(harness.database, zap.NewNop(), nil, nil, nil, nil, false, testWidgetBaseURL, "unit-test-session-secret", nil).
+		WithMetrics(serverMetrics)
+	router := gin.New()
+	router.Use(api.RequestLogger(zap.NewNop(), serverMetrics))
+	router.POST("/public/feedback", publicHandlers.CreateFeedback)
+	router.GET("/public/visits", publicHandlers.CollectVisit)
+
+	unknownSiteRequest := httptest.NewRequest(http.MethodPost, "/public/feedback", bytes.NewBufferString(`{"site_id":"missing","message":"hello"}`))
+	unknownSiteRequest.Header.Set("Content-Type", "application/json")
+	unknownSiteRecorder := httptest.NewRecorder()
+	router.ServeHTTP(unknownSiteRecorder, unknownSiteRequest)
+	require.Equal(testingT, http.StatusNotFound, unknownSiteRecorder.Code)
+
+	visitQuery := url.Values{"site_id": {site.ID}, "url": {"http://metrics.example/pricing"}}
+	visitRequest := httptest.NewRequest(http.MethodGet, "/public/visits?"+visitQuery.Encode(), nil)
+	visitRequest.Header.Set("Origin", "http://metrics.example")
+	visitRecorder := httptest.NewRecorder()
+	router.ServeHTTP(visitRecorder, visitRequest)
+	require.Equal(testingT, http.StatusOK, visitRecorder.Code)
+
+	forbiddenQuery := url.Values{"site_id": {site.ID}, "url": {"http://elsewhere.example/pricing"}}
+	forbiddenRequest := httptest.NewRequest(http.MethodGet, "/public/visits?"+forbiddenQuery.Encode(), nil)
+	forbiddenRequest.Header.Set("Origin", "http://elsewhere.example")
+	forbiddenRecorder := httptest.NewRecorder()
+	router.ServeHTTP(forbiddenRecorder, forbiddenRequest)
+	require.Equal(testingT, http.StatusForbidden, forbiddenRecorder.Code)
+
+	exposition := scrapeMetrics(testingT, serverMetrics)
+	require.Contains(testingT, exposition, `loopaware_public_submissions_total{kind="feedback",reason="unknown_site",result="rejected"} 1`)
+	require.Contains(testingT, exposition, `loopaware_public_submissions_total{kind="visit",reason="none",result="accepted"} 1`)
+	require.Contains(testingT, exposition, `loopaware_public_submissions_total{kind="visit",reason="origin_forbidden",result="rejected"} 1`)
+	require.Contains(testingT, exposition, `loopaware_http_request_duration_seconds_count{method="GET",route="/public/visits",status="200"} 1`)
+	require.Contains(testingT, exposition, `loopaware_http_request_duration_seconds_count{method="POST",route="/public/feedback",status="404"} 1`)
+}
@@ -3,16 +3,20 @@ package api
 import (
 	"context"
 	"errors"
+	"fmt"
 	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 
 	"github.com/MarkoPoloResearchLab/loopaware/internal/model"
 	"github.com/MarkoPoloResearchLab/loopaware/internal/task"
+	"github.com/MarkoPoloResearchLab/loopaware/internal/tracing"
 	"github.com/MarkoPoloResearchLab/loopaware/pkg/favicon"
 )
 
@@ -23,10 +27,15 @@ const (
 	defaultFaviconQueueCapacity   = 64
 )
 
+// SiteFaviconEvent announces a refreshed favicon or page metadata. FaviconURL is empty while the site has no favicon.
 type SiteFaviconEvent struct {
-	SiteID     string
-	FaviconURL string
-	UpdatedAt  time.Time
+	SiteID       string
+	FaviconURL   string
+	Title        string
+	Description  string
+	ImageURL     string
+	CanonicalURL string
+	UpdatedAt    time.Time
 }
 
 type SiteFaviconManagerOption func(*SiteFaviconManager)
@@ -38,6 +47,7 @@ type SiteFaviconManager struct {
 	retryInterval   time.Duration
 	refreshInterval time.Duration
 	scanInterval    time.Duration
+	externalScans   bool
 	now             func() time.Time
 
 	inFlight  sync.Map
@@ -46,11 +56,13 @@ type SiteFaviconManager struct {
 	scheduler    *task.Scheduler
 	workerCancel context.CancelFunc
 	workerGroup  sync.WaitGroup
+	workerAlive  atomic.Bool
 	startOnce    sync.Once
 	stopOnce     sync.Once
 
 	subscribersMutex sync.RWMutex
 	subscribers      map[int64]*faviconSubscriber
+	subscribersDone  bool
 	nextSubscriberID int64
 }
 
@@ -89,7 +101,9 @@ func NewSiteFaviconManager(database *gorm.DB, service *favicon.Service, logger *
 			option(manager)
 		}
 	}
-	manager.scheduler = task.NewScheduler(manager.scanInterval, manager.performScheduledRefresh)
+	if !manager.externalScans {
+		manager.scheduler = task.NewScheduler(manager.scanInterval, manager.performScheduledRefresh)
+	}
 	return manager
 }
 
@@ -112,6 +126,13 @@ func WithFaviconScanInterval(scanInterval time.Duration) SiteFaviconManagerOptio
 	}
 }
 
+// WithExternalFaviconScans leaves the periodic refresh scan to an external scheduler that calls RefreshAll.
+func WithExternalFaviconScans() SiteFaviconManagerOption {
+	return func(manager *SiteFaviconManager) {
+		manager.externalScans = true
+	}
+}
+
 func WithFaviconClock(clock func() time.Time) SiteFaviconManagerOption {
 	return func(manager *SiteFaviconManager) {
 		if clock != nil {
@@ -128,8 +149,10 @@ func (manager *SiteFaviconManager) Start(ctx context.Context) {
 		workerCtx, cancel := context.WithCancel(ctx)
 		manager.workerCancel = cancel
 		manager.workerGroup.Add(1)
+		manager.workerAlive.Store(true)
 		go func() {
 			defer manager.workerGroup.Done()
+			defer manager.workerAlive.Store(false)
 			manager.runWorker(workerCtx)
 		}()
 		if manager.scheduler != nil {
@@ -155,6 +178,21 @@ func (manager *SiteFaviconManager) Stop() {
 	})
 }
 
+// WorkerRunning reports whether the fetch worker started by Start is still processing the queue.
+func (manager *SiteFaviconManager) WorkerRunning() bool {
+	return manager != nil && manager.workerAlive.Load()
+}
+
+// SubscriberCount reports the number of open favicon event subscriptions.
+func (manager *SiteFaviconManager) SubscriberCount() int {
+	if manager == nil {
+		return 0
+	}
+	manager.subscribersMutex.RLock()
+	defer manager.subscribersMutex.RUnlock()
+	return len(manager.subscribers)
+}
+
 func (manager *SiteFaviconManager) ScheduleFetch(site model.Site) {
 	if manager == nil || manager.service == nil || manager.database == nil {
 		return
@@ -239,6 +277,10 @@ func (manager *SiteFaviconManager) Subscribe() *SiteFaviconSubscription {
 	subscriptionChannel := make(chan SiteFaviconEvent, 8)
 	identifier := atomic.AddInt64(&manager.nextSubscriberID, 1)
 	manager.subscribersMutex.Lock()
+	if manager.subscribersDone {
+		manager.subscribersMutex.Unlock()
+		return nil
+	}
 	manager.subscribers[identifier] = &faviconSubscriber{
 		identifier: identifier,
 		events:     subscriptionChannel,
@@ -298,8 +340,11 @@ func (manager *SiteFaviconManager) processFetch(ctx context.Context, task fetchT
 		return
 	}
 
+	ctx, span := tracing.Tracer().Start(ctx, "favicon.fetch", trace.WithAttributes(attribute.String("site_id", task.siteID)))
+	defer span.End()
+
 	var site model.Site
-	if err := manager.database.First(&site, "id = ?", task.siteID).Error; err != nil {
+	if err := manager.database.WithContext(ctx).First(&site, "id = ?", task.siteID).Error; err != nil {
 		if manager.logger != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
 			manager.logger.Warn("load_site_for_favicon", zap.String("site_id", task.siteID), zap.Error(err))
 		}
@@ -321,8 +366,17 @@ func (manager *SiteFaviconManager) processFetch(ctx context.Context, task fetchT
 		FaviconData:        site.FaviconData,
 		FaviconContentType: site.FaviconContentType,
 		FaviconFetchedAt:   site.FaviconFetchedAt,
+		Metadata: favicon.PageMetadata{
+			Title:        site.PageTitle,
+			Description:  site.PageDescription,
+			ImageURL:     site.PageImageURL,
+			CanonicalURL: site.PageCanonicalURL,
+		},
 	}
 	result, resolveErr := manager.service.Collect(ctx, siteSnapshot, normalizedOrigin, task.notify, currentTime)
+	if resolveErr != nil {
+		span.RecordError(resolveErr)
+	}
 	if resolveErr != nil && manager.logger != nil {
 		manager.logger.Debug(
 			"fetch_site_favicon_failed",
@@ -336,7 +390,7 @@ func (manager *SiteFaviconManager) processFetch(ctx context.Context, task fetchT
 		return
 	}
 
-	if updateErr := manager.database.Model(&model.Site{ID: task.siteID}).Updates(result.Updates).Error; updateErr != nil {
+	if updateErr := manager.database.WithContext(ctx).Model(&model.Site{ID: task.siteID}).Updates(result.Updates).Error; updateErr != nil {
 		if manager.logger != nil {
 			manager.logger.Warn("persist_site_favicon_failed", zap.String("site_id", task.siteID), zap.Error(updateErr))
 		}
@@ -348,34 +402,52 @@ func (manager *SiteFaviconManager) processFetch(ctx context.Context, task fetchT
 		if eventTimestamp.IsZero() {
 			eventTimestamp = currentTime
 		}
+		metadata := siteSnapshot.Metadata
+		if result.Metadata != nil {
+			metadata = *result.Metadata
+		}
+		faviconURL := ""
+		if _, faviconUpdated := result.Updates["favicon_data"]; faviconUpdated {
+			faviconURL = versionedSiteFaviconURL(task.siteID, eventTimestamp)
+		} else if len(site.FaviconData) > 0 {
+			faviconURL = versionedSiteFaviconURL(task.siteID, site.FaviconFetchedAt)
+		}
 		event := SiteFaviconEvent{
-			SiteID:     task.siteID,
-			FaviconURL: versionedSiteFaviconURL(task.siteID, eventTimestamp),
-			UpdatedAt:  eventTimestamp,
+			SiteID:       task.siteID,
+			FaviconURL:   faviconURL,
+			Title:        metadata.Title,
+			Description:  metadata.Description,
+			ImageURL:     metadata.ImageURL,
+			CanonicalURL: metadata.CanonicalURL,
+			UpdatedAt:    eventTimestamp,
 		}
 		manager.broadcast(event)
 	}
 }
 
 func (manager *SiteFaviconManager) performScheduledRefresh(ctx context.Context) {
-	if manager.database == nil {
-		return
+	if err := manager.RefreshAll(ctx); err != nil && !errors.Is(err, context.Canceled) && manager.logger != nil {
+		manager.logger.Warn("load_sites_for_favicon_refresh", zap.Error(err))
+	}
+}
+
+// RefreshAll queues a fetch for every site whose favicon is missing or stale; the worker started by Start performs them.
+func (manager *SiteFaviconManager) RefreshAll(ctx context.Context) error {
+	if manager == nil || manager.database == nil {
+		return nil
 	}
 
 	var sites []model.Site
-	if err := manager.database.
+	if err := manager.database.WithContext(ctx).
 		Select("id", "allowed_origin", "favicon_origin", "favicon_data", "favicon_fetched_at", "favicon_last_attempt_at").
 		Find(&sites).Error; err != nil {
-		if manager.logger != nil {
-			manager.logger.Warn("load_sites_for_favicon_refresh", zap.Error(err))
-		}
-		return
+		return fmt.Errorf("load sites for favicon refresh: %w", err)
 	}
 
 	for _, site := range sites {
 		select {
 		case <-ctx.Done():
-			return
+			return ctx.Err()
 		default:
 			primaryOriginValue := primaryAllowedOrigin(site.AllowedOrigin)
 			normalizedOrigin := strings.TrimSpace(primaryOriginValue)
@@ -387,6 +459,7 @@ func (manager *SiteFaviconManager) performScheduledRefresh(ctx context.Context)
 			)
 		}
 	}
+	return nil
 }
 
 func (manager *SiteFaviconManager) broadcast(event SiteFaviconEvent) {
@@ -412,8 +485,18 @@ func (manager *SiteFaviconManager) removeSubscriber(identifier int64) {
 	}
 }
 
+// CloseSubscribers ends every favicon event subscription and refuses new ones while the fetch worker keeps
+// running, so open event streams can finish before the manager is stopped.
+func (manager *SiteFaviconManager) CloseSubscribers() {
+	if manager == nil {
+		return
+	}
+	manager.closeSubscribers()
+}
+
 func (manager *SiteFaviconManager) closeSubscribers() {
 	manager.subscribersMutex.Lock()
+	manager.subscribersDone = true
 	for identifier, subscriber := range manager.subscribers {
 		close(subscriber.events)
 		delete(manager.subscribers, identifier)
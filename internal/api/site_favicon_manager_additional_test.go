@@ -1,8 +1,11 @@
 package api
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"image"
+	"image/png"
 	"testing"
 	"time"
 
@@ -394,7 +397,9 @@ func TestSiteFaviconManagerProcessFetchBroadcastsUpdates(testingT *testing.T) {
 	site := model.Site{ID: storage.NewID(), Name: "Broadcast", AllowedOrigin: testFaviconOrigin, OwnerEmail: "owner@example.com"}
 	require.NoError(testingT, database.Create(&site).Error)
 
-	resolver := &staticAssetResolver{asset: &favicon.Asset{ContentType: "image/png", Data: []byte{0x0A}}}
+	var sourceIcon bytes.Buffer
+	require.NoError(testingT, png.Encode(&sourceIcon, image.NewGray(image.Rect(0, 0, 128, 96))))
+	resolver := &staticAssetResolver{asset: &favicon.Asset{ContentType: "image/png", Data: sourceIcon.Bytes()}}
 	service := favicon.NewService(resolver)
 	manager := NewSiteFaviconManager(
 		database,
@@ -411,7 +416,10 @@ func TestSiteFaviconManagerProcessFetchBroadcastsUpdates(testingT *testing.T) {
 
 	var refreshed model.Site
 	require.NoError(testingT, database.First(&refreshed, "id = ?", site.ID).Error)
-	require.Equal(testingT, []byte{0x0A}, refreshed.FaviconData)
+	storedConfig, decodeErr := png.DecodeConfig(bytes.NewReader(refreshed.FaviconData))
+	require.NoError(testingT, decodeErr)
+	require.Equal(testingT, favicon.LargestVariantSize, storedConfig.Width)
+	require.Equal(testingT, favicon.LargestVariantSize, storedConfig.Height)
 	require.Equal(testingT, "image/png", refreshed.FaviconContentType)
 	require.False(testingT, refreshed.FaviconFetchedAt.IsZero())
 
@@ -1,8 +1,12 @@
 package api_test
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"image"
+	"image/color"
+	"image/png"
 	"net/http"
 	"strings"
 	"sync"
@@ -20,6 +24,18 @@ import (
 	"github.com/MarkoPoloResearchLab/loopaware/pkg/favicon"
 )
 
+func encodeTestFaviconPNG(testingT *testing.T, shade uint8) []byte {
+	testingT.Helper()
+	source := image.NewNRGBA(image.Rect(0, 0, 48, 48))
+	for index := 0; index < len(source.Pix); index += 4 {
+		source.Pix[index], source.Pix[index+1], source.Pix[index+2], source.Pix[index+3] = shade, 0x40, 0x80, 0xFF
+	}
+	source.SetNRGBA(0, 0, color.NRGBA{})
+	var buffer bytes.Buffer
+	require.NoError(testingT, png.Encode(&buffer, source))
+	return buffer.Bytes()
+}
+
 type stubAssetResolver struct {
 	asset *favicon.Asset
 	err   error
@@ -107,7 +123,7 @@ func TestSiteFaviconManagerStoresResolvedAsset(testingT *testing.T) {
 	}
 	require.NoError(testingT, database.Create(&site).Error)
 
-	resolver := &stubAssetResolver{asset: &favicon.Asset{ContentType: "image/png", Data: []byte{0x01, 0x02}}}
+	resolver := &stubAssetResolver{asset: &favicon.Asset{ContentType: "image/png", Data: encodeTestFaviconPNG(testingT, 0x01)}}
 	service := favicon.NewService(resolver)
 	manager := api.NewSiteFaviconManager(database, service, zap.NewNop())
 	manager.Start(context.Background())
@@ -120,7 +136,9 @@ func TestSiteFaviconManagerStoresResolvedAsset(testingT *testing.T) {
 		if err := database.First(&refreshed, "id = ?", site.ID).Error; err != nil {
 			return false
 		}
-		return len(refreshed.FaviconData) == 2 &&
+		return len(refreshed.FaviconData) > 0 &&
+			len(refreshed.FaviconData16) > 0 &&
+			len(refreshed.FaviconData32) > 0 &&
 			refreshed.FaviconContentType == "image/png" &&
 			!refreshed.FaviconFetchedAt.IsZero()
 	}, time.Second, 10*time.Millisecond)
@@ -145,7 +163,7 @@ func TestSiteFaviconManagerAvoidsDuplicateFetches(testingT *testing.T) {
 	}
 	require.NoError(testingT, database.Create(&site).Error)
 
-	resolver := &stubAssetResolver{asset: &favicon.Asset{ContentType: "image/png", Data: []byte{0x0A}}}
+	resolver := &stubAssetResolver{asset: &favicon.Asset{ContentType: "image/png", Data: encodeTestFaviconPNG(testingT, 0x0A)}}
 	service := favicon.NewService(resolver)
 	manager := api.NewSiteFaviconManager(database, service, zap.NewNop())
 	manager.Start(context.Background())
@@ -192,7 +210,7 @@ func TestSiteFaviconManagerScheduledRefreshQueuesStaleSites(testingT *testing.T)
 	}
 	require.NoError(testingT, database.Create(&site).Error)
 
-	resolver := &stubAssetResolver{asset: &favicon.Asset{ContentType: "image/png", Data: []byte{0x02}}}
+	resolver := &stubAssetResolver{asset: &favicon.Asset{ContentType: "image/png", Data: encodeTestFaviconPNG(testingT, 0x02)}}
 	service := favicon.NewService(resolver)
 	manager := api.NewSiteFaviconManager(
 		database,
@@ -229,7 +247,7 @@ func TestSiteFaviconManagerNotifiesSubscribers(testingT *testing.T) {
 	}
 	require.NoError(testingT, database.Create(&site).Error)
 
-	resolver := &stubAssetResolver{asset: &favicon.Asset{ContentType: "image/png", Data: []byte{0x03}}}
+	resolver := &stubAssetResolver{asset: &favicon.Asset{ContentType: "image/png", Data: encodeTestFaviconPNG(testingT, 0x03)}}
 	service := favicon.NewService(resolver)
 	manager := api.NewSiteFaviconManager(
 		database,
@@ -264,6 +282,77 @@ func TestSiteFaviconManagerNotifiesSubscribers(testingT *testing.T) {
 	require.NotEmpty(testingT, strings.TrimSpace(receivedEvent.FaviconURL))
 }
 
+type stubMetadataResolver struct {
+	stubAssetResolver
+	metadata *favicon.PageMetadata
+}
+
+func (resolver *stubMetadataResolver) ResolveSite(ctx context.Context, allowedOrigin string) (favicon.Resolution, error) {
+	asset, resolveErr := resolver.ResolveAsset(ctx, allowedOrigin)
+	return favicon.Resolution{Asset: asset, Metadata: resolver.metadata}, resolveErr
+}
+
+func TestSiteFaviconManagerStoresAndBroadcastsPageMetadata(testingT *testing.T) {
+	sqliteDatabase := testutil.NewSQLiteTestDatabase(testingT)
+	database, openErr := storage.OpenDatabase(sqliteDatabase.Configuration())
+	require.NoError(testingT, openErr)
+	database = testutil.ConfigureDatabaseLogger(testingT, database)
+	require.NoError(testingT, storage.AutoMigrate(database))
+
+	site := model.Site{
+		ID:            storage.NewID(),
+		Name:          "Metadata Site",
+		AllowedOrigin: "https://metadata.example",
+		OwnerEmail:    "owner@example.com",
+	}
+	require.NoError(testingT, database.Create(&site).Error)
+
+	metadata := favicon.PageMetadata{
+		Title:        "Metadata Example",
+		Description:  "Pages about metadata",
+		ImageURL:     "https://metadata.example/share.png",
+		CanonicalURL: "https://metadata.example/",
+	}
+	resolver := &stubMetadataResolver{metadata: &metadata}
+	manager := api.NewSiteFaviconManager(database, favicon.NewService(resolver), zap.NewNop())
+	manager.Start(context.Background())
+	testingT.Cleanup(manager.Stop)
+
+	subscription := manager.Subscribe()
+	require.NotNil(testingT, subscription)
+	defer subscription.Close()
+
+	manager.ScheduleFetch(site)
+
+	var receivedEvent api.SiteFaviconEvent
+	require.Eventually(testingT, func() bool {
+		select {
+		case event, ok := <-subscription.Events():
+			if !ok {
+				return false
+			}
+			receivedEvent = event
+			return true
+		default:
+			return false
+		}
+	}, time.Second, 10*time.Millisecond)
+
+	require.Equal(testingT, site.ID, receivedEvent.SiteID)
+	require.Empty(testingT, receivedEvent.FaviconURL)
+	require.Equal(testingT, metadata.Title, receivedEvent.Title)
+	require.Equal(testingT, metadata.Description, receivedEvent.Description)
+	require.Equal(testingT, metadata.ImageURL, receivedEvent.ImageURL)
+	require.Equal(testingT, metadata.CanonicalURL, receivedEvent.CanonicalURL)
+
+	var stored model.Site
+	require.NoError(testingT, database.First(&stored, "id = ?", site.ID).Error)
+	require.Equal(testingT, metadata.Title, stored.PageTitle)
+	require.Equal(testingT, metadata.Description, stored.PageDescription)
+	require.Equal(testingT, metadata.ImageURL, stored.PageImageURL)
+	require.Equal(testingT, metadata.CanonicalURL, stored.PageCanonicalURL)
+}
+
 func TestSiteFaviconManagerStopWaitsForInFlightFetch(testingT *testing.T) {
 	testingT.Helper()
 
@@ -281,7 +370,7 @@ func TestSiteFaviconManagerStopWaitsForInFlightFetch(testingT *testing.T) {
 	}
 	require.NoError(testingT, database.Create(&site).Error)
 
-	resolver := newBlockingAssetResolver(&favicon.Asset{ContentType: "image/png", Data: []byte{0x07}})
+	resolver := newBlockingAssetResolver(&favicon.Asset{ContentType: "image/png", Data: encodeTestFaviconPNG(testingT, 0x07)})
 	service := favicon.NewService(resolver)
 	manager := api.NewSiteFaviconManager(database, service, zap.NewNop())
 	manager.Start(context.Background())
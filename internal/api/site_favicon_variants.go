@@ -0,0 +1,65 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+
+	"github.com/MarkoPoloResearchLab/loopaware/internal/model"
+	"github.com/MarkoPoloResearchLab/loopaware/pkg/favicon"
+)
+
+const (
+	siteFaviconQuerySize            = "size"
+	faviconEntityTagLength          = 16
+	faviconSVGContentSecurityPolicy = "default-src 'none'; style-src 'unsafe-inline'; img-src data:"
+)
+
+// parseFaviconSize reads the optional size query parameter; an empty value selects the largest variant.
+func parseFaviconSize(rawValue string) (int, bool) {
+	trimmed := strings.TrimSpace(rawValue)
+	if trimmed == "" {
+		return favicon.LargestVariantSize, true
+	}
+	size, parseErr := strconv.Atoi(trimmed)
+	if parseErr != nil || size <= 0 {
+		return 0, false
+	}
+	return size, true
+}
+
+// selectFaviconVariant returns the smallest stored variant at least requestedSize pixels wide. Sites stored before
+// favicons were normalized, and SVG favicons, only have favicon_data, which is served for every size.
+func selectFaviconVariant(site model.Site, requestedSize int) []byte {
+	variants := map[int][]byte{
+		16:                         site.FaviconData16,
+		32:                         site.FaviconData32,
+		favicon.LargestVariantSize: site.FaviconData,
+	}
+	for _, size := range favicon.VariantSizes {
+		if size >= requestedSize && len(variants[size]) > 0 {
+			return variants[size]
+		}
+	}
+	return site.FaviconData
+}
+
+func faviconEntityTag(data []byte) string {
+	digest := sha256.Sum256(data)
+	return `"` + hex.EncodeToString(digest[:])[:faviconEntityTagLength] + `"`
+}
+
+// entityTagMatches applies the weak comparison If-None-Match requires, accepting lists and the * wildcard.
+func entityTagMatches(headerValue string, entityTag string) bool {
+	for _, candidate := range strings.Split(headerValue, ",") {
+		trimmed := strings.TrimSpace(candidate)
+		if trimmed == "*" {
+			return true
+		}
+		if strings.TrimPrefix(trimmed, "W/") == entityTag {
+			return true
+		}
+	}
+	return false
+}
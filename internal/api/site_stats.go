@@ -62,6 +62,7 @@ type SiteStatisticsProvider interface {
 	VisitCount(ctx context.Context, siteID string) (int64, error)
 	UniqueVisitorCount(ctx context.Context, siteID string) (int64, error)
 	TopPages(ctx context.Context, siteID string, limit int) ([]TopPageStat, error)
+	TopPagesBetween(ctx context.Context, siteID string, since time.Time, until time.Time, limit int) ([]TopPageStat, error)
 	VisitTrend(ctx context.Context, siteID string, days int) ([]DailyVisitTrendStat, error)
 	VisitAttribution(ctx context.Context, siteID string, limit int) (VisitAttributionBreakdown, error)
 	VisitEngagement(ctx context.Context, siteID string, days int) (VisitEngagementStat, error)
@@ -132,6 +133,18 @@ func (provider *DatabaseSiteStatisticsProvider) TopPages(ctx context.Context, si
 	if strings.TrimSpace(siteID) == "" {
 		return nil, nil
 	}
+	return provider.topPages(ctx, limit, "site_id = ? AND path <> '' AND is_bot = ?", siteID, false)
+}
+
+// TopPagesBetween returns top pages by visit count among visits that occurred in [since, until).
+func (provider *DatabaseSiteStatisticsProvider) TopPagesBetween(ctx context.Context, siteID string, since time.Time, until time.Time, limit int) ([]TopPageStat, error) {
+	if strings.TrimSpace(siteID) == "" || !since.Before(until) {
+		return nil, nil
+	}
+	return provider.topPages(ctx, limit, "site_id = ? AND path <> '' AND is_bot = ? AND occurred_at >= ? AND occurred_at < ?", siteID, false, since.UTC(), until.UTC())
+}
+
+func (provider *DatabaseSiteStatisticsProvider) topPages(ctx context.Context, limit int, condition string, arguments ...any) ([]TopPageStat, error) {
 	if limit <= 0 {
 		limit = 10
 	}
@@ -139,7 +152,7 @@ func (provider *DatabaseSiteStatisticsProvider) TopPages(ctx context.Context, si
 	err := provider.database.WithContext(ctx).
 		Model(&model.SiteVisit{}).
 		Select(topPagesSelectStatement).
-		Where("site_id = ? AND path <> '' AND is_bot = ?", siteID, false).
+		Where(condition, arguments...).
 		Group(topPagesCanonicalPathExpression).
 		Order("visit_count desc, path asc").
 		Limit(limit).
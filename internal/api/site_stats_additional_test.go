@@ -47,6 +47,36 @@ func TestDatabaseSiteStatisticsProviderTopPagesDefaultsLimit(testingT *testing.T
 	require.Equal(testingT, int64(2), results[0].VisitCount)
 }
 
+func TestDatabaseSiteStatisticsProviderTopPagesBetweenExcludesVisitsOutsideWindow(testingT *testing.T) {
+	database := openFaviconManagerDatabase(testingT)
+	siteID := storage.NewID()
+	since := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	until := since.Add(24 * time.Hour)
+
+	for _, visitInput := range []struct {
+		path     string
+		occurred time.Time
+	}{
+		{"/before", since.Add(-time.Second)},
+		{"/inside", since},
+		{"/inside", until.Add(-time.Second)},
+		{"/after", until},
+	} {
+		visit, visitErr := model.NewSiteVisit(model.SiteVisitInput{SiteID: siteID, URL: "https://example.com" + visitInput.path, Occurred: visitInput.occurred})
+		require.NoError(testingT, visitErr)
+		require.NoError(testingT, database.Create(&visit).Error)
+	}
+
+	provider := NewDatabaseSiteStatisticsProvider(database)
+	results, err := provider.TopPagesBetween(context.Background(), siteID, since, until, 5)
+	require.NoError(testingT, err)
+	require.Equal(testingT, []TopPageStat{{Path: "/inside", VisitCount: 2}}, results)
+
+	emptyResults, emptyErr := provider.TopPagesBetween(context.Background(), siteID, until, since, 5)
+	require.NoError(testingT, emptyErr)
+	require.Nil(testingT, emptyResults)
+}
+
 func TestDatabaseSiteStatisticsProviderTopPagesSkipsBlankSite(testingT *testing.T) {
 	database := openFaviconManagerDatabase(testingT)
 	provider := NewDatabaseSiteStatisticsProvider(database)
@@ -12,6 +12,7 @@ import (
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 
+	"github.com/MarkoPoloResearchLab/loopaware/internal/emailtemplate"
 	"github.com/MarkoPoloResearchLab/loopaware/internal/model"
 )
 
@@ -26,6 +27,7 @@ type SiteSubscribeTestHandlers struct {
 	subscriptionTokenSecret   string
 	subscriptionTokenTTL      time.Duration
 	confirmationEmailSender   EmailSender
+	emailTemplates            *emailtemplate.Renderer
 }
 
 // NewSiteSubscribeTestHandlers constructs handlers for subscription test APIs.
@@ -48,6 +50,12 @@ func NewSiteSubscribeTestHandlers(database *gorm.DB, logger *zap.Logger, broadca
 	}
 }
 
+// WithEmailTemplates renders confirmation emails through the provided site template renderer.
+func (handlers *SiteSubscribeTestHandlers) WithEmailTemplates(renderer *emailtemplate.Renderer) *SiteSubscribeTestHandlers {
+	handlers.emailTemplates = renderer
+	return handlers
+}
+
 // StreamSubscriptionTestEvents streams subscription test events as SSE.
 func (handlers *SiteSubscribeTestHandlers) StreamSubscriptionTestEvents(context *gin.Context) {
 	siteIdentifier := strings.TrimSpace(context.Param("id"))
@@ -102,6 +110,7 @@ func (handlers *SiteSubscribeTestHandlers) StreamSubscriptionTestEvents(context
 			return
 		case event, open := <-subscription.Events():
 			if !open {
+				writeServerShutdownEvent(writer, flusher)
 				return
 			}
 			if event.SiteID != site.ID {
@@ -169,17 +178,19 @@ func (handlers *SiteSubscribeTestHandlers) CreateSubscription(context *gin.Conte
 		return
 	}
 	if findErr == nil {
-		if existingSubscriber.Status == model.SubscriberStatusUnsubscribed {
+		if isResubscribableStatus(existingSubscriber.Status) {
 			now := time.Now().UTC()
 			updateErr := handlers.database.Model(&existingSubscriber).Updates(map[string]any{
-				"status":          model.SubscriberStatusPending,
-				"unsubscribed_at": time.Time{},
-				"confirmed_at":    time.Time{},
-				"consent_at":      now,
-				"name":            payload.Name,
-				"source_url":      payload.SourceURL,
-				"ip":              truncate(clientIP, subscriptionIPMaxLength),
-				"user_agent":      truncate(context.Request.UserAgent(), subscriptionUserAgentMaxLength),
+				"status":               model.SubscriberStatusPending,
+				"unsubscribed_at":      time.Time{},
+				"confirmed_at":         time.Time{},
+				"confirmation_sent_at": time.Time{},
+				"reminder_sent_at":     time.Time{},
+				"consent_at":           now,
+				"name":                 payload.Name,
+				"source_url":           payload.SourceURL,
+				"ip":                   truncate(clientIP, subscriptionIPMaxLength),
+				"user_agent":           truncate(context.Request.UserAgent(), subscriptionUserAgentMaxLength),
 			}).Error
 			if updateErr != nil {
 				context.JSON(http.StatusInternalServerError, gin.H{"error": errorValueSaveSubscriberFailed})
@@ -238,7 +249,9 @@ func (handlers *SiteSubscribeTestHandlers) sendSubscriptionConfirmation(ctx cont
 	if handlers == nil {
 		return
 	}
-	sendSubscriptionConfirmationEmail(ctx, handlers.logger, handlers.recordSubscriptionTestEvent, handlers.confirmationEmailSender, handlers.publicBaseURL, handlers.subscriptionTokenSecret, handlers.subscriptionTokenTTL, site, subscriber)
+	if sendSubscriptionConfirmationEmail(ctx, handlers.logger, handlers.recordSubscriptionTestEvent, handlers.confirmationEmailSender, handlers.emailTemplates, handlers.publicBaseURL, handlers.subscriptionTokenSecret, handlers.subscriptionTokenTTL, site, subscriber) {
+		markSubscriptionConfirmationSent(ctx, handlers.database, handlers.logger, subscriber.ID, time.Now().UTC())
+	}
 }
 
 func (handlers *SiteSubscribeTestHandlers) recordSubscriptionTestEvent(site model.Site, subscriber model.Subscriber, eventType, status, message string) {
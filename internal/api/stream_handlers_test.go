@@ -378,6 +378,68 @@ func TestStreamFeedbackUpdatesReturnsUnavailableWhenClosed(testingT *testing.T)
 	require.Equal(testingT, http.StatusServiceUnavailable, recorder.Code)
 }
 
+func TestStreamFeedbackUpdatesSendsShutdownEventWhenBroadcasterCloses(testingT *testing.T) {
+	gin.SetMode(gin.TestMode)
+	database := openStreamDatabase(testingT)
+
+	feedbackBroadcaster := NewFeedbackEventBroadcaster()
+	handlers := NewSiteHandlers(database, zap.NewNop(), testStreamPublicBaseURL, nil, nil, feedbackBroadcaster)
+
+	recorder := newNotifyingRecorder()
+	ginContext, _ := gin.CreateTestContext(recorder)
+	ginContext.Request = httptest.NewRequest(http.MethodGet, testStreamFeedbackEventsPath, nil)
+	ginContext.Set(contextKeyCurrentUser, &CurrentUser{Email: testStreamOwnerEmail, Role: RoleAdmin})
+
+	streamDone := make(chan struct{})
+	go func() {
+		handlers.StreamFeedbackUpdates(ginContext)
+		close(streamDone)
+	}()
+
+	waitForFeedbackSubscriber(testingT, feedbackBroadcaster)
+	feedbackBroadcaster.Close()
+
+	select {
+	case <-streamDone:
+	case <-time.After(testStreamTimeout):
+		testingT.Fatal(testStreamFeedbackShutdownMessage)
+	}
+
+	body := recorder.BodyString()
+	require.Contains(testingT, body, "retry: 5000\n")
+	require.Contains(testingT, body, "event: "+serverShutdownEventName+"\ndata: {\"reconnect_after_ms\":5000}\n\n")
+}
+
+func TestStreamFaviconUpdatesEndsWhenSubscribersClose(testingT *testing.T) {
+	gin.SetMode(gin.TestMode)
+	database := openStreamDatabase(testingT)
+
+	siteFaviconManager := NewSiteFaviconManager(database, favicon.NewService(&staticResolver{}), zap.NewNop())
+	handlers := NewSiteHandlers(database, zap.NewNop(), testStreamPublicBaseURL, siteFaviconManager, nil, nil)
+
+	recorder := newNotifyingRecorder()
+	ginContext, _ := gin.CreateTestContext(recorder)
+	ginContext.Request = httptest.NewRequest(http.MethodGet, testStreamFaviconEventsPath, nil)
+	ginContext.Set(contextKeyCurrentUser, &CurrentUser{Email: testStreamOwnerEmail, Role: RoleAdmin})
+
+	streamDone := make(chan struct{})
+	go func() {
+		handlers.StreamFaviconUpdates(ginContext)
+		close(streamDone)
+	}()
+
+	waitForFaviconSubscriber(testingT, siteFaviconManager)
+	siteFaviconManager.CloseSubscribers()
+
+	select {
+	case <-streamDone:
+	case <-time.After(testStreamTimeout):
+		testingT.Fatal(testStreamFaviconShutdownMessage)
+	}
+	require.Contains(testingT, recorder.BodyString(), "event: "+serverShutdownEventName)
+	require.Nil(testingT, siteFaviconManager.Subscribe())
+}
+
 func TestStreamSubscriptionTestEventsWritesEvent(testingT *testing.T) {
 	gin.SetMode(gin.TestMode)
 	database := openStreamDatabase(testingT)
@@ -605,3 +667,141 @@ func TestStreamFeedbackUpdatesSkipsUnauthorizedSite(testingT *testing.T) {
 		testingT.Fatal(testStreamFeedbackShutdownMessage)
 	}
 }
+
+func TestStreamFeedbackUpdatesResumesAfterLastEventID(testingT *testing.T) {
+	gin.SetMode(gin.TestMode)
+	database := openStreamDatabase(testingT)
+	site := createStreamSite(testingT, database)
+
+	feedbackBroadcaster := NewFeedbackEventBroadcaster()
+	feedbackBroadcaster.Broadcast(FeedbackEvent{SiteID: site.ID, FeedbackID: "feedback-before", CreatedAt: time.Now().UTC()})
+	feedbackBroadcaster.Broadcast(FeedbackEvent{SiteID: site.ID, FeedbackID: "feedback-missed", CreatedAt: time.Now().UTC()})
+	handlers := NewSiteHandlers(database, zap.NewNop(), testStreamPublicBaseURL, nil, nil, feedbackBroadcaster)
+
+	recorder := newNotifyingRecorder()
+	ginContext, _ := gin.CreateTestContext(recorder)
+	requestContext, cancel := context.WithCancel(context.Background())
+	testingT.Cleanup(cancel)
+	ginContext.Request = httptest.NewRequest(http.MethodGet, testStreamFeedbackEventsPath, nil).WithContext(requestContext)
+	ginContext.Request.Header.Set(headerLastEventID, "1")
+	ginContext.Set(contextKeyCurrentUser, &CurrentUser{Email: testStreamOwnerEmail, Role: RoleAdmin})
+
+	streamDone := make(chan struct{})
+	go func() {
+		handlers.StreamFeedbackUpdates(ginContext)
+		close(streamDone)
+	}()
+
+	select {
+	case <-recorder.writeNotification:
+	case <-time.After(testStreamTimeout):
+		testingT.Fatal(testStreamFeedbackWriteMessage)
+	}
+	cancel()
+	select {
+	case <-streamDone:
+	case <-time.After(testStreamTimeout):
+		testingT.Fatal(testStreamFeedbackShutdownMessage)
+	}
+
+	body := recorder.BodyString()
+	require.Contains(testingT, body, "id: 2\nevent: "+feedbackCreatedEventName)
+	require.Contains(testingT, body, "feedback-missed")
+	require.NotContains(testingT, body, "feedback-before")
+}
+
+func TestStreamFeedbackUpdatesFiltersBySiteAndSendsHeartbeats(testingT *testing.T) {
+	gin.SetMode(gin.TestMode)
+	database := openStreamDatabase(testingT)
+	site := createStreamSite(testingT, database)
+	otherSite := model.Site{ID: "stream-other-site", Name: "Other Stream Site", AllowedOrigin: "https://other-stream.example", OwnerEmail: testStreamOwnerEmail}
+	require.NoError(testingT, database.Create(&otherSite).Error)
+
+	feedbackBroadcaster := NewFeedbackEventBroadcaster()
+	handlers := NewSiteHandlers(database, zap.NewNop(), testStreamPublicBaseURL, nil, nil, feedbackBroadcaster)
+	handlers.streamHeartbeatInterval = testStreamPollInterval
+
+	recorder := newNotifyingRecorder()
+	ginContext, _ := gin.CreateTestContext(recorder)
+	requestContext, cancel := context.WithCancel(context.Background())
+	testingT.Cleanup(cancel)
+	ginContext.Request = httptest.NewRequest(http.MethodGet, testStreamFeedbackEventsPath+"?site_id="+site.ID, nil).WithContext(requestContext)
+	ginContext.Set(contextKeyCurrentUser, &CurrentUser{Email: testStreamOwnerEmail, Role: RoleUser})
+
+	streamDone := make(chan struct{})
+	go func() {
+		handlers.StreamFeedbackUpdates(ginContext)
+		close(streamDone)
+	}()
+
+	waitForFeedbackSubscriber(testingT, feedbackBroadcaster)
+	feedbackBroadcaster.Broadcast(FeedbackEvent{SiteID: otherSite.ID, FeedbackID: "feedback-other", CreatedAt: time.Now().UTC()})
+	feedbackBroadcaster.Broadcast(FeedbackEvent{SiteID: site.ID, FeedbackID: testStreamFeedbackID, CreatedAt: time.Now().UTC()})
+
+	require.Eventually(testingT, func() bool {
+		body := recorder.BodyString()
+		return strings.Contains(body, testStreamFeedbackID) && strings.Contains(body, eventStreamHeartbeatComment)
+	}, testStreamTimeout, testStreamPollInterval)
+	cancel()
+	select {
+	case <-streamDone:
+	case <-time.After(testStreamTimeout):
+		testingT.Fatal(testStreamFeedbackShutdownMessage)
+	}
+	require.NotContains(testingT, recorder.BodyString(), "feedback-other")
+}
+
+func TestStreamFeedbackUpdatesRejectsUnmanagedSiteFilter(testingT *testing.T) {
+	gin.SetMode(gin.TestMode)
+	database := openStreamDatabase(testingT)
+	site := createStreamSite(testingT, database)
+
+	handlers := NewSiteHandlers(database, zap.NewNop(), testStreamPublicBaseURL, nil, nil, NewFeedbackEventBroadcaster())
+
+	recorder := httptest.NewRecorder()
+	ginContext, _ := gin.CreateTestContext(recorder)
+	ginContext.Request = httptest.NewRequest(http.MethodGet, testStreamFeedbackEventsPath+"?site_id="+site.ID, nil)
+	ginContext.Set(contextKeyCurrentUser, &CurrentUser{Email: testStreamUnauthorizedEmail, Role: RoleUser})
+
+	handlers.StreamFeedbackUpdates(ginContext)
+	require.Equal(testingT, http.StatusForbidden, recorder.Code)
+}
+
+func TestStreamFeedbackUpdatesReportsEvictedEvents(testingT *testing.T) {
+	gin.SetMode(gin.TestMode)
+	database := openStreamDatabase(testingT)
+	site := createStreamSite(testingT, database)
+
+	feedbackBroadcaster := NewFeedbackEventBroadcaster()
+	feedbackBroadcaster.historyLength = 1
+	feedbackBroadcaster.Broadcast(FeedbackEvent{SiteID: site.ID, FeedbackID: "feedback-evicted", CreatedAt: time.Now().UTC()})
+	feedbackBroadcaster.Broadcast(FeedbackEvent{SiteID: site.ID, FeedbackID: testStreamFeedbackID, CreatedAt: time.Now().UTC()})
+	handlers := NewSiteHandlers(database, zap.NewNop(), testStreamPublicBaseURL, nil, nil, feedbackBroadcaster)
+
+	recorder := newNotifyingRecorder()
+	ginContext, _ := gin.CreateTestContext(recorder)
+	requestContext, cancel := context.WithCancel(context.Background())
+	testingT.Cleanup(cancel)
+	ginContext.Request = httptest.NewRequest(http.MethodGet, testStreamFeedbackEventsPath+"?last_event_id=0", nil).WithContext(requestContext)
+	ginContext.Set(contextKeyCurrentUser, &CurrentUser{Email: testStreamOwnerEmail, Role: RoleAdmin})
+
+	streamDone := make(chan struct{})
+	go func() {
+		handlers.StreamFeedbackUpdates(ginContext)
+		close(streamDone)
+	}()
+
+	require.Eventually(testingT, func() bool {
+		return strings.Contains(recorder.BodyString(), testStreamFeedbackID)
+	}, testStreamTimeout, testStreamPollInterval)
+	cancel()
+	select {
+	case <-streamDone:
+	case <-time.After(testStreamTimeout):
+		testingT.Fatal(testStreamFeedbackShutdownMessage)
+	}
+
+	body := recorder.BodyString()
+	require.Contains(testingT, body, "event: "+feedbackDroppedEventName+"\ndata: {\"dropped\":1,\"last_event_id\":2}")
+	require.NotContains(testingT, body, "feedback-evicted")
+}
@@ -0,0 +1,397 @@
+package api
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"github.com/MarkoPoloResearchLab/loopaware/internal/model"
+)
+
+const (
+	subscriberImportModeConsented = "consented"
+	subscriberImportModeConfirm   = "confirm"
+
+	subscriberImportQueryMode         = "mode"
+	subscriberImportFormFieldFile     = "file"
+	subscriberImportMaxBytes          = 10 << 20
+	subscriberImportBackgroundRows    = 200
+	subscriberImportColumnEmail       = "email"
+	subscriberImportColumnName        = "name"
+	subscriberImportColumnStatus      = "status"
+	subscriberImportColumnCreatedAt   = "created_at"
+	subscriberImportColumnConfirmedAt = "confirmed_at"
+	subscriberImportColumnUnsubAt     = "unsubscribed_at"
+
+	errorValueInvalidImportMode = "invalid_import_mode"
+	errorValueInvalidCSV        = "invalid_csv"
+	errorValueEmptyImport       = "empty_import"
+	errorValueImportTooLarge    = "import_too_large"
+	errorValueUnknownImportJob  = "unknown_import_job"
+	errorValueInvalidTimestamp  = "invalid_timestamp"
+)
+
+var subscriberExportColumns = []string{
+	subscriberImportColumnEmail,
+	subscriberImportColumnName,
+	subscriberImportColumnStatus,
+	subscriberImportColumnCreatedAt,
+	subscriberImportColumnConfirmedAt,
+	subscriberImportColumnUnsubAt,
+}
+
+// SubscriberImportHandlers serves bulk subscriber import endpoints.
+type SubscriberImportHandlers struct {
+	database               *gorm.DB
+	logger                 *zap.Logger
+	runner                 *SubscriberImportRunner
+	backgroundRowThreshold int
+}
+
+// NewSubscriberImportHandlers constructs handlers for subscriber CSV imports. Small imports run inline through runner;
+// larger ones are queued and handed to it through the job runner.
+func NewSubscriberImportHandlers(database *gorm.DB, logger *zap.Logger, runner *SubscriberImportRunner) *SubscriberImportHandlers {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &SubscriberImportHandlers{
+		database:               database,
+		logger:                 logger,
+		runner:                 runner,
+		backgroundRowThreshold: subscriberImportBackgroundRows,
+	}
+}
+
+// SubscriberImportRowError describes a CSV row that could not be imported.
+type SubscriberImportRowError = model.SubscriberImportRowError
+
+// SubscriberImportReport summarizes the progress and outcome of an import job.
+type SubscriberImportReport struct {
+	JobID         string                     `json:"job_id"`
+	SiteID        string                     `json:"site_id"`
+	Mode          string                     `json:"mode"`
+	Status        string                     `json:"status"`
+	TotalRows     int                        `json:"total_rows"`
+	ProcessedRows int                        `json:"processed_rows"`
+	ImportedCount int                        `json:"imported_count"`
+	SkippedCount  int                        `json:"skipped_count"`
+	Errors        []SubscriberImportRowError `json:"errors"`
+	StartedAt     int64                      `json:"started_at"`
+	CompletedAt   int64                      `json:"completed_at"`
+}
+
+// ImportSubscribers ingests a CSV file laid out like ExportSubscribers.
+func (handlers *SubscriberImportHandlers) ImportSubscribers(context *gin.Context) {
+	site, _, ok := resolveManageableSite(context, handlers.database)
+	if !ok {
+		return
+	}
+
+	mode, modeErr := parseSubscriberImportMode(context.Query(subscriberImportQueryMode))
+	if modeErr != nil {
+		context.JSON(http.StatusBadRequest, gin.H{jsonKeyError: errorValueInvalidImportMode})
+		return
+	}
+
+	source, sourceErr := subscriberImportSource(context)
+	if sourceErr != nil {
+		context.JSON(http.StatusBadRequest, gin.H{jsonKeyError: errorValueInvalidCSV})
+		return
+	}
+	defer source.Close()
+
+	rows, parseErr := parseSubscriberImportCSV(io.LimitReader(source, subscriberImportMaxBytes+1))
+	if parseErr != nil {
+		if errors.Is(parseErr, errSubscriberImportTooLarge) {
+			context.JSON(http.StatusRequestEntityTooLarge, gin.H{jsonKeyError: errorValueImportTooLarge})
+			return
+		}
+		context.JSON(http.StatusBadRequest, gin.H{jsonKeyError: errorValueInvalidCSV})
+		return
+	}
+	if len(rows) == 0 {
+		context.JSON(http.StatusBadRequest, gin.H{jsonKeyError: errorValueEmptyImport})
+		return
+	}
+
+	runInBackground := len(rows) > handlers.backgroundRowThreshold
+	initialStatus := model.SubscriberImportStatusRunning
+	if runInBackground {
+		initialStatus = model.SubscriberImportStatusQueued
+	}
+	subscriberImport, importErr := model.NewSubscriberImport(site.ID, mode, initialStatus, rows, time.Now().UTC())
+	if importErr != nil {
+		context.JSON(http.StatusBadRequest, gin.H{jsonKeyError: errorValueInvalidCSV})
+		return
+	}
+	if err := handlers.database.WithContext(context.Request.Context()).Create(&subscriberImport).Error; err != nil {
+		requestLogger(context.Request.Context(), handlers.logger).Warn("subscriber_import_create", zap.Error(err), zap.String("site_id", site.ID))
+		context.JSON(http.StatusInternalServerError, gin.H{jsonKeyError: errorValueSaveSubscriberFailed})
+		return
+	}
+
+	if runInBackground {
+		handlers.runner.Trigger()
+		context.JSON(http.StatusAccepted, newSubscriberImportReport(subscriberImport))
+		return
+	}
+
+	// A client disconnect stops the import between rows; the job runner resumes it once it goes stale.
+	if err := handlers.runner.Run(context.Request.Context(), &subscriberImport); err != nil {
+		requestLogger(context.Request.Context(), handlers.logger).Warn("subscriber_import_run", zap.Error(err), zap.String("site_id", site.ID), zap.String("import_id", subscriberImport.ID))
+	}
+	context.JSON(http.StatusOK, newSubscriberImportReport(subscriberImport))
+}
+
+// SubscriberImportStatus reports the progress of a previously started import.
+func (handlers *SubscriberImportHandlers) SubscriberImportStatus(context *gin.Context) {
+	site, _, ok := resolveManageableSite(context, handlers.database)
+	if !ok {
+		return
+	}
+
+	jobID := strings.TrimSpace(context.Param("job_id"))
+	var subscriberImport model.SubscriberImport
+	findErr := handlers.database.WithContext(context.Request.Context()).
+		Omit("rows").
+		First(&subscriberImport, "id = ? AND site_id = ?", jobID, site.ID).Error
+	if findErr != nil {
+		if errors.Is(findErr, gorm.ErrRecordNotFound) {
+			context.JSON(http.StatusNotFound, gin.H{jsonKeyError: errorValueUnknownImportJob})
+			return
+		}
+		requestLogger(context.Request.Context(), handlers.logger).Warn("subscriber_import_status", zap.Error(findErr), zap.String("site_id", site.ID))
+		context.JSON(http.StatusInternalServerError, gin.H{jsonKeyError: errorValueUnknownImportJob})
+		return
+	}
+
+	context.JSON(http.StatusOK, newSubscriberImportReport(subscriberImport))
+}
+
+func newSubscriberImportReport(subscriberImport model.SubscriberImport) SubscriberImportReport {
+	report := SubscriberImportReport{
+		JobID:         subscriberImport.ID,
+		SiteID:        subscriberImport.SiteID,
+		Mode:          subscriberImport.Mode,
+		Status:        subscriberImport.Status,
+		TotalRows:     subscriberImport.TotalRows,
+		ProcessedRows: subscriberImport.ProcessedRows,
+		ImportedCount: subscriberImport.ImportedCount,
+		SkippedCount:  subscriberImport.SkippedCount,
+		Errors:        append(make([]SubscriberImportRowError, 0, len(subscriberImport.Errors)), subscriberImport.Errors...),
+		StartedAt:     subscriberImport.StartedAt.Unix(),
+	}
+	if !subscriberImport.CompletedAt.IsZero() {
+		report.CompletedAt = subscriberImport.CompletedAt.Unix()
+	}
+	return report
+}
+
+func subscriberImportInput(siteID string, mode string, row model.SubscriberImportRow, now time.Time) (model.SubscriberInput, string) {
+	input := model.SubscriberInput{
+		SiteID: siteID,
+		Email:  row.Email,
+		Name:   row.Name,
+	}
+
+	if mode == subscriberImportModeConfirm {
+		if row.Status == model.SubscriberStatusUnsubscribed {
+			return model.SubscriberInput{}, errorValueUnsubscribedAccount
+		}
+		input.Status = model.SubscriberStatusPending
+		input.ConsentAt = now
+		return input, ""
+	}
+
+	input.Status = row.Status
+	if input.Status == "" {
+		input.Status = model.SubscriberStatusConfirmed
+	}
+	input.ConsentAt = row.CreatedAt
+	if input.ConsentAt.IsZero() {
+		input.ConsentAt = now
+	}
+	input.ConfirmedAt = row.ConfirmedAt
+	if input.Status == model.SubscriberStatusConfirmed && input.ConfirmedAt.IsZero() {
+		input.ConfirmedAt = now
+	}
+	input.UnsubscribedAt = row.UnsubscribedAt
+	if input.Status == model.SubscriberStatusUnsubscribed && input.UnsubscribedAt.IsZero() {
+		input.UnsubscribedAt = now
+	}
+	return input, ""
+}
+
+func parseSubscriberImportMode(rawMode string) (string, error) {
+	normalized := strings.ToLower(strings.TrimSpace(rawMode))
+	switch normalized {
+	case "", subscriberImportModeConfirm:
+		return subscriberImportModeConfirm, nil
+	case subscriberImportModeConsented:
+		return subscriberImportModeConsented, nil
+	default:
+		return "", fmt.Errorf("unsupported import mode: %s", normalized)
+	}
+}
+
+func subscriberImportSource(context *gin.Context) (io.ReadCloser, error) {
+	if strings.HasPrefix(strings.ToLower(context.ContentType()), "multipart/") {
+		fileHeader, fileErr := context.FormFile(subscriberImportFormFieldFile)
+		if fileErr != nil {
+			return nil, fileErr
+		}
+		return fileHeader.Open()
+	}
+	if context.Request.Body == nil {
+		return nil, errors.New("missing import body")
+	}
+	return context.Request.Body, nil
+}
+
+var errSubscriberImportTooLarge = errors.New("subscriber import exceeds size limit")
+
+type countingReader struct {
+	reader    io.Reader
+	bytesRead int64
+}
+
+func (counting *countingReader) Read(buffer []byte) (int, error) {
+	readCount, readErr := counting.reader.Read(buffer)
+	counting.bytesRead += int64(readCount)
+	return readCount, readErr
+}
+
+func parseSubscriberImportCSV(source io.Reader) ([]model.SubscriberImportRow, error) {
+	counting := &countingReader{reader: source}
+	csvReader := csv.NewReader(counting)
+	csvReader.FieldsPerRecord = -1
+	csvReader.TrimLeadingSpace = true
+
+	columnIndexes := defaultSubscriberImportColumnIndexes()
+	var rows []model.SubscriberImportRow
+	lineNumber := 0
+	for {
+		record, readErr := csvReader.Read()
+		if counting.bytesRead > subscriberImportMaxBytes {
+			return nil, errSubscriberImportTooLarge
+		}
+		if errors.Is(readErr, io.EOF) {
+			break
+		}
+		if readErr != nil {
+			return nil, readErr
+		}
+		lineNumber++
+		if lineNumber == 1 && isSubscriberImportHeader(record) {
+			columnIndexes = subscriberImportColumnIndexes(record)
+			continue
+		}
+		if isBlankCSVRecord(record) {
+			continue
+		}
+		rows = append(rows, parseSubscriberImportRow(lineNumber, record, columnIndexes))
+	}
+	return rows, nil
+}
+
+func defaultSubscriberImportColumnIndexes() map[string]int {
+	indexes := make(map[string]int, len(subscriberExportColumns))
+	for index, column := range subscriberExportColumns {
+		indexes[column] = index
+	}
+	return indexes
+}
+
+func isSubscriberImportHeader(record []string) bool {
+	for _, value := range record {
+		if strings.EqualFold(strings.TrimSpace(value), subscriberImportColumnEmail) {
+			return true
+		}
+	}
+	return false
+}
+
+func subscriberImportColumnIndexes(header []string) map[string]int {
+	indexes := make(map[string]int, len(header))
+	for index, value := range header {
+		normalized := strings.ToLower(strings.TrimSpace(value))
+		if normalized == "" {
+			continue
+		}
+		if _, exists := indexes[normalized]; !exists {
+			indexes[normalized] = index
+		}
+	}
+	return indexes
+}
+
+func isBlankCSVRecord(record []string) bool {
+	for _, value := range record {
+		if strings.TrimSpace(value) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func parseSubscriberImportRow(lineNumber int, record []string, columnIndexes map[string]int) model.SubscriberImportRow {
+	column := func(name string) string {
+		index, exists := columnIndexes[name]
+		if !exists || index >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[index])
+	}
+
+	row := model.SubscriberImportRow{
+		Line:   lineNumber,
+		Email:  strings.ToLower(column(subscriberImportColumnEmail)),
+		Name:   column(subscriberImportColumnName),
+		Status: strings.ToLower(column(subscriberImportColumnStatus)),
+	}
+
+	timestamps := []struct {
+		column string
+		target *time.Time
+	}{
+		{subscriberImportColumnCreatedAt, &row.CreatedAt},
+		{subscriberImportColumnConfirmedAt, &row.ConfirmedAt},
+		{subscriberImportColumnUnsubAt, &row.UnsubscribedAt},
+	}
+	for _, timestamp := range timestamps {
+		parsed, parseErr := parseSubscriberImportTimestamp(column(timestamp.column))
+		if parseErr != nil {
+			row.ParseError = errorValueInvalidTimestamp
+			return row
+		}
+		*timestamp.target = parsed
+	}
+	return row
+}
+
+func parseSubscriberImportTimestamp(rawValue string) (time.Time, error) {
+	if rawValue == "" {
+		return time.Time{}, nil
+	}
+	seconds, parseErr := strconv.ParseInt(rawValue, 10, 64)
+	if parseErr == nil {
+		if seconds <= 0 {
+			return time.Time{}, nil
+		}
+		return time.Unix(seconds, 0).UTC(), nil
+	}
+	parsed, rfcErr := time.Parse(time.RFC3339, rawValue)
+	if rfcErr != nil {
+		return time.Time{}, rfcErr
+	}
+	return parsed.UTC(), nil
+}
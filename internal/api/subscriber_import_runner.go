@@ -0,0 +1,286 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"github.com/MarkoPoloResearchLab/loopaware/internal/emailtemplate"
+	"github.com/MarkoPoloResearchLab/loopaware/internal/model"
+)
+
+const (
+	subscriberImportRetention  = 24 * time.Hour
+	subscriberImportStaleAfter = 2 * time.Minute
+)
+
+type SubscriberImportRunnerOption func(*SubscriberImportRunner)
+
+// SubscriberImportRunner processes stored subscriber imports. Progress is saved after every row, so an import
+// interrupted by a restart resumes from its next unprocessed row on whichever replica runs the job next.
+type SubscriberImportRunner struct {
+	database       *gorm.DB
+	logger         *zap.Logger
+	emailSender    EmailSender
+	emailTemplates *emailtemplate.Renderer
+	publicBaseURL  string
+	tokenSecret    string
+	tokenTTL       time.Duration
+	now            func() time.Time
+	trigger        func()
+}
+
+// NewSubscriberImportRunner constructs a runner that sends confirm-mode emails through emailSender. The job runner
+// calls RunPending on its schedule; trigger asks it for an immediate pass.
+func NewSubscriberImportRunner(database *gorm.DB, logger *zap.Logger, emailSender EmailSender, publicBaseURL string, tokenSecret string, trigger func(), options ...SubscriberImportRunnerOption) *SubscriberImportRunner {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	if trigger == nil {
+		trigger = func() {}
+	}
+	runner := &SubscriberImportRunner{
+		database:      database,
+		logger:        logger,
+		emailSender:   emailSender,
+		publicBaseURL: strings.TrimSpace(publicBaseURL),
+		tokenSecret:   strings.TrimSpace(tokenSecret),
+		tokenTTL:      defaultSubscriptionConfirmationTokenTTL,
+		now:           time.Now,
+		trigger:       trigger,
+	}
+	for _, option := range options {
+		if option != nil {
+			option(runner)
+		}
+	}
+	return runner
+}
+
+// WithSubscriberImportTemplates renders confirmation emails through the provided site template renderer.
+func WithSubscriberImportTemplates(renderer *emailtemplate.Renderer) SubscriberImportRunnerOption {
+	return func(runner *SubscriberImportRunner) {
+		runner.emailTemplates = renderer
+	}
+}
+
+// WithSubscriberImportClock overrides the runner clock.
+func WithSubscriberImportClock(clock func() time.Time) SubscriberImportRunnerOption {
+	return func(runner *SubscriberImportRunner) {
+		if clock != nil {
+			runner.now = clock
+		}
+	}
+}
+
+// Trigger requests an immediate pass over queued imports.
+func (runner *SubscriberImportRunner) Trigger() {
+	if runner == nil {
+		return
+	}
+	runner.trigger()
+}
+
+// RunPending processes queued imports, resumes imports abandoned by a stopped replica, and prunes finished imports
+// older than the retention window.
+func (runner *SubscriberImportRunner) RunPending(ctx context.Context) error {
+	now := runner.now().UTC()
+	pruneErr := runner.database.WithContext(ctx).
+		Where("status IN ? AND completed_at < ?", []string{model.SubscriberImportStatusCompleted, model.SubscriberImportStatusFailed}, now.Add(-subscriberImportRetention)).
+		Delete(&model.SubscriberImport{}).Error
+	if pruneErr != nil {
+		return fmt.Errorf("prune subscriber imports: %w", pruneErr)
+	}
+
+	var importIDs []string
+	findErr := runner.database.WithContext(ctx).
+		Model(&model.SubscriberImport{}).
+		Where("status = ? OR (status = ? AND updated_at < ?)", model.SubscriberImportStatusQueued, model.SubscriberImportStatusRunning, now.Add(-subscriberImportStaleAfter)).
+		Order("created_at asc").
+		Pluck("id", &importIDs).Error
+	if findErr != nil {
+		return fmt.Errorf("load pending subscriber imports: %w", findErr)
+	}
+
+	for _, importID := range importIDs {
+		if err := runner.resume(ctx, importID); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			runner.logger.Warn("subscriber_import_failed", zap.Error(err), zap.String("import_id", importID))
+		}
+	}
+	return nil
+}
+
+func (runner *SubscriberImportRunner) resume(ctx context.Context, importID string) error {
+	now := runner.now().UTC()
+	claimResult := runner.database.WithContext(ctx).
+		Model(&model.SubscriberImport{}).
+		Where("id = ? AND (status = ? OR (status = ? AND updated_at < ?))", importID, model.SubscriberImportStatusQueued, model.SubscriberImportStatusRunning, now.Add(-subscriberImportStaleAfter)).
+		Updates(map[string]any{
+			"status":     model.SubscriberImportStatusRunning,
+			"updated_at": now,
+		})
+	if claimResult.Error != nil {
+		return fmt.Errorf("claim subscriber import: %w", claimResult.Error)
+	}
+	if claimResult.RowsAffected == 0 {
+		return nil
+	}
+
+	var subscriberImport model.SubscriberImport
+	if err := runner.database.WithContext(ctx).First(&subscriberImport, "id = ?", importID).Error; err != nil {
+		return fmt.Errorf("load subscriber import: %w", err)
+	}
+	return runner.Run(ctx, &subscriberImport)
+}
+
+// Run imports the remaining rows of a claimed import, saving progress after each row. A cancelled context stops the
+// import between rows and leaves it running so the job resumes it later.
+func (runner *SubscriberImportRunner) Run(ctx context.Context, subscriberImport *model.SubscriberImport) error {
+	var site model.Site
+	if err := runner.database.WithContext(ctx).First(&site, "id = ?", subscriberImport.SiteID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return runner.finish(ctx, subscriberImport, model.SubscriberImportStatusFailed)
+		}
+		return fmt.Errorf("load import site: %w", err)
+	}
+
+	existingEmails, loadErr := loadSubscriberEmails(ctx, runner.database, site.ID)
+	if loadErr != nil {
+		return fmt.Errorf("load existing subscribers: %w", loadErr)
+	}
+
+	for subscriberImport.ProcessedRows < len(subscriberImport.Rows) {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		// The current row always completes, including its confirmation email, so a shutdown never leaves a
+		// subscriber created without its progress recorded.
+		rowContext := context.WithoutCancel(ctx)
+		row := subscriberImport.Rows[subscriberImport.ProcessedRows]
+		subscriber, imported, rowErr := runner.importRow(rowContext, site, subscriberImport, row, existingEmails)
+		if rowErr != nil {
+			return rowErr
+		}
+		if imported && subscriberImport.Mode == subscriberImportModeConfirm {
+			if sendSubscriptionConfirmationEmail(rowContext, runner.logger, nil, runner.emailSender, runner.emailTemplates, runner.publicBaseURL, runner.tokenSecret, runner.tokenTTL, site, subscriber) {
+				markSubscriptionConfirmationSent(rowContext, runner.database, runner.logger, subscriber.ID, runner.now().UTC())
+			}
+		}
+	}
+
+	return runner.finish(ctx, subscriberImport, model.SubscriberImportStatusCompleted)
+}
+
+func (runner *SubscriberImportRunner) importRow(ctx context.Context, site model.Site, subscriberImport *model.SubscriberImport, row model.SubscriberImportRow, existingEmails map[string]struct{}) (model.Subscriber, bool, error) {
+	var subscriber model.Subscriber
+	var rowError string
+	transactionErr := runner.database.WithContext(ctx).Transaction(func(transaction *gorm.DB) error {
+		subscriber, rowError = createImportedSubscriber(ctx, transaction, runner.logger, site, subscriberImport.Mode, row, existingEmails, runner.now().UTC())
+
+		progress := map[string]any{
+			"processed_rows": subscriberImport.ProcessedRows + 1,
+			"updated_at":     runner.now().UTC(),
+		}
+		errorsAfterRow := subscriberImport.Errors
+		if rowError == "" {
+			progress["imported_count"] = subscriberImport.ImportedCount + 1
+		} else {
+			errorsAfterRow = append(append([]model.SubscriberImportRowError{}, subscriberImport.Errors...), model.SubscriberImportRowError{Row: row.Line, Email: row.Email, Error: rowError})
+			encodedErrors, encodeErr := json.Marshal(errorsAfterRow)
+			if encodeErr != nil {
+				return encodeErr
+			}
+			progress["skipped_count"] = subscriberImport.SkippedCount + 1
+			progress["errors"] = string(encodedErrors)
+		}
+		if err := transaction.Model(&model.SubscriberImport{}).Where("id = ?", subscriberImport.ID).Updates(progress).Error; err != nil {
+			return err
+		}
+		subscriberImport.Errors = errorsAfterRow
+		return nil
+	})
+	if transactionErr != nil {
+		return model.Subscriber{}, false, fmt.Errorf("save subscriber import progress: %w", transactionErr)
+	}
+
+	subscriberImport.ProcessedRows++
+	if rowError != "" {
+		subscriberImport.SkippedCount++
+		return model.Subscriber{}, false, nil
+	}
+	subscriberImport.ImportedCount++
+	existingEmails[subscriber.Email] = struct{}{}
+	return subscriber, true, nil
+}
+
+func (runner *SubscriberImportRunner) finish(ctx context.Context, subscriberImport *model.SubscriberImport, status string) error {
+	completedAt := runner.now().UTC()
+	updateErr := runner.database.WithContext(context.WithoutCancel(ctx)).
+		Model(&model.SubscriberImport{}).
+		Where("id = ?", subscriberImport.ID).
+		Updates(map[string]any{
+			"status":       status,
+			"completed_at": completedAt,
+			"updated_at":   completedAt,
+		}).Error
+	if updateErr != nil {
+		return fmt.Errorf("finish subscriber import: %w", updateErr)
+	}
+	subscriberImport.Status = status
+	subscriberImport.CompletedAt = completedAt
+	return nil
+}
+
+func createImportedSubscriber(ctx context.Context, database *gorm.DB, logger *zap.Logger, site model.Site, mode string, row model.SubscriberImportRow, existingEmails map[string]struct{}, now time.Time) (model.Subscriber, string) {
+	if row.ParseError != "" {
+		return model.Subscriber{}, row.ParseError
+	}
+
+	input, inputErr := subscriberImportInput(site.ID, mode, row, now)
+	if inputErr != "" {
+		return model.Subscriber{}, inputErr
+	}
+
+	subscriber, subscriberErr := model.NewSubscriber(input)
+	if subscriberErr != nil {
+		if errors.Is(subscriberErr, model.ErrInvalidSubscriberStatus) {
+			return model.Subscriber{}, errorValueInvalidSubscriberStatus
+		}
+		return model.Subscriber{}, errorValueInvalidEmail
+	}
+
+	if _, duplicate := existingEmails[subscriber.Email]; duplicate {
+		return model.Subscriber{}, errorValueDuplicateSubscriber
+	}
+
+	if createErr := database.WithContext(ctx).Create(&subscriber).Error; createErr != nil {
+		if _, findErr := findSubscriber(ctx, database, site.ID, subscriber.Email); findErr == nil {
+			return model.Subscriber{}, errorValueDuplicateSubscriber
+		}
+		requestLogger(ctx, logger).Warn("subscriber_import_save", zap.Error(createErr), zap.String("site_id", site.ID))
+		return model.Subscriber{}, errorValueSaveSubscriberFailed
+	}
+
+	return subscriber, ""
+}
+
+func loadSubscriberEmails(ctx context.Context, database *gorm.DB, siteID string) (map[string]struct{}, error) {
+	var emails []string
+	if err := database.WithContext(ctx).Model(&model.Subscriber{}).Where("site_id = ?", siteID).Pluck("email", &emails).Error; err != nil {
+		return nil, err
+	}
+	existing := make(map[string]struct{}, len(emails))
+	for _, email := range emails {
+		existing[strings.ToLower(strings.TrimSpace(email))] = struct{}{}
+	}
+	return existing, nil
+}
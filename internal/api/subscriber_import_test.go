@@ -0,0 +1,304 @@
+package api_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"github.com/MarkoPoloResearchLab/loopaware/internal/api"
+	"github.com/MarkoPoloResearchLab/loopaware/internal/model"
+)
+
+const (
+	testImportBaseURL      = "https://loopaware.example.com"
+	testImportTokenSecret  = "import-secret"
+	testImportRoute        = "/api/sites/:id/subscribers/import"
+	testImportStatusRoute  = "/api/sites/:id/subscribers/import/:job_id"
+	testImportExistingUser = "existing@example.com"
+)
+
+type subscriberImportHarness struct {
+	router   *gin.Engine
+	database *gorm.DB
+	site     model.Site
+	sender   *recordingEmailSender
+	runner   *api.SubscriberImportRunner
+	triggers *atomic.Int32
+}
+
+func newSubscriberImportHarness(testingT *testing.T, currentUser *api.CurrentUser) subscriberImportHarness {
+	testingT.Helper()
+
+	database := newSiteTestHarness(testingT).database
+
+	site := insertSite(testingT, database, "Import Site", "http://example.com", testAdminEmailAddress)
+
+	existingSubscriber, subscriberErr := model.NewSubscriber(model.SubscriberInput{SiteID: site.ID, Email: testImportExistingUser})
+	require.NoError(testingT, subscriberErr)
+	require.NoError(testingT, database.Create(&existingSubscriber).Error)
+
+	sender := &recordingEmailSender{testingT: testingT}
+	triggers := &atomic.Int32{}
+	runner := api.NewSubscriberImportRunner(database, zap.NewNop(), sender, testImportBaseURL, testImportTokenSecret, func() { triggers.Add(1) })
+	handlers := api.NewSubscriberImportHandlers(database, zap.NewNop(), runner)
+
+	router := newAuthenticatedRouter(currentUser)
+	router.POST(testImportRoute, handlers.ImportSubscribers)
+	router.GET(testImportStatusRoute, handlers.SubscriberImportStatus)
+
+	return subscriberImportHarness{router: router, database: database, site: site, sender: sender, runner: runner, triggers: triggers}
+}
+
+func (harness subscriberImportHarness) status(testingT *testing.T, jobID string) (int, api.SubscriberImportReport) {
+	testingT.Helper()
+	request := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/sites/%s/subscribers/import/%s", harness.site.ID, jobID), nil)
+	recorder := httptest.NewRecorder()
+	harness.router.ServeHTTP(recorder, request)
+
+	var report api.SubscriberImportReport
+	if recorder.Code == http.StatusOK {
+		require.NoError(testingT, json.Unmarshal(recorder.Body.Bytes(), &report))
+	}
+	return recorder.Code, report
+}
+
+func (harness subscriberImportHarness) postCSV(testingT *testing.T, mode string, body string) (int, api.SubscriberImportReport) {
+	testingT.Helper()
+	request := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/api/sites/%s/subscribers/import?mode=%s", harness.site.ID, mode), strings.NewReader(body))
+	request.Header.Set("Content-Type", "text/csv")
+	recorder := httptest.NewRecorder()
+	harness.router.ServeHTTP(recorder, request)
+
+	var report api.SubscriberImportReport
+	if recorder.Code == http.StatusOK || recorder.Code == http.StatusAccepted {
+		require.NoError(testingT, json.Unmarshal(recorder.Body.Bytes(), &report))
+	}
+	return recorder.Code, report
+}
+
+func TestImportSubscribersConsentedModeReportsRowErrors(testingT *testing.T) {
+	harness := newSubscriberImportHarness(testingT, &api.CurrentUser{Email: testAdminEmailAddress, Role: api.RoleAdmin})
+
+	csvBody := strings.Join([]string{
+		"email,name,status,created_at,confirmed_at,unsubscribed_at",
+		"alpha@example.com,Alpha,confirmed,1700000000,1700000100,-62135596800",
+		"not-an-email,Broken,,,,",
+		"ALPHA@example.com,Alpha Again,,,,",
+		testImportExistingUser + ",Existing,,,,",
+		"beta@example.com,Beta,unsubscribed,1700000000,,1700000200",
+		"gamma@example.com,Gamma,,,,",
+	}, "\n")
+
+	statusCode, report := harness.postCSV(testingT, "consented", csvBody)
+	require.Equal(testingT, http.StatusOK, statusCode)
+	require.Equal(testingT, "completed", report.Status)
+	require.Equal(testingT, 6, report.TotalRows)
+	require.Equal(testingT, 6, report.ProcessedRows)
+	require.Equal(testingT, 3, report.ImportedCount)
+	require.Equal(testingT, 3, report.SkippedCount)
+	require.Equal(testingT, []api.SubscriberImportRowError{
+		{Row: 3, Email: "not-an-email", Error: "invalid_email"},
+		{Row: 4, Email: "alpha@example.com", Error: "duplicate_subscription"},
+		{Row: 5, Email: testImportExistingUser, Error: "duplicate_subscription"},
+	}, report.Errors)
+	require.Zero(testingT, harness.sender.CallCount())
+
+	var alpha model.Subscriber
+	require.NoError(testingT, harness.database.First(&alpha, "site_id = ? AND email = ?", harness.site.ID, "alpha@example.com").Error)
+	require.Equal(testingT, model.SubscriberStatusConfirmed, alpha.Status)
+	require.Equal(testingT, time.Unix(1700000100, 0).UTC(), alpha.ConfirmedAt.UTC())
+	require.True(testingT, alpha.UnsubscribedAt.IsZero())
+
+	var beta model.Subscriber
+	require.NoError(testingT, harness.database.First(&beta, "site_id = ? AND email = ?", harness.site.ID, "beta@example.com").Error)
+	require.Equal(testingT, model.SubscriberStatusUnsubscribed, beta.Status)
+
+	var gamma model.Subscriber
+	require.NoError(testingT, harness.database.First(&gamma, "site_id = ? AND email = ?", harness.site.ID, "gamma@example.com").Error)
+	require.Equal(testingT, model.SubscriberStatusConfirmed, gamma.Status)
+	require.False(testingT, gamma.ConfirmedAt.IsZero())
+}
+
+func TestImportSubscribersConfirmModeSendsConfirmationEmails(testingT *testing.T) {
+	harness := newSubscriberImportHarness(testingT, &api.CurrentUser{Email: testAdminEmailAddress, Role: api.RoleAdmin})
+
+	csvBody := strings.Join([]string{
+		"pending@example.com,Pending,confirmed,,,",
+		"gone@example.com,Gone,unsubscribed,,,",
+	}, "\n")
+
+	statusCode, report := harness.postCSV(testingT, "confirm", csvBody)
+	require.Equal(testingT, http.StatusOK, statusCode)
+	require.Equal(testingT, 1, report.ImportedCount)
+	require.Equal(testingT, []api.SubscriberImportRowError{{Row: 2, Email: "gone@example.com", Error: "unsubscribed"}}, report.Errors)
+
+	var pending model.Subscriber
+	require.NoError(testingT, harness.database.First(&pending, "site_id = ? AND email = ?", harness.site.ID, "pending@example.com").Error)
+	require.Equal(testingT, model.SubscriberStatusPending, pending.Status)
+
+	require.Equal(testingT, 1, harness.sender.CallCount())
+	call := harness.sender.LastCall()
+	require.Equal(testingT, "pending@example.com", call.Recipient)
+	require.Contains(testingT, call.Message, testImportBaseURL)
+}
+
+func TestImportSubscribersAcceptsMultipartUpload(testingT *testing.T) {
+	harness := newSubscriberImportHarness(testingT, &api.CurrentUser{Email: testAdminEmailAddress, Role: api.RoleAdmin})
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	fileWriter, createErr := writer.CreateFormFile("file", "subscribers.csv")
+	require.NoError(testingT, createErr)
+	_, writeErr := fileWriter.Write([]byte("email\nupload@example.com\n"))
+	require.NoError(testingT, writeErr)
+	require.NoError(testingT, writer.Close())
+
+	request := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/api/sites/%s/subscribers/import?mode=consented", harness.site.ID), &body)
+	request.Header.Set("Content-Type", writer.FormDataContentType())
+	recorder := httptest.NewRecorder()
+	harness.router.ServeHTTP(recorder, request)
+	require.Equal(testingT, http.StatusOK, recorder.Code)
+
+	var report api.SubscriberImportReport
+	require.NoError(testingT, json.Unmarshal(recorder.Body.Bytes(), &report))
+	require.Equal(testingT, 1, report.ImportedCount)
+}
+
+func TestImportSubscribersRunsLargeFilesInBackground(testingT *testing.T) {
+	harness := newSubscriberImportHarness(testingT, &api.CurrentUser{Email: testAdminEmailAddress, Role: api.RoleAdmin})
+
+	rowCount := 250
+	lines := make([]string, 0, rowCount)
+	for index := 0; index < rowCount; index++ {
+		lines = append(lines, fmt.Sprintf("bulk-%03d@example.com", index))
+	}
+
+	statusCode, report := harness.postCSV(testingT, "consented", strings.Join(lines, "\n"))
+	require.Equal(testingT, http.StatusAccepted, statusCode)
+	require.NotEmpty(testingT, report.JobID)
+	require.Equal(testingT, rowCount, report.TotalRows)
+
+	require.Equal(testingT, "queued", report.Status)
+	require.Equal(testingT, int32(1), harness.triggers.Load())
+
+	statusCode, queuedReport := harness.status(testingT, report.JobID)
+	require.Equal(testingT, http.StatusOK, statusCode)
+	require.Equal(testingT, "queued", queuedReport.Status)
+	require.Zero(testingT, queuedReport.ProcessedRows)
+
+	require.NoError(testingT, harness.runner.RunPending(context.Background()))
+
+	statusCode, finalReport := harness.status(testingT, report.JobID)
+	require.Equal(testingT, http.StatusOK, statusCode)
+	require.Equal(testingT, "completed", finalReport.Status)
+	require.NotZero(testingT, finalReport.CompletedAt)
+	require.Equal(testingT, rowCount, finalReport.ProcessedRows)
+	require.Equal(testingT, rowCount, finalReport.ImportedCount)
+
+	var storedCount int64
+	require.NoError(testingT, harness.database.Model(&model.Subscriber{}).Where("site_id = ?", harness.site.ID).Count(&storedCount).Error)
+	require.Equal(testingT, int64(rowCount+1), storedCount)
+}
+
+func TestImportSubscribersRejectsInvalidRequests(testingT *testing.T) {
+	harness := newSubscriberImportHarness(testingT, &api.CurrentUser{Email: testAdminEmailAddress, Role: api.RoleAdmin})
+
+	statusCode, _ := harness.postCSV(testingT, "bogus", "a@example.com")
+	require.Equal(testingT, http.StatusBadRequest, statusCode)
+
+	statusCode, _ = harness.postCSV(testingT, "consented", "email,name\n")
+	require.Equal(testingT, http.StatusBadRequest, statusCode)
+
+	statusCode, _ = harness.postCSV(testingT, "consented", "\"unterminated,row\n")
+	require.Equal(testingT, http.StatusBadRequest, statusCode)
+
+	statusCode, _ = harness.status(testingT, "missing-job")
+	require.Equal(testingT, http.StatusNotFound, statusCode)
+}
+
+func TestSubscriberImportRunnerResumesInterruptedImport(testingT *testing.T) {
+	harness := newSubscriberImportHarness(testingT, &api.CurrentUser{Email: testAdminEmailAddress, Role: api.RoleAdmin})
+
+	rows := []model.SubscriberImportRow{
+		{Line: 1, Email: "first@example.com"},
+		{Line: 2, Email: "second@example.com"},
+		{Line: 3, Email: "third@example.com"},
+	}
+	subscriberImport, importErr := model.NewSubscriberImport(harness.site.ID, "confirm", model.SubscriberImportStatusRunning, rows, time.Now().Add(-time.Hour))
+	require.NoError(testingT, importErr)
+	subscriberImport.ProcessedRows = 1
+	subscriberImport.ImportedCount = 1
+	require.NoError(testingT, harness.database.Create(&subscriberImport).Error)
+
+	require.NoError(testingT, harness.runner.RunPending(context.Background()))
+	statusCode, report := harness.status(testingT, subscriberImport.ID)
+	require.Equal(testingT, http.StatusOK, statusCode)
+	require.Equal(testingT, "running", report.Status, "an import updated moments ago belongs to another runner")
+	require.Zero(testingT, harness.sender.CallCount())
+
+	require.NoError(testingT, harness.database.Model(&model.SubscriberImport{}).Where("id = ?", subscriberImport.ID).UpdateColumn("updated_at", time.Now().Add(-10*time.Minute)).Error)
+	require.NoError(testingT, harness.runner.RunPending(context.Background()))
+
+	statusCode, report = harness.status(testingT, subscriberImport.ID)
+	require.Equal(testingT, http.StatusOK, statusCode)
+	require.Equal(testingT, "completed", report.Status)
+	require.Equal(testingT, 3, report.ProcessedRows)
+	require.Equal(testingT, 3, report.ImportedCount)
+	require.Equal(testingT, 2, harness.sender.CallCount())
+
+	var firstCount int64
+	require.NoError(testingT, harness.database.Model(&model.Subscriber{}).Where("site_id = ? AND email = ?", harness.site.ID, "first@example.com").Count(&firstCount).Error)
+	require.Zero(testingT, firstCount)
+}
+
+func TestSubscriberImportRunnerStopsBetweenRowsWhenCancelled(testingT *testing.T) {
+	harness := newSubscriberImportHarness(testingT, &api.CurrentUser{Email: testAdminEmailAddress, Role: api.RoleAdmin})
+
+	rows := []model.SubscriberImportRow{{Line: 1, Email: "first@example.com"}, {Line: 2, Email: "second@example.com"}}
+	subscriberImport, importErr := model.NewSubscriberImport(harness.site.ID, "consented", model.SubscriberImportStatusQueued, rows, time.Now())
+	require.NoError(testingT, importErr)
+	require.NoError(testingT, harness.database.Create(&subscriberImport).Error)
+
+	cancelledContext, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.ErrorIs(testingT, harness.runner.RunPending(cancelledContext), context.Canceled)
+
+	var stored model.SubscriberImport
+	require.NoError(testingT, harness.database.First(&stored, "id = ?", subscriberImport.ID).Error)
+	require.Equal(testingT, model.SubscriberImportStatusQueued, stored.Status)
+	require.Zero(testingT, stored.ProcessedRows)
+}
+
+func TestSubscriberImportRunnerPrunesFinishedImports(testingT *testing.T) {
+	harness := newSubscriberImportHarness(testingT, &api.CurrentUser{Email: testAdminEmailAddress, Role: api.RoleAdmin})
+
+	rows := []model.SubscriberImportRow{{Line: 1, Email: "old@example.com"}}
+	oldImport, importErr := model.NewSubscriberImport(harness.site.ID, "consented", model.SubscriberImportStatusCompleted, rows, time.Now().Add(-48*time.Hour))
+	require.NoError(testingT, importErr)
+	oldImport.CompletedAt = time.Now().Add(-25 * time.Hour)
+	require.NoError(testingT, harness.database.Create(&oldImport).Error)
+
+	require.NoError(testingT, harness.runner.RunPending(context.Background()))
+	statusCode, _ := harness.status(testingT, oldImport.ID)
+	require.Equal(testingT, http.StatusNotFound, statusCode)
+}
+
+func TestImportSubscribersRequiresSiteManagement(testingT *testing.T) {
+	harness := newSubscriberImportHarness(testingT, &api.CurrentUser{Email: testUserEmailAddress, Role: api.RoleUser})
+
+	statusCode, _ := harness.postCSV(testingT, "consented", "someone@example.com")
+	require.Equal(testingT, http.StatusForbidden, statusCode)
+}
@@ -2,90 +2,143 @@ package api
 
 import (
 	"context"
-	"fmt"
 	"net/url"
 	"strings"
 	"time"
 
 	"go.uber.org/zap"
+	"gorm.io/gorm"
 
+	"github.com/MarkoPoloResearchLab/loopaware/internal/emailtemplate"
+	"github.com/MarkoPoloResearchLab/loopaware/internal/mailer"
 	"github.com/MarkoPoloResearchLab/loopaware/internal/model"
 )
 
+const (
+	subscriptionConfirmPath             = "/subscriptions/confirm"
+	subscriptionUnsubscribePath         = "/subscriptions/unsubscribe"
+	subscriptionOneClickUnsubscribePath = "/public/subscriptions/unsubscribe-link"
+)
+
 type subscriptionTestEventRecorder func(site model.Site, subscriber model.Subscriber, eventType, status, message string)
 
-func sendSubscriptionConfirmationEmail(ctx context.Context, logger *zap.Logger, recordEvent subscriptionTestEventRecorder, emailSender EmailSender, publicBaseURL string, tokenSecret string, tokenTTL time.Duration, site model.Site, subscriber model.Subscriber) {
+func sendSubscriptionConfirmationEmail(ctx context.Context, logger *zap.Logger, recordEvent subscriptionTestEventRecorder, emailSender EmailSender, emailTemplates *emailtemplate.Renderer, publicBaseURL string, tokenSecret string, tokenTTL time.Duration, site model.Site, subscriber model.Subscriber) bool {
 	if emailSender == nil {
 		if recordEvent != nil {
 			recordEvent(site, subscriber, subscriptionEventTypeConfirmation, subscriptionEventStatusSkipped, "email sender unavailable")
 		}
-		return
+		return false
 	}
 	if strings.TrimSpace(publicBaseURL) == "" || strings.TrimSpace(tokenSecret) == "" {
 		if recordEvent != nil {
 			recordEvent(site, subscriber, subscriptionEventTypeConfirmation, subscriptionEventStatusSkipped, "confirmation email not configured")
 		}
-		return
+		return false
 	}
 	if subscriber.Status != model.SubscriberStatusPending {
 		if recordEvent != nil {
 			recordEvent(site, subscriber, subscriptionEventTypeConfirmation, subscriptionEventStatusSkipped, "subscriber not pending")
 		}
-		return
+		return false
 	}
 	if strings.TrimSpace(subscriber.ID) == "" || strings.TrimSpace(subscriber.SiteID) == "" || strings.TrimSpace(subscriber.Email) == "" {
 		if recordEvent != nil {
 			recordEvent(site, subscriber, subscriptionEventTypeConfirmation, subscriptionEventStatusSkipped, "subscriber missing fields")
 		}
-		return
+		return false
 	}
 
 	token, tokenErr := buildSubscriptionConfirmationToken(tokenSecret, subscriber.ID, subscriber.SiteID, subscriber.Email, time.Now().UTC(), tokenTTL)
 	if tokenErr != nil {
 		if logger != nil {
-			logger.Warn("subscription_confirmation_token_failed", zap.Error(tokenErr), zap.String("site_id", site.ID), zap.String("subscriber_id", subscriber.ID))
+			requestLogger(ctx, logger).Warn("subscription_confirmation_token_failed", zap.Error(tokenErr), zap.String("site_id", site.ID), zap.String("subscriber_id", subscriber.ID))
 		}
 		if recordEvent != nil {
 			recordEvent(site, subscriber, subscriptionEventTypeConfirmation, subscriptionEventStatusError, "confirmation token failed")
 		}
-		return
+		return false
 	}
 
-	confirmationURL, urlErr := url.Parse(strings.TrimRight(strings.TrimSpace(publicBaseURL), "/") + "/subscriptions/confirm")
+	baseURL := strings.TrimRight(strings.TrimSpace(publicBaseURL), "/")
+	confirmationURL, urlErr := buildSubscriptionTokenURL(baseURL, subscriptionConfirmPath, token)
 	if urlErr != nil {
 		if logger != nil {
-			logger.Warn("subscription_confirmation_url_failed", zap.Error(urlErr), zap.String("site_id", site.ID), zap.String("subscriber_id", subscriber.ID))
+			requestLogger(ctx, logger).Warn("subscription_confirmation_url_failed", zap.Error(urlErr), zap.String("site_id", site.ID), zap.String("subscriber_id", subscriber.ID))
 		}
 		if recordEvent != nil {
 			recordEvent(site, subscriber, subscriptionEventTypeConfirmation, subscriptionEventStatusError, "confirmation url failed")
 		}
-		return
+		return false
 	}
-	query := confirmationURL.Query()
-	query.Set("token", token)
-	confirmationURL.RawQuery = query.Encode()
+	unsubscribeURL, _ := buildSubscriptionTokenURL(baseURL, subscriptionUnsubscribePath, token)
+	oneClickUnsubscribeURL, _ := buildSubscriptionTokenURL(baseURL, subscriptionOneClickUnsubscribePath, token)
 
 	siteName := strings.TrimSpace(site.Name)
 	if siteName == "" {
 		siteName = "LoopAware"
 	}
-	subject := fmt.Sprintf("Confirm your subscription to %s", siteName)
-	messageBuilder := &strings.Builder{}
-	_, _ = fmt.Fprintf(messageBuilder, "Thanks for subscribing to %s.\n\n", siteName)
-	_, _ = fmt.Fprintf(messageBuilder, "Confirm your subscription:\n%s\n\n", confirmationURL.String())
-	_, _ = fmt.Fprintf(messageBuilder, "If you did not request this, you can ignore this email.\n")
+	message, renderErr := emailTemplates.Render(ctx, site.ID, model.EmailTemplateKindSubscriptionConfirmation, subscriber.Locale, emailtemplate.Data{
+		SiteName:        siteName,
+		ConfirmationURL: confirmationURL,
+		UnsubscribeURL:  unsubscribeURL,
+		SubscriberEmail: subscriber.Email,
+		SubscriberName:  subscriber.Name,
+	})
+	if renderErr != nil {
+		if logger != nil {
+			requestLogger(ctx, logger).Warn("subscription_confirmation_template_failed", zap.Error(renderErr), zap.String("site_id", site.ID), zap.String("subscriber_id", subscriber.ID))
+		}
+		if recordEvent != nil {
+			recordEvent(site, subscriber, subscriptionEventTypeConfirmation, subscriptionEventStatusError, "confirmation template failed")
+		}
+		return false
+	}
 
-	sendErr := emailSender.SendEmail(ctx, subscriber.Email, subject, messageBuilder.String())
+	sendErr := sendEmailMessage(ctx, emailSender, mailer.Message{
+		Recipient:      subscriber.Email,
+		Subject:        message.Subject,
+		TextBody:       message.Text,
+		HTMLBody:       message.HTML,
+		UnsubscribeURL: oneClickUnsubscribeURL,
+	})
 	if sendErr != nil {
 		if logger != nil {
-			logger.Warn("subscription_confirmation_email_failed", zap.Error(sendErr), zap.String("site_id", site.ID), zap.String("subscriber_id", subscriber.ID))
+			requestLogger(ctx, logger).Warn("subscription_confirmation_email_failed", zap.Error(sendErr), zap.String("site_id", site.ID), zap.String("subscriber_id", subscriber.ID))
 		}
 		if recordEvent != nil {
 			recordEvent(site, subscriber, subscriptionEventTypeConfirmation, subscriptionEventStatusError, "confirmation email failed")
 		}
-		return
+		return false
 	}
 	if recordEvent != nil {
 		recordEvent(site, subscriber, subscriptionEventTypeConfirmation, subscriptionEventStatusSuccess, "")
 	}
+	return true
+}
+
+func markSubscriptionConfirmationSent(ctx context.Context, database *gorm.DB, logger *zap.Logger, subscriberID string, sentAt time.Time) {
+	if database == nil {
+		return
+	}
+	updateErr := database.WithContext(ctx).
+		Model(&model.Subscriber{}).
+		Where("id = ?", subscriberID).
+		Updates(map[string]any{
+			"confirmation_sent_at": sentAt,
+			"reminder_sent_at":     time.Time{},
+		}).Error
+	if updateErr != nil && logger != nil {
+		requestLogger(ctx, logger).Warn("subscription_confirmation_mark_failed", zap.Error(updateErr), zap.String("subscriber_id", subscriberID))
+	}
+}
+
+func buildSubscriptionTokenURL(baseURL string, path string, token string) (string, error) {
+	tokenURL, parseErr := url.Parse(baseURL + path)
+	if parseErr != nil {
+		return "", parseErr
+	}
+	query := tokenURL.Query()
+	query.Set("token", token)
+	tokenURL.RawQuery = query.Encode()
+	return tokenURL.String(), nil
 }
@@ -46,7 +46,7 @@ func TestSendSubscriptionConfirmationEmailSkipsWithoutSender(testingT *testing.T
 	recordEvent := func(_ model.Site, _ model.Subscriber, eventType string, status string, message string) {
 		records = append(records, eventRecord{eventType: eventType, status: status, message: message})
 	}
-	sendSubscriptionConfirmationEmail(context.Background(), zap.NewNop(), recordEvent, nil, testConfirmationBaseURL, testConfirmationTokenSecret, time.Hour, model.Site{}, model.Subscriber{})
+	sendSubscriptionConfirmationEmail(context.Background(), zap.NewNop(), recordEvent, nil, nil, testConfirmationBaseURL, testConfirmationTokenSecret, time.Hour, model.Site{}, model.Subscriber{})
 	require.Len(testingT, records, 1)
 	require.Equal(testingT, subscriptionEventStatusSkipped, records[0].status)
 	require.Contains(testingT, records[0].message, "email sender unavailable")
@@ -61,7 +61,7 @@ func TestSendSubscriptionConfirmationEmailSkipsWhenConfigMissing(testingT *testi
 	site := model.Site{ID: testConfirmationSiteID, Name: testConfirmationSiteName}
 	subscriber := model.Subscriber{ID: testConfirmationSubscriber, SiteID: testConfirmationSiteID, Email: testConfirmationEmail, Status: model.SubscriberStatusPending}
 
-	sendSubscriptionConfirmationEmail(context.Background(), zap.NewNop(), recordEvent, &stubEmailSender{}, "", testConfirmationTokenSecret, time.Hour, site, subscriber)
+	sendSubscriptionConfirmationEmail(context.Background(), zap.NewNop(), recordEvent, &stubEmailSender{}, nil, "", testConfirmationTokenSecret, time.Hour, site, subscriber)
 	require.Len(testingT, records, 1)
 	require.Equal(testingT, subscriptionEventStatusSkipped, records[0].status)
 	require.Contains(testingT, records[0].message, "confirmation email not configured")
@@ -76,7 +76,7 @@ func TestSendSubscriptionConfirmationEmailSkipsWhenSubscriberNotPending(testingT
 	site := model.Site{ID: testConfirmationSiteID, Name: testConfirmationSiteName}
 	subscriber := model.Subscriber{ID: testConfirmationSubscriber, SiteID: testConfirmationSiteID, Email: testConfirmationEmail, Status: model.SubscriberStatusConfirmed}
 
-	sendSubscriptionConfirmationEmail(context.Background(), zap.NewNop(), recordEvent, &stubEmailSender{}, testConfirmationBaseURL, testConfirmationTokenSecret, time.Hour, site, subscriber)
+	sendSubscriptionConfirmationEmail(context.Background(), zap.NewNop(), recordEvent, &stubEmailSender{}, nil, testConfirmationBaseURL, testConfirmationTokenSecret, time.Hour, site, subscriber)
 	require.Len(testingT, records, 1)
 	require.Equal(testingT, subscriptionEventStatusSkipped, records[0].status)
 	require.Contains(testingT, records[0].message, "subscriber not pending")
@@ -91,7 +91,7 @@ func TestSendSubscriptionConfirmationEmailSkipsWhenSubscriberMissingFields(testi
 	site := model.Site{ID: testConfirmationSiteID, Name: testConfirmationSiteName}
 	subscriber := model.Subscriber{ID: "", SiteID: testConfirmationSiteID, Email: "", Status: model.SubscriberStatusPending}
 
-	sendSubscriptionConfirmationEmail(context.Background(), zap.NewNop(), recordEvent, &stubEmailSender{}, testConfirmationBaseURL, testConfirmationTokenSecret, time.Hour, site, subscriber)
+	sendSubscriptionConfirmationEmail(context.Background(), zap.NewNop(), recordEvent, &stubEmailSender{}, nil, testConfirmationBaseURL, testConfirmationTokenSecret, time.Hour, site, subscriber)
 	require.Len(testingT, records, 1)
 	require.Equal(testingT, subscriptionEventStatusSkipped, records[0].status)
 	require.Contains(testingT, records[0].message, "subscriber missing fields")
@@ -106,7 +106,7 @@ func TestSendSubscriptionConfirmationEmailRecordsTokenError(testingT *testing.T)
 	site := model.Site{ID: testConfirmationSiteID, Name: testConfirmationSiteName}
 	subscriber := model.Subscriber{ID: testConfirmationSubscriber, SiteID: testConfirmationSiteID, Email: testConfirmationEmail, Status: model.SubscriberStatusPending}
 
-	sendSubscriptionConfirmationEmail(context.Background(), zap.NewNop(), recordEvent, &stubEmailSender{}, testConfirmationBaseURL, testConfirmationTokenSecret, 0, site, subscriber)
+	sendSubscriptionConfirmationEmail(context.Background(), zap.NewNop(), recordEvent, &stubEmailSender{}, nil, testConfirmationBaseURL, testConfirmationTokenSecret, 0, site, subscriber)
 	require.Len(testingT, records, 1)
 	require.Equal(testingT, subscriptionEventStatusError, records[0].status)
 	require.Contains(testingT, records[0].message, "confirmation token failed")
@@ -121,7 +121,7 @@ func TestSendSubscriptionConfirmationEmailRecordsURLFailure(testingT *testing.T)
 	site := model.Site{ID: testConfirmationSiteID, Name: testConfirmationSiteName}
 	subscriber := model.Subscriber{ID: testConfirmationSubscriber, SiteID: testConfirmationSiteID, Email: testConfirmationEmail, Status: model.SubscriberStatusPending}
 
-	sendSubscriptionConfirmationEmail(context.Background(), zap.NewNop(), recordEvent, &stubEmailSender{}, "http://[::1", testConfirmationTokenSecret, time.Hour, site, subscriber)
+	sendSubscriptionConfirmationEmail(context.Background(), zap.NewNop(), recordEvent, &stubEmailSender{}, nil, "http://[::1", testConfirmationTokenSecret, time.Hour, site, subscriber)
 	require.Len(testingT, records, 1)
 	require.Equal(testingT, subscriptionEventStatusError, records[0].status)
 	require.Contains(testingT, records[0].message, "confirmation url failed")
@@ -137,7 +137,7 @@ func TestSendSubscriptionConfirmationEmailSendsMessage(testingT *testing.T) {
 	site := model.Site{ID: testConfirmationSiteID, Name: testConfirmationSiteName}
 	subscriber := model.Subscriber{ID: testConfirmationSubscriber, SiteID: testConfirmationSiteID, Email: testConfirmationEmail, Status: model.SubscriberStatusPending}
 
-	sendSubscriptionConfirmationEmail(context.Background(), zap.NewNop(), recordEvent, sender, testConfirmationBaseURL, testConfirmationTokenSecret, time.Hour, site, subscriber)
+	sendSubscriptionConfirmationEmail(context.Background(), zap.NewNop(), recordEvent, sender, nil, testConfirmationBaseURL, testConfirmationTokenSecret, time.Hour, site, subscriber)
 	require.Len(testingT, records, 1)
 	require.Equal(testingT, subscriptionEventStatusSuccess, records[0].status)
 	require.Equal(testingT, testConfirmationEmail, sender.recipient)
@@ -156,7 +156,7 @@ func TestSendSubscriptionConfirmationEmailRecordsSendFailure(testingT *testing.T
 	site := model.Site{ID: testConfirmationSiteID, Name: testConfirmationSiteName}
 	subscriber := model.Subscriber{ID: testConfirmationSubscriber, SiteID: testConfirmationSiteID, Email: testConfirmationEmail, Status: model.SubscriberStatusPending}
 
-	sendSubscriptionConfirmationEmail(context.Background(), zap.NewNop(), recordEvent, sender, testConfirmationBaseURL, testConfirmationTokenSecret, time.Hour, site, subscriber)
+	sendSubscriptionConfirmationEmail(context.Background(), zap.NewNop(), recordEvent, sender, nil, testConfirmationBaseURL, testConfirmationTokenSecret, time.Hour, site, subscriber)
 	require.Len(testingT, records, 1)
 	require.Equal(testingT, subscriptionEventStatusError, records[0].status)
 	require.Contains(testingT, records[0].message, "confirmation email failed")
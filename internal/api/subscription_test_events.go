@@ -54,6 +54,16 @@ func (broadcaster *SubscriptionTestEventBroadcaster) Subscribe() *SubscriptionTe
 	}
 }
 
+// SubscriberCount reports the number of open subscription test event subscriptions.
+func (broadcaster *SubscriptionTestEventBroadcaster) SubscriberCount() int {
+	if broadcaster == nil {
+		return 0
+	}
+	broadcaster.mutex.Lock()
+	defer broadcaster.mutex.Unlock()
+	return len(broadcaster.subscribers)
+}
+
 // Broadcast delivers an event to all active subscribers.
 func (broadcaster *SubscriptionTestEventBroadcaster) Broadcast(event SubscriptionTestEvent) {
 	if broadcaster == nil {
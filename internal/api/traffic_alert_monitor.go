@@ -0,0 +1,364 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"github.com/MarkoPoloResearchLab/loopaware/internal/model"
+	"github.com/MarkoPoloResearchLab/loopaware/pkg/egress"
+)
+
+const (
+	trafficAlertRuleBatchSize       = 100
+	trafficAlertWebhookEvent        = "traffic_alert"
+	trafficAlertWebhookUserAgent    = "LoopAware-TrafficAlert/1.0"
+	trafficAlertWebhookMaxBodyBytes = 4096
+)
+
+type TrafficAlertMonitorOption func(*TrafficAlertMonitor)
+
+// TrafficAlertMonitorResult reports the work done by a single evaluation pass.
+type TrafficAlertMonitorResult struct {
+	Evaluated int
+	Triggered int
+}
+
+// TrafficAlertMonitor evaluates traffic alert rules on a schedule and delivers alerts to the owner and the rule webhook.
+type TrafficAlertMonitor struct {
+	database   *gorm.DB
+	logger     *zap.Logger
+	notifier   TrafficAlertNotifier
+	httpClient *http.Client
+	now        func() time.Time
+}
+
+// NewTrafficAlertMonitor constructs a monitor that notifies site owners through notifier; notifier may be nil. Webhooks
+// go through an egress client that refuses internal addresses unless WithTrafficAlertHTTPClient supplies another.
+func NewTrafficAlertMonitor(database *gorm.DB, logger *zap.Logger, notifier TrafficAlertNotifier, options ...TrafficAlertMonitorOption) *TrafficAlertMonitor {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	monitor := &TrafficAlertMonitor{
+		database:   database,
+		logger:     logger,
+		notifier:   notifier,
+		httpClient: egress.NewClient(egress.WithLogger(logger)),
+		now:        time.Now,
+	}
+	for _, option := range options {
+		if option != nil {
+			option(monitor)
+		}
+	}
+	return monitor
+}
+
+// WithTrafficAlertHTTPClient sets the client used for webhook deliveries.
+func WithTrafficAlertHTTPClient(httpClient *http.Client) TrafficAlertMonitorOption {
+	return func(monitor *TrafficAlertMonitor) {
+		if httpClient != nil {
+			monitor.httpClient = httpClient
+		}
+	}
+}
+
+// WithTrafficAlertClock overrides the monitor clock.
+func WithTrafficAlertClock(clock func() time.Time) TrafficAlertMonitorOption {
+	return func(monitor *TrafficAlertMonitor) {
+		if clock != nil {
+			monitor.now = clock
+		}
+	}
+}
+
+// EvaluateRules checks every enabled rule once. A rule alerts when its condition starts to hold and stays
+// quiet until the condition clears; snoozed rules are evaluated but do not alert until the snooze ends.
+func (monitor *TrafficAlertMonitor) EvaluateRules(ctx context.Context) (TrafficAlertMonitorResult, error) {
+	var result TrafficAlertMonitorResult
+	if monitor == nil || monitor.database == nil {
+		return result, nil
+	}
+
+	lastRuleID := ""
+	for {
+		var rules []model.TrafficAlertRule
+		findErr := monitor.database.WithContext(ctx).
+			Where("enabled = ? AND id > ?", true, lastRuleID).
+			Order("id asc").
+			Limit(trafficAlertRuleBatchSize).
+			Find(&rules).Error
+		if findErr != nil {
+			return result, fmt.Errorf("load traffic alert rules: %w", findErr)
+		}
+		if len(rules) == 0 {
+			if result.Triggered > 0 {
+				monitor.logger.Info("traffic_alert_run", zap.Int("evaluated", result.Evaluated), zap.Int("triggered", result.Triggered))
+			}
+			return result, nil
+		}
+
+		sitesByID, sitesErr := monitor.loadRuleSites(ctx, rules)
+		if sitesErr != nil {
+			return result, sitesErr
+		}
+		for _, rule := range rules {
+			lastRuleID = rule.ID
+			if ctx.Err() != nil {
+				return result, ctx.Err()
+			}
+			site, ok := sitesByID[rule.SiteID]
+			if !ok {
+				continue
+			}
+			triggered, evaluateErr := monitor.evaluateRule(ctx, site, rule)
+			if evaluateErr != nil {
+				monitor.logger.Warn("traffic_alert_evaluate_failed", zap.Error(evaluateErr), zap.String("rule_id", rule.ID), zap.String("site_id", rule.SiteID))
+				continue
+			}
+			result.Evaluated++
+			if triggered {
+				result.Triggered++
+			}
+		}
+	}
+}
+
+func (monitor *TrafficAlertMonitor) loadRuleSites(ctx context.Context, rules []model.TrafficAlertRule) (map[string]model.Site, error) {
+	siteIDs := make([]string, 0, len(rules))
+	for _, rule := range rules {
+		siteIDs = append(siteIDs, rule.SiteID)
+	}
+	var sites []model.Site
+	if err := monitor.database.WithContext(ctx).
+		Select("id", "name", "owner_email").
+		Where("id IN ?", siteIDs).
+		Find(&sites).Error; err != nil {
+		return nil, fmt.Errorf("load traffic alert sites: %w", err)
+	}
+	sitesByID := make(map[string]model.Site, len(sites))
+	for _, site := range sites {
+		sitesByID[site.ID] = site
+	}
+	return sitesByID, nil
+}
+
+type trafficAlertEvaluation struct {
+	conditionMet  bool
+	observedValue float64
+	expectedValue float64
+	windowStart   time.Time
+	windowEnd     time.Time
+	message       string
+}
+
+func (monitor *TrafficAlertMonitor) evaluateRule(ctx context.Context, site model.Site, rule model.TrafficAlertRule) (bool, error) {
+	now := monitor.now().UTC()
+	evaluation, evaluationErr := monitor.measureRule(ctx, site, rule, now)
+	if evaluationErr != nil {
+		return false, evaluationErr
+	}
+
+	updates := map[string]any{"last_evaluated_at": now}
+	triggered := false
+	switch {
+	case !evaluation.conditionMet:
+		updates["firing"] = false
+	case rule.Firing || rule.IsSnoozed(now):
+	default:
+		monitor.triggerAlert(ctx, site, rule, evaluation)
+		updates["firing"] = true
+		updates["last_triggered_at"] = now
+		triggered = true
+	}
+
+	if err := monitor.database.WithContext(context.WithoutCancel(ctx)).
+		Model(&model.TrafficAlertRule{}).
+		Where("id = ?", rule.ID).
+		Updates(updates).Error; err != nil {
+		return triggered, fmt.Errorf("update traffic alert rule: %w", err)
+	}
+	return triggered, nil
+}
+
+func (monitor *TrafficAlertMonitor) measureRule(ctx context.Context, site model.Site, rule model.TrafficAlertRule, now time.Time) (trafficAlertEvaluation, error) {
+	evaluation := trafficAlertEvaluation{
+		windowStart: now.Add(-time.Duration(rule.WindowHours) * time.Hour),
+		windowEnd:   now,
+	}
+
+	pageViewQuery := monitor.database.WithContext(ctx).
+		Model(&model.SiteVisit{}).
+		Where("site_id = ? AND occurred_at >= ? AND occurred_at < ?", rule.SiteID, evaluation.windowStart, evaluation.windowEnd)
+	if !rule.IncludeBots {
+		pageViewQuery = pageViewQuery.Where("is_bot = ?", false)
+	}
+	var pageViews int64
+	if err := pageViewQuery.Count(&pageViews).Error; err != nil {
+		return evaluation, fmt.Errorf("count window page views: %w", err)
+	}
+	evaluation.observedValue = float64(pageViews)
+
+	siteName := campaignSiteName(site)
+	windowLabel := fmt.Sprintf("the last %dh", rule.WindowHours)
+	switch rule.Kind {
+	case model.TrafficAlertKindThreshold:
+		evaluation.expectedValue = rule.Threshold
+		if rule.Direction == model.TrafficAlertDirectionBelow {
+			evaluation.conditionMet = evaluation.observedValue < rule.Threshold
+			evaluation.message = fmt.Sprintf("Page views for %s fell to %d in %s, below the threshold of %s.", siteName, pageViews, windowLabel, formatTrafficAlertNumber(rule.Threshold))
+		} else {
+			evaluation.conditionMet = evaluation.observedValue > rule.Threshold
+			evaluation.message = fmt.Sprintf("Page views for %s rose to %d in %s, above the threshold of %s.", siteName, pageViews, windowLabel, formatTrafficAlertNumber(rule.Threshold))
+		}
+	case model.TrafficAlertKindDeviation:
+		dailyAverage, baselineErr := monitor.trailingDailyPageViews(ctx, rule, now)
+		if baselineErr != nil {
+			return evaluation, baselineErr
+		}
+		// Without any baseline traffic there is nothing to deviate from; wait until history exists.
+		if dailyAverage <= 0 {
+			return evaluation, nil
+		}
+		evaluation.expectedValue = dailyAverage * float64(rule.WindowHours) / 24
+		if rule.Direction == model.TrafficAlertDirectionBelow {
+			evaluation.conditionMet = evaluation.observedValue < evaluation.expectedValue*(1-rule.Threshold/100)
+			evaluation.message = fmt.Sprintf("Page views for %s fell to %d in %s, more than %s%% below the trailing %d-day average of %s.", siteName, pageViews, windowLabel, formatTrafficAlertNumber(rule.Threshold), rule.BaselineDays, formatTrafficAlertNumber(evaluation.expectedValue))
+		} else {
+			evaluation.conditionMet = evaluation.observedValue > evaluation.expectedValue*(1+rule.Threshold/100)
+			evaluation.message = fmt.Sprintf("Page views for %s rose to %d in %s, more than %s%% above the trailing %d-day average of %s.", siteName, pageViews, windowLabel, formatTrafficAlertNumber(rule.Threshold), rule.BaselineDays, formatTrafficAlertNumber(evaluation.expectedValue))
+		}
+	}
+	return evaluation, nil
+}
+
+// trailingDailyPageViews averages daily page views over the completed days before now. Rollups are preferred;
+// days the rollup job has not covered yet are counted from raw visits so the baseline does not read as a drop.
+func (monitor *TrafficAlertMonitor) trailingDailyPageViews(ctx context.Context, rule model.TrafficAlertRule, now time.Time) (float64, error) {
+	today := now.Truncate(24 * time.Hour)
+	baselineStart := today.AddDate(0, 0, -rule.BaselineDays)
+	database := monitor.database.WithContext(ctx)
+
+	var rollups []model.SiteVisitRollup
+	if err := database.
+		Select("date", "page_views").
+		Where("site_id = ? AND date >= ? AND date < ?", rule.SiteID, baselineStart, today).
+		Find(&rollups).Error; err != nil {
+		return 0, fmt.Errorf("load baseline rollups: %w", err)
+	}
+	pageViewsByDay := make(map[string]int64, rule.BaselineDays)
+	for _, rollup := range rollups {
+		pageViewsByDay[rollup.Date.UTC().Format(visitTrendDayLayout)] = rollup.PageViews
+	}
+
+	if len(pageViewsByDay) < rule.BaselineDays {
+		var rows []dailyVisitTrendRow
+		if err := database.
+			Model(&model.SiteVisit{}).
+			Select("DATE(occurred_at) as day, COUNT(*) as page_views").
+			Where("site_id = ? AND occurred_at >= ? AND occurred_at < ? AND is_bot = ?", rule.SiteID, baselineStart, today, false).
+			Group("DATE(occurred_at)").
+			Scan(&rows).Error; err != nil {
+			return 0, fmt.Errorf("count baseline visits: %w", err)
+		}
+		for _, row := range rows {
+			dayKey, _, normalizeErr := normalizeVisitTrendMapKey(row.Day)
+			if normalizeErr != nil {
+				return 0, normalizeErr
+			}
+			if _, covered := pageViewsByDay[dayKey]; !covered {
+				pageViewsByDay[dayKey] = row.PageViews
+			}
+		}
+	}
+
+	var total int64
+	for _, pageViews := range pageViewsByDay {
+		total += pageViews
+	}
+	return float64(total) / float64(rule.BaselineDays), nil
+}
+
+func (monitor *TrafficAlertMonitor) triggerAlert(ctx context.Context, site model.Site, rule model.TrafficAlertRule, evaluation trafficAlertEvaluation) {
+	alert := model.NewTrafficAlert(rule, evaluation.observedValue, evaluation.expectedValue, evaluation.windowStart, evaluation.windowEnd, evaluation.message)
+	deliveryContext := context.WithoutCancel(ctx)
+
+	if monitor.notifier != nil {
+		alert.NotifyStatus, alert.NotifyError = model.TrafficAlertDeliveryOutcome(monitor.notifier.NotifyTrafficAlert(deliveryContext, site, alert))
+	}
+	if rule.WebhookURL != "" {
+		alert.WebhookStatus, alert.WebhookError = model.TrafficAlertDeliveryOutcome(monitor.deliverWebhook(deliveryContext, site, rule, alert))
+	}
+	alert.CreatedAt = monitor.now().UTC()
+
+	if err := monitor.database.WithContext(deliveryContext).Create(&alert).Error; err != nil {
+		monitor.logger.Warn("traffic_alert_save_failed", zap.Error(err), zap.String("rule_id", rule.ID), zap.String("site_id", site.ID))
+	}
+}
+
+type trafficAlertWebhookPayload struct {
+	Event             string  `json:"event"`
+	AlertID           string  `json:"alert_id"`
+	RuleID            string  `json:"rule_id"`
+	SiteID            string  `json:"site_id"`
+	SiteName          string  `json:"site_name"`
+	Kind              string  `json:"kind"`
+	Direction         string  `json:"direction"`
+	ObservedPageViews float64 `json:"observed_page_views"`
+	ExpectedPageViews float64 `json:"expected_page_views"`
+	WindowStart       int64   `json:"window_start"`
+	WindowEnd         int64   `json:"window_end"`
+	Message           string  `json:"message"`
+}
+
+func (monitor *TrafficAlertMonitor) deliverWebhook(ctx context.Context, site model.Site, rule model.TrafficAlertRule, alert model.TrafficAlert) error {
+	encodedPayload, marshalErr := json.Marshal(trafficAlertWebhookPayload{
+		Event:             trafficAlertWebhookEvent,
+		AlertID:           alert.ID,
+		RuleID:            rule.ID,
+		SiteID:            site.ID,
+		SiteName:          strings.TrimSpace(site.Name),
+		Kind:              alert.Kind,
+		Direction:         alert.Direction,
+		ObservedPageViews: alert.ObservedValue,
+		ExpectedPageViews: alert.ExpectedValue,
+		WindowStart:       alert.WindowStart.Unix(),
+		WindowEnd:         alert.WindowEnd.Unix(),
+		Message:           alert.Message,
+	})
+	if marshalErr != nil {
+		return fmt.Errorf("encode webhook payload: %w", marshalErr)
+	}
+
+	request, requestErr := http.NewRequestWithContext(ctx, http.MethodPost, rule.WebhookURL, bytes.NewReader(encodedPayload))
+	if requestErr != nil {
+		return fmt.Errorf("build webhook request: %w", requestErr)
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("User-Agent", trafficAlertWebhookUserAgent)
+
+	response, sendErr := monitor.httpClient.Do(request)
+	if sendErr != nil {
+		monitor.logger.Warn("traffic_alert_webhook_failed", zap.Error(sendErr), zap.String("rule_id", rule.ID))
+		return fmt.Errorf("send webhook: %w", sendErr)
+	}
+	defer response.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(response.Body, trafficAlertWebhookMaxBodyBytes))
+	if response.StatusCode < http.StatusOK || response.StatusCode >= http.StatusMultipleChoices {
+		monitor.logger.Warn("traffic_alert_webhook_status", zap.Int("status", response.StatusCode), zap.String("rule_id", rule.ID))
+		return fmt.Errorf("webhook returned status %d", response.StatusCode)
+	}
+	return nil
+}
+
+func formatTrafficAlertNumber(value float64) string {
+	return strings.TrimSuffix(fmt.Sprintf("%.1f", value), ".0")
+}
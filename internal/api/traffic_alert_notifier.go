@@ -0,0 +1,12 @@
+package api
+
+import (
+	"context"
+
+	"github.com/MarkoPoloResearchLab/loopaware/internal/model"
+)
+
+// TrafficAlertNotifier tells the site owner that a traffic alert rule fired.
+type TrafficAlertNotifier interface {
+	NotifyTrafficAlert(ctx context.Context, site model.Site, alert model.TrafficAlert) error
+}
@@ -0,0 +1,443 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"github.com/MarkoPoloResearchLab/loopaware/internal/model"
+)
+
+const (
+	errorValueInvalidTrafficAlertRule = "invalid_traffic_alert_rule"
+	errorValueInvalidAlertKind        = "invalid_kind"
+	errorValueInvalidAlertDirection   = "invalid_direction"
+	errorValueInvalidAlertThreshold   = "invalid_threshold"
+	errorValueInvalidAlertWindow      = "invalid_window_hours"
+	errorValueInvalidAlertBaseline    = "invalid_baseline_days"
+	errorValueInvalidAlertWebhookURL  = "invalid_webhook_url"
+	errorValueInvalidSnooze           = "invalid_snooze"
+	errorValueUnknownTrafficAlertRule = "unknown_traffic_alert_rule"
+
+	defaultTrafficAlertPageSize = 50
+	maxTrafficAlertPageSize     = 200
+	maxTrafficAlertSnoozeHours  = 30 * 24
+)
+
+// TrafficAlertHandlers manages traffic alert rules, snoozes, and alert history for a site.
+type TrafficAlertHandlers struct {
+	database *gorm.DB
+	logger   *zap.Logger
+	now      func() time.Time
+}
+
+// NewTrafficAlertHandlers constructs traffic alert handlers.
+func NewTrafficAlertHandlers(database *gorm.DB, logger *zap.Logger) *TrafficAlertHandlers {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &TrafficAlertHandlers{
+		database: database,
+		logger:   logger,
+		now:      time.Now,
+	}
+}
+
+type trafficAlertRuleRequest struct {
+	Kind         *string  `json:"kind"`
+	Direction    *string  `json:"direction"`
+	Threshold    *float64 `json:"threshold"`
+	WindowHours  *int     `json:"window_hours"`
+	BaselineDays *int     `json:"baseline_days"`
+	IncludeBots  *bool    `json:"include_bots"`
+	WebhookURL   *string  `json:"webhook_url"`
+	Enabled      *bool    `json:"enabled"`
+}
+
+type trafficAlertSnoozeRequest struct {
+	Hours *int `json:"hours"`
+}
+
+// TrafficAlertRuleResponse describes a traffic alert rule and its current state.
+type TrafficAlertRuleResponse struct {
+	ID              string  `json:"id"`
+	SiteID          string  `json:"site_id"`
+	Kind            string  `json:"kind"`
+	Direction       string  `json:"direction"`
+	Threshold       float64 `json:"threshold"`
+	WindowHours     int     `json:"window_hours"`
+	BaselineDays    int     `json:"baseline_days"`
+	IncludeBots     bool    `json:"include_bots"`
+	WebhookURL      string  `json:"webhook_url"`
+	Enabled         bool    `json:"enabled"`
+	Firing          bool    `json:"firing"`
+	SnoozedUntil    int64   `json:"snoozed_until"`
+	LastTriggeredAt int64   `json:"last_triggered_at"`
+	LastEvaluatedAt int64   `json:"last_evaluated_at"`
+	CreatedByEmail  string  `json:"created_by_email"`
+	CreatedAt       int64   `json:"created_at"`
+	UpdatedAt       int64   `json:"updated_at"`
+}
+
+// SiteTrafficAlertRulesResponse lists the traffic alert rules for a site.
+type SiteTrafficAlertRulesResponse struct {
+	SiteID string                     `json:"site_id"`
+	Rules  []TrafficAlertRuleResponse `json:"rules"`
+}
+
+// TrafficAlertRecord describes one fired alert.
+type TrafficAlertRecord struct {
+	ID            string  `json:"id"`
+	RuleID        string  `json:"rule_id"`
+	Kind          string  `json:"kind"`
+	Direction     string  `json:"direction"`
+	ObservedValue float64 `json:"observed_value"`
+	ExpectedValue float64 `json:"expected_value"`
+	WindowStart   int64   `json:"window_start"`
+	WindowEnd     int64   `json:"window_end"`
+	Message       string  `json:"message"`
+	NotifyStatus  string  `json:"notify_status"`
+	NotifyError   string  `json:"notify_error"`
+	WebhookStatus string  `json:"webhook_status"`
+	WebhookError  string  `json:"webhook_error"`
+	CreatedAt     int64   `json:"created_at"`
+}
+
+// SiteTrafficAlertsResponse lists alert history for a site, newest first.
+type SiteTrafficAlertsResponse struct {
+	SiteID string               `json:"site_id"`
+	Total  int64                `json:"total"`
+	Alerts []TrafficAlertRecord `json:"alerts"`
+}
+
+// ListRules returns the traffic alert rules for a site, oldest first.
+func (handlers *TrafficAlertHandlers) ListRules(context *gin.Context) {
+	site, _, ok := resolveManageableSite(context, handlers.database)
+	if !ok {
+		return
+	}
+
+	var rules []model.TrafficAlertRule
+	if err := handlers.database.Where("site_id = ?", site.ID).Order("created_at asc, id asc").Find(&rules).Error; err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{jsonKeyError: errorValueQueryFailed})
+		return
+	}
+
+	response := SiteTrafficAlertRulesResponse{SiteID: site.ID, Rules: make([]TrafficAlertRuleResponse, 0, len(rules))}
+	for _, rule := range rules {
+		response.Rules = append(response.Rules, toTrafficAlertRuleResponse(rule))
+	}
+	context.JSON(http.StatusOK, response)
+}
+
+// CreateRule stores a new enabled traffic alert rule.
+func (handlers *TrafficAlertHandlers) CreateRule(context *gin.Context) {
+	site, currentUser, ok := resolveManageableSite(context, handlers.database)
+	if !ok {
+		return
+	}
+
+	var request trafficAlertRuleRequest
+	if err := context.ShouldBindJSON(&request); err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{jsonKeyError: errorValueInvalidJSON})
+		return
+	}
+
+	input := model.TrafficAlertRuleInput{
+		SiteID:         site.ID,
+		Kind:           stringValue(request.Kind),
+		Direction:      stringValue(request.Direction),
+		WebhookURL:     stringValue(request.WebhookURL),
+		CreatedByEmail: currentUser.normalizedEmail(),
+	}
+	if request.Threshold != nil {
+		input.Threshold = *request.Threshold
+	}
+	if request.WindowHours != nil {
+		input.WindowHours = *request.WindowHours
+	}
+	if request.BaselineDays != nil {
+		input.BaselineDays = *request.BaselineDays
+	}
+	if request.IncludeBots != nil {
+		input.IncludeBots = *request.IncludeBots
+	}
+	rule, ruleErr := model.NewTrafficAlertRule(input)
+	if ruleErr != nil {
+		context.JSON(http.StatusBadRequest, gin.H{jsonKeyError: trafficAlertRuleErrorValue(ruleErr)})
+		return
+	}
+	if request.Enabled != nil {
+		rule.Enabled = *request.Enabled
+	}
+
+	if err := handlers.database.Create(&rule).Error; err != nil {
+		requestLogger(context, handlers.logger).Warn("create_traffic_alert_rule", zap.Error(err), zap.String("site_id", site.ID))
+		context.JSON(http.StatusInternalServerError, gin.H{jsonKeyError: errorValueSaveFailed})
+		return
+	}
+
+	handlers.respondWithRule(context, http.StatusCreated, rule.ID)
+}
+
+// UpdateRule edits a traffic alert rule. Changing any setting clears the firing state so the rule is re-evaluated from scratch.
+func (handlers *TrafficAlertHandlers) UpdateRule(context *gin.Context) {
+	rule, ok := handlers.resolveRule(context)
+	if !ok {
+		return
+	}
+
+	var request trafficAlertRuleRequest
+	if err := context.ShouldBindJSON(&request); err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{jsonKeyError: errorValueInvalidJSON})
+		return
+	}
+	if request.Kind == nil && request.Direction == nil && request.Threshold == nil && request.WindowHours == nil &&
+		request.BaselineDays == nil && request.IncludeBots == nil && request.WebhookURL == nil && request.Enabled == nil {
+		context.JSON(http.StatusBadRequest, gin.H{jsonKeyError: errorValueNothingToUpdate})
+		return
+	}
+
+	if request.Kind != nil {
+		rule.Kind = *request.Kind
+	}
+	if request.Direction != nil {
+		rule.Direction = *request.Direction
+	}
+	if request.Threshold != nil {
+		rule.Threshold = *request.Threshold
+	}
+	if request.WindowHours != nil {
+		rule.WindowHours = *request.WindowHours
+	}
+	if request.BaselineDays != nil {
+		rule.BaselineDays = *request.BaselineDays
+	}
+	if request.IncludeBots != nil {
+		rule.IncludeBots = *request.IncludeBots
+	}
+	if request.WebhookURL != nil {
+		rule.WebhookURL = *request.WebhookURL
+	}
+	if request.Enabled != nil {
+		rule.Enabled = *request.Enabled
+	}
+	if normalizeErr := rule.Normalize(); normalizeErr != nil {
+		context.JSON(http.StatusBadRequest, gin.H{jsonKeyError: trafficAlertRuleErrorValue(normalizeErr)})
+		return
+	}
+
+	updateErr := handlers.database.Model(&model.TrafficAlertRule{}).
+		Where("id = ?", rule.ID).
+		Updates(map[string]any{
+			"kind":          rule.Kind,
+			"direction":     rule.Direction,
+			"threshold":     rule.Threshold,
+			"window_hours":  rule.WindowHours,
+			"baseline_days": rule.BaselineDays,
+			"include_bots":  rule.IncludeBots,
+			"webhook_url":   rule.WebhookURL,
+			"enabled":       rule.Enabled,
+			"firing":        false,
+		}).Error
+	if updateErr != nil {
+		requestLogger(context, handlers.logger).Warn("update_traffic_alert_rule", zap.Error(updateErr), zap.String("rule_id", rule.ID))
+		context.JSON(http.StatusInternalServerError, gin.H{jsonKeyError: errorValueSaveFailed})
+		return
+	}
+
+	handlers.respondWithRule(context, http.StatusOK, rule.ID)
+}
+
+// DeleteRule removes a traffic alert rule and its alert history.
+func (handlers *TrafficAlertHandlers) DeleteRule(context *gin.Context) {
+	rule, ok := handlers.resolveRule(context)
+	if !ok {
+		return
+	}
+
+	deleteErr := handlers.database.Transaction(func(transaction *gorm.DB) error {
+		if err := transaction.Where("rule_id = ?", rule.ID).Delete(&model.TrafficAlert{}).Error; err != nil {
+			return err
+		}
+		return transaction.Delete(&model.TrafficAlertRule{ID: rule.ID}).Error
+	})
+	if deleteErr != nil {
+		requestLogger(context, handlers.logger).Warn("delete_traffic_alert_rule", zap.Error(deleteErr), zap.String("rule_id", rule.ID))
+		context.JSON(http.StatusInternalServerError, gin.H{jsonKeyError: errorValueDeleteFailed})
+		return
+	}
+
+	context.Status(http.StatusNoContent)
+	context.Writer.WriteHeaderNow()
+}
+
+// SnoozeRule suppresses alerts from a rule for the requested number of hours; zero hours ends the snooze.
+func (handlers *TrafficAlertHandlers) SnoozeRule(context *gin.Context) {
+	rule, ok := handlers.resolveRule(context)
+	if !ok {
+		return
+	}
+
+	var request trafficAlertSnoozeRequest
+	if err := context.ShouldBindJSON(&request); err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{jsonKeyError: errorValueInvalidJSON})
+		return
+	}
+	if request.Hours == nil || *request.Hours < 0 || *request.Hours > maxTrafficAlertSnoozeHours {
+		context.JSON(http.StatusBadRequest, gin.H{jsonKeyError: errorValueInvalidSnooze})
+		return
+	}
+
+	snoozedUntil := time.Time{}
+	if *request.Hours > 0 {
+		snoozedUntil = handlers.now().UTC().Add(time.Duration(*request.Hours) * time.Hour)
+	}
+	if err := handlers.database.Model(&model.TrafficAlertRule{}).Where("id = ?", rule.ID).Update("snoozed_until", snoozedUntil).Error; err != nil {
+		requestLogger(context, handlers.logger).Warn("snooze_traffic_alert_rule", zap.Error(err), zap.String("rule_id", rule.ID))
+		context.JSON(http.StatusInternalServerError, gin.H{jsonKeyError: errorValueSaveFailed})
+		return
+	}
+
+	handlers.respondWithRule(context, http.StatusOK, rule.ID)
+}
+
+// ListAlerts returns fired alerts for a site, newest first, optionally for a single rule.
+func (handlers *TrafficAlertHandlers) ListAlerts(context *gin.Context) {
+	site, _, ok := resolveManageableSite(context, handlers.database)
+	if !ok {
+		return
+	}
+
+	limit := defaultTrafficAlertPageSize
+	if rawLimit := strings.TrimSpace(context.Query("limit")); rawLimit != "" {
+		parsedLimit, parseErr := strconv.Atoi(rawLimit)
+		if parseErr != nil || parsedLimit <= 0 || parsedLimit > maxTrafficAlertPageSize {
+			context.JSON(http.StatusBadRequest, gin.H{jsonKeyError: errorValueInvalidLimit})
+			return
+		}
+		limit = parsedLimit
+	}
+	offset := 0
+	if rawOffset := strings.TrimSpace(context.Query("offset")); rawOffset != "" {
+		parsedOffset, parseErr := strconv.Atoi(rawOffset)
+		if parseErr != nil || parsedOffset < 0 {
+			context.JSON(http.StatusBadRequest, gin.H{jsonKeyError: errorValueInvalidOffset})
+			return
+		}
+		offset = parsedOffset
+	}
+
+	query := handlers.database.Model(&model.TrafficAlert{}).Where("site_id = ?", site.ID)
+	if ruleID := strings.TrimSpace(context.Query("rule_id")); ruleID != "" {
+		query = query.Where("rule_id = ?", ruleID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{jsonKeyError: errorValueQueryFailed})
+		return
+	}
+	var alerts []model.TrafficAlert
+	if err := query.Order("created_at desc, id desc").Limit(limit).Offset(offset).Find(&alerts).Error; err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{jsonKeyError: errorValueQueryFailed})
+		return
+	}
+
+	response := SiteTrafficAlertsResponse{SiteID: site.ID, Total: total, Alerts: make([]TrafficAlertRecord, 0, len(alerts))}
+	for _, alert := range alerts {
+		response.Alerts = append(response.Alerts, TrafficAlertRecord{
+			ID:            alert.ID,
+			RuleID:        alert.RuleID,
+			Kind:          alert.Kind,
+			Direction:     alert.Direction,
+			ObservedValue: alert.ObservedValue,
+			ExpectedValue: alert.ExpectedValue,
+			WindowStart:   unixSecondsOrZero(alert.WindowStart),
+			WindowEnd:     unixSecondsOrZero(alert.WindowEnd),
+			Message:       alert.Message,
+			NotifyStatus:  alert.NotifyStatus,
+			NotifyError:   alert.NotifyError,
+			WebhookStatus: alert.WebhookStatus,
+			WebhookError:  alert.WebhookError,
+			CreatedAt:     unixSecondsOrZero(alert.CreatedAt),
+		})
+	}
+	context.JSON(http.StatusOK, response)
+}
+
+func (handlers *TrafficAlertHandlers) resolveRule(context *gin.Context) (model.TrafficAlertRule, bool) {
+	site, _, ok := resolveManageableSite(context, handlers.database)
+	if !ok {
+		return model.TrafficAlertRule{}, false
+	}
+
+	ruleID := strings.TrimSpace(context.Param("rule_id"))
+	var rule model.TrafficAlertRule
+	findErr := handlers.database.First(&rule, "id = ? AND site_id = ?", ruleID, site.ID).Error
+	if findErr != nil {
+		if errors.Is(findErr, gorm.ErrRecordNotFound) {
+			context.JSON(http.StatusNotFound, gin.H{jsonKeyError: errorValueUnknownTrafficAlertRule})
+			return model.TrafficAlertRule{}, false
+		}
+		context.JSON(http.StatusInternalServerError, gin.H{jsonKeyError: errorValueQueryFailed})
+		return model.TrafficAlertRule{}, false
+	}
+	return rule, true
+}
+
+func (handlers *TrafficAlertHandlers) respondWithRule(context *gin.Context, status int, ruleID string) {
+	var rule model.TrafficAlertRule
+	if err := handlers.database.First(&rule, "id = ?", ruleID).Error; err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{jsonKeyError: errorValueQueryFailed})
+		return
+	}
+	context.JSON(status, toTrafficAlertRuleResponse(rule))
+}
+
+func toTrafficAlertRuleResponse(rule model.TrafficAlertRule) TrafficAlertRuleResponse {
+	return TrafficAlertRuleResponse{
+		ID:              rule.ID,
+		SiteID:          rule.SiteID,
+		Kind:            rule.Kind,
+		Direction:       rule.Direction,
+		Threshold:       rule.Threshold,
+		WindowHours:     rule.WindowHours,
+		BaselineDays:    rule.BaselineDays,
+		IncludeBots:     rule.IncludeBots,
+		WebhookURL:      rule.WebhookURL,
+		Enabled:         rule.Enabled,
+		Firing:          rule.Firing,
+		SnoozedUntil:    unixSecondsOrZero(rule.SnoozedUntil),
+		LastTriggeredAt: unixSecondsOrZero(rule.LastTriggeredAt),
+		LastEvaluatedAt: unixSecondsOrZero(rule.LastEvaluatedAt),
+		CreatedByEmail:  rule.CreatedByEmail,
+		CreatedAt:       unixSecondsOrZero(rule.CreatedAt),
+		UpdatedAt:       unixSecondsOrZero(rule.UpdatedAt),
+	}
+}
+
+func trafficAlertRuleErrorValue(ruleErr error) string {
+	switch {
+	case errors.Is(ruleErr, model.ErrInvalidTrafficAlertKind):
+		return errorValueInvalidAlertKind
+	case errors.Is(ruleErr, model.ErrInvalidTrafficAlertDirection):
+		return errorValueInvalidAlertDirection
+	case errors.Is(ruleErr, model.ErrInvalidTrafficAlertThreshold):
+		return errorValueInvalidAlertThreshold
+	case errors.Is(ruleErr, model.ErrInvalidTrafficAlertWindow):
+		return errorValueInvalidAlertWindow
+	case errors.Is(ruleErr, model.ErrInvalidTrafficAlertBaseline):
+		return errorValueInvalidAlertBaseline
+	case errors.Is(ruleErr, model.ErrInvalidTrafficAlertWebhookURL):
+		return errorValueInvalidAlertWebhookURL
+	default:
+		return errorValueInvalidTrafficAlertRule
+	}
+}
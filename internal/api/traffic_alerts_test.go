@@ -0,0 +1,285 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/MarkoPoloResearchLab/loopaware/internal/api"
+	"github.com/MarkoPoloResearchLab/loopaware/internal/model"
+	"github.com/MarkoPoloResearchLab/loopaware/pkg/egress"
+)
+
+type recordingTrafficAlertNotifier struct {
+	mu     sync.Mutex
+	alerts []model.TrafficAlert
+}
+
+func (notifier *recordingTrafficAlertNotifier) NotifyTrafficAlert(_ context.Context, _ model.Site, alert model.TrafficAlert) error {
+	notifier.mu.Lock()
+	defer notifier.mu.Unlock()
+	notifier.alerts = append(notifier.alerts, alert)
+	return nil
+}
+
+func (notifier *recordingTrafficAlertNotifier) Alerts() []model.TrafficAlert {
+	notifier.mu.Lock()
+	defer notifier.mu.Unlock()
+	return append([]model.TrafficAlert(nil), notifier.alerts...)
+}
+
+type trafficAlertHarness struct {
+	siteTestHarness
+	site     model.Site
+	notifier *recordingTrafficAlertNotifier
+	monitor  *api.TrafficAlertMonitor
+	now      time.Time
+}
+
+func newTrafficAlertHarness(testingT *testing.T) *trafficAlertHarness {
+	testingT.Helper()
+	siteHarness := newSiteTestHarness(testingT)
+	harness := &trafficAlertHarness{
+		siteTestHarness: siteHarness,
+		site:            insertSite(testingT, siteHarness.database, "Alert Site", "http://alerts.example", testUserEmailAddress),
+		notifier:        &recordingTrafficAlertNotifier{},
+		now:             time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC),
+	}
+	loopbackWebhooks := egress.NewClient(egress.WithAllowedNetworks(netip.MustParsePrefix("127.0.0.0/8")))
+	harness.monitor = api.NewTrafficAlertMonitor(harness.database, zap.NewNop(), harness.notifier,
+		api.WithTrafficAlertHTTPClient(loopbackWebhooks),
+		api.WithTrafficAlertClock(func() time.Time { return harness.now }))
+	return harness
+}
+
+func (harness *trafficAlertHarness) createRule(testingT *testing.T, input model.TrafficAlertRuleInput) model.TrafficAlertRule {
+	testingT.Helper()
+	input.SiteID = harness.site.ID
+	rule, ruleErr := model.NewTrafficAlertRule(input)
+	require.NoError(testingT, ruleErr)
+	require.NoError(testingT, harness.database.Create(&rule).Error)
+	return rule
+}
+
+func (harness *trafficAlertHarness) insertVisits(testingT *testing.T, count int, occurredAt time.Time, isBot bool) {
+	testingT.Helper()
+	for index := 0; index < count; index++ {
+		visit, visitErr := model.NewSiteVisit(model.SiteVisitInput{SiteID: harness.site.ID, URL: "http://alerts.example/", IsBot: isBot, Occurred: occurredAt})
+		require.NoError(testingT, visitErr)
+		require.NoError(testingT, harness.database.Create(&visit).Error)
+	}
+}
+
+func (harness *trafficAlertHarness) evaluate(testingT *testing.T) api.TrafficAlertMonitorResult {
+	testingT.Helper()
+	result, evaluateErr := harness.monitor.EvaluateRules(context.Background())
+	require.NoError(testingT, evaluateErr)
+	return result
+}
+
+func TestTrafficAlertMonitorFiresOncePerIncidentAndCallsWebhook(testingT *testing.T) {
+	var webhookMutex sync.Mutex
+	var webhookPayloads []map[string]any
+	webhookServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		body, _ := io.ReadAll(request.Body)
+		var payload map[string]any
+		_ = json.Unmarshal(body, &payload)
+		webhookMutex.Lock()
+		webhookPayloads = append(webhookPayloads, payload)
+		webhookMutex.Unlock()
+		writer.WriteHeader(http.StatusNoContent)
+	}))
+	testingT.Cleanup(webhookServer.Close)
+
+	harness := newTrafficAlertHarness(testingT)
+	rule := harness.createRule(testingT, model.TrafficAlertRuleInput{Kind: model.TrafficAlertKindThreshold, Direction: model.TrafficAlertDirectionBelow, Threshold: 1, WindowHours: 6, WebhookURL: webhookServer.URL})
+	harness.insertVisits(testingT, 3, harness.now.Add(-8*time.Hour), false)
+
+	result := harness.evaluate(testingT)
+	require.Equal(testingT, api.TrafficAlertMonitorResult{Evaluated: 1, Triggered: 1}, result)
+	require.Len(testingT, harness.notifier.Alerts(), 1)
+	require.Contains(testingT, harness.notifier.Alerts()[0].Message, "fell to 0 in the last 6h")
+
+	var alerts []model.TrafficAlert
+	require.NoError(testingT, harness.database.Where("rule_id = ?", rule.ID).Find(&alerts).Error)
+	require.Len(testingT, alerts, 1)
+	require.Equal(testingT, model.TrafficAlertDeliverySent, alerts[0].NotifyStatus)
+	require.Equal(testingT, model.TrafficAlertDeliverySent, alerts[0].WebhookStatus)
+	webhookMutex.Lock()
+	require.Len(testingT, webhookPayloads, 1)
+	require.Equal(testingT, "traffic_alert", webhookPayloads[0]["event"])
+	require.Equal(testingT, harness.site.ID, webhookPayloads[0]["site_id"])
+	require.Equal(testingT, float64(0), webhookPayloads[0]["observed_page_views"])
+	webhookMutex.Unlock()
+
+	harness.now = harness.now.Add(15 * time.Minute)
+	require.Equal(testingT, api.TrafficAlertMonitorResult{Evaluated: 1}, harness.evaluate(testingT))
+	require.Len(testingT, harness.notifier.Alerts(), 1)
+
+	harness.insertVisits(testingT, 1, harness.now.Add(-time.Minute), false)
+	harness.evaluate(testingT)
+	var recoveredRule model.TrafficAlertRule
+	require.NoError(testingT, harness.database.First(&recoveredRule, "id = ?", rule.ID).Error)
+	require.False(testingT, recoveredRule.Firing)
+
+	harness.now = harness.now.Add(7 * time.Hour)
+	require.Equal(testingT, 1, harness.evaluate(testingT).Triggered)
+	require.Len(testingT, harness.notifier.Alerts(), 2)
+}
+
+func TestTrafficAlertMonitorRefusesInternalWebhooksByDefault(testingT *testing.T) {
+	var webhookCalls atomic.Int32
+	webhookServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, _ *http.Request) {
+		webhookCalls.Add(1)
+		writer.WriteHeader(http.StatusNoContent)
+	}))
+	testingT.Cleanup(webhookServer.Close)
+
+	harness := newTrafficAlertHarness(testingT)
+	harness.monitor = api.NewTrafficAlertMonitor(harness.database, zap.NewNop(), harness.notifier,
+		api.WithTrafficAlertClock(func() time.Time { return harness.now }))
+	rule := harness.createRule(testingT, model.TrafficAlertRuleInput{Kind: model.TrafficAlertKindThreshold, Direction: model.TrafficAlertDirectionBelow, Threshold: 1, WindowHours: 6, WebhookURL: webhookServer.URL})
+
+	require.Equal(testingT, 1, harness.evaluate(testingT).Triggered)
+	require.Zero(testingT, webhookCalls.Load())
+	var alert model.TrafficAlert
+	require.NoError(testingT, harness.database.First(&alert, "rule_id = ?", rule.ID).Error)
+	require.Equal(testingT, model.TrafficAlertDeliveryFailed, alert.WebhookStatus)
+	require.Contains(testingT, alert.WebhookError, "egress_blocked_address")
+}
+
+func TestTrafficAlertMonitorDetectsBotSpikeAgainstTrailingAverage(testingT *testing.T) {
+	webhookServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, _ *http.Request) {
+		writer.WriteHeader(http.StatusInternalServerError)
+	}))
+	testingT.Cleanup(webhookServer.Close)
+
+	harness := newTrafficAlertHarness(testingT)
+	today := harness.now.Truncate(24 * time.Hour)
+	for dayOffset := 1; dayOffset <= 6; dayOffset++ {
+		rollup, rollupErr := model.NewSiteVisitRollup(harness.site.ID, today.AddDate(0, 0, -dayOffset), 48, 20)
+		require.NoError(testingT, rollupErr)
+		require.NoError(testingT, harness.database.Create(&rollup).Error)
+	}
+	harness.insertVisits(testingT, 48, today.AddDate(0, 0, -7).Add(6*time.Hour), false)
+
+	humansOnly := harness.createRule(testingT, model.TrafficAlertRuleInput{Kind: model.TrafficAlertKindDeviation, Direction: model.TrafficAlertDirectionAbove, Threshold: 100, WindowHours: 1})
+	withBots := harness.createRule(testingT, model.TrafficAlertRuleInput{Kind: model.TrafficAlertKindDeviation, Direction: model.TrafficAlertDirectionAbove, Threshold: 100, WindowHours: 1, IncludeBots: true, WebhookURL: webhookServer.URL})
+	harness.insertVisits(testingT, 2, harness.now.Add(-30*time.Minute), false)
+	harness.insertVisits(testingT, 10, harness.now.Add(-20*time.Minute), true)
+
+	result := harness.evaluate(testingT)
+	require.Equal(testingT, api.TrafficAlertMonitorResult{Evaluated: 2, Triggered: 1}, result)
+
+	var alerts []model.TrafficAlert
+	require.NoError(testingT, harness.database.Find(&alerts).Error)
+	require.Len(testingT, alerts, 1)
+	require.Equal(testingT, withBots.ID, alerts[0].RuleID)
+	require.NotEqual(testingT, humansOnly.ID, alerts[0].RuleID)
+	require.Equal(testingT, float64(12), alerts[0].ObservedValue)
+	require.Equal(testingT, float64(2), alerts[0].ExpectedValue)
+	require.Contains(testingT, alerts[0].Message, "above the trailing 7-day average of 2")
+	require.Equal(testingT, model.TrafficAlertDeliverySent, alerts[0].NotifyStatus)
+	require.Equal(testingT, model.TrafficAlertDeliveryFailed, alerts[0].WebhookStatus)
+	require.Contains(testingT, alerts[0].WebhookError, "500")
+}
+
+func TestTrafficAlertMonitorSkipsDeviationWithoutBaseline(testingT *testing.T) {
+	harness := newTrafficAlertHarness(testingT)
+	harness.createRule(testingT, model.TrafficAlertRuleInput{Kind: model.TrafficAlertKindDeviation, Direction: model.TrafficAlertDirectionAbove, Threshold: 50, WindowHours: 1})
+	harness.insertVisits(testingT, 5, harness.now.Add(-time.Minute), false)
+
+	require.Equal(testingT, api.TrafficAlertMonitorResult{Evaluated: 1}, harness.evaluate(testingT))
+	require.Empty(testingT, harness.notifier.Alerts())
+}
+
+func newTrafficAlertRouter(harness *trafficAlertHarness, currentUser *api.CurrentUser) *gin.Engine {
+	handlers := api.NewTrafficAlertHandlers(harness.database, zap.NewNop())
+	router := newAuthenticatedRouter(currentUser)
+	router.GET("/api/sites/:id/traffic-alerts", handlers.ListAlerts)
+	router.GET("/api/sites/:id/traffic-alerts/rules", handlers.ListRules)
+	router.POST("/api/sites/:id/traffic-alerts/rules", handlers.CreateRule)
+	router.PATCH("/api/sites/:id/traffic-alerts/rules/:rule_id", handlers.UpdateRule)
+	router.DELETE("/api/sites/:id/traffic-alerts/rules/:rule_id", handlers.DeleteRule)
+	router.POST("/api/sites/:id/traffic-alerts/rules/:rule_id/snooze", handlers.SnoozeRule)
+	return router
+}
+
+func TestTrafficAlertRuleLifecycleWithSnooze(testingT *testing.T) {
+	harness := newTrafficAlertHarness(testingT)
+	router := newTrafficAlertRouter(harness, &api.CurrentUser{Email: testUserEmailAddress, Role: api.RoleUser})
+	rulesPath := "/api/sites/" + harness.site.ID + "/traffic-alerts/rules"
+
+	recorder := performJSONRequest(testingT, router, http.MethodPost, rulesPath, map[string]any{"kind": "ratio", "direction": "below", "threshold": 1}, nil)
+	require.Equal(testingT, http.StatusBadRequest, recorder.Code)
+	require.JSONEq(testingT, `{"error":"invalid_kind"}`, recorder.Body.String())
+
+	recorder = performJSONRequest(testingT, router, http.MethodPost, rulesPath, map[string]any{"kind": "threshold", "direction": "below", "threshold": 1, "webhook_url": "file:///etc/passwd"}, nil)
+	require.Equal(testingT, http.StatusBadRequest, recorder.Code)
+	require.JSONEq(testingT, `{"error":"invalid_webhook_url"}`, recorder.Body.String())
+
+	recorder = performJSONRequest(testingT, router, http.MethodPost, rulesPath, map[string]any{"kind": "threshold", "direction": "below", "threshold": 1, "window_hours": 2}, nil)
+	require.Equal(testingT, http.StatusCreated, recorder.Code, recorder.Body.String())
+	var created api.TrafficAlertRuleResponse
+	require.NoError(testingT, json.Unmarshal(recorder.Body.Bytes(), &created))
+	require.True(testingT, created.Enabled)
+	require.Equal(testingT, 2, created.WindowHours)
+	require.Equal(testingT, model.DefaultTrafficAlertBaselineDays, created.BaselineDays)
+	rulePath := rulesPath + "/" + created.ID
+
+	recorder = performJSONRequest(testingT, router, http.MethodPost, rulePath+"/snooze", map[string]any{"hours": 1000}, nil)
+	require.Equal(testingT, http.StatusBadRequest, recorder.Code)
+	recorder = performJSONRequest(testingT, router, http.MethodPost, rulePath+"/snooze", map[string]any{"hours": 4}, nil)
+	require.Equal(testingT, http.StatusOK, recorder.Code, recorder.Body.String())
+	var snoozed api.TrafficAlertRuleResponse
+	require.NoError(testingT, json.Unmarshal(recorder.Body.Bytes(), &snoozed))
+	require.NotZero(testingT, snoozed.SnoozedUntil)
+
+	var storedRule model.TrafficAlertRule
+	require.NoError(testingT, harness.database.First(&storedRule, "id = ?", created.ID).Error)
+	harness.now = storedRule.SnoozedUntil.Add(-time.Minute)
+	require.Equal(testingT, api.TrafficAlertMonitorResult{Evaluated: 1}, harness.evaluate(testingT))
+	harness.now = storedRule.SnoozedUntil.Add(time.Minute)
+	require.Equal(testingT, 1, harness.evaluate(testingT).Triggered)
+
+	recorder = performJSONRequest(testingT, router, http.MethodGet, "/api/sites/"+harness.site.ID+"/traffic-alerts?rule_id="+created.ID, nil, nil)
+	require.Equal(testingT, http.StatusOK, recorder.Code)
+	var history api.SiteTrafficAlertsResponse
+	require.NoError(testingT, json.Unmarshal(recorder.Body.Bytes(), &history))
+	require.Equal(testingT, int64(1), history.Total)
+	require.Equal(testingT, created.ID, history.Alerts[0].RuleID)
+	require.Equal(testingT, model.TrafficAlertDeliverySkipped, history.Alerts[0].WebhookStatus)
+
+	recorder = performJSONRequest(testingT, router, http.MethodPatch, rulePath, map[string]any{"enabled": false}, nil)
+	require.Equal(testingT, http.StatusOK, recorder.Code, recorder.Body.String())
+	var disabled api.TrafficAlertRuleResponse
+	require.NoError(testingT, json.Unmarshal(recorder.Body.Bytes(), &disabled))
+	require.False(testingT, disabled.Enabled)
+	require.False(testingT, disabled.Firing)
+	require.Zero(testingT, harness.evaluate(testingT).Evaluated)
+
+	recorder = performJSONRequest(testingT, router, http.MethodDelete, rulePath, nil, nil)
+	require.Equal(testingT, http.StatusNoContent, recorder.Code)
+	var remainingAlerts int64
+	require.NoError(testingT, harness.database.Model(&model.TrafficAlert{}).Count(&remainingAlerts).Error)
+	require.Zero(testingT, remainingAlerts)
+}
+
+func TestTrafficAlertRulesRequireSiteAccess(testingT *testing.T) {
+	harness := newTrafficAlertHarness(testingT)
+	router := newTrafficAlertRouter(harness, &api.CurrentUser{Email: "stranger@example.com", Role: api.RoleUser})
+
+	recorder := performJSONRequest(testingT, router, http.MethodGet, "/api/sites/"+harness.site.ID+"/traffic-alerts/rules", nil, nil)
+	require.Equal(testingT, http.StatusForbidden, recorder.Code)
+}
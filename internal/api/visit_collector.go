@@ -8,6 +8,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 
+	"github.com/MarkoPoloResearchLab/loopaware/internal/metrics"
 	"github.com/MarkoPoloResearchLab/loopaware/internal/model"
 )
 
@@ -44,13 +45,13 @@ var visitBotUserAgentTokens = [...]string{
 func (h *PublicHandlers) CollectVisit(context *gin.Context) {
 	siteID := strings.TrimSpace(context.Query(visitQuerySiteID))
 	if siteID == "" {
-		context.String(http.StatusBadRequest, "missing site_id")
+		h.rejectVisit(context, http.StatusBadRequest, "missing_site_id", "missing site_id")
 		return
 	}
 
 	var site model.Site
 	if err := h.database.First(&site, "id = ?", siteID).Error; err != nil {
-		context.String(http.StatusNotFound, "/* unknown site */")
+		h.rejectVisit(context, http.StatusNotFound, errorValueInvalidSite, "/* unknown site */")
 		return
 	}
 
@@ -64,7 +65,7 @@ func (h *PublicHandlers) CollectVisit(context *gin.Context) {
 	rawURL := strings.TrimSpace(context.Query(visitQueryURL))
 	allowedOrigins := mergedAllowedOrigins(site.AllowedOrigin, site.TrafficAllowedOrigins)
 	if !isOriginAllowed(allowedOrigins, originHeader, refererHeader, rawURL) {
-		context.String(http.StatusForbidden, "/* origin_forbidden */")
+		h.rejectVisit(context, http.StatusForbidden, "origin_forbidden", "/* origin_forbidden */")
 		return
 	}
 	if rawURL == "" && referrerValue != "" {
@@ -91,23 +92,25 @@ func (h *PublicHandlers) CollectVisit(context *gin.Context) {
 	visit, err := model.NewSiteVisit(input)
 	if err != nil {
 		if h.logger != nil {
-			h.logger.Debug("visit_validation_failed", zap.Error(err))
+			requestLogger(context, h.logger).Debug("visit_validation_failed", zap.Error(err))
 		}
 		if strings.Contains(err.Error(), "invalid_visit_id") {
-			context.String(http.StatusBadRequest, "/* "+errorValueInvalidVisitorID+" */")
+			h.rejectVisit(context, http.StatusBadRequest, errorValueInvalidVisitorID, "/* "+errorValueInvalidVisitorID+" */")
 			return
 		}
-		context.String(http.StatusBadRequest, "/* "+errorValueInvalidURL+" */")
+		h.rejectVisit(context, http.StatusBadRequest, errorValueInvalidURL, "/* "+errorValueInvalidURL+" */")
 		return
 	}
 
 	if err := h.database.Create(&visit).Error; err != nil {
 		if h.logger != nil {
-			h.logger.Warn("visit_save_failed", zap.Error(err))
+			requestLogger(context, h.logger).Warn("visit_save_failed", zap.Error(err))
 		}
-		context.String(http.StatusInternalServerError, "/* save_failed */")
+		h.rejectVisit(context, http.StatusInternalServerError, errorValueSaveFailed, "/* save_failed */")
 		return
 	}
+	h.visitorPresence.Record(visit)
+	h.metrics.SubmissionAccepted(metrics.SubmissionVisit)
 
 	context.Header("Content-Type", visitPixelContentType)
 	context.Header("Cache-Control", "no-store, no-cache, must-revalidate, max-age=0")
@@ -115,6 +118,12 @@ func (h *PublicHandlers) CollectVisit(context *gin.Context) {
 	context.Data(http.StatusOK, visitPixelContentType, []byte(visitPixelBody))
 }
 
+// rejectVisit counts a refused visit under reason and answers with a plain-text body the pixel ignores.
+func (h *PublicHandlers) rejectVisit(context *gin.Context, status int, reason string, body string) {
+	h.metrics.SubmissionRejected(metrics.SubmissionVisit, reason)
+	context.String(status, body)
+}
+
 func isLikelyBotUserAgent(userAgentValue string) bool {
 	normalizedUserAgent := strings.ToLower(strings.TrimSpace(userAgentValue))
 	if normalizedUserAgent == "" {
@@ -0,0 +1,184 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/MarkoPoloResearchLab/loopaware/internal/model"
+)
+
+const (
+	visitExportFilePrefix       = "visits"
+	visitRollupExportFilePrefix = "visit-rollups"
+)
+
+var visitExportCSVHeader = []string{
+	"id",
+	"url",
+	"path",
+	"visitor_id",
+	"ip",
+	"user_agent",
+	"referrer",
+	"is_bot",
+	"status",
+	"occurred_at",
+	"source",
+	"medium",
+	"campaign",
+}
+
+var visitRollupExportCSVHeader = []string{
+	"date",
+	"page_views",
+	"unique_visitors",
+}
+
+type visitExportRecord struct {
+	ID         string `json:"id"`
+	URL        string `json:"url"`
+	Path       string `json:"path"`
+	VisitorID  string `json:"visitor_id"`
+	IP         string `json:"ip"`
+	UserAgent  string `json:"user_agent"`
+	Referrer   string `json:"referrer"`
+	IsBot      bool   `json:"is_bot"`
+	Status     string `json:"status"`
+	OccurredAt int64  `json:"occurred_at"`
+	Source     string `json:"source"`
+	Medium     string `json:"medium"`
+	Campaign   string `json:"campaign"`
+}
+
+// ExportVisits streams raw page views, bots included, oldest first as CSV or JSON Lines with derived attribution fields.
+func (handlers *SiteHandlers) ExportVisits(context *gin.Context) {
+	site, format, dateRange, ok := handlers.resolveExportRequest(context)
+	if !ok {
+		return
+	}
+
+	query := handlers.database.WithContext(context.Request.Context()).Model(&model.SiteVisit{}).Where("site_id = ?", site.ID)
+	if condition, arguments := dateRange.apply("occurred_at"); condition != "" {
+		query = query.Where(condition, arguments...)
+	}
+	rows, rowsErr := query.Order("occurred_at asc").Order("id").Rows()
+	if rowsErr != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{jsonKeyError: errorValueQueryFailed})
+		return
+	}
+	defer rows.Close()
+
+	writeExportHeaders(context, exportFileName(visitExportFilePrefix, site.ID, format), format)
+	rowWriter, writerErr := newExportRowWriter(context.Writer, format, visitExportCSVHeader)
+	if writerErr != nil {
+		requestLogger(context, handlers.logger).Warn("export_visits_write", zap.Error(writerErr), zap.String("site_id", site.ID))
+		return
+	}
+
+	for rows.Next() {
+		var visit model.SiteVisit
+		if scanErr := handlers.database.ScanRows(rows, &visit); scanErr != nil {
+			requestLogger(context, handlers.logger).Warn("export_visits_scan", zap.Error(scanErr), zap.String("site_id", site.ID))
+			return
+		}
+		source, medium, campaign := resolveVisitAttribution(visit.URL, visit.Referrer)
+		record := visitExportRecord{
+			ID:         visit.ID,
+			URL:        visit.URL,
+			Path:       visit.Path,
+			VisitorID:  visit.VisitorID,
+			IP:         visit.IP,
+			UserAgent:  visit.UserAgent,
+			Referrer:   visit.Referrer,
+			IsBot:      visit.IsBot,
+			Status:     visit.Status,
+			OccurredAt: visit.OccurredAt.Unix(),
+			Source:     source,
+			Medium:     medium,
+			Campaign:   campaign,
+		}
+		csvRecord := []string{
+			record.ID,
+			record.URL,
+			record.Path,
+			record.VisitorID,
+			record.IP,
+			record.UserAgent,
+			record.Referrer,
+			strconv.FormatBool(record.IsBot),
+			record.Status,
+			fmt.Sprintf("%d", record.OccurredAt),
+			record.Source,
+			record.Medium,
+			record.Campaign,
+		}
+		if writeErr := rowWriter.Write(record, csvRecord); writeErr != nil {
+			requestLogger(context, handlers.logger).Warn("export_visits_write", zap.Error(writeErr), zap.String("site_id", site.ID))
+			return
+		}
+	}
+	if iterationErr := rows.Err(); iterationErr != nil {
+		requestLogger(context, handlers.logger).Warn("export_visits_rows", zap.Error(iterationErr), zap.String("site_id", site.ID))
+	}
+	if flushErr := rowWriter.Flush(); flushErr != nil {
+		requestLogger(context, handlers.logger).Warn("export_visits_write", zap.Error(flushErr), zap.String("site_id", site.ID))
+	}
+}
+
+// ExportVisitRollups streams the daily page view and unique visitor rollups, oldest day first, as CSV or JSON Lines.
+func (handlers *SiteHandlers) ExportVisitRollups(context *gin.Context) {
+	site, format, dateRange, ok := handlers.resolveExportRequest(context)
+	if !ok {
+		return
+	}
+
+	query := handlers.database.WithContext(context.Request.Context()).Model(&model.SiteVisitRollup{}).Where("site_id = ?", site.ID)
+	if condition, arguments := dateRange.apply("date"); condition != "" {
+		query = query.Where(condition, arguments...)
+	}
+	rows, rowsErr := query.Order("date asc").Rows()
+	if rowsErr != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{jsonKeyError: errorValueQueryFailed})
+		return
+	}
+	defer rows.Close()
+
+	writeExportHeaders(context, exportFileName(visitRollupExportFilePrefix, site.ID, format), format)
+	rowWriter, writerErr := newExportRowWriter(context.Writer, format, visitRollupExportCSVHeader)
+	if writerErr != nil {
+		requestLogger(context, handlers.logger).Warn("export_visit_rollups_write", zap.Error(writerErr), zap.String("site_id", site.ID))
+		return
+	}
+
+	for rows.Next() {
+		var rollup model.SiteVisitRollup
+		if scanErr := handlers.database.ScanRows(rows, &rollup); scanErr != nil {
+			requestLogger(context, handlers.logger).Warn("export_visit_rollups_scan", zap.Error(scanErr), zap.String("site_id", site.ID))
+			return
+		}
+		point := VisitTrendPoint{
+			Date:           rollup.Date.UTC().Format(visitTrendDayLayout),
+			PageViews:      rollup.PageViews,
+			UniqueVisitors: rollup.UniqueVisitors,
+		}
+		csvRecord := []string{
+			point.Date,
+			fmt.Sprintf("%d", point.PageViews),
+			fmt.Sprintf("%d", point.UniqueVisitors),
+		}
+		if writeErr := rowWriter.Write(point, csvRecord); writeErr != nil {
+			requestLogger(context, handlers.logger).Warn("export_visit_rollups_write", zap.Error(writeErr), zap.String("site_id", site.ID))
+			return
+		}
+	}
+	if iterationErr := rows.Err(); iterationErr != nil {
+		requestLogger(context, handlers.logger).Warn("export_visit_rollups_rows", zap.Error(iterationErr), zap.String("site_id", site.ID))
+	}
+	if flushErr := rowWriter.Flush(); flushErr != nil {
+		requestLogger(context, handlers.logger).Warn("export_visit_rollups_write", zap.Error(flushErr), zap.String("site_id", site.ID))
+	}
+}
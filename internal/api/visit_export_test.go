@@ -0,0 +1,115 @@
+package api_test
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+
+	"github.com/MarkoPoloResearchLab/loopaware/internal/api"
+	"github.com/MarkoPoloResearchLab/loopaware/internal/model"
+)
+
+type visitExportHarness struct {
+	siteTestHarness
+	site   model.Site
+	router *gin.Engine
+}
+
+func newVisitExportHarness(testingT *testing.T) visitExportHarness {
+	testingT.Helper()
+	harness := newSiteTestHarness(testingT)
+	site := insertSite(testingT, harness.database, "Traffic Site", "http://traffic.example", testUserEmailAddress)
+
+	router := newAuthenticatedRouter(&api.CurrentUser{Email: testUserEmailAddress, Role: api.RoleUser})
+	router.GET("/api/sites/:id/visits/export", harness.handlers.ExportVisits)
+	router.GET("/api/sites/:id/visits/rollups/export", harness.handlers.ExportVisitRollups)
+	return visitExportHarness{siteTestHarness: harness, site: site, router: router}
+}
+
+func (harness visitExportHarness) insertVisit(testingT *testing.T, input model.SiteVisitInput) model.SiteVisit {
+	testingT.Helper()
+	input.SiteID = harness.site.ID
+	visit, visitErr := model.NewSiteVisit(input)
+	require.NoError(testingT, visitErr)
+	require.NoError(testingT, harness.database.Create(&visit).Error)
+	return visit
+}
+
+func TestExportVisitsIncludesAttributionWithinDateRange(testingT *testing.T) {
+	harness := newVisitExportHarness(testingT)
+	harness.insertVisit(testingT, model.SiteVisitInput{URL: "http://traffic.example/old", Occurred: time.Date(2026, 2, 28, 10, 0, 0, 0, time.UTC)})
+	campaignVisit := harness.insertVisit(testingT, model.SiteVisitInput{URL: "http://traffic.example/pricing?utm_source=newsletter&utm_medium=email&utm_campaign=spring", Occurred: time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)})
+	referralVisit := harness.insertVisit(testingT, model.SiteVisitInput{URL: "http://traffic.example/", Referrer: "https://www.search.example/results", IsBot: true, Occurred: time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)})
+
+	recorder := performJSONRequest(testingT, harness.router, http.MethodGet, "/api/sites/"+harness.site.ID+"/visits/export?from=2026-03-01", nil, nil)
+	require.Equal(testingT, http.StatusOK, recorder.Code, recorder.Body.String())
+	require.Contains(testingT, recorder.Header().Get("Content-Disposition"), "visits-"+harness.site.ID+".csv")
+
+	records, parseErr := csv.NewReader(strings.NewReader(strings.TrimPrefix(recorder.Body.String(), "\ufeff"))).ReadAll()
+	require.NoError(testingT, parseErr)
+	require.Len(testingT, records, 3)
+	require.Equal(testingT, []string{"id", "url", "path", "visitor_id", "ip", "user_agent", "referrer", "is_bot", "status", "occurred_at", "source", "medium", "campaign"}, records[0])
+	require.Equal(testingT, campaignVisit.ID, records[1][0])
+	require.Equal(testingT, "/pricing", records[1][2])
+	require.Equal(testingT, "false", records[1][7])
+	require.Equal(testingT, []string{"newsletter", "email", "spring"}, records[1][10:])
+	require.Equal(testingT, referralVisit.ID, records[2][0])
+	require.Equal(testingT, "true", records[2][7])
+	require.Equal(testingT, []string{"search.example", "referral", "none"}, records[2][10:])
+}
+
+func TestExportVisitsStreamsJSONLines(testingT *testing.T) {
+	harness := newVisitExportHarness(testingT)
+	visit := harness.insertVisit(testingT, model.SiteVisitInput{URL: "http://traffic.example/docs", Occurred: time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)})
+
+	recorder := performJSONRequest(testingT, harness.router, http.MethodGet, "/api/sites/"+harness.site.ID+"/visits/export?format=jsonl", nil, nil)
+	require.Equal(testingT, http.StatusOK, recorder.Code, recorder.Body.String())
+	require.Equal(testingT, "application/x-ndjson", recorder.Header().Get("Content-Type"))
+
+	var exportedVisit map[string]any
+	require.NoError(testingT, json.Unmarshal([]byte(strings.TrimSpace(recorder.Body.String())), &exportedVisit))
+	require.Equal(testingT, visit.ID, exportedVisit["id"])
+	require.Equal(testingT, "direct", exportedVisit["source"])
+	require.Equal(testingT, "direct", exportedVisit["medium"])
+	require.Equal(testingT, float64(visit.OccurredAt.Unix()), exportedVisit["occurred_at"])
+}
+
+func TestExportVisitRollupsStreamsDailyRows(testingT *testing.T) {
+	harness := newVisitExportHarness(testingT)
+	for dayOffset, pageViews := range []int64{5, 7, 9} {
+		rollup, rollupErr := model.NewSiteVisitRollup(harness.site.ID, time.Date(2026, 3, 1+dayOffset, 0, 0, 0, 0, time.UTC), pageViews, pageViews-1)
+		require.NoError(testingT, rollupErr)
+		require.NoError(testingT, harness.database.Create(&rollup).Error)
+	}
+
+	recorder := performJSONRequest(testingT, harness.router, http.MethodGet, "/api/sites/"+harness.site.ID+"/visits/rollups/export?format=jsonl&to=2026-03-02", nil, nil)
+	require.Equal(testingT, http.StatusOK, recorder.Code, recorder.Body.String())
+	require.Contains(testingT, recorder.Header().Get("Content-Disposition"), "visit-rollups-"+harness.site.ID+".jsonl")
+
+	var points []api.VisitTrendPoint
+	scanner := bufio.NewScanner(recorder.Body)
+	for scanner.Scan() {
+		var point api.VisitTrendPoint
+		require.NoError(testingT, json.Unmarshal(scanner.Bytes(), &point))
+		points = append(points, point)
+	}
+	require.Equal(testingT, []api.VisitTrendPoint{
+		{Date: "2026-03-01", PageViews: 5, UniqueVisitors: 4},
+		{Date: "2026-03-02", PageViews: 7, UniqueVisitors: 6},
+	}, points)
+}
+
+func TestExportVisitsRejectsInvalidFormat(testingT *testing.T) {
+	harness := newVisitExportHarness(testingT)
+
+	recorder := performJSONRequest(testingT, harness.router, http.MethodGet, "/api/sites/"+harness.site.ID+"/visits/rollups/export?format=parquet", nil, nil)
+	require.Equal(testingT, http.StatusBadRequest, recorder.Code)
+	require.Contains(testingT, recorder.Body.String(), "invalid_format")
+}
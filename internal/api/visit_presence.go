@@ -0,0 +1,448 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/MarkoPoloResearchLab/loopaware/internal/model"
+	"github.com/MarkoPoloResearchLab/loopaware/internal/task"
+)
+
+const (
+	// DefaultVisitorPresenceWindow is how long a visitor counts as live after their last page view.
+	DefaultVisitorPresenceWindow = 5 * time.Minute
+
+	visitorPresencePruneInterval      = 30 * time.Second
+	visitorPresenceMaxVisitorsPerSite = 10000
+	visitorPresenceTopEntries         = 10
+	visitorPresenceStreamInterval     = time.Second
+	visitorPresenceEventName          = "visitors_live"
+)
+
+type VisitorPresenceOption func(*VisitorPresenceTracker)
+
+// VisitorPresenceCount is one page or referrer with the number of live visitors on it.
+type VisitorPresenceCount struct {
+	Value    string `json:"value"`
+	Visitors int    `json:"visitors"`
+}
+
+// VisitorPresenceSnapshot describes the visitors active on a site within the presence window.
+type VisitorPresenceSnapshot struct {
+	SiteID         string                 `json:"site_id"`
+	ActiveVisitors int                    `json:"active_visitors"`
+	Pages          []VisitorPresenceCount `json:"pages"`
+	Referrers      []VisitorPresenceCount `json:"referrers"`
+	WindowSeconds  int64                  `json:"window_seconds"`
+	GeneratedAt    int64                  `json:"generated_at"`
+}
+
+type visitorPresenceEntry struct {
+	path     string
+	referrer string
+	lastSeen time.Time
+}
+
+type siteVisitorPresence struct {
+	visitors    map[string]visitorPresenceEntry
+	subscribers map[int64]chan struct{}
+}
+
+// VisitorPresenceTracker keeps the visitors seen in the last few minutes per site in memory and
+// tells subscribers when a site's live audience changes.
+type VisitorPresenceTracker struct {
+	mutex  sync.Mutex
+	window time.Duration
+	now    func() time.Time
+	sites  map[string]*siteVisitorPresence
+	nextID int64
+	closed bool
+
+	scheduler *task.Scheduler
+	startOnce sync.Once
+	stopOnce  sync.Once
+}
+
+// NewVisitorPresenceTracker constructs a tracker that considers visitors live for window after their last page view.
+func NewVisitorPresenceTracker(window time.Duration, options ...VisitorPresenceOption) *VisitorPresenceTracker {
+	if window <= 0 {
+		window = DefaultVisitorPresenceWindow
+	}
+	tracker := &VisitorPresenceTracker{
+		window: window,
+		now:    time.Now,
+		sites:  make(map[string]*siteVisitorPresence),
+	}
+	for _, option := range options {
+		if option != nil {
+			option(tracker)
+		}
+	}
+	tracker.scheduler = task.NewScheduler(visitorPresencePruneInterval, func(context.Context) {
+		tracker.Prune()
+	})
+	return tracker
+}
+
+// WithVisitorPresenceClock overrides the tracker clock.
+func WithVisitorPresenceClock(clock func() time.Time) VisitorPresenceOption {
+	return func(tracker *VisitorPresenceTracker) {
+		if clock != nil {
+			tracker.now = clock
+		}
+	}
+}
+
+// Start begins periodically dropping visitors that left the presence window.
+func (tracker *VisitorPresenceTracker) Start(ctx context.Context) {
+	if tracker == nil {
+		return
+	}
+	tracker.startOnce.Do(func() {
+		tracker.scheduler.Start(ctx)
+	})
+}
+
+// Stop halts pruning and closes every subscription.
+func (tracker *VisitorPresenceTracker) Stop() {
+	if tracker == nil {
+		return
+	}
+	tracker.stopOnce.Do(func() {
+		tracker.scheduler.Stop()
+		tracker.mutex.Lock()
+		defer tracker.mutex.Unlock()
+		tracker.closed = true
+		for _, presence := range tracker.sites {
+			for identifier, channel := range presence.subscribers {
+				close(channel)
+				delete(presence.subscribers, identifier)
+			}
+		}
+	})
+}
+
+// Record marks the visitor behind visit as live on its current page. Bot visits are ignored.
+func (tracker *VisitorPresenceTracker) Record(visit model.SiteVisit) {
+	if tracker == nil || visit.IsBot {
+		return
+	}
+	siteID := strings.TrimSpace(visit.SiteID)
+	visitorKey := visitorPresenceKey(visit)
+	if siteID == "" || visitorKey == "" {
+		return
+	}
+	seenAt := visit.OccurredAt
+	if seenAt.IsZero() {
+		seenAt = tracker.now()
+	}
+
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+	if tracker.closed {
+		return
+	}
+	presence := tracker.sitePresence(siteID)
+	if _, known := presence.visitors[visitorKey]; !known && len(presence.visitors) >= visitorPresenceMaxVisitorsPerSite {
+		tracker.pruneSite(presence, tracker.now())
+		if len(presence.visitors) >= visitorPresenceMaxVisitorsPerSite {
+			return
+		}
+	}
+	presence.visitors[visitorKey] = visitorPresenceEntry{
+		path:     visit.Path,
+		referrer: normalizeReferrerHost(visit.Referrer),
+		lastSeen: seenAt,
+	}
+	notifyVisitorPresenceSubscribers(presence)
+}
+
+// Prune drops visitors whose last page view is older than the presence window.
+func (tracker *VisitorPresenceTracker) Prune() {
+	if tracker == nil {
+		return
+	}
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+	now := tracker.now()
+	for siteID, presence := range tracker.sites {
+		if tracker.pruneSite(presence, now) {
+			notifyVisitorPresenceSubscribers(presence)
+		}
+		if len(presence.visitors) == 0 && len(presence.subscribers) == 0 {
+			delete(tracker.sites, siteID)
+		}
+	}
+}
+
+// Snapshot summarizes the live visitors of a site, counting each visitor once on their latest page.
+func (tracker *VisitorPresenceTracker) Snapshot(siteID string) VisitorPresenceSnapshot {
+	snapshot := VisitorPresenceSnapshot{
+		SiteID:    siteID,
+		Pages:     []VisitorPresenceCount{},
+		Referrers: []VisitorPresenceCount{},
+	}
+	if tracker == nil {
+		return snapshot
+	}
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+	now := tracker.now()
+	snapshot.WindowSeconds = int64(tracker.window / time.Second)
+	snapshot.GeneratedAt = now.UTC().Unix()
+	presence, exists := tracker.sites[siteID]
+	if !exists {
+		return snapshot
+	}
+	cutoff := now.Add(-tracker.window)
+	pageCounts := make(map[string]int)
+	referrerCounts := make(map[string]int)
+	for _, entry := range presence.visitors {
+		if entry.lastSeen.Before(cutoff) {
+			continue
+		}
+		snapshot.ActiveVisitors++
+		if entry.path != "" {
+			pageCounts[entry.path]++
+		}
+		if entry.referrer != "" {
+			referrerCounts[entry.referrer]++
+		}
+	}
+	snapshot.Pages = topVisitorPresenceCounts(pageCounts)
+	snapshot.Referrers = topVisitorPresenceCounts(referrerCounts)
+	return snapshot
+}
+
+// Subscribe returns a subscription that signals whenever the live audience of siteID changes.
+func (tracker *VisitorPresenceTracker) Subscribe(siteID string) *VisitorPresenceSubscription {
+	if tracker == nil {
+		return nil
+	}
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+	if tracker.closed {
+		return nil
+	}
+	subscriptionID := tracker.nextID
+	tracker.nextID++
+	updates := make(chan struct{}, 1)
+	tracker.sitePresence(siteID).subscribers[subscriptionID] = updates
+	return &VisitorPresenceSubscription{
+		tracker:    tracker,
+		siteID:     siteID,
+		identifier: subscriptionID,
+		updates:    updates,
+	}
+}
+
+// SubscriberCount reports the number of open live visitor subscriptions across all sites.
+func (tracker *VisitorPresenceTracker) SubscriberCount() int {
+	if tracker == nil {
+		return 0
+	}
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+	count := 0
+	for _, presence := range tracker.sites {
+		count += len(presence.subscribers)
+	}
+	return count
+}
+
+func (tracker *VisitorPresenceTracker) sitePresence(siteID string) *siteVisitorPresence {
+	presence, exists := tracker.sites[siteID]
+	if !exists {
+		presence = &siteVisitorPresence{
+			visitors:    make(map[string]visitorPresenceEntry),
+			subscribers: make(map[int64]chan struct{}),
+		}
+		tracker.sites[siteID] = presence
+	}
+	return presence
+}
+
+func (tracker *VisitorPresenceTracker) pruneSite(presence *siteVisitorPresence, now time.Time) bool {
+	cutoff := now.Add(-tracker.window)
+	removed := false
+	for visitorKey, entry := range presence.visitors {
+		if entry.lastSeen.Before(cutoff) {
+			delete(presence.visitors, visitorKey)
+			removed = true
+		}
+	}
+	return removed
+}
+
+func (tracker *VisitorPresenceTracker) unsubscribe(siteID string, identifier int64) {
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+	presence, exists := tracker.sites[siteID]
+	if !exists {
+		return
+	}
+	if channel, subscribed := presence.subscribers[identifier]; subscribed {
+		delete(presence.subscribers, identifier)
+		close(channel)
+	}
+}
+
+// VisitorPresenceSubscription receives change signals for one site. Signals coalesce, so a receiver
+// should read a fresh snapshot after each one rather than count them.
+type VisitorPresenceSubscription struct {
+	tracker    *VisitorPresenceTracker
+	siteID     string
+	identifier int64
+	updates    chan struct{}
+	once       sync.Once
+}
+
+// Updates exposes the change signal channel; it is closed when the tracker stops.
+func (subscription *VisitorPresenceSubscription) Updates() <-chan struct{} {
+	if subscription == nil {
+		return nil
+	}
+	return subscription.updates
+}
+
+// Close releases the subscription.
+func (subscription *VisitorPresenceSubscription) Close() {
+	if subscription == nil {
+		return
+	}
+	subscription.once.Do(func() {
+		subscription.tracker.unsubscribe(subscription.siteID, subscription.identifier)
+	})
+}
+
+func notifyVisitorPresenceSubscribers(presence *siteVisitorPresence) {
+	for _, channel := range presence.subscribers {
+		select {
+		case channel <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func visitorPresenceKey(visit model.SiteVisit) string {
+	visitorID := strings.TrimSpace(visit.VisitorID)
+	if visitorID != "" {
+		return visitorID
+	}
+	clientIP := strings.TrimSpace(visit.IP)
+	if clientIP == "" {
+		return ""
+	}
+	return clientIP + "|" + strings.TrimSpace(visit.UserAgent)
+}
+
+func topVisitorPresenceCounts(counts map[string]int) []VisitorPresenceCount {
+	entries := make([]VisitorPresenceCount, 0, len(counts))
+	for value, visitors := range counts {
+		entries = append(entries, VisitorPresenceCount{Value: value, Visitors: visitors})
+	}
+	sort.Slice(entries, func(left, right int) bool {
+		if entries[left].Visitors != entries[right].Visitors {
+			return entries[left].Visitors > entries[right].Visitors
+		}
+		return entries[left].Value < entries[right].Value
+	})
+	if len(entries) > visitorPresenceTopEntries {
+		entries = entries[:visitorPresenceTopEntries]
+	}
+	return entries
+}
+
+// WithVisitorPresence feeds every recorded visit into tracker.
+func (h *PublicHandlers) WithVisitorPresence(tracker *VisitorPresenceTracker) *PublicHandlers {
+	h.visitorPresence = tracker
+	return h
+}
+
+// WithVisitorPresence lets site handlers stream live visitors from tracker.
+func (handlers *SiteHandlers) WithVisitorPresence(tracker *VisitorPresenceTracker) *SiteHandlers {
+	handlers.visitorPresence = tracker
+	return handlers
+}
+
+// StreamLiveVisitors pushes the live visitor snapshot of a site over SSE: once on connect and then
+// at most once per second while the audience keeps changing.
+func (handlers *SiteHandlers) StreamLiveVisitors(context *gin.Context) {
+	site, _, ok := handlers.resolveAuthorizedSite(context)
+	if !ok {
+		return
+	}
+	subscription := handlers.visitorPresence.Subscribe(site.ID)
+	if subscription == nil {
+		context.JSON(http.StatusServiceUnavailable, gin.H{jsonKeyError: errorValueStreamUnavailable})
+		return
+	}
+	defer subscription.Close()
+
+	context.Header("Content-Type", "text/event-stream")
+	context.Header("Cache-Control", "no-cache")
+	context.Header("Connection", "keep-alive")
+
+	flusher, flushable := context.Writer.(http.Flusher)
+	if !flushable {
+		context.JSON(http.StatusServiceUnavailable, gin.H{jsonKeyError: errorValueStreamUnavailable})
+		return
+	}
+
+	context.Writer.WriteHeaderNow()
+	flusher.Flush()
+
+	if !handlers.writeLiveVisitorSnapshot(context, flusher, site.ID) {
+		return
+	}
+
+	ticker := time.NewTicker(visitorPresenceStreamInterval)
+	defer ticker.Stop()
+	heartbeat := time.NewTicker(handlers.heartbeatInterval())
+	defer heartbeat.Stop()
+	pendingUpdate := false
+	requestContext := context.Request.Context()
+	for {
+		select {
+		case <-requestContext.Done():
+			return
+		case _, open := <-subscription.Updates():
+			if !open {
+				writeServerShutdownEvent(context.Writer, flusher)
+				return
+			}
+			pendingUpdate = true
+		case <-heartbeat.C:
+			if !writeServerSentHeartbeat(context.Writer, flusher) {
+				return
+			}
+		case <-ticker.C:
+			if !pendingUpdate {
+				continue
+			}
+			pendingUpdate = false
+			if !handlers.writeLiveVisitorSnapshot(context, flusher, site.ID) {
+				return
+			}
+		}
+	}
+}
+
+func (handlers *SiteHandlers) writeLiveVisitorSnapshot(context *gin.Context, flusher http.Flusher, siteID string) bool {
+	serializedPayload, marshalErr := json.Marshal(handlers.visitorPresence.Snapshot(siteID))
+	if marshalErr != nil {
+		if handlers.logger != nil {
+			requestLogger(context, handlers.logger).Debug("marshal_live_visitors_failed", zap.Error(marshalErr))
+		}
+		return true
+	}
+	return writeServerSentEvent(context.Writer, flusher, 0, visitorPresenceEventName, serializedPayload)
+}
@@ -0,0 +1,146 @@
+package api_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/MarkoPoloResearchLab/loopaware/internal/api"
+	"github.com/MarkoPoloResearchLab/loopaware/internal/model"
+)
+
+func TestVisitorPresenceTrackerSnapshotsLiveVisitors(testingT *testing.T) {
+	now := time.Date(2026, 4, 2, 9, 0, 0, 0, time.UTC)
+	tracker := api.NewVisitorPresenceTracker(5*time.Minute, api.WithVisitorPresenceClock(func() time.Time { return now }))
+	subscription := tracker.Subscribe("site-live")
+	require.NotNil(testingT, subscription)
+	defer subscription.Close()
+
+	tracker.Record(model.SiteVisit{SiteID: "site-live", VisitorID: "visitor-a", Path: "/", Referrer: "https://www.search.example/q", OccurredAt: now.Add(-time.Minute)})
+	tracker.Record(model.SiteVisit{SiteID: "site-live", VisitorID: "visitor-a", Path: "/pricing", Referrer: "https://www.search.example/q", OccurredAt: now})
+	tracker.Record(model.SiteVisit{SiteID: "site-live", IP: "203.0.113.9", UserAgent: "Firefox", Path: "/pricing", OccurredAt: now.Add(-4 * time.Minute)})
+	tracker.Record(model.SiteVisit{SiteID: "site-live", VisitorID: "crawler", Path: "/", IsBot: true, OccurredAt: now})
+	tracker.Record(model.SiteVisit{SiteID: "other-site", VisitorID: "visitor-b", Path: "/", OccurredAt: now})
+
+	select {
+	case <-subscription.Updates():
+	default:
+		testingT.Fatal("expected a presence update signal")
+	}
+
+	snapshot := tracker.Snapshot("site-live")
+	require.Equal(testingT, 2, snapshot.ActiveVisitors)
+	require.Equal(testingT, []api.VisitorPresenceCount{{Value: "/pricing", Visitors: 2}}, snapshot.Pages)
+	require.Equal(testingT, []api.VisitorPresenceCount{{Value: "search.example", Visitors: 1}}, snapshot.Referrers)
+	require.Equal(testingT, int64(300), snapshot.WindowSeconds)
+	require.Equal(testingT, now.Unix(), snapshot.GeneratedAt)
+
+	now = now.Add(2 * time.Minute)
+	tracker.Prune()
+	select {
+	case <-subscription.Updates():
+	default:
+		testingT.Fatal("expected a presence update signal after pruning")
+	}
+	snapshot = tracker.Snapshot("site-live")
+	require.Equal(testingT, 1, snapshot.ActiveVisitors)
+
+	tracker.Stop()
+	_, open := <-subscription.Updates()
+	require.False(testingT, open)
+	require.Nil(testingT, tracker.Subscribe("site-live"))
+}
+
+func TestCollectVisitRecordsVisitorPresence(testingT *testing.T) {
+	harness := newSiteTestHarness(testingT)
+	site := insertSite(testingT, harness.database, "Live Collect", "http://live.example", testUserEmailAddress)
+	tracker := api.NewVisitorPresenceTracker(api.DefaultVisitorPresenceWindow)
+	publicHandlers := api.NewPublicHandlers(harness.database, zap.NewNop(), nil, nil, nil, nil, false, "", "", nil).WithVisitorPresence(tracker)
+	router := gin.New()
+	router.GET("/public/visits", publicHandlers.CollectVisit)
+
+	request := httptest.NewRequest(http.MethodGet, "/public/visits?site_id="+site.ID+"&url=http://live.example/docs", nil)
+	request.Header.Set("Origin", "http://live.example")
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	require.Equal(testingT, http.StatusOK, recorder.Code)
+
+	snapshot := tracker.Snapshot(site.ID)
+	require.Equal(testingT, 1, snapshot.ActiveVisitors)
+	require.Equal(testingT, []api.VisitorPresenceCount{{Value: "/docs", Visitors: 1}}, snapshot.Pages)
+}
+
+func TestStreamLiveVisitorsRejectsUnmanagedSite(testingT *testing.T) {
+	harness := newSiteTestHarness(testingT)
+	site := insertSite(testingT, harness.database, "Live Forbidden", "http://forbidden.example", "someone-else@example.com")
+	handlers := harness.handlers.WithVisitorPresence(api.NewVisitorPresenceTracker(api.DefaultVisitorPresenceWindow))
+
+	recorder, ginContext := newJSONContext(http.MethodGet, "/api/sites/"+site.ID+"/visits/live", nil)
+	ginContext.Params = gin.Params{{Key: "id", Value: site.ID}}
+	ginContext.Set(testSessionContextKey, &api.CurrentUser{Email: testUserEmailAddress, Role: api.RoleUser})
+
+	handlers.StreamLiveVisitors(ginContext)
+	require.Equal(testingT, http.StatusForbidden, recorder.Code)
+}
+
+func TestStreamLiveVisitorsSendsSnapshots(testingT *testing.T) {
+	harness := newSiteTestHarness(testingT)
+	site := insertSite(testingT, harness.database, "Live Stream", "http://stream-live.example", testUserEmailAddress)
+	tracker := api.NewVisitorPresenceTracker(api.DefaultVisitorPresenceWindow)
+	handlers := harness.handlers.WithVisitorPresence(tracker)
+	router := gin.New()
+	router.GET("/api/sites/:id/visits/live", func(ginContext *gin.Context) {
+		ginContext.Set(testSessionContextKey, &api.CurrentUser{Email: testUserEmailAddress, Role: api.RoleUser})
+		handlers.StreamLiveVisitors(ginContext)
+	})
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	requestContext, cancelRequest := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelRequest()
+	request, requestErr := http.NewRequestWithContext(requestContext, http.MethodGet, server.URL+"/api/sites/"+site.ID+"/visits/live", nil)
+	require.NoError(testingT, requestErr)
+	response, responseErr := server.Client().Do(request)
+	require.NoError(testingT, responseErr)
+	defer response.Body.Close()
+	require.Equal(testingT, http.StatusOK, response.StatusCode)
+	require.Equal(testingT, "text/event-stream", response.Header.Get("Content-Type"))
+
+	reader := bufio.NewReader(response.Body)
+	initial := readLiveVisitorEvent(testingT, reader)
+	require.Equal(testingT, site.ID, initial.SiteID)
+	require.Zero(testingT, initial.ActiveVisitors)
+
+	tracker.Record(model.SiteVisit{SiteID: site.ID, VisitorID: "visitor-live", Path: "/blog", OccurredAt: time.Now()})
+	updated := readLiveVisitorEvent(testingT, reader)
+	require.Equal(testingT, 1, updated.ActiveVisitors)
+	require.Equal(testingT, []api.VisitorPresenceCount{{Value: "/blog", Visitors: 1}}, updated.Pages)
+}
+
+func readLiveVisitorEvent(testingT *testing.T, reader *bufio.Reader) api.VisitorPresenceSnapshot {
+	testingT.Helper()
+	eventName := ""
+	for {
+		line, readErr := reader.ReadString('\n')
+		require.NoError(testingT, readErr)
+		line = strings.TrimRight(line, "\n")
+		switch {
+		case strings.HasPrefix(line, "event: "):
+			eventName = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			require.Equal(testingT, "visitors_live", eventName)
+			var snapshot api.VisitorPresenceSnapshot
+			require.NoError(testingT, json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &snapshot))
+			return snapshot
+		}
+	}
+}
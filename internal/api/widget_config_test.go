@@ -0,0 +1,175 @@
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+
+	"github.com/MarkoPoloResearchLab/loopaware/internal/api"
+	"github.com/MarkoPoloResearchLab/loopaware/internal/model"
+)
+
+const (
+	testWidgetConfigOrigin = "http://widget-branding.example"
+)
+
+func TestCreateAndUpdateSiteValidateWidgetConfig(testingT *testing.T) {
+	harness := newSiteTestHarness(testingT)
+	adminUser := &api.CurrentUser{Email: testAdminEmailAddress, Role: api.RoleAdmin}
+
+	recorder, context := newJSONContext(http.MethodPost, "/api/sites", map[string]any{
+		"name":           "Branded Widget",
+		"allowed_origin": testWidgetConfigOrigin,
+		"widget_config": map[string]any{
+			"brand_color":  "#F60",
+			"theme":        "dark",
+			"contact_mode": "optional",
+			"extra_field":  map[string]any{"type": "rating", "required": true},
+			"copy":         map[string]any{"header_text": "Talk to us"},
+		},
+	})
+	context.Set(testSessionContextKey, adminUser)
+	harness.handlers.CreateSite(context)
+	require.Equal(testingT, http.StatusOK, recorder.Code, recorder.Body.String())
+
+	var createdSite model.Site
+	require.NoError(testingT, harness.database.First(&createdSite, "name = ?", "Branded Widget").Error)
+	require.Equal(testingT, "#ff6600", createdSite.WidgetConfig.BrandColor)
+	require.Equal(testingT, model.WidgetThemeDark, createdSite.WidgetConfig.Theme)
+	require.False(testingT, createdSite.WidgetConfig.ContactRequired())
+	require.Equal(testingT, model.WidgetExtraFieldRating, createdSite.WidgetConfig.ExtraField.Type)
+
+	invalidRecorder, invalidContext := newJSONContext(http.MethodPost, "/api/sites", map[string]any{
+		"name":           "Invalid Widget",
+		"allowed_origin": "http://invalid-widget.example",
+		"widget_config":  map[string]any{"theme": "neon"},
+	})
+	invalidContext.Set(testSessionContextKey, adminUser)
+	harness.handlers.CreateSite(invalidContext)
+	require.Equal(testingT, http.StatusBadRequest, invalidRecorder.Code)
+	var invalidBody map[string]string
+	require.NoError(testingT, json.Unmarshal(invalidRecorder.Body.Bytes(), &invalidBody))
+	require.Equal(testingT, "invalid_widget_config", invalidBody[jsonErrorKey])
+	require.Equal(testingT, "theme", invalidBody["field"])
+
+	updateRecorder, updateContext := newJSONContext(http.MethodPatch, "/api/sites/"+createdSite.ID, map[string]any{
+		"widget_config": map[string]any{
+			"extra_field": map[string]any{"type": "select", "options": []string{"Bug", "Idea"}},
+			"locales":     map[string]any{"de": map[string]any{"submit_label": "Senden"}},
+		},
+	})
+	updateContext.Params = gin.Params{{Key: "id", Value: createdSite.ID}}
+	updateContext.Set(testSessionContextKey, adminUser)
+	harness.handlers.UpdateSite(updateContext)
+	require.Equal(testingT, http.StatusOK, updateRecorder.Code, updateRecorder.Body.String())
+
+	var updateBody struct {
+		WidgetConfig model.WidgetConfig `json:"widget_config"`
+	}
+	require.NoError(testingT, json.Unmarshal(updateRecorder.Body.Bytes(), &updateBody))
+	require.Equal(testingT, model.WidgetThemeAuto, updateBody.WidgetConfig.Theme)
+	require.Equal(testingT, model.WidgetContactRequired, updateBody.WidgetConfig.ContactMode)
+	require.Equal(testingT, []string{"Bug", "Idea"}, updateBody.WidgetConfig.ExtraField.Options)
+
+	rejectedRecorder, rejectedContext := newJSONContext(http.MethodPatch, "/api/sites/"+createdSite.ID, map[string]any{
+		"widget_config": map[string]any{"extra_field": map[string]any{"type": "select", "options": []string{"Only"}}},
+	})
+	rejectedContext.Params = gin.Params{{Key: "id", Value: createdSite.ID}}
+	rejectedContext.Set(testSessionContextKey, adminUser)
+	harness.handlers.UpdateSite(rejectedContext)
+	require.Equal(testingT, http.StatusBadRequest, rejectedRecorder.Code)
+
+	var unchangedSite model.Site
+	require.NoError(testingT, harness.database.First(&unchangedSite, "id = ?", createdSite.ID).Error)
+	require.Equal(testingT, model.WidgetExtraFieldSelect, unchangedSite.WidgetConfig.ExtraField.Type)
+	require.Equal(testingT, "Senden", unchangedSite.WidgetConfig.ResolveCopy("de-AT").SubmitLabel)
+}
+
+func TestWidgetConfigServesLocalizedBranding(testingT *testing.T) {
+	apiHarness := buildAPIHarness(testingT, nil, nil, nil)
+	site := insertSite(testingT, apiHarness.database, "Localized Widget", testWidgetConfigOrigin, "owner@example.com")
+	widgetConfig, configErr := model.NewWidgetConfig(model.WidgetConfig{
+		BrandColor: "#123456",
+		Theme:      model.WidgetThemeLight,
+		ExtraField: &model.WidgetExtraField{Type: model.WidgetExtraFieldSelect, Options: []string{"Bug", "Idea"}},
+		Copy:       model.WidgetCopy{HeaderText: "Talk to us"},
+		Locales:    map[string]model.WidgetCopy{"fr": {HeaderText: "Parlez-nous", ExtraFieldLabel: "Sujet"}},
+	})
+	require.NoError(testingT, configErr)
+	site.WidgetConfig = widgetConfig
+	require.NoError(testingT, apiHarness.database.Save(&site).Error)
+
+	type widgetConfigPayload struct {
+		BrandColor  string                  `json:"brand_color"`
+		Theme       string                  `json:"theme"`
+		ContactMode string                  `json:"contact_mode"`
+		ExtraField  *model.WidgetExtraField `json:"extra_field"`
+		Copy        model.WidgetCopy        `json:"copy"`
+		Locale      string                  `json:"locale"`
+	}
+
+	queryRecorder := performJSONRequest(testingT, apiHarness.router, http.MethodGet, "/public/widget-config?site_id="+site.ID+"&locale=fr-CA", nil, map[string]string{
+		"Origin": testWidgetConfigOrigin,
+	})
+	require.Equal(testingT, http.StatusOK, queryRecorder.Code)
+	var localized widgetConfigPayload
+	require.NoError(testingT, json.Unmarshal(queryRecorder.Body.Bytes(), &localized))
+	require.Equal(testingT, "#123456", localized.BrandColor)
+	require.Equal(testingT, model.WidgetThemeLight, localized.Theme)
+	require.Equal(testingT, model.WidgetContactRequired, localized.ContactMode)
+	require.Equal(testingT, []string{"Bug", "Idea"}, localized.ExtraField.Options)
+	require.Equal(testingT, "Parlez-nous", localized.Copy.HeaderText)
+	require.Equal(testingT, "Sujet", localized.Copy.ExtraFieldLabel)
+	require.Equal(testingT, "Send", localized.Copy.SubmitLabel)
+	require.Equal(testingT, "fr-ca", localized.Locale)
+
+	headerRecorder := performJSONRequest(testingT, apiHarness.router, http.MethodGet, "/public/widget-config?site_id="+site.ID, nil, map[string]string{
+		"Origin":          testWidgetConfigOrigin,
+		"Accept-Language": "en-US,en;q=0.8",
+	})
+	require.Equal(testingT, http.StatusOK, headerRecorder.Code)
+	var fallback widgetConfigPayload
+	require.NoError(testingT, json.Unmarshal(headerRecorder.Body.Bytes(), &fallback))
+	require.Equal(testingT, "Talk to us", fallback.Copy.HeaderText)
+	require.Equal(testingT, "Choose one", fallback.Copy.ExtraFieldLabel)
+}
+
+func TestCreateFeedbackHonorsWidgetConfig(testingT *testing.T) {
+	apiHarness := buildAPIHarness(testingT, nil, nil, nil)
+	site := insertSite(testingT, apiHarness.database, "Rated Widget", testWidgetConfigOrigin, "owner@example.com")
+	site.WidgetConfig = model.WidgetConfig{
+		ContactMode: model.WidgetContactOptional,
+		ExtraField:  &model.WidgetExtraField{Type: model.WidgetExtraFieldRating, Required: true},
+	}
+	require.NoError(testingT, apiHarness.database.Save(&site).Error)
+	headers := map[string]string{"Origin": testWidgetConfigOrigin}
+
+	missingRatingRecorder := performJSONRequest(testingT, apiHarness.router, http.MethodPost, "/public/feedback", map[string]any{
+		"site_id": site.ID,
+		"message": "No rating",
+	}, headers)
+	require.Equal(testingT, http.StatusBadRequest, missingRatingRecorder.Code)
+	require.JSONEq(testingT, `{"error":"invalid_extra_field"}`, missingRatingRecorder.Body.String())
+
+	acceptedRecorder := performJSONRequest(testingT, apiHarness.router, http.MethodPost, "/public/feedback", map[string]any{
+		"site_id":     site.ID,
+		"message":     "Anonymous five stars",
+		"extra_field": "5",
+	}, headers)
+	require.Equal(testingT, http.StatusOK, acceptedRecorder.Code, acceptedRecorder.Body.String())
+
+	var stored model.Feedback
+	require.NoError(testingT, apiHarness.database.First(&stored, "site_id = ?", site.ID).Error)
+	require.Empty(testingT, stored.Contact)
+	require.Equal(testingT, "5", stored.ExtraField)
+
+	requiredSite := insertSite(testingT, apiHarness.database, "Default Widget", "http://default-widget.example", "owner@example.com")
+	missingContactRecorder := performJSONRequest(testingT, apiHarness.router, http.MethodPost, "/public/feedback", map[string]any{
+		"site_id": requiredSite.ID,
+		"message": "Who am I?",
+	}, map[string]string{"Origin": "http://default-widget.example"})
+	require.Equal(testingT, http.StatusBadRequest, missingContactRecorder.Code)
+}
@@ -0,0 +1,37 @@
+// Package blobstore persists opaque binary objects, such as feedback attachments,
+// either in the application database or in a local filesystem directory.
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+)
+
+const (
+	KindDatabase   = "database"
+	KindFilesystem = "filesystem"
+)
+
+var (
+	ErrNotFound   = errors.New("blob_not_found")
+	ErrInvalidKey = errors.New("invalid_blob_key")
+
+	keyExpression = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]{0,127}$`)
+)
+
+// Store saves, streams, and removes blobs addressed by an opaque key.
+type Store interface {
+	Put(ctx context.Context, key string, data []byte) error
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+func validateKey(key string) error {
+	if !keyExpression.MatchString(key) {
+		return fmt.Errorf("%w: %q", ErrInvalidKey, key)
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package blobstore_test
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/MarkoPoloResearchLab/loopaware/internal/blobstore"
+	"github.com/MarkoPoloResearchLab/loopaware/internal/storage"
+	"github.com/MarkoPoloResearchLab/loopaware/internal/testutil"
+)
+
+func TestStoresRoundTripBlobs(testingT *testing.T) {
+	sqliteDatabase := testutil.NewSQLiteTestDatabase(testingT)
+	database, openErr := storage.OpenDatabase(sqliteDatabase.Configuration())
+	require.NoError(testingT, openErr)
+	require.NoError(testingT, storage.AutoMigrate(database))
+
+	filesystemStore, filesystemErr := blobstore.NewFilesystemStore(filepath.Join(testingT.TempDir(), "blobs"))
+	require.NoError(testingT, filesystemErr)
+
+	stores := map[string]blobstore.Store{
+		blobstore.KindDatabase:   blobstore.NewDatabaseStore(database),
+		blobstore.KindFilesystem: filesystemStore,
+	}
+	for storeName, store := range stores {
+		testingT.Run(storeName, func(testingT *testing.T) {
+			ctx := context.Background()
+			key := storage.NewID()
+
+			_, missingErr := store.Open(ctx, key)
+			require.ErrorIs(testingT, missingErr, blobstore.ErrNotFound)
+
+			require.NoError(testingT, store.Put(ctx, key, []byte("first")))
+			require.NoError(testingT, store.Put(ctx, key, []byte("second")))
+			require.Equal(testingT, "second", readBlob(testingT, store, key))
+
+			require.NoError(testingT, store.Delete(ctx, key))
+			_, deletedErr := store.Open(ctx, key)
+			require.ErrorIs(testingT, deletedErr, blobstore.ErrNotFound)
+			require.NoError(testingT, store.Delete(ctx, key))
+
+			for _, invalidKey := range []string{"", "../escape", "nested/key", ".hidden"} {
+				require.ErrorIs(testingT, store.Put(ctx, invalidKey, []byte("x")), blobstore.ErrInvalidKey)
+			}
+		})
+	}
+}
+
+func TestNewFilesystemStoreRequiresDirectory(testingT *testing.T) {
+	_, err := blobstore.NewFilesystemStore("  ")
+	require.ErrorIs(testingT, err, blobstore.ErrMissingDirectory)
+}
+
+func TestFilesystemStoreLeavesNoTemporaryFiles(testingT *testing.T) {
+	directory := testingT.TempDir()
+	store, storeErr := blobstore.NewFilesystemStore(directory)
+	require.NoError(testingT, storeErr)
+	require.NoError(testingT, store.Put(context.Background(), "attachment-1", []byte("image")))
+
+	entries, readErr := os.ReadDir(directory)
+	require.NoError(testingT, readErr)
+	require.Len(testingT, entries, 1)
+	require.Equal(testingT, "attachment-1", entries[0].Name())
+}
+
+func readBlob(testingT *testing.T, store blobstore.Store, key string) string {
+	testingT.Helper()
+	reader, openErr := store.Open(context.Background(), key)
+	require.NoError(testingT, openErr)
+	defer reader.Close()
+	data, readErr := io.ReadAll(reader)
+	require.NoError(testingT, readErr)
+	return string(data)
+}
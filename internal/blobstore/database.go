@@ -0,0 +1,66 @@
+package blobstore
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/MarkoPoloResearchLab/loopaware/internal/model"
+)
+
+// DatabaseStore keeps blobs in the stored_blobs table of the application database.
+type DatabaseStore struct {
+	database *gorm.DB
+}
+
+// NewDatabaseStore constructs a Store backed by the provided database.
+func NewDatabaseStore(database *gorm.DB) *DatabaseStore {
+	return &DatabaseStore{database: database}
+}
+
+// Put writes data under key, replacing any existing blob.
+func (store *DatabaseStore) Put(ctx context.Context, key string, data []byte) error {
+	if keyErr := validateKey(key); keyErr != nil {
+		return keyErr
+	}
+	blob := model.StoredBlob{BlobKey: key, Data: data}
+	saveErr := store.database.WithContext(ctx).
+		Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "blob_key"}}, DoUpdates: clause.AssignmentColumns([]string{"data"})}).
+		Create(&blob).Error
+	if saveErr != nil {
+		return fmt.Errorf("save blob %s: %w", key, saveErr)
+	}
+	return nil
+}
+
+// Open returns a reader over the blob stored under key.
+func (store *DatabaseStore) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	if keyErr := validateKey(key); keyErr != nil {
+		return nil, keyErr
+	}
+	var blob model.StoredBlob
+	loadErr := store.database.WithContext(ctx).First(&blob, "blob_key = ?", key).Error
+	if errors.Is(loadErr, gorm.ErrRecordNotFound) {
+		return nil, ErrNotFound
+	}
+	if loadErr != nil {
+		return nil, fmt.Errorf("load blob %s: %w", key, loadErr)
+	}
+	return io.NopCloser(bytes.NewReader(blob.Data)), nil
+}
+
+// Delete removes the blob stored under key. Deleting a missing blob is not an error.
+func (store *DatabaseStore) Delete(ctx context.Context, key string) error {
+	if keyErr := validateKey(key); keyErr != nil {
+		return keyErr
+	}
+	if deleteErr := store.database.WithContext(ctx).Delete(&model.StoredBlob{}, "blob_key = ?", key).Error; deleteErr != nil {
+		return fmt.Errorf("delete blob %s: %w", key, deleteErr)
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	filesystemDirectoryMode = 0o750
+	filesystemFileMode      = 0o640
+	filesystemTempPattern   = ".blob-*"
+)
+
+var ErrMissingDirectory = errors.New("blobstore directory is required")
+
+// FilesystemStore keeps each blob as a file named after its key inside a single directory.
+type FilesystemStore struct {
+	directory string
+}
+
+// NewFilesystemStore constructs a Store rooted at directory, creating it when missing.
+func NewFilesystemStore(directory string) (*FilesystemStore, error) {
+	trimmedDirectory := strings.TrimSpace(directory)
+	if trimmedDirectory == "" {
+		return nil, ErrMissingDirectory
+	}
+	absoluteDirectory, absErr := filepath.Abs(trimmedDirectory)
+	if absErr != nil {
+		return nil, fmt.Errorf("resolve blob directory: %w", absErr)
+	}
+	if mkdirErr := os.MkdirAll(absoluteDirectory, filesystemDirectoryMode); mkdirErr != nil {
+		return nil, fmt.Errorf("create blob directory: %w", mkdirErr)
+	}
+	return &FilesystemStore{directory: absoluteDirectory}, nil
+}
+
+// Put writes data under key atomically, replacing any existing blob.
+func (store *FilesystemStore) Put(ctx context.Context, key string, data []byte) error {
+	if keyErr := validateKey(key); keyErr != nil {
+		return keyErr
+	}
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	tempFile, createErr := os.CreateTemp(store.directory, filesystemTempPattern)
+	if createErr != nil {
+		return fmt.Errorf("create blob %s: %w", key, createErr)
+	}
+	tempPath := tempFile.Name()
+	_, writeErr := tempFile.Write(data)
+	closeErr := tempFile.Close()
+	if writeErr == nil {
+		writeErr = closeErr
+	}
+	if writeErr == nil {
+		writeErr = os.Chmod(tempPath, filesystemFileMode)
+	}
+	if writeErr == nil {
+		writeErr = os.Rename(tempPath, store.path(key))
+	}
+	if writeErr != nil {
+		_ = os.Remove(tempPath)
+		return fmt.Errorf("write blob %s: %w", key, writeErr)
+	}
+	return nil
+}
+
+// Open returns a reader over the blob stored under key. Callers must close it.
+func (store *FilesystemStore) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	if keyErr := validateKey(key); keyErr != nil {
+		return nil, keyErr
+	}
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return nil, ctxErr
+	}
+	file, openErr := os.Open(store.path(key))
+	if errors.Is(openErr, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if openErr != nil {
+		return nil, fmt.Errorf("open blob %s: %w", key, openErr)
+	}
+	return file, nil
+}
+
+// Delete removes the blob stored under key. Deleting a missing blob is not an error.
+func (store *FilesystemStore) Delete(ctx context.Context, key string) error {
+	if keyErr := validateKey(key); keyErr != nil {
+		return keyErr
+	}
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	if removeErr := os.Remove(store.path(key)); removeErr != nil && !errors.Is(removeErr, fs.ErrNotExist) {
+		return fmt.Errorf("delete blob %s: %w", key, removeErr)
+	}
+	return nil
+}
+
+func (store *FilesystemStore) path(key string) string {
+	return filepath.Join(store.directory, key)
+}
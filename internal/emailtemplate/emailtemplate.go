@@ -0,0 +1,329 @@
+// Package emailtemplate renders the transactional emails LoopAware sends, using
+// built-in defaults or per-site overrides written in Go template syntax.
+package emailtemplate
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+
+	"go.uber.org/zap"
+
+	"github.com/MarkoPoloResearchLab/loopaware/internal/model"
+)
+
+const (
+	VariableSiteName            = "SiteName"
+	VariableConfirmationURL     = "ConfirmationURL"
+	VariableUnsubscribeURL      = "UnsubscribeURL"
+	VariableFeedbackMessage     = "FeedbackMessage"
+	VariableFeedbackContact     = "FeedbackContact"
+	VariableSubscriberEmail     = "SubscriberEmail"
+	VariableSubscriberName      = "SubscriberName"
+	VariableSubscriberSourceURL = "SubscriberSourceURL"
+
+	FieldSubject  = "subject"
+	FieldTextBody = "text_body"
+	FieldHTMLBody = "html_body"
+
+	missingKeyOption     = "missingkey=error"
+	maxRenderedBodyBytes = 256 << 10
+)
+
+var (
+	// ErrInvalidTemplate reports a template that fails to parse or references unknown variables.
+	ErrInvalidTemplate = errors.New("invalid_email_template")
+	// ErrUnknownKind reports a template kind without a defined variable set.
+	ErrUnknownKind = errors.New("unknown_email_template_kind")
+
+	errRenderedOutputTooLarge = errors.New("rendered output too large")
+)
+
+var kindVariables = map[string][]string{
+	model.EmailTemplateKindSubscriptionConfirmation: {
+		VariableSiteName,
+		VariableSubscriberEmail,
+		VariableSubscriberName,
+		VariableConfirmationURL,
+		VariableUnsubscribeURL,
+	},
+	model.EmailTemplateKindFeedbackNotification: {
+		VariableSiteName,
+		VariableFeedbackContact,
+		VariableFeedbackMessage,
+	},
+	model.EmailTemplateKindSubscriptionNotification: {
+		VariableSiteName,
+		VariableSubscriberEmail,
+		VariableSubscriberName,
+		VariableSubscriberSourceURL,
+	},
+}
+
+var defaultContents = map[string]Content{
+	model.EmailTemplateKindSubscriptionConfirmation: {
+		Subject: "Confirm your subscription to {{.SiteName}}",
+		Text: "Thanks for subscribing to {{.SiteName}}.\n\n" +
+			"Confirm your subscription:\n{{.ConfirmationURL}}\n\n" +
+			"If you did not request this, you can ignore this email.\n",
+		HTML: "<p>Thanks for subscribing to {{.SiteName}}.</p>\n" +
+			"<p><a href=\"{{.ConfirmationURL}}\">Confirm your subscription</a></p>\n" +
+			"<p>If you did not request this, you can ignore this email.</p>\n",
+	},
+	model.EmailTemplateKindFeedbackNotification: {
+		Subject: "New feedback for {{.SiteName}}",
+		Text: "A new feedback message was submitted for {{.SiteName}}.\n\n" +
+			"{{if .FeedbackContact}}Contact: {{.FeedbackContact}}\n{{end}}" +
+			"Message:\n{{.FeedbackMessage}}\n",
+		HTML: "<p>A new feedback message was submitted for {{.SiteName}}.</p>\n" +
+			"{{if .FeedbackContact}}<p>Contact: {{.FeedbackContact}}</p>\n{{end}}" +
+			"<p>Message:</p>\n<blockquote>{{.FeedbackMessage}}</blockquote>\n",
+	},
+	model.EmailTemplateKindSubscriptionNotification: {
+		Subject: "New subscriber for {{.SiteName}}",
+		Text: "A new subscriber joined {{.SiteName}}.\n\n" +
+			"{{if .SubscriberEmail}}Email: {{.SubscriberEmail}}\n{{end}}" +
+			"{{if .SubscriberName}}Name: {{.SubscriberName}}\n{{end}}" +
+			"{{if .SubscriberSourceURL}}Source: {{.SubscriberSourceURL}}\n{{end}}",
+		HTML: "<p>A new subscriber joined {{.SiteName}}.</p>\n<ul>\n" +
+			"{{if .SubscriberEmail}}<li>Email: {{.SubscriberEmail}}</li>\n{{end}}" +
+			"{{if .SubscriberName}}<li>Name: {{.SubscriberName}}</li>\n{{end}}" +
+			"{{if .SubscriberSourceURL}}<li>Source: {{.SubscriberSourceURL}}</li>\n{{end}}" +
+			"</ul>\n",
+	},
+}
+
+// Content holds the unrendered subject and bodies of an email template.
+type Content struct {
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Data carries the values available to templates; each kind exposes a subset.
+type Data struct {
+	SiteName            string
+	ConfirmationURL     string
+	UnsubscribeURL      string
+	FeedbackMessage     string
+	FeedbackContact     string
+	SubscriberEmail     string
+	SubscriberName      string
+	SubscriberSourceURL string
+}
+
+// Message is a rendered email ready for delivery.
+type Message struct {
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// ValidationError identifies the template field that failed validation.
+type ValidationError struct {
+	Field string
+	Err   error
+}
+
+func (validationError *ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s: %v", ErrInvalidTemplate.Error(), validationError.Field, validationError.Err)
+}
+
+func (validationError *ValidationError) Unwrap() error {
+	return ErrInvalidTemplate
+}
+
+// Variables lists the template variables available to a kind.
+func Variables(kind string) []string {
+	return append([]string(nil), kindVariables[kind]...)
+}
+
+// Default returns the built-in template for a kind.
+func Default(kind string) (Content, bool) {
+	content, found := defaultContents[kind]
+	return content, found
+}
+
+// SampleData returns placeholder values used to validate and preview templates.
+func SampleData(siteName string) Data {
+	trimmedSiteName := strings.TrimSpace(siteName)
+	if trimmedSiteName == "" {
+		trimmedSiteName = "Example Site"
+	}
+	return Data{
+		SiteName:            trimmedSiteName,
+		ConfirmationURL:     "https://loopaware.example.com/subscriptions/confirm?token=sample",
+		UnsubscribeURL:      "https://loopaware.example.com/subscriptions/unsubscribe?token=sample",
+		FeedbackMessage:     "I love the new dashboard, but the export button is hard to find.",
+		FeedbackContact:     "reader@example.com",
+		SubscriberEmail:     "reader@example.com",
+		SubscriberName:      "Ada Reader",
+		SubscriberSourceURL: "https://example.com/blog/welcome",
+	}
+}
+
+// Validate parses every template field and renders it with sample data so that
+// unknown variables and syntax errors are rejected before a template is saved.
+func Validate(kind string, content Content) error {
+	_, renderErr := Render(kind, content, SampleData(""))
+	return renderErr
+}
+
+// Render executes a template against data restricted to the variables of kind.
+func Render(kind string, content Content, data Data) (Message, error) {
+	variables, found := kindVariables[kind]
+	if !found {
+		return Message{}, fmt.Errorf("%w: %s", ErrUnknownKind, kind)
+	}
+	values := data.restrictedTo(variables)
+
+	subject, subjectErr := renderText(FieldSubject, content.Subject, values)
+	if subjectErr != nil {
+		return Message{}, subjectErr
+	}
+	textBody, textErr := renderText(FieldTextBody, content.Text, values)
+	if textErr != nil {
+		return Message{}, textErr
+	}
+	htmlBody := ""
+	if strings.TrimSpace(content.HTML) != "" {
+		renderedHTML, htmlErr := renderHTML(FieldHTMLBody, content.HTML, values)
+		if htmlErr != nil {
+			return Message{}, htmlErr
+		}
+		htmlBody = renderedHTML
+	}
+
+	return Message{
+		Subject: strings.Join(strings.Fields(subject), " "),
+		Text:    textBody,
+		HTML:    htmlBody,
+	}, nil
+}
+
+func (data Data) restrictedTo(variables []string) map[string]string {
+	available := map[string]string{
+		VariableSiteName:            data.SiteName,
+		VariableConfirmationURL:     data.ConfirmationURL,
+		VariableUnsubscribeURL:      data.UnsubscribeURL,
+		VariableFeedbackMessage:     data.FeedbackMessage,
+		VariableFeedbackContact:     data.FeedbackContact,
+		VariableSubscriberEmail:     data.SubscriberEmail,
+		VariableSubscriberName:      data.SubscriberName,
+		VariableSubscriberSourceURL: data.SubscriberSourceURL,
+	}
+	values := make(map[string]string, len(variables))
+	for _, variable := range variables {
+		values[variable] = available[variable]
+	}
+	return values
+}
+
+func renderText(field string, source string, values map[string]string) (string, error) {
+	parsed, parseErr := texttemplate.New(field).Option(missingKeyOption).Parse(source)
+	if parseErr != nil {
+		return "", &ValidationError{Field: field, Err: parseErr}
+	}
+	output := &limitedBuffer{limit: maxRenderedBodyBytes}
+	if executeErr := parsed.Execute(output, values); executeErr != nil {
+		return "", &ValidationError{Field: field, Err: executeErr}
+	}
+	return output.String(), nil
+}
+
+func renderHTML(field string, source string, values map[string]string) (string, error) {
+	parsed, parseErr := htmltemplate.New(field).Option(missingKeyOption).Parse(source)
+	if parseErr != nil {
+		return "", &ValidationError{Field: field, Err: parseErr}
+	}
+	output := &limitedBuffer{limit: maxRenderedBodyBytes}
+	if executeErr := parsed.Execute(output, values); executeErr != nil {
+		return "", &ValidationError{Field: field, Err: executeErr}
+	}
+	return output.String(), nil
+}
+
+type limitedBuffer struct {
+	buffer bytes.Buffer
+	limit  int
+}
+
+func (limited *limitedBuffer) Write(payload []byte) (int, error) {
+	if limited.buffer.Len()+len(payload) > limited.limit {
+		return 0, errRenderedOutputTooLarge
+	}
+	return limited.buffer.Write(payload)
+}
+
+func (limited *limitedBuffer) String() string {
+	return limited.buffer.String()
+}
+
+// Source looks up stored per-site template overrides.
+type Source interface {
+	FindEmailTemplate(ctx context.Context, siteID string, kind string, locale string) (Content, bool, error)
+}
+
+// Renderer resolves site overrides with locale fallback and renders them, falling
+// back to the built-in defaults when no override applies or an override fails.
+type Renderer struct {
+	source Source
+	logger *zap.Logger
+}
+
+// NewRenderer constructs a Renderer; source may be nil to always use defaults.
+func NewRenderer(source Source, logger *zap.Logger) *Renderer {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &Renderer{source: source, logger: logger}
+}
+
+// Render produces the message for a site, kind, and locale.
+func (renderer *Renderer) Render(ctx context.Context, siteID string, kind string, locale string, data Data) (Message, error) {
+	if renderer != nil && renderer.source != nil && strings.TrimSpace(siteID) != "" {
+		content, found := renderer.resolve(ctx, siteID, kind, locale)
+		if found {
+			message, renderErr := Render(kind, content, data)
+			if renderErr == nil {
+				return message, nil
+			}
+			renderer.logger.Warn("email_template_render_failed", zap.Error(renderErr), zap.String("site_id", siteID), zap.String("kind", kind))
+		}
+	}
+
+	defaultContent, found := Default(kind)
+	if !found {
+		return Message{}, fmt.Errorf("%w: %s", ErrUnknownKind, kind)
+	}
+	return Render(kind, defaultContent, data)
+}
+
+// Resolve returns the stored override for a site, kind, and locale, or the built-in default.
+func (renderer *Renderer) Resolve(ctx context.Context, siteID string, kind string, locale string) Content {
+	if renderer != nil && renderer.source != nil {
+		if content, found := renderer.resolve(ctx, siteID, kind, locale); found {
+			return content
+		}
+	}
+	defaultContent, _ := Default(kind)
+	return defaultContent
+}
+
+func (renderer *Renderer) resolve(ctx context.Context, siteID string, kind string, locale string) (Content, bool) {
+	for _, candidateLocale := range model.LocaleFallbacks(locale) {
+		content, found, findErr := renderer.source.FindEmailTemplate(ctx, siteID, kind, candidateLocale)
+		if findErr != nil {
+			renderer.logger.Warn("email_template_lookup_failed", zap.Error(findErr), zap.String("site_id", siteID), zap.String("kind", kind))
+			return Content{}, false
+		}
+		if found {
+			return content, true
+		}
+	}
+	return Content{}, false
+}
@@ -0,0 +1,119 @@
+package emailtemplate
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/MarkoPoloResearchLab/loopaware/internal/model"
+)
+
+const (
+	testTemplateSiteID   = "site-1"
+	testTemplateSiteName = "Example Blog"
+)
+
+type stubSource struct {
+	templates map[string]Content
+	lookups   []string
+	findErr   error
+}
+
+func (source *stubSource) FindEmailTemplate(_ context.Context, siteID string, kind string, locale string) (Content, bool, error) {
+	source.lookups = append(source.lookups, locale)
+	if source.findErr != nil {
+		return Content{}, false, source.findErr
+	}
+	content, found := source.templates[siteID+"|"+kind+"|"+locale]
+	return content, found, nil
+}
+
+func TestDefaultsRenderExistingEnglishCopy(t *testing.T) {
+	message, err := NewRenderer(nil, nil).Render(context.Background(), testTemplateSiteID, model.EmailTemplateKindFeedbackNotification, "", Data{
+		SiteName:        testTemplateSiteName,
+		FeedbackContact: "reader@example.com",
+		FeedbackMessage: "Great post",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "New feedback for Example Blog", message.Subject)
+	require.Equal(t, "A new feedback message was submitted for Example Blog.\n\nContact: reader@example.com\nMessage:\nGreat post\n", message.Text)
+	require.Contains(t, message.HTML, "<blockquote>Great post</blockquote>")
+}
+
+func TestEveryKindHasValidDefault(t *testing.T) {
+	for _, kind := range model.EmailTemplateKinds {
+		content, found := Default(kind)
+		require.True(t, found, kind)
+		require.NoError(t, Validate(kind, content), kind)
+		require.NotEmpty(t, Variables(kind), kind)
+	}
+}
+
+func TestValidateRejectsUnknownVariablesAndSyntaxErrors(t *testing.T) {
+	unknownVariableErr := Validate(model.EmailTemplateKindFeedbackNotification, Content{Subject: "Hi", Text: "{{.ConfirmationURL}}"})
+	require.ErrorIs(t, unknownVariableErr, ErrInvalidTemplate)
+	var validationError *ValidationError
+	require.True(t, errors.As(unknownVariableErr, &validationError))
+	require.Equal(t, FieldTextBody, validationError.Field)
+
+	syntaxErr := Validate(model.EmailTemplateKindSubscriptionConfirmation, Content{Subject: "{{.SiteName", Text: "body"})
+	require.True(t, errors.As(syntaxErr, &validationError))
+	require.Equal(t, FieldSubject, validationError.Field)
+
+	htmlErr := Validate(model.EmailTemplateKindSubscriptionConfirmation, Content{Subject: "ok", Text: "ok", HTML: "{{.Missing}}"})
+	require.True(t, errors.As(htmlErr, &validationError))
+	require.Equal(t, FieldHTMLBody, validationError.Field)
+
+	require.ErrorIs(t, Validate("unknown", Content{Subject: "a", Text: "b"}), ErrUnknownKind)
+}
+
+func TestRenderEscapesHTMLAndFlattensSubject(t *testing.T) {
+	message, err := Render(model.EmailTemplateKindFeedbackNotification, Content{
+		Subject: "Feedback\nfor {{.SiteName}}",
+		Text:    "{{.FeedbackMessage}}",
+		HTML:    "<p>{{.FeedbackMessage}}</p>",
+	}, Data{SiteName: testTemplateSiteName, FeedbackMessage: "<script>alert(1)</script>"})
+	require.NoError(t, err)
+	require.Equal(t, "Feedback for Example Blog", message.Subject)
+	require.Equal(t, "<script>alert(1)</script>", message.Text)
+	require.NotContains(t, message.HTML, "<script>")
+}
+
+func TestRenderRejectsOversizedOutput(t *testing.T) {
+	_, err := Render(model.EmailTemplateKindFeedbackNotification, Content{
+		Subject: "s",
+		Text:    "{{range 100000}}" + strings.Repeat("x", 64) + "{{end}}",
+	}, SampleData(""))
+	require.ErrorIs(t, err, ErrInvalidTemplate)
+}
+
+func TestRendererUsesLocaleFallbacks(t *testing.T) {
+	source := &stubSource{templates: map[string]Content{
+		testTemplateSiteID + "|" + model.EmailTemplateKindSubscriptionConfirmation + "|pt": {
+			Subject: "Confirme sua inscrição em {{.SiteName}}",
+			Text:    "Confirme: {{.ConfirmationURL}}",
+		},
+	}}
+	renderer := NewRenderer(source, nil)
+
+	message, err := renderer.Render(context.Background(), testTemplateSiteID, model.EmailTemplateKindSubscriptionConfirmation, "pt-BR", Data{SiteName: testTemplateSiteName, ConfirmationURL: "https://x/confirm"})
+	require.NoError(t, err)
+	require.Equal(t, "Confirme sua inscrição em Example Blog", message.Subject)
+	require.Equal(t, "Confirme: https://x/confirm", message.Text)
+	require.Empty(t, message.HTML)
+	require.Equal(t, []string{"pt-br", "pt"}, source.lookups)
+
+	englishMessage, englishErr := renderer.Render(context.Background(), testTemplateSiteID, model.EmailTemplateKindSubscriptionConfirmation, "en", Data{SiteName: testTemplateSiteName, ConfirmationURL: "https://x/confirm"})
+	require.NoError(t, englishErr)
+	require.Equal(t, "Confirm your subscription to Example Blog", englishMessage.Subject)
+}
+
+func TestRendererFallsBackToDefaultsWhenLookupFails(t *testing.T) {
+	renderer := NewRenderer(&stubSource{findErr: errors.New("database down")}, nil)
+	message, err := renderer.Render(context.Background(), testTemplateSiteID, model.EmailTemplateKindSubscriptionNotification, "", Data{SiteName: testTemplateSiteName, SubscriberEmail: "reader@example.com"})
+	require.NoError(t, err)
+	require.Equal(t, "A new subscriber joined Example Blog.\n\nEmail: reader@example.com\n", message.Text)
+}
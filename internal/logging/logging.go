@@ -0,0 +1,121 @@
+// Package logging builds the server's zap logger with a configurable level, encoding, and PII redaction, and carries
+// the per-request ID that ties handler logs to the request log line.
+package logging
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	// FormatJSON writes one JSON object per line.
+	FormatJSON = "json"
+	// FormatConsole writes human-readable lines for local development.
+	FormatConsole = "console"
+
+	// RedactionHash replaces emails, contact details, and IP addresses with keyed hashes.
+	RedactionHash = "hash"
+	// RedactionNone logs values unchanged.
+	RedactionNone = "none"
+
+	redactionKeyPurpose = "log-redaction"
+
+	// DefaultLevel is the minimum level logged when none is configured.
+	DefaultLevel = "info"
+)
+
+// ErrInvalidConfig reports an unknown level, format, or redaction policy.
+var ErrInvalidConfig = errors.New("invalid logging configuration")
+
+// Config selects the logger level, encoding, and redaction policy. RedactionKey keys the hashes so that logged
+// values correlate across lines without being reversible by hashing candidate values.
+type Config struct {
+	Level        string
+	Format       string
+	Redaction    string
+	RedactionKey []byte
+}
+
+// DeriveRedactionKey derives a key used only for log redaction from secret, so the logged hashes never expose a value
+// computed with the secret itself.
+func DeriveRedactionKey(secret string) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(redactionKeyPurpose))
+	return mac.Sum(nil)
+}
+
+// ValidLevel reports whether level is a zap level name. An empty level means DefaultLevel.
+func ValidLevel(level string) bool {
+	_, parseErr := parseLevel(level)
+	return parseErr == nil
+}
+
+// ValidFormat reports whether format is FormatJSON or FormatConsole. An empty format means FormatJSON.
+func ValidFormat(format string) bool {
+	switch normalize(format) {
+	case "", FormatJSON, FormatConsole:
+		return true
+	default:
+		return false
+	}
+}
+
+// ValidRedaction reports whether policy is RedactionHash or RedactionNone. An empty policy means RedactionHash.
+func ValidRedaction(policy string) bool {
+	switch normalize(policy) {
+	case "", RedactionHash, RedactionNone:
+		return true
+	default:
+		return false
+	}
+}
+
+// New builds a production logger for config.
+func New(config Config) (*zap.Logger, error) {
+	level, levelErr := parseLevel(config.Level)
+	if levelErr != nil {
+		return nil, levelErr
+	}
+	if !ValidFormat(config.Format) || !ValidRedaction(config.Redaction) {
+		return nil, fmt.Errorf("%w: format %q, redaction %q", ErrInvalidConfig, config.Format, config.Redaction)
+	}
+
+	zapConfig := zap.NewProductionConfig()
+	zapConfig.Level = zap.NewAtomicLevelAt(level)
+	if normalize(config.Format) == FormatConsole {
+		zapConfig.Encoding = FormatConsole
+		zapConfig.EncoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+		zapConfig.EncoderConfig.EncodeLevel = zapcore.CapitalLevelEncoder
+	}
+
+	var options []zap.Option
+	if normalize(config.Redaction) != RedactionNone {
+		redactionKey := config.RedactionKey
+		options = append(options, zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return NewRedactingCore(core, redactionKey)
+		}))
+	}
+	return zapConfig.Build(options...)
+}
+
+func parseLevel(level string) (zapcore.Level, error) {
+	normalized := normalize(level)
+	if normalized == "" {
+		normalized = DefaultLevel
+	}
+	parsed, parseErr := zapcore.ParseLevel(normalized)
+	if parseErr != nil {
+		return zapcore.InfoLevel, fmt.Errorf("%w: level %q", ErrInvalidConfig, level)
+	}
+	return parsed, nil
+}
+
+func normalize(value string) string {
+	return strings.ToLower(strings.TrimSpace(value))
+}
@@ -0,0 +1,110 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+var testRedactionKey = []byte("test-redaction-key")
+
+func redactingLogger() (*zap.Logger, *observer.ObservedLogs) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	return zap.New(NewRedactingCore(core, testRedactionKey)), logs
+}
+
+func TestConfigValidation(testingT *testing.T) {
+	for _, level := range []string{"", "debug", " INFO ", "warn", "error"} {
+		require.True(testingT, ValidLevel(level), level)
+	}
+	require.False(testingT, ValidLevel("verbose"))
+	require.True(testingT, ValidFormat(""))
+	require.True(testingT, ValidFormat("Console"))
+	require.False(testingT, ValidFormat("logfmt"))
+	require.True(testingT, ValidRedaction(RedactionNone))
+	require.False(testingT, ValidRedaction("mask"))
+
+	_, newErr := New(Config{Level: "verbose"})
+	require.ErrorIs(testingT, newErr, ErrInvalidConfig)
+	_, formatErr := New(Config{Format: "logfmt"})
+	require.ErrorIs(testingT, formatErr, ErrInvalidConfig)
+}
+
+func TestNewAppliesLevel(testingT *testing.T) {
+	logger, newErr := New(Config{Level: "warn", Format: FormatConsole, Redaction: RedactionNone})
+	require.NoError(testingT, newErr)
+	require.False(testingT, logger.Core().Enabled(zapcore.InfoLevel))
+	require.True(testingT, logger.Core().Enabled(zapcore.WarnLevel))
+}
+
+func TestDeriveRedactionKeyIsPurposeSpecific(testingT *testing.T) {
+	derivedKey := DeriveRedactionKey("session-secret")
+	require.Len(testingT, derivedKey, 32)
+	require.Equal(testingT, derivedKey, DeriveRedactionKey("session-secret"))
+	require.NotEqual(testingT, derivedKey, DeriveRedactionKey("other-secret"))
+	require.NotEqual(testingT, []byte("session-secret"), derivedKey)
+}
+
+func TestRedactingCoreHashesSensitiveFields(testingT *testing.T) {
+	logger, logs := redactingLogger()
+	logger.Info("http",
+		zap.String("ip", "203.0.113.7"),
+		zap.String("actor_email", "Owner@Example.com"),
+		zap.String("contact", "+1 555 0100"),
+		zap.String("site_id", "site-1"),
+		zap.Int("status", 200),
+	)
+
+	fields := logs.All()[0].ContextMap()
+	require.Regexp(testingT, `^hash:[0-9a-f]{12}$`, fields["ip"])
+	require.Regexp(testingT, `^hash:[0-9a-f]{12}$`, fields["actor_email"])
+	require.Regexp(testingT, `^hash:[0-9a-f]{12}$`, fields["contact"])
+	require.Equal(testingT, "site-1", fields["site_id"])
+	require.EqualValues(testingT, 200, fields["status"])
+
+	logger.Info("again", zap.String("email", "owner@example.com"))
+	require.Equal(testingT, fields["actor_email"], logs.All()[1].ContextMap()["email"])
+}
+
+func TestRedactingCoreReplacesEmbeddedEmails(testingT *testing.T) {
+	logger, logs := redactingLogger()
+	logger.With(zap.String("detail", "sent to owner@example.com")).Warn(
+		"notify owner@example.com",
+		zap.Error(fmt.Errorf("deliver: %w", errors.New("550 mailbox owner@example.com unavailable"))),
+		zap.Error(errors.New("timeout")),
+	)
+
+	entry := logs.All()[0]
+	require.NotContains(testingT, entry.Message, "owner@example.com")
+	require.Contains(testingT, entry.Message, "notify hash:")
+	for key, value := range entry.ContextMap() {
+		require.NotContains(testingT, fmt.Sprint(value), "owner@example.com", key)
+	}
+	require.True(testingT, strings.HasPrefix(entry.ContextMap()["detail"].(string), "sent to hash:"))
+}
+
+func TestRequestIDValidationAndContext(testingT *testing.T) {
+	generated := NewRequestID()
+	require.Len(testingT, generated, 32)
+	require.True(testingT, ValidRequestID(generated))
+	require.True(testingT, ValidRequestID("req-1_a.b:c"))
+	require.False(testingT, ValidRequestID(""))
+	require.False(testingT, ValidRequestID("bad id\n"))
+	require.False(testingT, ValidRequestID(strings.Repeat("a", 129)))
+
+	ctx := ContextWithRequestID(context.Background(), "req-1")
+	require.Equal(testingT, "req-1", RequestIDFromContext(ctx))
+	require.Empty(testingT, RequestIDFromContext(context.Background()))
+
+	core, logs := observer.New(zapcore.InfoLevel)
+	WithContext(ctx, zap.New(core)).Info("save_feedback")
+	require.Equal(testingT, "req-1", logs.All()[0].ContextMap()["request_id"])
+	require.Nil(testingT, WithContext(ctx, nil))
+}
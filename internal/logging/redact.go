@@ -0,0 +1,134 @@
+package logging
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+)
+
+const redactedHashLength = 12
+
+var (
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+
+	sensitiveFieldKeys = map[string]struct{}{
+		"ip":        {},
+		"client_ip": {},
+		"remote_ip": {},
+		"email":     {},
+		"recipient": {},
+		"contact":   {},
+		"phone":     {},
+	}
+	sensitiveFieldSuffixes = []string{"_email", "_ip", "_contact", "_phone"}
+)
+
+// redactingCore hashes sensitive values before they reach the wrapped core: whole string fields whose key names an
+// email, contact detail, or IP address, and email addresses found inside any other string field, error, or message.
+type redactingCore struct {
+	next zapcore.Core
+	key  []byte
+}
+
+// NewRedactingCore wraps next so that logged PII is replaced by "hash:" and the first twelve hex digits of its
+// HMAC-SHA256 under key. Equal values hash equally, so redacted lines still correlate.
+func NewRedactingCore(next zapcore.Core, key []byte) zapcore.Core {
+	return &redactingCore{next: next, key: key}
+}
+
+func (core *redactingCore) Enabled(level zapcore.Level) bool {
+	return core.next.Enabled(level)
+}
+
+func (core *redactingCore) With(fields []zapcore.Field) zapcore.Core {
+	return &redactingCore{next: core.next.With(core.redactFields(fields)), key: core.key}
+}
+
+func (core *redactingCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if core.Enabled(entry.Level) {
+		return checked.AddCore(entry, core)
+	}
+	return checked
+}
+
+func (core *redactingCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	entry.Message = core.redactEmails(entry.Message)
+	return core.next.Write(entry, core.redactFields(fields))
+}
+
+func (core *redactingCore) Sync() error {
+	return core.next.Sync()
+}
+
+func (core *redactingCore) redactFields(fields []zapcore.Field) []zapcore.Field {
+	redacted := make([]zapcore.Field, len(fields))
+	for index, field := range fields {
+		redacted[index] = core.redactField(field)
+	}
+	return redacted
+}
+
+func (core *redactingCore) redactField(field zapcore.Field) zapcore.Field {
+	var value string
+	switch field.Type {
+	case zapcore.StringType:
+		value = field.String
+	case zapcore.StringerType:
+		stringer, ok := field.Interface.(fmt.Stringer)
+		if !ok {
+			return field
+		}
+		value = stringer.String()
+	case zapcore.ErrorType:
+		err, ok := field.Interface.(error)
+		if !ok || err == nil {
+			return field
+		}
+		message := err.Error()
+		if !emailPattern.MatchString(message) {
+			return field
+		}
+		value = message
+	default:
+		return field
+	}
+
+	if sensitiveFieldKey(field.Key) {
+		if value == "" {
+			return field
+		}
+		return zapcore.Field{Key: field.Key, Type: zapcore.StringType, String: core.hash(value)}
+	}
+	return zapcore.Field{Key: field.Key, Type: zapcore.StringType, String: core.redactEmails(value)}
+}
+
+func (core *redactingCore) redactEmails(value string) string {
+	if !strings.Contains(value, "@") {
+		return value
+	}
+	return emailPattern.ReplaceAllStringFunc(value, core.hash)
+}
+
+func (core *redactingCore) hash(value string) string {
+	mac := hmac.New(sha256.New, core.key)
+	mac.Write([]byte(strings.ToLower(strings.TrimSpace(value))))
+	return "hash:" + hex.EncodeToString(mac.Sum(nil))[:redactedHashLength]
+}
+
+func sensitiveFieldKey(key string) bool {
+	normalized := strings.ToLower(key)
+	if _, sensitive := sensitiveFieldKeys[normalized]; sensitive {
+		return true
+	}
+	for _, suffix := range sensitiveFieldSuffixes {
+		if strings.HasSuffix(normalized, suffix) {
+			return true
+		}
+	}
+	return false
+}
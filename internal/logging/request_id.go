@@ -0,0 +1,76 @@
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+
+	"go.uber.org/zap"
+
+	"github.com/MarkoPoloResearchLab/loopaware/internal/tracing"
+)
+
+const (
+	// HeaderRequestID carries the request ID from the caller and back on the response.
+	HeaderRequestID = "X-Request-ID"
+
+	requestIDMaxLength = 128
+	requestIDByteCount = 16
+	fieldRequestID     = "request_id"
+)
+
+type requestIDContextKey struct{}
+
+// NewRequestID returns a random 32-character hex request ID.
+func NewRequestID() string {
+	buffer := make([]byte, requestIDByteCount)
+	_, _ = rand.Read(buffer)
+	return hex.EncodeToString(buffer)
+}
+
+// ValidRequestID reports whether a caller-supplied request ID is safe to reuse: 1 to 128 letters, digits, or
+// "-", "_", ".", ":" characters, so it cannot forge log lines or response headers.
+func ValidRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > requestIDMaxLength {
+		return false
+	}
+	for _, character := range requestID {
+		switch {
+		case character >= 'a' && character <= 'z', character >= 'A' && character <= 'Z', character >= '0' && character <= '9':
+		case character == '-', character == '_', character == '.', character == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// ContextWithRequestID stores requestID in ctx.
+func ContextWithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDContextKey{}, requestID)
+}
+
+// RequestIDFromContext returns the request ID stored in ctx, or "".
+func RequestIDFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	requestID, _ := ctx.Value(requestIDContextKey{}).(string)
+	return requestID
+}
+
+// WithContext returns logger annotated with the request ID and the trace and span IDs carried by ctx. A nil
+// logger is returned unchanged.
+func WithContext(ctx context.Context, logger *zap.Logger) *zap.Logger {
+	if logger == nil || ctx == nil {
+		return logger
+	}
+	fields := tracing.LogFields(ctx)
+	if requestID := RequestIDFromContext(ctx); requestID != "" {
+		fields = append([]zap.Field{zap.String(fieldRequestID, requestID)}, fields...)
+	}
+	if len(fields) == 0 {
+		return logger
+	}
+	return logger.With(fields...)
+}
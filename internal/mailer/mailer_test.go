@@ -0,0 +1,187 @@
+package mailer
+
+import (
+	"context"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/MarkoPoloResearchLab/loopaware/internal/testutil"
+)
+
+const (
+	testSenderAddress    = "LoopAware <noreply@loopaware.example.com>"
+	testRecipientAddress = "reader@example.com"
+	testUnsubscribeURL   = "https://loopaware.example.com/public/subscriptions/unsubscribe-link?token=abc"
+	testSMTPUsername     = "relay-user"
+	testSMTPPassword     = "relay-password"
+)
+
+func TestComposeBuildsMultipartAlternativeWithUnsubscribeHeaders(t *testing.T) {
+	document, err := Compose(testSenderAddress, Message{
+		Recipient:      testRecipientAddress,
+		Subject:        "Confirmação\nde inscrição",
+		TextBody:       "Plain body\nsecond line",
+		HTMLBody:       "<p>HTML body</p>",
+		UnsubscribeURL: testUnsubscribeURL,
+	}, time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+
+	parsed, parseErr := mail.ReadMessage(strings.NewReader(string(document)))
+	require.NoError(t, parseErr)
+	require.Equal(t, "<"+testUnsubscribeURL+">", parsed.Header.Get(headerListUnsubscribe))
+	require.Equal(t, listUnsubscribeOneClick, parsed.Header.Get(headerListUnsubscribePost))
+	decodedSubject, decodeErr := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get(headerSubject))
+	require.NoError(t, decodeErr)
+	require.Equal(t, "Confirmação de inscrição", decodedSubject)
+	require.NotEmpty(t, parsed.Header.Get(headerMessageID))
+
+	mediaType, parameters, mediaErr := mime.ParseMediaType(parsed.Header.Get(headerContentType))
+	require.NoError(t, mediaErr)
+	require.Equal(t, "multipart/alternative", mediaType)
+
+	partsReader := multipart.NewReader(parsed.Body, parameters["boundary"])
+	textPart, textErr := partsReader.NextPart()
+	require.NoError(t, textErr)
+	require.Equal(t, contentTypeTextPlain, textPart.Header.Get(headerContentType))
+	textBody, _ := io.ReadAll(textPart)
+	require.Equal(t, "Plain body\r\nsecond line", string(textBody))
+
+	htmlPart, htmlErr := partsReader.NextPart()
+	require.NoError(t, htmlErr)
+	require.Equal(t, contentTypeTextHTML, htmlPart.Header.Get(headerContentType))
+	htmlBody, _ := io.ReadAll(htmlPart)
+	require.Equal(t, "<p>HTML body</p>", string(htmlBody))
+}
+
+func TestComposeUsesSinglePartForTextOnlyMessages(t *testing.T) {
+	document, err := Compose(testSenderAddress, Message{Recipient: testRecipientAddress, Subject: "Hello", TextBody: "Only text"}, time.Now())
+	require.NoError(t, err)
+
+	parsed, parseErr := mail.ReadMessage(strings.NewReader(string(document)))
+	require.NoError(t, parseErr)
+	require.Equal(t, contentTypeTextPlain, parsed.Header.Get(headerContentType))
+	require.Empty(t, parsed.Header.Get(headerListUnsubscribe))
+}
+
+func TestComposeRejectsHeaderInjection(t *testing.T) {
+	_, recipientErr := Compose(testSenderAddress, Message{Recipient: "reader@example.com\r\nBcc: victim@example.com", Subject: "Hi", TextBody: "x"}, time.Now())
+	require.ErrorIs(t, recipientErr, ErrInvalidMessage)
+
+	_, unsubscribeErr := Compose(testSenderAddress, Message{Recipient: testRecipientAddress, Subject: "Hi", TextBody: "x", UnsubscribeURL: "https://x>\r\nBcc: y"}, time.Now())
+	require.ErrorIs(t, unsubscribeErr, ErrInvalidMessage)
+
+	document, subjectErr := Compose(testSenderAddress, Message{Recipient: testRecipientAddress, Subject: "Hi\r\nBcc: victim@example.com", TextBody: "x"}, time.Now())
+	require.NoError(t, subjectErr)
+	parsed, parseErr := mail.ReadMessage(strings.NewReader(string(document)))
+	require.NoError(t, parseErr)
+	require.Empty(t, parsed.Header.Get("Bcc"))
+}
+
+func TestNewSMTPSenderValidatesConfig(t *testing.T) {
+	validConfig := SMTPConfig{Host: "smtp.example.com", Port: 587, From: testSenderAddress}
+
+	_, err := NewSMTPSender(nil, validConfig)
+	require.NoError(t, err)
+
+	missingHost := validConfig
+	missingHost.Host = ""
+	_, err = NewSMTPSender(nil, missingHost)
+	require.Error(t, err)
+
+	invalidPort := validConfig
+	invalidPort.Port = 0
+	_, err = NewSMTPSender(nil, invalidPort)
+	require.Error(t, err)
+
+	invalidFrom := validConfig
+	invalidFrom.From = "not an address"
+	_, err = NewSMTPSender(nil, invalidFrom)
+	require.Error(t, err)
+
+	invalidSecurity := validConfig
+	invalidSecurity.Security = "ssl3"
+	_, err = NewSMTPSender(nil, invalidSecurity)
+	require.Error(t, err)
+}
+
+func TestSMTPSenderDeliversOverEachSecurityMode(t *testing.T) {
+	testCases := []struct {
+		name       string
+		serverMode string
+		security   string
+		expectTLS  bool
+	}{
+		{name: "starttls", serverMode: testutil.SMTPModeStartTLS, security: SecurityStartTLS, expectTLS: true},
+		{name: "implicit tls", serverMode: testutil.SMTPModeTLS, security: SecurityTLS, expectTLS: true},
+		{name: "plain relay", serverMode: testutil.SMTPModePlain, security: SecurityNone, expectTLS: false},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			server := testutil.NewSMTPTestServer(t, testCase.serverMode, testSMTPUsername, testSMTPPassword)
+			sender, senderErr := NewSMTPSender(nil, SMTPConfig{
+				Host:      server.Host,
+				Port:      server.Port,
+				Username:  testSMTPUsername,
+				Password:  testSMTPPassword,
+				From:      testSenderAddress,
+				Security:  testCase.security,
+				Timeout:   5 * time.Second,
+				TLSConfig: server.ClientTLSConfig(),
+			})
+			require.NoError(t, senderErr)
+
+			sendErr := sender.SendEmailMessage(context.Background(), Message{
+				Recipient:      testRecipientAddress,
+				Subject:        "Welcome",
+				TextBody:       "Hello there",
+				HTMLBody:       "<p>Hello there</p>",
+				UnsubscribeURL: testUnsubscribeURL,
+			})
+			require.NoError(t, sendErr)
+
+			messages := server.Messages()
+			require.Len(t, messages, 1)
+			require.Equal(t, "noreply@loopaware.example.com", messages[0].From)
+			require.Equal(t, []string{testRecipientAddress}, messages[0].To)
+			require.Equal(t, testSMTPUsername, messages[0].Username)
+			require.Equal(t, testCase.expectTLS, messages[0].TLS)
+			require.Contains(t, messages[0].Data, "List-Unsubscribe-Post: List-Unsubscribe=One-Click")
+			require.Contains(t, messages[0].Data, "multipart/alternative")
+		})
+	}
+}
+
+func TestSMTPSenderRequiresStartTLSWhenConfigured(t *testing.T) {
+	server := testutil.NewSMTPTestServer(t, testutil.SMTPModePlain, "", "")
+	sender, senderErr := NewSMTPSender(nil, SMTPConfig{Host: server.Host, Port: server.Port, From: testSenderAddress, Security: SecurityStartTLS, Timeout: 5 * time.Second})
+	require.NoError(t, senderErr)
+
+	sendErr := sender.SendEmail(context.Background(), testRecipientAddress, "Subject", "Body")
+	require.ErrorIs(t, sendErr, errStartTLSUnsupported)
+	require.Empty(t, server.Messages())
+}
+
+func TestSMTPSenderReportsAuthenticationFailure(t *testing.T) {
+	server := testutil.NewSMTPTestServer(t, testutil.SMTPModeStartTLS, testSMTPUsername, testSMTPPassword)
+	sender, senderErr := NewSMTPSender(nil, SMTPConfig{
+		Host:      server.Host,
+		Port:      server.Port,
+		Username:  testSMTPUsername,
+		Password:  "wrong",
+		From:      testSenderAddress,
+		TLSConfig: server.ClientTLSConfig(),
+		Timeout:   5 * time.Second,
+	})
+	require.NoError(t, senderErr)
+
+	require.Error(t, sender.SendEmail(context.Background(), testRecipientAddress, "Subject", "Body"))
+	require.Empty(t, server.Messages())
+}
@@ -0,0 +1,161 @@
+// Package mailer composes MIME email messages and delivers them over SMTP.
+package mailer
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+const (
+	headerFrom                    = "From"
+	headerTo                      = "To"
+	headerSubject                 = "Subject"
+	headerDate                    = "Date"
+	headerMessageID               = "Message-ID"
+	headerMIMEVersion             = "MIME-Version"
+	headerContentType             = "Content-Type"
+	headerContentTransferEncoding = "Content-Transfer-Encoding"
+	headerListUnsubscribe         = "List-Unsubscribe"
+	headerListUnsubscribePost     = "List-Unsubscribe-Post"
+
+	mimeVersion                = "1.0"
+	contentTypeTextPlain       = "text/plain; charset=utf-8"
+	contentTypeTextHTML        = "text/html; charset=utf-8"
+	contentTypeMultipartFormat = "multipart/alternative; boundary=%q"
+	transferQuotedPrintable    = "quoted-printable"
+	listUnsubscribeOneClick    = "List-Unsubscribe=One-Click"
+	charsetUTF8                = "utf-8"
+	defaultMessageIDDomain     = "loopaware.local"
+)
+
+var (
+	// ErrInvalidMessage reports a message that cannot be composed safely.
+	ErrInvalidMessage = errors.New("invalid email message")
+
+	orderedHeaderNames = []string{
+		headerFrom,
+		headerTo,
+		headerSubject,
+		headerDate,
+		headerMessageID,
+		headerListUnsubscribe,
+		headerListUnsubscribePost,
+		headerMIMEVersion,
+		headerContentType,
+		headerContentTransferEncoding,
+	}
+)
+
+// Message is an email with a plain-text body, an optional HTML alternative, and an optional one-click unsubscribe URL.
+type Message struct {
+	Recipient      string
+	Subject        string
+	TextBody       string
+	HTMLBody       string
+	UnsubscribeURL string
+}
+
+// Compose renders message as an RFC 5322 document sent from the given address.
+func Compose(from string, message Message, sentAt time.Time) ([]byte, error) {
+	fromAddress, fromErr := mail.ParseAddress(strings.TrimSpace(from))
+	if fromErr != nil {
+		return nil, fmt.Errorf("%w: sender: %v", ErrInvalidMessage, fromErr)
+	}
+	recipientAddress, recipientErr := mail.ParseAddress(strings.TrimSpace(message.Recipient))
+	if recipientErr != nil {
+		return nil, fmt.Errorf("%w: recipient: %v", ErrInvalidMessage, recipientErr)
+	}
+	unsubscribeURL := strings.TrimSpace(message.UnsubscribeURL)
+	if strings.ContainsAny(unsubscribeURL, "\r\n<>") {
+		return nil, fmt.Errorf("%w: unsubscribe url", ErrInvalidMessage)
+	}
+
+	headers := textproto.MIMEHeader{}
+	headers.Set(headerFrom, fromAddress.String())
+	headers.Set(headerTo, recipientAddress.String())
+	headers.Set(headerSubject, mime.QEncoding.Encode(charsetUTF8, strings.Join(strings.Fields(message.Subject), " ")))
+	headers.Set(headerDate, sentAt.Format(time.RFC1123Z))
+	headers.Set(headerMessageID, newMessageID(fromAddress.Address))
+	headers.Set(headerMIMEVersion, mimeVersion)
+	if unsubscribeURL != "" {
+		headers.Set(headerListUnsubscribe, "<"+unsubscribeURL+">")
+		headers.Set(headerListUnsubscribePost, listUnsubscribeOneClick)
+	}
+
+	body := &bytes.Buffer{}
+	if strings.TrimSpace(message.HTMLBody) == "" {
+		headers.Set(headerContentType, contentTypeTextPlain)
+		headers.Set(headerContentTransferEncoding, transferQuotedPrintable)
+		if err := writeQuotedPrintable(body, message.TextBody); err != nil {
+			return nil, err
+		}
+	} else {
+		partsWriter := multipart.NewWriter(body)
+		headers.Set(headerContentType, fmt.Sprintf(contentTypeMultipartFormat, partsWriter.Boundary()))
+		if err := writeAlternativePart(partsWriter, contentTypeTextPlain, message.TextBody); err != nil {
+			return nil, err
+		}
+		if err := writeAlternativePart(partsWriter, contentTypeTextHTML, message.HTMLBody); err != nil {
+			return nil, err
+		}
+		if err := partsWriter.Close(); err != nil {
+			return nil, err
+		}
+	}
+
+	document := &bytes.Buffer{}
+	for _, headerName := range orderedHeaderNames {
+		headerValue := headers.Get(headerName)
+		if headerValue == "" {
+			continue
+		}
+		_, _ = fmt.Fprintf(document, "%s: %s\r\n", headerName, headerValue)
+	}
+	document.WriteString("\r\n")
+	document.Write(body.Bytes())
+	return document.Bytes(), nil
+}
+
+func writeAlternativePart(partsWriter *multipart.Writer, contentType string, content string) error {
+	partHeaders := textproto.MIMEHeader{}
+	partHeaders.Set(headerContentType, contentType)
+	partHeaders.Set(headerContentTransferEncoding, transferQuotedPrintable)
+	part, partErr := partsWriter.CreatePart(partHeaders)
+	if partErr != nil {
+		return partErr
+	}
+	return writeQuotedPrintable(part, content)
+}
+
+func writeQuotedPrintable(destination io.Writer, content string) error {
+	encoder := quotedprintable.NewWriter(destination)
+	if _, err := encoder.Write([]byte(normalizeLineEndings(content))); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+func normalizeLineEndings(content string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(content, "\r\n", "\n"), "\n", "\r\n")
+}
+
+func newMessageID(senderAddress string) string {
+	domain := defaultMessageIDDomain
+	if separatorIndex := strings.LastIndex(senderAddress, "@"); separatorIndex >= 0 && separatorIndex < len(senderAddress)-1 {
+		domain = senderAddress[separatorIndex+1:]
+	}
+	randomBytes := make([]byte, 16)
+	_, _ = rand.Read(randomBytes)
+	return "<" + hex.EncodeToString(randomBytes) + "@" + domain + ">"
+}
@@ -0,0 +1,207 @@
+package mailer
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/MarkoPoloResearchLab/loopaware/internal/logging"
+)
+
+const (
+	// SecurityStartTLS upgrades a plain connection with STARTTLS and refuses servers that do not offer it.
+	SecurityStartTLS = "starttls"
+	// SecurityTLS connects with implicit TLS, typically on port 465.
+	SecurityTLS = "tls"
+	// SecurityNone sends without transport encryption; intended for local relays only.
+	SecurityNone = "none"
+
+	smtpExtensionStartTLS = "STARTTLS"
+	smtpExtensionAuth     = "AUTH"
+	defaultSMTPTimeout    = 30 * time.Second
+)
+
+var errStartTLSUnsupported = errors.New("smtp server does not support STARTTLS")
+
+// SMTPConfig captures connection settings for an SMTP relay.
+type SMTPConfig struct {
+	Host      string
+	Port      int
+	Username  string
+	Password  string
+	From      string
+	Security  string
+	Timeout   time.Duration
+	TLSConfig *tls.Config
+}
+
+// SMTPSender delivers email through an SMTP relay.
+type SMTPSender struct {
+	logger       *zap.Logger
+	address      string
+	host         string
+	username     string
+	password     string
+	from         string
+	envelopeFrom string
+	security     string
+	timeout      time.Duration
+	tlsConfig    *tls.Config
+	now          func() time.Time
+}
+
+// NewSMTPSender validates cfg and constructs an SMTPSender.
+func NewSMTPSender(logger *zap.Logger, cfg SMTPConfig) (*SMTPSender, error) {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	host := strings.TrimSpace(cfg.Host)
+	if host == "" {
+		return nil, errors.New("smtp host is required")
+	}
+	if cfg.Port <= 0 || cfg.Port > 65535 {
+		return nil, fmt.Errorf("smtp port %d is invalid", cfg.Port)
+	}
+	fromAddress, fromErr := mail.ParseAddress(strings.TrimSpace(cfg.From))
+	if fromErr != nil {
+		return nil, fmt.Errorf("smtp from address is invalid: %w", fromErr)
+	}
+	security := strings.ToLower(strings.TrimSpace(cfg.Security))
+	if security == "" {
+		security = SecurityStartTLS
+	}
+	if security != SecurityStartTLS && security != SecurityTLS && security != SecurityNone {
+		return nil, fmt.Errorf("smtp security %q is not one of %s, %s, %s", security, SecurityStartTLS, SecurityTLS, SecurityNone)
+	}
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = defaultSMTPTimeout
+	}
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if cfg.TLSConfig != nil {
+		tlsConfig = cfg.TLSConfig.Clone()
+	}
+	if tlsConfig.ServerName == "" {
+		tlsConfig.ServerName = host
+	}
+
+	return &SMTPSender{
+		logger:       logger,
+		address:      net.JoinHostPort(host, strconv.Itoa(cfg.Port)),
+		host:         host,
+		username:     strings.TrimSpace(cfg.Username),
+		password:     cfg.Password,
+		from:         fromAddress.String(),
+		envelopeFrom: fromAddress.Address,
+		security:     security,
+		timeout:      timeout,
+		tlsConfig:    tlsConfig,
+		now:          time.Now,
+	}, nil
+}
+
+// SendEmail sends a plain-text email.
+func (sender *SMTPSender) SendEmail(ctx context.Context, recipient string, subject string, message string) error {
+	return sender.SendEmailMessage(ctx, Message{Recipient: recipient, Subject: subject, TextBody: message})
+}
+
+// SendEmailMessage sends a message with its HTML alternative and unsubscribe headers.
+func (sender *SMTPSender) SendEmailMessage(ctx context.Context, message Message) error {
+	if sender == nil {
+		return errors.New("smtp sender not initialized")
+	}
+	recipientAddress, recipientErr := mail.ParseAddress(strings.TrimSpace(message.Recipient))
+	if recipientErr != nil {
+		return fmt.Errorf("%w: recipient: %v", ErrInvalidMessage, recipientErr)
+	}
+	document, composeErr := Compose(sender.from, message, sender.now())
+	if composeErr != nil {
+		return composeErr
+	}
+
+	if deliverErr := sender.deliver(ctx, recipientAddress.Address, document); deliverErr != nil {
+		logging.WithContext(ctx, sender.logger).Warn("smtp_send_failed", zap.Error(deliverErr), zap.String("smtp_address", sender.address))
+		return deliverErr
+	}
+	return nil
+}
+
+func (sender *SMTPSender) deliver(ctx context.Context, recipient string, document []byte) error {
+	dialer := &net.Dialer{Timeout: sender.timeout}
+	connection, dialErr := dialer.DialContext(ctx, "tcp", sender.address)
+	if dialErr != nil {
+		return fmt.Errorf("connect to smtp server: %w", dialErr)
+	}
+	stopWatchingContext := context.AfterFunc(ctx, func() {
+		_ = connection.Close()
+	})
+	defer stopWatchingContext()
+
+	deadline := time.Now().Add(sender.timeout)
+	if contextDeadline, hasDeadline := ctx.Deadline(); hasDeadline && contextDeadline.Before(deadline) {
+		deadline = contextDeadline
+	}
+	_ = connection.SetDeadline(deadline)
+
+	if sender.security == SecurityTLS {
+		tlsConnection := tls.Client(connection, sender.tlsConfig)
+		if handshakeErr := tlsConnection.HandshakeContext(ctx); handshakeErr != nil {
+			_ = connection.Close()
+			return fmt.Errorf("smtp tls handshake: %w", handshakeErr)
+		}
+		connection = tlsConnection
+	}
+
+	client, clientErr := smtp.NewClient(connection, sender.host)
+	if clientErr != nil {
+		_ = connection.Close()
+		return fmt.Errorf("smtp greeting: %w", clientErr)
+	}
+	defer client.Close()
+
+	if sender.security == SecurityStartTLS {
+		if supported, _ := client.Extension(smtpExtensionStartTLS); !supported {
+			return errStartTLSUnsupported
+		}
+		if startTLSErr := client.StartTLS(sender.tlsConfig); startTLSErr != nil {
+			return fmt.Errorf("smtp starttls: %w", startTLSErr)
+		}
+	}
+
+	if sender.username != "" {
+		if supported, _ := client.Extension(smtpExtensionAuth); !supported {
+			return errors.New("smtp server does not support AUTH")
+		}
+		if authErr := client.Auth(smtp.PlainAuth("", sender.username, sender.password, sender.host)); authErr != nil {
+			return fmt.Errorf("smtp auth: %w", authErr)
+		}
+	}
+
+	if mailErr := client.Mail(sender.envelopeFrom); mailErr != nil {
+		return fmt.Errorf("smtp mail from: %w", mailErr)
+	}
+	if rcptErr := client.Rcpt(recipient); rcptErr != nil {
+		return fmt.Errorf("smtp rcpt to: %w", rcptErr)
+	}
+	dataWriter, dataErr := client.Data()
+	if dataErr != nil {
+		return fmt.Errorf("smtp data: %w", dataErr)
+	}
+	if _, writeErr := dataWriter.Write(document); writeErr != nil {
+		_ = dataWriter.Close()
+		return fmt.Errorf("smtp write message: %w", writeErr)
+	}
+	if closeErr := dataWriter.Close(); closeErr != nil {
+		return fmt.Errorf("smtp finish message: %w", closeErr)
+	}
+	return client.Quit()
+}
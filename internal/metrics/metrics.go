@@ -0,0 +1,174 @@
+// Package metrics collects the server's Prometheus metrics. Every method is safe to call on a nil *Metrics, so
+// components can record unconditionally and tests can leave metrics out.
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	namespace = "loopaware"
+
+	// SubmissionFeedback labels widget feedback submissions.
+	SubmissionFeedback = "feedback"
+	// SubmissionSubscription labels subscription widget submissions.
+	SubmissionSubscription = "subscription"
+	// SubmissionVisit labels traffic pixel visits.
+	SubmissionVisit = "visit"
+
+	// NotificationFeedback labels owner notifications about new feedback.
+	NotificationFeedback = "feedback"
+	// NotificationSubscription labels owner notifications about new subscribers.
+	NotificationSubscription = "subscription"
+	// NotificationTrafficAlert labels traffic alert notifications.
+	NotificationTrafficAlert = "traffic_alert"
+	// NotificationEmail labels transactional and bulk emails (confirmations, campaigns, digests, reminders).
+	NotificationEmail = "email"
+
+	// OutcomeSent marks a notification the backend accepted.
+	OutcomeSent = "sent"
+	// OutcomeSkipped marks a notification that had no deliverable channel.
+	OutcomeSkipped = "skipped"
+	// OutcomeFailed marks a notification the backend rejected.
+	OutcomeFailed = "failed"
+
+	resultAccepted = "accepted"
+	resultRejected = "rejected"
+	reasonNone     = "none"
+	routeUnmatched = "unmatched"
+)
+
+// Metrics owns a Prometheus registry with the server's collectors.
+type Metrics struct {
+	registry        *prometheus.Registry
+	requestDuration *prometheus.HistogramVec
+	submissions     *prometheus.CounterVec
+	notifications   *prometheus.CounterVec
+	jobDuration     *prometheus.HistogramVec
+}
+
+// New builds a registry with the HTTP, submission, notification, and job collectors plus the Go runtime and
+// process collectors.
+func New() *Metrics {
+	registry := prometheus.NewRegistry()
+	instance := &Metrics{
+		registry: registry,
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency by method, route template, and status code.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		submissions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "public_submissions_total",
+			Help:      "Public feedback, subscription, and visit submissions by result and rejection reason.",
+		}, []string{"kind", "result", "reason"}),
+		notifications: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "notifications_total",
+			Help:      "Notification and email deliveries by kind and outcome.",
+		}, []string{"kind", "outcome"}),
+		jobDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "job_duration_seconds",
+			Help:      "Background job run durations by job and outcome.",
+			Buckets:   []float64{0.1, 0.5, 1, 5, 15, 30, 60, 300, 900, 1800, 3600},
+		}, []string{"job", "outcome"}),
+	}
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		instance.requestDuration,
+		instance.submissions,
+		instance.notifications,
+		instance.jobDuration,
+	)
+	return instance
+}
+
+// Handler serves the registry in the Prometheus text exposition format.
+func (instance *Metrics) Handler() http.Handler {
+	if instance == nil {
+		return http.NotFoundHandler()
+	}
+	return promhttp.HandlerFor(instance.registry, promhttp.HandlerOpts{})
+}
+
+// Registry exposes the underlying registry for additional collectors.
+func (instance *Metrics) Registry() *prometheus.Registry {
+	if instance == nil {
+		return nil
+	}
+	return instance.registry
+}
+
+// ObserveRequest records one HTTP request. route is the matched route template; an empty route (no match) is
+// recorded as "unmatched" so that arbitrary paths do not create new series.
+func (instance *Metrics) ObserveRequest(method string, route string, status int, duration time.Duration) {
+	if instance == nil {
+		return
+	}
+	if strings.TrimSpace(route) == "" {
+		route = routeUnmatched
+	}
+	instance.requestDuration.WithLabelValues(method, route, strconv.Itoa(status)).Observe(duration.Seconds())
+}
+
+// SubmissionAccepted counts a stored public submission of the given kind.
+func (instance *Metrics) SubmissionAccepted(kind string) {
+	if instance == nil {
+		return
+	}
+	instance.submissions.WithLabelValues(kind, resultAccepted, reasonNone).Inc()
+}
+
+// SubmissionRejected counts a refused public submission with the error code returned to the client.
+func (instance *Metrics) SubmissionRejected(kind string, reason string) {
+	if instance == nil {
+		return
+	}
+	instance.submissions.WithLabelValues(kind, resultRejected, reason).Inc()
+}
+
+// ObserveNotification counts one notification attempt of kind with outcome.
+func (instance *Metrics) ObserveNotification(kind string, outcome string) {
+	if instance == nil {
+		return
+	}
+	instance.notifications.WithLabelValues(kind, outcome).Inc()
+}
+
+// ObserveJob records a finished background job run.
+func (instance *Metrics) ObserveJob(name string, duration time.Duration, runErr error) {
+	if instance == nil {
+		return
+	}
+	outcome := "succeeded"
+	if runErr != nil {
+		outcome = "failed"
+	}
+	instance.jobDuration.WithLabelValues(name, outcome).Observe(duration.Seconds())
+}
+
+// RegisterSubscriberGauge publishes the live subscriber count of a server-sent events stream.
+func (instance *Metrics) RegisterSubscriberGauge(stream string, count func() int) {
+	if instance == nil || count == nil {
+		return
+	}
+	instance.registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace:   namespace,
+		Name:        "sse_subscribers",
+		Help:        "Connected server-sent events subscribers by stream.",
+		ConstLabels: prometheus.Labels{"stream": stream},
+	}, func() float64 {
+		return float64(count())
+	}))
+}
@@ -0,0 +1,56 @@
+package metrics
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func scrape(testingT *testing.T, instance *Metrics) string {
+	testingT.Helper()
+	recorder := httptest.NewRecorder()
+	instance.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(testingT, http.StatusOK, recorder.Code)
+	body, readErr := io.ReadAll(recorder.Body)
+	require.NoError(testingT, readErr)
+	return string(body)
+}
+
+func TestMetricsExposeRecordedSeries(testingT *testing.T) {
+	instance := New()
+	subscriberCount := 3
+	instance.RegisterSubscriberGauge("feedback", func() int { return subscriberCount })
+	instance.ObserveRequest(http.MethodGet, "", http.StatusNotFound, 20*time.Millisecond)
+	instance.ObserveNotification(NotificationEmail, OutcomeFailed)
+	instance.ObserveJob("digest_emails", 2*time.Second, nil)
+	instance.ObserveJob("digest_emails", time.Second, errors.New("smtp down"))
+
+	exposition := scrape(testingT, instance)
+	require.Contains(testingT, exposition, `loopaware_sse_subscribers{stream="feedback"} 3`)
+	require.Contains(testingT, exposition, `loopaware_http_request_duration_seconds_count{method="GET",route="unmatched",status="404"} 1`)
+	require.Contains(testingT, exposition, `loopaware_notifications_total{kind="email",outcome="failed"} 1`)
+	require.Contains(testingT, exposition, `loopaware_job_duration_seconds_count{job="digest_emails",outcome="succeeded"} 1`)
+	require.Contains(testingT, exposition, `loopaware_job_duration_seconds_count{job="digest_emails",outcome="failed"} 1`)
+	require.Contains(testingT, exposition, "go_goroutines")
+
+	subscriberCount = 1
+	require.Contains(testingT, scrape(testingT, instance), `loopaware_sse_subscribers{stream="feedback"} 1`)
+}
+
+func TestNilMetricsIgnoreObservations(testingT *testing.T) {
+	var instance *Metrics
+	require.NotPanics(testingT, func() {
+		instance.ObserveRequest(http.MethodGet, "/", http.StatusOK, time.Millisecond)
+		instance.SubmissionAccepted(SubmissionFeedback)
+		instance.SubmissionRejected(SubmissionVisit, "invalid_url")
+		instance.ObserveNotification(NotificationFeedback, OutcomeSent)
+		instance.ObserveJob("visit_rollup", time.Second, nil)
+		instance.RegisterSubscriberGauge("feedback", func() int { return 0 })
+	})
+	require.Nil(testingT, instance.Registry())
+}
@@ -0,0 +1,167 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	CampaignStatusDraft     = "draft"
+	CampaignStatusScheduled = "scheduled"
+	CampaignStatusSending   = "sending"
+	CampaignStatusSent      = "sent"
+
+	CampaignBodyFormatText     = "text"
+	CampaignBodyFormatMarkdown = "markdown"
+
+	CampaignDeliveryStatusPending = "pending"
+	CampaignDeliveryStatusSent    = "sent"
+	CampaignDeliveryStatusFailed  = "failed"
+	CampaignDeliveryStatusSkipped = "skipped"
+
+	campaignSubjectMaxLength       = 200
+	campaignBodyMaxLength          = 100000
+	campaignCreatorEmailMaxLength  = 320
+	campaignDeliveryErrorMaxLength = 500
+)
+
+var (
+	ErrInvalidCampaignSiteID     = errors.New("invalid_campaign_site_id")
+	ErrInvalidCampaignSubject    = errors.New("invalid_campaign_subject")
+	ErrInvalidCampaignBody       = errors.New("invalid_campaign_body")
+	ErrInvalidCampaignBodyFormat = errors.New("invalid_campaign_body_format")
+	ErrInvalidCampaignDelivery   = errors.New("invalid_campaign_delivery")
+)
+
+// Campaign is a newsletter broadcast sent to the confirmed subscribers of a site.
+type Campaign struct {
+	ID             string `gorm:"primaryKey;size:36"`
+	SiteID         string `gorm:"not null;size:36;index"`
+	Subject        string `gorm:"not null;size:200"`
+	Body           string `gorm:"type:text;not null"`
+	BodyFormat     string `gorm:"not null;size:16"`
+	Status         string `gorm:"not null;size:16;index"`
+	CreatedByEmail string `gorm:"size:320"`
+	ScheduledAt    time.Time
+	SendStartedAt  time.Time
+	SentAt         time.Time
+	RecipientCount int64
+	SentCount      int64
+	FailedCount    int64
+	CreatedAt      time.Time `gorm:"autoCreateTime"`
+	UpdatedAt      time.Time `gorm:"autoUpdateTime"`
+}
+
+// CampaignInput holds the raw values used to construct a Campaign.
+type CampaignInput struct {
+	SiteID         string
+	Subject        string
+	Body           string
+	BodyFormat     string
+	CreatedByEmail string
+}
+
+// CampaignDelivery records the delivery state of a campaign for one subscriber.
+type CampaignDelivery struct {
+	ID           string `gorm:"primaryKey;size:36"`
+	CampaignID   string `gorm:"not null;size:36;uniqueIndex:idx_campaign_deliveries_campaign_subscriber;index:idx_campaign_deliveries_campaign_status"`
+	SubscriberID string `gorm:"not null;size:36;uniqueIndex:idx_campaign_deliveries_campaign_subscriber"`
+	Email        string `gorm:"not null;size:320"`
+	Status       string `gorm:"not null;size:16;index:idx_campaign_deliveries_campaign_status"`
+	Error        string `gorm:"size:500"`
+	Attempts     int
+	SentAt       time.Time
+	CreatedAt    time.Time `gorm:"autoCreateTime"`
+	UpdatedAt    time.Time `gorm:"autoUpdateTime"`
+}
+
+// NewCampaign constructs a draft Campaign with validated, normalized content.
+func NewCampaign(input CampaignInput) (Campaign, error) {
+	siteID := strings.TrimSpace(input.SiteID)
+	if siteID == "" {
+		return Campaign{}, ErrInvalidCampaignSiteID
+	}
+
+	subject, body, bodyFormat, contentErr := NormalizeCampaignContent(input.Subject, input.Body, input.BodyFormat)
+	if contentErr != nil {
+		return Campaign{}, contentErr
+	}
+
+	createdByEmail := strings.ToLower(strings.TrimSpace(input.CreatedByEmail))
+	if len(createdByEmail) > campaignCreatorEmailMaxLength {
+		createdByEmail = createdByEmail[:campaignCreatorEmailMaxLength]
+	}
+
+	return Campaign{
+		ID:             uuid.NewString(),
+		SiteID:         siteID,
+		Subject:        subject,
+		Body:           body,
+		BodyFormat:     bodyFormat,
+		Status:         CampaignStatusDraft,
+		CreatedByEmail: createdByEmail,
+	}, nil
+}
+
+// NormalizeCampaignContent validates campaign subject, body, and body format.
+func NormalizeCampaignContent(rawSubject string, rawBody string, rawBodyFormat string) (string, string, string, error) {
+	subject := strings.TrimSpace(rawSubject)
+	if subject == "" || len(subject) > campaignSubjectMaxLength || strings.ContainsAny(subject, "\r\n") {
+		return "", "", "", fmt.Errorf("%w: empty, too long, or multi-line", ErrInvalidCampaignSubject)
+	}
+
+	body := strings.TrimSpace(rawBody)
+	if body == "" || len(body) > campaignBodyMaxLength {
+		return "", "", "", fmt.Errorf("%w: empty or too long", ErrInvalidCampaignBody)
+	}
+
+	bodyFormat := strings.ToLower(strings.TrimSpace(rawBodyFormat))
+	if bodyFormat == "" {
+		bodyFormat = CampaignBodyFormatText
+	}
+	switch bodyFormat {
+	case CampaignBodyFormatText, CampaignBodyFormatMarkdown:
+	default:
+		return "", "", "", fmt.Errorf("%w: %s", ErrInvalidCampaignBodyFormat, bodyFormat)
+	}
+
+	return subject, body, bodyFormat, nil
+}
+
+// IsEditable reports whether the campaign content may still change.
+func (campaign Campaign) IsEditable() bool {
+	return campaign.Status == CampaignStatusDraft || campaign.Status == CampaignStatusScheduled
+}
+
+// NewCampaignDelivery constructs a pending delivery for a campaign recipient.
+func NewCampaignDelivery(campaignID string, subscriberID string, email string) (CampaignDelivery, error) {
+	normalizedCampaignID := strings.TrimSpace(campaignID)
+	normalizedSubscriberID := strings.TrimSpace(subscriberID)
+	normalizedEmail := strings.ToLower(strings.TrimSpace(email))
+	if normalizedCampaignID == "" || normalizedSubscriberID == "" {
+		return CampaignDelivery{}, fmt.Errorf("%w: missing identifiers", ErrInvalidCampaignDelivery)
+	}
+	if err := validateSubscriberEmail(normalizedEmail); err != nil {
+		return CampaignDelivery{}, fmt.Errorf("%w: %v", ErrInvalidCampaignDelivery, err)
+	}
+	return CampaignDelivery{
+		ID:           uuid.NewString(),
+		CampaignID:   normalizedCampaignID,
+		SubscriberID: normalizedSubscriberID,
+		Email:        normalizedEmail,
+		Status:       CampaignDeliveryStatusPending,
+	}, nil
+}
+
+// TruncateCampaignDeliveryError bounds a delivery error message to the stored column size.
+func TruncateCampaignDeliveryError(message string) string {
+	trimmed := strings.TrimSpace(message)
+	if len(trimmed) > campaignDeliveryErrorMaxLength {
+		return trimmed[:campaignDeliveryErrorMaxLength]
+	}
+	return trimmed
+}
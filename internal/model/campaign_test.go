@@ -0,0 +1,84 @@
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testCampaignSiteID       = "site-123"
+	testCampaignSubject      = "Monthly update"
+	testCampaignBody         = "Here is what changed this month."
+	testCampaignCreatorEmail = "Owner@Example.com"
+)
+
+func TestNewCampaignBuildsDraft(t *testing.T) {
+	campaign, err := NewCampaign(CampaignInput{
+		SiteID:         " " + testCampaignSiteID + " ",
+		Subject:        "  " + testCampaignSubject + "  ",
+		Body:           testCampaignBody,
+		BodyFormat:     "Markdown",
+		CreatedByEmail: testCampaignCreatorEmail,
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, campaign.ID)
+	require.Equal(t, testCampaignSiteID, campaign.SiteID)
+	require.Equal(t, testCampaignSubject, campaign.Subject)
+	require.Equal(t, CampaignBodyFormatMarkdown, campaign.BodyFormat)
+	require.Equal(t, CampaignStatusDraft, campaign.Status)
+	require.Equal(t, strings.ToLower(testCampaignCreatorEmail), campaign.CreatedByEmail)
+	require.True(t, campaign.IsEditable())
+}
+
+func TestNewCampaignDefaultsToTextFormat(t *testing.T) {
+	campaign, err := NewCampaign(CampaignInput{SiteID: testCampaignSiteID, Subject: testCampaignSubject, Body: testCampaignBody})
+	require.NoError(t, err)
+	require.Equal(t, CampaignBodyFormatText, campaign.BodyFormat)
+}
+
+func TestNewCampaignRejectsInvalidInput(t *testing.T) {
+	testCases := []struct {
+		name        string
+		input       CampaignInput
+		expectedErr error
+	}{
+		{"missing site", CampaignInput{Subject: testCampaignSubject, Body: testCampaignBody}, ErrInvalidCampaignSiteID},
+		{"missing subject", CampaignInput{SiteID: testCampaignSiteID, Body: testCampaignBody}, ErrInvalidCampaignSubject},
+		{"multi-line subject", CampaignInput{SiteID: testCampaignSiteID, Subject: "a\nb", Body: testCampaignBody}, ErrInvalidCampaignSubject},
+		{"long subject", CampaignInput{SiteID: testCampaignSiteID, Subject: strings.Repeat("s", campaignSubjectMaxLength+1), Body: testCampaignBody}, ErrInvalidCampaignSubject},
+		{"missing body", CampaignInput{SiteID: testCampaignSiteID, Subject: testCampaignSubject}, ErrInvalidCampaignBody},
+		{"unknown format", CampaignInput{SiteID: testCampaignSiteID, Subject: testCampaignSubject, Body: testCampaignBody, BodyFormat: "html"}, ErrInvalidCampaignBodyFormat},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			_, err := NewCampaign(testCase.input)
+			require.ErrorIs(t, err, testCase.expectedErr)
+		})
+	}
+}
+
+func TestCampaignIsEditableOnlyBeforeSending(t *testing.T) {
+	require.True(t, Campaign{Status: CampaignStatusScheduled}.IsEditable())
+	require.False(t, Campaign{Status: CampaignStatusSending}.IsEditable())
+	require.False(t, Campaign{Status: CampaignStatusSent}.IsEditable())
+}
+
+func TestNewCampaignDeliveryValidates(t *testing.T) {
+	delivery, err := NewCampaignDelivery("campaign-1", "subscriber-1", "Reader@Example.com")
+	require.NoError(t, err)
+	require.Equal(t, CampaignDeliveryStatusPending, delivery.Status)
+	require.Equal(t, "reader@example.com", delivery.Email)
+
+	_, missingErr := NewCampaignDelivery("", "subscriber-1", "reader@example.com")
+	require.ErrorIs(t, missingErr, ErrInvalidCampaignDelivery)
+
+	_, emailErr := NewCampaignDelivery("campaign-1", "subscriber-1", "not-an-email")
+	require.ErrorIs(t, emailErr, ErrInvalidCampaignDelivery)
+}
+
+func TestTruncateCampaignDeliveryError(t *testing.T) {
+	require.Len(t, TruncateCampaignDeliveryError(strings.Repeat("e", campaignDeliveryErrorMaxLength+10)), campaignDeliveryErrorMaxLength)
+	require.Equal(t, "boom", TruncateCampaignDeliveryError(" boom "))
+}
@@ -0,0 +1,40 @@
+package model
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+const (
+	DigestFrequencyOff    = "off"
+	DigestFrequencyDaily  = "daily"
+	DigestFrequencyWeekly = "weekly"
+)
+
+var ErrInvalidDigestFrequency = errors.New("invalid_digest_frequency")
+
+// NormalizeDigestFrequency validates a digest preference, treating an empty value as off.
+func NormalizeDigestFrequency(rawFrequency string) (string, error) {
+	normalizedFrequency := strings.ToLower(strings.TrimSpace(rawFrequency))
+	switch normalizedFrequency {
+	case "":
+		return DigestFrequencyOff, nil
+	case DigestFrequencyOff, DigestFrequencyDaily, DigestFrequencyWeekly:
+		return normalizedFrequency, nil
+	default:
+		return "", ErrInvalidDigestFrequency
+	}
+}
+
+// DigestPeriod returns how much activity one digest covers, or zero when digests are off.
+func DigestPeriod(frequency string) time.Duration {
+	switch frequency {
+	case DigestFrequencyDaily:
+		return 24 * time.Hour
+	case DigestFrequencyWeekly:
+		return 7 * 24 * time.Hour
+	default:
+		return 0
+	}
+}
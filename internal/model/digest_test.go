@@ -0,0 +1,37 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeDigestFrequency(t *testing.T) {
+	testCases := []struct {
+		name              string
+		rawFrequency      string
+		expectedFrequency string
+		expectedErr       error
+	}{
+		{name: "empty means off", rawFrequency: "", expectedFrequency: DigestFrequencyOff},
+		{name: "daily", rawFrequency: " Daily ", expectedFrequency: DigestFrequencyDaily},
+		{name: "weekly", rawFrequency: "weekly", expectedFrequency: DigestFrequencyWeekly},
+		{name: "off", rawFrequency: "OFF", expectedFrequency: DigestFrequencyOff},
+		{name: "unknown", rawFrequency: "monthly", expectedErr: ErrInvalidDigestFrequency},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			frequency, err := NormalizeDigestFrequency(testCase.rawFrequency)
+			require.ErrorIs(t, err, testCase.expectedErr)
+			require.Equal(t, testCase.expectedFrequency, frequency)
+		})
+	}
+}
+
+func TestDigestPeriod(t *testing.T) {
+	require.Equal(t, 24*time.Hour, DigestPeriod(DigestFrequencyDaily))
+	require.Equal(t, 7*24*time.Hour, DigestPeriod(DigestFrequencyWeekly))
+	require.Zero(t, DigestPeriod(DigestFrequencyOff))
+}
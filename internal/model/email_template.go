@@ -0,0 +1,151 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	EmailTemplateKindSubscriptionConfirmation = "subscription_confirmation"
+	EmailTemplateKindFeedbackNotification     = "feedback_notification"
+	EmailTemplateKindSubscriptionNotification = "subscription_notification"
+
+	emailTemplateSubjectMaxLength = 300
+	emailTemplateBodyMaxLength    = 20000
+	localeMaxLength               = 16
+)
+
+var (
+	ErrInvalidEmailTemplateSiteID  = errors.New("invalid_email_template_site_id")
+	ErrInvalidEmailTemplateKind    = errors.New("invalid_email_template_kind")
+	ErrInvalidEmailTemplateSubject = errors.New("invalid_email_template_subject")
+	ErrInvalidEmailTemplateBody    = errors.New("invalid_email_template_body")
+	ErrInvalidLocale               = errors.New("invalid_locale")
+
+	localeExpression = regexp.MustCompile(`^[a-z]{2,3}(-[a-z0-9]{2,8})*$`)
+)
+
+// EmailTemplateKinds lists the message kinds that support per-site templates.
+var EmailTemplateKinds = []string{
+	EmailTemplateKindSubscriptionConfirmation,
+	EmailTemplateKindFeedbackNotification,
+	EmailTemplateKindSubscriptionNotification,
+}
+
+// EmailTemplate stores a site-specific override for one email kind and locale.
+type EmailTemplate struct {
+	ID             string    `gorm:"primaryKey;size:36"`
+	SiteID         string    `gorm:"not null;size:36;uniqueIndex:idx_email_templates_site_kind_locale"`
+	Kind           string    `gorm:"not null;size:64;uniqueIndex:idx_email_templates_site_kind_locale"`
+	Locale         string    `gorm:"not null;size:16;uniqueIndex:idx_email_templates_site_kind_locale"`
+	Subject        string    `gorm:"not null;size:300"`
+	TextBody       string    `gorm:"type:text;not null"`
+	HTMLBody       string    `gorm:"type:text"`
+	UpdatedByEmail string    `gorm:"size:320"`
+	CreatedAt      time.Time `gorm:"autoCreateTime"`
+	UpdatedAt      time.Time `gorm:"autoUpdateTime"`
+}
+
+// EmailTemplateInput holds the raw values used to construct an EmailTemplate.
+type EmailTemplateInput struct {
+	SiteID         string
+	Kind           string
+	Locale         string
+	Subject        string
+	TextBody       string
+	HTMLBody       string
+	UpdatedByEmail string
+}
+
+// NewEmailTemplate constructs an EmailTemplate with validated, normalized fields.
+func NewEmailTemplate(input EmailTemplateInput) (EmailTemplate, error) {
+	siteID := strings.TrimSpace(input.SiteID)
+	if siteID == "" {
+		return EmailTemplate{}, ErrInvalidEmailTemplateSiteID
+	}
+
+	kind, kindErr := NormalizeEmailTemplateKind(input.Kind)
+	if kindErr != nil {
+		return EmailTemplate{}, kindErr
+	}
+
+	locale, localeErr := NormalizeLocale(input.Locale)
+	if localeErr != nil {
+		return EmailTemplate{}, localeErr
+	}
+
+	subject := strings.TrimSpace(input.Subject)
+	if subject == "" || len(subject) > emailTemplateSubjectMaxLength {
+		return EmailTemplate{}, fmt.Errorf("%w: empty or too long", ErrInvalidEmailTemplateSubject)
+	}
+
+	textBody := input.TextBody
+	if strings.TrimSpace(textBody) == "" || len(textBody) > emailTemplateBodyMaxLength {
+		return EmailTemplate{}, fmt.Errorf("%w: text body empty or too long", ErrInvalidEmailTemplateBody)
+	}
+
+	htmlBody := input.HTMLBody
+	if strings.TrimSpace(htmlBody) == "" {
+		htmlBody = ""
+	}
+	if len(htmlBody) > emailTemplateBodyMaxLength {
+		return EmailTemplate{}, fmt.Errorf("%w: html body too long", ErrInvalidEmailTemplateBody)
+	}
+
+	return EmailTemplate{
+		ID:             uuid.NewString(),
+		SiteID:         siteID,
+		Kind:           kind,
+		Locale:         locale,
+		Subject:        subject,
+		TextBody:       textBody,
+		HTMLBody:       htmlBody,
+		UpdatedByEmail: strings.ToLower(strings.TrimSpace(input.UpdatedByEmail)),
+	}, nil
+}
+
+// NormalizeEmailTemplateKind validates an email template kind.
+func NormalizeEmailTemplateKind(rawKind string) (string, error) {
+	kind := strings.ToLower(strings.TrimSpace(rawKind))
+	for _, supportedKind := range EmailTemplateKinds {
+		if kind == supportedKind {
+			return kind, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s", ErrInvalidEmailTemplateKind, kind)
+}
+
+// NormalizeLocale lowercases a BCP 47 style language tag; an empty value selects the default locale.
+func NormalizeLocale(rawLocale string) (string, error) {
+	locale := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(rawLocale), "_", "-"))
+	if locale == "" {
+		return "", nil
+	}
+	if len(locale) > localeMaxLength || !localeExpression.MatchString(locale) {
+		return "", fmt.Errorf("%w: %s", ErrInvalidLocale, locale)
+	}
+	return locale, nil
+}
+
+// LocaleFallbacks returns the lookup order for a locale, ending with the default locale.
+func LocaleFallbacks(locale string) []string {
+	normalized, err := NormalizeLocale(locale)
+	if err != nil || normalized == "" {
+		return []string{""}
+	}
+	fallbacks := []string{normalized}
+	for {
+		separatorIndex := strings.LastIndex(normalized, "-")
+		if separatorIndex <= 0 {
+			break
+		}
+		normalized = normalized[:separatorIndex]
+		fallbacks = append(fallbacks, normalized)
+	}
+	return append(fallbacks, "")
+}
@@ -0,0 +1,64 @@
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewEmailTemplateNormalizesKindAndLocale(t *testing.T) {
+	emailTemplate, err := NewEmailTemplate(EmailTemplateInput{
+		SiteID:         " site-1 ",
+		Kind:           " Feedback_Notification ",
+		Locale:         "PT_br",
+		Subject:        "  New feedback  ",
+		TextBody:       "{{.FeedbackMessage}}",
+		HTMLBody:       "   ",
+		UpdatedByEmail: "Owner@Example.com",
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, emailTemplate.ID)
+	require.Equal(t, "site-1", emailTemplate.SiteID)
+	require.Equal(t, EmailTemplateKindFeedbackNotification, emailTemplate.Kind)
+	require.Equal(t, "pt-br", emailTemplate.Locale)
+	require.Equal(t, "New feedback", emailTemplate.Subject)
+	require.Empty(t, emailTemplate.HTMLBody)
+	require.Equal(t, "owner@example.com", emailTemplate.UpdatedByEmail)
+}
+
+func TestNewEmailTemplateRejectsInvalidInput(t *testing.T) {
+	validInput := EmailTemplateInput{SiteID: "site-1", Kind: EmailTemplateKindFeedbackNotification, Subject: "Subject", TextBody: "Body"}
+
+	missingSite := validInput
+	missingSite.SiteID = " "
+	_, err := NewEmailTemplate(missingSite)
+	require.ErrorIs(t, err, ErrInvalidEmailTemplateSiteID)
+
+	unknownKind := validInput
+	unknownKind.Kind = "welcome"
+	_, err = NewEmailTemplate(unknownKind)
+	require.ErrorIs(t, err, ErrInvalidEmailTemplateKind)
+
+	badLocale := validInput
+	badLocale.Locale = "english please"
+	_, err = NewEmailTemplate(badLocale)
+	require.ErrorIs(t, err, ErrInvalidLocale)
+
+	emptySubject := validInput
+	emptySubject.Subject = ""
+	_, err = NewEmailTemplate(emptySubject)
+	require.ErrorIs(t, err, ErrInvalidEmailTemplateSubject)
+
+	oversizedBody := validInput
+	oversizedBody.TextBody = strings.Repeat("a", emailTemplateBodyMaxLength+1)
+	_, err = NewEmailTemplate(oversizedBody)
+	require.ErrorIs(t, err, ErrInvalidEmailTemplateBody)
+}
+
+func TestLocaleFallbacksEndWithDefaultLocale(t *testing.T) {
+	require.Equal(t, []string{"zh-hant-tw", "zh-hant", "zh", ""}, LocaleFallbacks("zh_Hant_TW"))
+	require.Equal(t, []string{"en", ""}, LocaleFallbacks("EN"))
+	require.Equal(t, []string{""}, LocaleFallbacks(""))
+	require.Equal(t, []string{""}, LocaleFallbacks("%%"))
+}
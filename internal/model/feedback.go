@@ -0,0 +1,152 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+const (
+	FeedbackRatingMin   = 1
+	FeedbackRatingMax   = 5
+	FeedbackNPSScoreMin = 0
+	FeedbackNPSScoreMax = 10
+
+	FeedbackNPSDetractorMax = 6
+	FeedbackNPSPromoterMin  = 9
+
+	FeedbackNPSSegmentPromoter  = "promoter"
+	FeedbackNPSSegmentPassive   = "passive"
+	FeedbackNPSSegmentDetractor = "detractor"
+
+	feedbackContactMaxLength    = 320
+	feedbackMessageMaxLength    = 4000
+	feedbackCategoryMaxLength   = 64
+	feedbackPageURLMaxLength    = 500
+	feedbackExtraFieldMaxLength = 80
+	feedbackIPMaxLength         = 64
+	feedbackUserAgentMaxLength  = 400
+)
+
+var (
+	ErrInvalidFeedbackSiteID   = errors.New("invalid_feedback_site_id")
+	ErrInvalidFeedbackMessage  = errors.New("invalid_feedback_message")
+	ErrInvalidFeedbackRating   = errors.New("invalid_feedback_rating")
+	ErrInvalidFeedbackNPSScore = errors.New("invalid_feedback_nps_score")
+	ErrInvalidFeedbackCategory = errors.New("invalid_feedback_category")
+	ErrInvalidFeedbackPageURL  = errors.New("invalid_feedback_page_url")
+)
+
+// FeedbackInput holds the raw values used to construct a Feedback.
+type FeedbackInput struct {
+	SiteID     string
+	Contact    string
+	Message    string
+	Rating     *int
+	NPSScore   *int
+	Category   string
+	PageURL    string
+	ExtraField string
+	IP         string
+	UserAgent  string
+}
+
+// NewFeedback constructs a Feedback with validated, normalized fields.
+func NewFeedback(input FeedbackInput) (Feedback, error) {
+	siteID := strings.TrimSpace(input.SiteID)
+	if siteID == "" {
+		return Feedback{}, ErrInvalidFeedbackSiteID
+	}
+
+	message := strings.TrimSpace(input.Message)
+	if message == "" {
+		return Feedback{}, ErrInvalidFeedbackMessage
+	}
+
+	rating, ratingErr := normalizeFeedbackScore(input.Rating, FeedbackRatingMin, FeedbackRatingMax, ErrInvalidFeedbackRating)
+	if ratingErr != nil {
+		return Feedback{}, ratingErr
+	}
+
+	npsScore, npsErr := normalizeFeedbackScore(input.NPSScore, FeedbackNPSScoreMin, FeedbackNPSScoreMax, ErrInvalidFeedbackNPSScore)
+	if npsErr != nil {
+		return Feedback{}, npsErr
+	}
+
+	category := strings.Join(strings.Fields(input.Category), " ")
+	if len(category) > feedbackCategoryMaxLength {
+		return Feedback{}, fmt.Errorf("%w: longer than %d characters", ErrInvalidFeedbackCategory, feedbackCategoryMaxLength)
+	}
+
+	pageURL, pageURLErr := normalizeFeedbackPageURL(input.PageURL)
+	if pageURLErr != nil {
+		return Feedback{}, pageURLErr
+	}
+
+	return Feedback{
+		ID:         uuid.NewString(),
+		SiteID:     siteID,
+		Contact:    truncateFeedbackValue(strings.TrimSpace(input.Contact), feedbackContactMaxLength),
+		Message:    truncateFeedbackValue(message, feedbackMessageMaxLength),
+		Rating:     rating,
+		NPSScore:   npsScore,
+		Category:   category,
+		PageURL:    pageURL,
+		ExtraField: truncateFeedbackValue(strings.TrimSpace(input.ExtraField), feedbackExtraFieldMaxLength),
+		IP:         truncateFeedbackValue(strings.TrimSpace(input.IP), feedbackIPMaxLength),
+		UserAgent:  truncateFeedbackValue(input.UserAgent, feedbackUserAgentMaxLength),
+		Delivery:   FeedbackDeliveryNone,
+	}, nil
+}
+
+// NPSSegment classifies an NPS score as a promoter, passive, or detractor.
+func NPSSegment(score int) string {
+	switch {
+	case score >= FeedbackNPSPromoterMin:
+		return FeedbackNPSSegmentPromoter
+	case score <= FeedbackNPSDetractorMax:
+		return FeedbackNPSSegmentDetractor
+	default:
+		return FeedbackNPSSegmentPassive
+	}
+}
+
+func normalizeFeedbackScore(value *int, minimum int, maximum int, invalidErr error) (*int, error) {
+	if value == nil {
+		return nil, nil
+	}
+	if *value < minimum || *value > maximum {
+		return nil, fmt.Errorf("%w: %d outside %d-%d", invalidErr, *value, minimum, maximum)
+	}
+	score := *value
+	return &score, nil
+}
+
+func normalizeFeedbackPageURL(rawValue string) (string, error) {
+	pageURL := strings.TrimSpace(rawValue)
+	if pageURL == "" {
+		return "", nil
+	}
+	if len(pageURL) > feedbackPageURLMaxLength {
+		return "", fmt.Errorf("%w: longer than %d characters", ErrInvalidFeedbackPageURL, feedbackPageURLMaxLength)
+	}
+	parsedURL, parseErr := url.Parse(pageURL)
+	if parseErr != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidFeedbackPageURL, parseErr)
+	}
+	scheme := strings.ToLower(parsedURL.Scheme)
+	if (scheme != "http" && scheme != "https") || parsedURL.Host == "" {
+		return "", fmt.Errorf("%w: expected absolute http(s) URL", ErrInvalidFeedbackPageURL)
+	}
+	return pageURL, nil
+}
+
+func truncateFeedbackValue(value string, maxLength int) string {
+	if len(value) <= maxLength {
+		return value
+	}
+	return value[:maxLength]
+}
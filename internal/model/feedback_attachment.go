@@ -0,0 +1,138 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+)
+
+const (
+	FeedbackAttachmentContentTypePNG  = "image/png"
+	FeedbackAttachmentContentTypeJPEG = "image/jpeg"
+	FeedbackAttachmentContentTypeGIF  = "image/gif"
+	FeedbackAttachmentContentTypeWebP = "image/webp"
+
+	feedbackAttachmentFileNameMaxLength = 255
+	feedbackAttachmentDefaultFileName   = "attachment"
+	feedbackAttachmentOctetStream       = "application/octet-stream"
+)
+
+var (
+	ErrInvalidFeedbackAttachmentFeedbackID  = errors.New("invalid_feedback_attachment_feedback_id")
+	ErrInvalidFeedbackAttachmentSiteID      = errors.New("invalid_feedback_attachment_site_id")
+	ErrEmptyFeedbackAttachment              = errors.New("empty_feedback_attachment")
+	ErrUnsupportedFeedbackAttachmentContent = errors.New("unsupported_feedback_attachment_content")
+
+	feedbackAttachmentExtensions = map[string]string{
+		FeedbackAttachmentContentTypePNG:  ".png",
+		FeedbackAttachmentContentTypeJPEG: ".jpg",
+		FeedbackAttachmentContentTypeGIF:  ".gif",
+		FeedbackAttachmentContentTypeWebP: ".webp",
+	}
+)
+
+// FeedbackAttachment records an image uploaded alongside a feedback message. The bytes live in a blob store under StorageKey.
+type FeedbackAttachment struct {
+	ID          string    `gorm:"primaryKey;size:36"`
+	FeedbackID  string    `gorm:"index;not null;size:36"`
+	SiteID      string    `gorm:"index;not null;size:36"`
+	FileName    string    `gorm:"not null;size:255"`
+	ContentType string    `gorm:"not null;size:100"`
+	SizeBytes   int64     `gorm:"not null"`
+	StorageKey  string    `gorm:"not null;size:128"`
+	CreatedAt   time.Time `gorm:"autoCreateTime"`
+}
+
+// FeedbackAttachmentInput holds the raw values used to construct a FeedbackAttachment.
+type FeedbackAttachmentInput struct {
+	FeedbackID          string
+	SiteID              string
+	FileName            string
+	DeclaredContentType string
+	Data                []byte
+}
+
+// NewFeedbackAttachment validates an uploaded image and constructs its metadata record.
+// The content type is sniffed from the data; the declared type only has to be plausible.
+func NewFeedbackAttachment(input FeedbackAttachmentInput) (FeedbackAttachment, error) {
+	feedbackID := strings.TrimSpace(input.FeedbackID)
+	if feedbackID == "" {
+		return FeedbackAttachment{}, ErrInvalidFeedbackAttachmentFeedbackID
+	}
+	siteID := strings.TrimSpace(input.SiteID)
+	if siteID == "" {
+		return FeedbackAttachment{}, ErrInvalidFeedbackAttachmentSiteID
+	}
+	if len(input.Data) == 0 {
+		return FeedbackAttachment{}, ErrEmptyFeedbackAttachment
+	}
+
+	contentType, contentTypeErr := DetectFeedbackAttachmentContentType(input.DeclaredContentType, input.Data)
+	if contentTypeErr != nil {
+		return FeedbackAttachment{}, contentTypeErr
+	}
+
+	attachmentID := uuid.NewString()
+	return FeedbackAttachment{
+		ID:          attachmentID,
+		FeedbackID:  feedbackID,
+		SiteID:      siteID,
+		FileName:    sanitizeFeedbackAttachmentFileName(input.FileName, contentType),
+		ContentType: contentType,
+		SizeBytes:   int64(len(input.Data)),
+		StorageKey:  attachmentID,
+	}, nil
+}
+
+// DetectFeedbackAttachmentContentType sniffs data and returns its image content type when it is a supported raster format.
+func DetectFeedbackAttachmentContentType(declaredContentType string, data []byte) (string, error) {
+	if !isPlausibleAttachmentContentType(declaredContentType) {
+		return "", fmt.Errorf("%w: declared %q", ErrUnsupportedFeedbackAttachmentContent, declaredContentType)
+	}
+	sniffedContentType := http.DetectContentType(data)
+	if _, supported := feedbackAttachmentExtensions[sniffedContentType]; !supported {
+		return "", fmt.Errorf("%w: detected %q", ErrUnsupportedFeedbackAttachmentContent, sniffedContentType)
+	}
+	return sniffedContentType, nil
+}
+
+func isPlausibleAttachmentContentType(declaredContentType string) bool {
+	trimmedContentType := strings.TrimSpace(declaredContentType)
+	if trimmedContentType == "" {
+		return true
+	}
+	mediaType, _, parseErr := mime.ParseMediaType(trimmedContentType)
+	if parseErr != nil {
+		return false
+	}
+	if mediaType == feedbackAttachmentOctetStream {
+		return true
+	}
+	return strings.HasPrefix(mediaType, "image/") && !strings.Contains(mediaType, "svg")
+}
+
+func sanitizeFeedbackAttachmentFileName(rawFileName string, contentType string) string {
+	baseName := path.Base(strings.ReplaceAll(strings.TrimSpace(rawFileName), "\\", "/"))
+	var builder strings.Builder
+	for _, character := range baseName {
+		if unicode.IsControl(character) || strings.ContainsRune(`"/\;`, character) {
+			continue
+		}
+		builder.WriteRune(character)
+	}
+	fileName := strings.TrimSpace(builder.String())
+	if fileName == "" || fileName == "." || fileName == ".." {
+		fileName = feedbackAttachmentDefaultFileName + feedbackAttachmentExtensions[contentType]
+	}
+	if len(fileName) > feedbackAttachmentFileNameMaxLength {
+		fileName = strings.ToValidUTF8(fileName[:feedbackAttachmentFileNameMaxLength], "")
+	}
+	return fileName
+}
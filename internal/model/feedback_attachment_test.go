@@ -0,0 +1,58 @@
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var testPNGHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+func TestNewFeedbackAttachmentSniffsContentType(t *testing.T) {
+	attachment, err := NewFeedbackAttachment(FeedbackAttachmentInput{
+		FeedbackID:          "feedback-1",
+		SiteID:              "site-1",
+		FileName:            `C:\Users\me\"screen"shot.png`,
+		DeclaredContentType: "image/jpeg",
+		Data:                testPNGHeader,
+	})
+	require.NoError(t, err)
+	require.Equal(t, FeedbackAttachmentContentTypePNG, attachment.ContentType)
+	require.Equal(t, "screenshot.png", attachment.FileName)
+	require.Equal(t, int64(len(testPNGHeader)), attachment.SizeBytes)
+	require.Equal(t, attachment.ID, attachment.StorageKey)
+}
+
+func TestNewFeedbackAttachmentDefaultsFileName(t *testing.T) {
+	attachment, err := NewFeedbackAttachment(FeedbackAttachmentInput{FeedbackID: "feedback-1", SiteID: "site-1", Data: []byte("GIF89a....")})
+	require.NoError(t, err)
+	require.Equal(t, "attachment.gif", attachment.FileName)
+
+	longName := strings.Repeat("a", 300) + ".png"
+	attachment, err = NewFeedbackAttachment(FeedbackAttachmentInput{FeedbackID: "feedback-1", SiteID: "site-1", FileName: longName, Data: testPNGHeader})
+	require.NoError(t, err)
+	require.Len(t, attachment.FileName, feedbackAttachmentFileNameMaxLength)
+}
+
+func TestNewFeedbackAttachmentRejectsInvalidInput(t *testing.T) {
+	testCases := []struct {
+		name        string
+		input       FeedbackAttachmentInput
+		expectedErr error
+	}{
+		{name: "missing feedback", input: FeedbackAttachmentInput{SiteID: "site-1", Data: testPNGHeader}, expectedErr: ErrInvalidFeedbackAttachmentFeedbackID},
+		{name: "missing site", input: FeedbackAttachmentInput{FeedbackID: "feedback-1", Data: testPNGHeader}, expectedErr: ErrInvalidFeedbackAttachmentSiteID},
+		{name: "empty data", input: FeedbackAttachmentInput{FeedbackID: "feedback-1", SiteID: "site-1"}, expectedErr: ErrEmptyFeedbackAttachment},
+		{name: "declared text", input: FeedbackAttachmentInput{FeedbackID: "feedback-1", SiteID: "site-1", DeclaredContentType: "text/html", Data: testPNGHeader}, expectedErr: ErrUnsupportedFeedbackAttachmentContent},
+		{name: "declared svg", input: FeedbackAttachmentInput{FeedbackID: "feedback-1", SiteID: "site-1", DeclaredContentType: "image/svg+xml", Data: testPNGHeader}, expectedErr: ErrUnsupportedFeedbackAttachmentContent},
+		{name: "sniffed html", input: FeedbackAttachmentInput{FeedbackID: "feedback-1", SiteID: "site-1", DeclaredContentType: "image/png", Data: []byte("<html><script>alert(1)</script>")}, expectedErr: ErrUnsupportedFeedbackAttachmentContent},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			_, err := NewFeedbackAttachment(testCase.input)
+			require.ErrorIs(t, err, testCase.expectedErr)
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewFeedbackNormalizesStructuredFields(t *testing.T) {
+	rating := 4
+	npsScore := 9
+	feedback, err := NewFeedback(FeedbackInput{
+		SiteID:    " site-1 ",
+		Contact:   " user@example.com ",
+		Message:   "  Great product  ",
+		Rating:    &rating,
+		NPSScore:  &npsScore,
+		Category:  "  Feature   request ",
+		PageURL:   " https://example.com/pricing?plan=pro ",
+		IP:        "127.0.0.1",
+		UserAgent: strings.Repeat("a", 500),
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, feedback.ID)
+	require.Equal(t, "site-1", feedback.SiteID)
+	require.Equal(t, "user@example.com", feedback.Contact)
+	require.Equal(t, "Great product", feedback.Message)
+	require.Equal(t, 4, *feedback.Rating)
+	require.Equal(t, 9, *feedback.NPSScore)
+	require.Equal(t, "Feature request", feedback.Category)
+	require.Equal(t, "https://example.com/pricing?plan=pro", feedback.PageURL)
+	require.Len(t, feedback.UserAgent, feedbackUserAgentMaxLength)
+	require.Equal(t, FeedbackDeliveryNone, feedback.Delivery)
+
+	rating = 1
+	require.Equal(t, 4, *feedback.Rating)
+}
+
+func TestNewFeedbackLeavesOptionalFieldsEmpty(t *testing.T) {
+	feedback, err := NewFeedback(FeedbackInput{SiteID: "site-1", Message: "Hello"})
+	require.NoError(t, err)
+	require.Nil(t, feedback.Rating)
+	require.Nil(t, feedback.NPSScore)
+	require.Empty(t, feedback.Category)
+	require.Empty(t, feedback.PageURL)
+}
+
+func TestNewFeedbackRejectsInvalidInput(t *testing.T) {
+	zero := 0
+	six := 6
+	negative := -1
+	eleven := 11
+	testCases := []struct {
+		name        string
+		input       FeedbackInput
+		expectedErr error
+	}{
+		{name: "missing site", input: FeedbackInput{Message: "Hi"}, expectedErr: ErrInvalidFeedbackSiteID},
+		{name: "missing message", input: FeedbackInput{SiteID: "site-1", Message: "   "}, expectedErr: ErrInvalidFeedbackMessage},
+		{name: "rating below range", input: FeedbackInput{SiteID: "site-1", Message: "Hi", Rating: &zero}, expectedErr: ErrInvalidFeedbackRating},
+		{name: "rating above range", input: FeedbackInput{SiteID: "site-1", Message: "Hi", Rating: &six}, expectedErr: ErrInvalidFeedbackRating},
+		{name: "nps below range", input: FeedbackInput{SiteID: "site-1", Message: "Hi", NPSScore: &negative}, expectedErr: ErrInvalidFeedbackNPSScore},
+		{name: "nps above range", input: FeedbackInput{SiteID: "site-1", Message: "Hi", NPSScore: &eleven}, expectedErr: ErrInvalidFeedbackNPSScore},
+		{name: "category too long", input: FeedbackInput{SiteID: "site-1", Message: "Hi", Category: strings.Repeat("c", 65)}, expectedErr: ErrInvalidFeedbackCategory},
+		{name: "relative page url", input: FeedbackInput{SiteID: "site-1", Message: "Hi", PageURL: "/pricing"}, expectedErr: ErrInvalidFeedbackPageURL},
+		{name: "non http page url", input: FeedbackInput{SiteID: "site-1", Message: "Hi", PageURL: "javascript:alert(1)"}, expectedErr: ErrInvalidFeedbackPageURL},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			_, err := NewFeedback(testCase.input)
+			require.ErrorIs(t, err, testCase.expectedErr)
+		})
+	}
+}
+
+func TestNPSSegment(t *testing.T) {
+	require.Equal(t, FeedbackNPSSegmentDetractor, NPSSegment(0))
+	require.Equal(t, FeedbackNPSSegmentDetractor, NPSSegment(6))
+	require.Equal(t, FeedbackNPSSegmentPassive, NPSSegment(7))
+	require.Equal(t, FeedbackNPSSegmentPassive, NPSSegment(8))
+	require.Equal(t, FeedbackNPSSegmentPromoter, NPSSegment(9))
+	require.Equal(t, FeedbackNPSSegmentPromoter, NPSSegment(10))
+}
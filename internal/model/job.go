@@ -0,0 +1,25 @@
+package model
+
+import "time"
+
+// JobStateErrorMaxLength bounds the stored error of a failed job run.
+const JobStateErrorMaxLength = 1000
+
+// JobState persists the schedule, lease, and last outcome of a background job so that every replica sees the same
+// history and only the replica holding an unexpired lease runs the job.
+type JobState struct {
+	Name             string `gorm:"primaryKey;size:64"`
+	Schedule         string `gorm:"size:128"`
+	Paused           bool   `gorm:"not null;default:false"`
+	TriggerRequested bool   `gorm:"not null;default:false"`
+	NextRunAt        time.Time
+	LastStartedAt    time.Time
+	LastFinishedAt   time.Time
+	LastDurationMs   int64
+	LastError        string `gorm:"size:1000"`
+	RunCount         int64  `gorm:"not null;default:0"`
+	FailureCount     int64  `gorm:"not null;default:0"`
+	LeaseOwner       string `gorm:"size:128"`
+	LeaseExpiresAt   time.Time
+	UpdatedAt        time.Time `gorm:"autoUpdateTime"`
+}
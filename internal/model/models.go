@@ -9,42 +9,64 @@ const (
 )
 
 type Site struct {
-	ID                         string `gorm:"primaryKey;size:36"`
-	Name                       string `gorm:"not null;size:200"`
-	AllowedOrigin              string `gorm:"not null;size:500"`
-	SubscribeAllowedOrigins    string `gorm:"size:500"`
-	WidgetAllowedOrigins       string `gorm:"size:500"`
-	TrafficAllowedOrigins      string `gorm:"size:500"`
-	OwnerEmail                 string `gorm:"size:320"`
-	CreatorEmail               string `gorm:"size:320"`
-	WidgetBubbleSide           string `gorm:"not null;size:16;default:right"`
-	WidgetBubbleBottomOffsetPx int    `gorm:"not null;default:16"`
-	FaviconData                []byte `gorm:"type:blob"`
-	FaviconContentType         string `gorm:"size:100"`
+	ID                         string       `gorm:"primaryKey;size:36"`
+	Name                       string       `gorm:"not null;size:200"`
+	AllowedOrigin              string       `gorm:"not null;size:500"`
+	SubscribeAllowedOrigins    string       `gorm:"size:500"`
+	WidgetAllowedOrigins       string       `gorm:"size:500"`
+	TrafficAllowedOrigins      string       `gorm:"size:500"`
+	OwnerEmail                 string       `gorm:"size:320"`
+	CreatorEmail               string       `gorm:"size:320"`
+	WidgetBubbleSide           string       `gorm:"not null;size:16;default:right"`
+	WidgetBubbleBottomOffsetPx int          `gorm:"not null;default:16"`
+	WidgetConfig               WidgetConfig `gorm:"serializer:json;type:text"`
+	FaviconData                []byte       `gorm:"type:blob"`
+	FaviconData16              []byte       `gorm:"column:favicon_data_16;type:blob"`
+	FaviconData32              []byte       `gorm:"column:favicon_data_32;type:blob"`
+	FaviconContentType         string       `gorm:"size:100"`
 	FaviconFetchedAt           time.Time
 	FaviconLastAttemptAt       time.Time
 	FaviconOrigin              string    `gorm:"size:500"`
+	FaviconSource              string    `gorm:"size:500"`
+	FaviconSourceReason        string    `gorm:"size:255"`
+	PageTitle                  string    `gorm:"size:300"`
+	PageDescription            string    `gorm:"size:1000"`
+	PageImageURL               string    `gorm:"size:500"`
+	PageCanonicalURL           string    `gorm:"size:500"`
 	CreatedAt                  time.Time `gorm:"autoCreateTime"`
 	UpdatedAt                  time.Time `gorm:"autoUpdateTime"`
 }
 
 type Feedback struct {
-	ID        string    `gorm:"primaryKey;size:36"`
-	SiteID    string    `gorm:"index;not null;size:36"`
-	Contact   string    `gorm:"not null;size:320"`
-	Message   string    `gorm:"not null;size:4000"`
-	IP        string    `gorm:"size:64"`
-	UserAgent string    `gorm:"size:400"`
-	Delivery  string    `gorm:"not null;size:16;default:no"`
-	CreatedAt time.Time `gorm:"autoCreateTime"`
+	ID         string `gorm:"primaryKey;size:36"`
+	SiteID     string `gorm:"index;not null;size:36"`
+	Contact    string `gorm:"not null;size:320"`
+	Message    string `gorm:"not null;size:4000"`
+	Rating     *int
+	NPSScore   *int      `gorm:"column:nps_score"`
+	Category   string    `gorm:"size:64"`
+	PageURL    string    `gorm:"size:500"`
+	ExtraField string    `gorm:"size:80"`
+	IP         string    `gorm:"size:64"`
+	UserAgent  string    `gorm:"size:400"`
+	Delivery   string    `gorm:"not null;size:16;default:no"`
+	CreatedAt  time.Time `gorm:"autoCreateTime;index"`
 }
 
 type User struct {
-	Email             string    `gorm:"primaryKey;size:320"`
-	Name              string    `gorm:"not null;size:320"`
-	PictureSourceURL  string    `gorm:"size:500"`
-	AvatarContentType string    `gorm:"size:100"`
-	AvatarData        []byte    `gorm:"type:blob"`
+	Email             string `gorm:"primaryKey;size:320"`
+	Name              string `gorm:"not null;size:320"`
+	PictureSourceURL  string `gorm:"size:500"`
+	AvatarContentType string `gorm:"size:100"`
+	AvatarData        []byte `gorm:"type:blob"`
+	DigestFrequency   string `gorm:"not null;size:16;default:off"`
+	DigestLastSentAt  time.Time
 	CreatedAt         time.Time `gorm:"autoCreateTime"`
 	UpdatedAt         time.Time `gorm:"autoUpdateTime"`
 }
+
+type StoredBlob struct {
+	BlobKey   string    `gorm:"primaryKey;size:128"`
+	Data      []byte    `gorm:"type:blob;not null"`
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
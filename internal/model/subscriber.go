@@ -14,6 +14,7 @@ const (
 	SubscriberStatusPending      = "pending"
 	SubscriberStatusConfirmed    = "confirmed"
 	SubscriberStatusUnsubscribed = "unsubscribed"
+	SubscriberStatusExpired      = "expired"
 
 	subscriberEmailMaxLength     = 320
 	subscriberNameMaxLength      = 200
@@ -32,19 +33,22 @@ var (
 
 // Subscriber captures newsletter/announcement subscriptions for a site.
 type Subscriber struct {
-	ID             string `gorm:"primaryKey;size:36"`
-	SiteID         string `gorm:"not null;size:36;uniqueIndex:idx_subscribers_site_email"`
-	Email          string `gorm:"not null;size:320;uniqueIndex:idx_subscribers_site_email"`
-	Name           string `gorm:"size:200"`
-	SourceURL      string `gorm:"size:500"`
-	IP             string `gorm:"size:64"`
-	UserAgent      string `gorm:"size:400"`
-	Status         string `gorm:"not null;size:16;index"`
-	ConsentAt      time.Time
-	ConfirmedAt    time.Time
-	UnsubscribedAt time.Time
-	CreatedAt      time.Time `gorm:"autoCreateTime"`
-	UpdatedAt      time.Time `gorm:"autoUpdateTime"`
+	ID                 string `gorm:"primaryKey;size:36"`
+	SiteID             string `gorm:"not null;size:36;uniqueIndex:idx_subscribers_site_email"`
+	Email              string `gorm:"not null;size:320;uniqueIndex:idx_subscribers_site_email"`
+	Name               string `gorm:"size:200"`
+	SourceURL          string `gorm:"size:500"`
+	IP                 string `gorm:"size:64"`
+	UserAgent          string `gorm:"size:400"`
+	Status             string `gorm:"not null;size:16;index"`
+	Locale             string `gorm:"size:16"`
+	ConsentAt          time.Time
+	ConfirmedAt        time.Time
+	UnsubscribedAt     time.Time
+	ConfirmationSentAt time.Time
+	ReminderSentAt     time.Time
+	CreatedAt          time.Time `gorm:"autoCreateTime"`
+	UpdatedAt          time.Time `gorm:"autoUpdateTime"`
 }
 
 // SubscriberInput holds the raw values used to construct a Subscriber.
@@ -56,6 +60,7 @@ type SubscriberInput struct {
 	IP             string
 	UserAgent      string
 	Status         string
+	Locale         string
 	ConsentAt      time.Time
 	ConfirmedAt    time.Time
 	UnsubscribedAt time.Time
@@ -101,6 +106,11 @@ func NewSubscriber(input SubscriberInput) (Subscriber, error) {
 		return Subscriber{}, fmt.Errorf("%w: user_agent too long", ErrInvalidSubscriberContact)
 	}
 
+	locale, localeErr := NormalizeLocale(input.Locale)
+	if localeErr != nil {
+		return Subscriber{}, localeErr
+	}
+
 	return Subscriber{
 		ID:             uuid.NewString(),
 		SiteID:         siteID,
@@ -110,6 +120,7 @@ func NewSubscriber(input SubscriberInput) (Subscriber, error) {
 		IP:             ip,
 		UserAgent:      userAgent,
 		Status:         status,
+		Locale:         locale,
 		ConsentAt:      input.ConsentAt,
 		ConfirmedAt:    input.ConfirmedAt,
 		UnsubscribedAt: input.UnsubscribedAt,
@@ -132,7 +143,7 @@ func validateSubscriberStatus(status string) error {
 		return fmt.Errorf("%w: too long", ErrInvalidSubscriberStatus)
 	}
 	switch status {
-	case SubscriberStatusPending, SubscriberStatusConfirmed, SubscriberStatusUnsubscribed:
+	case SubscriberStatusPending, SubscriberStatusConfirmed, SubscriberStatusUnsubscribed, SubscriberStatusExpired:
 		return nil
 	default:
 		return fmt.Errorf("%w: %s", ErrInvalidSubscriberStatus, status)
@@ -0,0 +1,90 @@
+package model
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	SubscriberImportStatusQueued    = "queued"
+	SubscriberImportStatusRunning   = "running"
+	SubscriberImportStatusCompleted = "completed"
+	SubscriberImportStatusFailed    = "failed"
+)
+
+var (
+	ErrInvalidSubscriberImportSiteID = errors.New("invalid_subscriber_import_site_id")
+	ErrInvalidSubscriberImportMode   = errors.New("invalid_subscriber_import_mode")
+	ErrEmptySubscriberImport         = errors.New("empty_subscriber_import")
+)
+
+// SubscriberImportRow is one parsed CSV row waiting to be imported.
+type SubscriberImportRow struct {
+	Line           int       `json:"line"`
+	Email          string    `json:"email"`
+	Name           string    `json:"name,omitempty"`
+	Status         string    `json:"status,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+	ConfirmedAt    time.Time `json:"confirmed_at"`
+	UnsubscribedAt time.Time `json:"unsubscribed_at"`
+	ParseError     string    `json:"parse_error,omitempty"`
+}
+
+// SubscriberImportRowError describes a CSV row that could not be imported.
+type SubscriberImportRowError struct {
+	Row   int    `json:"row"`
+	Email string `json:"email"`
+	Error string `json:"error"`
+}
+
+// SubscriberImport is a bulk subscriber CSV import. Rows holds the parsed file and ProcessedRows is the cursor an
+// interrupted import resumes from.
+type SubscriberImport struct {
+	ID            string                     `gorm:"primaryKey;size:36"`
+	SiteID        string                     `gorm:"not null;size:36;index"`
+	Mode          string                     `gorm:"not null;size:16"`
+	Status        string                     `gorm:"not null;size:16;index"`
+	TotalRows     int                        `gorm:"not null;default:0"`
+	ProcessedRows int                        `gorm:"not null;default:0"`
+	ImportedCount int                        `gorm:"not null;default:0"`
+	SkippedCount  int                        `gorm:"not null;default:0"`
+	Rows          []SubscriberImportRow      `gorm:"serializer:json;type:text"`
+	Errors        []SubscriberImportRowError `gorm:"serializer:json;type:text"`
+	StartedAt     time.Time
+	CompletedAt   time.Time
+	CreatedAt     time.Time `gorm:"autoCreateTime"`
+	UpdatedAt     time.Time `gorm:"autoUpdateTime"`
+}
+
+// NewSubscriberImport validates the import parameters and returns an import in the given initial status.
+func NewSubscriberImport(siteID string, mode string, status string, rows []SubscriberImportRow, startedAt time.Time) (SubscriberImport, error) {
+	normalizedSiteID := strings.TrimSpace(siteID)
+	if normalizedSiteID == "" {
+		return SubscriberImport{}, ErrInvalidSubscriberImportSiteID
+	}
+	normalizedMode := strings.TrimSpace(mode)
+	if normalizedMode == "" {
+		return SubscriberImport{}, ErrInvalidSubscriberImportMode
+	}
+	if len(rows) == 0 {
+		return SubscriberImport{}, ErrEmptySubscriberImport
+	}
+	return SubscriberImport{
+		ID:        uuid.NewString(),
+		SiteID:    normalizedSiteID,
+		Mode:      normalizedMode,
+		Status:    status,
+		TotalRows: len(rows),
+		Rows:      rows,
+		Errors:    []SubscriberImportRowError{},
+		StartedAt: startedAt.UTC(),
+	}, nil
+}
+
+// Finished reports whether the import has reached a terminal status.
+func (subscriberImport SubscriberImport) Finished() bool {
+	return subscriberImport.Status == SubscriberImportStatusCompleted || subscriberImport.Status == SubscriberImportStatusFailed
+}
@@ -0,0 +1,39 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewSubscriberImportTracksRows(t *testing.T) {
+	startedAt := time.Date(2026, time.March, 4, 10, 0, 0, 0, time.FixedZone("EST", -5*60*60))
+	rows := []SubscriberImportRow{{Line: 1, Email: "alpha@example.com"}, {Line: 2, Email: "beta@example.com"}}
+
+	subscriberImport, err := NewSubscriberImport(" site-1 ", "consented", SubscriberImportStatusQueued, rows, startedAt)
+	require.NoError(t, err)
+	require.NotEmpty(t, subscriberImport.ID)
+	require.Equal(t, "site-1", subscriberImport.SiteID)
+	require.Equal(t, 2, subscriberImport.TotalRows)
+	require.Equal(t, 0, subscriberImport.ProcessedRows)
+	require.NotNil(t, subscriberImport.Errors)
+	require.Equal(t, startedAt.UTC(), subscriberImport.StartedAt)
+	require.False(t, subscriberImport.Finished())
+
+	subscriberImport.Status = SubscriberImportStatusFailed
+	require.True(t, subscriberImport.Finished())
+}
+
+func TestNewSubscriberImportRejectsInvalidInput(t *testing.T) {
+	rows := []SubscriberImportRow{{Line: 1, Email: "alpha@example.com"}}
+
+	_, err := NewSubscriberImport(" ", "consented", SubscriberImportStatusQueued, rows, time.Now())
+	require.ErrorIs(t, err, ErrInvalidSubscriberImportSiteID)
+
+	_, err = NewSubscriberImport("site-1", "", SubscriberImportStatusQueued, rows, time.Now())
+	require.ErrorIs(t, err, ErrInvalidSubscriberImportMode)
+
+	_, err = NewSubscriberImport("site-1", "consented", SubscriberImportStatusQueued, nil, time.Now())
+	require.ErrorIs(t, err, ErrEmptySubscriberImport)
+}
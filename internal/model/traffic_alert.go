@@ -0,0 +1,219 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// TrafficAlertKindThreshold compares page views in the window against a fixed number.
+	TrafficAlertKindThreshold = "threshold"
+	// TrafficAlertKindDeviation compares page views in the window against the trailing daily average from rollups.
+	TrafficAlertKindDeviation = "deviation"
+
+	TrafficAlertDirectionBelow = "below"
+	TrafficAlertDirectionAbove = "above"
+
+	TrafficAlertDeliverySent    = "sent"
+	TrafficAlertDeliveryFailed  = "failed"
+	TrafficAlertDeliverySkipped = "skipped"
+
+	DefaultTrafficAlertWindowHours  = 24
+	DefaultTrafficAlertBaselineDays = 7
+
+	trafficAlertMaxWindowHours  = 168
+	trafficAlertMaxBaselineDays = 30
+	trafficAlertWebhookMaxLen   = 2048
+	trafficAlertMessageMaxLen   = 500
+	trafficAlertErrorMaxLen     = 500
+)
+
+var (
+	ErrInvalidTrafficAlertSiteID     = errors.New("invalid_traffic_alert_site_id")
+	ErrInvalidTrafficAlertKind       = errors.New("invalid_traffic_alert_kind")
+	ErrInvalidTrafficAlertDirection  = errors.New("invalid_traffic_alert_direction")
+	ErrInvalidTrafficAlertThreshold  = errors.New("invalid_traffic_alert_threshold")
+	ErrInvalidTrafficAlertWindow     = errors.New("invalid_traffic_alert_window")
+	ErrInvalidTrafficAlertBaseline   = errors.New("invalid_traffic_alert_baseline")
+	ErrInvalidTrafficAlertWebhookURL = errors.New("invalid_traffic_alert_webhook_url")
+)
+
+// TrafficAlertRule describes a per-site condition on recent page views that should alert the owner.
+// Firing is set while the condition holds so that one incident produces one alert.
+type TrafficAlertRule struct {
+	ID              string  `gorm:"primaryKey;size:36"`
+	SiteID          string  `gorm:"not null;size:36;index"`
+	Kind            string  `gorm:"not null;size:16"`
+	Direction       string  `gorm:"not null;size:8"`
+	Threshold       float64 `gorm:"not null"`
+	WindowHours     int     `gorm:"not null"`
+	BaselineDays    int     `gorm:"not null"`
+	IncludeBots     bool    `gorm:"not null;default:false"`
+	WebhookURL      string  `gorm:"size:2048"`
+	Enabled         bool    `gorm:"not null;index"`
+	Firing          bool    `gorm:"not null;default:false"`
+	SnoozedUntil    time.Time
+	LastTriggeredAt time.Time
+	LastEvaluatedAt time.Time
+	CreatedByEmail  string    `gorm:"size:320"`
+	CreatedAt       time.Time `gorm:"autoCreateTime"`
+	UpdatedAt       time.Time `gorm:"autoUpdateTime"`
+}
+
+// TrafficAlertRuleInput holds the raw values used to construct a TrafficAlertRule.
+type TrafficAlertRuleInput struct {
+	SiteID         string
+	Kind           string
+	Direction      string
+	Threshold      float64
+	WindowHours    int
+	BaselineDays   int
+	IncludeBots    bool
+	WebhookURL     string
+	CreatedByEmail string
+}
+
+// TrafficAlert records one triggered rule and how it was delivered.
+type TrafficAlert struct {
+	ID            string    `gorm:"primaryKey;size:36"`
+	RuleID        string    `gorm:"not null;size:36;index"`
+	SiteID        string    `gorm:"not null;size:36;index:idx_traffic_alerts_site_created"`
+	Kind          string    `gorm:"not null;size:16"`
+	Direction     string    `gorm:"not null;size:8"`
+	ObservedValue float64   `gorm:"not null"`
+	ExpectedValue float64   `gorm:"not null"`
+	WindowStart   time.Time `gorm:"not null"`
+	WindowEnd     time.Time `gorm:"not null"`
+	Message       string    `gorm:"size:500"`
+	NotifyStatus  string    `gorm:"not null;size:16"`
+	NotifyError   string    `gorm:"size:500"`
+	WebhookStatus string    `gorm:"not null;size:16"`
+	WebhookError  string    `gorm:"size:500"`
+	CreatedAt     time.Time `gorm:"autoCreateTime;index:idx_traffic_alerts_site_created"`
+}
+
+// NewTrafficAlertRule constructs an enabled rule with validated, normalized settings.
+func NewTrafficAlertRule(input TrafficAlertRuleInput) (TrafficAlertRule, error) {
+	siteID := strings.TrimSpace(input.SiteID)
+	if siteID == "" {
+		return TrafficAlertRule{}, ErrInvalidTrafficAlertSiteID
+	}
+	rule := TrafficAlertRule{
+		ID:             uuid.NewString(),
+		SiteID:         siteID,
+		Kind:           input.Kind,
+		Direction:      input.Direction,
+		Threshold:      input.Threshold,
+		WindowHours:    input.WindowHours,
+		BaselineDays:   input.BaselineDays,
+		IncludeBots:    input.IncludeBots,
+		WebhookURL:     input.WebhookURL,
+		Enabled:        true,
+		CreatedByEmail: strings.ToLower(strings.TrimSpace(input.CreatedByEmail)),
+	}
+	if err := rule.Normalize(); err != nil {
+		return TrafficAlertRule{}, err
+	}
+	return rule, nil
+}
+
+// Normalize validates the rule settings in place and fills in default window and baseline lengths.
+func (rule *TrafficAlertRule) Normalize() error {
+	rule.Kind = strings.ToLower(strings.TrimSpace(rule.Kind))
+	switch rule.Kind {
+	case TrafficAlertKindThreshold, TrafficAlertKindDeviation:
+	default:
+		return fmt.Errorf("%w: %s", ErrInvalidTrafficAlertKind, rule.Kind)
+	}
+
+	rule.Direction = strings.ToLower(strings.TrimSpace(rule.Direction))
+	switch rule.Direction {
+	case TrafficAlertDirectionBelow, TrafficAlertDirectionAbove:
+	default:
+		return fmt.Errorf("%w: %s", ErrInvalidTrafficAlertDirection, rule.Direction)
+	}
+
+	if rule.Threshold < 0 {
+		return fmt.Errorf("%w: negative", ErrInvalidTrafficAlertThreshold)
+	}
+	if rule.Kind == TrafficAlertKindDeviation && (rule.Threshold <= 0 || (rule.Direction == TrafficAlertDirectionBelow && rule.Threshold > 100)) {
+		return fmt.Errorf("%w: deviation percent out of range", ErrInvalidTrafficAlertThreshold)
+	}
+	if rule.Kind == TrafficAlertKindThreshold && rule.Direction == TrafficAlertDirectionBelow && rule.Threshold == 0 {
+		return fmt.Errorf("%w: nothing is below zero", ErrInvalidTrafficAlertThreshold)
+	}
+
+	if rule.WindowHours == 0 {
+		rule.WindowHours = DefaultTrafficAlertWindowHours
+	}
+	if rule.WindowHours < 1 || rule.WindowHours > trafficAlertMaxWindowHours {
+		return fmt.Errorf("%w: %d hours", ErrInvalidTrafficAlertWindow, rule.WindowHours)
+	}
+	if rule.BaselineDays == 0 {
+		rule.BaselineDays = DefaultTrafficAlertBaselineDays
+	}
+	if rule.BaselineDays < 1 || rule.BaselineDays > trafficAlertMaxBaselineDays {
+		return fmt.Errorf("%w: %d days", ErrInvalidTrafficAlertBaseline, rule.BaselineDays)
+	}
+
+	webhookURL, webhookErr := normalizeTrafficAlertWebhookURL(rule.WebhookURL)
+	if webhookErr != nil {
+		return webhookErr
+	}
+	rule.WebhookURL = webhookURL
+	return nil
+}
+
+// IsSnoozed reports whether notifications for the rule are suppressed at now.
+func (rule TrafficAlertRule) IsSnoozed(now time.Time) bool {
+	return !rule.SnoozedUntil.IsZero() && now.Before(rule.SnoozedUntil)
+}
+
+// NewTrafficAlert constructs a history entry for a triggered rule.
+func NewTrafficAlert(rule TrafficAlertRule, observedValue float64, expectedValue float64, windowStart time.Time, windowEnd time.Time, message string) TrafficAlert {
+	return TrafficAlert{
+		ID:            uuid.NewString(),
+		RuleID:        rule.ID,
+		SiteID:        rule.SiteID,
+		Kind:          rule.Kind,
+		Direction:     rule.Direction,
+		ObservedValue: observedValue,
+		ExpectedValue: expectedValue,
+		WindowStart:   windowStart.UTC(),
+		WindowEnd:     windowEnd.UTC(),
+		Message:       truncateString(strings.TrimSpace(message), trafficAlertMessageMaxLen),
+		NotifyStatus:  TrafficAlertDeliverySkipped,
+		WebhookStatus: TrafficAlertDeliverySkipped,
+	}
+}
+
+// TrafficAlertDeliveryOutcome converts a delivery error into a status and a bounded error message.
+func TrafficAlertDeliveryOutcome(deliveryErr error) (string, string) {
+	if deliveryErr == nil {
+		return TrafficAlertDeliverySent, ""
+	}
+	return TrafficAlertDeliveryFailed, truncateString(deliveryErr.Error(), trafficAlertErrorMaxLen)
+}
+
+func normalizeTrafficAlertWebhookURL(raw string) (string, error) {
+	trimmed := strings.TrimSpace(raw)
+	if trimmed == "" {
+		return "", nil
+	}
+	if len(trimmed) > trafficAlertWebhookMaxLen {
+		return "", fmt.Errorf("%w: too long", ErrInvalidTrafficAlertWebhookURL)
+	}
+	parsed, parseErr := url.Parse(trimmed)
+	if parseErr != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidTrafficAlertWebhookURL, parseErr)
+	}
+	if (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" || parsed.User != nil {
+		return "", fmt.Errorf("%w: must be an absolute http(s) URL without credentials", ErrInvalidTrafficAlertWebhookURL)
+	}
+	return parsed.String(), nil
+}
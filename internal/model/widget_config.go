@@ -0,0 +1,330 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	WidgetThemeAuto  = "auto"
+	WidgetThemeLight = "light"
+	WidgetThemeDark  = "dark"
+
+	WidgetContactRequired = "required"
+	WidgetContactOptional = "optional"
+
+	WidgetExtraFieldSelect = "select"
+	WidgetExtraFieldRating = "rating"
+
+	WidgetRatingMin = 1
+	WidgetRatingMax = 5
+
+	widgetCopyTextMaxLength       = 200
+	widgetSelectOptionMaxLength   = 80
+	widgetSelectMinOptions        = 2
+	widgetSelectMaxOptions        = 12
+	widgetConfigMaxLocales        = 24
+	widgetExtraFieldValueMaxBytes = widgetSelectOptionMaxLength
+
+	defaultWidgetHeaderText                 = "Send feedback"
+	defaultWidgetThankYouText               = "Thanks! Sent."
+	defaultWidgetContactPlaceholder         = "Email or phone"
+	defaultWidgetOptionalContactPlaceholder = "Email or phone (optional)"
+	defaultWidgetMessagePlaceholder         = "Your message"
+	defaultWidgetSubmitLabel                = "Send"
+	defaultWidgetRatingLabel                = "How would you rate us?"
+	defaultWidgetSelectLabel                = "Choose one"
+	defaultWidgetAttachmentLabel            = "Attach a screenshot"
+)
+
+var (
+	ErrInvalidWidgetConfig     = errors.New("invalid_widget_config")
+	ErrInvalidWidgetExtraValue = errors.New("invalid_widget_extra_field")
+
+	widgetBrandColorExpression = regexp.MustCompile(`^#([0-9a-f]{3}|[0-9a-f]{6})$`)
+)
+
+// WidgetConfigError reports the widget configuration field that failed validation.
+type WidgetConfigError struct {
+	Field  string
+	Reason string
+}
+
+func (configError *WidgetConfigError) Error() string {
+	return fmt.Sprintf("%s: %s: %s", ErrInvalidWidgetConfig, configError.Field, configError.Reason)
+}
+
+func (configError *WidgetConfigError) Unwrap() error {
+	return ErrInvalidWidgetConfig
+}
+
+// WidgetCopy holds the user-facing text rendered by the feedback widget.
+type WidgetCopy struct {
+	HeaderText         string `json:"header_text,omitempty"`
+	ThankYouText       string `json:"thank_you_text,omitempty"`
+	ContactPlaceholder string `json:"contact_placeholder,omitempty"`
+	MessagePlaceholder string `json:"message_placeholder,omitempty"`
+	SubmitLabel        string `json:"submit_label,omitempty"`
+	ExtraFieldLabel    string `json:"extra_field_label,omitempty"`
+	AttachmentLabel    string `json:"attachment_label,omitempty"`
+}
+
+// WidgetExtraField describes an optional select or rating input shown above the submit button.
+type WidgetExtraField struct {
+	Type     string   `json:"type"`
+	Required bool     `json:"required"`
+	Options  []string `json:"options,omitempty"`
+}
+
+// WidgetConfig captures the appearance, copy and fields of a site's feedback widget.
+type WidgetConfig struct {
+	BrandColor       string                `json:"brand_color,omitempty"`
+	Theme            string                `json:"theme,omitempty"`
+	ContactMode      string                `json:"contact_mode,omitempty"`
+	ExtraField       *WidgetExtraField     `json:"extra_field,omitempty"`
+	AllowAttachments bool                  `json:"allow_attachments,omitempty"`
+	Copy             WidgetCopy            `json:"copy"`
+	Locales          map[string]WidgetCopy `json:"locales,omitempty"`
+}
+
+// NewWidgetConfig validates and normalizes a widget configuration.
+func NewWidgetConfig(input WidgetConfig) (WidgetConfig, error) {
+	brandColor := strings.ToLower(strings.TrimSpace(input.BrandColor))
+	if brandColor != "" {
+		if !widgetBrandColorExpression.MatchString(brandColor) {
+			return WidgetConfig{}, &WidgetConfigError{Field: "brand_color", Reason: "expected #rgb or #rrggbb"}
+		}
+		if len(brandColor) == 4 {
+			brandColor = "#" + strings.Repeat(brandColor[1:2], 2) + strings.Repeat(brandColor[2:3], 2) + strings.Repeat(brandColor[3:4], 2)
+		}
+	}
+
+	theme := strings.ToLower(strings.TrimSpace(input.Theme))
+	switch theme {
+	case "":
+		theme = WidgetThemeAuto
+	case WidgetThemeAuto, WidgetThemeLight, WidgetThemeDark:
+	default:
+		return WidgetConfig{}, &WidgetConfigError{Field: "theme", Reason: "expected auto, light or dark"}
+	}
+
+	contactMode := strings.ToLower(strings.TrimSpace(input.ContactMode))
+	switch contactMode {
+	case "":
+		contactMode = WidgetContactRequired
+	case WidgetContactRequired, WidgetContactOptional:
+	default:
+		return WidgetConfig{}, &WidgetConfigError{Field: "contact_mode", Reason: "expected required or optional"}
+	}
+
+	extraField, extraFieldErr := normalizeWidgetExtraField(input.ExtraField)
+	if extraFieldErr != nil {
+		return WidgetConfig{}, extraFieldErr
+	}
+
+	copyText, copyErr := normalizeWidgetCopy("copy", input.Copy)
+	if copyErr != nil {
+		return WidgetConfig{}, copyErr
+	}
+
+	if len(input.Locales) > widgetConfigMaxLocales {
+		return WidgetConfig{}, &WidgetConfigError{Field: "locales", Reason: fmt.Sprintf("at most %d locales", widgetConfigMaxLocales)}
+	}
+	var locales map[string]WidgetCopy
+	for rawLocale, localeCopy := range input.Locales {
+		locale, localeErr := NormalizeLocale(rawLocale)
+		if localeErr != nil || locale == "" {
+			return WidgetConfig{}, &WidgetConfigError{Field: "locales", Reason: fmt.Sprintf("invalid locale %q", rawLocale)}
+		}
+		normalizedCopy, localeCopyErr := normalizeWidgetCopy("locales."+locale, localeCopy)
+		if localeCopyErr != nil {
+			return WidgetConfig{}, localeCopyErr
+		}
+		if locales == nil {
+			locales = make(map[string]WidgetCopy)
+		}
+		locales[locale] = normalizedCopy
+	}
+
+	return WidgetConfig{
+		BrandColor:       brandColor,
+		Theme:            theme,
+		ContactMode:      contactMode,
+		ExtraField:       extraField,
+		AllowAttachments: input.AllowAttachments,
+		Copy:             copyText,
+		Locales:          locales,
+	}, nil
+}
+
+// ResolvedTheme returns the configured theme, defaulting to automatic detection.
+func (config WidgetConfig) ResolvedTheme() string {
+	if config.Theme == WidgetThemeLight || config.Theme == WidgetThemeDark {
+		return config.Theme
+	}
+	return WidgetThemeAuto
+}
+
+// ContactRequired reports whether feedback submissions must include contact details.
+func (config WidgetConfig) ContactRequired() bool {
+	return config.ContactMode != WidgetContactOptional
+}
+
+// ResolvedContactMode returns the configured contact mode, defaulting to required.
+func (config WidgetConfig) ResolvedContactMode() string {
+	if config.ContactRequired() {
+		return WidgetContactRequired
+	}
+	return WidgetContactOptional
+}
+
+// ResolveCopy returns the widget copy for locale, falling back to less specific locales and then built-in defaults.
+func (config WidgetConfig) ResolveCopy(locale string) WidgetCopy {
+	resolved := WidgetCopy{
+		HeaderText:         defaultWidgetHeaderText,
+		ThankYouText:       defaultWidgetThankYouText,
+		ContactPlaceholder: defaultWidgetContactPlaceholder,
+		MessagePlaceholder: defaultWidgetMessagePlaceholder,
+		SubmitLabel:        defaultWidgetSubmitLabel,
+	}
+	if !config.ContactRequired() {
+		resolved.ContactPlaceholder = defaultWidgetOptionalContactPlaceholder
+	}
+	if config.ExtraField != nil {
+		resolved.ExtraFieldLabel = defaultWidgetSelectLabel
+		if config.ExtraField.Type == WidgetExtraFieldRating {
+			resolved.ExtraFieldLabel = defaultWidgetRatingLabel
+		}
+	}
+	if config.AllowAttachments {
+		resolved.AttachmentLabel = defaultWidgetAttachmentLabel
+	}
+
+	resolved = overlayWidgetCopy(resolved, config.Copy)
+	fallbacks := LocaleFallbacks(locale)
+	for index := len(fallbacks) - 1; index >= 0; index-- {
+		if localeCopy, ok := config.Locales[fallbacks[index]]; ok && fallbacks[index] != "" {
+			resolved = overlayWidgetCopy(resolved, localeCopy)
+		}
+	}
+	return resolved
+}
+
+// NormalizeExtraFieldValue validates a submitted extra field answer against the configuration.
+func (config WidgetConfig) NormalizeExtraFieldValue(rawValue string) (string, error) {
+	value := strings.TrimSpace(rawValue)
+	if config.ExtraField == nil {
+		return "", nil
+	}
+	if value == "" {
+		if config.ExtraField.Required {
+			return "", fmt.Errorf("%w: required", ErrInvalidWidgetExtraValue)
+		}
+		return "", nil
+	}
+	if len(value) > widgetExtraFieldValueMaxBytes {
+		return "", fmt.Errorf("%w: too long", ErrInvalidWidgetExtraValue)
+	}
+
+	switch config.ExtraField.Type {
+	case WidgetExtraFieldRating:
+		rating, parseErr := strconv.Atoi(value)
+		if parseErr != nil || rating < WidgetRatingMin || rating > WidgetRatingMax {
+			return "", fmt.Errorf("%w: rating out of range", ErrInvalidWidgetExtraValue)
+		}
+		return strconv.Itoa(rating), nil
+	case WidgetExtraFieldSelect:
+		for _, option := range config.ExtraField.Options {
+			if option == value {
+				return option, nil
+			}
+		}
+		return "", fmt.Errorf("%w: unknown option", ErrInvalidWidgetExtraValue)
+	default:
+		return "", nil
+	}
+}
+
+func normalizeWidgetExtraField(input *WidgetExtraField) (*WidgetExtraField, error) {
+	if input == nil {
+		return nil, nil
+	}
+	fieldType := strings.ToLower(strings.TrimSpace(input.Type))
+	switch fieldType {
+	case WidgetExtraFieldRating:
+		if len(input.Options) > 0 {
+			return nil, &WidgetConfigError{Field: "extra_field.options", Reason: "rating fields do not take options"}
+		}
+		return &WidgetExtraField{Type: fieldType, Required: input.Required}, nil
+	case WidgetExtraFieldSelect:
+		options := make([]string, 0, len(input.Options))
+		seenOptions := make(map[string]struct{}, len(input.Options))
+		for _, rawOption := range input.Options {
+			option := strings.TrimSpace(rawOption)
+			if option == "" || len(option) > widgetSelectOptionMaxLength {
+				return nil, &WidgetConfigError{Field: "extra_field.options", Reason: fmt.Sprintf("options must be 1-%d characters", widgetSelectOptionMaxLength)}
+			}
+			if _, duplicate := seenOptions[option]; duplicate {
+				return nil, &WidgetConfigError{Field: "extra_field.options", Reason: fmt.Sprintf("duplicate option %q", option)}
+			}
+			seenOptions[option] = struct{}{}
+			options = append(options, option)
+		}
+		if len(options) < widgetSelectMinOptions || len(options) > widgetSelectMaxOptions {
+			return nil, &WidgetConfigError{Field: "extra_field.options", Reason: fmt.Sprintf("select fields need %d-%d options", widgetSelectMinOptions, widgetSelectMaxOptions)}
+		}
+		return &WidgetExtraField{Type: fieldType, Required: input.Required, Options: options}, nil
+	default:
+		return nil, &WidgetConfigError{Field: "extra_field.type", Reason: "expected select or rating"}
+	}
+}
+
+func normalizeWidgetCopy(fieldPrefix string, input WidgetCopy) (WidgetCopy, error) {
+	fields := []struct {
+		name  string
+		value *string
+	}{
+		{"header_text", &input.HeaderText},
+		{"thank_you_text", &input.ThankYouText},
+		{"contact_placeholder", &input.ContactPlaceholder},
+		{"message_placeholder", &input.MessagePlaceholder},
+		{"submit_label", &input.SubmitLabel},
+		{"extra_field_label", &input.ExtraFieldLabel},
+		{"attachment_label", &input.AttachmentLabel},
+	}
+	for _, field := range fields {
+		*field.value = strings.TrimSpace(*field.value)
+		if len(*field.value) > widgetCopyTextMaxLength {
+			return WidgetCopy{}, &WidgetConfigError{Field: fieldPrefix + "." + field.name, Reason: fmt.Sprintf("at most %d characters", widgetCopyTextMaxLength)}
+		}
+	}
+	return input, nil
+}
+
+func overlayWidgetCopy(base WidgetCopy, overlay WidgetCopy) WidgetCopy {
+	if overlay.HeaderText != "" {
+		base.HeaderText = overlay.HeaderText
+	}
+	if overlay.ThankYouText != "" {
+		base.ThankYouText = overlay.ThankYouText
+	}
+	if overlay.ContactPlaceholder != "" {
+		base.ContactPlaceholder = overlay.ContactPlaceholder
+	}
+	if overlay.MessagePlaceholder != "" {
+		base.MessagePlaceholder = overlay.MessagePlaceholder
+	}
+	if overlay.SubmitLabel != "" {
+		base.SubmitLabel = overlay.SubmitLabel
+	}
+	if overlay.ExtraFieldLabel != "" {
+		base.ExtraFieldLabel = overlay.ExtraFieldLabel
+	}
+	if overlay.AttachmentLabel != "" {
+		base.AttachmentLabel = overlay.AttachmentLabel
+	}
+	return base
+}
@@ -0,0 +1,108 @@
+package model
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewWidgetConfigNormalizesValues(t *testing.T) {
+	config, err := NewWidgetConfig(WidgetConfig{
+		BrandColor:  " #0AF ",
+		Theme:       "Dark",
+		ContactMode: "OPTIONAL",
+		ExtraField:  &WidgetExtraField{Type: "Select", Required: true, Options: []string{" Bug ", "Idea"}},
+		Copy:        WidgetCopy{HeaderText: "  Tell us  "},
+		Locales:     map[string]WidgetCopy{"PT_BR": {HeaderText: "Fale conosco"}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "#00aaff", config.BrandColor)
+	require.Equal(t, WidgetThemeDark, config.Theme)
+	require.Equal(t, WidgetContactOptional, config.ContactMode)
+	require.Equal(t, []string{"Bug", "Idea"}, config.ExtraField.Options)
+	require.Equal(t, "Tell us", config.Copy.HeaderText)
+	require.Contains(t, config.Locales, "pt-br")
+
+	defaults, defaultsErr := NewWidgetConfig(WidgetConfig{})
+	require.NoError(t, defaultsErr)
+	require.Equal(t, WidgetThemeAuto, defaults.Theme)
+	require.Equal(t, WidgetContactRequired, defaults.ContactMode)
+	require.Nil(t, defaults.ExtraField)
+}
+
+func TestNewWidgetConfigRejectsInvalidValues(t *testing.T) {
+	testCases := []struct {
+		name          string
+		input         WidgetConfig
+		expectedField string
+	}{
+		{name: "brand color", input: WidgetConfig{BrandColor: "blue"}, expectedField: "brand_color"},
+		{name: "theme", input: WidgetConfig{Theme: "sepia"}, expectedField: "theme"},
+		{name: "contact mode", input: WidgetConfig{ContactMode: "hidden"}, expectedField: "contact_mode"},
+		{name: "extra field type", input: WidgetConfig{ExtraField: &WidgetExtraField{Type: "checkbox"}}, expectedField: "extra_field.type"},
+		{name: "select needs options", input: WidgetConfig{ExtraField: &WidgetExtraField{Type: WidgetExtraFieldSelect, Options: []string{"Only"}}}, expectedField: "extra_field.options"},
+		{name: "duplicate options", input: WidgetConfig{ExtraField: &WidgetExtraField{Type: WidgetExtraFieldSelect, Options: []string{"A", "A"}}}, expectedField: "extra_field.options"},
+		{name: "rating with options", input: WidgetConfig{ExtraField: &WidgetExtraField{Type: WidgetExtraFieldRating, Options: []string{"A", "B"}}}, expectedField: "extra_field.options"},
+		{name: "copy too long", input: WidgetConfig{Copy: WidgetCopy{SubmitLabel: string(make([]byte, 201))}}, expectedField: "copy.submit_label"},
+		{name: "locale", input: WidgetConfig{Locales: map[string]WidgetCopy{"not a locale": {}}}, expectedField: "locales"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			_, err := NewWidgetConfig(testCase.input)
+			require.ErrorIs(t, err, ErrInvalidWidgetConfig)
+			var configError *WidgetConfigError
+			require.True(t, errors.As(err, &configError))
+			require.Equal(t, testCase.expectedField, configError.Field)
+		})
+	}
+}
+
+func TestWidgetConfigResolveCopyUsesLocaleFallbacks(t *testing.T) {
+	config, err := NewWidgetConfig(WidgetConfig{
+		ContactMode: WidgetContactOptional,
+		ExtraField:  &WidgetExtraField{Type: WidgetExtraFieldRating},
+		Copy:        WidgetCopy{HeaderText: "Talk to us"},
+		Locales: map[string]WidgetCopy{
+			"pt":    {HeaderText: "Fale conosco", SubmitLabel: "Enviar"},
+			"pt-br": {SubmitLabel: "Mandar"},
+		},
+	})
+	require.NoError(t, err)
+
+	brazilian := config.ResolveCopy("pt-BR")
+	require.Equal(t, "Fale conosco", brazilian.HeaderText)
+	require.Equal(t, "Mandar", brazilian.SubmitLabel)
+	require.Equal(t, defaultWidgetOptionalContactPlaceholder, brazilian.ContactPlaceholder)
+	require.Equal(t, defaultWidgetRatingLabel, brazilian.ExtraFieldLabel)
+
+	english := config.ResolveCopy("en-US")
+	require.Equal(t, "Talk to us", english.HeaderText)
+	require.Equal(t, defaultWidgetSubmitLabel, english.SubmitLabel)
+}
+
+func TestWidgetConfigNormalizeExtraFieldValue(t *testing.T) {
+	rating := WidgetConfig{ExtraField: &WidgetExtraField{Type: WidgetExtraFieldRating, Required: true}}
+	value, err := rating.NormalizeExtraFieldValue(" 4 ")
+	require.NoError(t, err)
+	require.Equal(t, "4", value)
+	_, err = rating.NormalizeExtraFieldValue("6")
+	require.ErrorIs(t, err, ErrInvalidWidgetExtraValue)
+	_, err = rating.NormalizeExtraFieldValue("")
+	require.ErrorIs(t, err, ErrInvalidWidgetExtraValue)
+
+	selection := WidgetConfig{ExtraField: &WidgetExtraField{Type: WidgetExtraFieldSelect, Options: []string{"Bug", "Idea"}}}
+	value, err = selection.NormalizeExtraFieldValue("Idea")
+	require.NoError(t, err)
+	require.Equal(t, "Idea", value)
+	_, err = selection.NormalizeExtraFieldValue("Praise")
+	require.ErrorIs(t, err, ErrInvalidWidgetExtraValue)
+	value, err = selection.NormalizeExtraFieldValue("")
+	require.NoError(t, err)
+	require.Empty(t, value)
+
+	value, err = WidgetConfig{}.NormalizeExtraFieldValue("ignored")
+	require.NoError(t, err)
+	require.Empty(t, value)
+}
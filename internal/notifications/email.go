@@ -0,0 +1,98 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/MarkoPoloResearchLab/loopaware/internal/emailtemplate"
+	"github.com/MarkoPoloResearchLab/loopaware/internal/logging"
+	"github.com/MarkoPoloResearchLab/loopaware/internal/mailer"
+	"github.com/MarkoPoloResearchLab/loopaware/internal/model"
+)
+
+// MessageSender delivers composed email messages.
+type MessageSender interface {
+	SendEmailMessage(ctx context.Context, message mailer.Message) error
+}
+
+// EmailNotifier notifies site owners by email without the Pinguin service.
+type EmailNotifier struct {
+	logger    *zap.Logger
+	sender    MessageSender
+	templates *emailtemplate.Renderer
+}
+
+// NewEmailNotifier creates a notifier that emails site owners through sender.
+func NewEmailNotifier(logger *zap.Logger, sender MessageSender, templates *emailtemplate.Renderer) *EmailNotifier {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &EmailNotifier{logger: logger, sender: sender, templates: templates}
+}
+
+// NotifyFeedback emails the site owner about a feedback submission.
+func (notifier *EmailNotifier) NotifyFeedback(ctx context.Context, site model.Site, feedback model.Feedback) (string, error) {
+	message, renderErr := notifier.templates.Render(ctx, site.ID, model.EmailTemplateKindFeedbackNotification, "", emailtemplate.Data{
+		SiteName:        strings.TrimSpace(site.Name),
+		FeedbackContact: strings.TrimSpace(feedback.Contact),
+		FeedbackMessage: strings.TrimSpace(feedback.Message),
+	})
+	if renderErr != nil {
+		return model.FeedbackDeliveryNone, fmt.Errorf("render feedback notification: %w", renderErr)
+	}
+	if sendErr := notifier.send(ctx, site, message); sendErr != nil {
+		logging.WithContext(ctx, notifier.logger).Warn("email_notification_failed", zap.Error(sendErr), zap.String("site_id", site.ID), zap.String("feedback_id", feedback.ID))
+		return model.FeedbackDeliveryNone, sendErr
+	}
+	return model.FeedbackDeliveryMailed, nil
+}
+
+// NotifySubscription emails the site owner about a confirmed subscriber.
+func (notifier *EmailNotifier) NotifySubscription(ctx context.Context, site model.Site, subscriber model.Subscriber) error {
+	message, renderErr := notifier.templates.Render(ctx, site.ID, model.EmailTemplateKindSubscriptionNotification, "", emailtemplate.Data{
+		SiteName:            strings.TrimSpace(site.Name),
+		SubscriberEmail:     strings.TrimSpace(subscriber.Email),
+		SubscriberName:      strings.TrimSpace(subscriber.Name),
+		SubscriberSourceURL: strings.TrimSpace(subscriber.SourceURL),
+	})
+	if renderErr != nil {
+		return fmt.Errorf("render subscription notification: %w", renderErr)
+	}
+	if sendErr := notifier.send(ctx, site, message); sendErr != nil {
+		logging.WithContext(ctx, notifier.logger).Warn("email_notification_failed", zap.Error(sendErr), zap.String("site_id", site.ID), zap.String("subscriber_id", subscriber.ID))
+		return sendErr
+	}
+	return nil
+}
+
+// NotifyTrafficAlert emails the site owner about a fired traffic alert.
+func (notifier *EmailNotifier) NotifyTrafficAlert(ctx context.Context, site model.Site, alert model.TrafficAlert) error {
+	if sendErr := notifier.send(ctx, site, composeTrafficAlertMessage(site, alert)); sendErr != nil {
+		logging.WithContext(ctx, notifier.logger).Warn("email_notification_failed", zap.Error(sendErr), zap.String("site_id", site.ID), zap.String("traffic_alert_id", alert.ID))
+		return sendErr
+	}
+	return nil
+}
+
+func (notifier *EmailNotifier) send(ctx context.Context, site model.Site, message emailtemplate.Message) error {
+	if notifier == nil || notifier.sender == nil {
+		return errors.New("email notifier not initialized")
+	}
+	_, recipient, delivery, recipientErr := determineRecipient(site.OwnerEmail)
+	if recipientErr != nil {
+		return recipientErr
+	}
+	if delivery != model.FeedbackDeliveryMailed {
+		return fmt.Errorf("owner contact is not an email address: %s", recipient)
+	}
+	return notifier.sender.SendEmailMessage(ctx, mailer.Message{
+		Recipient: recipient,
+		Subject:   message.Subject,
+		TextBody:  message.Text,
+		HTMLBody:  message.HTML,
+	})
+}
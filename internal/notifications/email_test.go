@@ -0,0 +1,87 @@
+package notifications
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/MarkoPoloResearchLab/loopaware/internal/mailer"
+	"github.com/MarkoPoloResearchLab/loopaware/internal/model"
+	"github.com/MarkoPoloResearchLab/loopaware/internal/testutil"
+)
+
+func newTestEmailNotifier(testingT *testing.T) (*EmailNotifier, *testutil.SMTPTestServer) {
+	testingT.Helper()
+	server := testutil.NewSMTPTestServer(testingT, testutil.SMTPModeStartTLS, "", "")
+	sender, senderErr := mailer.NewSMTPSender(zap.NewNop(), mailer.SMTPConfig{
+		Host:      server.Host,
+		Port:      server.Port,
+		From:      "noreply@loopaware.example.com",
+		TLSConfig: server.ClientTLSConfig(),
+		Timeout:   5 * time.Second,
+	})
+	require.NoError(testingT, senderErr)
+	return NewEmailNotifier(zap.NewNop(), sender, nil), server
+}
+
+func TestEmailNotifierSendsFeedbackOverSMTP(testingT *testing.T) {
+	notifier, server := newTestEmailNotifier(testingT)
+
+	delivery, notifyErr := notifier.NotifyFeedback(context.Background(), model.Site{
+		ID:         testFeedbackSiteID,
+		Name:       testFeedbackSiteName,
+		OwnerEmail: testFeedbackOwnerEmail,
+	}, model.Feedback{
+		ID:      testFeedbackID,
+		Contact: testFeedbackContactEmail,
+		Message: testFeedbackMessage,
+	})
+	require.NoError(testingT, notifyErr)
+	require.Equal(testingT, model.FeedbackDeliveryMailed, delivery)
+
+	messages := server.Messages()
+	require.Len(testingT, messages, 1)
+	require.Equal(testingT, []string{testFeedbackOwnerEmail}, messages[0].To)
+	require.Contains(testingT, messages[0].Data, "Subject: New feedback for Example Site")
+	require.Contains(testingT, messages[0].Data, "text/html")
+}
+
+func TestEmailNotifierRejectsNonEmailOwners(testingT *testing.T) {
+	notifier, server := newTestEmailNotifier(testingT)
+
+	subscriptionErr := notifier.NotifySubscription(context.Background(), model.Site{
+		ID:         testFeedbackSiteID,
+		Name:       testFeedbackSiteName,
+		OwnerEmail: "+15555550100",
+	}, model.Subscriber{ID: testSubscriberID, Email: testSubscriberEmail, Name: testSubscriberName})
+	require.Error(testingT, subscriptionErr)
+	require.Empty(testingT, server.Messages())
+}
+
+func TestEmailNotifierSendsTrafficAlert(testingT *testing.T) {
+	notifier, server := newTestEmailNotifier(testingT)
+
+	notifyErr := notifier.NotifyTrafficAlert(context.Background(), model.Site{
+		ID:         testFeedbackSiteID,
+		Name:       testFeedbackSiteName,
+		OwnerEmail: testFeedbackOwnerEmail,
+	}, model.TrafficAlert{
+		ID:            "alert-id",
+		Kind:          model.TrafficAlertKindDeviation,
+		Direction:     model.TrafficAlertDirectionAbove,
+		ObservedValue: 120,
+		ExpectedValue: 12.25,
+		WindowStart:   time.Date(2026, 3, 1, 11, 0, 0, 0, time.UTC),
+		WindowEnd:     time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC),
+		Message:       "Page views for Example Site rose to 120 in the last 1h.",
+	})
+	require.NoError(testingT, notifyErr)
+
+	messages := server.Messages()
+	require.Len(testingT, messages, 1)
+	require.Contains(testingT, messages[0].Data, "Subject: Traffic alert for Example Site")
+	require.Contains(testingT, messages[0].Data, "Trailing average: 12.3")
+}
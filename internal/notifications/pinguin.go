@@ -9,10 +9,14 @@ import (
 	"strings"
 	"time"
 
+	"github.com/MarkoPoloResearchLab/loopaware/internal/emailtemplate"
+	"github.com/MarkoPoloResearchLab/loopaware/internal/logging"
 	"github.com/MarkoPoloResearchLab/loopaware/internal/model"
 	"github.com/MarkoPoloResearchLab/loopaware/internal/notifications/pinguinpb"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/metadata"
 )
@@ -25,6 +29,7 @@ type PinguinConfig struct {
 	ConnectionTimeout time.Duration
 	OperationTimeout  time.Duration
 	Dialer            func(context.Context, string) (net.Conn, error)
+	Templates         *emailtemplate.Renderer
 }
 
 // PinguinNotifier dispatches notifications through the Pinguin gRPC service.
@@ -36,6 +41,7 @@ type PinguinNotifier struct {
 	tenantID          string
 	operationTimeout  time.Duration
 	connectionTimeout time.Duration
+	templates         *emailtemplate.Renderer
 }
 
 var phoneNumberExpression = regexp.MustCompile(`^\+[1-9][0-9]{7,14}$`)
@@ -65,6 +71,8 @@ func NewPinguinNotifier(logger *zap.Logger, cfg PinguinConfig) (*PinguinNotifier
 	dialOptions := []grpc.DialOption{
 		grpc.WithTransportCredentials(insecure.NewCredentials()),
 		grpc.WithBlock(),
+		// Spans go to the global tracer provider, which records nothing unless tracing is configured.
+		grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
 	}
 	if cfg.Dialer != nil {
 		dialOptions = append(dialOptions, grpc.WithContextDialer(cfg.Dialer))
@@ -82,9 +90,28 @@ func NewPinguinNotifier(logger *zap.Logger, cfg PinguinConfig) (*PinguinNotifier
 		tenantID:          cfg.TenantID,
 		operationTimeout:  cfg.OperationTimeout,
 		connectionTimeout: cfg.ConnectionTimeout,
+		templates:         cfg.Templates,
 	}, nil
 }
 
+// ErrPinguinUnavailable reports a Pinguin connection that is failing or closed.
+var ErrPinguinUnavailable = errors.New("pinguin_unavailable")
+
+// CheckConnection reports ErrPinguinUnavailable while the gRPC connection is in transient failure or shut down.
+// An idle connection counts as available and is asked to reconnect.
+func (notifier *PinguinNotifier) CheckConnection() error {
+	if notifier == nil || notifier.conn == nil {
+		return ErrPinguinUnavailable
+	}
+	switch state := notifier.conn.GetState(); state {
+	case connectivity.TransientFailure, connectivity.Shutdown:
+		return fmt.Errorf("%w: %s", ErrPinguinUnavailable, state)
+	case connectivity.Idle:
+		notifier.conn.Connect()
+	}
+	return nil
+}
+
 // Close releases the underlying gRPC connection.
 func (notifier *PinguinNotifier) Close() error {
 	if notifier == nil || notifier.conn == nil {
@@ -104,19 +131,20 @@ func (notifier *PinguinNotifier) NotifyFeedback(ctx context.Context, site model.
 		return model.FeedbackDeliveryNone, deliveryErr
 	}
 
-	subject := fmt.Sprintf("New feedback for %s", strings.TrimSpace(site.Name))
-	messageBuilder := &strings.Builder{}
-	_, _ = fmt.Fprintf(messageBuilder, "A new feedback message was submitted for %s.\n\n", strings.TrimSpace(site.Name))
-	if feedback.Contact != "" {
-		_, _ = fmt.Fprintf(messageBuilder, "Contact: %s\n", strings.TrimSpace(feedback.Contact))
+	message, renderErr := notifier.templates.Render(ctx, site.ID, model.EmailTemplateKindFeedbackNotification, "", emailtemplate.Data{
+		SiteName:        strings.TrimSpace(site.Name),
+		FeedbackContact: strings.TrimSpace(feedback.Contact),
+		FeedbackMessage: strings.TrimSpace(feedback.Message),
+	})
+	if renderErr != nil {
+		return model.FeedbackDeliveryNone, fmt.Errorf("render feedback notification: %w", renderErr)
 	}
-	_, _ = fmt.Fprintf(messageBuilder, "Message:\n%s\n", strings.TrimSpace(feedback.Message))
 
 	request := &pinguinpb.NotificationRequest{
 		NotificationType: notificationType,
 		Recipient:        recipient,
-		Subject:          subject,
-		Message:          messageBuilder.String(),
+		Subject:          message.Subject,
+		Message:          message.Text,
 	}
 
 	callCtx, cancel := context.WithTimeout(ctx, notifier.operationTimeout)
@@ -125,13 +153,13 @@ func (notifier *PinguinNotifier) NotifyFeedback(ctx context.Context, site model.
 
 	response, sendErr := notifier.client.SendNotification(callCtx, request)
 	if sendErr != nil {
-		notifier.logger.Warn("pinguin_send_failed", zap.Error(sendErr), zap.String("site_id", site.ID), zap.String("feedback_id", feedback.ID))
+		logging.WithContext(ctx, notifier.logger).Warn("pinguin_send_failed", zap.Error(sendErr), zap.String("site_id", site.ID), zap.String("feedback_id", feedback.ID))
 		return model.FeedbackDeliveryNone, sendErr
 	}
 
 	if response.GetStatus() == pinguinpb.Status_FAILED {
 		err := fmt.Errorf("notification failed with status %s", response.GetStatus().String())
-		notifier.logger.Warn("pinguin_send_failed_status", zap.Error(err), zap.String("site_id", site.ID), zap.String("feedback_id", feedback.ID))
+		logging.WithContext(ctx, notifier.logger).Warn("pinguin_send_failed_status", zap.Error(err), zap.String("site_id", site.ID), zap.String("feedback_id", feedback.ID))
 		return model.FeedbackDeliveryNone, err
 	}
 
@@ -149,24 +177,21 @@ func (notifier *PinguinNotifier) NotifySubscription(ctx context.Context, site mo
 		return deliveryErr
 	}
 
-	subject := fmt.Sprintf("New subscriber for %s", strings.TrimSpace(site.Name))
-	messageBuilder := &strings.Builder{}
-	_, _ = fmt.Fprintf(messageBuilder, "A new subscriber joined %s.\n\n", strings.TrimSpace(site.Name))
-	if subscriber.Email != "" {
-		_, _ = fmt.Fprintf(messageBuilder, "Email: %s\n", strings.TrimSpace(subscriber.Email))
-	}
-	if subscriber.Name != "" {
-		_, _ = fmt.Fprintf(messageBuilder, "Name: %s\n", strings.TrimSpace(subscriber.Name))
-	}
-	if subscriber.SourceURL != "" {
-		_, _ = fmt.Fprintf(messageBuilder, "Source: %s\n", strings.TrimSpace(subscriber.SourceURL))
+	message, renderErr := notifier.templates.Render(ctx, site.ID, model.EmailTemplateKindSubscriptionNotification, "", emailtemplate.Data{
+		SiteName:            strings.TrimSpace(site.Name),
+		SubscriberEmail:     strings.TrimSpace(subscriber.Email),
+		SubscriberName:      strings.TrimSpace(subscriber.Name),
+		SubscriberSourceURL: strings.TrimSpace(subscriber.SourceURL),
+	})
+	if renderErr != nil {
+		return fmt.Errorf("render subscription notification: %w", renderErr)
 	}
 
 	request := &pinguinpb.NotificationRequest{
 		NotificationType: notificationType,
 		Recipient:        recipient,
-		Subject:          subject,
-		Message:          messageBuilder.String(),
+		Subject:          message.Subject,
+		Message:          message.Text,
 	}
 
 	callCtx, cancel := context.WithTimeout(ctx, notifier.operationTimeout)
@@ -175,13 +200,13 @@ func (notifier *PinguinNotifier) NotifySubscription(ctx context.Context, site mo
 
 	response, sendErr := notifier.client.SendNotification(callCtx, request)
 	if sendErr != nil {
-		notifier.logger.Warn("pinguin_send_failed", zap.Error(sendErr), zap.String("site_id", site.ID), zap.String("subscriber_id", subscriber.ID))
+		logging.WithContext(ctx, notifier.logger).Warn("pinguin_send_failed", zap.Error(sendErr), zap.String("site_id", site.ID), zap.String("subscriber_id", subscriber.ID))
 		return sendErr
 	}
 
 	if response.GetStatus() == pinguinpb.Status_FAILED {
 		err := fmt.Errorf("notification failed with status %s", response.GetStatus().String())
-		notifier.logger.Warn("pinguin_send_failed_status", zap.Error(err), zap.String("site_id", site.ID), zap.String("subscriber_id", subscriber.ID))
+		logging.WithContext(ctx, notifier.logger).Warn("pinguin_send_failed_status", zap.Error(err), zap.String("site_id", site.ID), zap.String("subscriber_id", subscriber.ID))
 		return err
 	}
 
@@ -192,7 +217,48 @@ func (notifier *PinguinNotifier) NotifySubscription(ctx context.Context, site mo
 	return nil
 }
 
-// SendEmail dispatches an email notification through the Pinguin service.
+// NotifyTrafficAlert sends the site owner a traffic alert by email or SMS, depending on the owner contact.
+func (notifier *PinguinNotifier) NotifyTrafficAlert(ctx context.Context, site model.Site, alert model.TrafficAlert) error {
+	if notifier == nil || notifier.client == nil {
+		return errors.New("pinguin notifier not initialized")
+	}
+
+	notificationType, recipient, _, deliveryErr := determineRecipient(site.OwnerEmail)
+	if deliveryErr != nil {
+		return deliveryErr
+	}
+
+	message := composeTrafficAlertMessage(site, alert)
+	request := &pinguinpb.NotificationRequest{
+		NotificationType: notificationType,
+		Recipient:        recipient,
+		Subject:          message.Subject,
+		Message:          message.Text,
+	}
+
+	callCtx, cancel := context.WithTimeout(ctx, notifier.operationTimeout)
+	defer cancel()
+	callCtx = metadata.AppendToOutgoingContext(callCtx, "authorization", "Bearer "+notifier.authToken, "x-tenant-id", notifier.tenantID)
+
+	response, sendErr := notifier.client.SendNotification(callCtx, request)
+	if sendErr != nil {
+		logging.WithContext(ctx, notifier.logger).Warn("pinguin_send_failed", zap.Error(sendErr), zap.String("site_id", site.ID), zap.String("traffic_alert_id", alert.ID))
+		return sendErr
+	}
+
+	if response.GetStatus() == pinguinpb.Status_FAILED {
+		err := fmt.Errorf("notification failed with status %s", response.GetStatus().String())
+		logging.WithContext(ctx, notifier.logger).Warn("pinguin_send_failed_status", zap.Error(err), zap.String("site_id", site.ID), zap.String("traffic_alert_id", alert.ID))
+		return err
+	}
+
+	return nil
+}
+
+// SendEmail dispatches an email notification through the Pinguin service. Pinguin requests carry only a subject and a
+// plain-text message, so the notifier deliberately does not implement SendEmailMessage: HTML alternatives and
+// List-Unsubscribe headers are SMTP-only, and callers fall back to the text body. Campaign text bodies carry their own
+// unsubscribe link for this reason.
 func (notifier *PinguinNotifier) SendEmail(ctx context.Context, recipient string, subject string, message string) error {
 	if notifier == nil || notifier.client == nil {
 		return errors.New("pinguin notifier not initialized")
@@ -219,13 +285,13 @@ func (notifier *PinguinNotifier) SendEmail(ctx context.Context, recipient string
 
 	response, sendErr := notifier.client.SendNotification(callCtx, request)
 	if sendErr != nil {
-		notifier.logger.Warn("pinguin_send_failed", zap.Error(sendErr))
+		logging.WithContext(ctx, notifier.logger).Warn("pinguin_send_failed", zap.Error(sendErr))
 		return sendErr
 	}
 
 	if response.GetStatus() == pinguinpb.Status_FAILED {
 		err := fmt.Errorf("notification failed with status %s", response.GetStatus().String())
-		notifier.logger.Warn("pinguin_send_failed_status", zap.Error(err))
+		logging.WithContext(ctx, notifier.logger).Warn("pinguin_send_failed_status", zap.Error(err))
 		return err
 	}
 
@@ -14,6 +14,8 @@ import (
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/test/bufconn"
 
+	"github.com/MarkoPoloResearchLab/loopaware/internal/emailtemplate"
+	"github.com/MarkoPoloResearchLab/loopaware/internal/mailer"
 	"github.com/MarkoPoloResearchLab/loopaware/internal/model"
 	"github.com/MarkoPoloResearchLab/loopaware/internal/notifications/pinguinpb"
 )
@@ -268,6 +270,40 @@ func TestNotifySubscriptionSendsEmailNotification(testingT *testing.T) {
 	require.NoError(testingT, notifyErr)
 }
 
+type localizedTemplateSource struct{}
+
+func (localizedTemplateSource) FindEmailTemplate(_ context.Context, _ string, kind string, locale string) (emailtemplate.Content, bool, error) {
+	if locale == "" {
+		return emailtemplate.Content{Subject: "Owner: " + kind, Text: "{{.SiteName}}"}, true, nil
+	}
+	return emailtemplate.Content{Subject: "Visitor " + locale + ": " + kind, Text: "{{.SiteName}}"}, true, nil
+}
+
+func TestNotifierRendersOwnerNotificationsInDefaultLocale(testingT *testing.T) {
+	service := &testNotificationService{responseStatus: pinguinpb.Status_SENT}
+	listener := startNotificationServer(testingT, service)
+
+	notifier, createErr := NewPinguinNotifier(zap.NewNop(), PinguinConfig{
+		Address:           testPinguinAddress,
+		AuthToken:         testPinguinAuthToken,
+		TenantID:          testPinguinTenantID,
+		ConnectionTimeout: time.Second,
+		OperationTimeout:  time.Second,
+		Dialer:            createPinguinDialer(listener),
+		Templates:         emailtemplate.NewRenderer(localizedTemplateSource{}, zap.NewNop()),
+	})
+	require.NoError(testingT, createErr)
+	testingT.Cleanup(func() {
+		_ = notifier.Close()
+	})
+	site := model.Site{ID: testFeedbackSiteID, Name: testFeedbackSiteName, OwnerEmail: testFeedbackOwnerEmail}
+
+	subscriptionErr := notifier.NotifySubscription(context.Background(), site, model.Subscriber{ID: testSubscriberID, Email: testSubscriberEmail, Locale: "de"})
+	require.NoError(testingT, subscriptionErr)
+	request, _ := service.recordedRequest()
+	require.Equal(testingT, "Owner: "+model.EmailTemplateKindSubscriptionNotification, request.GetSubject())
+}
+
 func TestNotifySubscriptionReturnsErrorOnFailedStatus(testingT *testing.T) {
 	service := &testNotificationService{responseStatus: pinguinpb.Status_FAILED}
 	listener := startNotificationServer(testingT, service)
@@ -361,6 +397,35 @@ func TestSendEmailValidatesRecipient(testingT *testing.T) {
 	require.NoError(testingT, sendErr)
 }
 
+func TestPinguinEmailCarriesOnlyPlainText(testingT *testing.T) {
+	service := &testNotificationService{responseStatus: pinguinpb.Status_SENT}
+	listener := startNotificationServer(testingT, service)
+
+	notifier, createErr := NewPinguinNotifier(zap.NewNop(), PinguinConfig{
+		Address:           testPinguinAddress,
+		AuthToken:         testPinguinAuthToken,
+		TenantID:          testPinguinTenantID,
+		ConnectionTimeout: time.Second,
+		OperationTimeout:  time.Second,
+		Dialer:            createPinguinDialer(listener),
+	})
+	require.NoError(testingT, createErr)
+	testingT.Cleanup(func() {
+		_ = notifier.Close()
+	})
+
+	_, sendsMultipart := any(notifier).(interface {
+		SendEmailMessage(context.Context, mailer.Message) error
+	})
+	require.False(testingT, sendsMultipart, "pinguin requests have no HTML or header fields; multipart mail is SMTP-only")
+
+	textBody := "Hello\n\nUnsubscribe: https://loopaware.example.com/subscriptions/unsubscribe?token=abc"
+	require.NoError(testingT, notifier.SendEmail(context.Background(), testSubscriberEmail, testEmailSubject, textBody))
+	request, _ := service.recordedRequest()
+	require.Equal(testingT, pinguinpb.NotificationType_EMAIL, request.GetNotificationType())
+	require.Equal(testingT, textBody, request.GetMessage())
+}
+
 func TestSendEmailReturnsErrorOnFailedStatus(testingT *testing.T) {
 	service := &testNotificationService{responseStatus: pinguinpb.Status_FAILED}
 	listener := startNotificationServer(testingT, service)
@@ -470,3 +535,43 @@ func TestNewPinguinNotifierDefaultsTimeouts(testingT *testing.T) {
 	require.Equal(testingT, testDefaultConnTimeout, notifier.connectionTimeout)
 	require.Equal(testingT, testDefaultOpTimeout, notifier.operationTimeout)
 }
+
+func TestNotifyTrafficAlertTextsPhoneOwners(testingT *testing.T) {
+	service := &testNotificationService{responseStatus: pinguinpb.Status_SENT}
+	listener := startNotificationServer(testingT, service)
+
+	notifier, createErr := NewPinguinNotifier(zap.NewNop(), PinguinConfig{
+		Address:           testPinguinAddress,
+		AuthToken:         testPinguinAuthToken,
+		TenantID:          testPinguinTenantID,
+		ConnectionTimeout: time.Second,
+		OperationTimeout:  time.Second,
+		Dialer:            createPinguinDialer(listener),
+	})
+	require.NoError(testingT, createErr)
+	testingT.Cleanup(func() {
+		_ = notifier.Close()
+	})
+
+	site := model.Site{ID: testFeedbackSiteID, Name: testFeedbackSiteName, OwnerEmail: "+15555550100"}
+	alert := model.TrafficAlert{
+		ID:            "alert-id",
+		Kind:          model.TrafficAlertKindThreshold,
+		Direction:     model.TrafficAlertDirectionBelow,
+		ObservedValue: 0,
+		ExpectedValue: 1,
+		WindowStart:   time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+		WindowEnd:     time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC),
+		Message:       "Page views for Example Site fell to 0 in the last 24h, below the threshold of 1.",
+	}
+
+	require.NoError(testingT, notifier.NotifyTrafficAlert(context.Background(), site, alert))
+
+	request, _ := service.recordedRequest()
+	require.NotNil(testingT, request)
+	require.Equal(testingT, pinguinpb.NotificationType_SMS, request.GetNotificationType())
+	require.Equal(testingT, "+15555550100", request.GetRecipient())
+	require.Equal(testingT, "Traffic alert for "+testFeedbackSiteName, request.GetSubject())
+	require.Contains(testingT, request.GetMessage(), alert.Message)
+	require.Contains(testingT, request.GetMessage(), "Threshold: 1")
+}
@@ -0,0 +1,52 @@
+package notifications
+
+import (
+	"fmt"
+	"html"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/MarkoPoloResearchLab/loopaware/internal/emailtemplate"
+	"github.com/MarkoPoloResearchLab/loopaware/internal/model"
+)
+
+const trafficAlertTimeLayout = "Jan 2 15:04 MST"
+
+// composeTrafficAlertMessage renders the owner-facing text for a fired traffic alert.
+// Traffic alerts are operational messages, so they use fixed copy instead of site email templates.
+func composeTrafficAlertMessage(site model.Site, alert model.TrafficAlert) emailtemplate.Message {
+	siteName := strings.TrimSpace(site.Name)
+	if siteName == "" {
+		siteName = site.ID
+	}
+	windowText := fmt.Sprintf("%s – %s", alert.WindowStart.UTC().Format(trafficAlertTimeLayout), alert.WindowEnd.UTC().Format(trafficAlertTimeLayout))
+	observedText := formatTrafficAlertValue(alert.ObservedValue)
+	expectedLabel := "Threshold"
+	if alert.Kind == model.TrafficAlertKindDeviation {
+		expectedLabel = "Trailing average"
+	}
+	expectedText := formatTrafficAlertValue(alert.ExpectedValue)
+
+	textBuilder := &strings.Builder{}
+	_, _ = fmt.Fprintf(textBuilder, "%s\n\n", alert.Message)
+	_, _ = fmt.Fprintf(textBuilder, "Page views: %s\n", observedText)
+	_, _ = fmt.Fprintf(textBuilder, "%s: %s\n", expectedLabel, expectedText)
+	_, _ = fmt.Fprintf(textBuilder, "Window: %s\n", windowText)
+
+	htmlBuilder := &strings.Builder{}
+	_, _ = fmt.Fprintf(htmlBuilder, "<p>%s</p>\n<ul>\n", html.EscapeString(alert.Message))
+	_, _ = fmt.Fprintf(htmlBuilder, "<li>Page views: %s</li>\n", observedText)
+	_, _ = fmt.Fprintf(htmlBuilder, "<li>%s: %s</li>\n", expectedLabel, expectedText)
+	_, _ = fmt.Fprintf(htmlBuilder, "<li>Window: %s</li>\n</ul>\n", html.EscapeString(windowText))
+
+	return emailtemplate.Message{
+		Subject: "Traffic alert for " + siteName,
+		Text:    textBuilder.String(),
+		HTML:    htmlBuilder.String(),
+	}
+}
+
+func formatTrafficAlertValue(value float64) string {
+	return strconv.FormatFloat(math.Round(value*10)/10, 'f', -1, 64)
+}
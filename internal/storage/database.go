@@ -82,7 +82,10 @@ func openSQLiteDatabase(configuration Config) (*gorm.DB, error) {
 
 // AutoMigrate runs database migrations for the storage layer models.
 func AutoMigrate(database *gorm.DB) error {
-	if err := database.AutoMigrate(&model.Site{}, &model.Feedback{}, &model.User{}, &model.Subscriber{}, &model.SiteVisit{}, &model.SiteVisitRollup{}); err != nil {
+	if err := database.AutoMigrate(&model.Site{}, &model.Feedback{}, &model.User{}, &model.Subscriber{}, &model.SiteVisit{}, &model.SiteVisitRollup{}, &model.Campaign{}, &model.CampaignDelivery{}, &model.SubscriberImport{}, &model.EmailTemplate{}, &model.FeedbackAttachment{}, &model.StoredBlob{}, &model.TrafficAlertRule{}, &model.TrafficAlert{}, &model.JobState{}); err != nil {
+		return err
+	}
+	if err := ensureFeedbackSearchIndex(database); err != nil {
 		return err
 	}
 	return backfillSiteCreatorEmails(database)
@@ -0,0 +1,84 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// FeedbackSearchTable names the SQLite FTS5 index over feedback message, contact, and category text.
+// It stores its own copy of the text together with feedbacks.id in the unindexed feedback_id column, and triggers keep
+// it in sync. Search results join on feedback_id rather than on the feedbacks rowid, which VACUUM may renumber because
+// feedbacks has a string primary key.
+const FeedbackSearchTable = "feedback_search"
+
+// feedbackSearchKeyColumn is only present in the current index layout; an index without it predates the layout and is
+// rebuilt once.
+const feedbackSearchKeyColumn = "feedback_id"
+
+var feedbackSearchTriggerNames = []string{
+	"feedback_search_after_insert",
+	"feedback_search_after_delete",
+	"feedback_search_after_update",
+}
+
+var feedbackSearchCreateStatements = []string{
+	`CREATE VIRTUAL TABLE feedback_search USING fts5(feedback_id UNINDEXED, message, contact, category, tokenize='unicode61 remove_diacritics 2')`,
+	`INSERT INTO feedback_search(feedback_id, message, contact, category) SELECT id, message, contact, category FROM feedbacks`,
+}
+
+var feedbackSearchTriggerStatements = []string{
+	`CREATE TRIGGER IF NOT EXISTS feedback_search_after_insert AFTER INSERT ON feedbacks BEGIN
+		INSERT INTO feedback_search(feedback_id, message, contact, category) VALUES (new.id, new.message, new.contact, new.category);
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS feedback_search_after_delete AFTER DELETE ON feedbacks BEGIN
+		DELETE FROM feedback_search WHERE feedback_id = old.id;
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS feedback_search_after_update AFTER UPDATE OF id, message, contact, category ON feedbacks BEGIN
+		UPDATE feedback_search SET feedback_id = new.id, message = new.message, contact = new.contact, category = new.category WHERE feedback_id = old.id;
+	END`,
+}
+
+// SupportsFeedbackSearch reports whether the database maintains the full-text feedback index.
+func SupportsFeedbackSearch(database *gorm.DB) bool {
+	return database != nil && database.Dialector != nil && database.Dialector.Name() == DriverNameSQLite
+}
+
+// ensureFeedbackSearchIndex creates the index and its triggers. The index is only populated from feedbacks when it is
+// created or replaced; later migrations leave it untouched.
+func ensureFeedbackSearchIndex(database *gorm.DB) error {
+	if !SupportsFeedbackSearch(database) {
+		return nil
+	}
+
+	var tableDefinitions []string
+	if err := database.Raw("SELECT sql FROM sqlite_master WHERE type = 'table' AND name = ?", FeedbackSearchTable).Scan(&tableDefinitions).Error; err != nil {
+		return fmt.Errorf("storage: inspect feedback search index: %w", err)
+	}
+	currentLayout := len(tableDefinitions) == 1 && strings.Contains(tableDefinitions[0], feedbackSearchKeyColumn)
+
+	return database.Transaction(func(transaction *gorm.DB) error {
+		if !currentLayout {
+			for _, triggerName := range feedbackSearchTriggerNames {
+				if err := transaction.Exec("DROP TRIGGER IF EXISTS " + triggerName).Error; err != nil {
+					return fmt.Errorf("storage: drop feedback search trigger: %w", err)
+				}
+			}
+			if err := transaction.Exec("DROP TABLE IF EXISTS " + FeedbackSearchTable).Error; err != nil {
+				return fmt.Errorf("storage: drop feedback search index: %w", err)
+			}
+			for _, statement := range feedbackSearchCreateStatements {
+				if err := transaction.Exec(statement).Error; err != nil {
+					return fmt.Errorf("storage: build feedback search index: %w", err)
+				}
+			}
+		}
+		for _, statement := range feedbackSearchTriggerStatements {
+			if err := transaction.Exec(statement).Error; err != nil {
+				return fmt.Errorf("storage: create feedback search trigger: %w", err)
+			}
+		}
+		return nil
+	})
+}
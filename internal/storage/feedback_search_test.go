@@ -0,0 +1,92 @@
+package storage_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/MarkoPoloResearchLab/loopaware/internal/model"
+	"github.com/MarkoPoloResearchLab/loopaware/internal/storage"
+	"github.com/MarkoPoloResearchLab/loopaware/internal/testutil"
+)
+
+func TestFeedbackSearchIndexTracksFeedbackChanges(testingT *testing.T) {
+	sqliteDatabase := testutil.NewSQLiteTestDatabase(testingT)
+	database, openErr := storage.OpenDatabase(sqliteDatabase.Configuration())
+	require.NoError(testingT, openErr)
+	require.NoError(testingT, database.AutoMigrate(&model.Feedback{}))
+
+	existingFeedback := model.Feedback{ID: storage.NewID(), SiteID: "site-1", Contact: "old@example.com", Message: "Checkout button is broken"}
+	require.NoError(testingT, database.Create(&existingFeedback).Error)
+
+	require.NoError(testingT, storage.AutoMigrate(database))
+	require.NoError(testingT, storage.AutoMigrate(database))
+	require.True(testingT, storage.SupportsFeedbackSearch(database))
+	require.Equal(testingT, []string{existingFeedback.ID}, matchFeedbackIDs(testingT, database, "checkout"))
+
+	newFeedback := model.Feedback{ID: storage.NewID(), SiteID: "site-1", Contact: "new@example.com", Message: "Café menu is lovely", Category: "Praise"}
+	require.NoError(testingT, database.Create(&newFeedback).Error)
+	require.Equal(testingT, []string{newFeedback.ID}, matchFeedbackIDs(testingT, database, "cafe"))
+	require.Equal(testingT, []string{newFeedback.ID}, matchFeedbackIDs(testingT, database, "praise"))
+
+	require.NoError(testingT, database.Model(&newFeedback).Update("message", "Dessert menu is lovely").Error)
+	require.Empty(testingT, matchFeedbackIDs(testingT, database, "cafe"))
+	require.Equal(testingT, []string{newFeedback.ID}, matchFeedbackIDs(testingT, database, "dessert"))
+
+	require.NoError(testingT, database.Delete(&existingFeedback).Error)
+	require.Empty(testingT, matchFeedbackIDs(testingT, database, "checkout"))
+}
+
+func TestFeedbackSearchIndexSurvivesRowidRenumbering(testingT *testing.T) {
+	sqliteDatabase := testutil.NewSQLiteTestDatabase(testingT)
+	database, openErr := storage.OpenDatabase(sqliteDatabase.Configuration())
+	require.NoError(testingT, openErr)
+	require.NoError(testingT, storage.AutoMigrate(database))
+
+	firstFeedback := model.Feedback{ID: storage.NewID(), SiteID: "site-1", Message: "Checkout button is broken"}
+	secondFeedback := model.Feedback{ID: storage.NewID(), SiteID: "site-1", Message: "Dessert menu is lovely"}
+	require.NoError(testingT, database.Create(&firstFeedback).Error)
+	require.NoError(testingT, database.Create(&secondFeedback).Error)
+
+	// Swap the implicit rowids the way VACUUM may renumber them.
+	require.NoError(testingT, database.Exec("UPDATE feedbacks SET rowid = -rowid").Error)
+	require.NoError(testingT, database.Exec("UPDATE feedbacks SET rowid = CASE WHEN id = ? THEN 2 ELSE 1 END", firstFeedback.ID).Error)
+	require.Equal(testingT, []string{firstFeedback.ID}, matchFeedbackIDs(testingT, database, "checkout"))
+	require.Equal(testingT, []string{secondFeedback.ID}, matchFeedbackIDs(testingT, database, "dessert"))
+
+	require.NoError(testingT, database.Delete(&firstFeedback).Error)
+	require.Empty(testingT, matchFeedbackIDs(testingT, database, "checkout"))
+	require.Equal(testingT, []string{secondFeedback.ID}, matchFeedbackIDs(testingT, database, "dessert"))
+}
+
+func TestFeedbackSearchIndexReplacesRowidKeyedIndex(testingT *testing.T) {
+	sqliteDatabase := testutil.NewSQLiteTestDatabase(testingT)
+	database, openErr := storage.OpenDatabase(sqliteDatabase.Configuration())
+	require.NoError(testingT, openErr)
+	require.NoError(testingT, database.AutoMigrate(&model.Feedback{}))
+	require.NoError(testingT, database.Exec(`CREATE VIRTUAL TABLE feedback_search USING fts5(message, contact, category, content='feedbacks', content_rowid='rowid')`).Error)
+	require.NoError(testingT, database.Exec(`CREATE TRIGGER feedback_search_after_insert AFTER INSERT ON feedbacks BEGIN
+		INSERT INTO feedback_search(rowid, message, contact, category) VALUES (new.rowid, new.message, new.contact, new.category);
+	END`).Error)
+
+	existingFeedback := model.Feedback{ID: storage.NewID(), SiteID: "site-1", Message: "Checkout button is broken"}
+	require.NoError(testingT, database.Create(&existingFeedback).Error)
+
+	require.NoError(testingT, storage.AutoMigrate(database))
+	require.Equal(testingT, []string{existingFeedback.ID}, matchFeedbackIDs(testingT, database, "checkout"))
+
+	newFeedback := model.Feedback{ID: storage.NewID(), SiteID: "site-1", Message: "Checkout again"}
+	require.NoError(testingT, database.Create(&newFeedback).Error)
+	require.ElementsMatch(testingT, []string{existingFeedback.ID, newFeedback.ID}, matchFeedbackIDs(testingT, database, "checkout"))
+}
+
+func matchFeedbackIDs(testingT *testing.T, database *gorm.DB, matchExpression string) []string {
+	testingT.Helper()
+	var feedbackIDs []string
+	require.NoError(testingT, database.Raw(
+		"SELECT feedbacks.id FROM feedback_search JOIN feedbacks ON feedbacks.id = feedback_search.feedback_id WHERE feedback_search MATCH ?",
+		matchExpression,
+	).Scan(&feedbackIDs).Error)
+	return feedbackIDs
+}
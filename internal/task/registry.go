@@ -0,0 +1,561 @@
+package task
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	mathrand "math/rand/v2"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/MarkoPoloResearchLab/loopaware/internal/model"
+	"github.com/MarkoPoloResearchLab/loopaware/internal/tracing"
+)
+
+const (
+	defaultJobPollInterval = 15 * time.Second
+	defaultJobTimeout      = 10 * time.Minute
+	jobLeaseMargin         = 30 * time.Second
+	jobOverdueGrace        = 5 * time.Minute
+	maxJobNameLength       = 64
+)
+
+var (
+	// ErrInvalidJob reports a job without a name, schedule, or run function.
+	ErrInvalidJob = errors.New("invalid_job")
+	// ErrDuplicateJob reports a second registration under an existing job name.
+	ErrDuplicateJob = errors.New("duplicate_job")
+	// ErrJobNotFound reports a job name that is not registered.
+	ErrJobNotFound = errors.New("job_not_found")
+	// ErrRegistryStarted reports a registration attempted after Start.
+	ErrRegistryStarted = errors.New("registry_started")
+)
+
+// JobFunc runs one pass of a background job.
+type JobFunc func(context.Context) error
+
+// Job describes named background work run by a Registry.
+type Job struct {
+	Name        string
+	Description string
+	Schedule    Schedule
+	// Jitter delays every scheduled run by a random duration below it so that jobs sharing a schedule spread out.
+	Jitter time.Duration
+	// Timeout bounds a single run; the lease taken on the job lasts slightly longer.
+	Timeout time.Duration
+	Run     JobFunc
+}
+
+// JobStatus combines a registered job with its persisted state.
+type JobStatus struct {
+	Name             string
+	Description      string
+	Schedule         string
+	Paused           bool
+	Running          bool
+	Healthy          bool
+	TriggerRequested bool
+	NextRunAt        time.Time
+	LastStartedAt    time.Time
+	LastFinishedAt   time.Time
+	LastDuration     time.Duration
+	LastError        string
+	RunCount         int64
+	FailureCount     int64
+	LeaseOwner       string
+}
+
+// RunObserver is told about every job run that finished while the registry was running.
+type RunObserver func(name string, duration time.Duration, runErr error)
+
+// RegistryOption customizes a Registry.
+type RegistryOption func(*Registry)
+
+// Registry runs named jobs on cron or interval schedules. Job state lives in the job_states table: a replica runs a
+// job only after taking its lease with a conditional update, so a job runs once per schedule across replicas, and
+// pause, resume, and trigger requests made through any replica apply to all of them.
+type Registry struct {
+	database     *gorm.DB
+	logger       *zap.Logger
+	instanceID   string
+	pollInterval time.Duration
+	now          func() time.Time
+	observer     RunObserver
+
+	jobsMutex sync.Mutex
+	jobs      map[string]Job
+	names     []string
+	running   map[string]bool
+	started   bool
+
+	wake         chan struct{}
+	controlMutex sync.Mutex
+	cancel       context.CancelFunc
+	done         chan struct{}
+	runs         sync.WaitGroup
+}
+
+// NewRegistry builds an empty registry that stores job state in database.
+func NewRegistry(database *gorm.DB, logger *zap.Logger, options ...RegistryOption) *Registry {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	registry := &Registry{
+		database:     database,
+		logger:       logger,
+		instanceID:   defaultInstanceID(),
+		pollInterval: defaultJobPollInterval,
+		now:          time.Now,
+		jobs:         make(map[string]Job),
+		running:      make(map[string]bool),
+		wake:         make(chan struct{}, 1),
+	}
+	for _, option := range options {
+		if option != nil {
+			option(registry)
+		}
+	}
+	return registry
+}
+
+// WithRegistryInstanceID sets the lease owner recorded by this replica.
+func WithRegistryInstanceID(instanceID string) RegistryOption {
+	return func(registry *Registry) {
+		if trimmed := strings.TrimSpace(instanceID); trimmed != "" {
+			registry.instanceID = trimmed
+		}
+	}
+}
+
+// WithRegistryPollInterval sets how often the registry looks for due jobs.
+func WithRegistryPollInterval(pollInterval time.Duration) RegistryOption {
+	return func(registry *Registry) {
+		if pollInterval > 0 {
+			registry.pollInterval = pollInterval
+		}
+	}
+}
+
+// WithRegistryRunObserver reports the duration and error of each completed run, for example to metrics.
+func WithRegistryRunObserver(observer RunObserver) RegistryOption {
+	return func(registry *Registry) {
+		registry.observer = observer
+	}
+}
+
+// WithRegistryClock overrides the registry clock.
+func WithRegistryClock(clock func() time.Time) RegistryOption {
+	return func(registry *Registry) {
+		if clock != nil {
+			registry.now = clock
+		}
+	}
+}
+
+func defaultInstanceID() string {
+	hostname, _ := os.Hostname()
+	suffix := make([]byte, 4)
+	_, _ = rand.Read(suffix)
+	if hostname == "" {
+		hostname = "loopaware"
+	}
+	return hostname + "-" + hex.EncodeToString(suffix)
+}
+
+// Register adds a job. Jobs must be registered before Start.
+func (registry *Registry) Register(job Job) error {
+	job.Name = strings.TrimSpace(job.Name)
+	if job.Name == "" || len(job.Name) > maxJobNameLength || job.Schedule == nil || job.Run == nil {
+		return fmt.Errorf("%w: %q", ErrInvalidJob, job.Name)
+	}
+	if job.Timeout <= 0 {
+		job.Timeout = defaultJobTimeout
+	}
+	registry.jobsMutex.Lock()
+	defer registry.jobsMutex.Unlock()
+	if registry.started {
+		return ErrRegistryStarted
+	}
+	if _, exists := registry.jobs[job.Name]; exists {
+		return fmt.Errorf("%w: %q", ErrDuplicateJob, job.Name)
+	}
+	registry.jobs[job.Name] = job
+	registry.names = append(registry.names, job.Name)
+	return nil
+}
+
+// Start begins polling for due jobs. Stop cancels running jobs and waits for them.
+func (registry *Registry) Start(ctx context.Context) {
+	if registry == nil || registry.database == nil {
+		return
+	}
+	registry.controlMutex.Lock()
+	if registry.cancel != nil {
+		registry.controlMutex.Unlock()
+		return
+	}
+	registry.jobsMutex.Lock()
+	registry.started = true
+	registry.jobsMutex.Unlock()
+	runtimeCtx, cancel := context.WithCancel(ctx)
+	registry.cancel = cancel
+	done := make(chan struct{})
+	registry.done = done
+	registry.controlMutex.Unlock()
+
+	go registry.loop(runtimeCtx, done)
+}
+
+// Stop halts polling, cancels running jobs, and waits for them to release their leases.
+func (registry *Registry) Stop() {
+	if registry == nil {
+		return
+	}
+	registry.controlMutex.Lock()
+	cancel := registry.cancel
+	done := registry.done
+	registry.cancel = nil
+	registry.done = nil
+	registry.controlMutex.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+	if done != nil {
+		<-done
+	}
+}
+
+// Jobs reports every registered job in registration order.
+func (registry *Registry) Jobs(ctx context.Context) ([]JobStatus, error) {
+	states, loadErr := registry.loadStates(ctx)
+	if loadErr != nil {
+		return nil, loadErr
+	}
+	now := registry.now()
+	jobs, _ := registry.snapshot()
+	statuses := make([]JobStatus, 0, len(jobs))
+	for _, job := range jobs {
+		statuses = append(statuses, registry.status(job, states[job.Name], now))
+	}
+	return statuses, nil
+}
+
+// Job reports a single registered job.
+func (registry *Registry) Job(ctx context.Context, name string) (JobStatus, error) {
+	job, exists := registry.lookup(name)
+	if !exists {
+		return JobStatus{}, ErrJobNotFound
+	}
+	states, loadErr := registry.loadStates(ctx)
+	if loadErr != nil {
+		return JobStatus{}, loadErr
+	}
+	return registry.status(job, states[job.Name], registry.now()), nil
+}
+
+// Pause stops scheduled runs of a job on every replica. A paused job still runs when triggered.
+func (registry *Registry) Pause(ctx context.Context, name string) (JobStatus, error) {
+	return registry.updateState(ctx, name, map[string]any{"paused": true})
+}
+
+// Resume re-enables scheduled runs of a job, starting from its next scheduled time rather than the runs it missed.
+func (registry *Registry) Resume(ctx context.Context, name string) (JobStatus, error) {
+	job, exists := registry.lookup(name)
+	if !exists {
+		return JobStatus{}, ErrJobNotFound
+	}
+	return registry.updateState(ctx, name, map[string]any{"paused": false, "next_run_at": registry.nextRun(job, registry.now())})
+}
+
+// Trigger asks for a run of a job as soon as a replica polls, even when the job is paused.
+func (registry *Registry) Trigger(ctx context.Context, name string) (JobStatus, error) {
+	status, updateErr := registry.updateState(ctx, name, map[string]any{"trigger_requested": true})
+	if updateErr != nil {
+		return JobStatus{}, updateErr
+	}
+	select {
+	case registry.wake <- struct{}{}:
+	default:
+	}
+	return status, nil
+}
+
+func (registry *Registry) updateState(ctx context.Context, name string, updates map[string]any) (JobStatus, error) {
+	job, exists := registry.lookup(name)
+	if !exists {
+		return JobStatus{}, ErrJobNotFound
+	}
+	if _, loadErr := registry.loadStates(ctx); loadErr != nil {
+		return JobStatus{}, loadErr
+	}
+	if updateErr := registry.database.WithContext(ctx).Model(&model.JobState{}).Where("name = ?", job.Name).Updates(updates).Error; updateErr != nil {
+		return JobStatus{}, fmt.Errorf("update job state: %w", updateErr)
+	}
+	return registry.Job(ctx, job.Name)
+}
+
+func (registry *Registry) loop(ctx context.Context, done chan struct{}) {
+	ticker := time.NewTicker(registry.pollInterval)
+	defer func() {
+		ticker.Stop()
+		registry.runs.Wait()
+		close(done)
+	}()
+	registry.poll(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-registry.wake:
+		}
+		registry.poll(ctx)
+	}
+}
+
+// poll starts every due job that this replica is not already running and whose lease it can take.
+func (registry *Registry) poll(ctx context.Context) {
+	states, loadErr := registry.loadStates(ctx)
+	if loadErr != nil {
+		if ctx.Err() == nil {
+			registry.logger.Warn("job_states_load_failed", zap.Error(loadErr))
+		}
+		return
+	}
+	now := registry.now()
+	jobs, _ := registry.snapshot()
+	for _, job := range jobs {
+		state := states[job.Name]
+		if !state.TriggerRequested && (state.Paused || state.NextRunAt.After(now)) {
+			continue
+		}
+		if !registry.markRunning(job.Name) {
+			continue
+		}
+		acquired, acquireErr := registry.acquire(ctx, job, now)
+		if acquireErr != nil || !acquired {
+			if acquireErr != nil && ctx.Err() == nil {
+				registry.logger.Warn("job_lease_failed", zap.String("job", job.Name), zap.Error(acquireErr))
+			}
+			registry.clearRunning(job.Name)
+			continue
+		}
+		registry.runs.Add(1)
+		go registry.execute(ctx, job)
+	}
+}
+
+func (registry *Registry) acquire(ctx context.Context, job Job, now time.Time) (bool, error) {
+	result := registry.database.WithContext(ctx).
+		Model(&model.JobState{}).
+		Where("name = ? AND lease_expires_at < ?", job.Name, now).
+		Where("(trigger_requested = ? OR (paused = ? AND next_run_at <= ?))", true, false, now).
+		Updates(map[string]any{
+			"lease_owner":       registry.instanceID,
+			"lease_expires_at":  now.Add(job.Timeout + jobLeaseMargin),
+			"last_started_at":   now,
+			"trigger_requested": false,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// execute runs a leased job and records the outcome. A run interrupted by Stop only releases the lease so that
+// another replica, or this one after a restart, picks the job up again.
+func (registry *Registry) execute(ctx context.Context, job Job) {
+	defer registry.runs.Done()
+	defer registry.clearRunning(job.Name)
+
+	startedAt := registry.now()
+	runCtx, cancel := context.WithTimeout(ctx, job.Timeout)
+	runCtx, span := tracing.Tracer().Start(runCtx, "job "+job.Name, trace.WithAttributes(attribute.String("job", job.Name)))
+	runErr := runJob(runCtx, job)
+	if runErr != nil {
+		span.RecordError(runErr)
+		span.SetStatus(codes.Error, runErr.Error())
+	}
+	span.End()
+	cancel()
+	finishedAt := registry.now()
+
+	recordCtx := context.WithoutCancel(ctx)
+	updates := map[string]any{
+		"lease_owner":      "",
+		"lease_expires_at": time.Time{},
+	}
+	if ctx.Err() == nil {
+		updates["last_finished_at"] = finishedAt
+		updates["last_duration_ms"] = finishedAt.Sub(startedAt).Milliseconds()
+		updates["last_error"] = truncateJobError(runErr)
+		updates["run_count"] = gorm.Expr("run_count + 1")
+		updates["next_run_at"] = registry.nextRun(job, finishedAt)
+		if runErr != nil {
+			updates["failure_count"] = gorm.Expr("failure_count + 1")
+		}
+		if registry.observer != nil {
+			registry.observer(job.Name, finishedAt.Sub(startedAt), runErr)
+		}
+	}
+	recordErr := registry.database.WithContext(recordCtx).
+		Model(&model.JobState{}).
+		Where("name = ? AND lease_owner = ?", job.Name, registry.instanceID).
+		Updates(updates).Error
+	if recordErr != nil {
+		registry.logger.Warn("job_state_record_failed", zap.String("job", job.Name), zap.Error(recordErr))
+	}
+
+	switch {
+	case ctx.Err() != nil:
+		registry.logger.Info("job_run_interrupted", zap.String("job", job.Name))
+	case runErr != nil:
+		registry.logger.Warn("job_run_failed", zap.String("job", job.Name), zap.Duration("duration", finishedAt.Sub(startedAt)), zap.Error(runErr))
+	default:
+		registry.logger.Debug("job_run_completed", zap.String("job", job.Name), zap.Duration("duration", finishedAt.Sub(startedAt)))
+	}
+}
+
+func runJob(ctx context.Context, job Job) (runErr error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			runErr = fmt.Errorf("job panicked: %v", recovered)
+		}
+	}()
+	return job.Run(ctx)
+}
+
+// loadStates returns the stored state of every registered job, creating missing rows and rescheduling jobs whose
+// schedule changed since the state was written.
+func (registry *Registry) loadStates(ctx context.Context) (map[string]model.JobState, error) {
+	if registry == nil || registry.database == nil {
+		return nil, errors.New("job registry is not configured")
+	}
+	jobs, names := registry.snapshot()
+	var stored []model.JobState
+	if findErr := registry.database.WithContext(ctx).Where("name IN ?", names).Find(&stored).Error; findErr != nil {
+		return nil, fmt.Errorf("load job states: %w", findErr)
+	}
+	states := make(map[string]model.JobState, len(stored))
+	for _, state := range stored {
+		states[state.Name] = state
+	}
+
+	now := registry.now()
+	for _, job := range jobs {
+		state, exists := states[job.Name]
+		scheduleExpression := job.Schedule.String()
+		switch {
+		case !exists:
+			state = model.JobState{Name: job.Name, Schedule: scheduleExpression, NextRunAt: registry.nextRun(job, now)}
+			createErr := registry.database.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&state).Error
+			if createErr != nil {
+				return nil, fmt.Errorf("create job state: %w", createErr)
+			}
+		case state.Schedule != scheduleExpression:
+			state.Schedule = scheduleExpression
+			state.NextRunAt = registry.nextRun(job, now)
+			updateErr := registry.database.WithContext(ctx).Model(&model.JobState{}).Where("name = ?", job.Name).
+				Updates(map[string]any{"schedule": state.Schedule, "next_run_at": state.NextRunAt}).Error
+			if updateErr != nil {
+				return nil, fmt.Errorf("reschedule job: %w", updateErr)
+			}
+		}
+		states[job.Name] = state
+	}
+	return states, nil
+}
+
+func (registry *Registry) status(job Job, state model.JobState, now time.Time) JobStatus {
+	status := JobStatus{
+		Name:             job.Name,
+		Description:      job.Description,
+		Schedule:         job.Schedule.String(),
+		Paused:           state.Paused,
+		TriggerRequested: state.TriggerRequested,
+		NextRunAt:        state.NextRunAt,
+		LastStartedAt:    state.LastStartedAt,
+		LastFinishedAt:   state.LastFinishedAt,
+		LastDuration:     time.Duration(state.LastDurationMs) * time.Millisecond,
+		LastError:        state.LastError,
+		RunCount:         state.RunCount,
+		FailureCount:     state.FailureCount,
+		LeaseOwner:       state.LeaseOwner,
+	}
+	leaseHeld := state.LeaseOwner != ""
+	leaseExpired := leaseHeld && state.LeaseExpiresAt.Before(now)
+	status.Running = leaseHeld && !leaseExpired
+	overdue := !state.Paused && !status.Running && !state.NextRunAt.IsZero() && now.Sub(state.NextRunAt) > registry.overdueGrace()
+	status.Healthy = state.LastError == "" && !overdue && !leaseExpired
+	return status
+}
+
+func (registry *Registry) overdueGrace() time.Duration {
+	return max(jobOverdueGrace, 2*registry.pollInterval)
+}
+
+func (registry *Registry) nextRun(job Job, after time.Time) time.Time {
+	next := job.Schedule.Next(after)
+	if next.IsZero() || job.Jitter <= 0 {
+		return next
+	}
+	return next.Add(mathrand.N(job.Jitter))
+}
+
+func (registry *Registry) snapshot() ([]Job, []string) {
+	registry.jobsMutex.Lock()
+	defer registry.jobsMutex.Unlock()
+	jobs := make([]Job, 0, len(registry.names))
+	for _, name := range registry.names {
+		jobs = append(jobs, registry.jobs[name])
+	}
+	return jobs, append([]string(nil), registry.names...)
+}
+
+func (registry *Registry) lookup(name string) (Job, bool) {
+	if registry == nil {
+		return Job{}, false
+	}
+	registry.jobsMutex.Lock()
+	defer registry.jobsMutex.Unlock()
+	job, exists := registry.jobs[strings.TrimSpace(name)]
+	return job, exists
+}
+
+func (registry *Registry) markRunning(name string) bool {
+	registry.jobsMutex.Lock()
+	defer registry.jobsMutex.Unlock()
+	if registry.running[name] {
+		return false
+	}
+	registry.running[name] = true
+	return true
+}
+
+func (registry *Registry) clearRunning(name string) {
+	registry.jobsMutex.Lock()
+	defer registry.jobsMutex.Unlock()
+	delete(registry.running, name)
+}
+
+func truncateJobError(runErr error) string {
+	if runErr == nil {
+		return ""
+	}
+	message := runErr.Error()
+	if len(message) > model.JobStateErrorMaxLength {
+		return message[:model.JobStateErrorMaxLength]
+	}
+	return message
+}
@@ -0,0 +1,299 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"github.com/MarkoPoloResearchLab/loopaware/internal/model"
+	"github.com/MarkoPoloResearchLab/loopaware/internal/storage"
+	"github.com/MarkoPoloResearchLab/loopaware/internal/testutil"
+)
+
+const (
+	testRegistryPollInterval = 10 * time.Millisecond
+	testRegistryJobName      = "test_job"
+)
+
+func newRegistryTestDatabase(testingT *testing.T) *gorm.DB {
+	testingT.Helper()
+	sqliteDatabase := testutil.NewSQLiteTestDatabase(testingT)
+	database, openErr := storage.OpenDatabase(sqliteDatabase.Configuration())
+	require.NoError(testingT, openErr)
+	require.NoError(testingT, storage.AutoMigrate(database))
+	return database
+}
+
+func newTestRegistry(database *gorm.DB, instanceID string, options ...RegistryOption) *Registry {
+	options = append([]RegistryOption{WithRegistryInstanceID(instanceID), WithRegistryPollInterval(testRegistryPollInterval)}, options...)
+	return NewRegistry(database, zap.NewNop(), options...)
+}
+
+func loadJobState(testingT *testing.T, database *gorm.DB, name string) model.JobState {
+	testingT.Helper()
+	var state model.JobState
+	require.NoError(testingT, database.First(&state, "name = ?", name).Error)
+	return state
+}
+
+func TestRegistryRegisterValidatesJobs(testingT *testing.T) {
+	registry := NewRegistry(nil, nil)
+	noop := func(context.Context) error { return nil }
+
+	require.ErrorIs(testingT, registry.Register(Job{Schedule: Every(time.Minute), Run: noop}), ErrInvalidJob)
+	require.ErrorIs(testingT, registry.Register(Job{Name: testRegistryJobName, Run: noop}), ErrInvalidJob)
+	require.ErrorIs(testingT, registry.Register(Job{Name: testRegistryJobName, Schedule: Every(time.Minute)}), ErrInvalidJob)
+	require.NoError(testingT, registry.Register(Job{Name: testRegistryJobName, Schedule: Every(time.Minute), Run: noop}))
+	require.ErrorIs(testingT, registry.Register(Job{Name: testRegistryJobName, Schedule: Every(time.Minute), Run: noop}), ErrDuplicateJob)
+	require.Equal(testingT, defaultJobTimeout, registry.jobs[testRegistryJobName].Timeout)
+}
+
+func TestRegistryRunsTriggeredJobAndRecordsOutcome(testingT *testing.T) {
+	database := newRegistryTestDatabase(testingT)
+	var observedMutex sync.Mutex
+	var observedErrors []error
+	registry := newTestRegistry(database, "replica-a", WithRegistryRunObserver(func(name string, duration time.Duration, runErr error) {
+		observedMutex.Lock()
+		defer observedMutex.Unlock()
+		observedErrors = append(observedErrors, runErr)
+	}))
+	var runCount int64
+	runErr := errors.New("smtp unavailable")
+	require.NoError(testingT, registry.Register(Job{
+		Name:     testRegistryJobName,
+		Schedule: Every(time.Hour),
+		Run: func(context.Context) error {
+			if atomic.AddInt64(&runCount, 1) == 1 {
+				return runErr
+			}
+			return nil
+		},
+	}))
+	registry.Start(context.Background())
+	testingT.Cleanup(registry.Stop)
+
+	_, triggerErr := registry.Trigger(context.Background(), testRegistryJobName)
+	require.NoError(testingT, triggerErr)
+	require.Eventually(testingT, func() bool {
+		return loadJobState(testingT, database, testRegistryJobName).RunCount == 1
+	}, testSchedulerTimeout, testRegistryPollInterval)
+
+	status, statusErr := registry.Job(context.Background(), testRegistryJobName)
+	require.NoError(testingT, statusErr)
+	require.False(testingT, status.Healthy)
+	require.Equal(testingT, runErr.Error(), status.LastError)
+	require.Equal(testingT, int64(1), status.FailureCount)
+	require.False(testingT, status.Running)
+	require.WithinDuration(testingT, time.Now().Add(time.Hour), status.NextRunAt, time.Minute)
+
+	_, triggerErr = registry.Trigger(context.Background(), testRegistryJobName)
+	require.NoError(testingT, triggerErr)
+	require.Eventually(testingT, func() bool {
+		return loadJobState(testingT, database, testRegistryJobName).RunCount == 2
+	}, testSchedulerTimeout, testRegistryPollInterval)
+
+	statuses, listErr := registry.Jobs(context.Background())
+	require.NoError(testingT, listErr)
+	require.Len(testingT, statuses, 1)
+	observedMutex.Lock()
+	require.Equal(testingT, []error{runErr, nil}, observedErrors)
+	observedMutex.Unlock()
+	require.True(testingT, statuses[0].Healthy)
+	require.Empty(testingT, statuses[0].LastError)
+	require.Equal(testingT, int64(1), statuses[0].FailureCount)
+	require.Equal(testingT, "@every 1h0m0s", statuses[0].Schedule)
+}
+
+func TestRegistryLeaseAllowsOneReplica(testingT *testing.T) {
+	database := newRegistryTestDatabase(testingT)
+	job := Job{Name: testRegistryJobName, Schedule: Every(time.Hour), Timeout: time.Minute, Run: func(context.Context) error { return nil }}
+	firstReplica := newTestRegistry(database, "replica-a")
+	secondReplica := newTestRegistry(database, "replica-b")
+	require.NoError(testingT, firstReplica.Register(job))
+	require.NoError(testingT, secondReplica.Register(job))
+
+	_, triggerErr := secondReplica.Trigger(context.Background(), testRegistryJobName)
+	require.NoError(testingT, triggerErr)
+
+	now := time.Now()
+	acquired, acquireErr := firstReplica.acquire(context.Background(), firstReplica.jobs[testRegistryJobName], now)
+	require.NoError(testingT, acquireErr)
+	require.True(testingT, acquired)
+	acquired, acquireErr = secondReplica.acquire(context.Background(), secondReplica.jobs[testRegistryJobName], now)
+	require.NoError(testingT, acquireErr)
+	require.False(testingT, acquired)
+
+	status, statusErr := secondReplica.Job(context.Background(), testRegistryJobName)
+	require.NoError(testingT, statusErr)
+	require.True(testingT, status.Running)
+	require.Equal(testingT, "replica-a", status.LeaseOwner)
+
+	require.NoError(testingT, database.Model(&model.JobState{}).Where("name = ?", testRegistryJobName).
+		Updates(map[string]any{"trigger_requested": true}).Error)
+	acquired, acquireErr = secondReplica.acquire(context.Background(), secondReplica.jobs[testRegistryJobName], now.Add(2*time.Minute))
+	require.NoError(testingT, acquireErr)
+	require.True(testingT, acquired, "an expired lease can be taken over")
+}
+
+func TestRegistryPauseSkipsScheduledRunsButNotTriggers(testingT *testing.T) {
+	database := newRegistryTestDatabase(testingT)
+	registry := newTestRegistry(database, "replica-a")
+	var runCount int64
+	require.NoError(testingT, registry.Register(Job{
+		Name:     testRegistryJobName,
+		Schedule: Every(time.Hour),
+		Run: func(context.Context) error {
+			atomic.AddInt64(&runCount, 1)
+			return nil
+		},
+	}))
+
+	status, pauseErr := registry.Pause(context.Background(), testRegistryJobName)
+	require.NoError(testingT, pauseErr)
+	require.True(testingT, status.Paused)
+	require.NoError(testingT, database.Model(&model.JobState{}).Where("name = ?", testRegistryJobName).
+		Update("next_run_at", time.Now().Add(-time.Minute)).Error)
+
+	registry.poll(context.Background())
+	registry.runs.Wait()
+	require.Zero(testingT, atomic.LoadInt64(&runCount))
+
+	_, triggerErr := registry.Trigger(context.Background(), testRegistryJobName)
+	require.NoError(testingT, triggerErr)
+	registry.poll(context.Background())
+	registry.runs.Wait()
+	require.Equal(testingT, int64(1), atomic.LoadInt64(&runCount))
+
+	status, resumeErr := registry.Resume(context.Background(), testRegistryJobName)
+	require.NoError(testingT, resumeErr)
+	require.False(testingT, status.Paused)
+	require.True(testingT, status.NextRunAt.After(time.Now()))
+
+	_, unknownErr := registry.Pause(context.Background(), "missing")
+	require.ErrorIs(testingT, unknownErr, ErrJobNotFound)
+}
+
+func TestRegistryStopReleasesLeaseWithoutRecordingRun(testingT *testing.T) {
+	database := newRegistryTestDatabase(testingT)
+	registry := newTestRegistry(database, "replica-a")
+	started := make(chan struct{})
+	require.NoError(testingT, registry.Register(Job{
+		Name:     testRegistryJobName,
+		Schedule: Every(time.Hour),
+		Run: func(ctx context.Context) error {
+			close(started)
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	}))
+	registry.Start(context.Background())
+	_, triggerErr := registry.Trigger(context.Background(), testRegistryJobName)
+	require.NoError(testingT, triggerErr)
+
+	select {
+	case <-started:
+	case <-time.After(testSchedulerTimeout):
+		testingT.Fatal("job did not start")
+	}
+	registry.Stop()
+
+	state := loadJobState(testingT, database, testRegistryJobName)
+	require.Empty(testingT, state.LeaseOwner)
+	require.Zero(testingT, state.RunCount)
+	require.Empty(testingT, state.LastError)
+	require.ErrorIs(testingT, registry.Register(Job{Name: "late", Schedule: Every(time.Hour), Run: func(context.Context) error { return nil }}), ErrRegistryStarted)
+}
+
+func TestRegistryReschedulesChangedSchedules(testingT *testing.T) {
+	database := newRegistryTestDatabase(testingT)
+	noop := func(context.Context) error { return nil }
+	registry := newTestRegistry(database, "replica-a")
+	require.NoError(testingT, registry.Register(Job{Name: testRegistryJobName, Schedule: Every(24 * time.Hour), Run: noop}))
+	_, listErr := registry.Jobs(context.Background())
+	require.NoError(testingT, listErr)
+
+	daily, parseErr := ParseSchedule("15 0 * * *")
+	require.NoError(testingT, parseErr)
+	rescheduled := newTestRegistry(database, "replica-a")
+	require.NoError(testingT, rescheduled.Register(Job{Name: testRegistryJobName, Schedule: daily, Run: noop}))
+	status, statusErr := rescheduled.Job(context.Background(), testRegistryJobName)
+	require.NoError(testingT, statusErr)
+	require.Equal(testingT, "15 0 * * *", status.Schedule)
+	require.Equal(testingT, daily.Next(time.Now()), status.NextRunAt.UTC())
+	require.Equal(testingT, "15 0 * * *", loadJobState(testingT, database, testRegistryJobName).Schedule)
+}
+
+func TestRegistryReportsPanicsAndOverdueJobs(testingT *testing.T) {
+	database := newRegistryTestDatabase(testingT)
+	registry := newTestRegistry(database, "replica-a")
+	require.NoError(testingT, registry.Register(Job{
+		Name:     testRegistryJobName,
+		Schedule: Every(time.Hour),
+		Run:      func(context.Context) error { panic("boom") },
+	}))
+	_, triggerErr := registry.Trigger(context.Background(), testRegistryJobName)
+	require.NoError(testingT, triggerErr)
+	registry.poll(context.Background())
+	registry.runs.Wait()
+	require.Equal(testingT, "job panicked: boom", loadJobState(testingT, database, testRegistryJobName).LastError)
+
+	require.NoError(testingT, database.Model(&model.JobState{}).Where("name = ?", testRegistryJobName).
+		Updates(map[string]any{"last_error": "", "next_run_at": time.Now().Add(-time.Hour)}).Error)
+	status, statusErr := registry.Job(context.Background(), testRegistryJobName)
+	require.NoError(testingT, statusErr)
+	require.False(testingT, status.Healthy)
+}
+
+func TestRegistryTracesJobRuns(testingT *testing.T) {
+	previousProvider := otel.GetTracerProvider()
+	spanRecorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spanRecorder)))
+	testingT.Cleanup(func() {
+		otel.SetTracerProvider(previousProvider)
+	})
+
+	database := newRegistryTestDatabase(testingT)
+	registry := newTestRegistry(database, "replica-a")
+	var jobSpanValid atomic.Bool
+	require.NoError(testingT, registry.Register(Job{
+		Name:     testRegistryJobName,
+		Schedule: Every(time.Hour),
+		Run: func(ctx context.Context) error {
+			jobSpanValid.Store(trace.SpanContextFromContext(ctx).IsValid())
+			return errors.New("digest failed")
+		},
+	}))
+	registry.Start(context.Background())
+	testingT.Cleanup(registry.Stop)
+
+	_, triggerErr := registry.Trigger(context.Background(), testRegistryJobName)
+	require.NoError(testingT, triggerErr)
+	require.Eventually(testingT, func() bool {
+		for _, span := range spanRecorder.Ended() {
+			if span.Name() == "job "+testRegistryJobName {
+				return true
+			}
+		}
+		return false
+	}, testSchedulerTimeout, testRegistryPollInterval)
+
+	require.True(testingT, jobSpanValid.Load())
+	for _, span := range spanRecorder.Ended() {
+		if span.Name() == "job "+testRegistryJobName {
+			require.Equal(testingT, codes.Error, span.Status().Code)
+			require.Equal(testingT, "digest failed", span.Status().Description)
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package task
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidSchedule reports a schedule expression that is neither a cron expression nor an interval.
+var ErrInvalidSchedule = errors.New("invalid_schedule")
+
+const maxScheduleSearchYears = 5
+
+var scheduleDescriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+var monthNames = map[string]int{
+	"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+	"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+}
+
+var weekdayNames = map[string]int{
+	"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+}
+
+// Schedule computes when a job should run next.
+type Schedule interface {
+	// Next returns the first run time strictly after the given time, or the zero time when there is none.
+	Next(after time.Time) time.Time
+	String() string
+}
+
+type intervalSchedule struct {
+	interval time.Duration
+}
+
+// Every returns a schedule that runs a fixed interval after the previous run; non-positive intervals become one minute.
+func Every(interval time.Duration) Schedule {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	return intervalSchedule{interval: interval}
+}
+
+func (schedule intervalSchedule) Next(after time.Time) time.Time {
+	return after.Add(schedule.interval)
+}
+
+func (schedule intervalSchedule) String() string {
+	return "@every " + schedule.interval.String()
+}
+
+// cronSchedule matches the standard five cron fields in UTC. Each field is a bit set of allowed values.
+type cronSchedule struct {
+	expression            string
+	minutes               uint64
+	hours                 uint64
+	daysOfMonth           uint64
+	months                uint64
+	daysOfWeek            uint64
+	daysOfMonthRestricted bool
+	daysOfWeekRestricted  bool
+}
+
+// ParseSchedule parses "@every <duration>", the @hourly/@daily/@weekly/@monthly/@yearly descriptors, or a
+// five-field cron expression (minute hour day-of-month month day-of-week) evaluated in UTC. Fields accept
+// "*", values, ranges, lists, steps, and month or weekday names. As in cron, a run matches either restricted
+// day field when both day-of-month and day-of-week are restricted.
+func ParseSchedule(expression string) (Schedule, error) {
+	trimmed := strings.TrimSpace(expression)
+	if strings.HasPrefix(trimmed, "@every ") {
+		interval, parseErr := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(trimmed, "@every ")))
+		if parseErr != nil || interval < time.Second {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidSchedule, expression)
+		}
+		return Every(interval), nil
+	}
+	fieldsExpression := trimmed
+	if descriptor, exists := scheduleDescriptors[strings.ToLower(trimmed)]; exists {
+		fieldsExpression = descriptor
+	}
+	fields := strings.Fields(fieldsExpression)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("%w: %q needs five fields", ErrInvalidSchedule, expression)
+	}
+
+	schedule := cronSchedule{expression: trimmed}
+	var parseErr error
+	if schedule.minutes, parseErr = parseCronField(fields[0], 0, 59, nil); parseErr != nil {
+		return nil, fmt.Errorf("%w: minute: %v", ErrInvalidSchedule, parseErr)
+	}
+	if schedule.hours, parseErr = parseCronField(fields[1], 0, 23, nil); parseErr != nil {
+		return nil, fmt.Errorf("%w: hour: %v", ErrInvalidSchedule, parseErr)
+	}
+	if schedule.daysOfMonth, parseErr = parseCronField(fields[2], 1, 31, nil); parseErr != nil {
+		return nil, fmt.Errorf("%w: day of month: %v", ErrInvalidSchedule, parseErr)
+	}
+	if schedule.months, parseErr = parseCronField(fields[3], 1, 12, monthNames); parseErr != nil {
+		return nil, fmt.Errorf("%w: month: %v", ErrInvalidSchedule, parseErr)
+	}
+	if schedule.daysOfWeek, parseErr = parseCronField(fields[4], 0, 7, weekdayNames); parseErr != nil {
+		return nil, fmt.Errorf("%w: day of week: %v", ErrInvalidSchedule, parseErr)
+	}
+	if schedule.daysOfWeek&(1<<7) != 0 {
+		schedule.daysOfWeek |= 1
+	}
+	schedule.daysOfMonthRestricted = !strings.HasPrefix(fields[2], "*")
+	schedule.daysOfWeekRestricted = !strings.HasPrefix(fields[4], "*")
+
+	if schedule.Next(time.Now()).IsZero() {
+		return nil, fmt.Errorf("%w: %q never matches", ErrInvalidSchedule, expression)
+	}
+	return schedule, nil
+}
+
+func parseCronField(field string, minimum int, maximum int, names map[string]int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			parsedStep, stepErr := strconv.Atoi(stepPart)
+			if stepErr != nil || parsedStep <= 0 {
+				return 0, fmt.Errorf("invalid step %q", part)
+			}
+			step = parsedStep
+		}
+
+		start, end := minimum, maximum
+		switch {
+		case rangePart == "*":
+		case strings.Contains(rangePart, "-"):
+			startValue, endValue, _ := strings.Cut(rangePart, "-")
+			var startErr, endErr error
+			start, startErr = parseCronValue(startValue, minimum, maximum, names)
+			end, endErr = parseCronValue(endValue, minimum, maximum, names)
+			if startErr != nil || endErr != nil || start > end {
+				return 0, fmt.Errorf("invalid range %q", part)
+			}
+		default:
+			value, valueErr := parseCronValue(rangePart, minimum, maximum, names)
+			if valueErr != nil {
+				return 0, valueErr
+			}
+			start = value
+			if !hasStep {
+				end = value
+			}
+		}
+		for value := start; value <= end; value += step {
+			bits |= 1 << uint(value)
+		}
+	}
+	return bits, nil
+}
+
+func parseCronValue(value string, minimum int, maximum int, names map[string]int) (int, error) {
+	if named, exists := names[strings.ToLower(value)]; exists {
+		return named, nil
+	}
+	parsed, parseErr := strconv.Atoi(value)
+	if parseErr != nil || parsed < minimum || parsed > maximum {
+		return 0, fmt.Errorf("invalid value %q", value)
+	}
+	return parsed, nil
+}
+
+// Next walks forward from the following minute, skipping whole months, days, and hours that cannot match.
+func (schedule cronSchedule) Next(after time.Time) time.Time {
+	candidate := after.UTC().Truncate(time.Minute).Add(time.Minute)
+	limit := candidate.AddDate(maxScheduleSearchYears, 0, 0)
+	for candidate.Before(limit) {
+		if schedule.months&(1<<uint(candidate.Month())) == 0 {
+			candidate = time.Date(candidate.Year(), candidate.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+		if !schedule.dayMatches(candidate) {
+			candidate = time.Date(candidate.Year(), candidate.Month(), candidate.Day()+1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+		if schedule.hours&(1<<uint(candidate.Hour())) == 0 {
+			candidate = candidate.Truncate(time.Hour).Add(time.Hour)
+			continue
+		}
+		if schedule.minutes&(1<<uint(candidate.Minute())) == 0 {
+			candidate = candidate.Add(time.Minute)
+			continue
+		}
+		return candidate
+	}
+	return time.Time{}
+}
+
+func (schedule cronSchedule) dayMatches(candidate time.Time) bool {
+	dayOfMonthMatches := schedule.daysOfMonth&(1<<uint(candidate.Day())) != 0
+	dayOfWeekMatches := schedule.daysOfWeek&(1<<uint(candidate.Weekday())) != 0
+	if schedule.daysOfMonthRestricted && schedule.daysOfWeekRestricted {
+		return dayOfMonthMatches || dayOfWeekMatches
+	}
+	return dayOfMonthMatches && dayOfWeekMatches
+}
+
+func (schedule cronSchedule) String() string {
+	return schedule.expression
+}
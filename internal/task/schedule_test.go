@@ -0,0 +1,46 @@
+package task
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseScheduleComputesNextRun(testingT *testing.T) {
+	reference := time.Date(2024, time.January, 31, 10, 7, 30, 0, time.UTC) // a Wednesday
+	testCases := []struct {
+		name       string
+		expression string
+		expected   time.Time
+	}{
+		{name: "interval", expression: "@every 90m", expected: reference.Add(90 * time.Minute)},
+		{name: "hourly", expression: "@hourly", expected: time.Date(2024, time.January, 31, 11, 0, 0, 0, time.UTC)},
+		{name: "step", expression: "*/15 * * * *", expected: time.Date(2024, time.January, 31, 10, 15, 0, 0, time.UTC)},
+		{name: "daily", expression: "15 0 * * *", expected: time.Date(2024, time.February, 1, 0, 15, 0, 0, time.UTC)},
+		{name: "weekdayNames", expression: "0 9 * * mon-fri", expected: time.Date(2024, time.February, 1, 9, 0, 0, 0, time.UTC)},
+		{name: "sundayAsSeven", expression: "0 0 * * 7", expected: time.Date(2024, time.February, 4, 0, 0, 0, 0, time.UTC)},
+		{name: "leapDay", expression: "30 6 29 feb *", expected: time.Date(2024, time.February, 29, 6, 30, 0, 0, time.UTC)},
+		{name: "dayOfMonthOrWeekday", expression: "0 12 15 * sat", expected: time.Date(2024, time.February, 3, 12, 0, 0, 0, time.UTC)},
+		{name: "list", expression: "5,50 10 * * *", expected: time.Date(2024, time.January, 31, 10, 50, 0, 0, time.UTC)},
+	}
+
+	for _, testCase := range testCases {
+		testingT.Run(testCase.name, func(nestedT *testing.T) {
+			schedule, parseErr := ParseSchedule(testCase.expression)
+			require.NoError(nestedT, parseErr)
+			require.Equal(nestedT, testCase.expected, schedule.Next(reference))
+		})
+	}
+}
+
+func TestParseScheduleRejectsInvalidExpressions(testingT *testing.T) {
+	for _, expression := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "*/0 * * * *", "5-1 * * * *", "0 0 30 2 *", "@every 10ms", "@every soon", "@fortnightly"} {
+		_, parseErr := ParseSchedule(expression)
+		require.ErrorIs(testingT, parseErr, ErrInvalidSchedule, expression)
+	}
+}
+
+func TestEveryDefaultsInterval(testingT *testing.T) {
+	require.Equal(testingT, "@every 1m0s", Every(0).String())
+}
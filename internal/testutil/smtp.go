@@ -0,0 +1,257 @@
+package testutil
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"math/big"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	// SMTPModePlain accepts plain connections and does not offer STARTTLS.
+	SMTPModePlain = "plain"
+	// SMTPModeStartTLS accepts plain connections and offers STARTTLS.
+	SMTPModeStartTLS = "starttls"
+	// SMTPModeTLS accepts implicit TLS connections.
+	SMTPModeTLS = "tls"
+
+	smtpTestServerHost = "127.0.0.1"
+)
+
+// SMTPTestMessage is a message accepted by an SMTPTestServer.
+type SMTPTestMessage struct {
+	From     string
+	To       []string
+	Data     string
+	Username string
+	Password string
+	TLS      bool
+}
+
+// SMTPTestServer is an in-process SMTP stand-in that records delivered messages.
+type SMTPTestServer struct {
+	Host string
+	Port int
+
+	listener     net.Listener
+	tlsConfig    *tls.Config
+	clientConfig *tls.Config
+	mode         string
+	username     string
+	password     string
+
+	mutex    sync.Mutex
+	messages []SMTPTestMessage
+}
+
+// NewSMTPTestServer starts an SMTP stand-in in the given mode; when username is set, AUTH PLAIN is required.
+func NewSMTPTestServer(testingT *testing.T, mode string, username string, password string) *SMTPTestServer {
+	testingT.Helper()
+
+	serverTLSConfig, clientTLSConfig := newLoopbackTLSConfigs(testingT)
+	listener, listenErr := net.Listen("tcp", net.JoinHostPort(smtpTestServerHost, "0"))
+	if listenErr != nil {
+		testingT.Fatalf("listen for smtp: %v", listenErr)
+	}
+	if mode == SMTPModeTLS {
+		listener = tls.NewListener(listener, serverTLSConfig)
+	}
+
+	server := &SMTPTestServer{
+		Host:         smtpTestServerHost,
+		Port:         listener.Addr().(*net.TCPAddr).Port,
+		listener:     listener,
+		tlsConfig:    serverTLSConfig,
+		clientConfig: clientTLSConfig,
+		mode:         mode,
+		username:     username,
+		password:     password,
+	}
+	go server.serve()
+	testingT.Cleanup(func() {
+		_ = listener.Close()
+	})
+	return server
+}
+
+// ClientTLSConfig returns a TLS configuration that trusts the server certificate.
+func (server *SMTPTestServer) ClientTLSConfig() *tls.Config {
+	return server.clientConfig.Clone()
+}
+
+// Messages returns the messages accepted so far.
+func (server *SMTPTestServer) Messages() []SMTPTestMessage {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	return append([]SMTPTestMessage(nil), server.messages...)
+}
+
+func (server *SMTPTestServer) serve() {
+	for {
+		connection, acceptErr := server.listener.Accept()
+		if acceptErr != nil {
+			return
+		}
+		go server.handle(connection)
+	}
+}
+
+func (server *SMTPTestServer) handle(connection net.Conn) {
+	defer connection.Close()
+	_ = connection.SetDeadline(time.Now().Add(10 * time.Second))
+
+	session := SMTPTestMessage{TLS: server.mode == SMTPModeTLS}
+	authenticated := server.username == ""
+	reader := bufio.NewReader(connection)
+	writer := bufio.NewWriter(connection)
+	reply := func(lines ...string) {
+		for _, line := range lines {
+			_, _ = writer.WriteString(line + "\r\n")
+		}
+		_ = writer.Flush()
+	}
+
+	reply("220 " + smtpTestServerHost + " ESMTP loopaware-test")
+	for {
+		line, readErr := reader.ReadString('\n')
+		if readErr != nil {
+			return
+		}
+		command, argument, _ := strings.Cut(strings.TrimRight(line, "\r\n"), " ")
+		switch strings.ToUpper(command) {
+		case "EHLO", "HELO":
+			capabilities := []string{"250-" + smtpTestServerHost}
+			if server.mode == SMTPModeStartTLS && !session.TLS {
+				capabilities = append(capabilities, "250-STARTTLS")
+			}
+			if server.username != "" {
+				capabilities = append(capabilities, "250-AUTH PLAIN")
+			}
+			capabilities = append(capabilities, "250 8BITMIME")
+			reply(capabilities...)
+		case "STARTTLS":
+			if server.mode != SMTPModeStartTLS || session.TLS {
+				reply("502 STARTTLS not available")
+				continue
+			}
+			reply("220 ready to start TLS")
+			tlsConnection := tls.Server(connection, server.tlsConfig)
+			if handshakeErr := tlsConnection.Handshake(); handshakeErr != nil {
+				return
+			}
+			connection = tlsConnection
+			reader = bufio.NewReader(connection)
+			writer = bufio.NewWriter(connection)
+			session.TLS = true
+		case "AUTH":
+			mechanism, encoded, _ := strings.Cut(argument, " ")
+			if !strings.EqualFold(mechanism, "PLAIN") {
+				reply("504 unsupported mechanism")
+				continue
+			}
+			decoded, decodeErr := base64.StdEncoding.DecodeString(encoded)
+			fields := strings.Split(string(decoded), "\x00")
+			if decodeErr != nil || len(fields) != 3 || fields[1] != server.username || fields[2] != server.password {
+				reply("535 authentication failed")
+				continue
+			}
+			session.Username = fields[1]
+			session.Password = fields[2]
+			authenticated = true
+			reply("235 authenticated")
+		case "MAIL":
+			if !authenticated {
+				reply("530 authentication required")
+				continue
+			}
+			session.From = extractSMTPPath(argument)
+			session.To = nil
+			reply("250 ok")
+		case "RCPT":
+			session.To = append(session.To, extractSMTPPath(argument))
+			reply("250 ok")
+		case "DATA":
+			reply("354 end data with <CR><LF>.<CR><LF>")
+			data := &strings.Builder{}
+			for {
+				dataLine, dataErr := reader.ReadString('\n')
+				if dataErr != nil {
+					return
+				}
+				if dataLine == ".\r\n" {
+					break
+				}
+				data.WriteString(strings.TrimPrefix(dataLine, "."))
+			}
+			session.Data = data.String()
+			server.mutex.Lock()
+			server.messages = append(server.messages, session)
+			server.mutex.Unlock()
+			reply("250 queued")
+		case "RSET", "NOOP":
+			reply("250 ok")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("502 command not implemented")
+		}
+	}
+}
+
+func extractSMTPPath(argument string) string {
+	_, path, _ := strings.Cut(argument, ":")
+	path = strings.TrimSpace(path)
+	if separatorIndex := strings.Index(path, " "); separatorIndex >= 0 {
+		path = path[:separatorIndex]
+	}
+	return strings.Trim(path, "<>")
+}
+
+func newLoopbackTLSConfigs(testingT *testing.T) (*tls.Config, *tls.Config) {
+	testingT.Helper()
+
+	privateKey, keyErr := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if keyErr != nil {
+		testingT.Fatalf("generate key: %v", keyErr)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: smtpTestServerHost},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		IPAddresses:           []net.IP{net.ParseIP(smtpTestServerHost)},
+		DNSNames:              []string{"localhost"},
+	}
+	certificateBytes, certificateErr := x509.CreateCertificate(rand.Reader, template, template, &privateKey.PublicKey, privateKey)
+	if certificateErr != nil {
+		testingT.Fatalf("create certificate: %v", certificateErr)
+	}
+	certificate, parseErr := x509.ParseCertificate(certificateBytes)
+	if parseErr != nil {
+		testingT.Fatalf("parse certificate: %v", parseErr)
+	}
+
+	rootPool := x509.NewCertPool()
+	rootPool.AddCert(certificate)
+	serverConfig := &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{certificateBytes}, PrivateKey: privateKey, Leaf: certificate}},
+		MinVersion:   tls.VersionTLS12,
+	}
+	clientConfig := &tls.Config{RootCAs: rootPool, ServerName: smtpTestServerHost, MinVersion: tls.VersionTLS12}
+	return serverConfig, clientConfig
+}
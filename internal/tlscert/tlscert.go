@@ -0,0 +1,139 @@
+// Package tlscert serves a TLS certificate and key pair from files and reloads them when either file changes, so
+// renewed certificates take effect without a restart.
+package tlscert
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// DefaultCheckInterval is how often Run looks for changed certificate files.
+const DefaultCheckInterval = 30 * time.Second
+
+// ErrMissingFiles reports a reloader created without both a certificate and a key file.
+var ErrMissingFiles = errors.New("tls certificate and key files are required")
+
+type fileVersion struct {
+	modTime time.Time
+	size    int64
+}
+
+func sameVersions(left [2]fileVersion, right [2]fileVersion) bool {
+	for index := range left {
+		if !left[index].modTime.Equal(right[index].modTime) || left[index].size != right[index].size {
+			return false
+		}
+	}
+	return true
+}
+
+// Reloader holds the current certificate for a certificate and key file pair.
+type Reloader struct {
+	certificateFile string
+	keyFile         string
+	logger          *zap.Logger
+
+	mutex       sync.RWMutex
+	certificate *tls.Certificate
+	versions    [2]fileVersion
+}
+
+// NewReloader loads the pair once and fails when it cannot, so a misconfigured server does not start.
+func NewReloader(certificateFile string, keyFile string, logger *zap.Logger) (*Reloader, error) {
+	if certificateFile == "" || keyFile == "" {
+		return nil, ErrMissingFiles
+	}
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	reloader := &Reloader{certificateFile: certificateFile, keyFile: keyFile, logger: logger}
+	if _, reloadErr := reloader.Reload(); reloadErr != nil {
+		return nil, reloadErr
+	}
+	return reloader, nil
+}
+
+// TLSConfig returns a server configuration that serves the current certificate and offers HTTP/2.
+func (reloader *Reloader) TLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		NextProtos:     []string{"h2", "http/1.1"},
+		GetCertificate: reloader.GetCertificate,
+	}
+}
+
+// GetCertificate returns the most recently loaded certificate.
+func (reloader *Reloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	reloader.mutex.RLock()
+	defer reloader.mutex.RUnlock()
+	return reloader.certificate, nil
+}
+
+// Reload loads the pair again when either file's modification time or size changed and reports whether it did. A
+// pair that fails to load leaves the previous certificate in place.
+func (reloader *Reloader) Reload() (bool, error) {
+	versions, statErr := reloader.currentVersions()
+	if statErr != nil {
+		return false, statErr
+	}
+	reloader.mutex.RLock()
+	unchanged := reloader.certificate != nil && sameVersions(versions, reloader.versions)
+	reloader.mutex.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	certificate, loadErr := tls.LoadX509KeyPair(reloader.certificateFile, reloader.keyFile)
+	if loadErr != nil {
+		return false, fmt.Errorf("load tls certificate: %w", loadErr)
+	}
+	reloader.mutex.Lock()
+	reloader.certificate = &certificate
+	reloader.versions = versions
+	reloader.mutex.Unlock()
+
+	fields := []zap.Field{zap.String("certificate_file", reloader.certificateFile)}
+	if certificate.Leaf != nil {
+		fields = append(fields, zap.Time("not_after", certificate.Leaf.NotAfter))
+	}
+	reloader.logger.Info("tls_certificate_loaded", fields...)
+	return true, nil
+}
+
+// Run checks the files every interval until ctx is done. Failed reloads are logged and retried on the next tick.
+func (reloader *Reloader) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultCheckInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, reloadErr := reloader.Reload(); reloadErr != nil {
+				reloader.logger.Warn("tls_certificate_reload_failed", zap.Error(reloadErr))
+			}
+		}
+	}
+}
+
+func (reloader *Reloader) currentVersions() ([2]fileVersion, error) {
+	var versions [2]fileVersion
+	for index, path := range []string{reloader.certificateFile, reloader.keyFile} {
+		info, statErr := os.Stat(path)
+		if statErr != nil {
+			return versions, fmt.Errorf("stat tls file: %w", statErr)
+		}
+		versions[index] = fileVersion{modTime: info.ModTime(), size: info.Size()}
+	}
+	return versions, nil
+}
@@ -0,0 +1,94 @@
+package tlscert
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writeCertificatePair(testingT *testing.T, directory string, commonName string, modTime time.Time) (string, string) {
+	testingT.Helper()
+	privateKey, keyErr := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(testingT, keyErr)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		DNSNames:     []string{commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	certificateDER, certificateErr := x509.CreateCertificate(rand.Reader, template, template, &privateKey.PublicKey, privateKey)
+	require.NoError(testingT, certificateErr)
+	keyDER, marshalErr := x509.MarshalECPrivateKey(privateKey)
+	require.NoError(testingT, marshalErr)
+
+	certificateFile := filepath.Join(directory, "tls.crt")
+	keyFile := filepath.Join(directory, "tls.key")
+	require.NoError(testingT, os.WriteFile(certificateFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificateDER}), 0o600))
+	require.NoError(testingT, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	require.NoError(testingT, os.Chtimes(certificateFile, modTime, modTime))
+	require.NoError(testingT, os.Chtimes(keyFile, modTime, modTime))
+	return certificateFile, keyFile
+}
+
+func servedCommonName(testingT *testing.T, reloader *Reloader) string {
+	testingT.Helper()
+	certificate, getErr := reloader.GetCertificate(&tls.ClientHelloInfo{})
+	require.NoError(testingT, getErr)
+	leaf, parseErr := x509.ParseCertificate(certificate.Certificate[0])
+	require.NoError(testingT, parseErr)
+	return leaf.Subject.CommonName
+}
+
+func TestNewReloaderRequiresLoadablePair(testingT *testing.T) {
+	_, missingErr := NewReloader("", "tls.key", nil)
+	require.ErrorIs(testingT, missingErr, ErrMissingFiles)
+
+	directory := testingT.TempDir()
+	_, statErr := NewReloader(filepath.Join(directory, "absent.crt"), filepath.Join(directory, "absent.key"), nil)
+	require.Error(testingT, statErr)
+
+	certificateFile, keyFile := writeCertificatePair(testingT, directory, "one.example", time.Now())
+	reloader, newErr := NewReloader(certificateFile, keyFile, nil)
+	require.NoError(testingT, newErr)
+	require.Equal(testingT, "one.example", servedCommonName(testingT, reloader))
+
+	tlsConfig := reloader.TLSConfig()
+	require.Contains(testingT, tlsConfig.NextProtos, "h2")
+	require.Equal(testingT, uint16(tls.VersionTLS12), tlsConfig.MinVersion)
+}
+
+func TestReloadPicksUpChangedFilesAndKeepsCertificateOnFailure(testingT *testing.T) {
+	directory := testingT.TempDir()
+	firstModTime := time.Now().Add(-time.Minute)
+	certificateFile, keyFile := writeCertificatePair(testingT, directory, "one.example", firstModTime)
+	reloader, newErr := NewReloader(certificateFile, keyFile, nil)
+	require.NoError(testingT, newErr)
+
+	reloaded, reloadErr := reloader.Reload()
+	require.NoError(testingT, reloadErr)
+	require.False(testingT, reloaded)
+
+	writeCertificatePair(testingT, directory, "two.example", firstModTime.Add(30*time.Second))
+	reloaded, reloadErr = reloader.Reload()
+	require.NoError(testingT, reloadErr)
+	require.True(testingT, reloaded)
+	require.Equal(testingT, "two.example", servedCommonName(testingT, reloader))
+
+	require.NoError(testingT, os.WriteFile(keyFile, []byte("not a key"), 0o600))
+	reloaded, reloadErr = reloader.Reload()
+	require.Error(testingT, reloadErr)
+	require.False(testingT, reloaded)
+	require.Equal(testingT, "two.example", servedCommonName(testingT, reloader))
+}
@@ -0,0 +1,97 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+const (
+	gormPluginName           = "loopaware:tracing"
+	gormParentContextKey     = "loopaware:tracing:parent_context"
+	gormCallbackBeforeSuffix = ":before"
+	gormCallbackAfterSuffix  = ":after"
+)
+
+// GormPlugin starts one client span per GORM create, query, update, delete, row, and raw operation, as a child of
+// the span in the statement context. The span carries the SQL with placeholders, never the bound values.
+type GormPlugin struct{}
+
+// Name identifies the plugin to gorm.DB.Use.
+func (GormPlugin) Name() string {
+	return gormPluginName
+}
+
+// Initialize registers the span callbacks around every GORM operation.
+func (GormPlugin) Initialize(database *gorm.DB) error {
+	callbacks := database.Callback()
+	return errors.Join(
+		callbacks.Create().Before("gorm:create").Register(gormCallbackName("create", gormCallbackBeforeSuffix), startGormSpan("create")),
+		callbacks.Create().After("gorm:create").Register(gormCallbackName("create", gormCallbackAfterSuffix), endGormSpan),
+		callbacks.Query().Before("gorm:query").Register(gormCallbackName("query", gormCallbackBeforeSuffix), startGormSpan("query")),
+		callbacks.Query().After("gorm:query").Register(gormCallbackName("query", gormCallbackAfterSuffix), endGormSpan),
+		callbacks.Update().Before("gorm:update").Register(gormCallbackName("update", gormCallbackBeforeSuffix), startGormSpan("update")),
+		callbacks.Update().After("gorm:update").Register(gormCallbackName("update", gormCallbackAfterSuffix), endGormSpan),
+		callbacks.Delete().Before("gorm:delete").Register(gormCallbackName("delete", gormCallbackBeforeSuffix), startGormSpan("delete")),
+		callbacks.Delete().After("gorm:delete").Register(gormCallbackName("delete", gormCallbackAfterSuffix), endGormSpan),
+		callbacks.Row().Before("gorm:row").Register(gormCallbackName("row", gormCallbackBeforeSuffix), startGormSpan("row")),
+		callbacks.Row().After("gorm:row").Register(gormCallbackName("row", gormCallbackAfterSuffix), endGormSpan),
+		callbacks.Raw().Before("gorm:raw").Register(gormCallbackName("raw", gormCallbackBeforeSuffix), startGormSpan("raw")),
+		callbacks.Raw().After("gorm:raw").Register(gormCallbackName("raw", gormCallbackAfterSuffix), endGormSpan),
+	)
+}
+
+func gormCallbackName(operation string, suffix string) string {
+	return gormPluginName + ":" + operation + suffix
+}
+
+func startGormSpan(operation string) func(*gorm.DB) {
+	return func(database *gorm.DB) {
+		if database.Statement == nil || database.Statement.Context == nil {
+			return
+		}
+		parentContext := database.Statement.Context
+		spanContext, _ := Tracer().Start(parentContext, "db."+operation,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				semconv.DBSystemNameKey.String(database.Dialector.Name()),
+				semconv.DBOperationName(operation),
+			),
+		)
+		database.InstanceSet(gormParentContextKey, parentContext)
+		database.Statement.Context = spanContext
+	}
+}
+
+func endGormSpan(database *gorm.DB) {
+	if database.Statement == nil || database.Statement.Context == nil {
+		return
+	}
+	parentValue, started := database.InstanceGet(gormParentContextKey)
+	if !started {
+		return
+	}
+	span := trace.SpanFromContext(database.Statement.Context)
+	if table := strings.TrimSpace(database.Statement.Table); table != "" {
+		span.SetAttributes(semconv.DBCollectionName(table))
+	}
+	if queryText := strings.TrimSpace(database.Statement.SQL.String()); queryText != "" {
+		span.SetAttributes(semconv.DBQueryText(queryText))
+	}
+	span.SetAttributes(attribute.Int64("db.rows_affected", database.Statement.RowsAffected))
+	if database.Error != nil && !errors.Is(database.Error, gorm.ErrRecordNotFound) {
+		span.RecordError(database.Error)
+		span.SetStatus(codes.Error, database.Error.Error())
+	}
+	span.End()
+	// Later operations on the same statement must not become children of the finished span.
+	if parentContext, ok := parentValue.(context.Context); ok {
+		database.Statement.Context = parentContext
+	}
+}
@@ -0,0 +1,145 @@
+// Package tracing configures OpenTelemetry tracing for the server. Tracing is optional: with the "none" exporter
+// nothing is installed and every instrumented call site records into the global no-op tracer provider.
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+const (
+	// ExporterNone disables tracing.
+	ExporterNone = "none"
+	// ExporterStdout writes finished spans as JSON to standard output.
+	ExporterStdout = "stdout"
+	// ExporterOTLPGRPC sends spans to an OTLP collector over gRPC.
+	ExporterOTLPGRPC = "otlp-grpc"
+	// ExporterOTLPHTTP sends spans to an OTLP collector over HTTP/protobuf.
+	ExporterOTLPHTTP = "otlp-http"
+
+	// DefaultServiceName is the service.name resource attribute used when none is configured.
+	DefaultServiceName = "loopaware"
+
+	instrumentationName = "github.com/MarkoPoloResearchLab/loopaware"
+)
+
+// ErrUnknownExporter reports an exporter name other than the Exporter constants.
+var ErrUnknownExporter = errors.New("unknown tracing exporter")
+
+// Config selects the span exporter. Endpoint and Insecure apply to the OTLP exporters; when Endpoint is empty the
+// exporter falls back to the standard OTEL_EXPORTER_OTLP_* environment variables. Sampling follows
+// OTEL_TRACES_SAMPLER and defaults to sampling every trace that has no sampled parent.
+type Config struct {
+	Exporter    string
+	Endpoint    string
+	Insecure    bool
+	ServiceName string
+	// Writer receives stdout exporter output; os.Stdout when nil.
+	Writer io.Writer
+}
+
+// ValidExporter reports whether name is one of the supported exporters. An empty name means ExporterNone.
+func ValidExporter(name string) bool {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", ExporterNone, ExporterStdout, ExporterOTLPGRPC, ExporterOTLPHTTP:
+		return true
+	default:
+		return false
+	}
+}
+
+// Setup installs a global tracer provider and the W3C trace context and baggage propagators for config. The
+// returned function flushes buffered spans and shuts the provider down; it is a no-op when tracing is disabled.
+func Setup(ctx context.Context, config Config) (func(context.Context) error, error) {
+	exporterName := strings.ToLower(strings.TrimSpace(config.Exporter))
+	if exporterName == "" || exporterName == ExporterNone {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, exporterErr := newExporter(ctx, exporterName, config)
+	if exporterErr != nil {
+		return nil, exporterErr
+	}
+
+	serviceName := strings.TrimSpace(config.ServiceName)
+	if serviceName == "" {
+		serviceName = DefaultServiceName
+	}
+	serviceResource, resourceErr := resource.Merge(resource.Default(), resource.NewSchemaless(semconv.ServiceName(serviceName)))
+	if resourceErr != nil {
+		return nil, fmt.Errorf("tracing resource: %w", resourceErr)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(serviceResource),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	return provider.Shutdown, nil
+}
+
+func newExporter(ctx context.Context, exporterName string, config Config) (sdktrace.SpanExporter, error) {
+	endpoint := strings.TrimSpace(config.Endpoint)
+	switch exporterName {
+	case ExporterStdout:
+		writer := config.Writer
+		if writer == nil {
+			writer = os.Stdout
+		}
+		return stdouttrace.New(stdouttrace.WithWriter(writer))
+	case ExporterOTLPGRPC:
+		options := make([]otlptracegrpc.Option, 0, 2)
+		if endpoint != "" {
+			options = append(options, otlptracegrpc.WithEndpoint(endpoint))
+		}
+		if config.Insecure {
+			options = append(options, otlptracegrpc.WithInsecure())
+		}
+		return otlptracegrpc.New(ctx, options...)
+	case ExporterOTLPHTTP:
+		options := make([]otlptracehttp.Option, 0, 2)
+		if endpoint != "" {
+			options = append(options, otlptracehttp.WithEndpoint(endpoint))
+		}
+		if config.Insecure {
+			options = append(options, otlptracehttp.WithInsecure())
+		}
+		return otlptracehttp.New(ctx, options...)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownExporter, exporterName)
+	}
+}
+
+// Tracer returns the application tracer from the global provider, so spans follow whatever Setup installed.
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// LogFields returns the trace_id and span_id of the span in ctx as zap fields, or nothing when ctx carries no
+// valid span context.
+func LogFields(ctx context.Context) []zap.Field {
+	spanContext := trace.SpanContextFromContext(ctx)
+	if !spanContext.IsValid() {
+		return nil
+	}
+	return []zap.Field{
+		zap.String("trace_id", spanContext.TraceID().String()),
+		zap.String("span_id", spanContext.SpanID().String()),
+	}
+}
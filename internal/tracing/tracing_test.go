@@ -0,0 +1,113 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"gorm.io/gorm"
+)
+
+type tracedRecord struct {
+	ID   uint `gorm:"primaryKey"`
+	Name string
+}
+
+func installRecorder(testingT *testing.T) *tracetest.SpanRecorder {
+	testingT.Helper()
+	previous := otel.GetTracerProvider()
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	testingT.Cleanup(func() {
+		otel.SetTracerProvider(previous)
+	})
+	return recorder
+}
+
+func TestValidExporter(testingT *testing.T) {
+	for _, name := range []string{"", ExporterNone, ExporterStdout, ExporterOTLPGRPC, " OTLP-HTTP "} {
+		require.True(testingT, ValidExporter(name), name)
+	}
+	require.False(testingT, ValidExporter("jaeger"))
+}
+
+func TestSetupWithoutExporterInstallsNothing(testingT *testing.T) {
+	previous := otel.GetTracerProvider()
+	shutdown, setupErr := Setup(context.Background(), Config{Exporter: ExporterNone})
+	require.NoError(testingT, setupErr)
+	require.NoError(testingT, shutdown(context.Background()))
+	require.Equal(testingT, previous, otel.GetTracerProvider())
+
+	_, unknownErr := Setup(context.Background(), Config{Exporter: "jaeger"})
+	require.ErrorIs(testingT, unknownErr, ErrUnknownExporter)
+}
+
+func TestSetupStdoutExportsSpansOnShutdown(testingT *testing.T) {
+	previous := otel.GetTracerProvider()
+	testingT.Cleanup(func() {
+		otel.SetTracerProvider(previous)
+	})
+	var output bytes.Buffer
+	shutdown, setupErr := Setup(context.Background(), Config{Exporter: ExporterStdout, ServiceName: "loopaware-test", Writer: &output})
+	require.NoError(testingT, setupErr)
+
+	_, span := Tracer().Start(context.Background(), "submit_feedback")
+	span.End()
+	require.NoError(testingT, shutdown(context.Background()))
+	require.Contains(testingT, output.String(), `"Name":"submit_feedback"`)
+	require.Contains(testingT, output.String(), "loopaware-test")
+}
+
+func TestLogFieldsCarryTraceAndSpanIDs(testingT *testing.T) {
+	require.Empty(testingT, LogFields(context.Background()))
+
+	installRecorder(testingT)
+	ctx, span := Tracer().Start(context.Background(), "request")
+	defer span.End()
+	fields := LogFields(ctx)
+	require.Len(testingT, fields, 2)
+	require.Equal(testingT, "trace_id", fields[0].Key)
+	require.Equal(testingT, span.SpanContext().TraceID().String(), fields[0].String)
+	require.Equal(testingT, "span_id", fields[1].Key)
+	require.Equal(testingT, span.SpanContext().SpanID().String(), fields[1].String)
+}
+
+func TestGormPluginRecordsQuerySpansUnderRequestSpan(testingT *testing.T) {
+	recorder := installRecorder(testingT)
+	database, openErr := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	require.NoError(testingT, openErr)
+	require.NoError(testingT, database.AutoMigrate(&tracedRecord{}))
+	require.NoError(testingT, database.Use(GormPlugin{}))
+
+	ctx, parent := Tracer().Start(context.Background(), "request")
+	session := database.WithContext(ctx)
+	require.NoError(testingT, session.Create(&tracedRecord{Name: "secret@example.com"}).Error)
+	var loaded tracedRecord
+	require.NoError(testingT, session.First(&loaded, "name = ?", "secret@example.com").Error)
+	parent.End()
+
+	var databaseSpans []sdktrace.ReadOnlySpan
+	for _, span := range recorder.Ended() {
+		if span.Name() == "db.create" || span.Name() == "db.query" {
+			databaseSpans = append(databaseSpans, span)
+		}
+	}
+	require.Len(testingT, databaseSpans, 2)
+	for _, span := range databaseSpans {
+		require.Equal(testingT, parent.SpanContext().SpanID(), span.Parent().SpanID())
+		attributes := make(map[string]string)
+		for _, attribute := range span.Attributes() {
+			attributes[string(attribute.Key)] = attribute.Value.Emit()
+		}
+		require.Equal(testingT, "sqlite", attributes[string(semconv.DBSystemNameKey)])
+		require.Equal(testingT, "traced_records", attributes["db.collection.name"])
+		require.Contains(testingT, attributes["db.query.text"], "traced_records")
+		require.NotContains(testingT, attributes["db.query.text"], "secret@example.com")
+	}
+}
@@ -0,0 +1,304 @@
+// Package egress builds HTTP clients for fetching user-supplied URLs without letting them reach internal networks.
+package egress
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"syscall"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	defaultRequestTimeout = 5 * time.Second
+	defaultDialTimeout    = 3 * time.Second
+	defaultMaxRedirects   = 3
+	auditLogMessage       = "egress_blocked"
+)
+
+var (
+	// ErrBlockedAddress reports a connection attempt to an address the policy denies.
+	ErrBlockedAddress = errors.New("egress_blocked_address")
+	// ErrBlockedScheme reports a request or redirect to a scheme other than http or https.
+	ErrBlockedScheme = errors.New("egress_blocked_scheme")
+	// ErrTooManyRedirects reports a redirect chain longer than the configured cap.
+	ErrTooManyRedirects = errors.New("egress_too_many_redirects")
+)
+
+// deniedNetworks lists special-purpose ranges that are never public destinations, on top of the
+// loopback, private, link-local, multicast and unspecified checks netip already provides.
+var deniedNetworks = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("192.0.2.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("198.51.100.0/24"),
+	netip.MustParsePrefix("203.0.113.0/24"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+	netip.MustParsePrefix("64:ff9b:1::/48"),
+	netip.MustParsePrefix("2001:db8::/32"),
+	netip.MustParsePrefix("2002::/16"),
+}
+
+// BlockedError describes a fetch the policy refused.
+type BlockedError struct {
+	Reason  error
+	Address string
+}
+
+func (blockedErr *BlockedError) Error() string {
+	if blockedErr.Address == "" {
+		return blockedErr.Reason.Error()
+	}
+	return fmt.Sprintf("%s: %s", blockedErr.Reason.Error(), blockedErr.Address)
+}
+
+func (blockedErr *BlockedError) Unwrap() error {
+	return blockedErr.Reason
+}
+
+// Option customizes a Client.
+type Option func(*config)
+
+type config struct {
+	logger          *zap.Logger
+	allowedNetworks []netip.Prefix
+	deniedNetworks  []netip.Prefix
+	requestTimeout  time.Duration
+	dialTimeout     time.Duration
+	hostTimeouts    map[string]time.Duration
+	maxRedirects    int
+}
+
+// WithLogger sets the logger that records blocked fetches.
+func WithLogger(logger *zap.Logger) Option {
+	return func(clientConfig *config) {
+		if logger != nil {
+			clientConfig.logger = logger
+		}
+	}
+}
+
+// WithAllowedNetworks lets connections reach the given ranges even when the default policy denies them,
+// for example an internal asset host that operators trust.
+func WithAllowedNetworks(prefixes ...netip.Prefix) Option {
+	return func(clientConfig *config) {
+		clientConfig.allowedNetworks = append(clientConfig.allowedNetworks, prefixes...)
+	}
+}
+
+// WithDeniedNetworks blocks the given ranges in addition to the default policy.
+func WithDeniedNetworks(prefixes ...netip.Prefix) Option {
+	return func(clientConfig *config) {
+		clientConfig.deniedNetworks = append(clientConfig.deniedNetworks, prefixes...)
+	}
+}
+
+// WithRequestTimeout bounds each request, including redirects and reading the body, for hosts without their own timeout.
+func WithRequestTimeout(timeout time.Duration) Option {
+	return func(clientConfig *config) {
+		if timeout > 0 {
+			clientConfig.requestTimeout = timeout
+		}
+	}
+}
+
+// WithHostTimeouts overrides the request timeout for specific host names.
+func WithHostTimeouts(hostTimeouts map[string]time.Duration) Option {
+	return func(clientConfig *config) {
+		for host, timeout := range hostTimeouts {
+			normalizedHost := strings.ToLower(strings.TrimSpace(host))
+			if normalizedHost != "" && timeout > 0 {
+				clientConfig.hostTimeouts[normalizedHost] = timeout
+			}
+		}
+	}
+}
+
+// WithMaxRedirects caps how many redirects a request follows; zero disables redirects.
+func WithMaxRedirects(maxRedirects int) Option {
+	return func(clientConfig *config) {
+		if maxRedirects >= 0 {
+			clientConfig.maxRedirects = maxRedirects
+		}
+	}
+}
+
+// NewClient returns an HTTP client that refuses to connect to loopback, private, link-local, cloud metadata
+// and other non-public addresses. The check runs on the resolved IP at dial time, so DNS names that point
+// inward and redirects to internal hosts are refused as well. Proxies from the environment are ignored
+// because they would hide the destination address from the check.
+func NewClient(options ...Option) *http.Client {
+	clientConfig := &config{
+		logger:         zap.NewNop(),
+		requestTimeout: defaultRequestTimeout,
+		dialTimeout:    defaultDialTimeout,
+		hostTimeouts:   make(map[string]time.Duration),
+		maxRedirects:   defaultMaxRedirects,
+	}
+	for _, option := range options {
+		if option != nil {
+			option(clientConfig)
+		}
+	}
+
+	policy := &addressPolicy{allowed: clientConfig.allowedNetworks, denied: clientConfig.deniedNetworks}
+	dialer := &net.Dialer{
+		Timeout: clientConfig.dialTimeout,
+		Control: policy.control,
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	guarded := &guardedTransport{config: clientConfig, next: transport}
+	return &http.Client{
+		Transport:     guarded,
+		CheckRedirect: guarded.checkRedirect,
+	}
+}
+
+type addressPolicy struct {
+	allowed []netip.Prefix
+	denied  []netip.Prefix
+}
+
+func (policy *addressPolicy) control(_ string, address string, _ syscall.RawConn) error {
+	host, _, splitErr := net.SplitHostPort(address)
+	if splitErr != nil {
+		host = address
+	}
+	ipAddress, parseErr := netip.ParseAddr(host)
+	if parseErr != nil {
+		return &BlockedError{Reason: ErrBlockedAddress, Address: address}
+	}
+	if !policy.permits(ipAddress) {
+		return &BlockedError{Reason: ErrBlockedAddress, Address: address}
+	}
+	return nil
+}
+
+func (policy *addressPolicy) permits(ipAddress netip.Addr) bool {
+	ipAddress = ipAddress.Unmap().WithZone("")
+	for _, prefix := range policy.allowed {
+		if prefix.Contains(ipAddress) {
+			return true
+		}
+	}
+	for _, prefix := range policy.denied {
+		if prefix.Contains(ipAddress) {
+			return false
+		}
+	}
+	return IsPublicAddress(ipAddress)
+}
+
+// IsPublicAddress reports whether ipAddress is a globally routable unicast address under the default policy.
+func IsPublicAddress(ipAddress netip.Addr) bool {
+	ipAddress = ipAddress.Unmap().WithZone("")
+	if !ipAddress.IsValid() ||
+		ipAddress.IsLoopback() ||
+		ipAddress.IsPrivate() ||
+		ipAddress.IsLinkLocalUnicast() ||
+		ipAddress.IsLinkLocalMulticast() ||
+		ipAddress.IsInterfaceLocalMulticast() ||
+		ipAddress.IsMulticast() ||
+		ipAddress.IsUnspecified() {
+		return false
+	}
+	for _, prefix := range deniedNetworks {
+		if prefix.Contains(ipAddress) {
+			return false
+		}
+	}
+	return true
+}
+
+type guardedTransport struct {
+	config *config
+	next   http.RoundTripper
+}
+
+func (transport *guardedTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	if schemeErr := validateScheme(request); schemeErr != nil {
+		transport.audit(request, schemeErr)
+		return nil, schemeErr
+	}
+	timeout := transport.config.requestTimeout
+	if hostTimeout, exists := transport.config.hostTimeouts[strings.ToLower(request.URL.Hostname())]; exists {
+		timeout = hostTimeout
+	}
+	requestContext, cancel := context.WithTimeout(request.Context(), timeout)
+	response, roundTripErr := transport.next.RoundTrip(request.Clone(requestContext))
+	if roundTripErr != nil {
+		cancel()
+		var blockedErr *BlockedError
+		if errors.As(roundTripErr, &blockedErr) {
+			transport.audit(request, blockedErr)
+		}
+		return nil, roundTripErr
+	}
+	response.Body = &cancelOnCloseBody{ReadCloser: response.Body, cancel: cancel}
+	return response, nil
+}
+
+func (transport *guardedTransport) checkRedirect(request *http.Request, via []*http.Request) error {
+	if len(via) > transport.config.maxRedirects {
+		blockedErr := &BlockedError{Reason: ErrTooManyRedirects, Address: request.URL.Host}
+		transport.audit(request, blockedErr)
+		return blockedErr
+	}
+	return nil
+}
+
+func (transport *guardedTransport) audit(request *http.Request, blockedErr error) {
+	transport.config.logger.Warn(
+		auditLogMessage,
+		zap.String("host", request.URL.Hostname()),
+		zap.String("url", redactedURL(request)),
+		zap.Error(blockedErr),
+	)
+}
+
+func validateScheme(request *http.Request) error {
+	if request.URL == nil {
+		return &BlockedError{Reason: ErrBlockedScheme}
+	}
+	switch strings.ToLower(request.URL.Scheme) {
+	case "http", "https":
+		return nil
+	default:
+		return &BlockedError{Reason: ErrBlockedScheme, Address: request.URL.Scheme}
+	}
+}
+
+func redactedURL(request *http.Request) string {
+	if request.URL == nil {
+		return ""
+	}
+	redacted := *request.URL
+	redacted.User = nil
+	redacted.RawQuery = ""
+	redacted.Fragment = ""
+	return redacted.String()
+}
+
+type cancelOnCloseBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (body *cancelOnCloseBody) Close() error {
+	closeErr := body.ReadCloser.Close()
+	body.cancel()
+	return closeErr
+}
@@ -0,0 +1,148 @@
+package egress
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+var testLoopbackNetwork = netip.MustParsePrefix("127.0.0.0/8")
+
+func fetch(testingT *testing.T, client *http.Client, targetURL string) (string, error) {
+	testingT.Helper()
+	request, requestErr := http.NewRequestWithContext(context.Background(), http.MethodGet, targetURL, nil)
+	require.NoError(testingT, requestErr)
+	response, responseErr := client.Do(request)
+	if responseErr != nil {
+		return "", responseErr
+	}
+	defer response.Body.Close()
+	body, readErr := io.ReadAll(response.Body)
+	return string(body), readErr
+}
+
+func TestClientBlocksLoopbackAndAuditsIt(testingT *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, _ *http.Request) {
+		_, _ = writer.Write([]byte("internal"))
+	}))
+	defer server.Close()
+
+	observedCore, observedLogs := observer.New(zapcore.WarnLevel)
+	client := NewClient(WithLogger(zap.New(observedCore)))
+
+	_, fetchErr := fetch(testingT, client, server.URL+"/secret?token=abc")
+	require.ErrorIs(testingT, fetchErr, ErrBlockedAddress)
+
+	entries := observedLogs.FilterMessage(auditLogMessage).All()
+	require.Len(testingT, entries, 1)
+	require.Equal(testingT, "127.0.0.1", entries[0].ContextMap()["host"])
+	require.Equal(testingT, server.URL+"/secret", entries[0].ContextMap()["url"])
+}
+
+func TestClientAllowsListedNetworks(testingT *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, _ *http.Request) {
+		_, _ = writer.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	client := NewClient(WithAllowedNetworks(testLoopbackNetwork))
+	body, fetchErr := fetch(testingT, client, server.URL)
+	require.NoError(testingT, fetchErr)
+	require.Equal(testingT, "ok", body)
+}
+
+func TestAddressPolicyAppliesDeniedNetworks(testingT *testing.T) {
+	policy := &addressPolicy{denied: []netip.Prefix{netip.MustParsePrefix("93.184.216.0/24")}}
+	require.False(testingT, policy.permits(netip.MustParseAddr("93.184.216.34")))
+	require.True(testingT, policy.permits(netip.MustParseAddr("8.8.8.8")))
+	require.False(testingT, policy.permits(netip.MustParseAddr("10.0.0.1")))
+}
+
+func TestClientCapsRedirects(testingT *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		http.Redirect(writer, request, server.URL+"/again", http.StatusFound)
+	}))
+	defer server.Close()
+
+	client := NewClient(WithAllowedNetworks(testLoopbackNetwork), WithMaxRedirects(2))
+	_, fetchErr := fetch(testingT, client, server.URL)
+	require.ErrorIs(testingT, fetchErr, ErrTooManyRedirects)
+}
+
+func TestClientRevalidatesRedirectTargets(testingT *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		http.Redirect(writer, request, "file:///etc/passwd", http.StatusFound)
+	}))
+	defer server.Close()
+
+	client := NewClient(WithAllowedNetworks(testLoopbackNetwork))
+	_, fetchErr := fetch(testingT, client, server.URL)
+	require.ErrorIs(testingT, fetchErr, ErrBlockedScheme)
+}
+
+func TestClientAppliesHostTimeouts(testingT *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		select {
+		case <-request.Context().Done():
+		case <-time.After(time.Second):
+		}
+		_, _ = writer.Write([]byte("slow"))
+	}))
+	defer server.Close()
+
+	client := NewClient(WithAllowedNetworks(testLoopbackNetwork), WithHostTimeouts(map[string]time.Duration{"127.0.0.1": 50 * time.Millisecond}))
+	_, fetchErr := fetch(testingT, client, server.URL)
+	require.Error(testingT, fetchErr)
+	require.True(testingT, errors.Is(fetchErr, context.DeadlineExceeded))
+}
+
+func TestIsPublicAddress(testingT *testing.T) {
+	testCases := map[string]bool{
+		"93.184.216.34":    true,
+		"2606:4700::1111":  true,
+		"127.0.0.1":        false,
+		"10.1.2.3":         false,
+		"172.16.0.1":       false,
+		"192.168.1.1":      false,
+		"169.254.169.254":  false,
+		"100.100.100.200":  false,
+		"0.0.0.0":          false,
+		"::1":              false,
+		"::ffff:127.0.0.1": false,
+		"fd00:ec2::254":    false,
+		"fe80::1":          false,
+		"64:ff9b::a00:1":   false,
+	}
+	for rawAddress, expected := range testCases {
+		require.Equal(testingT, expected, IsPublicAddress(netip.MustParseAddr(rawAddress)), rawAddress)
+	}
+}
+
+func TestParseNetworksAndHostTimeouts(testingT *testing.T) {
+	prefixes, parseErr := ParseNetworks("10.0.0.0/8, 192.168.1.7 fd00::/8")
+	require.NoError(testingT, parseErr)
+	require.Equal(testingT, []netip.Prefix{
+		netip.MustParsePrefix("10.0.0.0/8"),
+		netip.MustParsePrefix("192.168.1.7/32"),
+		netip.MustParsePrefix("fd00::/8"),
+	}, prefixes)
+	_, parseErr = ParseNetworks("not-a-network")
+	require.Error(testingT, parseErr)
+
+	hostTimeouts, parseErr := ParseHostTimeouts("CDN.example=2s, slow.example=750ms")
+	require.NoError(testingT, parseErr)
+	require.Equal(testingT, map[string]time.Duration{"cdn.example": 2 * time.Second, "slow.example": 750 * time.Millisecond}, hostTimeouts)
+	_, parseErr = ParseHostTimeouts("cdn.example")
+	require.Error(testingT, parseErr)
+}
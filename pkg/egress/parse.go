@@ -0,0 +1,53 @@
+package egress
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+	"time"
+)
+
+// ParseNetworks reads a comma- or space-separated list of CIDR ranges; bare addresses become single-address ranges.
+func ParseNetworks(rawValue string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0)
+	for _, field := range splitList(rawValue) {
+		if strings.Contains(field, "/") {
+			prefix, parseErr := netip.ParsePrefix(field)
+			if parseErr != nil {
+				return nil, fmt.Errorf("invalid network %q: %w", field, parseErr)
+			}
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+		ipAddress, parseErr := netip.ParseAddr(field)
+		if parseErr != nil {
+			return nil, fmt.Errorf("invalid network %q: %w", field, parseErr)
+		}
+		prefixes = append(prefixes, netip.PrefixFrom(ipAddress, ipAddress.BitLen()))
+	}
+	return prefixes, nil
+}
+
+// ParseHostTimeouts reads a comma- or space-separated list of host=duration pairs, e.g. "cdn.example=2s".
+func ParseHostTimeouts(rawValue string) (map[string]time.Duration, error) {
+	hostTimeouts := make(map[string]time.Duration)
+	for _, field := range splitList(rawValue) {
+		host, rawTimeout, found := strings.Cut(field, "=")
+		host = strings.ToLower(strings.TrimSpace(host))
+		if !found || host == "" {
+			return nil, fmt.Errorf("invalid host timeout %q", field)
+		}
+		timeout, parseErr := time.ParseDuration(strings.TrimSpace(rawTimeout))
+		if parseErr != nil || timeout <= 0 {
+			return nil, fmt.Errorf("invalid host timeout %q", field)
+		}
+		hostTimeouts[host] = timeout
+	}
+	return hostTimeouts, nil
+}
+
+func splitList(rawValue string) []string {
+	return strings.FieldsFunc(rawValue, func(character rune) bool {
+		return character == ',' || character == ' ' || character == '\t' || character == '\n'
+	})
+}
@@ -0,0 +1,380 @@
+package favicon
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+type candidateKind string
+
+const (
+	candidateKindLinkIcon       candidateKind = "link_icon"
+	candidateKindAppleTouchIcon candidateKind = "apple_touch_icon"
+	candidateKindMaskIcon       candidateKind = "mask_icon"
+	candidateKindManifestIcon   candidateKind = "manifest_icon"
+	candidateKindDefaultFavicon candidateKind = "default_favicon"
+	candidateKindOpenGraphImage candidateKind = "og_image"
+)
+
+const (
+	scalableIconSize          = 1024
+	assumedIconSize           = 32
+	defaultAppleTouchIconSize = 180
+	maxManifestIcons          = 32
+	maxSourceLength           = 500
+
+	// Tiers keep full-colour icons ahead of icons meant for other surfaces, whatever their size.
+	candidateTierPrimary    = 0
+	candidateTierMaskable   = 1
+	candidateTierMonochrome = 2
+	candidateTierFallback   = 3
+)
+
+// iconCandidate is an icon reference found on a page or in its web app manifest.
+type iconCandidate struct {
+	href        string
+	kind        candidateKind
+	size        int
+	contentType string
+	tier        int
+	order       int
+}
+
+// pageIcons collects every icon reference declared by one HTML page, along with the head values used for metadata.
+type pageIcons struct {
+	pageURL        *url.URL
+	icons          []iconCandidate
+	manifestHref   string
+	openGraphImage string
+	head           pageHead
+	parsedMetadata *PageMetadata
+}
+
+func (icons pageIcons) empty() bool {
+	return len(icons.icons) == 0 && icons.manifestHref == "" && icons.openGraphImage == ""
+}
+
+// extractPageIcons walks a parsed document for link icons, the first manifest link, the first og:image and the
+// title, description and canonical URL.
+func extractPageIcons(node *html.Node) pageIcons {
+	var icons pageIcons
+	var traverse func(*html.Node)
+	traverse = func(current *html.Node) {
+		if current == nil {
+			return
+		}
+		if current.Type == html.ElementNode {
+			switch strings.ToLower(current.Data) {
+			case "link":
+				icons.addLink(current)
+			case "meta":
+				icons.addMeta(current)
+			case "title":
+				icons.head.addTitle(current)
+			}
+		}
+		for child := current.FirstChild; child != nil; child = child.NextSibling {
+			traverse(child)
+		}
+	}
+	traverse(node)
+	return icons
+}
+
+func (icons *pageIcons) addLink(node *html.Node) {
+	relValue := strings.ToLower(htmlAttribute(node, "rel"))
+	hrefValue := strings.TrimSpace(htmlAttribute(node, "href"))
+	if relValue == "" || hrefValue == "" {
+		return
+	}
+	relTokens := strings.Fields(relValue)
+	if containsToken(relTokens, "canonical") {
+		icons.head.addCanonical(hrefValue)
+		return
+	}
+	if containsToken(relTokens, "manifest") {
+		if icons.manifestHref == "" {
+			icons.manifestHref = hrefValue
+		}
+		return
+	}
+	kind, isIcon := classifyIconRel(relTokens)
+	if !isIcon {
+		return
+	}
+	declared := iconCandidate{
+		href:        hrefValue,
+		kind:        kind,
+		size:        parseDeclaredSize(htmlAttribute(node, "sizes")),
+		contentType: declaredContentType(htmlAttribute(node, "type"), hrefValue),
+		tier:        candidateTierPrimary,
+		order:       len(icons.icons),
+	}
+	if kind == candidateKindMaskIcon {
+		declared.tier = candidateTierMonochrome
+	}
+	icons.icons = append(icons.icons, declared)
+}
+
+func (icons *pageIcons) addMeta(node *html.Node) {
+	property := strings.ToLower(strings.TrimSpace(htmlAttribute(node, "property")))
+	if property == "" {
+		property = strings.ToLower(strings.TrimSpace(htmlAttribute(node, "name")))
+	}
+	content := htmlAttribute(node, "content")
+	switch property {
+	case "og:image", "og:image:url", "og:image:secure_url":
+		setFirst(&icons.openGraphImage, content)
+	default:
+		icons.head.addMeta(property, content)
+	}
+}
+
+// classifyIconRel maps rel tokens to a candidate kind; "shortcut icon" and "icon" are both plain link icons.
+func classifyIconRel(relTokens []string) (candidateKind, bool) {
+	switch {
+	case containsToken(relTokens, "apple-touch-icon"), containsToken(relTokens, "apple-touch-icon-precomposed"):
+		return candidateKindAppleTouchIcon, true
+	case containsToken(relTokens, "mask-icon"):
+		return candidateKindMaskIcon, true
+	case containsToken(relTokens, "icon"):
+		return candidateKindLinkIcon, true
+	default:
+		return "", false
+	}
+}
+
+type webAppManifest struct {
+	Icons []webAppManifestIcon `json:"icons"`
+}
+
+type webAppManifestIcon struct {
+	Source  string `json:"src"`
+	Sizes   string `json:"sizes"`
+	Type    string `json:"type"`
+	Purpose string `json:"purpose"`
+}
+
+// manifestCandidates converts manifest icons into candidates with absolute URLs. Icons whose purpose is only
+// maskable or monochrome rank below regular ones because they are padded or single-colour.
+func manifestCandidates(manifestURL *url.URL, manifest webAppManifest, firstOrder int) []iconCandidate {
+	candidates := make([]iconCandidate, 0, len(manifest.Icons))
+	for index, icon := range manifest.Icons {
+		if index >= maxManifestIcons {
+			break
+		}
+		source := strings.TrimSpace(icon.Source)
+		if source == "" {
+			continue
+		}
+		parsedSource, parseErr := url.Parse(source)
+		if parseErr != nil {
+			continue
+		}
+		absolute := manifestURL.ResolveReference(parsedSource).String()
+		candidates = append(candidates, iconCandidate{
+			href:        absolute,
+			kind:        candidateKindManifestIcon,
+			size:        parseDeclaredSize(icon.Sizes),
+			contentType: declaredContentType(icon.Type, absolute),
+			tier:        manifestIconTier(icon.Purpose),
+			order:       firstOrder + index,
+		})
+	}
+	return candidates
+}
+
+func manifestIconTier(purpose string) int {
+	purposes := strings.Fields(strings.ToLower(purpose))
+	if len(purposes) == 0 || containsToken(purposes, "any") {
+		return candidateTierPrimary
+	}
+	if containsToken(purposes, "maskable") {
+		return candidateTierMaskable
+	}
+	return candidateTierMonochrome
+}
+
+func decodeManifest(body []byte) (webAppManifest, error) {
+	var manifest webAppManifest
+	if decodeErr := json.Unmarshal(body, &manifest); decodeErr != nil {
+		return webAppManifest{}, fmt.Errorf("decode web app manifest: %w", decodeErr)
+	}
+	return manifest, nil
+}
+
+// rankIconCandidates orders declared icons from best to worst: lower tier first, then the smallest icon that still
+// covers LargestVariantSize, then larger icons among those that do not, then PNG, SVG, ICO and other types.
+func rankIconCandidates(candidates []iconCandidate) []iconCandidate {
+	ranked := append([]iconCandidate(nil), candidates...)
+	sort.SliceStable(ranked, func(left, right int) bool {
+		return candidatePreferred(ranked[left], ranked[right])
+	})
+	return ranked
+}
+
+func candidatePreferred(left iconCandidate, right iconCandidate) bool {
+	if left.tier != right.tier {
+		return left.tier < right.tier
+	}
+	leftSize := left.effectiveSize()
+	rightSize := right.effectiveSize()
+	leftCovers := leftSize >= LargestVariantSize
+	rightCovers := rightSize >= LargestVariantSize
+	switch {
+	case leftCovers != rightCovers:
+		return leftCovers
+	case leftSize != rightSize && leftCovers:
+		return leftSize < rightSize
+	case leftSize != rightSize:
+		return leftSize > rightSize
+	}
+	leftType := contentTypeRank(left.contentType)
+	rightType := contentTypeRank(right.contentType)
+	if leftType != rightType {
+		return leftType < rightType
+	}
+	return left.order < right.order
+}
+
+func (icon iconCandidate) effectiveSize() int {
+	switch {
+	case icon.size > 0:
+		return icon.size
+	case strings.Contains(icon.contentType, "svg"):
+		return scalableIconSize
+	case icon.kind == candidateKindAppleTouchIcon:
+		return defaultAppleTouchIconSize
+	default:
+		return assumedIconSize
+	}
+}
+
+func contentTypeRank(contentType string) int {
+	switch {
+	case contentType == ContentTypePNG:
+		return 0
+	case strings.Contains(contentType, "svg"):
+		return 1
+	case strings.Contains(contentType, "icon"):
+		return 2
+	default:
+		return 3
+	}
+}
+
+// reason explains why the candidate was used; rank and total are one-based positions among declared icons.
+func (icon iconCandidate) reason(rank int, total int) string {
+	switch icon.kind {
+	case candidateKindDefaultFavicon:
+		return "default_favicon: no declared icon could be fetched"
+	case candidateKindOpenGraphImage:
+		return "og_image: no declared icon or /favicon.ico could be fetched"
+	}
+	details := []string{string(icon.kind)}
+	switch {
+	case icon.size == scalableIconSize:
+		details = append(details, "any size")
+	case icon.size > 0:
+		details = append(details, fmt.Sprintf("%dx%d", icon.size, icon.size))
+	}
+	if icon.contentType != "" {
+		details = append(details, icon.contentType)
+	}
+	description := strings.Join(details, " ")
+	if rank == 1 {
+		return fmt.Sprintf("%s: best of %d declared icons", description, total)
+	}
+	return fmt.Sprintf("%s: declared icon %d of %d after better candidates failed", description, rank, total)
+}
+
+// recordedSource returns the value stored as the favicon source: the absolute URL, or only the media type for data URLs.
+func recordedSource(href string) string {
+	trimmed := strings.TrimSpace(href)
+	if strings.HasPrefix(strings.ToLower(trimmed), "data:") {
+		mediaType, _, _ := strings.Cut(trimmed, ",")
+		mediaType, _, _ = strings.Cut(mediaType, ";")
+		return strings.ToLower(mediaType)
+	}
+	if len(trimmed) > maxSourceLength {
+		return trimmed[:maxSourceLength]
+	}
+	return trimmed
+}
+
+// parseDeclaredSize returns the largest edge listed in a sizes attribute, scalableIconSize for "any", or zero.
+func parseDeclaredSize(sizes string) int {
+	largest := 0
+	for _, token := range strings.Fields(strings.ToLower(sizes)) {
+		if token == "any" {
+			return scalableIconSize
+		}
+		widthValue, heightValue, found := strings.Cut(token, "x")
+		if !found {
+			continue
+		}
+		width, widthErr := strconv.Atoi(widthValue)
+		height, heightErr := strconv.Atoi(heightValue)
+		if widthErr != nil || heightErr != nil || width <= 0 || height <= 0 {
+			continue
+		}
+		largest = max(largest, min(width, height))
+	}
+	return largest
+}
+
+// declaredContentType prefers the declared type and otherwise infers it from the URL extension or data URL.
+func declaredContentType(declared string, href string) string {
+	normalized := strings.ToLower(strings.TrimSpace(declared))
+	if normalized != "" {
+		return normalized
+	}
+	lowered := strings.ToLower(strings.TrimSpace(href))
+	if strings.HasPrefix(lowered, "data:") {
+		return recordedSource(lowered)[len("data:"):]
+	}
+	if parsed, parseErr := url.Parse(lowered); parseErr == nil {
+		lowered = parsed.Path
+	}
+	switch path.Ext(lowered) {
+	case ".png":
+		return ContentTypePNG
+	case ".svg":
+		return ContentTypeSVG
+	case ".ico":
+		return "image/x-icon"
+	case ".jpg", ".jpeg":
+		return "image/jpeg"
+	case ".gif":
+		return "image/gif"
+	case ".webp":
+		return "image/webp"
+	default:
+		return ""
+	}
+}
+
+func htmlAttribute(node *html.Node, name string) string {
+	for _, attribute := range node.Attr {
+		if strings.EqualFold(attribute.Key, name) {
+			return attribute.Val
+		}
+	}
+	return ""
+}
+
+func containsToken(tokens []string, token string) bool {
+	for _, candidate := range tokens {
+		if candidate == token {
+			return true
+		}
+	}
+	return false
+}
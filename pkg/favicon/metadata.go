@@ -0,0 +1,135 @@
+package favicon
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+)
+
+const (
+	maxTitleLength       = 300
+	maxDescriptionLength = 1000
+)
+
+// PageMetadata describes a site's page as declared in its HTML head.
+type PageMetadata struct {
+	Title        string
+	Description  string
+	ImageURL     string
+	CanonicalURL string
+}
+
+// Resolution bundles the favicon and the page metadata read during one lookup. Metadata is nil when no page could be
+// read, so callers can tell a page without a description from a page that failed to load.
+type Resolution struct {
+	Asset    *Asset
+	Metadata *PageMetadata
+}
+
+// SiteResolver is implemented by resolvers that return page metadata along with the favicon from the same page fetch.
+type SiteResolver interface {
+	Resolver
+	ResolveSite(ctx context.Context, allowedOrigin string) (Resolution, error)
+}
+
+// pageHead holds the raw head values a page declares; OpenGraph values are fallbacks for the standard ones.
+type pageHead struct {
+	title                string
+	description          string
+	canonical            string
+	openGraphTitle       string
+	openGraphDescription string
+	openGraphURL         string
+}
+
+func (head *pageHead) addTitle(node *html.Node) {
+	if head.title != "" || node.Namespace != "" {
+		return
+	}
+	var builder strings.Builder
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.TextNode {
+			builder.WriteString(child.Data)
+		}
+	}
+	head.title = builder.String()
+}
+
+func (head *pageHead) addMeta(property string, content string) {
+	switch property {
+	case "description":
+		setFirst(&head.description, content)
+	case "og:title":
+		setFirst(&head.openGraphTitle, content)
+	case "og:description":
+		setFirst(&head.openGraphDescription, content)
+	case "og:url":
+		setFirst(&head.openGraphURL, content)
+	}
+}
+
+func (head *pageHead) addCanonical(href string) {
+	setFirst(&head.canonical, href)
+}
+
+// metadata normalizes the declared values: whitespace is collapsed, text is truncated, and URLs are resolved against
+// the page and kept only when they are absolute http or https URLs.
+func (icons pageIcons) metadata() PageMetadata {
+	head := icons.head
+	return PageMetadata{
+		Title:        normalizeMetadataText(firstNonEmpty(head.title, head.openGraphTitle), maxTitleLength),
+		Description:  normalizeMetadataText(firstNonEmpty(head.description, head.openGraphDescription), maxDescriptionLength),
+		ImageURL:     normalizeMetadataURL(icons.pageURL, icons.openGraphImage),
+		CanonicalURL: normalizeMetadataURL(icons.pageURL, firstNonEmpty(head.canonical, head.openGraphURL)),
+	}
+}
+
+func normalizeMetadataText(value string, limit int) string {
+	collapsed := strings.Join(strings.Fields(value), " ")
+	if len(collapsed) <= limit {
+		return collapsed
+	}
+	truncated := collapsed[:limit]
+	for !utf8.ValidString(truncated) {
+		truncated = truncated[:len(truncated)-1]
+	}
+	return strings.TrimSpace(truncated)
+}
+
+func normalizeMetadataURL(pageURL *url.URL, value string) string {
+	trimmed := strings.TrimSpace(value)
+	if trimmed == "" || pageURL == nil {
+		return ""
+	}
+	parsed, parseErr := url.Parse(trimmed)
+	if parseErr != nil {
+		return ""
+	}
+	resolved := pageURL.ResolveReference(parsed)
+	if resolved.Scheme != "http" && resolved.Scheme != "https" {
+		return ""
+	}
+	resolvedValue := resolved.String()
+	if len(resolvedValue) > maxSourceLength {
+		return ""
+	}
+	return resolvedValue
+}
+
+func setFirst(target *string, value string) {
+	if *target == "" {
+		*target = strings.TrimSpace(value)
+	}
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if strings.TrimSpace(value) != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,320 @@
+package favicon
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+	"sort"
+	"strings"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+const (
+	// ContentTypePNG is the content type of normalized raster favicons.
+	ContentTypePNG = "image/png"
+	// ContentTypeSVG is the content type of sanitized vector favicons.
+	ContentTypeSVG = "image/svg+xml"
+	// LargestVariantSize is the edge length of the primary normalized favicon.
+	LargestVariantSize = 64
+
+	maxSourceDimension = 1024
+	icoHeaderLength    = 6
+	icoEntryLength     = 16
+	dibMinHeaderLength = 40
+)
+
+// VariantSizes lists the edge lengths, in pixels, of the normalized PNG variants in ascending order.
+var VariantSizes = []int{16, 32, LargestVariantSize}
+
+var (
+	// ErrUnsupportedImage reports favicon bytes that cannot be decoded as ICO, PNG, GIF, JPEG, WebP or SVG.
+	ErrUnsupportedImage = errors.New("favicon_unsupported_image")
+	// ErrImageTooLarge reports a favicon whose pixel dimensions exceed the decoding limit.
+	ErrImageTooLarge = errors.New("favicon_image_too_large")
+
+	pngSignature = []byte("\x89PNG\r\n\x1a\n")
+)
+
+// NormalizedIcon holds the variants produced from a fetched favicon. Raster icons carry a PNG per
+// VariantSizes entry; SVG icons carry a single sanitized document under LargestVariantSize.
+type NormalizedIcon struct {
+	ContentType string
+	Variants    map[int][]byte
+}
+
+// Primary returns the largest variant, which is what legacy consumers of favicon_data expect.
+func (icon NormalizedIcon) Primary() []byte {
+	return icon.Variants[LargestVariantSize]
+}
+
+// Normalize decodes a fetched favicon and converts it into square PNG variants, or sanitizes it when it is an SVG.
+// ICO bundles are reduced to their best frame: the smallest frame at least LargestVariantSize wide, otherwise the largest.
+func Normalize(asset Asset) (NormalizedIcon, error) {
+	if len(asset.Data) == 0 {
+		return NormalizedIcon{}, ErrUnsupportedImage
+	}
+	if looksLikeSVG(asset.ContentType, asset.Data) {
+		sanitized, sanitizeErr := SanitizeSVG(asset.Data)
+		if sanitizeErr != nil {
+			return NormalizedIcon{}, sanitizeErr
+		}
+		return NormalizedIcon{ContentType: ContentTypeSVG, Variants: map[int][]byte{LargestVariantSize: sanitized}}, nil
+	}
+
+	source, decodeErr := decodeRaster(asset.Data)
+	if decodeErr != nil {
+		return NormalizedIcon{}, decodeErr
+	}
+	variants := make(map[int][]byte, len(VariantSizes))
+	for _, size := range VariantSizes {
+		encoded, encodeErr := encodeVariant(source, size)
+		if encodeErr != nil {
+			return NormalizedIcon{}, encodeErr
+		}
+		variants[size] = encoded
+	}
+	return NormalizedIcon{ContentType: ContentTypePNG, Variants: variants}, nil
+}
+
+func decodeRaster(data []byte) (image.Image, error) {
+	if isICO(data) {
+		return decodeICO(data)
+	}
+	return decodeBounded(data)
+}
+
+func decodeBounded(data []byte) (image.Image, error) {
+	config, _, configErr := image.DecodeConfig(bytes.NewReader(data))
+	if configErr != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedImage, configErr)
+	}
+	if config.Width <= 0 || config.Height <= 0 || config.Width > maxSourceDimension || config.Height > maxSourceDimension {
+		return nil, ErrImageTooLarge
+	}
+	decoded, _, decodeErr := image.Decode(bytes.NewReader(data))
+	if decodeErr != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedImage, decodeErr)
+	}
+	return decoded, nil
+}
+
+func encodeVariant(source image.Image, size int) ([]byte, error) {
+	target := image.NewRGBA(image.Rect(0, 0, size, size))
+	bounds := source.Bounds()
+	width, height := size, size
+	if bounds.Dx() > bounds.Dy() {
+		height = max(1, bounds.Dy()*size/bounds.Dx())
+	} else if bounds.Dy() > bounds.Dx() {
+		width = max(1, bounds.Dx()*size/bounds.Dy())
+	}
+	offsetX := (size - width) / 2
+	offsetY := (size - height) / 2
+	draw.CatmullRom.Scale(target, image.Rect(offsetX, offsetY, offsetX+width, offsetY+height), source, bounds, draw.Src, nil)
+
+	var buffer bytes.Buffer
+	encoder := png.Encoder{CompressionLevel: png.BestCompression}
+	if encodeErr := encoder.Encode(&buffer, target); encodeErr != nil {
+		return nil, encodeErr
+	}
+	return buffer.Bytes(), nil
+}
+
+type icoEntry struct {
+	width    int
+	bitCount int
+	data     []byte
+}
+
+func isICO(data []byte) bool {
+	return len(data) >= icoHeaderLength && binary.LittleEndian.Uint16(data[0:2]) == 0 && binary.LittleEndian.Uint16(data[2:4]) == 1
+}
+
+func decodeICO(data []byte) (image.Image, error) {
+	count := int(binary.LittleEndian.Uint16(data[4:6]))
+	if count == 0 || len(data) < icoHeaderLength+count*icoEntryLength {
+		return nil, ErrUnsupportedImage
+	}
+	entries := make([]icoEntry, 0, count)
+	for index := 0; index < count; index++ {
+		header := data[icoHeaderLength+index*icoEntryLength : icoHeaderLength+(index+1)*icoEntryLength]
+		width := int(header[0])
+		if width == 0 {
+			width = 256
+		}
+		length := int(binary.LittleEndian.Uint32(header[8:12]))
+		offset := int(binary.LittleEndian.Uint32(header[12:16]))
+		if length <= 0 || offset < 0 || offset > len(data) || length > len(data)-offset {
+			continue
+		}
+		entries = append(entries, icoEntry{
+			width:    width,
+			bitCount: int(binary.LittleEndian.Uint16(header[6:8])),
+			data:     data[offset : offset+length],
+		})
+	}
+	sort.SliceStable(entries, func(left, right int) bool {
+		return icoEntryPreferred(entries[left], entries[right])
+	})
+	for _, entry := range entries {
+		var decoded image.Image
+		var decodeErr error
+		if bytes.HasPrefix(entry.data, pngSignature) {
+			decoded, decodeErr = decodeBounded(entry.data)
+		} else {
+			decoded, decodeErr = decodeDIB(entry.data)
+		}
+		if decodeErr == nil {
+			return decoded, nil
+		}
+	}
+	return nil, ErrUnsupportedImage
+}
+
+// icoEntryPreferred orders frames so that the smallest frame covering LargestVariantSize comes first,
+// followed by smaller frames from largest to smallest; deeper colour wins between equal widths.
+func icoEntryPreferred(left icoEntry, right icoEntry) bool {
+	leftCovers := left.width >= LargestVariantSize
+	rightCovers := right.width >= LargestVariantSize
+	switch {
+	case leftCovers != rightCovers:
+		return leftCovers
+	case left.width != right.width && leftCovers:
+		return left.width < right.width
+	case left.width != right.width:
+		return left.width > right.width
+	default:
+		return left.bitCount > right.bitCount
+	}
+}
+
+// decodeDIB decodes the headerless bitmap stored inside ICO entries: a BITMAPINFOHEADER whose height covers
+// both the colour rows and the 1-bit transparency mask that follows them.
+func decodeDIB(data []byte) (image.Image, error) {
+	if len(data) < dibMinHeaderLength {
+		return nil, ErrUnsupportedImage
+	}
+	headerLength := int(binary.LittleEndian.Uint32(data[0:4]))
+	width := int(int32(binary.LittleEndian.Uint32(data[4:8])))
+	rawHeight := int(int32(binary.LittleEndian.Uint32(data[8:12])))
+	bitCount := int(binary.LittleEndian.Uint16(data[14:16]))
+	compression := binary.LittleEndian.Uint32(data[16:20])
+	colorsUsed := int(binary.LittleEndian.Uint32(data[32:36]))
+
+	topDown := rawHeight < 0
+	if topDown {
+		rawHeight = -rawHeight
+	}
+	height := rawHeight / 2
+	if headerLength < dibMinHeaderLength || headerLength > len(data) || width <= 0 || height <= 0 || width > 256 || height > 256 {
+		return nil, ErrUnsupportedImage
+	}
+	if compression != 0 && !(compression == 3 && bitCount == 32) {
+		return nil, ErrUnsupportedImage
+	}
+
+	var palette []color.NRGBA
+	if bitCount <= 8 {
+		switch bitCount {
+		case 1, 4, 8:
+		default:
+			return nil, ErrUnsupportedImage
+		}
+		paletteLength := colorsUsed
+		if paletteLength <= 0 || paletteLength > 1<<bitCount {
+			paletteLength = 1 << bitCount
+		}
+		paletteEnd := headerLength + paletteLength*4
+		if paletteEnd > len(data) {
+			return nil, ErrUnsupportedImage
+		}
+		palette = make([]color.NRGBA, paletteLength)
+		for index := range palette {
+			entry := data[headerLength+index*4:]
+			palette[index] = color.NRGBA{R: entry[2], G: entry[1], B: entry[0], A: 0xFF}
+		}
+	} else if bitCount != 24 && bitCount != 32 {
+		return nil, ErrUnsupportedImage
+	}
+
+	pixelOffset := headerLength + len(palette)*4
+	colorStride := ((width*bitCount + 31) / 32) * 4
+	maskStride := ((width + 31) / 32) * 4
+	maskOffset := pixelOffset + colorStride*height
+	if maskOffset > len(data) {
+		return nil, ErrUnsupportedImage
+	}
+	hasMask := maskOffset+maskStride*height <= len(data)
+
+	decoded := image.NewNRGBA(image.Rect(0, 0, width, height))
+	hasAlpha := false
+	for row := 0; row < height; row++ {
+		targetY := height - 1 - row
+		if topDown {
+			targetY = row
+		}
+		line := data[pixelOffset+row*colorStride:]
+		for column := 0; column < width; column++ {
+			var pixel color.NRGBA
+			switch bitCount {
+			case 32:
+				pixel = color.NRGBA{R: line[column*4+2], G: line[column*4+1], B: line[column*4], A: line[column*4+3]}
+				if pixel.A != 0 {
+					hasAlpha = true
+				}
+			case 24:
+				pixel = color.NRGBA{R: line[column*3+2], G: line[column*3+1], B: line[column*3], A: 0xFF}
+			default:
+				pixelsPerByte := 8 / bitCount
+				shift := uint((pixelsPerByte - 1 - column%pixelsPerByte) * bitCount)
+				index := int(line[column/pixelsPerByte]>>shift) & (1<<bitCount - 1)
+				if index < len(palette) {
+					pixel = palette[index]
+				}
+			}
+			decoded.SetNRGBA(column, targetY, pixel)
+		}
+	}
+
+	// 32-bit frames carry their own alpha; older frames rely on the AND mask, where a set bit means transparent.
+	if bitCount == 32 && hasAlpha {
+		return decoded, nil
+	}
+	for row := 0; row < height; row++ {
+		targetY := height - 1 - row
+		if topDown {
+			targetY = row
+		}
+		for column := 0; column < width; column++ {
+			offset := decoded.PixOffset(column, targetY) + 3
+			if hasMask && data[maskOffset+row*maskStride+column/8]&(0x80>>uint(column%8)) != 0 {
+				decoded.Pix[offset] = 0
+			} else {
+				decoded.Pix[offset] = 0xFF
+			}
+		}
+	}
+	return decoded, nil
+}
+
+func looksLikeSVG(contentType string, data []byte) bool {
+	if strings.Contains(strings.ToLower(contentType), "svg") {
+		return true
+	}
+	prefix := data
+	if len(prefix) > 512 {
+		prefix = prefix[:512]
+	}
+	trimmed := bytes.TrimLeft(bytes.TrimPrefix(prefix, []byte("\xef\xbb\xbf")), " \t\r\n")
+	if !bytes.HasPrefix(trimmed, []byte("<")) {
+		return false
+	}
+	return bytes.Contains(bytes.ToLower(prefix), []byte("<svg"))
+}
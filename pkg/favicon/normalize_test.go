@@ -0,0 +1,169 @@
+package favicon_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/MarkoPoloResearchLab/loopaware/pkg/favicon"
+)
+
+type testICOFrame struct {
+	width    int
+	bitCount int
+	data     []byte
+}
+
+func encodeTestICO(frames ...testICOFrame) []byte {
+	var buffer bytes.Buffer
+	_ = binary.Write(&buffer, binary.LittleEndian, []uint16{0, 1, uint16(len(frames))})
+	offset := 6 + 16*len(frames)
+	for _, frame := range frames {
+		buffer.Write([]byte{byte(frame.width % 256), byte(frame.width % 256), 0, 0})
+		_ = binary.Write(&buffer, binary.LittleEndian, []uint16{1, uint16(frame.bitCount)})
+		_ = binary.Write(&buffer, binary.LittleEndian, []uint32{uint32(len(frame.data)), uint32(offset)})
+		offset += len(frame.data)
+	}
+	for _, frame := range frames {
+		buffer.Write(frame.data)
+	}
+	return buffer.Bytes()
+}
+
+// encodeTestDIB builds a 24-bit bottom-up ICO bitmap whose AND mask marks the left column transparent.
+func encodeTestDIB(width int, fill color.NRGBA) []byte {
+	var buffer bytes.Buffer
+	_ = binary.Write(&buffer, binary.LittleEndian, []uint32{40, uint32(width), uint32(width * 2)})
+	_ = binary.Write(&buffer, binary.LittleEndian, []uint16{1, 24})
+	_ = binary.Write(&buffer, binary.LittleEndian, make([]uint32, 6))
+	colorStride := ((width*24 + 31) / 32) * 4
+	for row := 0; row < width; row++ {
+		line := make([]byte, colorStride)
+		for column := 0; column < width; column++ {
+			line[column*3], line[column*3+1], line[column*3+2] = fill.B, fill.G, fill.R
+		}
+		buffer.Write(line)
+	}
+	maskStride := ((width + 31) / 32) * 4
+	for row := 0; row < width; row++ {
+		line := make([]byte, maskStride)
+		line[0] = 0x80
+		buffer.Write(line)
+	}
+	return buffer.Bytes()
+}
+
+func decodeVariant(testingT *testing.T, data []byte) image.Image {
+	testingT.Helper()
+	decoded, decodeErr := png.Decode(bytes.NewReader(data))
+	require.NoError(testingT, decodeErr)
+	return decoded
+}
+
+func requireColorNear(testingT *testing.T, expected color.NRGBA, actual color.Color) {
+	testingT.Helper()
+	converted := color.NRGBAModel.Convert(actual).(color.NRGBA)
+	for _, pair := range [][2]uint8{{expected.R, converted.R}, {expected.G, converted.G}, {expected.B, converted.B}, {expected.A, converted.A}} {
+		difference := int(pair[0]) - int(pair[1])
+		require.True(testingT, difference >= -8 && difference <= 8, "expected %v, got %v", expected, converted)
+	}
+}
+
+func TestNormalizeProducesSquarePNGVariants(testingT *testing.T) {
+	red := color.NRGBA{R: 0xFF, A: 0xFF}
+	icon, normalizeErr := favicon.Normalize(favicon.Asset{ContentType: "image/png", Data: encodeTestPNG(testingT, 200, 100, red)})
+	require.NoError(testingT, normalizeErr)
+	require.Equal(testingT, favicon.ContentTypePNG, icon.ContentType)
+	require.Len(testingT, icon.Variants, len(favicon.VariantSizes))
+
+	for _, size := range favicon.VariantSizes {
+		variant := decodeVariant(testingT, icon.Variants[size])
+		require.Equal(testingT, image.Rect(0, 0, size, size), variant.Bounds())
+	}
+	largest := decodeVariant(testingT, icon.Primary())
+	requireColorNear(testingT, red, largest.At(32, 32))
+	requireColorNear(testingT, color.NRGBA{}, largest.At(32, 2))
+}
+
+func TestNormalizePicksBestICOFrame(testingT *testing.T) {
+	small := color.NRGBA{G: 0xFF, A: 0xFF}
+	large := color.NRGBA{B: 0xFF, A: 0xFF}
+	huge := color.NRGBA{R: 0xFF, A: 0xFF}
+	bundle := encodeTestICO(
+		testICOFrame{width: 16, bitCount: 32, data: encodeTestPNG(testingT, 16, 16, small)},
+		testICOFrame{width: 256, bitCount: 32, data: encodeTestPNG(testingT, 256, 256, huge)},
+		testICOFrame{width: 64, bitCount: 32, data: encodeTestPNG(testingT, 64, 64, large)},
+	)
+
+	icon, normalizeErr := favicon.Normalize(favicon.Asset{ContentType: "image/x-icon", Data: bundle})
+	require.NoError(testingT, normalizeErr)
+	requireColorNear(testingT, large, decodeVariant(testingT, icon.Primary()).At(10, 10))
+	requireColorNear(testingT, large, decodeVariant(testingT, icon.Variants[16]).At(8, 8))
+}
+
+func TestNormalizeDecodesBitmapICOFrames(testingT *testing.T) {
+	fill := color.NRGBA{R: 0x20, G: 0x80, B: 0xC0, A: 0xFF}
+	bundle := encodeTestICO(testICOFrame{width: 32, bitCount: 24, data: encodeTestDIB(32, fill)})
+
+	icon, normalizeErr := favicon.Normalize(favicon.Asset{ContentType: "application/octet-stream", Data: bundle})
+	require.NoError(testingT, normalizeErr)
+	largest := decodeVariant(testingT, icon.Primary())
+	requireColorNear(testingT, fill, largest.At(32, 32))
+	requireColorNear(testingT, color.NRGBA{}, largest.At(0, 32))
+}
+
+func TestNormalizeRejectsUnsupportedImages(testingT *testing.T) {
+	_, normalizeErr := favicon.Normalize(favicon.Asset{ContentType: "image/png", Data: []byte("not an image")})
+	require.ErrorIs(testingT, normalizeErr, favicon.ErrUnsupportedImage)
+
+	_, normalizeErr = favicon.Normalize(favicon.Asset{ContentType: "image/png", Data: encodeTestPNG(testingT, 2048, 1, color.NRGBA{A: 0xFF})})
+	require.ErrorIs(testingT, normalizeErr, favicon.ErrImageTooLarge)
+
+	_, normalizeErr = favicon.Normalize(favicon.Asset{ContentType: "image/svg+xml", Data: []byte(`<html><script>alert(1)</script></html>`)})
+	require.ErrorIs(testingT, normalizeErr, favicon.ErrInvalidSVG)
+}
+
+func TestSanitizeSVGStripsActiveContent(testingT *testing.T) {
+	source := `<?xml version="1.0"?>
+<!DOCTYPE svg>
+<svg xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink" viewBox="0 0 16 16" onload="alert(1)">
+  <!-- comment -->
+  <script>alert(2)</script>
+  <style>@media (prefers-color-scheme: dark) { circle { fill: #fff; } }</style>
+  <style>@import url(https://evil.example/x.css);</style>
+  <foreignObject><div xmlns="http://www.w3.org/1999/xhtml">hi</div></foreignObject>
+  <linearGradient id="g"><stop offset="0" stop-color="#000"/></linearGradient>
+  <a xlink:href="java&#9;script:alert(3)"><circle cx="8" cy="8" r="7" fill="url(#g)" onclick="alert(4)"/></a>
+  <use href="#g"/><image href="https://tracker.example/pixel.png"/>
+  <set attributeName="href" to="javascript:alert(5)"/>
+</svg>`
+
+	icon, normalizeErr := favicon.Normalize(favicon.Asset{ContentType: "text/xml", Data: []byte(source)})
+	require.NoError(testingT, normalizeErr)
+	require.Equal(testingT, favicon.ContentTypeSVG, icon.ContentType)
+	sanitized := string(icon.Primary())
+
+	for _, forbidden := range []string{"script", "onload", "onclick", "foreignObject", "@import", "javascript", "tracker.example", "<set", "DOCTYPE", "comment"} {
+		require.NotContains(testingT, strings.ToLower(sanitized), strings.ToLower(forbidden))
+	}
+	for _, kept := range []string{`viewBox="0 0 16 16"`, "linearGradient", `fill="url(#g)"`, `<use href="#g">`, "prefers-color-scheme", `xmlns:xlink="http://www.w3.org/1999/xlink"`} {
+		require.Contains(testingT, sanitized, kept)
+	}
+}
+
+func TestServiceCollectRejectsUndecodableFavicons(testingT *testing.T) {
+	service := favicon.NewService(&stubResolver{asset: &favicon.Asset{ContentType: "image/png", Data: []byte{0x0A}}})
+	result, collectErr := service.Collect(context.Background(), favicon.Site{}, "https://example.com", true, time.Now())
+	require.ErrorIs(testingT, collectErr, favicon.ErrUnsupportedImage)
+	require.False(testingT, result.ShouldNotify)
+	_, hasData := result.Updates["favicon_data"]
+	require.False(testingT, hasData)
+}
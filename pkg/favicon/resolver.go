@@ -37,10 +37,13 @@ type Resolver interface {
 	ResolveAsset(ctx context.Context, allowedOrigin string) (*Asset, error)
 }
 
-// Asset represents favicon binary contents and metadata.
+// Asset represents favicon binary contents and metadata. Source is the URL the icon came from (only the media type
+// for data URLs) and Reason explains why that candidate was chosen.
 type Asset struct {
 	ContentType string
 	Data        []byte
+	Source      string
+	Reason      string
 }
 
 type cacheEntry struct {
@@ -102,7 +105,7 @@ func (resolver *HTTPResolver) Resolve(ctx context.Context, allowedOrigin string)
 		}
 	}
 
-	candidate, lookupErr := resolver.lookupFavicon(ctx, baseURL)
+	candidate, _, lookupErr := resolver.lookupFavicon(ctx, baseURL)
 	if lookupErr != nil && resolver.logger != nil {
 		resolver.logger.Debug(
 			"favicon_lookup_failed",
@@ -130,19 +133,30 @@ func (resolver *HTTPResolver) Resolve(ctx context.Context, allowedOrigin string)
 
 // ResolveAsset returns the favicon contents for the given allowed origin.
 func (resolver *HTTPResolver) ResolveAsset(ctx context.Context, allowedOrigin string) (*Asset, error) {
+	resolution, resolveErr := resolver.ResolveSite(ctx, allowedOrigin)
+	if resolveErr != nil {
+		return nil, resolveErr
+	}
+	return resolution.Asset, nil
+}
+
+// ResolveSite returns the favicon contents and the page metadata for the given allowed origin. Metadata is returned
+// even when the favicon lookup fails, as long as a page could be parsed.
+func (resolver *HTTPResolver) ResolveSite(ctx context.Context, allowedOrigin string) (Resolution, error) {
 	normalized := strings.TrimSpace(allowedOrigin)
 	if normalized == "" {
-		return nil, nil
+		return Resolution{}, nil
 	}
 
 	baseURL, parseErr := url.Parse(normalized)
 	if parseErr != nil || baseURL == nil || baseURL.Scheme == "" || baseURL.Host == "" {
-		return nil, nil
+		return Resolution{}, nil
 	}
 	baseURL.Fragment = ""
 	baseURL.RawQuery = ""
 
-	candidate, lookupErr := resolver.lookupFavicon(ctx, baseURL)
+	candidate, metadata, lookupErr := resolver.lookupFavicon(ctx, baseURL)
+	resolution := Resolution{Metadata: metadata}
 	if lookupErr != nil {
 		if resolver.logger != nil {
 			resolver.logger.Debug(
@@ -151,49 +165,86 @@ func (resolver *HTTPResolver) ResolveAsset(ctx context.Context, allowedOrigin st
 				zap.Error(lookupErr),
 			)
 		}
-		return nil, lookupErr
-	}
-	if candidate == nil {
-		return nil, nil
+		return resolution, lookupErr
 	}
-	if candidate.asset == nil {
-		return nil, nil
+	if candidate != nil {
+		resolution.Asset = candidate.asset
 	}
-	return candidate.asset, nil
+	return resolution, nil
 }
 
-func (resolver *HTTPResolver) lookupFavicon(ctx context.Context, baseURL *url.URL) (*candidate, error) {
+// lookupFavicon tries the icons the site declares, best ranked first, then /favicon.ico, then the og:image. It also
+// returns the metadata of the first page it could parse.
+func (resolver *HTTPResolver) lookupFavicon(ctx context.Context, baseURL *url.URL) (*candidate, *PageMetadata, error) {
 	root := &url.URL{
 		Scheme: baseURL.Scheme,
 		Host:   baseURL.Host,
 	}
 
-	if candidate, err := resolver.fetchDefaultFavicon(ctx, root); err == nil && candidate != nil {
-		return candidate, nil
+	page, discoverErr := resolver.discoverPageIcons(ctx, root, resolver.htmlProbePaths(baseURL))
+	ranked := rankIconCandidates(page.icons)
+	declaredCount := len(ranked)
+	ranked = append(ranked, iconCandidate{
+		href: root.ResolveReference(&url.URL{Path: "/favicon.ico"}).String(),
+		kind: candidateKindDefaultFavicon,
+		tier: candidateTierFallback,
+	})
+	if page.openGraphImage != "" {
+		ranked = append(ranked, iconCandidate{href: page.openGraphImage, kind: candidateKindOpenGraphImage, tier: candidateTierFallback})
+	}
+
+	lastErr := discoverErr
+	for index, nextCandidate := range ranked {
+		resolved, fetchErr := resolver.fetchCandidate(ctx, page.pageURL, nextCandidate)
+		if fetchErr != nil {
+			lastErr = fetchErr
+			if resolver.logger != nil {
+				resolver.logger.Debug(
+					"favicon_candidate_fetch_failed",
+					zap.String("candidate", recordedSource(nextCandidate.href)),
+					zap.String("kind", string(nextCandidate.kind)),
+					zap.Error(fetchErr),
+				)
+			}
+			continue
+		}
+		if resolved == nil {
+			continue
+		}
+		resolved.asset.Reason = nextCandidate.reason(index+1, declaredCount)
+		return resolved, page.parsedMetadata, nil
 	}
-
-	return resolver.fetchHTMLDeclaredFavicon(ctx, root, resolver.htmlProbePaths(baseURL))
+	return nil, page.parsedMetadata, lastErr
 }
 
-func (resolver *HTTPResolver) fetchDefaultFavicon(ctx context.Context, root *url.URL) (*candidate, error) {
-	target := root.ResolveReference(&url.URL{Path: "/favicon.ico"})
-	asset, err := resolver.fetchRemoteIconAsset(ctx, target.String())
-	if err != nil {
-		return nil, err
+func (resolver *HTTPResolver) fetchCandidate(ctx context.Context, pageURL *url.URL, icon iconCandidate) (*candidate, error) {
+	if strings.HasPrefix(strings.ToLower(icon.href), "data:") {
+		asset, parseErr := resolver.parseDataURL(icon.href)
+		if parseErr != nil {
+			return nil, parseErr
+		}
+		asset.Source = recordedSource(icon.href)
+		return &candidate{dataURL: icon.href, asset: asset}, nil
 	}
-	if asset == nil {
-		return nil, nil
+	absoluteURL, resolveErr := resolver.absoluteURL(pageURL, icon.href)
+	if resolveErr != nil {
+		return nil, resolveErr
 	}
-	return &candidate{
-		remoteURL: target.String(),
-		asset:     asset,
-	}, nil
+	asset, fetchErr := resolver.fetchRemoteIconAsset(ctx, absoluteURL)
+	if fetchErr != nil || asset == nil {
+		return nil, fetchErr
+	}
+	asset.Source = recordedSource(absoluteURL)
+	return &candidate{remoteURL: absoluteURL, asset: asset}, nil
 }
 
-func (resolver *HTTPResolver) fetchHTMLDeclaredFavicon(ctx context.Context, root *url.URL, probePaths []string) (*candidate, error) {
+// discoverPageIcons returns the icons declared by the first probed page that declares any, including the icons
+// listed in its web app manifest. Its parsedMetadata comes from the first page that could be parsed at all.
+func (resolver *HTTPResolver) discoverPageIcons(ctx context.Context, root *url.URL, probePaths []string) (pageIcons, error) {
 	var lastErr error
-	for _, path := range probePaths {
-		pageURL := root.ResolveReference(&url.URL{Path: path})
+	var parsedMetadata *PageMetadata
+	for _, probePath := range probePaths {
+		pageURL := root.ResolveReference(&url.URL{Path: probePath})
 		body, err := resolver.get(ctx, pageURL.String(), resolver.maxHTMLBytes)
 		if err != nil {
 			lastErr = err
@@ -210,50 +261,48 @@ func (resolver *HTTPResolver) fetchHTMLDeclaredFavicon(ctx context.Context, root
 			continue
 		}
 
-		candidates := findFaviconCandidates(document)
-		for _, value := range candidates {
-			value = strings.TrimSpace(value)
-			if value == "" {
-				continue
-			}
-			if strings.HasPrefix(strings.ToLower(value), "data:") {
-				asset, parseErr := resolver.parseDataURL(value)
-				if parseErr != nil {
-					lastErr = parseErr
-					continue
-				}
-				return &candidate{
-					dataURL: value,
-					asset:   asset,
-				}, nil
-			}
-			absoluteURL, resolveErr := resolver.absoluteURL(root, value)
-			if resolveErr != nil {
-				lastErr = resolveErr
-				continue
-			}
-			asset, fetchErr := resolver.fetchRemoteIconAsset(ctx, absoluteURL)
-			if fetchErr != nil {
-				lastErr = fetchErr
-				if resolver.logger != nil {
-					resolver.logger.Debug(
-						"favicon_candidate_fetch_failed",
-						zap.String("candidate", absoluteURL),
-						zap.Error(fetchErr),
-					)
-				}
-				continue
-			}
-			if asset != nil {
-				return &candidate{
-					remoteURL: absoluteURL,
-					asset:     asset,
-				}, nil
+		page := extractPageIcons(document)
+		page.pageURL = pageURL
+		if parsedMetadata == nil {
+			metadata := page.metadata()
+			parsedMetadata = &metadata
+		}
+		if page.empty() {
+			continue
+		}
+		page.parsedMetadata = parsedMetadata
+		if page.manifestHref != "" {
+			manifestIcons, manifestErr := resolver.fetchManifestIcons(ctx, pageURL, page.manifestHref, len(page.icons))
+			if manifestErr != nil && resolver.logger != nil {
+				resolver.logger.Debug("favicon_manifest_fetch_failed", zap.String("manifest", page.manifestHref), zap.Error(manifestErr))
 			}
+			page.icons = append(page.icons, manifestIcons...)
 		}
+		return page, nil
 	}
+	return pageIcons{pageURL: root, parsedMetadata: parsedMetadata}, lastErr
+}
 
-	return nil, lastErr
+func (resolver *HTTPResolver) fetchManifestIcons(ctx context.Context, pageURL *url.URL, manifestHref string, firstOrder int) ([]iconCandidate, error) {
+	parsedHref, parseErr := url.Parse(manifestHref)
+	if parseErr != nil {
+		return nil, parseErr
+	}
+	manifestURL := pageURL.ResolveReference(parsedHref)
+	body, fetchErr := resolver.get(ctx, manifestURL.String(), resolver.maxHTMLBytes)
+	if fetchErr != nil || body == nil {
+		return nil, fetchErr
+	}
+	defer body.Close()
+	payload, readErr := io.ReadAll(body)
+	if readErr != nil {
+		return nil, readErr
+	}
+	manifest, decodeErr := decodeManifest(payload)
+	if decodeErr != nil {
+		return nil, decodeErr
+	}
+	return manifestCandidates(manifestURL, manifest, firstOrder), nil
 }
 
 func (resolver *HTTPResolver) fetchRemoteIconAsset(ctx context.Context, iconURL string) (*Asset, error) {
@@ -474,53 +523,6 @@ func (resolver *HTTPResolver) absoluteURL(root *url.URL, href string) (string, e
 	return resolved.String(), nil
 }
 
-func findFaviconCandidates(node *html.Node) []string {
-	var candidates []string
-	var traverse func(*html.Node)
-	traverse = func(current *html.Node) {
-		if current == nil {
-			return
-		}
-		if current.Type == html.ElementNode && strings.EqualFold(current.Data, "link") {
-			var relValue string
-			var hrefValue string
-			for _, attribute := range current.Attr {
-				switch strings.ToLower(attribute.Key) {
-				case "rel":
-					relValue = strings.ToLower(attribute.Val)
-				case "href":
-					hrefValue = attribute.Val
-				}
-			}
-			if relValue != "" && hrefValue != "" && relContainsIcon(relValue) {
-				candidates = append(candidates, hrefValue)
-			}
-		}
-		for child := current.FirstChild; child != nil; child = child.NextSibling {
-			traverse(child)
-		}
-	}
-	traverse(node)
-	return candidates
-}
-
-func relContainsIcon(relValue string) bool {
-	if relValue == "" {
-		return false
-	}
-	normalized := strings.ToLower(relValue)
-	if strings.Contains(normalized, "icon") {
-		return true
-	}
-	if strings.Contains(normalized, "apple-touch-icon") {
-		return true
-	}
-	if strings.Contains(normalized, "mask-icon") {
-		return true
-	}
-	return false
-}
-
 type limitedReadCloser struct {
 	reader io.Reader
 	closer io.Closer
@@ -85,10 +85,12 @@ func TestHTTPResolverResolveCachesResult(testingT *testing.T) {
 	require.NoError(testingT, resolveErr)
 	require.Equal(testingT, testResolverIconURL, resolved)
 
+	requestsForFirstLookup := requestCount
+
 	resolvedAgain, resolveErr := resolver.Resolve(context.Background(), testResolverOrigin)
 	require.NoError(testingT, resolveErr)
 	require.Equal(testingT, resolved, resolvedAgain)
-	require.Equal(testingT, 1, requestCount)
+	require.Equal(testingT, requestsForFirstLookup, requestCount)
 }
 
 func TestHTTPResolverResolveAssetReturnsNilForEmptyOrigin(testingT *testing.T) {
@@ -225,7 +227,7 @@ func TestFetchRemoteIconAssetRejectsOversizedPayload(testingT *testing.T) {
 	require.Nil(testingT, asset)
 }
 
-func TestFetchHTMLDeclaredFaviconResolvesRemote(testingT *testing.T) {
+func TestLookupFaviconResolvesDeclaredRemoteIcon(testingT *testing.T) {
 	htmlBody := `<html><head><link rel="icon" href="/icon.png"></head></html>`
 	client := &http.Client{
 		Transport: roundTripFunc(func(request *http.Request) (*http.Response, error) {
@@ -257,14 +259,16 @@ func TestFetchHTMLDeclaredFaviconResolvesRemote(testingT *testing.T) {
 	root, parseErr := url.Parse(testResolverOrigin)
 	require.NoError(testingT, parseErr)
 
-	candidate, fetchErr := resolver.fetchHTMLDeclaredFavicon(context.Background(), root, []string{"/"})
+	candidate, _, fetchErr := resolver.lookupFavicon(context.Background(), root)
 	require.NoError(testingT, fetchErr)
 	require.NotNil(testingT, candidate)
 	require.Equal(testingT, testResolverRelativeIconURL, candidate.remoteURL)
 	require.NotNil(testingT, candidate.asset)
+	require.Equal(testingT, testResolverRelativeIconURL, candidate.asset.Source)
+	require.Equal(testingT, "link_icon image/png: best of 1 declared icons", candidate.asset.Reason)
 }
 
-func TestFetchHTMLDeclaredFaviconReturnsParseError(testingT *testing.T) {
+func TestLookupFaviconReturnsDataURLParseError(testingT *testing.T) {
 	htmlBody := `<html><head><link rel="icon" href="data:text/plain,hello"></head></html>`
 	client := &http.Client{
 		Transport: roundTripFunc(func(request *http.Request) (*http.Response, error) {
@@ -281,7 +285,7 @@ func TestFetchHTMLDeclaredFaviconReturnsParseError(testingT *testing.T) {
 	root, parseErr := url.Parse(testResolverOrigin)
 	require.NoError(testingT, parseErr)
 
-	candidate, fetchErr := resolver.fetchHTMLDeclaredFavicon(context.Background(), root, []string{"/"})
+	candidate, _, fetchErr := resolver.lookupFavicon(context.Background(), root)
 	require.Error(testingT, fetchErr)
 	require.Nil(testingT, candidate)
 }
@@ -400,9 +404,11 @@ func TestAbsoluteURLRejectsInvalidHref(testingT *testing.T) {
 	require.Empty(testingT, absolute)
 }
 
-func TestRelContainsIconRejectsNonIcon(testingT *testing.T) {
-	require.False(testingT, relContainsIcon(""))
-	require.False(testingT, relContainsIcon("stylesheet"))
+func TestClassifyIconRelRejectsNonIcon(testingT *testing.T) {
+	_, isIcon := classifyIconRel(nil)
+	require.False(testingT, isIcon)
+	_, isIcon = classifyIconRel([]string{"stylesheet"})
+	require.False(testingT, isIcon)
 }
 
 func TestFindFaviconCandidatesExtractsLinks(testingT *testing.T) {
@@ -414,8 +420,11 @@ func TestFindFaviconCandidatesExtractsLinks(testingT *testing.T) {
 	document, parseErr := html.Parse(strings.NewReader(htmlBody))
 	require.NoError(testingT, parseErr)
 
-	candidates := findFaviconCandidates(document)
-	require.Len(testingT, candidates, 3)
+	page := extractPageIcons(document)
+	require.Len(testingT, page.icons, 3)
+	require.Equal(testingT, candidateKindLinkIcon, page.icons[0].kind)
+	require.Equal(testingT, candidateKindAppleTouchIcon, page.icons[1].kind)
+	require.Equal(testingT, candidateKindMaskIcon, page.icons[2].kind)
 }
 
 func TestNewLimitedReadCloserRespectsLimits(testingT *testing.T) {
@@ -473,7 +482,7 @@ func TestHTTPResolverResolveAssetReturnsErrorOnLookupFailure(testingT *testing.T
 	require.Nil(testingT, asset)
 }
 
-func TestFetchHTMLDeclaredFaviconReportsInvalidHref(testingT *testing.T) {
+func TestLookupFaviconReportsInvalidHref(testingT *testing.T) {
 	htmlBody := `<html><head><link rel="icon" href="http://%zz"></head></html>`
 	client := &http.Client{
 		Transport: roundTripFunc(func(*http.Request) (*http.Response, error) {
@@ -490,7 +499,7 @@ func TestFetchHTMLDeclaredFaviconReportsInvalidHref(testingT *testing.T) {
 	root, parseErr := url.Parse(testResolverOrigin)
 	require.NoError(testingT, parseErr)
 
-	candidate, fetchErr := resolver.fetchHTMLDeclaredFavicon(context.Background(), root, []string{"/"})
+	candidate, _, fetchErr := resolver.lookupFavicon(context.Background(), root)
 	require.Error(testingT, fetchErr)
 	require.Nil(testingT, candidate)
 }
@@ -522,9 +531,13 @@ func TestIsSupportedContentTypeAcceptsEmptyAndBinary(testingT *testing.T) {
 	require.True(testingT, isSupportedContentType("binary/octet-stream"))
 }
 
-func TestRelContainsIconAcceptsIconValues(testingT *testing.T) {
-	require.True(testingT, relContainsIcon("icon"))
-	require.True(testingT, relContainsIcon("apple-touch-icon"))
+func TestClassifyIconRelAcceptsIconValues(testingT *testing.T) {
+	kind, isIcon := classifyIconRel([]string{"icon"})
+	require.True(testingT, isIcon)
+	require.Equal(testingT, candidateKindLinkIcon, kind)
+	kind, isIcon = classifyIconRel([]string{"apple-touch-icon"})
+	require.True(testingT, isIcon)
+	require.Equal(testingT, candidateKindAppleTouchIcon, kind)
 }
 
 func TestIsSupportedContentTypeAcceptsIconAndSVG(testingT *testing.T) {
@@ -539,8 +552,166 @@ func TestFindFaviconCandidatesFiltersLinks(testingT *testing.T) {
 	root, parseErr := html.Parse(strings.NewReader(htmlBody))
 	require.NoError(testingT, parseErr)
 
-	candidates := findFaviconCandidates(root)
+	candidates := make([]string, 0)
+	for _, icon := range extractPageIcons(root).icons {
+		candidates = append(candidates, icon.href)
+	}
 	require.Contains(testingT, candidates, testFaviconCandidateIcon)
 	require.Contains(testingT, candidates, testFaviconCandidateMask)
 	require.NotContains(testingT, candidates, testFaviconCandidateStyle)
 }
+
+func newIconSiteClient(pages map[string]string, requested *[]string) *http.Client {
+	return &http.Client{
+		Transport: roundTripFunc(func(request *http.Request) (*http.Response, error) {
+			if requested != nil {
+				*requested = append(*requested, request.URL.Path)
+			}
+			body, exists := pages[request.URL.Path]
+			if !exists {
+				return &http.Response{StatusCode: http.StatusNotFound, Body: io.NopCloser(strings.NewReader("missing")), Header: http.Header{}}, nil
+			}
+			header := make(http.Header)
+			switch {
+			case strings.HasSuffix(request.URL.Path, ".png"):
+				header.Set("Content-Type", testResolverIconContentType)
+			case strings.HasSuffix(request.URL.Path, ".ico"):
+				header.Set("Content-Type", testSupportedTypeIcon)
+			case strings.HasSuffix(request.URL.Path, ".webmanifest"):
+				header.Set("Content-Type", "application/manifest+json")
+			default:
+				header.Set("Content-Type", testResolverHTMLContentType)
+			}
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body)), Header: header}, nil
+		}),
+	}
+}
+
+func TestResolveAssetRanksManifestAndTouchIcons(testingT *testing.T) {
+	pages := map[string]string{
+		"/": `<html><head>
+<link rel="icon" href="/favicon-16.png" sizes="16x16">
+<link rel="apple-touch-icon" href="/apple.png">
+<link rel="mask-icon" href="/mask.svg" color="#000">
+<link rel="manifest" href="/app/site.webmanifest">
+<meta property="og:image" content="/og.png">
+</head></html>`,
+		"/app/site.webmanifest": `{"icons":[
+{"src":"icons/192.png","sizes":"192x192","type":"image/png"},
+{"src":"icons/512.png","sizes":"512x512","purpose":"maskable"},
+{"src":"/icons/96.png","sizes":"96x96","purpose":"any maskable"}]}`,
+		"/icons/96.png":   "manifest-96",
+		"/apple.png":      "apple",
+		"/favicon-16.png": "small",
+		"/favicon.ico":    "default",
+	}
+	resolver := NewHTTPResolver(newIconSiteClient(pages, nil), zap.NewNop())
+
+	asset, resolveErr := resolver.ResolveAsset(context.Background(), testResolverOrigin)
+	require.NoError(testingT, resolveErr)
+	require.NotNil(testingT, asset)
+	require.Equal(testingT, "manifest-96", string(asset.Data))
+	require.Equal(testingT, "https://example.com/icons/96.png", asset.Source)
+	require.Equal(testingT, "manifest_icon 96x96 image/png: best of 6 declared icons", asset.Reason)
+
+	delete(pages, "/icons/96.png")
+	asset, resolveErr = resolver.ResolveAsset(context.Background(), testResolverOrigin)
+	require.NoError(testingT, resolveErr)
+	require.Equal(testingT, "apple", string(asset.Data))
+	require.Equal(testingT, "apple_touch_icon image/png: declared icon 2 of 6 after better candidates failed", asset.Reason)
+}
+
+func TestResolveAssetFallsBackToDefaultFaviconThenOpenGraphImage(testingT *testing.T) {
+	pages := map[string]string{
+		"/":            `<html><head><meta property="og:image" content="https://example.com/og.png"></head></html>`,
+		"/favicon.ico": "default",
+		"/og.png":      "social",
+	}
+	var requested []string
+	resolver := NewHTTPResolver(newIconSiteClient(pages, &requested), zap.NewNop())
+
+	asset, resolveErr := resolver.ResolveAsset(context.Background(), testResolverOrigin)
+	require.NoError(testingT, resolveErr)
+	require.Equal(testingT, "default", string(asset.Data))
+	require.Equal(testingT, testResolverIconURL, asset.Source)
+	require.Equal(testingT, "default_favicon: no declared icon could be fetched", asset.Reason)
+	require.Equal(testingT, []string{"/", "/favicon.ico"}, requested)
+
+	delete(pages, "/favicon.ico")
+	asset, resolveErr = resolver.ResolveAsset(context.Background(), testResolverOrigin)
+	require.NoError(testingT, resolveErr)
+	require.Equal(testingT, "social", string(asset.Data))
+	require.Equal(testingT, "og_image: no declared icon or /favicon.ico could be fetched", asset.Reason)
+}
+
+func TestRankIconCandidatesPrefersCoveringSizesAndTypes(testingT *testing.T) {
+	ranked := rankIconCandidates([]iconCandidate{
+		{href: "/mask.svg", kind: candidateKindMaskIcon, contentType: ContentTypeSVG, tier: candidateTierMonochrome, order: 0},
+		{href: "/favicon.ico", kind: candidateKindLinkIcon, contentType: "image/x-icon", order: 1},
+		{href: "/icon.svg", kind: candidateKindLinkIcon, size: parseDeclaredSize("any"), contentType: ContentTypeSVG, order: 2},
+		{href: "/icon-48.png", kind: candidateKindLinkIcon, size: parseDeclaredSize("16x16 48x48"), contentType: ContentTypePNG, order: 3},
+		{href: "/icon-128.png", kind: candidateKindLinkIcon, size: 128, contentType: ContentTypePNG, order: 4},
+	})
+	hrefs := make([]string, 0, len(ranked))
+	for _, icon := range ranked {
+		hrefs = append(hrefs, icon.href)
+	}
+	require.Equal(testingT, []string{"/icon-128.png", "/icon.svg", "/icon-48.png", "/favicon.ico", "/mask.svg"}, hrefs)
+	require.Equal(testingT, "data:image/png", recordedSource("data:image/png;base64,AAAA"))
+	require.Equal(testingT, ContentTypePNG, declaredContentType("", "data:image/png;base64,AAAA"))
+}
+
+func TestResolveSiteReadsPageMetadata(testingT *testing.T) {
+	pages := map[string]string{
+		"/": `<html><head>
+<title>
+  Example   Shop
+</title>
+<meta name="description" content="  Handmade   goods ">
+<meta property="og:title" content="Social title">
+<meta property="og:image" content="/images/share.png">
+<link rel="canonical" href="/home">
+<link rel="icon" href="/icon.png">
+</head><body><svg><title>Logo</title></svg></body></html>`,
+		"/icon.png": "icon",
+	}
+	resolver := NewHTTPResolver(newIconSiteClient(pages, nil), zap.NewNop())
+
+	resolution, resolveErr := resolver.ResolveSite(context.Background(), testResolverOrigin)
+	require.NoError(testingT, resolveErr)
+	require.NotNil(testingT, resolution.Asset)
+	require.Equal(testingT, "icon", string(resolution.Asset.Data))
+	require.Equal(testingT, &PageMetadata{
+		Title:        "Example Shop",
+		Description:  "Handmade goods",
+		ImageURL:     "https://example.com/images/share.png",
+		CanonicalURL: "https://example.com/home",
+	}, resolution.Metadata)
+}
+
+func TestResolveSiteFallsBackToOpenGraphMetadata(testingT *testing.T) {
+	pages := map[string]string{
+		"/": `<html><head>
+<meta property="og:title" content="Social title">
+<meta property="og:description" content="Social description">
+<meta property="og:url" content="javascript:alert(1)">
+<meta property="og:image" content="ftp://example.com/share.png">
+</head></html>`,
+	}
+	resolver := NewHTTPResolver(newIconSiteClient(pages, nil), zap.NewNop())
+
+	resolution, resolveErr := resolver.ResolveSite(context.Background(), testResolverOrigin)
+	require.NoError(testingT, resolveErr)
+	require.Nil(testingT, resolution.Asset)
+	require.Equal(testingT, &PageMetadata{Title: "Social title", Description: "Social description"}, resolution.Metadata)
+
+	delete(pages, "/")
+	resolution, resolveErr = resolver.ResolveSite(context.Background(), testResolverOrigin)
+	require.NoError(testingT, resolveErr)
+	require.Nil(testingT, resolution.Metadata)
+}
+
+func TestNormalizeMetadataTextTruncatesOnRuneBoundary(testingT *testing.T) {
+	require.Equal(testingT, "a b", normalizeMetadataText(" a\n  b ", 10))
+	require.Equal(testingT, "aé", normalizeMetadataText("aéé", 4))
+}
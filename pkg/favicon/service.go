@@ -13,13 +13,16 @@ type Site struct {
 	FaviconData        []byte
 	FaviconContentType string
 	FaviconFetchedAt   time.Time
+	Metadata           PageMetadata
 }
 
-// CollectionResult describes persistence changes and notification intent.
+// CollectionResult describes persistence changes and notification intent. Metadata is the page metadata read during
+// collection, or nil when no page could be read.
 type CollectionResult struct {
 	Updates        map[string]any
 	ShouldNotify   bool
 	EventTimestamp time.Time
+	Metadata       *PageMetadata
 }
 
 // Service orchestrates favicon retrieval for persistence layers.
@@ -32,7 +35,11 @@ func NewService(resolver Resolver) *Service {
 	return &Service{resolver: resolver}
 }
 
-// Collect resolves favicon assets and prepares persistence updates.
+// Collect resolves favicon assets and prepares persistence updates. Fetched icons are normalized before they are
+// compared and stored: favicon_data holds the 64px PNG or the sanitized SVG, favicon_data_16 and favicon_data_32
+// hold the smaller PNGs and are cleared for SVG icons. favicon_source and favicon_source_reason record where the icon
+// came from and why it was chosen. When the resolver is a SiteResolver, changed page metadata is stored in the
+// page_title, page_description, page_image_url and page_canonical_url columns and triggers a notification.
 func (service *Service) Collect(ctx context.Context, site Site, allowedOrigin string, notify bool, timestamp time.Time) (CollectionResult, error) {
 	if service == nil || service.resolver == nil {
 		return CollectionResult{}, errors.New("favicon resolver is not configured")
@@ -49,31 +56,62 @@ func (service *Service) Collect(ctx context.Context, site Site, allowedOrigin st
 	}
 	result := CollectionResult{Updates: updates}
 
-	asset, resolveErr := service.resolver.ResolveAsset(ctx, normalizedOrigin)
+	resolution, resolveErr := service.resolve(ctx, normalizedOrigin)
+	shouldNotify := false
+	if resolution.Metadata != nil {
+		result.Metadata = resolution.Metadata
+		if *resolution.Metadata != site.Metadata {
+			result.Updates["page_title"] = resolution.Metadata.Title
+			result.Updates["page_description"] = resolution.Metadata.Description
+			result.Updates["page_image_url"] = resolution.Metadata.ImageURL
+			result.Updates["page_canonical_url"] = resolution.Metadata.CanonicalURL
+			shouldNotify = true
+		}
+	}
 	if resolveErr != nil {
-		return result, resolveErr
+		return withNotification(result, shouldNotify, timestamp), resolveErr
 	}
 
-	shouldNotify := false
+	asset := resolution.Asset
 	if asset != nil && len(asset.Data) > 0 {
-		contentTypesEqual := strings.EqualFold(strings.TrimSpace(site.FaviconContentType), strings.TrimSpace(asset.ContentType))
-		if site.FaviconFetchedAt.IsZero() || !bytes.Equal(site.FaviconData, asset.Data) || !contentTypesEqual {
-			result.Updates["favicon_data"] = asset.Data
-			result.Updates["favicon_content_type"] = asset.ContentType
+		icon, normalizeErr := Normalize(*asset)
+		if normalizeErr != nil {
+			return withNotification(result, shouldNotify, timestamp), normalizeErr
+		}
+		primary := icon.Primary()
+		contentTypesEqual := strings.EqualFold(strings.TrimSpace(site.FaviconContentType), icon.ContentType)
+		if site.FaviconFetchedAt.IsZero() || !bytes.Equal(site.FaviconData, primary) || !contentTypesEqual {
+			result.Updates["favicon_data"] = primary
+			result.Updates["favicon_data_16"] = icon.Variants[16]
+			result.Updates["favicon_data_32"] = icon.Variants[32]
+			result.Updates["favicon_content_type"] = icon.ContentType
 			result.Updates["favicon_fetched_at"] = timestamp
 			shouldNotify = true
 		} else {
 			result.Updates["favicon_fetched_at"] = timestamp
 		}
+		result.Updates["favicon_source"] = asset.Source
+		result.Updates["favicon_source_reason"] = asset.Reason
 		if notify {
 			shouldNotify = true
 		}
 	}
 
+	return withNotification(result, shouldNotify, timestamp), nil
+}
+
+func withNotification(result CollectionResult, shouldNotify bool, timestamp time.Time) CollectionResult {
 	if shouldNotify {
 		result.ShouldNotify = true
 		result.EventTimestamp = timestamp
 	}
+	return result
+}
 
-	return result, nil
+func (service *Service) resolve(ctx context.Context, allowedOrigin string) (Resolution, error) {
+	if siteResolver, ok := service.resolver.(SiteResolver); ok {
+		return siteResolver.ResolveSite(ctx, allowedOrigin)
+	}
+	asset, resolveErr := service.resolver.ResolveAsset(ctx, allowedOrigin)
+	return Resolution{Asset: asset}, resolveErr
 }
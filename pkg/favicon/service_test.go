@@ -1,8 +1,12 @@
 package favicon_test
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"image"
+	"image/color"
+	"image/png"
 	"testing"
 	"time"
 
@@ -24,10 +28,27 @@ func (resolver *stubResolver) ResolveAsset(_ context.Context, _ string) (*favico
 	return resolver.asset, resolver.resolveErr
 }
 
+func encodeTestPNG(testingT *testing.T, width int, height int, fill color.NRGBA) []byte {
+	testingT.Helper()
+	source := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for index := 0; index < len(source.Pix); index += 4 {
+		source.Pix[index], source.Pix[index+1], source.Pix[index+2], source.Pix[index+3] = fill.R, fill.G, fill.B, fill.A
+	}
+	var buffer bytes.Buffer
+	require.NoError(testingT, png.Encode(&buffer, source))
+	return buffer.Bytes()
+}
+
 func TestServiceCollect(testingT *testing.T) {
 	testTimestamp := time.Date(2024, time.January, 15, 12, 0, 0, 0, time.UTC)
+	existingAsset := favicon.Asset{ContentType: "image/png", Data: encodeTestPNG(testingT, 32, 32, color.NRGBA{R: 0x10, A: 0xFF})}
+	existingIcon, normalizeErr := favicon.Normalize(existingAsset)
+	require.NoError(testingT, normalizeErr)
+	changedAsset := &favicon.Asset{ContentType: "image/x-icon", Data: encodeTestPNG(testingT, 32, 32, color.NRGBA{B: 0x10, A: 0xFF})}
+	changedIcon, normalizeErr := favicon.Normalize(*changedAsset)
+	require.NoError(testingT, normalizeErr)
 	existingSite := favicon.Site{
-		FaviconData:        []byte{0x01, 0x02},
+		FaviconData:        existingIcon.Primary(),
 		FaviconContentType: "image/png",
 		FaviconFetchedAt:   testTimestamp.Add(-time.Hour),
 	}
@@ -45,10 +66,10 @@ func TestServiceCollect(testingT *testing.T) {
 		{
 			name: "updatesWhenAssetChanges",
 			resolver: &stubResolver{
-				asset: &favicon.Asset{ContentType: "image/png", Data: []byte{0x0A}},
+				asset: changedAsset,
 			},
 			site:             existingSite,
-			expectedKeys:     []string{"favicon_origin", "favicon_last_attempt_at", "favicon_data", "favicon_content_type", "favicon_fetched_at"},
+			expectedKeys:     []string{"favicon_origin", "favicon_last_attempt_at", "favicon_data", "favicon_data_16", "favicon_data_32", "favicon_content_type", "favicon_fetched_at", "favicon_source", "favicon_source_reason"},
 			expectDataUpdate: true,
 			expectNotify:     true,
 		},
@@ -63,11 +84,11 @@ func TestServiceCollect(testingT *testing.T) {
 		{
 			name: "notifiesWhenRequested",
 			resolver: &stubResolver{
-				asset: &favicon.Asset{ContentType: "image/png", Data: existingSite.FaviconData},
+				asset: &existingAsset,
 			},
 			site:         existingSite,
 			notify:       true,
-			expectedKeys: []string{"favicon_origin", "favicon_last_attempt_at", "favicon_fetched_at"},
+			expectedKeys: []string{"favicon_origin", "favicon_last_attempt_at", "favicon_fetched_at", "favicon_source", "favicon_source_reason"},
 			expectNotify: true,
 		},
 		{
@@ -108,8 +129,9 @@ func TestServiceCollect(testingT *testing.T) {
 			}
 
 			if testCase.expectDataUpdate {
-				require.Equal(nestedT, []byte{0x0A}, result.Updates["favicon_data"])
-				require.Equal(nestedT, "image/png", result.Updates["favicon_content_type"])
+				require.Equal(nestedT, changedIcon.Primary(), result.Updates["favicon_data"])
+				require.Equal(nestedT, changedIcon.Variants[16], result.Updates["favicon_data_16"])
+				require.Equal(nestedT, favicon.ContentTypePNG, result.Updates["favicon_content_type"])
 			} else {
 				_, hasData := result.Updates["favicon_data"]
 				require.False(nestedT, hasData)
@@ -129,6 +151,43 @@ func TestServiceCollect(testingT *testing.T) {
 	}
 }
 
+type stubSiteResolver struct {
+	stubResolver
+	metadata *favicon.PageMetadata
+}
+
+func (resolver *stubSiteResolver) ResolveSite(_ context.Context, _ string) (favicon.Resolution, error) {
+	return favicon.Resolution{Asset: resolver.asset, Metadata: resolver.metadata}, resolver.resolveErr
+}
+
+func TestServiceCollectRecordsChangedPageMetadata(testingT *testing.T) {
+	testTimestamp := time.Date(2024, time.January, 15, 12, 0, 0, 0, time.UTC)
+	metadata := favicon.PageMetadata{Title: "Example", Description: "Handmade goods", CanonicalURL: "https://example.com/"}
+	resolver := &stubSiteResolver{stubResolver: stubResolver{resolveErr: errors.New("icon lookup failed")}, metadata: &metadata}
+	service := favicon.NewService(resolver)
+
+	result, collectErr := service.Collect(context.Background(), favicon.Site{}, "https://example.com", false, testTimestamp)
+	require.EqualError(testingT, collectErr, "icon lookup failed")
+	require.Equal(testingT, "Example", result.Updates["page_title"])
+	require.Equal(testingT, "Handmade goods", result.Updates["page_description"])
+	require.Equal(testingT, "", result.Updates["page_image_url"])
+	require.Equal(testingT, "https://example.com/", result.Updates["page_canonical_url"])
+	require.Equal(testingT, &metadata, result.Metadata)
+	require.True(testingT, result.ShouldNotify)
+	require.True(testingT, result.EventTimestamp.Equal(testTimestamp))
+
+	result, collectErr = service.Collect(context.Background(), favicon.Site{Metadata: metadata}, "https://example.com", false, testTimestamp)
+	require.Error(testingT, collectErr)
+	_, hasTitle := result.Updates["page_title"]
+	require.False(testingT, hasTitle)
+	require.False(testingT, result.ShouldNotify)
+
+	resolver.metadata = nil
+	result, _ = service.Collect(context.Background(), favicon.Site{Metadata: metadata}, "https://example.com", false, testTimestamp)
+	_, hasTitle = result.Updates["page_title"]
+	require.False(testingT, hasTitle)
+}
+
 func TestServiceCollectReturnsEmptyResultForBlankOrigin(testingT *testing.T) {
 	service := favicon.NewService(&stubResolver{})
 	result, err := service.Collect(context.Background(), favicon.Site{}, "   ", false, time.Now())
@@ -0,0 +1,187 @@
+package favicon
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ErrInvalidSVG reports SVG favicons that are not well-formed documents with an svg root element.
+var ErrInvalidSVG = errors.New("favicon_invalid_svg")
+
+var blockedSVGElements = map[string]struct{}{
+	"script":        {},
+	"foreignobject": {},
+	"iframe":        {},
+	"embed":         {},
+	"object":        {},
+	"handler":       {},
+	"listener":      {},
+	"audio":         {},
+	"video":         {},
+}
+
+var animationSVGElements = map[string]struct{}{
+	"animate": {},
+	"set":     {},
+}
+
+// SanitizeSVG re-serializes an SVG document keeping only its drawing content. Scripts, foreign objects,
+// event handler attributes, javascript: URLs, external references and CSS imports are removed, as are
+// comments, processing instructions and DOCTYPE declarations.
+func SanitizeSVG(data []byte) ([]byte, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.Strict = true
+
+	var output bytes.Buffer
+	depth := 0
+	skipDepth := 0
+	styleDepth := 0
+	rootSeen := false
+	for {
+		token, tokenErr := decoder.RawToken()
+		if errors.Is(tokenErr, io.EOF) {
+			break
+		}
+		if tokenErr != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidSVG, tokenErr)
+		}
+		switch typed := token.(type) {
+		case xml.StartElement:
+			if skipDepth > 0 {
+				skipDepth++
+				continue
+			}
+			localName := strings.ToLower(typed.Name.Local)
+			if depth == 0 {
+				if rootSeen || localName != "svg" {
+					return nil, ErrInvalidSVG
+				}
+				rootSeen = true
+			}
+			if svgElementBlocked(localName, typed.Attr) {
+				skipDepth = 1
+				continue
+			}
+			depth++
+			if localName == "style" {
+				styleDepth = depth
+			}
+			writeSVGStartElement(&output, typed)
+		case xml.EndElement:
+			if skipDepth > 0 {
+				skipDepth--
+				continue
+			}
+			if depth == styleDepth {
+				styleDepth = 0
+			}
+			depth--
+			output.WriteString("</")
+			output.WriteString(qualifiedXMLName(typed.Name))
+			output.WriteString(">")
+		case xml.CharData:
+			if skipDepth > 0 || depth == 0 {
+				continue
+			}
+			if styleDepth > 0 && !safeCSS(string(typed)) {
+				continue
+			}
+			_ = xml.EscapeText(&output, typed)
+		}
+	}
+	if !rootSeen || depth != 0 {
+		return nil, ErrInvalidSVG
+	}
+	return output.Bytes(), nil
+}
+
+func svgElementBlocked(localName string, attributes []xml.Attr) bool {
+	if _, blocked := blockedSVGElements[localName]; blocked {
+		return true
+	}
+	if _, animation := animationSVGElements[localName]; animation {
+		for _, attribute := range attributes {
+			if strings.EqualFold(attribute.Name.Local, "attributeName") && strings.Contains(strings.ToLower(attribute.Value), "href") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func writeSVGStartElement(output *bytes.Buffer, element xml.StartElement) {
+	output.WriteString("<")
+	output.WriteString(qualifiedXMLName(element.Name))
+	for _, attribute := range element.Attr {
+		if !svgAttributeAllowed(attribute) {
+			continue
+		}
+		output.WriteString(" ")
+		output.WriteString(qualifiedXMLName(attribute.Name))
+		output.WriteString(`="`)
+		_ = xml.EscapeText(output, []byte(attribute.Value))
+		output.WriteString(`"`)
+	}
+	output.WriteString(">")
+}
+
+func svgAttributeAllowed(attribute xml.Attr) bool {
+	localName := strings.ToLower(attribute.Name.Local)
+	if strings.HasPrefix(localName, "on") {
+		return false
+	}
+	value := compactAttributeValue(attribute.Value)
+	if strings.Contains(value, "javascript:") || strings.Contains(value, "vbscript:") {
+		return false
+	}
+	switch localName {
+	case "href":
+		return strings.HasPrefix(value, "#") || (strings.HasPrefix(value, "data:image/") && !strings.HasPrefix(value, "data:image/svg"))
+	case "style":
+		return safeCSS(attribute.Value)
+	}
+	return true
+}
+
+// safeCSS rejects stylesheets that import other resources or reference anything but local fragments.
+func safeCSS(value string) bool {
+	compacted := compactAttributeValue(value)
+	if strings.Contains(compacted, "@import") || strings.Contains(compacted, "javascript:") || strings.Contains(compacted, "expression(") {
+		return false
+	}
+	for remainder := compacted; ; {
+		index := strings.Index(remainder, "url(")
+		if index < 0 {
+			return true
+		}
+		remainder = strings.TrimLeft(remainder[index+len("url("):], `"'`)
+		if !strings.HasPrefix(remainder, "#") {
+			return false
+		}
+	}
+}
+
+// compactAttributeValue lowercases a value and drops whitespace and control characters, which browsers
+// ignore inside URL schemes, so "java\tscript:" is caught as well.
+func compactAttributeValue(value string) string {
+	var builder strings.Builder
+	builder.Grow(len(value))
+	for _, character := range strings.ToLower(value) {
+		if character <= ' ' || character == 0x7f {
+			continue
+		}
+		builder.WriteRune(character)
+	}
+	return builder.String()
+}
+
+func qualifiedXMLName(name xml.Name) string {
+	if name.Space == "" {
+		return name.Local
+	}
+	return name.Space + ":" + name.Local
+}
@@ -65,6 +65,32 @@
   var widgetTestEndpointOverride = "";
   var widgetSiteId = "";
   var widgetApiOrigin = "";
+  var widgetThemeOverrideValue = "";
+  var widgetContactOptional = false;
+  var widgetExtraFieldConfig = null;
+  var widgetExtraFieldTypeSelect = "select";
+  var widgetExtraFieldTypeRating = "rating";
+  var widgetRatingMaximumValue = 5;
+  var widgetRatingStarText = "★";
+  var widgetPageURLMaxLength = 500;
+  var widgetRatingDefaultLabel = "How would you rate us?";
+  var widgetSelectDefaultLabel = "Choose one";
+  var widgetAttachmentsEnabled = false;
+  var widgetAttachmentMaxBytes = 0;
+  var widgetAttachmentDefaultLabel = "Attach a screenshot";
+  var widgetAttachmentAcceptedTypes = ["image/png", "image/jpeg", "image/gif", "image/webp"];
+  var widgetBrandTextLuminanceThreshold = 0.179;
+  var widgetBrandTextColorDark = "#111827";
+  var widgetBrandTextColorLight = "#ffffff";
+  var widgetCopyValues = {
+    header_text: "Send feedback",
+    thank_you_text: "Thanks! Sent.",
+    contact_placeholder: "Email or phone",
+    message_placeholder: "Your message",
+    submit_label: "Send",
+    extra_field_label: "",
+    attachment_label: ""
+  };
   try {
     if (typeof window === "object" && window) {
       widgetDemoModeEnabled = Boolean(window[widgetDemoModeFlagName]);
@@ -252,6 +278,110 @@
     if (typeof offset === "number") {
       widgetPlacementBottomOffsetValue = offset;
     }
+    var theme = typeof config.theme === "string" ? config.theme.trim().toLowerCase() : "";
+    if (theme === themeNameLight || theme === themeNameDark) {
+      widgetThemeOverrideValue = theme;
+    }
+    if (typeof config.brandColor === "string" && config.brandColor.trim().length > 0) {
+      applyWidgetBrandColor(config.brandColor.trim());
+    }
+    widgetContactOptional = config.contactMode === "optional";
+    widgetExtraFieldConfig = normalizeWidgetExtraField(config.extraField);
+    var attachmentMaxBytes = Number(config.attachmentMaxBytes);
+    widgetAttachmentsEnabled = config.allowAttachments === true && isFinite(attachmentMaxBytes) && attachmentMaxBytes > 0;
+    widgetAttachmentMaxBytes = widgetAttachmentsEnabled ? attachmentMaxBytes : 0;
+    if (config.copy && typeof config.copy === "object") {
+      for (var copyKey in widgetCopyValues) {
+        if (!Object.prototype.hasOwnProperty.call(widgetCopyValues, copyKey)) {
+          continue;
+        }
+        var copyValue = config.copy[copyKey];
+        if (typeof copyValue === "string" && copyValue.trim().length > 0) {
+          widgetCopyValues[copyKey] = copyValue.trim();
+        }
+      }
+    }
+    if (widgetExtraFieldConfig && !widgetCopyValues.extra_field_label) {
+      widgetCopyValues.extra_field_label = widgetExtraFieldConfig.type === widgetExtraFieldTypeRating
+        ? widgetRatingDefaultLabel
+        : widgetSelectDefaultLabel;
+    }
+    if (widgetAttachmentsEnabled && !widgetCopyValues.attachment_label) {
+      widgetCopyValues.attachment_label = widgetAttachmentDefaultLabel;
+    }
+  }
+
+  function normalizeWidgetExtraField(rawValue) {
+    if (!rawValue || typeof rawValue !== "object") {
+      return null;
+    }
+    var fieldType = typeof rawValue.type === "string" ? rawValue.type.trim().toLowerCase() : "";
+    if (fieldType === widgetExtraFieldTypeRating) {
+      return {type: fieldType, required: rawValue.required === true, options: []};
+    }
+    if (fieldType !== widgetExtraFieldTypeSelect || !Array.isArray(rawValue.options)) {
+      return null;
+    }
+    var options = [];
+    for (var index = 0; index < rawValue.options.length; index++) {
+      if (typeof rawValue.options[index] === "string" && rawValue.options[index].trim().length > 0) {
+        options.push(rawValue.options[index].trim());
+      }
+    }
+    if (options.length === 0) {
+      return null;
+    }
+    return {type: fieldType, required: rawValue.required === true, options: options};
+  }
+
+  function applyWidgetBrandColor(brandColor) {
+    var parsedBrandColor = parseRGBColor(brandColor);
+    if (!parsedBrandColor) {
+      return;
+    }
+    var brandTextColor = computeRelativeLuminance(parsedBrandColor) > widgetBrandTextLuminanceThreshold
+      ? widgetBrandTextColorDark
+      : widgetBrandTextColorLight;
+    var themeNames = [themeNameLight, themeNameDark];
+    for (var index = 0; index < themeNames.length; index++) {
+      var palette = widgetThemePalettes[themeNames[index]];
+      palette.bubbleBackground = brandColor;
+      palette.bubbleTextColor = brandTextColor;
+      palette.buttonBackground = brandColor;
+      palette.buttonTextColor = brandTextColor;
+    }
+  }
+
+  function mapWidgetConfigPayload(payload, side, bottomOffset) {
+    return {
+      side: side,
+      bottomOffset: bottomOffset,
+      theme: payload.theme,
+      brandColor: payload.brand_color,
+      contactMode: payload.contact_mode,
+      extraField: payload.extra_field,
+      allowAttachments: payload.allow_attachments,
+      attachmentMaxBytes: payload.attachment_max_bytes,
+      copy: payload.copy,
+    };
+  }
+
+  function resolveWidgetPageURL() {
+    try {
+      var pageURL = String(window.location.href || "");
+      var isHTTPPage = pageURL.indexOf("http://") === 0 || pageURL.indexOf("https://") === 0;
+      if (isHTTPPage && pageURL.length <= widgetPageURLMaxLength) {
+        return pageURL;
+      }
+    } catch(pageURLError){}
+    return "";
+  }
+
+  function resolveWidgetLocale() {
+    if (typeof navigator === "object" && navigator && typeof navigator.language === "string") {
+      return navigator.language;
+    }
+    return "";
   }
 
   function fetchWidgetPlacementFromPublicAPI() {
@@ -261,7 +391,9 @@
     var requestURL =
       widgetApiOrigin +
       "/public/widget-config?site_id=" +
-      encodeURIComponent(widgetSiteId);
+      encodeURIComponent(widgetSiteId) +
+      "&locale=" +
+      encodeURIComponent(resolveWidgetLocale());
     return fetch(requestURL, {
       method: "GET",
       headers: { "Accept": "application/json" },
@@ -291,10 +423,11 @@
         if (!payload || typeof payload !== "object") {
           return null;
         }
-        return {
-          side: payload.widget_bubble_side,
-          bottomOffset: payload.widget_bubble_bottom_offset,
-        };
+        return mapWidgetConfigPayload(
+          payload,
+          payload.widget_bubble_side,
+          payload.widget_bubble_bottom_offset
+        );
       });
   }
 
@@ -334,10 +467,12 @@
           if (String(site.id || "").trim() !== widgetSiteId) {
             continue;
           }
-          return {
-            side: site.widget_bubble_side,
-            bottomOffset: site.widget_bubble_bottom_offset,
-          };
+          var siteWidgetConfig = site.widget_config && typeof site.widget_config === "object" ? site.widget_config : {};
+          return mapWidgetConfigPayload(
+            siteWidgetConfig,
+            site.widget_bubble_side,
+            site.widget_bubble_bottom_offset
+          );
         }
         return null;
       });
@@ -444,7 +579,7 @@
       headline.id = widgetHeadlineElementID;
       headline.style.fontWeight = widgetHeadlineFontWeightValue;
       headline.style.flexGrow = widgetHeadlineFlexGrowValue;
-      headline.innerText = widgetDemoModeEnabled ? "Example widget" : "Send feedback";
+      headline.innerText = widgetDemoModeEnabled ? "Example widget" : widgetCopyValues.header_text;
       headerContainer.appendChild(headline);
 
       var closeButton = document.createElement("button");
@@ -468,7 +603,7 @@
       var contact = document.createElement("input");
       contact.id = "mp-feedback-contact";
       contact.type = "text";
-      contact.placeholder = "Email or phone";
+      contact.placeholder = widgetCopyValues.contact_placeholder;
       contact.autocomplete = "email";
       contact.style.width = "100%";
       contact.style.margin = "6px 0";
@@ -479,7 +614,7 @@
 
       var message = document.createElement("textarea");
       message.id = "mp-feedback-message";
-      message.placeholder = "Your message";
+      message.placeholder = widgetCopyValues.message_placeholder;
       message.rows = 4;
       message.style.width = "100%";
       message.style.margin = "6px 0 8px";
@@ -488,40 +623,239 @@
       message.style.boxSizing = boxSizingBorderBoxValue;
       panelContainer.appendChild(message);
 
-      function handleInputTabNavigation(event) {
-        if (event.key !== "Tab") {
+      var extraFieldSelect = null;
+      var extraFieldRatingButtons = [];
+      var extraFieldRatingValue = 0;
+      var extraFieldFocusElements = [];
+      if (widgetExtraFieldConfig && widgetExtraFieldConfig.type === widgetExtraFieldTypeSelect) {
+        extraFieldSelect = document.createElement("select");
+        extraFieldSelect.id = "mp-feedback-extra";
+        extraFieldSelect.setAttribute("aria-label", widgetCopyValues.extra_field_label);
+        extraFieldSelect.style.width = "100%";
+        extraFieldSelect.style.margin = "0 0 8px";
+        extraFieldSelect.style.padding = "10px";
+        extraFieldSelect.style.borderRadius = "8px";
+        extraFieldSelect.style.boxSizing = boxSizingBorderBoxValue;
+        var placeholderOption = document.createElement("option");
+        placeholderOption.value = "";
+        placeholderOption.innerText = widgetCopyValues.extra_field_label;
+        extraFieldSelect.appendChild(placeholderOption);
+        for (var optionIndex = 0; optionIndex < widgetExtraFieldConfig.options.length; optionIndex++) {
+          var selectOption = document.createElement("option");
+          selectOption.value = widgetExtraFieldConfig.options[optionIndex];
+          selectOption.innerText = widgetExtraFieldConfig.options[optionIndex];
+          extraFieldSelect.appendChild(selectOption);
+        }
+        panelContainer.appendChild(extraFieldSelect);
+        extraFieldFocusElements.push(extraFieldSelect);
+      } else if (widgetExtraFieldConfig && widgetExtraFieldConfig.type === widgetExtraFieldTypeRating) {
+        var ratingContainer = document.createElement("div");
+        ratingContainer.id = "mp-feedback-rating";
+        ratingContainer.setAttribute("role", "radiogroup");
+        ratingContainer.setAttribute("aria-label", widgetCopyValues.extra_field_label);
+        ratingContainer.style.display = "flex";
+        ratingContainer.style.alignItems = "center";
+        ratingContainer.style.gap = "4px";
+        ratingContainer.style.margin = "0 0 8px";
+        var ratingLabel = document.createElement("span");
+        ratingLabel.innerText = widgetCopyValues.extra_field_label;
+        ratingLabel.style.fontSize = "13px";
+        ratingLabel.style.flexGrow = "1";
+        ratingContainer.appendChild(ratingLabel);
+        for (var ratingIndex = 1; ratingIndex <= widgetRatingMaximumValue; ratingIndex++) {
+          var ratingButton = document.createElement("button");
+          ratingButton.type = "button";
+          ratingButton.innerText = widgetRatingStarText;
+          ratingButton.setAttribute("role", "radio");
+          ratingButton.setAttribute("aria-checked", "false");
+          ratingButton.setAttribute("aria-label", ratingIndex + " of " + widgetRatingMaximumValue);
+          ratingButton.setAttribute("data-rating", String(ratingIndex));
+          ratingButton.style.border = "0";
+          ratingButton.style.background = "transparent";
+          ratingButton.style.fontSize = "20px";
+          ratingButton.style.lineHeight = "1";
+          ratingButton.style.padding = "0 2px";
+          ratingButton.style.cursor = "pointer";
+          ratingButton.style.opacity = "0.35";
+          ratingButton.addEventListener("click", function(){
+            setExtraFieldRating(Number(this.getAttribute("data-rating")));
+          });
+          ratingContainer.appendChild(ratingButton);
+          extraFieldRatingButtons.push(ratingButton);
+          extraFieldFocusElements.push(ratingButton);
+        }
+        panelContainer.appendChild(ratingContainer);
+      }
+
+      var attachmentInput = null;
+      var attachmentName = null;
+      var selectedAttachmentFile = null;
+      if (widgetAttachmentsEnabled) {
+        var attachmentContainer = document.createElement("label");
+        attachmentContainer.id = "mp-feedback-attachment";
+        attachmentContainer.style.display = "flex";
+        attachmentContainer.style.alignItems = "center";
+        attachmentContainer.style.gap = "6px";
+        attachmentContainer.style.margin = "0 0 8px";
+        attachmentContainer.style.fontSize = "13px";
+        attachmentContainer.style.cursor = "pointer";
+        attachmentInput = document.createElement("input");
+        attachmentInput.type = "file";
+        attachmentInput.accept = widgetAttachmentAcceptedTypes.join(",");
+        attachmentInput.setAttribute("aria-label", widgetCopyValues.attachment_label);
+        attachmentName = document.createElement("span");
+        attachmentName.innerText = widgetCopyValues.attachment_label;
+        attachmentName.style.overflow = "hidden";
+        attachmentName.style.textOverflow = "ellipsis";
+        attachmentName.style.whiteSpace = "nowrap";
+        attachmentInput.style.display = "none";
+        var attachmentIcon = document.createElement("span");
+        attachmentIcon.innerText = "\uD83D\uDCCE";
+        attachmentIcon.setAttribute("aria-hidden", "true");
+        attachmentContainer.appendChild(attachmentInput);
+        attachmentContainer.appendChild(attachmentIcon);
+        attachmentContainer.appendChild(attachmentName);
+        attachmentContainer.tabIndex = 0;
+        attachmentContainer.addEventListener("keydown", function(event){
+          if (event.key === "Enter" || event.key === " ") {
+            event.preventDefault();
+            attachmentInput.click();
+          }
+        });
+        attachmentInput.addEventListener("change", function(){
+          var files = attachmentInput.files;
+          selectAttachmentFile(files && files.length > 0 ? files[0] : null);
+        });
+        message.addEventListener("paste", function(event){
+          var clipboardItems = event.clipboardData && event.clipboardData.items;
+          if (!clipboardItems) {
+            return;
+          }
+          for (var itemIndex = 0; itemIndex < clipboardItems.length; itemIndex++) {
+            if (clipboardItems[itemIndex].kind === "file" && widgetAttachmentAcceptedTypes.indexOf(clipboardItems[itemIndex].type) !== -1) {
+              selectAttachmentFile(clipboardItems[itemIndex].getAsFile());
+              event.preventDefault();
+              return;
+            }
+          }
+        });
+        panelContainer.appendChild(attachmentContainer);
+        extraFieldFocusElements.push(attachmentContainer);
+      }
+
+      function selectAttachmentFile(file) {
+        if (!attachmentName) {
           return;
         }
-        var focusedElement = event.target;
-        var isShiftTab = event.shiftKey === true;
-        if (focusedElement === contact && !isShiftTab) {
-          event.preventDefault();
-          focusInputElement(message);
+        if (!file) {
+          resetAttachment();
           return;
         }
-        if (focusedElement === contact && isShiftTab) {
-          event.preventDefault();
-          focusInputElement(send);
+        if (widgetAttachmentAcceptedTypes.indexOf(file.type) === -1) {
+          resetAttachment();
+          show("Please attach a PNG, JPEG, GIF or WebP image.", statusStateError);
           return;
         }
-        if (focusedElement === message && isShiftTab) {
-          event.preventDefault();
-          focusInputElement(contact);
+        if (file.size > widgetAttachmentMaxBytes) {
+          resetAttachment();
+          show("Image is too large (max " + Math.floor(widgetAttachmentMaxBytes / 1048576 * 10) / 10 + " MB).", statusStateError);
           return;
         }
-        if (focusedElement === message && !isShiftTab) {
-          event.preventDefault();
-          focusInputElement(send);
+        selectedAttachmentFile = file;
+        attachmentName.innerText = file.name || widgetCopyValues.attachment_label;
+        show("", statusStatePending);
+      }
+
+      function resetAttachment() {
+        selectedAttachmentFile = null;
+        if (attachmentInput) {
+          attachmentInput.value = "";
+        }
+        if (attachmentName) {
+          attachmentName.innerText = widgetCopyValues.attachment_label;
+        }
+      }
+
+      function uploadAttachment(attachmentEndpoint, feedbackID) {
+        var formData = new FormData();
+        formData.append("site_id", widgetSiteId);
+        formData.append("feedback_id", feedbackID);
+        formData.append("attachment", selectedAttachmentFile, selectedAttachmentFile.name || "screenshot.png");
+        return fetch(attachmentEndpoint, {
+          method: "POST",
+          body: formData,
+          credentials: "same-origin",
+          referrer: window.location.href,
+          referrerPolicy: "strict-origin-when-cross-origin"
+        }).then(function(resp){
+          if (!resp.ok) { throw new Error("HTTP " + resp.status); }
+          return true;
+        });
+      }
+
+      function setExtraFieldRating(ratingValue) {
+        extraFieldRatingValue = ratingValue;
+        for (var index = 0; index < extraFieldRatingButtons.length; index++) {
+          var isActive = index < ratingValue;
+          extraFieldRatingButtons[index].style.opacity = isActive ? "1" : "0.35";
+          extraFieldRatingButtons[index].setAttribute("aria-checked", index + 1 === ratingValue ? "true" : "false");
+        }
+      }
+
+      function readExtraFieldValue() {
+        if (extraFieldSelect) {
+          return extraFieldSelect.value || "";
+        }
+        if (extraFieldRatingValue > 0) {
+          return String(extraFieldRatingValue);
+        }
+        return "";
+      }
+
+      function resetExtraField() {
+        if (extraFieldSelect) {
+          extraFieldSelect.value = "";
+        }
+        setExtraFieldRating(0);
+      }
+
+      function resolveFocusSequence() {
+        return [contact, message].concat(extraFieldFocusElements, [send]);
+      }
+
+      function moveFocusWithinPanel(focusedElement, isShiftTab) {
+        var focusSequence = resolveFocusSequence();
+        var currentIndex = focusSequence.indexOf(focusedElement);
+        if (currentIndex === -1) {
+          return false;
+        }
+        var step = isShiftTab ? -1 : 1;
+        var nextIndex = (currentIndex + step + focusSequence.length) % focusSequence.length;
+        focusInputElement(focusSequence[nextIndex]);
+        return true;
+      }
+
+      function handleInputTabNavigation(event) {
+        if (event.key !== "Tab") {
           return;
         }
+        if (event.target === send) {
+          return;
+        }
+        if (moveFocusWithinPanel(event.target, event.shiftKey === true)) {
+          event.preventDefault();
+        }
       }
 
       contact.addEventListener("keydown", handleInputTabNavigation);
       message.addEventListener("keydown", handleInputTabNavigation);
+      for (var focusIndex = 0; focusIndex < extraFieldFocusElements.length; focusIndex++) {
+        extraFieldFocusElements[focusIndex].addEventListener("keydown", handleInputTabNavigation);
+      }
 
       var send = document.createElement("button");
       send.type = "button";
-      send.innerText = "Send";
+      send.innerText = widgetCopyValues.submit_label;
       send.style.width = "100%";
       send.style.padding = "10px 12px";
       send.style.border = "0";
@@ -610,6 +944,14 @@
         message.style.border = palette.inputBorder;
         message.style.background = palette.inputBackground;
         message.style.color = palette.inputTextColor;
+        if (extraFieldSelect) {
+          extraFieldSelect.style.border = palette.inputBorder;
+          extraFieldSelect.style.background = palette.inputBackground;
+          extraFieldSelect.style.color = palette.inputTextColor;
+        }
+        for (var ratingButtonIndex = 0; ratingButtonIndex < extraFieldRatingButtons.length; ratingButtonIndex++) {
+          extraFieldRatingButtons[ratingButtonIndex].style.color = palette.buttonBackground;
+        }
         send.style.background = palette.buttonBackground;
         send.style.color = palette.buttonTextColor;
         closeButton.style.color = palette.closeButtonColor;
@@ -673,22 +1015,11 @@
         if (panel.style.display !== panelDisplayBlockValue) {
           return;
         }
-        var active = document.activeElement;
-        var isShift = event.shiftKey === true;
-        if (active === contact && !isShift) {
-          event.preventDefault();
-          focusInputElement(message);
+        if (event.shiftKey === true) {
           return;
         }
-        if (active === message && !isShift) {
+        if (moveFocusWithinPanel(document.activeElement, false)) {
           event.preventDefault();
-          focusInputElement(send);
-          return;
-        }
-        if (active === send && !isShift) {
-          event.preventDefault();
-          focusInputElement(contact);
-          return;
         }
       }
 
@@ -736,9 +1067,12 @@
       function validate() {
         var contactValue = (contact.value || "").trim();
         var messageValue = (message.value || "").trim();
-        if (contactValue.length < 3) { show("Please enter a valid email or phone.", statusStateError); return null; }
+        var extraFieldValue = readExtraFieldValue();
+        var contactMissingAllowed = widgetContactOptional && contactValue.length === 0;
+        if (!contactMissingAllowed && contactValue.length < 3) { show("Please enter a valid email or phone.", statusStateError); return null; }
         if (messageValue.length === 0) { show("Please write a message.", statusStateError); return null; }
-        return {contact: contactValue, message: messageValue};
+        if (widgetExtraFieldConfig && widgetExtraFieldConfig.required && extraFieldValue.length === 0) { show("Please answer: " + widgetCopyValues.extra_field_label, statusStateError); return null; }
+        return {contact: contactValue, message: messageValue, extraField: extraFieldValue};
       }
 
       send.addEventListener("click", function(){
@@ -760,7 +1094,9 @@
         var payload = JSON.stringify({
           site_id: widgetSiteId,
           contact: valid.contact,
-          message: valid.message
+          message: valid.message,
+          extra_field: valid.extraField,
+          page_url: resolveWidgetPageURL()
         });
 
         var endpoint = widgetApiOrigin
@@ -789,10 +1125,26 @@
         fetch(targetEndpoint, fetchOptions).then(function(resp){
           if (!resp.ok) { throw new Error("HTTP " + resp.status); }
           return resp.json();
-        }).then(function(){
-          show("Thanks! Sent.", statusStateSuccess);
+        }).then(function(body){
+          var feedbackID = body && typeof body.feedback_id === "string" ? body.feedback_id : "";
+          if (!selectedAttachmentFile || !feedbackID || widgetTestEndpointOverride) {
+            return true;
+          }
+          show("Uploading image...", statusStatePending);
+          return uploadAttachment(endpoint + "/attachments", feedbackID).catch(function(uploadError){
+            console.error(uploadError);
+            return false;
+          });
+        }).then(function(attachmentUploaded){
+          if (attachmentUploaded) {
+            show(widgetCopyValues.thank_you_text, statusStateSuccess);
+          } else {
+            show("Feedback sent, but the image could not be uploaded.", statusStateError);
+          }
           contact.value = "";
           message.value = "";
+          resetExtraField();
+          resetAttachment();
           send.disabled = false;
           schedulePanelAutoHide();
         }).catch(function(err){
@@ -808,7 +1160,7 @@
   }
 
   function selectThemePalette(bodyElement) {
-    var detectedTheme = detectPageTheme(bodyElement);
+    var detectedTheme = widgetThemeOverrideValue || detectPageTheme(bodyElement);
     var palette = widgetThemePalettes[detectedTheme];
     if (!palette) {
       return widgetThemePalettes[themeNameLight];